@@ -33,6 +33,8 @@ const (
 var (
 	ErrNonceTooHigh = errors.New("nonce too high")
 	ErrNonceTooLow  = errors.New("nonce too low")
+
+	ErrOrderNotFullyFilled = errors.New("fill-or-kill order not fully filled")
 )
 
 type Config struct {
@@ -179,34 +181,11 @@ func (XDCx *XDCX) ProcessOrderPending(header *types.Header, coinbase common.Addr
 		numberTx++
 		log.Debug("ProcessOrderPending start", "len", len(pending))
 		log.Debug("Get pending orders to process", "address", tx.UserAddress(), "nonce", tx.Nonce())
-		V, R, S := tx.Signature()
-
-		bigstr := V.String()
-		n, e := strconv.ParseInt(bigstr, 10, 8)
-		if e != nil {
+		order, err := NewOrderItem(tx)
+		if err != nil {
 			continue
 		}
 
-		order := &tradingstate.OrderItem{
-			Nonce:           big.NewInt(int64(tx.Nonce())),
-			Quantity:        tx.Quantity(),
-			Price:           tx.Price(),
-			ExchangeAddress: tx.ExchangeAddress(),
-			UserAddress:     tx.UserAddress(),
-			BaseToken:       tx.BaseToken(),
-			QuoteToken:      tx.QuoteToken(),
-			Status:          tx.Status(),
-			Side:            tx.Side(),
-			Type:            tx.Type(),
-			Hash:            tx.OrderHash(),
-			OrderID:         tx.OrderID(),
-			Signature: &tradingstate.Signature{
-				V: byte(n),
-				R: common.BigToHash(R),
-				S: common.BigToHash(S),
-			},
-		}
-
 		log.Info("Process order pending", "orderPending", order, "BaseToken", order.BaseToken.Hex(), "QuoteToken", order.QuoteToken)
 		originalOrder := &tradingstate.OrderItem{}
 		*originalOrder = *order
@@ -263,6 +242,43 @@ func (XDCx *XDCX) ProcessOrderPending(header *types.Header, coinbase common.Addr
 	return txMatches, matchingResults
 }
 
+// NewOrderItem converts an order transaction into the order matched by
+// ProcessOrderPending.
+func NewOrderItem(tx *types.OrderTransaction) (*tradingstate.OrderItem, error) {
+	V, R, S := tx.Signature()
+
+	bigstr := V.String()
+	n, err := strconv.ParseInt(bigstr, 10, 8)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tradingstate.OrderItem{
+		Nonce:            big.NewInt(int64(tx.Nonce())),
+		Quantity:         tx.Quantity(),
+		Price:            tx.Price(),
+		ExchangeAddress:  tx.ExchangeAddress(),
+		UserAddress:      tx.UserAddress(),
+		BaseToken:        tx.BaseToken(),
+		QuoteToken:       tx.QuoteToken(),
+		Status:           tx.Status(),
+		Side:             tx.Side(),
+		Type:             tx.Type(),
+		Hash:             tx.OrderHash(),
+		OrderID:          tx.OrderID(),
+		TimeInForce:      tx.TimeInForce(),
+		PostOnly:         tx.PostOnly(),
+		ExpireBlock:      tx.ExpireBlock(),
+		TriggerPrice:     tx.TriggerPrice(),
+		TriggerCondition: tx.TriggerCondition(),
+		Signature: &tradingstate.Signature{
+			V: byte(n),
+			R: common.BigToHash(R),
+			S: common.BigToHash(S),
+		},
+	}, nil
+}
+
 // return average price of the given pair in the last epoch
 func (XDCx *XDCX) GetAveragePriceLastEpoch(chain consensus.ChainContext, statedb *state.StateDB, tradingStateDb *tradingstate.TradingStateDB, baseToken common.Address, quoteToken common.Address) (*big.Int, error) {
 	price := tradingStateDb.GetMediumPriceBeforeEpoch(tradingstate.GetTradingOrderBookHash(baseToken, quoteToken))
@@ -352,6 +368,10 @@ func (XDCx *XDCX) SyncDataToSDKNode(takerOrderInTx *tradingstate.OrderItem, txHa
 
 	if takerOrderInTx.Status != tradingstate.OrderStatusCancelled {
 		updatedTakerOrder.Status = tradingstate.OrderStatusOpen
+		if originTakerOrder == nil && takerOrderInTx.IsTriggerOrder() {
+			// the order waits in the trigger book until its trigger price is crossed
+			updatedTakerOrder.Status = tradingstate.OrderStatusPendingTrigger
+		}
 	} else {
 		updatedTakerOrder.Status = tradingstate.OrderStatusCancelled
 		updatedTakerOrder.ExtraData = takerOrderInTx.ExtraData
@@ -438,10 +458,10 @@ func (XDCx *XDCX) SyncDataToSDKNode(takerOrderInTx *tradingstate.OrderItem, txHa
 		}
 	}
 
-	// for Market orders
+	// for Market orders and immediate-or-cancel, fill-or-kill Limit orders
 	// filledAmount > 0 : FILLED
 	// otherwise: REJECTED
-	if updatedTakerOrder.Type == tradingstate.Market {
+	if updatedTakerOrder.Status != tradingstate.OrderStatusPendingTrigger && (updatedTakerOrder.Type == tradingstate.Market || updatedTakerOrder.IsImmediate()) {
 		if updatedTakerOrder.FilledAmount.Sign() > 0 {
 			updatedTakerOrder.Status = tradingstate.OrderStatusFilled
 		} else {
@@ -494,9 +514,14 @@ func (XDCx *XDCX) SyncDataToSDKNode(takerOrderInTx *tradingstate.OrderItem, txHa
 
 	if len(rejectedOrders) > 0 {
 		var rejectedHashes []string
+		// good-til-block orders rejected because they expired get status EXPIRED
+		expiredHashes := make(map[common.Hash]bool)
 		// updateRejectedOrders
 		for _, rejectedOrder := range rejectedOrders {
 			rejectedHashes = append(rejectedHashes, rejectedOrder.Hash.Hex())
+			if rejectedOrder.Status == tradingstate.OrderStatusExpired {
+				expiredHashes[rejectedOrder.Hash] = true
+			}
 			if updatedTakerOrder.Hash == rejectedOrder.Hash && !txMatchTime.Before(updatedTakerOrder.UpdatedAt) {
 				// cache order history for handling reorg
 				orderHistoryRecord := tradingstate.OrderHistoryItem{
@@ -508,7 +533,9 @@ func (XDCx *XDCX) SyncDataToSDKNode(takerOrderInTx *tradingstate.OrderItem, txHa
 				XDCx.UpdateOrderCache(updatedTakerOrder.BaseToken, updatedTakerOrder.QuoteToken, updatedTakerOrder.Hash, txHash, orderHistoryRecord)
 				// if whole order is rejected, status = REJECTED
 				// otherwise, status = FILLED
-				if updatedTakerOrder.FilledAmount.Sign() > 0 {
+				if expiredHashes[updatedTakerOrder.Hash] {
+					updatedTakerOrder.Status = tradingstate.OrderStatusExpired
+				} else if updatedTakerOrder.FilledAmount.Sign() > 0 {
 					updatedTakerOrder.Status = tradingstate.OrderStatusFilled
 				} else {
 					updatedTakerOrder.Status = tradingstate.OrderStatusRejected
@@ -542,7 +569,9 @@ func (XDCx *XDCX) SyncDataToSDKNode(takerOrderInTx *tradingstate.OrderItem, txHa
 				}
 				// if whole order is rejected, status = REJECTED
 				// otherwise, status = FILLED
-				if order.FilledAmount.Sign() > 0 {
+				if expiredHashes[order.Hash] {
+					order.Status = tradingstate.OrderStatusExpired
+				} else if order.FilledAmount.Sign() > 0 {
 					order.Status = tradingstate.OrderStatusFilled
 				} else {
 					order.Status = tradingstate.OrderStatusRejected
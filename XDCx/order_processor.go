@@ -1,8 +1,10 @@
 package XDCx
 
 import (
+	"bytes"
 	"encoding/json"
 	"math/big"
+	"sort"
 	"strconv"
 	"time"
 
@@ -81,11 +83,61 @@ func (XDCx *XDCX) ApplyOrder(header *types.Header, coinbase common.Address, chai
 		rejects = append(rejects, order)
 		return trades, rejects, nil
 	}
+	if order.HasMatchingOptions() {
+		if !chain.Config().IsTIPXDCXOrderTypes(header.Number) {
+			log.Debug("Reject order with matching options before hardfork", "timeInForce", order.TimeInForce, "postOnly", order.PostOnly, "expireBlock", order.ExpireBlock)
+			rejects = append(rejects, order)
+			return trades, rejects, nil
+		}
+		if order.IsExpired(header.Number.Uint64()) {
+			log.Debug("Reject expired order", "expireBlock", order.ExpireBlock, "number", header.Number)
+			order.Status = tradingstate.OrderStatusExpired
+			rejects = append(rejects, order)
+			return trades, rejects, nil
+		}
+	}
+	if order.TriggerPrice != nil || order.TriggerCondition != "" {
+		if !chain.Config().IsTIPXDCXTriggerOrders(header.Number) {
+			log.Debug("Reject trigger order before hardfork", "triggerPrice", order.TriggerPrice, "triggerCondition", order.TriggerCondition)
+			rejects = append(rejects, order)
+			return trades, rejects, nil
+		}
+		// stop-loss and take-profit orders wait in the trigger book until the
+		// epoch price crosses their trigger price, see ProcessTriggerOrders
+		orderId := tradingStateDB.GetNonce(orderBook)
+		order.OrderID = orderId + 1
+		tradingStateDB.SetNonce(orderBook, orderId+1)
+		orderIdHash := common.BigToHash(new(big.Int).SetUint64(order.OrderID))
+		tradingStateDB.InsertTriggerOrder(orderBook, orderIdHash, *order)
+		if order.ExpireBlock != 0 {
+			tradingStateDB.InsertExpiringOrder(orderBook, orderIdHash, order.ExpireBlock)
+		}
+		log.Debug("Trigger order is added to trigger book", "side", order.Side, "triggerPrice", order.TriggerPrice, "triggerCondition", order.TriggerCondition)
+		return trades, rejects, nil
+	}
+	trades, rejects, err = XDCx.matchOrder(header, coinbase, chain, statedb, tradingStateDB, orderBook, order)
+	return trades, rejects, nil
+}
+
+// matchOrder matches a new or triggered order against the order book. A non-nil
+// error means the state changes made while matching must be reverted.
+func (XDCx *XDCX) matchOrder(header *types.Header, coinbase common.Address, chain consensus.ChainContext, statedb *state.StateDB, tradingStateDB *tradingstate.TradingStateDB, orderBook common.Hash, order *tradingstate.OrderItem) ([]map[string]string, []*tradingstate.OrderItem, error) {
+	var (
+		rejects []*tradingstate.OrderItem
+		trades  []map[string]string
+		err     error
+	)
+	if order.PostOnly && wouldTake(tradingStateDB, orderBook, order) {
+		log.Debug("Reject post-only order which would take liquidity", "side", order.Side, "price", order.Price)
+		rejects = append(rejects, order)
+		return trades, rejects, nil
+	}
+	quantity := tradingstate.CloneBigInt(order.Quantity)
 	orderType := order.Type
 	// if we do not use auto-increment orderid, we must set price slot to avoid conflict
 	if orderType == tradingstate.Market {
 		log.Debug("Process maket order", "side", order.Side, "quantity", order.Quantity, "price", order.Price)
-		trades, rejects, err = XDCx.processMarketOrder(coinbase, chain, statedb, tradingStateDB, orderBook, order)
+		trades, rejects, err = XDCx.processMarketOrder(header, coinbase, chain, statedb, tradingStateDB, orderBook, order)
 		if err != nil {
 			log.Debug("Reject market order", "err", err, "order", tradingstate.ToJSON(order))
 			trades = []map[string]string{}
@@ -93,19 +145,57 @@ func (XDCx *XDCX) ApplyOrder(header *types.Header, coinbase common.Address, chai
 		}
 	} else {
 		log.Debug("Process limit order", "side", order.Side, "quantity", order.Quantity, "price", order.Price)
-		trades, rejects, err = XDCx.processLimitOrder(coinbase, chain, statedb, tradingStateDB, orderBook, order)
+		trades, rejects, err = XDCx.processLimitOrder(header, coinbase, chain, statedb, tradingStateDB, orderBook, order)
 		if err != nil {
 			log.Debug("Reject limit order", "err", err, "order", tradingstate.ToJSON(order))
 			trades = []map[string]string{}
 			rejects = append(rejects, order)
 		}
 	}
+	if err == nil && order.TimeInForce == tradingstate.TimeInForceFOK && !isFullyFilled(quantity, trades) {
+		// Fill or kill orders are all or nothing, undo the partial matching
+		log.Debug("Reject fill-or-kill order not fully filled", "quantity", quantity, "trades", len(trades))
+		err = ErrOrderNotFullyFilled
+		order.Quantity = quantity
+		trades = []map[string]string{}
+		rejects = []*tradingstate.OrderItem{order}
+	}
+	return trades, rejects, err
+}
 
-	return trades, rejects, nil
+// wouldTake reports whether a limit order would be matched against the opposite
+// side of the book right away. The best prices are the ones of live makers, as
+// expired orders are swept out of the book before any order of the block is
+// matched, see ProcessExpiredOrders.
+func wouldTake(tradingStateDB *tradingstate.TradingStateDB, orderBook common.Hash, order *tradingstate.OrderItem) bool {
+	if order.Side == tradingstate.Bid {
+		bestAsk, _ := tradingStateDB.GetBestAskPrice(orderBook)
+		return bestAsk.Sign() > 0 && order.Price.Cmp(bestAsk) >= 0
+	}
+	bestBid, _ := tradingStateDB.GetBestBidPrice(orderBook)
+	return bestBid.Sign() > 0 && order.Price.Cmp(bestBid) <= 0
+}
+
+func containsOrder(orders []*tradingstate.OrderItem, order *tradingstate.OrderItem) bool {
+	for _, o := range orders {
+		if o == order {
+			return true
+		}
+	}
+	return false
+}
+
+// isFullyFilled reports whether the given trades cover the whole quantity.
+func isFullyFilled(quantity *big.Int, trades []map[string]string) bool {
+	filled := new(big.Int)
+	for _, trade := range trades {
+		filled.Add(filled, tradingstate.ToBigInt(trade[tradingstate.TradeQuantity]))
+	}
+	return filled.Cmp(quantity) >= 0
 }
 
 // processMarketOrder : process the market order
-func (XDCx *XDCX) processMarketOrder(coinbase common.Address, chain consensus.ChainContext, statedb *state.StateDB, tradingStateDB *tradingstate.TradingStateDB, orderBook common.Hash, order *tradingstate.OrderItem) ([]map[string]string, []*tradingstate.OrderItem, error) {
+func (XDCx *XDCX) processMarketOrder(header *types.Header, coinbase common.Address, chain consensus.ChainContext, statedb *state.StateDB, tradingStateDB *tradingstate.TradingStateDB, orderBook common.Hash, order *tradingstate.OrderItem) ([]map[string]string, []*tradingstate.OrderItem, error) {
 	var (
 		trades     []map[string]string
 		newTrades  []map[string]string
@@ -121,7 +211,7 @@ func (XDCx *XDCX) processMarketOrder(coinbase common.Address, chain consensus.Ch
 		bestPrice, volume := tradingStateDB.GetBestAskPrice(orderBook)
 		log.Debug("processMarketOrder ", "side", side, "bestPrice", bestPrice, "quantityToTrade", quantityToTrade, "volume", volume)
 		for quantityToTrade.Cmp(zero) > 0 && bestPrice.Cmp(zero) > 0 {
-			quantityToTrade, newTrades, newRejects, err = XDCx.processOrderList(coinbase, chain, statedb, tradingStateDB, tradingstate.Ask, orderBook, bestPrice, quantityToTrade, order)
+			quantityToTrade, newTrades, newRejects, err = XDCx.processOrderList(header, coinbase, chain, statedb, tradingStateDB, tradingstate.Ask, orderBook, bestPrice, quantityToTrade, order)
 			if err != nil {
 				return nil, nil, err
 			}
@@ -134,7 +224,7 @@ func (XDCx *XDCX) processMarketOrder(coinbase common.Address, chain consensus.Ch
 		bestPrice, volume := tradingStateDB.GetBestBidPrice(orderBook)
 		log.Debug("processMarketOrder ", "side", side, "bestPrice", bestPrice, "quantityToTrade", quantityToTrade, "volume", volume)
 		for quantityToTrade.Cmp(zero) > 0 && bestPrice.Cmp(zero) > 0 {
-			quantityToTrade, newTrades, newRejects, err = XDCx.processOrderList(coinbase, chain, statedb, tradingStateDB, tradingstate.Bid, orderBook, bestPrice, quantityToTrade, order)
+			quantityToTrade, newTrades, newRejects, err = XDCx.processOrderList(header, coinbase, chain, statedb, tradingStateDB, tradingstate.Bid, orderBook, bestPrice, quantityToTrade, order)
 			if err != nil {
 				return nil, nil, err
 			}
@@ -149,7 +239,7 @@ func (XDCx *XDCX) processMarketOrder(coinbase common.Address, chain consensus.Ch
 
 // processLimitOrder : process the limit order, can change the quote
 // If not care for performance, we should make a copy of quote to prevent further reference problem
-func (XDCx *XDCX) processLimitOrder(coinbase common.Address, chain consensus.ChainContext, statedb *state.StateDB, tradingStateDB *tradingstate.TradingStateDB, orderBook common.Hash, order *tradingstate.OrderItem) ([]map[string]string, []*tradingstate.OrderItem, error) {
+func (XDCx *XDCX) processLimitOrder(header *types.Header, coinbase common.Address, chain consensus.ChainContext, statedb *state.StateDB, tradingStateDB *tradingstate.TradingStateDB, orderBook common.Hash, order *tradingstate.OrderItem) ([]map[string]string, []*tradingstate.OrderItem, error) {
 	var (
 		trades     []map[string]string
 		newTrades  []map[string]string
@@ -169,7 +259,7 @@ func (XDCx *XDCX) processLimitOrder(coinbase common.Address, chain consensus.Cha
 		log.Debug("processLimitOrder ", "side", side, "minPrice", minPrice, "orderPrice", price, "volume", volume)
 		for quantityToTrade.Cmp(zero) > 0 && price.Cmp(minPrice) >= 0 && minPrice.Cmp(zero) > 0 {
 			log.Debug("Min price in asks tree", "price", minPrice.String())
-			quantityToTrade, newTrades, newRejects, err = XDCx.processOrderList(coinbase, chain, statedb, tradingStateDB, tradingstate.Ask, orderBook, minPrice, quantityToTrade, order)
+			quantityToTrade, newTrades, newRejects, err = XDCx.processOrderList(header, coinbase, chain, statedb, tradingStateDB, tradingstate.Ask, orderBook, minPrice, quantityToTrade, order)
 			if err != nil {
 				return nil, nil, err
 			}
@@ -184,7 +274,7 @@ func (XDCx *XDCX) processLimitOrder(coinbase common.Address, chain consensus.Cha
 		log.Debug("processLimitOrder ", "side", side, "maxPrice", maxPrice, "orderPrice", price, "volume", volume)
 		for quantityToTrade.Cmp(zero) > 0 && price.Cmp(maxPrice) <= 0 && maxPrice.Cmp(zero) > 0 {
 			log.Debug("Max price in bids tree", "price", maxPrice.String())
-			quantityToTrade, newTrades, newRejects, err = XDCx.processOrderList(coinbase, chain, statedb, tradingStateDB, tradingstate.Bid, orderBook, maxPrice, quantityToTrade, order)
+			quantityToTrade, newTrades, newRejects, err = XDCx.processOrderList(header, coinbase, chain, statedb, tradingStateDB, tradingstate.Bid, orderBook, maxPrice, quantityToTrade, order)
 			if err != nil {
 				return nil, nil, err
 			}
@@ -195,20 +285,34 @@ func (XDCx *XDCX) processLimitOrder(coinbase common.Address, chain consensus.Cha
 			log.Debug("processLimitOrder ", "side", side, "maxPrice", maxPrice, "orderPrice", price, "volume", volume)
 		}
 	}
+	if quantityToTrade.Cmp(zero) > 0 && order.IsImmediate() {
+		// Immediate orders never rest on the book, the remainder is dropped
+		log.Debug("Unmatched part of immediate order is cancelled", "timeInForce", order.TimeInForce, "quantity", quantityToTrade)
+		if len(trades) == 0 && !containsOrder(rejects, order) {
+			rejects = append(rejects, order)
+		}
+		return trades, rejects, nil
+	}
 	if quantityToTrade.Cmp(zero) > 0 {
-		orderId := tradingStateDB.GetNonce(orderBook)
-		order.OrderID = orderId + 1
+		// triggered orders keep the id they got when added to the trigger book
+		if !order.IsTriggerOrder() {
+			orderId := tradingStateDB.GetNonce(orderBook)
+			order.OrderID = orderId + 1
+			tradingStateDB.SetNonce(orderBook, orderId+1)
+		}
 		order.Quantity = quantityToTrade
-		tradingStateDB.SetNonce(orderBook, orderId+1)
 		orderIdHash := common.BigToHash(new(big.Int).SetUint64(order.OrderID))
 		tradingStateDB.InsertOrderItem(orderBook, orderIdHash, *order)
+		if order.ExpireBlock != 0 {
+			tradingStateDB.InsertExpiringOrder(orderBook, orderIdHash, order.ExpireBlock)
+		}
 		log.Debug("After matching, order (unmatched part) is now added to tree", "side", order.Side, "order", order)
 	}
 	return trades, rejects, nil
 }
 
 // processOrderList : process the order list
-func (XDCx *XDCX) processOrderList(coinbase common.Address, chain consensus.ChainContext, statedb *state.StateDB, tradingStateDB *tradingstate.TradingStateDB, side string, orderBook common.Hash, price *big.Int, quantityStillToTrade *big.Int, order *tradingstate.OrderItem) (*big.Int, []map[string]string, []*tradingstate.OrderItem, error) {
+func (XDCx *XDCX) processOrderList(header *types.Header, coinbase common.Address, chain consensus.ChainContext, statedb *state.StateDB, tradingStateDB *tradingstate.TradingStateDB, side string, orderBook common.Hash, price *big.Int, quantityStillToTrade *big.Int, order *tradingstate.OrderItem) (*big.Int, []map[string]string, []*tradingstate.OrderItem, error) {
 	quantityToTrade := tradingstate.CloneBigInt(quantityStillToTrade)
 	log.Debug("Process matching between order and orderlist", "quantityToTrade", quantityToTrade)
 	var (
@@ -667,7 +771,12 @@ func (XDCx *XDCX) ProcessCancelOrder(header *types.Header, tradingStateDB *tradi
 		return nil, true
 	}
 
-	err = tradingStateDB.CancelOrder(orderBook, order)
+	if originOrder.IsTriggerOrder() && tradingStateDB.IsWaitingTriggerOrder(orderBook, &originOrder) {
+		// the order is still in the trigger book, not in the bid/ask trees
+		err = tradingStateDB.RemoveTriggerOrder(orderBook, order)
+	} else {
+		err = tradingStateDB.CancelOrder(orderBook, order)
+	}
 	if err != nil {
 		log.Debug("Error when cancel order", "order", order)
 		return err, false
@@ -791,6 +900,128 @@ func (XDCx *XDCX) UpdateMediumPriceBeforeEpoch(epochNumber uint64, tradingStateD
 	return nil
 }
 
+// ProcessTriggerOrders converts the stop-loss and take-profit orders whose trigger
+// price has been crossed by the epoch price into market or limit orders and
+// matches them. It runs at epoch switch blocks, after UpdateMediumPriceBeforeEpoch.
+// The triggered orders and their trades are returned for the SDK node, which
+// records them once the block is written.
+func (XDCx *XDCX) ProcessTriggerOrders(header *types.Header, coinbase common.Address, chain consensus.ChainContext, statedb *state.StateDB, tradingStateDB *tradingstate.TradingStateDB) ([]tradingstate.OrderResult, error) {
+	if !chain.Config().IsTIPXDCXTriggerOrders(header.Number) {
+		return nil, nil
+	}
+	mapPairs, err := tradingstate.GetAllTradingPairs(statedb)
+	if err != nil {
+		return nil, err
+	}
+	// the order books must be processed in the same order on every node
+	orderBooks := make([]common.Hash, 0, len(mapPairs))
+	for orderBook := range mapPairs {
+		orderBooks = append(orderBooks, orderBook)
+	}
+	sort.Slice(orderBooks, func(i, j int) bool {
+		return bytes.Compare(orderBooks[i][:], orderBooks[j][:]) < 0
+	})
+	var results []tradingstate.OrderResult
+	for _, orderBook := range orderBooks {
+		epochPrice := tradingStateDB.GetMediumPriceBeforeEpoch(orderBook)
+		if epochPrice.Sign() <= 0 {
+			continue
+		}
+		for {
+			order, ok := tradingStateDB.GetTriggeredOrder(orderBook, epochPrice)
+			if !ok {
+				break
+			}
+			if err := tradingStateDB.RemoveTriggerOrder(orderBook, &order); err != nil {
+				return nil, err
+			}
+			order.Quantity = tradingstate.CloneBigInt(order.Quantity)
+			log.Debug("Trigger order is triggered", "orderBook", orderBook.Hex(), "orderId", order.OrderID, "epochPrice", epochPrice, "triggerPrice", order.TriggerPrice, "triggerCondition", order.TriggerCondition)
+			originalOrder := order
+			originalOrder.Quantity = tradingstate.CloneBigInt(order.Quantity)
+
+			trades, rejects := XDCx.applyTriggeredOrder(header, coinbase, chain, statedb, tradingStateDB, orderBook, &order)
+			results = append(results, tradingstate.OrderResult{Order: &originalOrder, Trades: trades, Rejects: rejects})
+		}
+	}
+	return results, nil
+}
+
+// ProcessExpiredOrders cancels the good-til-block orders, resting in the book or
+// waiting for their trigger price, which expired before the given block. It runs
+// for every block before its orders are matched, so that expired makers neither
+// match nor show as best bid or ask. The cancelled orders are returned for the
+// SDK node, which records them once the block is written.
+func (XDCx *XDCX) ProcessExpiredOrders(header *types.Header, chain consensus.ChainContext, statedb *state.StateDB, tradingStateDB *tradingstate.TradingStateDB) ([]tradingstate.OrderResult, error) {
+	if !chain.Config().IsTIPXDCXOrderTypes(header.Number) {
+		return nil, nil
+	}
+	var (
+		number  = header.Number.Uint64()
+		results []tradingstate.OrderResult
+	)
+	// only the order books with orders expiring before the block are visited,
+	// in the same order on every node
+	for {
+		orderBook, bookExpireBlock, ok := tradingStateDB.GetExpiredOrderBook(number)
+		if !ok {
+			break
+		}
+		if err := tradingStateDB.RemoveExpiredOrderBook(orderBook, bookExpireBlock); err != nil {
+			return nil, err
+		}
+		for {
+			orderId, expireBlock, ok := tradingStateDB.GetExpiredOrder(orderBook, number)
+			if !ok {
+				break
+			}
+			if err := tradingStateDB.RemoveExpiringOrder(orderBook, orderId, expireBlock); err != nil {
+				return nil, err
+			}
+			// the order may have been filled or cancelled in the meantime
+			order := tradingStateDB.GetOrder(orderBook, orderId)
+			if order.Quantity == nil || order.Quantity.Sign() == 0 {
+				continue
+			}
+			var err error
+			if tradingStateDB.IsWaitingTriggerOrder(orderBook, &order) {
+				err = tradingStateDB.RemoveTriggerOrder(orderBook, &order)
+			} else {
+				err = tradingStateDB.CancelOrder(orderBook, &order)
+			}
+			if err != nil {
+				return nil, err
+			}
+			log.Debug("Cancel expired order", "orderBook", orderBook.Hex(), "orderId", order.OrderID, "expireBlock", expireBlock, "number", number)
+			order.Status = tradingstate.OrderStatusExpired
+			results = append(results, tradingstate.OrderResult{Order: &order, Rejects: []*tradingstate.OrderItem{&order}})
+		}
+	}
+	return results, nil
+}
+
+// applyTriggeredOrder matches an order which has just left the trigger book.
+// Orders which can't be matched any more are rejected.
+func (XDCx *XDCX) applyTriggeredOrder(header *types.Header, coinbase common.Address, chain consensus.ChainContext, statedb *state.StateDB, tradingStateDB *tradingstate.TradingStateDB, orderBook common.Hash, order *tradingstate.OrderItem) ([]map[string]string, []*tradingstate.OrderItem) {
+	if order.IsExpired(header.Number.Uint64()) {
+		log.Debug("Reject expired trigger order", "expireBlock", order.ExpireBlock, "number", header.Number)
+		order.Status = tradingstate.OrderStatusExpired
+		return nil, []*tradingstate.OrderItem{order}
+	}
+	if !tradingstate.IsValidRelayer(statedb, order.ExchangeAddress) {
+		log.Debug("Reject trigger order of invalid relayer", "relayer", order.ExchangeAddress.Hex())
+		return nil, []*tradingstate.OrderItem{order}
+	}
+	XDCxSnap := tradingStateDB.Snapshot()
+	dbSnap := statedb.Snapshot()
+	trades, rejects, err := XDCx.matchOrder(header, coinbase, chain, statedb, tradingStateDB, orderBook, order)
+	if err != nil {
+		tradingStateDB.RevertToSnapshot(XDCxSnap)
+		statedb.RevertToSnapshot(dbSnap)
+	}
+	return trades, rejects
+}
+
 // put average price of epoch to mongodb for tracking liquidation trades
 // epochPriceResult: a map of epoch average price, key is orderbook hash , value is epoch average price
 // orderbook hash genereted from baseToken, quoteToken at XDPoSChain/XDCx/tradingstate/common.go:214
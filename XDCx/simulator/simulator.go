@@ -0,0 +1,381 @@
+// Package simulator replays orders through the XDCx matching engine outside of
+// a running node, on an in-memory copy of the state. The orders are matched and
+// settled by the same code as block processing, so the trades, balances and
+// fees it reports are the ones the consensus would compute.
+package simulator
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"sort"
+
+	"github.com/XinFinOrg/XDPoSChain/XDCx"
+	"github.com/XinFinOrg/XDPoSChain/XDCx/tradingstate"
+	"github.com/XinFinOrg/XDPoSChain/common"
+	"github.com/XinFinOrg/XDPoSChain/consensus"
+	"github.com/XinFinOrg/XDPoSChain/consensus/ethash"
+	"github.com/XinFinOrg/XDPoSChain/core/rawdb"
+	"github.com/XinFinOrg/XDPoSChain/core/state"
+	"github.com/XinFinOrg/XDPoSChain/core/types"
+	"github.com/XinFinOrg/XDPoSChain/crypto"
+	"github.com/XinFinOrg/XDPoSChain/params"
+)
+
+// Relayer is a relayer registration written into the relayer contract storage.
+type Relayer struct {
+	Coinbase common.Address
+	Owner    common.Address // receives the trading fees
+	Deposit  *big.Int       // pays the matching fees, must stay above the locked fund
+	Fee      *big.Int       // trading fee rate in 1/10000 of the traded value
+	Pairs    []Pair
+}
+
+// Pair is a trading pair listed by a relayer.
+type Pair struct {
+	BaseToken  common.Address
+	QuoteToken common.Address
+}
+
+// Result is the outcome of a simulated order.
+type Result struct {
+	Order   *tradingstate.OrderItem
+	Trades  []map[string]string
+	Rejects []*tradingstate.OrderItem
+}
+
+// RelayerFees sums up the fees of a relayer over the simulated orders.
+type RelayerFees struct {
+	Trades      uint64                      // trades the relayer paid the matching fee for
+	MatchingFee *big.Int                    // XDC taken from the deposit by the masternodes
+	TradingFees map[common.Address]*big.Int // fees earned by the owner, by quote token
+}
+
+// Simulator matches orders on a private copy of a state and trading state.
+// It is not safe for concurrent use.
+type Simulator struct {
+	XDCx *XDCx.XDCX
+
+	chain        consensus.ChainContext
+	header       *types.Header
+	coinbase     common.Address
+	statedb      *state.StateDB
+	tradingState *tradingstate.TradingStateDB
+	fees         map[common.Address]*RelayerFees
+}
+
+// New creates a simulator on top of the state at parent, which is usually
+// loaded from a node database. The states are copied, so the simulated orders
+// never change them. Orders are matched in the block following parent, mined
+// by coinbase.
+func New(chain consensus.ChainContext, parent *types.Header, coinbase common.Address, statedb *state.StateDB, tradingState *tradingstate.TradingStateDB) (*Simulator, error) {
+	s := &Simulator{
+		XDCx:         XDCx.New(&XDCx.DefaultConfig),
+		chain:        chain,
+		header:       types.CopyHeader(parent),
+		coinbase:     coinbase,
+		statedb:      statedb.Copy(),
+		tradingState: tradingState.Copy(),
+		fees:         make(map[common.Address]*RelayerFees),
+	}
+	if err := s.NextBlock(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// NewEmpty creates a simulator on an empty state, matching orders from block
+// number on. Tokens, balances and relayers are then set up with the mocking
+// methods.
+func NewEmpty(config *params.ChainConfig, number uint64, coinbase common.Address) (*Simulator, error) {
+	db := rawdb.NewMemoryDatabase()
+	statedb, err := state.New(common.Hash{}, state.NewDatabase(db))
+	if err != nil {
+		return nil, err
+	}
+	tradingState, err := tradingstate.New(common.Hash{}, tradingstate.NewDatabase(db))
+	if err != nil {
+		return nil, err
+	}
+	chain := &chainContext{config: config, engine: ethash.NewFaker(), headers: make(map[uint64]*types.Header)}
+	s := &Simulator{
+		XDCx:         XDCx.New(&XDCx.DefaultConfig),
+		chain:        chain,
+		header:       &types.Header{Number: new(big.Int).SetUint64(number), Time: new(big.Int), Coinbase: coinbase, Difficulty: new(big.Int)},
+		coinbase:     coinbase,
+		statedb:      statedb,
+		tradingState: tradingState,
+		fees:         make(map[common.Address]*RelayerFees),
+	}
+	chain.headers[number] = s.header
+	return s, nil
+}
+
+// Header returns the header of the block the orders are matched in.
+func (s *Simulator) Header() *types.Header { return s.header }
+
+// State returns the simulated state.
+func (s *Simulator) State() *state.StateDB { return s.statedb }
+
+// TradingState returns the simulated trading state.
+func (s *Simulator) TradingState() *tradingstate.TradingStateDB { return s.tradingState }
+
+// NextBlock moves the simulation to the next block. Like block processing, the
+// orders which expired are cancelled first, and epoch switch blocks don't match
+// new orders but update the epoch prices and fire the trigger orders, after
+// which the simulation moves on to the block following them. A block is
+// treated as an epoch switch when its number is a multiple of the epoch
+// length, which doesn't hold for XDPoS v2 epochs shifted by skipped rounds.
+func (s *Simulator) NextBlock() error {
+	header := types.CopyHeader(s.header)
+	header.ParentHash = s.header.Hash()
+	header.Number = new(big.Int).Add(s.header.Number, common.Big1)
+	header.Coinbase = s.coinbase
+	if config := s.chain.Config(); config != nil && config.XDPoS != nil {
+		header.Time = new(big.Int).Add(s.header.Time, new(big.Int).SetUint64(config.XDPoS.Period))
+	}
+	s.header = header
+	if chain, ok := s.chain.(*chainContext); ok {
+		chain.headers[header.Number.Uint64()] = header
+	}
+	if _, err := s.XDCx.ProcessExpiredOrders(s.header, s.chain, s.statedb, s.tradingState); err != nil {
+		return err
+	}
+	if epoch, ok := s.epochSwitch(); ok {
+		if err := s.XDCx.UpdateMediumPriceBeforeEpoch(epoch, s.tradingState, s.statedb); err != nil {
+			return err
+		}
+		results, err := s.XDCx.ProcessTriggerOrders(s.header, s.coinbase, s.chain, s.statedb, s.tradingState)
+		if err != nil {
+			return err
+		}
+		for _, result := range results {
+			s.countFees(result.Order, result.Trades)
+		}
+		return s.NextBlock()
+	}
+	return nil
+}
+
+// epochSwitch returns the epoch started by the current block, if any.
+func (s *Simulator) epochSwitch() (uint64, bool) {
+	config := s.chain.Config()
+	if config == nil || config.XDPoS == nil || config.XDPoS.Epoch == 0 {
+		return 0, false
+	}
+	number := s.header.Number.Uint64()
+	if number <= config.XDPoS.Epoch || number%config.XDPoS.Epoch != 0 {
+		return 0, false
+	}
+	return number / config.XDPoS.Epoch, true
+}
+
+// SignOrder completes a synthetic order and signs it with the key of its user,
+// which the matching engine requires. The user, the status of new orders, the
+// next nonce of the user and the hash of new orders are filled in when empty.
+func (s *Simulator) SignOrder(order *tradingstate.OrderItem, key *ecdsa.PrivateKey) error {
+	order.UserAddress = crypto.PubkeyToAddress(key.PublicKey)
+	if order.Status == "" {
+		order.Status = tradingstate.OrderNew
+	}
+	if order.Nonce == nil {
+		order.Nonce = new(big.Int).SetUint64(s.tradingState.GetNonce(order.UserAddress.Hash()))
+	}
+	tx := types.NewOrderTransaction(order.Nonce.Uint64(), order.Quantity, order.Price, order.ExchangeAddress, order.UserAddress,
+		order.BaseToken, order.QuoteToken, order.Status, order.Side, order.Type, order.Hash, order.OrderID)
+	tx.SetMatchingOptions(order.TimeInForce, order.PostOnly, order.ExpireBlock)
+	tx.SetTrigger(order.TriggerPrice, order.TriggerCondition)
+	if order.Hash == (common.Hash{}) && !tx.IsCancelledOrder() {
+		order.Hash = types.OrderTxSigner{}.OrderCreateHash(tx)
+		tx.SetOrderHash(order.Hash)
+	}
+	tx, err := types.OrderSignTx(tx, types.OrderTxSigner{}, key)
+	if err != nil {
+		return err
+	}
+	signed, err := XDCx.NewOrderItem(tx)
+	if err != nil {
+		return err
+	}
+	order.Signature = signed.Signature
+	return nil
+}
+
+// Apply matches a single signed order in the current block.
+func (s *Simulator) Apply(order *tradingstate.OrderItem) (*Result, error) {
+	orderBook := tradingstate.GetTradingOrderBookHash(order.BaseToken, order.QuoteToken)
+	trades, rejects, err := s.XDCx.CommitOrder(s.header, s.coinbase, s.chain, s.statedb, s.tradingState, orderBook, order)
+	if err != nil {
+		return nil, err
+	}
+	s.countFees(order, trades)
+	return &Result{Order: order, Trades: trades, Rejects: rejects}, nil
+}
+
+// ApplyTransactions matches signed order transactions in the current block the
+// way a block producer does, by nonce within each user. Transactions which
+// can't be processed, like the ones with a wrong nonce, are left out of the
+// results.
+func (s *Simulator) ApplyTransactions(txs types.OrderTransactions) ([]*Result, error) {
+	pending := make(map[common.Address]types.OrderTransactions)
+	for _, tx := range txs {
+		pending[tx.UserAddress()] = append(pending[tx.UserAddress()], tx)
+	}
+	for _, userTxs := range pending {
+		sort.Sort(types.OrderTxByNonce(userTxs))
+	}
+	txMatches, matchingResults := s.XDCx.ProcessOrderPending(s.header, s.coinbase, s.chain, pending, s.statedb, s.tradingState)
+	results := make([]*Result, 0, len(txMatches))
+	for _, txMatch := range txMatches {
+		order, err := txMatch.DecodeOrder()
+		if err != nil {
+			return nil, err
+		}
+		matchingResult := matchingResults[tradingstate.GetMatchingResultCacheKey(order)]
+		s.countFees(order, matchingResult.Trades)
+		results = append(results, &Result{Order: order, Trades: matchingResult.Trades, Rejects: matchingResult.Rejects})
+	}
+	return results, nil
+}
+
+// countFees adds the fees settled by the trades of a taker order.
+func (s *Simulator) countFees(taker *tradingstate.OrderItem, trades []map[string]string) {
+	for _, trade := range trades {
+		quoteToken := common.HexToAddress(trade[tradingstate.TradeQuoteToken])
+		takerFee, _ := new(big.Int).SetString(trade[tradingstate.TakerFee], 10)
+		makerFee, _ := new(big.Int).SetString(trade[tradingstate.MakerFee], 10)
+		s.addFee(taker.ExchangeAddress, quoteToken, takerFee)
+		s.addFee(common.HexToAddress(trade[tradingstate.TradeMakerExchange]), quoteToken, makerFee)
+	}
+}
+
+func (s *Simulator) addFee(relayer, token common.Address, fee *big.Int) {
+	fees := s.fees[relayer]
+	if fees == nil {
+		fees = &RelayerFees{MatchingFee: new(big.Int), TradingFees: make(map[common.Address]*big.Int)}
+		s.fees[relayer] = fees
+	}
+	fees.Trades++
+	fees.MatchingFee.Add(fees.MatchingFee, common.RelayerFee)
+	if fee == nil {
+		return
+	}
+	if fees.TradingFees[token] == nil {
+		fees.TradingFees[token] = new(big.Int)
+	}
+	fees.TradingFees[token].Add(fees.TradingFees[token], fee)
+}
+
+// Fees returns the fees of the relayers which took part in the trades of the
+// simulated orders, the trigger orders fired at epoch switches included.
+func (s *Simulator) Fees() map[common.Address]*RelayerFees {
+	return s.fees
+}
+
+// Balance returns the balance of an account in a token, XDC included.
+func (s *Simulator) Balance(account, token common.Address) *big.Int {
+	return tradingstate.GetTokenBalance(account, token, s.statedb)
+}
+
+// SetBalance overrides the balance of an account in a token, XDC included.
+// TRC21 tokens missing from the state are created.
+func (s *Simulator) SetBalance(account, token common.Address, balance *big.Int) error {
+	if token != common.XDCNativeAddressBinary && !s.statedb.Exist(token) {
+		s.statedb.CreateAccount(token)
+	}
+	return tradingstate.SetTokenBalance(account, balance, token, s.statedb)
+}
+
+// SetTokenDecimals overrides the decimals of a token, 10^decimals, which is
+// otherwise read from the token contract.
+func (s *Simulator) SetTokenDecimals(token common.Address, decimals uint8) {
+	s.XDCx.SetTokenDecimal(token, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil))
+}
+
+// SetLastPrice overrides the last traded price of a pair, which converts the
+// quote token fees into XDC.
+func (s *Simulator) SetLastPrice(baseToken, quoteToken common.Address, price *big.Int) {
+	s.tradingState.SetLastPrice(tradingstate.GetTradingOrderBookHash(baseToken, quoteToken), price)
+}
+
+// SetEpochPrice overrides the average price of a pair in the last epoch.
+func (s *Simulator) SetEpochPrice(baseToken, quoteToken common.Address, price *big.Int) {
+	s.tradingState.SetMediumPriceBeforeEpoch(tradingstate.GetTradingOrderBookHash(baseToken, quoteToken), price)
+}
+
+// RegisterRelayer writes a relayer registration into the relayer contract
+// storage. A relayer which is already registered is updated in place.
+func (s *Simulator) RegisterRelayer(relayer Relayer) {
+	contract := common.HexToAddress(common.RelayerRegistrationSMC)
+	loc := tradingstate.GetLocMappingAtKey(relayer.Coinbase.Hash(), tradingstate.RelayerMappingSlot["RELAYER_LIST"])
+	field := func(name string) common.Hash {
+		return state.GetLocOfStructElement(loc, tradingstate.RelayerStructMappingSlot[name])
+	}
+	if tradingstate.GetRelayerOwner(relayer.Coinbase, s.statedb) == (common.Address{}) {
+		count := tradingstate.GetRelayerCount(s.statedb)
+		coinbases := state.GetLocMappingAtKey(common.BigToHash(new(big.Int).SetUint64(count)), tradingstate.RelayerMappingSlot["RELAYER_COINBASES"])
+		s.statedb.SetState(contract, common.BigToHash(coinbases), relayer.Coinbase.Hash())
+		s.statedb.SetState(contract, field("_index"), common.BigToHash(new(big.Int).SetUint64(count)))
+		s.statedb.SetState(contract, common.BigToHash(new(big.Int).SetUint64(tradingstate.RelayerMappingSlot["RelayerCount"])), common.BigToHash(new(big.Int).SetUint64(count+1)))
+	}
+	s.statedb.SetState(contract, field("_owner"), relayer.Owner.Hash())
+	s.SetRelayerFee(relayer.Coinbase, relayer.Fee)
+	s.SetRelayerDeposit(relayer.Coinbase, relayer.Deposit)
+
+	fromTokens, toTokens := field("_fromTokens"), field("_toTokens")
+	s.statedb.SetState(contract, fromTokens, common.BigToHash(big.NewInt(int64(len(relayer.Pairs)))))
+	s.statedb.SetState(contract, toTokens, common.BigToHash(big.NewInt(int64(len(relayer.Pairs)))))
+	for i, pair := range relayer.Pairs {
+		s.statedb.SetState(contract, state.GetLocDynamicArrAtElement(fromTokens, uint64(i), 1), pair.BaseToken.Hash())
+		s.statedb.SetState(contract, state.GetLocDynamicArrAtElement(toTokens, uint64(i), 1), pair.QuoteToken.Hash())
+	}
+}
+
+// SetRelayerFee overrides the trading fee rate of a relayer.
+func (s *Simulator) SetRelayerFee(coinbase common.Address, fee *big.Int) {
+	if fee == nil {
+		fee = new(big.Int)
+	}
+	loc := tradingstate.GetLocMappingAtKey(coinbase.Hash(), tradingstate.RelayerMappingSlot["RELAYER_LIST"])
+	s.statedb.SetState(common.HexToAddress(common.RelayerRegistrationSMC), state.GetLocOfStructElement(loc, tradingstate.RelayerStructMappingSlot["_fee"]), common.BigToHash(fee))
+}
+
+// SetRelayerDeposit overrides the deposit of a relayer, keeping the balance
+// of the relayer contract in line with it.
+func (s *Simulator) SetRelayerDeposit(coinbase common.Address, deposit *big.Int) {
+	if deposit == nil {
+		deposit = new(big.Int)
+	}
+	contract := common.HexToAddress(common.RelayerRegistrationSMC)
+	s.statedb.SubBalance(contract, tradingstate.GetRelayerDeposit(coinbase, s.statedb))
+	s.statedb.AddBalance(contract, deposit)
+	loc := tradingstate.GetLocMappingAtKey(coinbase.Hash(), tradingstate.RelayerMappingSlot["RELAYER_LIST"])
+	s.statedb.SetState(contract, state.GetLocOfStructElement(loc, tradingstate.RelayerStructMappingSlot["_deposit"]), common.BigToHash(deposit))
+}
+
+// chainContext is the chain of an empty simulator, made of the simulated
+// headers only.
+type chainContext struct {
+	config  *params.ChainConfig
+	engine  consensus.Engine
+	headers map[uint64]*types.Header
+}
+
+func (c *chainContext) Engine() consensus.Engine    { return c.engine }
+func (c *chainContext) Config() *params.ChainConfig { return c.config }
+
+func (c *chainContext) GetHeader(hash common.Hash, number uint64) *types.Header {
+	if header := c.headers[number]; header != nil && header.Hash() == hash {
+		return header
+	}
+	return nil
+}
+
+func (c *chainContext) CurrentHeader() *types.Header {
+	var current *types.Header
+	for _, header := range c.headers {
+		if current == nil || header.Number.Cmp(current.Number) > 0 {
+			current = header
+		}
+	}
+	return current
+}
@@ -0,0 +1,354 @@
+package simulator
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/XinFinOrg/XDPoSChain/XDCx/tradingstate"
+	"github.com/XinFinOrg/XDPoSChain/common"
+	"github.com/XinFinOrg/XDPoSChain/core/types"
+	"github.com/XinFinOrg/XDPoSChain/crypto"
+	"github.com/XinFinOrg/XDPoSChain/params"
+)
+
+func xdc(amount int64) *big.Int {
+	return new(big.Int).Mul(big.NewInt(amount), common.BasePrice)
+}
+
+func TestSimulateMatching(t *testing.T) {
+	var (
+		token       = common.HexToAddress("0xd9bb01454c85247B2ef35BB5BE57384cC275a8cf")
+		relayer     = common.HexToAddress("0x0D3ab14BBaD3D99F4203bd7a11aCB94882050E7e")
+		owner       = common.HexToAddress("0x4d7eA2cE949216D6b120f3AA10164173615A2b6C")
+		makerKey, _ = crypto.GenerateKey()
+		takerKey, _ = crypto.GenerateKey()
+		maker       = crypto.PubkeyToAddress(makerKey.PublicKey)
+		taker       = crypto.PubkeyToAddress(takerKey.PublicKey)
+		coinbase    = common.HexToAddress("0x0000000000000000000000000000000000000099")
+	)
+	sim, err := NewEmpty(params.AllXDPoSProtocolChanges, 1000, coinbase)
+	if err != nil {
+		t.Fatalf("failed to create simulator: %v", err)
+	}
+	sim.SetTokenDecimals(token, 18)
+	deposit := xdc(25000)
+	sim.RegisterRelayer(Relayer{
+		Coinbase: relayer,
+		Owner:    owner,
+		Deposit:  deposit,
+		Fee:      big.NewInt(10), // 0.1%
+		Pairs:    []Pair{{BaseToken: token, QuoteToken: common.XDCNativeAddressBinary}},
+	})
+	if err := sim.SetBalance(maker, token, xdc(100)); err != nil {
+		t.Fatalf("failed to set token balance: %v", err)
+	}
+	if err := sim.SetBalance(taker, common.XDCNativeAddressBinary, xdc(200)); err != nil {
+		t.Fatalf("failed to set XDC balance: %v", err)
+	}
+	order := func(key *ecdsa.PrivateKey, side string) *tradingstate.OrderItem {
+		order := &tradingstate.OrderItem{
+			Quantity:        xdc(100),
+			Price:           xdc(1),
+			ExchangeAddress: relayer,
+			BaseToken:       token,
+			QuoteToken:      common.XDCNativeAddressBinary,
+			Side:            side,
+			Type:            tradingstate.Limit,
+		}
+		if err := sim.SignOrder(order, key); err != nil {
+			t.Fatalf("failed to sign order: %v", err)
+		}
+		return order
+	}
+	result, err := sim.Apply(order(makerKey, tradingstate.Ask))
+	if err != nil {
+		t.Fatalf("failed to apply maker order: %v", err)
+	}
+	if len(result.Trades) != 0 || len(result.Rejects) != 0 {
+		t.Fatalf("maker order matched: %+v", result)
+	}
+	// the taker order is replayed as a recorded transaction
+	tx := types.NewOrderTransaction(0, xdc(100), xdc(1), relayer, taker, token, common.XDCNativeAddressBinary, tradingstate.OrderNew, tradingstate.Bid, tradingstate.Limit, common.Hash{}, 0)
+	tx.SetOrderHash(types.OrderTxSigner{}.OrderCreateHash(tx))
+	if tx, err = types.OrderSignTx(tx, types.OrderTxSigner{}, takerKey); err != nil {
+		t.Fatalf("failed to sign order transaction: %v", err)
+	}
+	results, err := sim.ApplyTransactions(types.OrderTransactions{tx})
+	if err != nil {
+		t.Fatalf("failed to apply taker order: %v", err)
+	}
+	if len(results) != 1 || len(results[0].Trades) != 1 || len(results[0].Rejects) != 0 {
+		t.Fatalf("taker order mismatch: %+v", results)
+	}
+	result = results[0]
+	if have, want := result.Trades[0][tradingstate.TradeQuantity], xdc(100).String(); have != want {
+		t.Errorf("traded quantity mismatch: have %s, want %s", have, want)
+	}
+	// both sides pay 0.1% of the 100 XDC traded to the relayer owner
+	fee := new(big.Int).Div(xdc(1), big.NewInt(10))
+	balances := []struct {
+		account, token common.Address
+		want           *big.Int
+	}{
+		{maker, token, new(big.Int)},
+		{maker, common.XDCNativeAddressBinary, new(big.Int).Sub(xdc(100), fee)},
+		{taker, token, xdc(100)},
+		{taker, common.XDCNativeAddressBinary, new(big.Int).Sub(xdc(100), fee)},
+		{owner, common.XDCNativeAddressBinary, new(big.Int).Mul(fee, big.NewInt(2))},
+	}
+	for i, balance := range balances {
+		if have := sim.Balance(balance.account, balance.token); have.Cmp(balance.want) != 0 {
+			t.Errorf("balance %d mismatch: have %v, want %v", i, have, balance.want)
+		}
+	}
+	// the relayer is charged the matching fee for both sides
+	matchingFee := new(big.Int).Mul(common.RelayerFee, big.NewInt(2))
+	if have, want := tradingstate.GetRelayerDeposit(relayer, sim.State()), new(big.Int).Sub(deposit, matchingFee); have.Cmp(want) != 0 {
+		t.Errorf("relayer deposit mismatch: have %v, want %v", have, want)
+	}
+	fees := sim.Fees()[relayer]
+	if fees == nil {
+		t.Fatalf("missing relayer fees")
+	}
+	if fees.Trades != 2 || fees.MatchingFee.Cmp(matchingFee) != 0 || fees.TradingFees[common.XDCNativeAddressBinary].Cmp(new(big.Int).Mul(fee, big.NewInt(2))) != 0 {
+		t.Errorf("relayer fees mismatch: %+v", fees)
+	}
+	// the order book is kept across blocks
+	if err := sim.NextBlock(); err != nil {
+		t.Fatalf("failed to move to the next block: %v", err)
+	}
+	if sim.Header().Number.Uint64() != 1001 {
+		t.Errorf("block number mismatch: have %v, want 1001", sim.Header().Number)
+	}
+	if have := sim.TradingState().GetLastPrice(tradingstate.GetTradingOrderBookHash(token, common.XDCNativeAddressBinary)); have.Cmp(xdc(1)) != 0 {
+		t.Errorf("last price mismatch: have %v, want %v", have, xdc(1))
+	}
+}
+
+func TestSimulateUnknownRelayer(t *testing.T) {
+	sim, err := NewEmpty(params.AllXDPoSProtocolChanges, 1000, common.Address{})
+	if err != nil {
+		t.Fatalf("failed to create simulator: %v", err)
+	}
+	key, _ := crypto.GenerateKey()
+	order := &tradingstate.OrderItem{
+		Quantity:        xdc(1),
+		Price:           xdc(1),
+		ExchangeAddress: common.HexToAddress("0x0D3ab14BBaD3D99F4203bd7a11aCB94882050E7e"),
+		BaseToken:       common.HexToAddress("0xd9bb01454c85247B2ef35BB5BE57384cC275a8cf"),
+		QuoteToken:      common.XDCNativeAddressBinary,
+		Side:            tradingstate.Bid,
+		Type:            tradingstate.Limit,
+	}
+	if err := sim.SignOrder(order, key); err != nil {
+		t.Fatalf("failed to sign order: %v", err)
+	}
+	result, err := sim.Apply(order)
+	if err != nil {
+		t.Fatalf("failed to apply order: %v", err)
+	}
+	if len(result.Rejects) != 1 {
+		t.Errorf("order of an unknown relayer not rejected: %+v", result)
+	}
+}
+
+// testMarket is a simulated market of a token against XDC, listed by a single
+// relayer charging a 0.1% trading fee.
+type testMarket struct {
+	sim       *Simulator
+	relayer   common.Address
+	token     common.Address
+	orderBook common.Hash
+}
+
+func newTestMarket(t *testing.T, number uint64) *testMarket {
+	m := &testMarket{
+		relayer: common.HexToAddress("0x0D3ab14BBaD3D99F4203bd7a11aCB94882050E7e"),
+		token:   common.HexToAddress("0xd9bb01454c85247B2ef35BB5BE57384cC275a8cf"),
+	}
+	m.orderBook = tradingstate.GetTradingOrderBookHash(m.token, common.XDCNativeAddressBinary)
+	sim, err := NewEmpty(params.AllXDPoSProtocolChanges, number, common.HexToAddress("0x0000000000000000000000000000000000000099"))
+	if err != nil {
+		t.Fatalf("failed to create simulator: %v", err)
+	}
+	sim.SetTokenDecimals(m.token, 18)
+	sim.RegisterRelayer(Relayer{
+		Coinbase: m.relayer,
+		Owner:    common.HexToAddress("0x4d7eA2cE949216D6b120f3AA10164173615A2b6C"),
+		Deposit:  xdc(25000),
+		Fee:      big.NewInt(10),
+		Pairs:    []Pair{{BaseToken: m.token, QuoteToken: common.XDCNativeAddressBinary}},
+	})
+	m.sim = sim
+	return m
+}
+
+// fund returns a new user holding 1000 of both the token and XDC.
+func (m *testMarket) fund(t *testing.T) *ecdsa.PrivateKey {
+	key, _ := crypto.GenerateKey()
+	for _, token := range []common.Address{m.token, common.XDCNativeAddressBinary} {
+		if err := m.sim.SetBalance(crypto.PubkeyToAddress(key.PublicKey), token, xdc(1000)); err != nil {
+			t.Fatalf("failed to set balance: %v", err)
+		}
+	}
+	return key
+}
+
+// apply signs and matches a limit order of 10 tokens, completed by fill.
+func (m *testMarket) apply(t *testing.T, key *ecdsa.PrivateKey, side string, price int64, fill func(*tradingstate.OrderItem)) *Result {
+	order := &tradingstate.OrderItem{
+		Quantity:        xdc(10),
+		Price:           xdc(price),
+		ExchangeAddress: m.relayer,
+		BaseToken:       m.token,
+		QuoteToken:      common.XDCNativeAddressBinary,
+		Side:            side,
+		Type:            tradingstate.Limit,
+	}
+	if fill != nil {
+		fill(order)
+	}
+	if err := m.sim.SignOrder(order, key); err != nil {
+		t.Fatalf("failed to sign order: %v", err)
+	}
+	result, err := m.sim.Apply(order)
+	if err != nil {
+		t.Fatalf("failed to apply order: %v", err)
+	}
+	return result
+}
+
+// at returns a copy of the current header moved to the given block.
+func (m *testMarket) at(number uint64) *types.Header {
+	header := types.CopyHeader(m.sim.Header())
+	header.Number = new(big.Int).SetUint64(number)
+	return header
+}
+
+func TestSimulateExpiredOrders(t *testing.T) {
+	defer func(fork *big.Int) { common.TIPXDCXOrderTypes = fork }(common.TIPXDCXOrderTypes)
+	common.TIPXDCXOrderTypes = common.Big0
+
+	m := newTestMarket(t, 1000)
+	maker := m.fund(t)
+	goodTilBlock := func(expireBlock uint64) func(*tradingstate.OrderItem) {
+		return func(order *tradingstate.OrderItem) {
+			order.TimeInForce = tradingstate.TimeInForceGTB
+			order.ExpireBlock = expireBlock
+		}
+	}
+	expiring := m.apply(t, maker, tradingstate.Ask, 1, goodTilBlock(1001)).Order
+	lasting := m.apply(t, maker, tradingstate.Ask, 2, goodTilBlock(1005)).Order
+
+	// orders expire after their expire block
+	results, err := m.sim.XDCx.ProcessExpiredOrders(m.at(1001), m.sim.chain, m.sim.State(), m.sim.TradingState())
+	if err != nil || len(results) != 0 {
+		t.Fatalf("orders expired at their expire block: %v, %v", results, err)
+	}
+	results, err = m.sim.XDCx.ProcessExpiredOrders(m.at(1002), m.sim.chain, m.sim.State(), m.sim.TradingState())
+	if err != nil {
+		t.Fatalf("failed to process expired orders: %v", err)
+	}
+	if len(results) != 1 || results[0].Order.Hash != expiring.Hash || results[0].Order.Status != tradingstate.OrderStatusExpired {
+		t.Fatalf("expired orders mismatch: %+v", results)
+	}
+	if len(results[0].Trades) != 0 || len(results[0].Rejects) != 1 || results[0].Rejects[0].Hash != expiring.Hash {
+		t.Errorf("expired order result mismatch: %+v", results[0])
+	}
+	if price, _ := m.sim.TradingState().GetBestAskPrice(m.orderBook); price.Cmp(lasting.Price) != 0 {
+		t.Errorf("best ask mismatch: have %v, want %v", price, lasting.Price)
+	}
+	// the expired order book is only visited once
+	results, err = m.sim.XDCx.ProcessExpiredOrders(m.at(1003), m.sim.chain, m.sim.State(), m.sim.TradingState())
+	if err != nil || len(results) != 0 {
+		t.Fatalf("orders expired twice: %v, %v", results, err)
+	}
+	if _, _, ok := m.sim.TradingState().GetExpiredOrderBook(1006); !ok {
+		t.Errorf("order book of the remaining good-til-block order not indexed")
+	}
+}
+
+func TestSimulateTriggerOrders(t *testing.T) {
+	defer func(fork *big.Int) { common.TIPXDCXTriggerOrders = fork }(common.TIPXDCXTriggerOrders)
+	common.TIPXDCXTriggerOrders = common.Big0
+
+	m := newTestMarket(t, 1000)
+	maker, buyer, seller := m.fund(t), m.fund(t), m.fund(t)
+	trigger := func(price int64, condition string) func(*tradingstate.OrderItem) {
+		return func(order *tradingstate.OrderItem) {
+			order.TriggerPrice = xdc(price)
+			order.TriggerCondition = condition
+		}
+	}
+	m.apply(t, maker, tradingstate.Ask, 2, nil)
+	// a stop buy above 2 and a stop sell below 1 wait for the epoch price
+	stopBuy := m.apply(t, buyer, tradingstate.Bid, 3, trigger(2, tradingstate.TriggerAbove))
+	stopSell := m.apply(t, seller, tradingstate.Ask, 1, trigger(1, tradingstate.TriggerBelow))
+	for _, result := range []*Result{stopBuy, stopSell} {
+		if len(result.Trades) != 0 || len(result.Rejects) != 0 || !m.sim.TradingState().IsWaitingTriggerOrder(m.orderBook, result.Order) {
+			t.Fatalf("trigger order not waiting: %+v", result)
+		}
+	}
+
+	// the epoch price crosses the trigger price of the stop buy only
+	m.sim.TradingState().SetMediumPriceBeforeEpoch(m.orderBook, xdc(2))
+	results, err := m.sim.XDCx.ProcessTriggerOrders(m.at(1001), m.sim.coinbase, m.sim.chain, m.sim.State(), m.sim.TradingState())
+	if err != nil {
+		t.Fatalf("failed to process trigger orders: %v", err)
+	}
+	if len(results) != 1 || results[0].Order.Hash != stopBuy.Order.Hash || len(results[0].Rejects) != 0 {
+		t.Fatalf("triggered orders mismatch: %+v", results)
+	}
+	trades := results[0].Trades
+	if len(trades) != 1 || trades[0][tradingstate.TradeQuantity] != xdc(10).String() || trades[0][tradingstate.TradePrice] != xdc(2).String() {
+		t.Fatalf("triggered trades mismatch: %+v", trades)
+	}
+	if have, want := m.sim.Balance(crypto.PubkeyToAddress(buyer.PublicKey), m.token), xdc(1010); have.Cmp(want) != 0 {
+		t.Errorf("buyer token balance mismatch: have %v, want %v", have, want)
+	}
+	if m.sim.TradingState().IsWaitingTriggerOrder(m.orderBook, stopBuy.Order) {
+		t.Errorf("triggered order still waiting")
+	}
+	if !m.sim.TradingState().IsWaitingTriggerOrder(m.orderBook, stopSell.Order) {
+		t.Errorf("stop sell triggered above its trigger price")
+	}
+	// orders trigger once
+	results, err = m.sim.XDCx.ProcessTriggerOrders(m.at(1002), m.sim.coinbase, m.sim.chain, m.sim.State(), m.sim.TradingState())
+	if err != nil || len(results) != 0 {
+		t.Fatalf("orders triggered twice: %v, %v", results, err)
+	}
+}
+
+func TestSimulateTriggerOrderFees(t *testing.T) {
+	defer func(fork *big.Int) { common.TIPXDCXTriggerOrders = fork }(common.TIPXDCXTriggerOrders)
+	common.TIPXDCXTriggerOrders = common.Big0
+
+	// the next block switches the epoch
+	m := newTestMarket(t, 1799)
+	maker, buyer := m.fund(t), m.fund(t)
+	m.apply(t, maker, tradingstate.Ask, 2, nil)
+	m.apply(t, buyer, tradingstate.Bid, 3, func(order *tradingstate.OrderItem) {
+		order.TriggerPrice = xdc(2)
+		order.TriggerCondition = tradingstate.TriggerAbove
+	})
+	if fees := m.sim.Fees()[m.relayer]; fees != nil {
+		t.Fatalf("fees counted before the trade: %+v", fees)
+	}
+	m.sim.TradingState().SetMediumPriceBeforeEpoch(m.orderBook, xdc(2))
+	if err := m.sim.NextBlock(); err != nil {
+		t.Fatalf("failed to move to the next block: %v", err)
+	}
+	if have := m.sim.Header().Number.Uint64(); have != 1801 {
+		t.Errorf("block number mismatch: have %v, want 1801", have)
+	}
+	// both sides of the triggered trade pay 0.1% of the 20 XDC traded
+	fee := new(big.Int).Div(xdc(2), big.NewInt(100))
+	fees := m.sim.Fees()[m.relayer]
+	if fees == nil {
+		t.Fatalf("missing relayer fees of the triggered trade")
+	}
+	if fees.Trades != 2 || fees.TradingFees[common.XDCNativeAddressBinary].Cmp(new(big.Int).Mul(fee, big.NewInt(2))) != 0 {
+		t.Errorf("relayer fees mismatch: %+v", fees)
+	}
+}
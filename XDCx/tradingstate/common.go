@@ -23,6 +23,14 @@ var (
 	Limit     = "LO"
 	Cancel    = "CANCELLED"
 	OrderNew  = "NEW"
+
+	TimeInForceGTC = "GTC"
+	TimeInForceIOC = "IOC"
+	TimeInForceFOK = "FOK"
+	TimeInForceGTB = "GTB"
+
+	TriggerAbove = "ABOVE"
+	TriggerBelow = "BELOW"
 )
 
 var EmptyHash = common.Hash{}
@@ -42,6 +50,11 @@ var EmptyOrder = OrderItem{
 	Quantity: Zero,
 }
 
+// ExpiringOrderBooks is the key of the pseudo order book whose expiry index maps
+// every expire block to the order books with good-til-block orders expiring
+// there, so that expired orders are found without visiting every order book.
+var ExpiringOrderBooks = crypto.Keccak256Hash([]byte("XDCx expiring order books"))
+
 var (
 	ErrInvalidSignature = errors.New("verify order: invalid signature")
 	ErrInvalidPrice     = errors.New("verify order: invalid price")
@@ -51,6 +64,13 @@ var (
 	ErrInvalidOrderSide = errors.New("verify order: invalid order side")
 	ErrInvalidStatus    = errors.New("verify order: invalid status")
 
+	ErrInvalidTimeInForce = errors.New("verify order: invalid time in force")
+	ErrInvalidExpireBlock = errors.New("verify order: invalid expire block")
+	ErrInvalidPostOnly    = errors.New("verify order: post-only order can't be market or immediate")
+
+	ErrInvalidTriggerPrice     = errors.New("verify order: invalid trigger price")
+	ErrInvalidTriggerCondition = errors.New("verify order: invalid trigger condition")
+
 	// supported order types
 	MatchingOrderType = map[string]bool{
 		Market: true,
@@ -78,6 +98,9 @@ type tradingExchangeObject struct {
 	BidRoot                common.Hash // merkle root of the storage trie
 	OrderRoot              common.Hash
 	LiquidationPriceRoot   common.Hash
+	TriggerAboveRoot       common.Hash `rlp:"optional"` // merkle root of the orders triggered when the price rises
+	TriggerBelowRoot       common.Hash `rlp:"optional"` // merkle root of the orders triggered when the price falls
+	ExpiryRoot             common.Hash `rlp:"optional"` // merkle root of the good-til-block orders by expire block
 }
 
 var (
@@ -120,6 +143,15 @@ type MatchingResult struct {
 	Rejects []*OrderItem
 }
 
+// OrderResult is the outcome of an order processed by a block outside of its
+// trading transactions, like an expired good-til-block order or a triggered
+// stop order.
+type OrderResult struct {
+	Order   *OrderItem
+	Trades  []map[string]string
+	Rejects []*OrderItem
+}
+
 func EncodeTxMatchesBatch(txMatchBatch TxMatchBatch) ([]byte, error) {
 	data, err := json.Marshal(txMatchBatch)
 	if err != nil || data == nil {
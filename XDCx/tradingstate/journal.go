@@ -76,6 +76,26 @@ type (
 		lendingBook common.Hash
 		tradeId     uint64
 	}
+	insertTriggerOrder struct {
+		orderBook common.Hash
+		orderId   common.Hash
+		order     OrderItem
+	}
+	removeTriggerOrder struct {
+		orderBook common.Hash
+		orderId   common.Hash
+		order     OrderItem
+	}
+	insertExpiringOrder struct {
+		orderBook   common.Hash
+		orderId     common.Hash
+		expireBlock uint64
+	}
+	removeExpiringOrder struct {
+		orderBook   common.Hash
+		orderId     common.Hash
+		expireBlock uint64
+	}
 )
 
 func (ch insertOrder) undo(s *TradingStateDB) {
@@ -96,6 +116,24 @@ func (ch insertLiquidationPrice) undo(s *TradingStateDB) {
 func (ch removeLiquidationPrice) undo(s *TradingStateDB) {
 	s.InsertLiquidationPrice(ch.orderBook, ch.price, ch.lendingBook, ch.tradeId)
 }
+func (ch insertTriggerOrder) undo(s *TradingStateDB) {
+	err := s.RemoveTriggerOrder(ch.orderBook, &ch.order)
+	if err != nil {
+		log.Warn("undo RemoveTriggerOrder", "err", err, "ch.orderBook", ch.orderBook, "ch.order", ch.order)
+	}
+}
+func (ch removeTriggerOrder) undo(s *TradingStateDB) {
+	s.InsertTriggerOrder(ch.orderBook, ch.orderId, ch.order)
+}
+func (ch insertExpiringOrder) undo(s *TradingStateDB) {
+	err := s.RemoveExpiringOrder(ch.orderBook, ch.orderId, ch.expireBlock)
+	if err != nil {
+		log.Warn("undo RemoveExpiringOrder", "err", err, "ch.orderBook", ch.orderBook, "ch.orderId", ch.orderId, "ch.expireBlock", ch.expireBlock)
+	}
+}
+func (ch removeExpiringOrder) undo(s *TradingStateDB) {
+	s.InsertExpiringOrder(ch.orderBook, ch.orderId, ch.expireBlock)
+}
 func (ch subAmountOrder) undo(s *TradingStateDB) {
 	priceHash := common.BigToHash(ch.order.Price)
 	stateOrderBook := s.getStateExchangeObject(ch.orderBook)
@@ -15,34 +15,41 @@ import (
 )
 
 const (
-	OrderStatusNew           = "NEW"
-	OrderStatusOpen          = "OPEN"
-	OrderStatusPartialFilled = "PARTIAL_FILLED"
-	OrderStatusFilled        = "FILLED"
-	OrderStatusCancelled     = "CANCELLED"
-	OrderStatusRejected      = "REJECTED"
+	OrderStatusNew            = "NEW"
+	OrderStatusOpen           = "OPEN"
+	OrderStatusPartialFilled  = "PARTIAL_FILLED"
+	OrderStatusFilled         = "FILLED"
+	OrderStatusCancelled      = "CANCELLED"
+	OrderStatusRejected       = "REJECTED"
+	OrderStatusExpired        = "EXPIRED"
+	OrderStatusPendingTrigger = "PENDING_TRIGGER"
 )
 
 // OrderItem : info that will be store in database
 type OrderItem struct {
-	Quantity        *big.Int       `json:"quantity,omitempty"`
-	Price           *big.Int       `json:"price,omitempty"`
-	ExchangeAddress common.Address `json:"exchangeAddress,omitempty"`
-	UserAddress     common.Address `json:"userAddress,omitempty"`
-	BaseToken       common.Address `json:"baseToken,omitempty"`
-	QuoteToken      common.Address `json:"quoteToken,omitempty"`
-	Status          string         `json:"status,omitempty"`
-	Side            string         `json:"side,omitempty"`
-	Type            string         `json:"type,omitempty"`
-	Hash            common.Hash    `json:"hash,omitempty"`
-	TxHash          common.Hash    `json:"txHash,omitempty"`
-	Signature       *Signature     `json:"signature,omitempty"`
-	FilledAmount    *big.Int       `json:"filledAmount,omitempty"`
-	Nonce           *big.Int       `json:"nonce,omitempty"`
-	CreatedAt       time.Time      `json:"createdAt,omitempty"`
-	UpdatedAt       time.Time      `json:"updatedAt,omitempty"`
-	OrderID         uint64         `json:"orderID,omitempty"`
-	ExtraData       string         `json:"extraData,omitempty"`
+	Quantity         *big.Int       `json:"quantity,omitempty"`
+	Price            *big.Int       `json:"price,omitempty"`
+	ExchangeAddress  common.Address `json:"exchangeAddress,omitempty"`
+	UserAddress      common.Address `json:"userAddress,omitempty"`
+	BaseToken        common.Address `json:"baseToken,omitempty"`
+	QuoteToken       common.Address `json:"quoteToken,omitempty"`
+	Status           string         `json:"status,omitempty"`
+	Side             string         `json:"side,omitempty"`
+	Type             string         `json:"type,omitempty"`
+	Hash             common.Hash    `json:"hash,omitempty"`
+	TxHash           common.Hash    `json:"txHash,omitempty"`
+	Signature        *Signature     `json:"signature,omitempty"`
+	FilledAmount     *big.Int       `json:"filledAmount,omitempty"`
+	Nonce            *big.Int       `json:"nonce,omitempty"`
+	CreatedAt        time.Time      `json:"createdAt,omitempty"`
+	UpdatedAt        time.Time      `json:"updatedAt,omitempty"`
+	OrderID          uint64         `json:"orderID,omitempty"`
+	ExtraData        string         `json:"extraData,omitempty"`
+	TimeInForce      string         `json:"timeInForce,omitempty" rlp:"optional"`
+	PostOnly         bool           `json:"postOnly,omitempty" rlp:"optional"`
+	ExpireBlock      uint64         `json:"expireBlock,omitempty" rlp:"optional"`
+	TriggerPrice     *big.Int       `json:"triggerPrice,omitempty" rlp:"optional"`
+	TriggerCondition string         `json:"triggerCondition,omitempty" rlp:"optional"`
 }
 
 // Signature struct
@@ -59,44 +66,57 @@ type SignatureRecord struct {
 }
 
 type OrderItemBSON struct {
-	Quantity        string           `json:"quantity,omitempty" bson:"quantity"`
-	Price           string           `json:"price,omitempty" bson:"price"`
-	ExchangeAddress string           `json:"exchangeAddress,omitempty" bson:"exchangeAddress"`
-	UserAddress     string           `json:"userAddress,omitempty" bson:"userAddress"`
-	BaseToken       string           `json:"baseToken,omitempty" bson:"baseToken"`
-	QuoteToken      string           `json:"quoteToken,omitempty" bson:"quoteToken"`
-	Status          string           `json:"status,omitempty" bson:"status"`
-	Side            string           `json:"side,omitempty" bson:"side"`
-	Type            string           `json:"type,omitempty" bson:"type"`
-	Hash            string           `json:"hash,omitempty" bson:"hash"`
-	TxHash          string           `json:"txHash,omitempty" bson:"txHash"`
-	Signature       *SignatureRecord `json:"signature,omitempty" bson:"signature"`
-	FilledAmount    string           `json:"filledAmount,omitempty" bson:"filledAmount"`
-	Nonce           string           `json:"nonce,omitempty" bson:"nonce"`
-	CreatedAt       time.Time        `json:"createdAt,omitempty" bson:"createdAt"`
-	UpdatedAt       time.Time        `json:"updatedAt,omitempty" bson:"updatedAt"`
-	OrderID         string           `json:"orderID,omitempty" bson:"orderID"`
-	ExtraData       string           `json:"extraData,omitempty" bson:"extraData"`
+	Quantity         string           `json:"quantity,omitempty" bson:"quantity"`
+	Price            string           `json:"price,omitempty" bson:"price"`
+	ExchangeAddress  string           `json:"exchangeAddress,omitempty" bson:"exchangeAddress"`
+	UserAddress      string           `json:"userAddress,omitempty" bson:"userAddress"`
+	BaseToken        string           `json:"baseToken,omitempty" bson:"baseToken"`
+	QuoteToken       string           `json:"quoteToken,omitempty" bson:"quoteToken"`
+	Status           string           `json:"status,omitempty" bson:"status"`
+	Side             string           `json:"side,omitempty" bson:"side"`
+	Type             string           `json:"type,omitempty" bson:"type"`
+	Hash             string           `json:"hash,omitempty" bson:"hash"`
+	TxHash           string           `json:"txHash,omitempty" bson:"txHash"`
+	Signature        *SignatureRecord `json:"signature,omitempty" bson:"signature"`
+	FilledAmount     string           `json:"filledAmount,omitempty" bson:"filledAmount"`
+	Nonce            string           `json:"nonce,omitempty" bson:"nonce"`
+	CreatedAt        time.Time        `json:"createdAt,omitempty" bson:"createdAt"`
+	UpdatedAt        time.Time        `json:"updatedAt,omitempty" bson:"updatedAt"`
+	OrderID          string           `json:"orderID,omitempty" bson:"orderID"`
+	ExtraData        string           `json:"extraData,omitempty" bson:"extraData"`
+	TimeInForce      string           `json:"timeInForce,omitempty" bson:"timeInForce,omitempty"`
+	PostOnly         bool             `json:"postOnly,omitempty" bson:"postOnly,omitempty"`
+	ExpireBlock      uint64           `json:"expireBlock,omitempty" bson:"expireBlock,omitempty"`
+	TriggerPrice     string           `json:"triggerPrice,omitempty" bson:"triggerPrice,omitempty"`
+	TriggerCondition string           `json:"triggerCondition,omitempty" bson:"triggerCondition,omitempty"`
 }
 
 func (o *OrderItem) GetBSON() (interface{}, error) {
 	or := OrderItemBSON{
-		ExchangeAddress: o.ExchangeAddress.Hex(),
-		UserAddress:     o.UserAddress.Hex(),
-		BaseToken:       o.BaseToken.Hex(),
-		QuoteToken:      o.QuoteToken.Hex(),
-		Status:          o.Status,
-		Side:            o.Side,
-		Type:            o.Type,
-		Hash:            o.Hash.Hex(),
-		TxHash:          o.TxHash.Hex(),
-		Quantity:        o.Quantity.String(),
-		Price:           o.Price.String(),
-		Nonce:           o.Nonce.String(),
-		CreatedAt:       o.CreatedAt,
-		UpdatedAt:       o.UpdatedAt,
-		OrderID:         strconv.FormatUint(o.OrderID, 10),
-		ExtraData:       o.ExtraData,
+		ExchangeAddress:  o.ExchangeAddress.Hex(),
+		UserAddress:      o.UserAddress.Hex(),
+		BaseToken:        o.BaseToken.Hex(),
+		QuoteToken:       o.QuoteToken.Hex(),
+		Status:           o.Status,
+		Side:             o.Side,
+		Type:             o.Type,
+		Hash:             o.Hash.Hex(),
+		TxHash:           o.TxHash.Hex(),
+		Quantity:         o.Quantity.String(),
+		Price:            o.Price.String(),
+		Nonce:            o.Nonce.String(),
+		CreatedAt:        o.CreatedAt,
+		UpdatedAt:        o.UpdatedAt,
+		OrderID:          strconv.FormatUint(o.OrderID, 10),
+		ExtraData:        o.ExtraData,
+		TimeInForce:      o.TimeInForce,
+		PostOnly:         o.PostOnly,
+		ExpireBlock:      o.ExpireBlock,
+		TriggerCondition: o.TriggerCondition,
+	}
+
+	if o.TriggerPrice != nil {
+		or.TriggerPrice = o.TriggerPrice.String()
 	}
 
 	if o.FilledAmount != nil {
@@ -116,27 +136,32 @@ func (o *OrderItem) GetBSON() (interface{}, error) {
 
 func (o *OrderItem) SetBSON(raw bson.Raw) error {
 	decoded := new(struct {
-		ID              bson.ObjectId    `json:"id,omitempty" bson:"_id"`
-		ExchangeAddress string           `json:"exchangeAddress" bson:"exchangeAddress"`
-		UserAddress     string           `json:"userAddress" bson:"userAddress"`
-		BaseToken       string           `json:"baseToken" bson:"baseToken"`
-		QuoteToken      string           `json:"quoteToken" bson:"quoteToken"`
-		Status          string           `json:"status" bson:"status"`
-		Side            string           `json:"side" bson:"side"`
-		Type            string           `json:"type" bson:"type"`
-		Hash            string           `json:"hash" bson:"hash"`
-		TxHash          string           `json:"txHash,omitempty" bson:"txHash"`
-		Price           string           `json:"price" bson:"price"`
-		Quantity        string           `json:"quantity" bson:"quantity"`
-		FilledAmount    string           `json:"filledAmount" bson:"filledAmount"`
-		Nonce           string           `json:"nonce" bson:"nonce"`
-		MakeFee         string           `json:"makeFee" bson:"makeFee"`
-		TakeFee         string           `json:"takeFee" bson:"takeFee"`
-		Signature       *SignatureRecord `json:"signature" bson:"signature"`
-		CreatedAt       time.Time        `json:"createdAt" bson:"createdAt"`
-		UpdatedAt       time.Time        `json:"updatedAt" bson:"updatedAt"`
-		OrderID         string           `json:"orderID" bson:"orderID"`
-		ExtraData       string           `json:"extraData,omitempty" bson:"extraData"`
+		ID               bson.ObjectId    `json:"id,omitempty" bson:"_id"`
+		ExchangeAddress  string           `json:"exchangeAddress" bson:"exchangeAddress"`
+		UserAddress      string           `json:"userAddress" bson:"userAddress"`
+		BaseToken        string           `json:"baseToken" bson:"baseToken"`
+		QuoteToken       string           `json:"quoteToken" bson:"quoteToken"`
+		Status           string           `json:"status" bson:"status"`
+		Side             string           `json:"side" bson:"side"`
+		Type             string           `json:"type" bson:"type"`
+		Hash             string           `json:"hash" bson:"hash"`
+		TxHash           string           `json:"txHash,omitempty" bson:"txHash"`
+		Price            string           `json:"price" bson:"price"`
+		Quantity         string           `json:"quantity" bson:"quantity"`
+		FilledAmount     string           `json:"filledAmount" bson:"filledAmount"`
+		Nonce            string           `json:"nonce" bson:"nonce"`
+		MakeFee          string           `json:"makeFee" bson:"makeFee"`
+		TakeFee          string           `json:"takeFee" bson:"takeFee"`
+		Signature        *SignatureRecord `json:"signature" bson:"signature"`
+		CreatedAt        time.Time        `json:"createdAt" bson:"createdAt"`
+		UpdatedAt        time.Time        `json:"updatedAt" bson:"updatedAt"`
+		OrderID          string           `json:"orderID" bson:"orderID"`
+		ExtraData        string           `json:"extraData,omitempty" bson:"extraData"`
+		TimeInForce      string           `json:"timeInForce,omitempty" bson:"timeInForce"`
+		PostOnly         bool             `json:"postOnly,omitempty" bson:"postOnly"`
+		ExpireBlock      uint64           `json:"expireBlock,omitempty" bson:"expireBlock"`
+		TriggerPrice     string           `json:"triggerPrice,omitempty" bson:"triggerPrice"`
+		TriggerCondition string           `json:"triggerCondition,omitempty" bson:"triggerCondition"`
 	})
 
 	err := raw.Unmarshal(decoded)
@@ -184,6 +209,13 @@ func (o *OrderItem) SetBSON(raw bson.Raw) error {
 	}
 	o.OrderID = uint64(orderID)
 	o.ExtraData = decoded.ExtraData
+	o.TimeInForce = decoded.TimeInForce
+	o.PostOnly = decoded.PostOnly
+	o.ExpireBlock = decoded.ExpireBlock
+	if decoded.TriggerPrice != "" {
+		o.TriggerPrice = ToBigInt(decoded.TriggerPrice)
+	}
+	o.TriggerCondition = decoded.TriggerCondition
 	return nil
 }
 
@@ -221,6 +253,12 @@ func (o *OrderItem) VerifyBasicOrderInfo() error {
 		if err := o.verifyOrderType(); err != nil {
 			return err
 		}
+		if err := o.VerifyMatchingOptions(); err != nil {
+			return err
+		}
+		if err := o.VerifyTriggerOptions(); err != nil {
+			return err
+		}
 	}
 	if err := o.verifyStatus(); err != nil {
 		return err
@@ -252,6 +290,8 @@ func (o *OrderItem) verifySignature() error {
 
 	tx := types.NewOrderTransaction(uint64(n), o.Quantity, o.Price, o.ExchangeAddress, o.UserAddress,
 		o.BaseToken, o.QuoteToken, o.Status, o.Side, o.Type, o.Hash, o.OrderID)
+	tx.SetMatchingOptions(o.TimeInForce, o.PostOnly, o.ExpireBlock)
+	tx.SetTrigger(o.TriggerPrice, o.TriggerCondition)
 	tx.ImportSignature(V, R, S)
 	from, _ := types.OrderSender(types.OrderTxSigner{}, tx)
 	if from != tx.UserAddress() {
@@ -269,6 +309,77 @@ func (o *OrderItem) verifyOrderType() error {
 	return nil
 }
 
+// VerifyMatchingOptions make sure the time-in-force policy, the post-only flag
+// and the expiry block are consistent with each other and with the order type
+func (o *OrderItem) VerifyMatchingOptions() error {
+	switch o.TimeInForce {
+	case "", TimeInForceGTC, TimeInForceIOC, TimeInForceFOK:
+		if o.ExpireBlock != 0 {
+			log.Debug("Expiry block without good-til-block policy", "timeInForce", o.TimeInForce, "expireBlock", o.ExpireBlock)
+			return ErrInvalidExpireBlock
+		}
+	case TimeInForceGTB:
+		if o.ExpireBlock == 0 {
+			log.Debug("Good-til-block order without expiry block")
+			return ErrInvalidExpireBlock
+		}
+	default:
+		log.Debug("Invalid time in force", "timeInForce", o.TimeInForce)
+		return ErrInvalidTimeInForce
+	}
+	if o.Type == Market && !o.IsImmediate() && o.TimeInForce != "" {
+		// Market orders never rest on the book
+		log.Debug("Invalid time in force for market order", "timeInForce", o.TimeInForce)
+		return ErrInvalidTimeInForce
+	}
+	if o.PostOnly && (o.Type == Market || o.IsImmediate()) {
+		log.Debug("Post-only order must be able to rest on the book", "type", o.Type, "timeInForce", o.TimeInForce)
+		return ErrInvalidPostOnly
+	}
+	return nil
+}
+
+// HasMatchingOptions reports whether the order uses any of the time-in-force,
+// post-only or expiry options.
+func (o *OrderItem) HasMatchingOptions() bool {
+	return o.TimeInForce != "" || o.PostOnly || o.ExpireBlock != 0
+}
+
+// IsImmediate reports whether the unfilled part of the order is cancelled
+// instead of resting on the book.
+func (o *OrderItem) IsImmediate() bool {
+	return o.TimeInForce == TimeInForceIOC || o.TimeInForce == TimeInForceFOK
+}
+
+// IsExpired reports whether a good-til-block order can't be matched any more
+// at the given block.
+func (o *OrderItem) IsExpired(blockNumber uint64) bool {
+	return o.ExpireBlock != 0 && o.ExpireBlock < blockNumber
+}
+
+// VerifyTriggerOptions make sure a trigger order has both a positive trigger
+// price and a trigger condition, and that other orders have neither.
+func (o *OrderItem) VerifyTriggerOptions() error {
+	if o.TriggerPrice == nil && o.TriggerCondition == "" {
+		return nil
+	}
+	if o.TriggerPrice == nil || o.TriggerPrice.Sign() <= 0 {
+		log.Debug("Invalid trigger price", "triggerPrice", o.TriggerPrice)
+		return ErrInvalidTriggerPrice
+	}
+	if o.TriggerCondition != TriggerAbove && o.TriggerCondition != TriggerBelow {
+		log.Debug("Invalid trigger condition", "triggerCondition", o.TriggerCondition)
+		return ErrInvalidTriggerCondition
+	}
+	return nil
+}
+
+// IsTriggerOrder reports whether the order waits for the epoch price to cross
+// its trigger price before being matched.
+func (o *OrderItem) IsTriggerOrder() bool {
+	return o.TriggerPrice != nil && o.TriggerPrice.Sign() > 0
+}
+
 // verify order side
 func (o *OrderItem) verifyOrderSide() error {
 
@@ -0,0 +1,142 @@
+package tradingstate
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/XinFinOrg/XDPoSChain/common"
+	"github.com/XinFinOrg/XDPoSChain/rlp"
+)
+
+func TestVerifyMatchingOptions(t *testing.T) {
+	tests := []struct {
+		order OrderItem
+		err   error
+	}{
+		{OrderItem{Type: Limit}, nil},
+		{OrderItem{Type: Limit, TimeInForce: TimeInForceGTC, PostOnly: true}, nil},
+		{OrderItem{Type: Limit, TimeInForce: TimeInForceIOC}, nil},
+		{OrderItem{Type: Limit, TimeInForce: TimeInForceFOK}, nil},
+		{OrderItem{Type: Limit, TimeInForce: TimeInForceGTB, ExpireBlock: 100, PostOnly: true}, nil},
+		{OrderItem{Type: Market, TimeInForce: TimeInForceFOK}, nil},
+		{OrderItem{Type: Limit, TimeInForce: "DAY"}, ErrInvalidTimeInForce},
+		{OrderItem{Type: Limit, TimeInForce: TimeInForceGTB}, ErrInvalidExpireBlock},
+		{OrderItem{Type: Limit, ExpireBlock: 100}, ErrInvalidExpireBlock},
+		{OrderItem{Type: Limit, TimeInForce: TimeInForceIOC, ExpireBlock: 100}, ErrInvalidExpireBlock},
+		{OrderItem{Type: Market, TimeInForce: TimeInForceGTC}, ErrInvalidTimeInForce},
+		{OrderItem{Type: Market, TimeInForce: TimeInForceGTB, ExpireBlock: 100}, ErrInvalidTimeInForce},
+		{OrderItem{Type: Market, PostOnly: true}, ErrInvalidPostOnly},
+		{OrderItem{Type: Limit, TimeInForce: TimeInForceIOC, PostOnly: true}, ErrInvalidPostOnly},
+	}
+	for i, tt := range tests {
+		if err := tt.order.VerifyMatchingOptions(); err != tt.err {
+			t.Errorf("test %d: have %v, want %v", i, err, tt.err)
+		}
+	}
+}
+
+func TestVerifyTriggerOptions(t *testing.T) {
+	tests := []struct {
+		order OrderItem
+		err   error
+	}{
+		{OrderItem{}, nil},
+		{OrderItem{TriggerPrice: big.NewInt(100), TriggerCondition: TriggerAbove}, nil},
+		{OrderItem{TriggerPrice: big.NewInt(100), TriggerCondition: TriggerBelow}, nil},
+		{OrderItem{TriggerCondition: TriggerAbove}, ErrInvalidTriggerPrice},
+		{OrderItem{TriggerPrice: big.NewInt(0), TriggerCondition: TriggerBelow}, ErrInvalidTriggerPrice},
+		{OrderItem{TriggerPrice: big.NewInt(100)}, ErrInvalidTriggerCondition},
+		{OrderItem{TriggerPrice: big.NewInt(100), TriggerCondition: "CROSS"}, ErrInvalidTriggerCondition},
+	}
+	for i, tt := range tests {
+		if err := tt.order.VerifyTriggerOptions(); err != tt.err {
+			t.Errorf("test %d: have %v, want %v", i, err, tt.err)
+		}
+	}
+}
+
+func TestOrderItemExpiry(t *testing.T) {
+	order := OrderItem{TimeInForce: TimeInForceGTB, ExpireBlock: 100}
+	if order.IsExpired(100) {
+		t.Error("order expired at its expiry block")
+	}
+	if !order.IsExpired(101) {
+		t.Error("order not expired after its expiry block")
+	}
+	if (&OrderItem{}).IsExpired(101) {
+		t.Error("order without expiry block expired")
+	}
+}
+
+// Orders without matching options must keep the encoding they had before the
+// options were introduced, since they are part of the trading state.
+func TestOrderItemLegacyEncoding(t *testing.T) {
+	type legacyOrderItem struct {
+		Quantity        *big.Int
+		Price           *big.Int
+		ExchangeAddress common.Address
+		UserAddress     common.Address
+		BaseToken       common.Address
+		QuoteToken      common.Address
+		Status          string
+		Side            string
+		Type            string
+		Hash            common.Hash
+		TxHash          common.Hash
+		Signature       *Signature
+		FilledAmount    *big.Int
+		Nonce           *big.Int
+		CreatedAt       time.Time
+		UpdatedAt       time.Time
+		OrderID         uint64
+		ExtraData       string
+	}
+	order := OrderItem{
+		Quantity:  big.NewInt(10),
+		Price:     big.NewInt(20),
+		Status:    OrderNew,
+		Side:      Bid,
+		Type:      Limit,
+		Hash:      common.HexToHash("0x01"),
+		Signature: &Signature{V: 27},
+		Nonce:     big.NewInt(1),
+		OrderID:   5,
+	}
+	legacy := legacyOrderItem{
+		Quantity:  order.Quantity,
+		Price:     order.Price,
+		Status:    order.Status,
+		Side:      order.Side,
+		Type:      order.Type,
+		Hash:      order.Hash,
+		Signature: order.Signature,
+		Nonce:     order.Nonce,
+		OrderID:   order.OrderID,
+	}
+	have, err := rlp.EncodeToBytes(&order)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := rlp.EncodeToBytes(&legacy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(have, want) {
+		t.Fatalf("encoding mismatch:\nhave %x\nwant %x", have, want)
+	}
+
+	order.TimeInForce, order.PostOnly, order.ExpireBlock = TimeInForceGTB, true, 100
+	enc, err := rlp.EncodeToBytes(&order)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded OrderItem
+	if err := rlp.DecodeBytes(enc, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.TimeInForce != TimeInForceGTB || !decoded.PostOnly || decoded.ExpireBlock != 100 {
+		t.Errorf("matching options lost: %+v", decoded)
+	}
+}
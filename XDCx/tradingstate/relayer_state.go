@@ -44,6 +44,25 @@ func IsResignedRelayer(relayer common.Address, statedb *state.StateDB) bool {
 	return statedb.GetState(common.HexToAddress(common.RelayerRegistrationSMC), locHash) != (common.Hash{})
 }
 
+// GetRelayerDeposit returns the deposit of the relayer, which pays the matching
+// fees as long as it stays above the locked fund.
+func GetRelayerDeposit(relayer common.Address, statedb *state.StateDB) *big.Int {
+	slot := RelayerMappingSlot["RELAYER_LIST"]
+	locBig := GetLocMappingAtKey(relayer.Hash(), slot)
+	locBig = new(big.Int).Add(locBig, RelayerStructMappingSlot["_deposit"])
+	locHash := common.BigToHash(locBig)
+	return statedb.GetState(common.HexToAddress(common.RelayerRegistrationSMC), locHash).Big()
+}
+
+// GetResignRequest returns the time the deposit of a resigned relayer can be
+// refunded, or zero if the relayer didn't resign.
+func GetResignRequest(relayer common.Address, statedb *state.StateDB) *big.Int {
+	slot := RelayerMappingSlot["RESIGN_REQUESTS"]
+	locBig := GetLocMappingAtKey(relayer.Hash(), slot)
+	locHash := common.BigToHash(locBig)
+	return statedb.GetState(common.HexToAddress(common.RelayerRegistrationSMC), locHash).Big()
+}
+
 func GetBaseTokenLength(relayer common.Address, statedb *state.StateDB) uint64 {
 	slot := RelayerMappingSlot["RELAYER_LIST"]
 	locBig := GetLocMappingAtKey(relayer.Hash(), slot)
@@ -62,6 +62,10 @@ type tradingExchanges struct {
 	liquidationPriceStates      map[common.Hash]*liquidationPriceState
 	liquidationPriceStatesDirty map[common.Hash]struct{}
 
+	triggerAbove *triggerBook
+	triggerBelow *triggerBook
+	expiry       *triggerBook
+
 	onDirty func(hash common.Hash) // Callback method to mark a state object newly dirty
 }
 
@@ -97,12 +101,18 @@ func (s *tradingExchanges) empty() bool {
 	if !common.EmptyHash(s.data.LiquidationPriceRoot) {
 		return false
 	}
+	if !common.EmptyHash(s.data.TriggerAboveRoot) || !common.EmptyHash(s.data.TriggerBelowRoot) {
+		return false
+	}
+	if !common.EmptyHash(s.data.ExpiryRoot) {
+		return false
+	}
 	return true
 }
 
 // newObject creates a state object.
 func newStateExchanges(db *TradingStateDB, hash common.Hash, data tradingExchangeObject, onDirty func(addr common.Hash)) *tradingExchanges {
-	return &tradingExchanges{
+	exchange := &tradingExchanges{
 		db:                          db,
 		orderBookHash:               hash,
 		data:                        data,
@@ -116,6 +126,22 @@ func newStateExchanges(db *TradingStateDB, hash common.Hash, data tradingExchang
 		liquidationPriceStatesDirty: make(map[common.Hash]struct{}),
 		onDirty:                     onDirty,
 	}
+	exchange.triggerAbove = newTriggerBook(exchange, TriggerAbove)
+	exchange.triggerBelow = newTriggerBook(exchange, TriggerBelow)
+	exchange.expiry = newTriggerBook(exchange, expiryIndex)
+	return exchange
+}
+
+// getTriggerBook returns the trigger orders waiting for the given condition.
+func (self *tradingExchanges) getTriggerBook(condition string) *triggerBook {
+	switch condition {
+	case TriggerAbove:
+		return self.triggerAbove
+	case TriggerBelow:
+		return self.triggerBelow
+	default:
+		return nil
+	}
 }
 
 // EncodeRLP implements rlp.Encoder.
@@ -357,6 +383,9 @@ func (self *tradingExchanges) deepCopy(db *TradingStateDB, onDirty func(hash com
 	for price := range self.liquidationPriceStatesDirty {
 		stateExchanges.liquidationPriceStatesDirty[price] = struct{}{}
 	}
+	stateExchanges.triggerAbove = self.triggerAbove.deepCopy(db, stateExchanges)
+	stateExchanges.triggerBelow = self.triggerBelow.deepCopy(db, stateExchanges)
+	stateExchanges.expiry = self.expiry.deepCopy(db, stateExchanges)
 	return stateExchanges
 }
 
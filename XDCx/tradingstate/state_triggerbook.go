@@ -0,0 +1,244 @@
+// Copyright (c) 2018 XDPoSChain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package tradingstate
+
+import (
+	"fmt"
+
+	"github.com/XinFinOrg/XDPoSChain/common"
+	"github.com/XinFinOrg/XDPoSChain/log"
+	"github.com/XinFinOrg/XDPoSChain/rlp"
+)
+
+// expiryIndex is the condition of the book indexing the good-til-block orders of
+// an order book by expire block.
+const expiryIndex = "EXPIRY"
+
+// triggerBook holds the trigger orders of an order book waiting for the epoch
+// price to cross their trigger price in one direction. Like the bid and ask
+// trees, it maps every trigger price to the list of order ids and quantities.
+// The expiry index maps every expire block to the list of the good-til-block
+// orders expiring there in the same way, with a unit amount per order.
+type triggerBook struct {
+	condition string
+	exchange  *tradingExchanges
+
+	trie Trie // storage trie, which becomes non-nil on first access
+
+	stateOrderLists      map[common.Hash]*stateOrderList
+	stateOrderListsDirty map[common.Hash]struct{}
+}
+
+func newTriggerBook(exchange *tradingExchanges, condition string) *triggerBook {
+	return &triggerBook{
+		condition:            condition,
+		exchange:             exchange,
+		stateOrderLists:      make(map[common.Hash]*stateOrderList),
+		stateOrderListsDirty: make(map[common.Hash]struct{}),
+	}
+}
+
+// root returns the field of the order book holding the root of the trie.
+func (self *triggerBook) root() *common.Hash {
+	switch self.condition {
+	case TriggerAbove:
+		return &self.exchange.data.TriggerAboveRoot
+	case expiryIndex:
+		return &self.exchange.data.ExpiryRoot
+	default:
+		return &self.exchange.data.TriggerBelowRoot
+	}
+}
+
+func (self *triggerBook) getTrie(db Database) Trie {
+	if self.trie == nil {
+		var err error
+		self.trie, err = db.OpenStorageTrie(self.exchange.orderBookHash, *self.root())
+		if err != nil {
+			self.trie, _ = db.OpenStorageTrie(self.exchange.orderBookHash, EmptyHash)
+			self.exchange.setError(fmt.Errorf("can't create trigger trie: %v", err))
+		}
+	}
+	return self.trie
+}
+
+// MarkStateOrderListDirty adds the specified object to the dirty map to avoid
+// costly state object cache iteration to find a handful of modified ones.
+func (self *triggerBook) MarkStateOrderListDirty(price common.Hash) {
+	self.stateOrderListsDirty[price] = struct{}{}
+	if self.exchange.onDirty != nil {
+		self.exchange.onDirty(self.exchange.Hash())
+		self.exchange.onDirty = nil
+	}
+}
+
+// getStateOrderList retrieves the list of orders at the given trigger price.
+// Returns nil if not found.
+func (self *triggerBook) getStateOrderList(db Database, price common.Hash) *stateOrderList {
+	// Prefer 'live' objects.
+	if obj := self.stateOrderLists[price]; obj != nil {
+		return obj
+	}
+	// Load the object from the database.
+	enc, err := self.getTrie(db).TryGet(price[:])
+	if len(enc) == 0 {
+		self.exchange.setError(err)
+		return nil
+	}
+	var data orderList
+	if err := rlp.DecodeBytes(enc, &data); err != nil {
+		log.Error("Failed to decode trigger order list object", "price", price, "err", err)
+		return nil
+	}
+	// Insert into the live set.
+	obj := newStateOrderList(self.exchange.db, self.condition, self.exchange.orderBookHash, price, data, self.MarkStateOrderListDirty)
+	self.stateOrderLists[price] = obj
+	return obj
+}
+
+func (self *triggerBook) createStateOrderList(db Database, price common.Hash) *stateOrderList {
+	newobj := newStateOrderList(self.exchange.db, self.condition, self.exchange.orderBookHash, price, orderList{Volume: Zero}, self.MarkStateOrderListDirty)
+	self.stateOrderLists[price] = newobj
+	self.stateOrderListsDirty[price] = struct{}{}
+	data, err := rlp.EncodeToBytes(newobj)
+	if err != nil {
+		panic(fmt.Errorf("can't encode trigger order list object at %x: %v", price[:], err))
+	}
+	self.exchange.setError(self.getTrie(db).TryUpdate(price[:], data))
+	if self.exchange.onDirty != nil {
+		self.exchange.onDirty(self.exchange.Hash())
+		self.exchange.onDirty = nil
+	}
+	return newobj
+}
+
+func (self *triggerBook) removeStateOrderList(db Database, stateOrderList *stateOrderList) {
+	self.exchange.setError(self.getTrie(db).TryDelete(stateOrderList.price[:]))
+}
+
+// getNextStateOrderList returns the list of orders which triggers first as the
+// price moves: the lowest trigger price of orders waiting for the price to rise,
+// the highest one of orders waiting for the price to fall. For the expiry index
+// it is the list of orders expiring first.
+func (self *triggerBook) getNextStateOrderList(db Database) (common.Hash, *stateOrderList) {
+	var (
+		encKey, encValue []byte
+		err              error
+	)
+	if self.condition == TriggerBelow {
+		encKey, encValue, err = self.getTrie(db).TryGetBestRightKeyAndValue()
+	} else {
+		encKey, encValue, err = self.getTrie(db).TryGetBestLeftKeyAndValue()
+	}
+	if err != nil {
+		log.Error("Failed find next trigger price", "orderbook", self.exchange.orderBookHash.Hex(), "condition", self.condition, "err", err)
+		return EmptyHash, nil
+	}
+	if len(encKey) == 0 || len(encValue) == 0 {
+		return EmptyHash, nil
+	}
+	price := common.BytesToHash(encKey)
+	obj := self.stateOrderLists[price]
+	if obj == nil {
+		var data orderList
+		if err := rlp.DecodeBytes(encValue, &data); err != nil {
+			log.Error("Failed to decode trigger order list object", "price", price, "err", err)
+			return EmptyHash, nil
+		}
+		obj = newStateOrderList(self.exchange.db, self.condition, self.exchange.orderBookHash, price, data, self.MarkStateOrderListDirty)
+		self.stateOrderLists[price] = obj
+	}
+	if obj.empty() {
+		return EmptyHash, nil
+	}
+	return price, obj
+}
+
+// updateTrie writes cached storage modifications into the trigger trie.
+func (self *triggerBook) updateTrie(db Database) Trie {
+	tr := self.getTrie(db)
+	for price, orderList := range self.stateOrderLists {
+		if _, isDirty := self.stateOrderListsDirty[price]; isDirty {
+			delete(self.stateOrderListsDirty, price)
+			if orderList.empty() {
+				self.exchange.setError(tr.TryDelete(price[:]))
+				continue
+			}
+			if err := orderList.updateRoot(db); err != nil {
+				log.Warn("triggerBook updateTrie updateRoot", "err", err, "price", price, "condition", self.condition)
+			}
+			// Encoding []byte cannot fail, ok to ignore the error.
+			v, _ := rlp.EncodeToBytes(orderList)
+			self.exchange.setError(tr.TryUpdate(price[:], v))
+		}
+	}
+	return tr
+}
+
+// setRoot stores the root of the trigger trie in the order book. Empty tries
+// are stored as an empty hash, so that order books which never had trigger
+// orders keep their former encoding.
+func (self *triggerBook) setRoot(root common.Hash) {
+	if root == EmptyRoot {
+		root = EmptyHash
+	}
+	*self.root() = root
+}
+
+func (self *triggerBook) updateRoot(db Database) {
+	if self.trie == nil {
+		return
+	}
+	self.setRoot(self.updateTrie(db).Hash())
+}
+
+func (self *triggerBook) commitTrie(db Database) error {
+	if self.trie == nil {
+		return nil
+	}
+	self.updateTrie(db)
+	if self.exchange.dbErr != nil {
+		return self.exchange.dbErr
+	}
+	root, err := self.trie.Commit(func(leaf []byte, parent common.Hash) error {
+		var orderList orderList
+		if err := rlp.DecodeBytes(leaf, &orderList); err != nil {
+			return nil
+		}
+		if orderList.Root != EmptyRoot {
+			db.TrieDB().Reference(orderList.Root, parent)
+		}
+		return nil
+	})
+	if err == nil {
+		self.setRoot(root)
+	}
+	return err
+}
+
+func (self *triggerBook) deepCopy(db *TradingStateDB, exchange *tradingExchanges) *triggerBook {
+	book := newTriggerBook(exchange, self.condition)
+	if self.trie != nil {
+		book.trie = db.db.CopyTrie(self.trie)
+	}
+	for price, orderList := range self.stateOrderLists {
+		book.stateOrderLists[price] = orderList.deepCopy(db, book.MarkStateOrderListDirty)
+	}
+	for price := range self.stateOrderListsDirty {
+		book.stateOrderListsDirty[price] = struct{}{}
+	}
+	return book
+}
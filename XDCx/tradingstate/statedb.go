@@ -546,6 +546,9 @@ func (s *TradingStateDB) Finalise() {
 			stateObject.updateBidsRoot(s.db)
 			stateObject.updateOrdersRoot(s.db)
 			stateObject.updateLiquidationPriceRoot(s.db)
+			stateObject.triggerAbove.updateRoot(s.db)
+			stateObject.triggerBelow.updateRoot(s.db)
+			stateObject.expiry.updateRoot(s.db)
 			// Update the object in the main orderId trie.
 			s.updateStateExchangeObject(stateObject)
 			//delete(s.stateExhangeObjectsDirty, addr)
@@ -581,6 +584,15 @@ func (s *TradingStateDB) Commit() (root common.Hash, err error) {
 			if err := stateObject.CommitLiquidationPriceTrie(s.db); err != nil {
 				return EmptyHash, err
 			}
+			if err := stateObject.triggerAbove.commitTrie(s.db); err != nil {
+				return EmptyHash, err
+			}
+			if err := stateObject.triggerBelow.commitTrie(s.db); err != nil {
+				return EmptyHash, err
+			}
+			if err := stateObject.expiry.commitTrie(s.db); err != nil {
+				return EmptyHash, err
+			}
 			// Update the object in the main orderId trie.
 			s.updateStateExchangeObject(stateObject)
 			delete(s.stateExhangeObjectsDirty, addr)
@@ -604,6 +616,15 @@ func (s *TradingStateDB) Commit() (root common.Hash, err error) {
 		if exchange.LiquidationPriceRoot != EmptyRoot {
 			s.db.TrieDB().Reference(exchange.LiquidationPriceRoot, parent)
 		}
+		if !common.EmptyHash(exchange.TriggerAboveRoot) {
+			s.db.TrieDB().Reference(exchange.TriggerAboveRoot, parent)
+		}
+		if !common.EmptyHash(exchange.TriggerBelowRoot) {
+			s.db.TrieDB().Reference(exchange.TriggerBelowRoot, parent)
+		}
+		if !common.EmptyHash(exchange.ExpiryRoot) {
+			s.db.TrieDB().Reference(exchange.ExpiryRoot, parent)
+		}
 		return nil
 	})
 	log.Debug("Trading State Trie cache stats after commit", "root", root.Hex())
@@ -730,3 +751,220 @@ func (self *TradingStateDB) RemoveLiquidationPrice(orderBook common.Hash, price
 	})
 	return nil
 }
+
+// InsertTriggerOrder adds an order to the trigger book of the order book, where
+// it waits for the epoch price to cross its trigger price.
+func (self *TradingStateDB) InsertTriggerOrder(orderBook common.Hash, orderId common.Hash, order OrderItem) {
+	stateExchange := self.getStateExchangeObject(orderBook)
+	if stateExchange == nil {
+		stateExchange = self.createExchangeObject(orderBook)
+	}
+	triggerBook := stateExchange.getTriggerBook(order.TriggerCondition)
+	if triggerBook == nil || order.TriggerPrice == nil {
+		return
+	}
+	priceHash := common.BigToHash(order.TriggerPrice)
+	stateOrderList := triggerBook.getStateOrderList(self.db, priceHash)
+	if stateOrderList == nil || stateOrderList.empty() {
+		stateOrderList = triggerBook.createStateOrderList(self.db, priceHash)
+	}
+	self.journal = append(self.journal, insertTriggerOrder{
+		orderBook: orderBook,
+		orderId:   orderId,
+		order:     order,
+	})
+	stateExchange.createStateOrderObject(self.db, orderId, order)
+	stateOrderList.insertOrderItem(self.db, orderId, common.BigToHash(order.Quantity))
+	stateOrderList.AddVolume(order.Quantity)
+}
+
+// RemoveTriggerOrder removes an order waiting for its trigger price from the
+// trigger book of the order book.
+func (self *TradingStateDB) RemoveTriggerOrder(orderBook common.Hash, order *OrderItem) error {
+	orderIdHash := common.BigToHash(new(big.Int).SetUint64(order.OrderID))
+	stateExchange := self.getStateExchangeObject(orderBook)
+	if stateExchange == nil {
+		return fmt.Errorf("Order book not found : %s ", orderBook.Hex())
+	}
+	stateOrderItem := stateExchange.getStateOrderObject(self.db, orderIdHash)
+	if stateOrderItem == nil || stateOrderItem.empty() {
+		return fmt.Errorf("Order item empty  order book : %s , order id  : %s ", orderBook, orderIdHash.Hex())
+	}
+	if stateOrderItem.data.Hash != order.Hash {
+		return fmt.Errorf("Invalid order hash :  got : %s , expect : %s ", order.Hash.Hex(), stateOrderItem.data.Hash.Hex())
+	}
+	if stateOrderItem.data.UserAddress != order.UserAddress {
+		return fmt.Errorf("Error Order User Address mismatch when remove trigger order book : %s , order id  : %s , got : %s , expect : %s ", orderBook, orderIdHash.Hex(), stateOrderItem.data.UserAddress.Hex(), order.UserAddress.Hex())
+	}
+	triggerBook := stateExchange.getTriggerBook(stateOrderItem.data.TriggerCondition)
+	if triggerBook == nil || stateOrderItem.data.TriggerPrice == nil {
+		return fmt.Errorf("Order is not a trigger order : %s , order id  : %s ", orderBook, orderIdHash.Hex())
+	}
+	stateOrderList := triggerBook.getStateOrderList(self.db, common.BigToHash(stateOrderItem.data.TriggerPrice))
+	if stateOrderList == nil || stateOrderList.empty() {
+		return fmt.Errorf("Trigger order list empty  order book : %s , order id  : %s , trigger price  : %s ", orderBook, orderIdHash.Hex(), stateOrderItem.data.TriggerPrice)
+	}
+	currentAmount := new(big.Int).SetBytes(stateOrderList.GetOrderAmount(self.db, orderIdHash).Bytes())
+	if currentAmount.Sign() == 0 {
+		return fmt.Errorf("Order is not waiting for its trigger price : %s , order id  : %s ", orderBook, orderIdHash.Hex())
+	}
+	self.journal = append(self.journal, removeTriggerOrder{
+		orderBook: orderBook,
+		orderId:   orderIdHash,
+		order:     stateOrderItem.data,
+	})
+	stateOrderItem.setVolume(big.NewInt(0))
+	stateOrderList.subVolume(currentAmount)
+	stateOrderList.removeOrderItem(self.db, orderIdHash)
+	if stateOrderList.empty() {
+		triggerBook.removeStateOrderList(self.db, stateOrderList)
+	}
+	return nil
+}
+
+// IsWaitingTriggerOrder reports whether the given order is still waiting for
+// its trigger price.
+func (self *TradingStateDB) IsWaitingTriggerOrder(orderBook common.Hash, order *OrderItem) bool {
+	stateExchange := self.getStateExchangeObject(orderBook)
+	if stateExchange == nil || order.TriggerPrice == nil {
+		return false
+	}
+	triggerBook := stateExchange.getTriggerBook(order.TriggerCondition)
+	if triggerBook == nil {
+		return false
+	}
+	stateOrderList := triggerBook.getStateOrderList(self.db, common.BigToHash(order.TriggerPrice))
+	if stateOrderList == nil || stateOrderList.empty() {
+		return false
+	}
+	orderIdHash := common.BigToHash(new(big.Int).SetUint64(order.OrderID))
+	return !common.EmptyHash(stateOrderList.GetOrderAmount(self.db, orderIdHash))
+}
+
+// GetTriggeredOrder returns the next order of the order book whose trigger price
+// has been crossed by the given price. Orders triggered by a rising price come
+// first, then the ones triggered by a falling price, each by trigger price and
+// then by order id.
+func (self *TradingStateDB) GetTriggeredOrder(orderBook common.Hash, price *big.Int) (OrderItem, bool) {
+	stateExchange := self.getStateExchangeObject(orderBook)
+	if stateExchange == nil || price == nil || price.Sign() <= 0 {
+		return EmptyOrder, false
+	}
+	for _, triggerBook := range []*triggerBook{stateExchange.triggerAbove, stateExchange.triggerBelow} {
+		priceHash, stateOrderList := triggerBook.getNextStateOrderList(self.db)
+		if stateOrderList == nil {
+			continue
+		}
+		triggerPrice := new(big.Int).SetBytes(priceHash[:])
+		if triggerBook.condition == TriggerAbove && price.Cmp(triggerPrice) < 0 {
+			continue
+		}
+		if triggerBook.condition == TriggerBelow && price.Cmp(triggerPrice) > 0 {
+			continue
+		}
+		key, _, err := stateOrderList.getTrie(self.db).TryGetBestLeftKeyAndValue()
+		if err != nil || len(key) == 0 {
+			log.Error("Failed to find triggered order", "orderBook", orderBook.Hex(), "triggerPrice", triggerPrice, "err", err)
+			continue
+		}
+		order := self.GetOrder(orderBook, common.BytesToHash(key))
+		if order.Quantity == nil || order.Quantity.Sign() == 0 {
+			continue
+		}
+		return order, true
+	}
+	return EmptyOrder, false
+}
+
+// InsertExpiringOrder indexes a good-til-block order of the order book by its
+// expire block, so that it can be cancelled once expired. The order book itself
+// is indexed by the expire block in ExpiringOrderBooks. Indexing an order which
+// is already indexed is a noop.
+func (self *TradingStateDB) InsertExpiringOrder(orderBook common.Hash, orderId common.Hash, expireBlock uint64) {
+	if orderBook != ExpiringOrderBooks {
+		self.InsertExpiringOrder(ExpiringOrderBooks, orderBook, expireBlock)
+	}
+	stateExchange := self.getStateExchangeObject(orderBook)
+	if stateExchange == nil {
+		stateExchange = self.createExchangeObject(orderBook)
+	}
+	blockHash := common.BigToHash(new(big.Int).SetUint64(expireBlock))
+	stateOrderList := stateExchange.expiry.getStateOrderList(self.db, blockHash)
+	if stateOrderList == nil || stateOrderList.empty() {
+		stateOrderList = stateExchange.expiry.createStateOrderList(self.db, blockHash)
+	} else if !common.EmptyHash(stateOrderList.GetOrderAmount(self.db, orderId)) {
+		return
+	}
+	self.journal = append(self.journal, insertExpiringOrder{
+		orderBook:   orderBook,
+		orderId:     orderId,
+		expireBlock: expireBlock,
+	})
+	stateOrderList.insertOrderItem(self.db, orderId, common.BigToHash(One))
+	stateOrderList.AddVolume(One)
+}
+
+// RemoveExpiringOrder removes an order from the expiry index of the order book.
+func (self *TradingStateDB) RemoveExpiringOrder(orderBook common.Hash, orderId common.Hash, expireBlock uint64) error {
+	stateExchange := self.getStateExchangeObject(orderBook)
+	if stateExchange == nil {
+		return fmt.Errorf("Order book not found : %s ", orderBook.Hex())
+	}
+	blockHash := common.BigToHash(new(big.Int).SetUint64(expireBlock))
+	stateOrderList := stateExchange.expiry.getStateOrderList(self.db, blockHash)
+	if stateOrderList == nil || stateOrderList.empty() {
+		return fmt.Errorf("Expiring order list empty  order book : %s , order id  : %s , expire block  : %d ", orderBook, orderId.Hex(), expireBlock)
+	}
+	if common.EmptyHash(stateOrderList.GetOrderAmount(self.db, orderId)) {
+		return fmt.Errorf("Order is not indexed by expire block : %s , order id  : %s , expire block  : %d ", orderBook, orderId.Hex(), expireBlock)
+	}
+	self.journal = append(self.journal, removeExpiringOrder{
+		orderBook:   orderBook,
+		orderId:     orderId,
+		expireBlock: expireBlock,
+	})
+	stateOrderList.subVolume(One)
+	stateOrderList.removeOrderItem(self.db, orderId)
+	if stateOrderList.empty() {
+		stateExchange.expiry.removeStateOrderList(self.db, stateOrderList)
+	}
+	return nil
+}
+
+// GetExpiredOrder returns the id and the expire block of the next indexed order
+// of the order book which expired before the given block, by expire block and
+// then by order id. The order may have been filled or cancelled since it was
+// indexed.
+func (self *TradingStateDB) GetExpiredOrder(orderBook common.Hash, number uint64) (common.Hash, uint64, bool) {
+	stateExchange := self.getStateExchangeObject(orderBook)
+	if stateExchange == nil {
+		return EmptyHash, 0, false
+	}
+	blockHash, stateOrderList := stateExchange.expiry.getNextStateOrderList(self.db)
+	if stateOrderList == nil {
+		return EmptyHash, 0, false
+	}
+	expireBlock := new(big.Int).SetBytes(blockHash[:]).Uint64()
+	if expireBlock >= number {
+		return EmptyHash, 0, false
+	}
+	key, _, err := stateOrderList.getTrie(self.db).TryGetBestLeftKeyAndValue()
+	if err != nil || len(key) == 0 {
+		log.Error("Failed to find expired order", "orderBook", orderBook.Hex(), "expireBlock", expireBlock, "err", err)
+		return EmptyHash, 0, false
+	}
+	return common.BytesToHash(key), expireBlock, true
+}
+
+// GetExpiredOrderBook returns the next order book indexed with good-til-block
+// orders which expired before the given block, and the expire block it is
+// indexed at. The orders may have been filled or cancelled since.
+func (self *TradingStateDB) GetExpiredOrderBook(number uint64) (common.Hash, uint64, bool) {
+	return self.GetExpiredOrder(ExpiringOrderBooks, number)
+}
+
+// RemoveExpiredOrderBook removes an order book from the expiry index of the
+// order books.
+func (self *TradingStateDB) RemoveExpiredOrderBook(orderBook common.Hash, expireBlock uint64) error {
+	return self.RemoveExpiringOrder(ExpiringOrderBooks, orderBook, expireBlock)
+}
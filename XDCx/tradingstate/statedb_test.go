@@ -17,10 +17,12 @@
 package tradingstate
 
 import (
+	"bytes"
 	"fmt"
 	"github.com/XinFinOrg/XDPoSChain/common"
 	"github.com/XinFinOrg/XDPoSChain/common/math"
 	"github.com/XinFinOrg/XDPoSChain/core/rawdb"
+	"github.com/XinFinOrg/XDPoSChain/rlp"
 	"math/big"
 	"testing"
 )
@@ -300,3 +302,186 @@ func TestDumpState(t *testing.T) {
 	fmt.Println("bidTrie", bidTrie)
 	db.Close()
 }
+
+func TestTriggerOrders(t *testing.T) {
+	orderBook := common.StringToHash("BTC/XDC")
+	sig := &Signature{V: 1, R: common.HexToHash("111111"), S: common.HexToHash("222222222222")}
+	orderItems := []OrderItem{
+		{OrderID: 1, Quantity: big.NewInt(10), Price: big.NewInt(110), Side: Bid, Type: Limit, Hash: common.HexToHash("01"), TriggerPrice: big.NewInt(100), TriggerCondition: TriggerAbove, Signature: sig},
+		{OrderID: 2, Quantity: big.NewInt(20), Side: Bid, Type: Market, Hash: common.HexToHash("02"), TriggerPrice: big.NewInt(120), TriggerCondition: TriggerAbove, Signature: sig},
+		{OrderID: 3, Quantity: big.NewInt(30), Side: Ask, Type: Market, Hash: common.HexToHash("03"), TriggerPrice: big.NewInt(80), TriggerCondition: TriggerBelow, Signature: sig},
+		{OrderID: 4, Quantity: big.NewInt(40), Side: Ask, Type: Market, Hash: common.HexToHash("04"), TriggerPrice: big.NewInt(100), TriggerCondition: TriggerAbove, Signature: sig},
+	}
+	// Create an empty statedb database
+	db := rawdb.NewMemoryDatabase()
+	stateCache := NewDatabase(db)
+	statedb, _ := New(common.Hash{}, stateCache)
+	for i := range orderItems {
+		orderIdHash := common.BigToHash(new(big.Int).SetUint64(orderItems[i].OrderID))
+		statedb.InsertTriggerOrder(orderBook, orderIdHash, orderItems[i])
+	}
+	root := statedb.IntermediateRoot()
+	statedb.Commit()
+	stateCache.TrieDB().Reference(root, common.Hash{})
+	statedb, err := New(root, stateCache)
+	if err != nil {
+		t.Fatalf("Error when get trie in database: %s , err: %v", root.Hex(), err)
+	}
+	if bestBid, _ := statedb.GetBestBidPrice(orderBook); bestBid.Sign() != 0 {
+		t.Fatalf("trigger orders must not be in the order book, got best bid %v", bestBid)
+	}
+	if _, ok := statedb.GetTriggeredOrder(orderBook, big.NewInt(90)); ok {
+		t.Fatalf("no order should be triggered at price 90")
+	}
+
+	// the oldest order at the lowest trigger price goes first
+	order, ok := statedb.GetTriggeredOrder(orderBook, big.NewInt(125))
+	if !ok || order.OrderID != 1 {
+		t.Fatalf("wrong triggered order: got %d, want 1", order.OrderID)
+	}
+	if !statedb.IsWaitingTriggerOrder(orderBook, &order) {
+		t.Fatalf("order %d should be waiting for its trigger price", order.OrderID)
+	}
+
+	// removing an order can be reverted
+	snap := statedb.Snapshot()
+	if err := statedb.RemoveTriggerOrder(orderBook, &order); err != nil {
+		t.Fatalf("failed to remove trigger order: %v", err)
+	}
+	if statedb.IsWaitingTriggerOrder(orderBook, &order) {
+		t.Fatalf("order %d should not be waiting any more", order.OrderID)
+	}
+	statedb.RevertToSnapshot(snap)
+	if got, _ := statedb.GetTriggeredOrder(orderBook, big.NewInt(125)); got.OrderID != 1 {
+		t.Fatalf("wrong triggered order after revert: got %d, want 1", got.OrderID)
+	}
+
+	// orders leave the trigger book in order
+	for _, want := range []uint64{1, 4, 2} {
+		order, ok := statedb.GetTriggeredOrder(orderBook, big.NewInt(125))
+		if !ok || order.OrderID != want {
+			t.Fatalf("wrong triggered order: got %d, want %d", order.OrderID, want)
+		}
+		if err := statedb.RemoveTriggerOrder(orderBook, &order); err != nil {
+			t.Fatalf("failed to remove trigger order: %v", err)
+		}
+	}
+	if _, ok := statedb.GetTriggeredOrder(orderBook, big.NewInt(125)); ok {
+		t.Fatalf("no order should be triggered at price 125 any more")
+	}
+	order, ok = statedb.GetTriggeredOrder(orderBook, big.NewInt(80))
+	if !ok || order.OrderID != 3 {
+		t.Fatalf("wrong triggered order: got %d, want 3", order.OrderID)
+	}
+	db.Close()
+}
+
+func TestExpiringOrders(t *testing.T) {
+	orderBook := common.StringToHash("BTC/XDC")
+	orderId := func(id uint64) common.Hash { return common.BigToHash(new(big.Int).SetUint64(id)) }
+	// Create an empty statedb database
+	db := rawdb.NewMemoryDatabase()
+	stateCache := NewDatabase(db)
+	statedb, _ := New(common.Hash{}, stateCache)
+	statedb.InsertExpiringOrder(orderBook, orderId(3), 20)
+	statedb.InsertExpiringOrder(orderBook, orderId(2), 10)
+	statedb.InsertExpiringOrder(orderBook, orderId(1), 10)
+	statedb.InsertExpiringOrder(orderBook, orderId(1), 10)
+	root := statedb.IntermediateRoot()
+	statedb.Commit()
+	stateCache.TrieDB().Reference(root, common.Hash{})
+	statedb, err := New(root, stateCache)
+	if err != nil {
+		t.Fatalf("Error when get trie in database: %s , err: %v", root.Hex(), err)
+	}
+	if _, _, ok := statedb.GetExpiredOrder(orderBook, 10); ok {
+		t.Fatalf("no order should be expired at block 10")
+	}
+
+	// removing an order can be reverted
+	snap := statedb.Snapshot()
+	if err := statedb.RemoveExpiringOrder(orderBook, orderId(1), 10); err != nil {
+		t.Fatalf("failed to remove expiring order: %v", err)
+	}
+	if err := statedb.RemoveExpiringOrder(orderBook, orderId(1), 10); err == nil {
+		t.Fatalf("removing an order which is not indexed should fail")
+	}
+	statedb.RevertToSnapshot(snap)
+
+	// orders expire by expire block, then by order id
+	for _, want := range []uint64{1, 2, 3} {
+		id, expireBlock, ok := statedb.GetExpiredOrder(orderBook, 21)
+		if !ok || id != orderId(want) {
+			t.Fatalf("wrong expired order: got %x, want %d", id, want)
+		}
+		if err := statedb.RemoveExpiringOrder(orderBook, id, expireBlock); err != nil {
+			t.Fatalf("failed to remove expiring order: %v", err)
+		}
+	}
+	if _, _, ok := statedb.GetExpiredOrder(orderBook, 21); ok {
+		t.Fatalf("no order should be expired any more")
+	}
+	db.Close()
+}
+
+func TestExpiringOrderBooks(t *testing.T) {
+	btc, eth := common.StringToHash("BTC/XDC"), common.StringToHash("ETH/XDC")
+	orderId := func(id uint64) common.Hash { return common.BigToHash(new(big.Int).SetUint64(id)) }
+	// Create an empty statedb database
+	db := rawdb.NewMemoryDatabase()
+	statedb, _ := New(common.Hash{}, NewDatabase(db))
+	statedb.InsertExpiringOrder(eth, orderId(1), 10)
+	statedb.InsertExpiringOrder(btc, orderId(2), 20)
+	statedb.InsertExpiringOrder(btc, orderId(3), 10)
+	statedb.InsertExpiringOrder(eth, orderId(4), 10)
+
+	if _, _, ok := statedb.GetExpiredOrderBook(10); ok {
+		t.Fatalf("no order book should be expired at block 10")
+	}
+	// order books are indexed once per expire block, by expire block then by hash
+	want := []struct {
+		orderBook   common.Hash
+		expireBlock uint64
+	}{{btc, 10}, {eth, 10}, {btc, 20}}
+	if bytes.Compare(eth[:], btc[:]) < 0 {
+		want[0].orderBook, want[1].orderBook = eth, btc
+	}
+	for _, w := range want {
+		orderBook, expireBlock, ok := statedb.GetExpiredOrderBook(21)
+		if !ok || orderBook != w.orderBook || expireBlock != w.expireBlock {
+			t.Fatalf("wrong expired order book: got %x at %d, want %x at %d", orderBook, expireBlock, w.orderBook, w.expireBlock)
+		}
+		if err := statedb.RemoveExpiredOrderBook(orderBook, expireBlock); err != nil {
+			t.Fatalf("failed to remove expired order book: %v", err)
+		}
+	}
+	if _, _, ok := statedb.GetExpiredOrderBook(21); ok {
+		t.Fatalf("no order book should be expired any more")
+	}
+	db.Close()
+}
+
+func TestTriggerRootsLegacyEncoding(t *testing.T) {
+	type legacyTradingExchangeObject struct {
+		Nonce                  uint64
+		LastPrice              *big.Int
+		MediumPriceBeforeEpoch *big.Int
+		MediumPrice            *big.Int
+		TotalQuantity          *big.Int
+		LendingCount           *big.Int
+		AskRoot                common.Hash
+		BidRoot                common.Hash
+		OrderRoot              common.Hash
+		LiquidationPriceRoot   common.Hash
+	}
+	exchange := tradingExchangeObject{Nonce: 1, LastPrice: big.NewInt(2), AskRoot: common.HexToHash("03")}
+	legacy := legacyTradingExchangeObject{Nonce: 1, LastPrice: big.NewInt(2), AskRoot: common.HexToHash("03")}
+	got, err := rlp.EncodeToBytes(exchange)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := rlp.EncodeToBytes(legacy)
+	if !bytes.Equal(got, want) {
+		t.Fatalf("encoding of order book without trigger orders changed: got %x, want %x", got, want)
+	}
+}
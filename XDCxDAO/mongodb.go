@@ -834,6 +834,13 @@ func (db *MongoDatabase) EnsureIndexes() error {
 	return nil
 }
 
+// Ping checks that the database answers.
+func (db *MongoDatabase) Ping() error {
+	sc := db.Session.Copy()
+	defer sc.Close()
+	return sc.Ping()
+}
+
 func (db *MongoDatabase) Close() error {
 	return db.Close()
 }
@@ -0,0 +1,103 @@
+package XDCxlending
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/XinFinOrg/XDPoSChain/XDCxlending/lendingstate"
+	"github.com/XinFinOrg/XDPoSChain/common"
+	"github.com/XinFinOrg/XDPoSChain/consensus"
+	"github.com/XinFinOrg/XDPoSChain/core/state"
+	"github.com/XinFinOrg/XDPoSChain/core/types"
+)
+
+// TradeHealth tells how close an open lending trade is to being liquidated at
+// a collateral price, following the rules applied by ProcessLiquidationData.
+type TradeHealth struct {
+	CollateralPrice *big.Int // price of the collateral in lending token used for the check, nil if unknown
+	CollateralRatio *big.Int // value of the locked collateral over the loan, in percent like the deposit rate
+	ExpiresIn       uint64   // seconds left until the trade is repaid or liquidated by time
+	Expired         bool     // whether the trade will be closed at the next liquidation block
+
+	Liquidatable bool     // whether the trade will be liquidated by price at the next liquidation block
+	TopUpAmount  *big.Int // collateral the auto top-up deposits, nil if it would not be applied
+	TopUpFunded  bool     // whether the borrower has the balance to pay TopUpAmount
+	RecallAmount *big.Int // collateral the auto recall releases, nil if it would not be applied
+}
+
+// TopUpAmount returns the collateral that AutoTopUp deposits into a trade whose
+// liquidation price is above the collateral price, together with the resulting
+// liquidation price which is 90% of the collateral price.
+func TopUpAmount(trade *lendingstate.LendingTrade, collateralPrice *big.Int) (amount, liquidationPrice *big.Int) {
+	// newLiquidationPrice = currentPrice * 90%
+	liquidationPrice = new(big.Int).Mul(collateralPrice, common.RateTopUp)
+	liquidationPrice = new(big.Int).Div(liquidationPrice, common.BaseTopUp)
+	// newLockedAmount = CollateralLockedAmount *  LiquidationPrice / newLiquidationPrice
+	newLockedAmount := new(big.Int).Mul(trade.CollateralLockedAmount, trade.LiquidationPrice)
+	newLockedAmount = new(big.Int).Div(newLockedAmount, liquidationPrice)
+
+	return new(big.Int).Sub(newLockedAmount, trade.CollateralLockedAmount), liquidationPrice
+}
+
+// RecallAmount returns the collateral that ProcessRecallLendingTrade releases
+// to the borrower when the liquidation price of the trade is raised to the
+// given one.
+func RecallAmount(trade *lendingstate.LendingTrade, liquidationPrice *big.Int) *big.Int {
+	newLockedAmount := new(big.Int).Mul(trade.CollateralLockedAmount, trade.LiquidationPrice)
+	newLockedAmount = new(big.Int).Div(newLockedAmount, liquidationPrice)
+	return new(big.Int).Sub(trade.CollateralLockedAmount, newLockedAmount)
+}
+
+// GetTradeHealth evaluates an open lending trade against the collateral price,
+// which is usually the one returned by GetCollateralPrices at the header but
+// can be any price to simulate its moves. The balances and the collateral
+// rates are read from statedb. As the liquidation pass skips the pairs without
+// a price, only the expiry is evaluated if collateralPrice is nil or zero.
+func (l *Lending) GetTradeHealth(header *types.Header, chain consensus.ChainContext, statedb *state.StateDB, trade *lendingstate.LendingTrade, collateralPrice *big.Int) (*TradeHealth, error) {
+	health := &TradeHealth{}
+	// trades are repaid once their liquidation time is before the block time
+	if now := header.Time.Uint64(); trade.LiquidationTime > now {
+		health.ExpiresIn = trade.LiquidationTime - now
+	} else {
+		health.Expired = true
+	}
+	if collateralPrice == nil || collateralPrice.Sign() <= 0 {
+		return health, nil
+	}
+	collateralTokenDecimal, err := l.XDCx.GetTokenDecimal(chain, statedb, trade.CollateralToken)
+	if err != nil || collateralTokenDecimal == nil || collateralTokenDecimal.Sign() == 0 {
+		return nil, fmt.Errorf("fail to get tokenDecimal. Token: %v . Err: %v", trade.CollateralToken.Hex(), err)
+	}
+	health.CollateralPrice = collateralPrice
+	health.CollateralRatio = new(big.Int)
+	// collateralRatio = CollateralLockedAmount * collateralPrice * 100 / (Amount * collateralTokenDecimal)
+	if trade.Amount != nil && trade.Amount.Sign() > 0 {
+		value := new(big.Int).Mul(trade.CollateralLockedAmount, collateralPrice)
+		value = new(big.Int).Mul(value, big.NewInt(100))
+		health.CollateralRatio = value.Div(value, new(big.Int).Mul(trade.Amount, collateralTokenDecimal))
+	}
+	if collateralPrice.Cmp(trade.LiquidationPrice) < 0 {
+		health.Liquidatable = true
+		if trade.AutoTopUp {
+			amount, _ := TopUpAmount(trade, collateralPrice)
+			health.TopUpAmount = amount
+			health.TopUpFunded = lendingstate.GetTokenBalance(trade.Borrower, trade.CollateralToken, statedb).Cmp(amount) >= 0
+			health.Liquidatable = !health.TopUpFunded
+		}
+		return health, nil
+	}
+	if trade.AutoTopUp {
+		depositRate, liquidationRate, recallRate := lendingstate.GetCollateralDetail(statedb, trade.CollateralToken)
+		if depositRate.Sign() == 0 || recallRate.Sign() == 0 {
+			return health, nil
+		}
+		recallPrice := new(big.Int).Mul(collateralPrice, common.BaseRecall)
+		recallPrice = new(big.Int).Div(recallPrice, recallRate)
+		liquidationPrice := new(big.Int).Mul(collateralPrice, liquidationRate)
+		liquidationPrice = new(big.Int).Div(liquidationPrice, depositRate)
+		if recallPrice.Cmp(trade.LiquidationPrice) > 0 && liquidationPrice.Cmp(trade.LiquidationPrice) > 0 {
+			health.RecallAmount = RecallAmount(trade, liquidationPrice)
+		}
+	}
+	return health, nil
+}
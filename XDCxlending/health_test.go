@@ -0,0 +1,95 @@
+package XDCxlending
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/XinFinOrg/XDPoSChain/XDCx"
+	"github.com/XinFinOrg/XDPoSChain/XDCxlending/lendingstate"
+	"github.com/XinFinOrg/XDPoSChain/common"
+	"github.com/XinFinOrg/XDPoSChain/core/rawdb"
+	"github.com/XinFinOrg/XDPoSChain/core/state"
+	"github.com/XinFinOrg/XDPoSChain/core/types"
+)
+
+func TestGetTradeHealth(t *testing.T) {
+	l := New(XDCx.New(&XDCx.DefaultConfig))
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()))
+	borrower := common.HexToAddress("0xDeE6238780f98c0ca2c2C28453149bEA49a3Abc9")
+	lendingToken := common.HexToAddress("0xd9bb01454c85247B2ef35BB5BE57384cC275a8cf") // USD
+
+	// XDC collateral: depositRate = 150%, liquidationRate = 110%, recallRate = 200%
+	collateralState := lendingstate.GetLocMappingAtKey(common.XDCNativeAddressBinary.Hash(), lendingstate.CollateralMapSlot)
+	for slot, rate := range map[string]int64{"depositRate": 150, "liquidationRate": 110, "recallRate": 200} {
+		loc := state.GetLocOfStructElement(collateralState, lendingstate.CollateralStructSlots[slot])
+		statedb.SetState(common.HexToAddress(common.LendingRegistrationSMC), loc, common.BigToHash(big.NewInt(rate)))
+	}
+	// 1000 USD lent against 1500 XDC when 1 XDC = 1 USD
+	trade := &lendingstate.LendingTrade{
+		Borrower:               borrower,
+		LendingToken:           lendingToken,
+		CollateralToken:        common.XDCNativeAddressBinary,
+		Amount:                 new(big.Int).Mul(big.NewInt(1000), common.BasePrice),
+		CollateralLockedAmount: new(big.Int).Mul(big.NewInt(1500), common.BasePrice),
+		LiquidationPrice:       new(big.Int).Div(new(big.Int).Mul(common.BasePrice, big.NewInt(110)), big.NewInt(150)),
+		LiquidationTime:        1600,
+		AutoTopUp:              true,
+	}
+	header := &types.Header{Number: big.NewInt(950), Time: big.NewInt(1000)}
+	price := func(cents int64) *big.Int {
+		return new(big.Int).Div(new(big.Int).Mul(common.BasePrice, big.NewInt(cents)), big.NewInt(100))
+	}
+
+	// Healthy at the opening price
+	health, err := l.GetTradeHealth(header, nil, statedb, trade, price(100))
+	if err != nil {
+		t.Fatalf("failed to get trade health: %v", err)
+	}
+	if health.CollateralRatio.Int64() != 150 || health.ExpiresIn != 600 || health.Expired || health.Liquidatable || health.TopUpAmount != nil || health.RecallAmount != nil {
+		t.Errorf("healthy trade mismatch: %+v", health)
+	}
+	// Below the liquidation price without the balance to top up
+	health, err = l.GetTradeHealth(header, nil, statedb, trade, price(70))
+	if err != nil {
+		t.Fatalf("failed to get trade health: %v", err)
+	}
+	if health.CollateralRatio.Int64() != 105 || !health.Liquidatable || health.TopUpFunded || health.TopUpAmount == nil || health.TopUpAmount.Sign() <= 0 {
+		t.Errorf("underwater trade mismatch: %+v", health)
+	}
+	// The top up brings the liquidation price to 90% of the collateral price
+	amount, liquidationPrice := TopUpAmount(trade, price(70))
+	if amount.Cmp(health.TopUpAmount) != 0 || liquidationPrice.Cmp(price(63)) != 0 {
+		t.Errorf("top up mismatch: have %v at %v, want %v at %v", amount, liquidationPrice, health.TopUpAmount, price(63))
+	}
+	newLockedAmount := new(big.Int).Add(trade.CollateralLockedAmount, amount)
+	newLiquidationPrice := new(big.Int).Div(new(big.Int).Mul(trade.LiquidationPrice, trade.CollateralLockedAmount), newLockedAmount)
+	if diff := new(big.Int).Sub(liquidationPrice, newLiquidationPrice); diff.CmpAbs(common.Big1) > 0 {
+		t.Errorf("topped up liquidation price mismatch: have %v, want %v", newLiquidationPrice, liquidationPrice)
+	}
+	// Topped up instead of liquidated once the borrower can afford it
+	statedb.SetBalance(borrower, amount)
+	health, err = l.GetTradeHealth(header, nil, statedb, trade, price(70))
+	if err != nil {
+		t.Fatalf("failed to get trade health: %v", err)
+	}
+	if health.Liquidatable || !health.TopUpFunded {
+		t.Errorf("funded top up mismatch: %+v", health)
+	}
+	// Half of the collateral is recalled when the price doubles
+	health, err = l.GetTradeHealth(header, nil, statedb, trade, price(200))
+	if err != nil {
+		t.Fatalf("failed to get trade health: %v", err)
+	}
+	if want := new(big.Int).Mul(big.NewInt(750), common.BasePrice); health.RecallAmount == nil || health.RecallAmount.Cmp(want) != 0 {
+		t.Errorf("recall amount mismatch: have %v, want %v", health.RecallAmount, want)
+	}
+	// Only the expiry is known without a price
+	header.Time = big.NewInt(1600)
+	health, err = l.GetTradeHealth(header, nil, statedb, trade, nil)
+	if err != nil {
+		t.Fatalf("failed to get trade health: %v", err)
+	}
+	if !health.Expired || health.ExpiresIn != 0 || health.CollateralPrice != nil || health.Liquidatable {
+		t.Errorf("expired trade mismatch: %+v", health)
+	}
+}
@@ -1100,14 +1100,7 @@ func (l *Lending) AutoTopUp(statedb *state.StateDB, tradingState *tradingstate.T
 	if currentPrice.Cmp(lendingTrade.LiquidationPrice) >= 0 {
 		return nil, fmt.Errorf("CurrentPrice is still higher than or equal to LiquidationPrice. current price: %v  , liquidation price : %v  ", currentPrice, lendingTrade.LiquidationPrice)
 	}
-	// newLiquidationPrice = currentPrice * 90%
-	newLiquidationPrice := new(big.Int).Mul(currentPrice, common.RateTopUp)
-	newLiquidationPrice = new(big.Int).Div(newLiquidationPrice, common.BaseTopUp)
-	// newLockedAmount = CollateralLockedAmount *  LiquidationPrice / newLiquidationPrice
-	newLockedAmount := new(big.Int).Mul(lendingTrade.CollateralLockedAmount, lendingTrade.LiquidationPrice)
-	newLockedAmount = new(big.Int).Div(newLockedAmount, newLiquidationPrice)
-
-	requiredDepositAmount := new(big.Int).Sub(newLockedAmount, lendingTrade.CollateralLockedAmount)
+	requiredDepositAmount, _ := TopUpAmount(&lendingTrade, currentPrice)
 	tokenBalance := lendingstate.GetTokenBalance(lendingTrade.Borrower, lendingTrade.CollateralToken, statedb)
 	if tokenBalance.Cmp(requiredDepositAmount) < 0 {
 		return nil, fmt.Errorf("not enough balance to AutoTopUp. requiredDepositAmount: %v . tokenBalance: %v . Token: %s", requiredDepositAmount, tokenBalance, lendingTrade.CollateralToken.Hex())
@@ -1244,9 +1237,8 @@ func (l *Lending) ProcessRecallLendingTrade(lendingStateDB *lendingstate.Lending
 	if newLiquidationPrice.Cmp(lendingTrade.LiquidationPrice) <= 0 {
 		return fmt.Errorf("New liquidation price must higher than  old liquidation price. current liquidation price: %v  , new liquidation price : %v  ", lendingTrade.LiquidationPrice, newLiquidationPrice), true, nil
 	}
-	newLockedAmount := new(big.Int).Mul(lendingTrade.CollateralLockedAmount, lendingTrade.LiquidationPrice)
-	newLockedAmount = new(big.Int).Div(newLockedAmount, newLiquidationPrice)
-	recallAmount := new(big.Int).Sub(lendingTrade.CollateralLockedAmount, newLockedAmount)
+	recallAmount := RecallAmount(&lendingTrade, newLiquidationPrice)
+	newLockedAmount := new(big.Int).Sub(lendingTrade.CollateralLockedAmount, recallAmount)
 	log.Debug("ProcessRecallLendingTrade", "newLockedAmount", newLockedAmount, "recallAmount", recallAmount, "oldLiquidationPrice", lendingTrade.LiquidationPrice, "newLiquidationPrice", newLiquidationPrice)
 	err := tradingStateDb.RemoveLiquidationPrice(tradingstate.GetTradingOrderBookHash(lendingTrade.CollateralToken, lendingTrade.LendingToken), lendingTrade.LiquidationPrice, lendingBook, lendingTrade.TradeId)
 	if err != nil {
@@ -250,6 +250,22 @@ func (b *SimulatedBackend) ForEachStorageAt(ctx context.Context, contract common
 	return nil
 }
 
+// HeaderByNumber returns a block header from the current canonical chain. If
+// number is nil, the latest known header is returned.
+func (b *SimulatedBackend) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if number == nil {
+		return b.blockchain.CurrentHeader(), nil
+	}
+	header := b.blockchain.GetHeaderByNumber(number.Uint64())
+	if header == nil {
+		return nil, XDPoSChain.NotFound
+	}
+	return header, nil
+}
+
 // TransactionReceipt returns the receipt of a transaction.
 func (b *SimulatedBackend) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
 	receipt, _, _, _ := core.GetReceipt(b.database, txHash)
@@ -408,6 +424,20 @@ func (b *SimulatedBackend) SendTransaction(ctx context.Context, tx *types.Transa
 	if tx.Nonce() != nonce {
 		panic(fmt.Errorf("invalid transaction nonce: got %d, want %d", tx.Nonce(), nonce))
 	}
+	// Check the transactions paid by the TRC21 issuer like the transaction pool,
+	// reporting an exhausted fee capacity instead of failing the block.
+	if to := tx.To(); to != nil {
+		if capacity, ok := state.GetTRC21FeeCapacityFromState(b.pendingState)[*to]; ok {
+			if !state.ValidateTRC21Tx(b.pendingState, sender, *to, tx.Data()) {
+				return core.ErrInsufficientFunds
+			}
+			number := new(big.Int).Add(block.Number(), common.Big1)
+			fee := new(big.Int).Mul(common.GetTRC21GasPrice(number), new(big.Int).SetUint64(tx.Gas()))
+			if capacity.Cmp(fee) < 0 {
+				return &bind.TRC21CapacityError{Token: *to, Capacity: capacity, Fee: fee}
+			}
+		}
+	}
 
 	// Include tx in chain.
 	blocks, receipts := core.GenerateChain(b.config, block, b.blockchain.Engine(), b.database, 1, func(number int, block *core.BlockGen) {
@@ -41,11 +41,22 @@ const (
 	LangObjC
 )
 
+// BindOptions tunes the generated bindings.
+type BindOptions struct {
+	TRC21 bool // Whether to generate the TRC21 fee helpers of the transactions (Go only)
+}
+
 // Bind generates a Go wrapper around a contract ABI. This wrapper isn't meant
 // to be used as is in client code, but rather as an intermediate struct which
 // enforces compile time type safety and naming convention opposed to having to
 // manually maintain hard coded strings that break on runtime.
 func Bind(types []string, abis []string, bytecodes []string, pkg string, lang Lang) (string, error) {
+	return BindWithOptions(types, abis, bytecodes, pkg, lang, BindOptions{})
+}
+
+// BindWithOptions generates a wrapper around a contract ABI like Bind, tuned by
+// the given options.
+func BindWithOptions(types []string, abis []string, bytecodes []string, pkg string, lang Lang, opts BindOptions) (string, error) {
 	// Process each individual contract requested binding
 	contracts := make(map[string]*tmplContract)
 
@@ -131,6 +142,7 @@ func Bind(types []string, abis []string, bytecodes []string, pkg string, lang La
 	data := &tmplData{
 		Package:   pkg,
 		Contracts: contracts,
+		TRC21:     opts.TRC21 && lang == LangGo,
 	}
 	buffer := new(bytes.Buffer)
 
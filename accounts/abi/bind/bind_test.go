@@ -867,3 +867,44 @@ func TestBindings(t *testing.T) {
 		t.Fatalf("failed to run binding test: %v\n%s", err, out)
 	}
 }
+
+// Tests that the TRC21 fee helpers generated with the TRC21 option compile
+// against the bind package of this module.
+func TestTRC21Bindings(t *testing.T) {
+	gocmd := runtime.GOROOT() + "/bin/go"
+	if !common.FileExist(gocmd) {
+		t.Skip("go sdk not found for testing")
+	}
+	const abi = `[{"constant":false,"inputs":[{"name":"to","type":"address"},{"name":"value","type":"uint256"}],"name":"transfer","outputs":[{"name":"","type":"bool"}],"type":"function"},{"constant":true,"inputs":[{"name":"owner","type":"address"}],"name":"balanceOf","outputs":[{"name":"","type":"uint256"}],"type":"function"}]`
+
+	bind, err := BindWithOptions([]string{"Token"}, []string{abi}, []string{""}, "bindtest", LangGo, BindOptions{TRC21: true})
+	if err != nil {
+		t.Fatalf("failed to generate binding: %v", err)
+	}
+	// The package is created within the module so that the binding is built
+	// against its bind package, the underscore keeps it out of ./... patterns.
+	pkg, err := os.MkdirTemp(".", "_trc21test")
+	if err != nil {
+		t.Fatalf("failed to create package: %v", err)
+	}
+	defer os.RemoveAll(pkg)
+
+	if err := os.WriteFile(filepath.Join(pkg, "token.go"), []byte(bind), 0600); err != nil {
+		t.Fatalf("failed to write binding: %v", err)
+	}
+	helpers := `package bindtest
+
+var (
+	_ = (*TokenTransactor).TransferTRC21Opts
+	_ = (*TokenSession).TransferTRC21Opts
+)
+`
+	if err := os.WriteFile(filepath.Join(pkg, "helpers.go"), []byte(helpers), 0600); err != nil {
+		t.Fatalf("failed to write helpers check: %v", err)
+	}
+	cmd := exec.Command(gocmd, "vet", ".")
+	cmd.Dir = pkg
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("failed to compile TRC21 binding: %v\n%s", err, out)
+	}
+}
@@ -22,6 +22,7 @@ import "github.com/XinFinOrg/XDPoSChain/accounts/abi"
 type tmplData struct {
 	Package   string                   // Name of the package to place the generated file in
 	Contracts map[string]*tmplContract // List of contracts to generate into this file
+	TRC21     bool                     // Whether to generate the TRC21 fee helpers of the transactions
 }
 
 // tmplContract contains the data needed to generate an individual contract binding.
@@ -284,6 +285,25 @@ package {{.Package}}
 		func (_{{$contract.Type}} *{{$contract.Type}}TransactorSession) {{.Normalized.Name}}({{range $i, $_ := .Normalized.Inputs}}{{if ne $i 0}},{{end}} {{.Name}} {{bindtype .Type}} {{end}}) (*types.Transaction, error) {
 		  return _{{$contract.Type}}.Contract.{{.Normalized.Name}}(&_{{$contract.Type}}.TransactOpts {{range $i, $_ := .Normalized.Inputs}}, {{.Name}}{{end}})
 		}
+		{{if $.TRC21}}
+			// {{.Normalized.Name}}TRC21Opts estimates the fee of {{.Normalized.Name}} when paid by the TRC21 issuer,
+			// returning the transaction options to use. It fails with a *bind.TRC21CapacityError if the
+			// issuer can't pay the fee.
+			//
+			// Solidity: {{.Original.String}}
+			func (_{{$contract.Type}} *{{$contract.Type}}Transactor) {{.Normalized.Name}}TRC21Opts(opts *bind.TransactOpts, fees bind.TRC21FeeReader {{range .Normalized.Inputs}}, {{.Name}} {{bindtype .Type}} {{end}}) (*bind.TransactOpts, *bind.TRC21Fee, error) {
+				return _{{$contract.Type}}.contract.TRC21TransactOpts(opts, fees, "{{.Original.Name}}" {{range .Normalized.Inputs}}, {{.Name}}{{end}})
+			}
+
+			// {{.Normalized.Name}}TRC21Opts estimates the fee of {{.Normalized.Name}} when paid by the TRC21 issuer,
+			// returning the transaction options to use. It fails with a *bind.TRC21CapacityError if the
+			// issuer can't pay the fee.
+			//
+			// Solidity: {{.Original.String}}
+			func (_{{$contract.Type}} *{{$contract.Type}}Session) {{.Normalized.Name}}TRC21Opts(fees bind.TRC21FeeReader {{range .Normalized.Inputs}}, {{.Name}} {{bindtype .Type}} {{end}}) (*bind.TransactOpts, *bind.TRC21Fee, error) {
+				return _{{$contract.Type}}.Contract.{{.Normalized.Name}}TRC21Opts(&_{{$contract.Type}}.TransactOpts, fees {{range .Normalized.Inputs}}, {{.Name}}{{end}})
+			}
+		{{end}}
 	{{end}}
 
 	{{range .Events}}
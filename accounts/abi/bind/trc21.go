@@ -0,0 +1,147 @@
+// Copyright (c) 2018 XDPoSChain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package bind
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/XinFinOrg/XDPoSChain"
+	"github.com/XinFinOrg/XDPoSChain/common"
+	"github.com/XinFinOrg/XDPoSChain/core/types"
+	"github.com/XinFinOrg/XDPoSChain/params"
+)
+
+// ErrTRC21CapacityExhausted is matched by every TRC21CapacityError.
+var ErrTRC21CapacityExhausted = errors.New("trc21 fee capacity exhausted")
+
+var (
+	transferMethodID     = common.Hex2Bytes("a9059cbb") // transfer(address,uint256)
+	transferFromMethodID = common.Hex2Bytes("23b872dd") // transferFrom(address,address,uint256)
+
+	intrinsicGas = new(big.Int).SetUint64(params.TxGas) // Least gas a transaction can use
+)
+
+// TRC21CapacityError is returned when the TRC21 issuer can't pay the fee of a
+// transaction to a token anymore.
+type TRC21CapacityError struct {
+	Token    common.Address // Token the transaction is sent to
+	Capacity *big.Int       // Remaining fee capacity of the token
+	Fee      *big.Int       // Fee required by the transaction
+}
+
+func (e *TRC21CapacityError) Error() string {
+	return fmt.Sprintf("trc21 fee capacity exhausted: token %s, capacity %v, fee %v", e.Token.Hex(), e.Capacity, e.Fee)
+}
+
+// Is makes errors.Is match the error with ErrTRC21CapacityExhausted.
+func (e *TRC21CapacityError) Is(target error) bool {
+	return target == ErrTRC21CapacityExhausted
+}
+
+// TRC21FeeReader reads the fee state of TRC21 tokens, usually from the TRC21
+// issuer contract.
+type TRC21FeeReader interface {
+	// TokenCapacity returns the remaining amount of wei the issuer can spend to
+	// pay the fees of the transactions to the token.
+	TokenCapacity(opts *CallOpts, token common.Address) (*big.Int, error)
+
+	// EstimateTokenFee returns the fee the token charges, in tokens, to the
+	// sender of a transfer of the given value.
+	EstimateTokenFee(opts *CallOpts, token common.Address, value *big.Int) (*big.Int, error)
+}
+
+// TRC21Fee is the estimated fee of a transaction paid by the TRC21 issuer.
+type TRC21Fee struct {
+	GasLimit uint64   // Gas limit of the transaction
+	GasPrice *big.Int // Gas price charged to the issuer
+	Fee      *big.Int // Capacity the transaction requires
+	Capacity *big.Int // Remaining fee capacity of the token
+	TokenFee *big.Int // Fee charged in tokens to the sender
+}
+
+// headerReader is implemented by the backends which can tell the current block,
+// which sets the gas price of the TRC21 transactions.
+type headerReader interface {
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+}
+
+// TRC21TransactOpts estimates the fee of invoking the method of the bound TRC21
+// token when the issuer pays it. It returns a copy of opts with the gas limit
+// and gas price of the transaction set, or a *TRC21CapacityError if the issuer
+// can't pay the fee.
+func (c *BoundContract) TRC21TransactOpts(opts *TransactOpts, fees TRC21FeeReader, method string, params ...interface{}) (*TransactOpts, *TRC21Fee, error) {
+	input, err := c.abi.Pack(method, params...)
+	if err != nil {
+		return nil, nil, err
+	}
+	ctx := ensureContext(opts.Context)
+	estimate := new(TRC21Fee)
+
+	// The transaction goes into the next block
+	var number *big.Int
+	if reader, ok := c.transactor.(headerReader); ok {
+		header, err := reader.HeaderByNumber(ctx, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		number = new(big.Int).Add(header.Number, common.Big1)
+	}
+	estimate.GasPrice = common.GetTRC21GasPrice(number)
+
+	// Gas estimation fails when the capacity can't even pay the intrinsic gas,
+	// so that is checked first.
+	callOpts := &CallOpts{From: opts.From, Context: opts.Context}
+	if estimate.Capacity, err = fees.TokenCapacity(callOpts, c.address); err != nil {
+		return nil, nil, err
+	}
+	if estimate.GasLimit = opts.GasLimit; estimate.GasLimit == 0 {
+		estimate.Fee = new(big.Int).Mul(estimate.GasPrice, intrinsicGas)
+		if estimate.Capacity.Cmp(estimate.Fee) < 0 {
+			return nil, estimate, &TRC21CapacityError{Token: c.address, Capacity: estimate.Capacity, Fee: estimate.Fee}
+		}
+		msg := XDPoSChain.CallMsg{From: opts.From, To: &c.address, Value: opts.Value, Data: input}
+		if estimate.GasLimit, err = c.transactor.EstimateGas(ctx, msg); err != nil {
+			return nil, nil, fmt.Errorf("failed to estimate gas needed: %v", err)
+		}
+	}
+	estimate.Fee = new(big.Int).Mul(estimate.GasPrice, new(big.Int).SetUint64(estimate.GasLimit))
+	if estimate.Capacity.Cmp(estimate.Fee) < 0 {
+		return nil, estimate, &TRC21CapacityError{Token: c.address, Capacity: estimate.Capacity, Fee: estimate.Fee}
+	}
+	if estimate.TokenFee, err = fees.EstimateTokenFee(callOpts, c.address, transferValue(input)); err != nil {
+		return nil, nil, err
+	}
+	feeOpts := *opts
+	feeOpts.GasLimit = estimate.GasLimit
+	feeOpts.GasPrice = estimate.GasPrice
+	return &feeOpts, estimate, nil
+}
+
+// transferValue returns the amount of tokens moved by a transfer or transferFrom
+// call, or zero for the other calls.
+func transferValue(input []byte) *big.Int {
+	switch {
+	case len(input) == 4+2*32 && bytes.Equal(input[:4], transferMethodID):
+		return new(big.Int).SetBytes(input[4+32:])
+	case len(input) == 4+3*32 && bytes.Equal(input[:4], transferFromMethodID):
+		return new(big.Int).SetBytes(input[4+2*32:])
+	}
+	return new(big.Int)
+}
@@ -33,6 +33,7 @@ import (
 	"github.com/XinFinOrg/XDPoSChain/cmd/utils"
 	"github.com/XinFinOrg/XDPoSChain/common"
 	"github.com/XinFinOrg/XDPoSChain/eth/ethconfig"
+	"github.com/XinFinOrg/XDPoSChain/health"
 	"github.com/XinFinOrg/XDPoSChain/internal/debug"
 	"github.com/XinFinOrg/XDPoSChain/log"
 	"github.com/XinFinOrg/XDPoSChain/node"
@@ -94,6 +95,7 @@ type XDCConfig struct {
 	Shh         whisper.Config
 	Node        node.Config
 	Ethstats    ethstatsConfig
+	Health      health.Config
 	XDCX        XDCx.Config
 	Account     account
 	StakeEnable bool
@@ -132,6 +134,7 @@ func makeConfigNode(ctx *cli.Context) (*node.Node, XDCConfig) {
 		Eth:         ethconfig.Defaults,
 		Shh:         whisper.DefaultConfig,
 		XDCX:        XDCx.DefaultConfig,
+		Health:      health.DefaultConfig,
 		Node:        defaultNodeConfig(),
 		StakeEnable: true,
 		Verbosity:   3,
@@ -214,6 +217,7 @@ func makeConfigNode(ctx *cli.Context) (*node.Node, XDCConfig) {
 
 	utils.SetShhConfig(ctx, stack, &cfg.Shh)
 	utils.SetXDCXConfig(ctx, &cfg.XDCX, cfg.Node.DataDir)
+	utils.SetHealthConfig(ctx, &cfg.Health)
 	return stack, cfg
 }
 
@@ -261,6 +265,22 @@ func makeFullNode(ctx *cli.Context) (*node.Node, XDCConfig) {
 		utils.RegisterShhService(stack, &cfg.Shh)
 	}
 
+	// Add the GraphQL service if requested.
+	if ctx.GlobalBool(utils.GraphQLEnabledFlag.Name) {
+		if cfg.Node.HTTPHost == "" {
+			utils.Fatalf("Option %q requires the HTTP-RPC server, enable it with %q", utils.GraphQLEnabledFlag.Name, utils.RPCEnabledFlag.Name)
+		}
+		utils.RegisterGraphQLService(stack)
+	}
+
+	// Add the health and readiness checks if requested.
+	if ctx.GlobalBool(utils.HealthEnabledFlag.Name) {
+		if cfg.Node.HTTPHost == "" {
+			utils.Fatalf("Option %q requires the HTTP-RPC server, enable it with %q", utils.HealthEnabledFlag.Name, utils.RPCEnabledFlag.Name)
+		}
+		utils.RegisterHealthService(stack, &cfg.Health)
+	}
+
 	// Add the Ethereum Stats daemon if requested.
 	if cfg.Ethstats.URL != "" {
 		utils.RegisterEthStatsService(stack, cfg.Ethstats.URL)
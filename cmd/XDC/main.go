@@ -60,6 +60,7 @@ var (
 		utils.BootnodesFlag,
 		utils.BootnodesV4Flag,
 		utils.BootnodesV5Flag,
+		utils.DNSDiscoveryFlag,
 		utils.DataDirFlag,
 		utils.KeyStoreDirFlag,
 		//utils.NoUSBFlag,
@@ -103,6 +104,8 @@ var (
 		utils.MaxPendingPeersFlag,
 		utils.EtherbaseFlag,
 		utils.GasPriceFlag,
+		utils.MinerOrderingFlag,
+		utils.MinerPrioritySendersFlag,
 		utils.StakerThreadsFlag,
 		utils.StakingEnabledFlag,
 		utils.TargetGasLimitFlag,
@@ -140,6 +143,10 @@ var (
 		utils.StoreRewardFlag,
 		utils.RollbackFlag,
 		utils.XDCSlaveModeFlag,
+		utils.FailoverFlag,
+		utils.FailoverLockFlag,
+		utils.FailoverHolderFlag,
+		utils.FailoverIntervalFlag,
 	}
 
 	rpcFlags = []cli.Flag{
@@ -149,11 +156,29 @@ var (
 		utils.RPCPortFlag,
 		utils.RPCHttpWriteTimeoutFlag,
 		utils.RPCApiFlag,
+		utils.GraphQLEnabledFlag,
+		utils.HealthEnabledFlag,
+		utils.HealthMinPeersFlag,
+		utils.HealthMaxHeadAgeFlag,
+		utils.HealthMaxBlocksBehindFlag,
+		utils.HealthMaxCommitLagFlag,
 		utils.WSEnabledFlag,
 		utils.WSListenAddrFlag,
 		utils.WSPortFlag,
 		utils.WSApiFlag,
 		utils.WSAllowedOriginsFlag,
+		utils.AuthRPCEnabledFlag,
+		utils.AuthRPCListenAddrFlag,
+		utils.AuthRPCPortFlag,
+		utils.AuthRPCVirtualHostsFlag,
+		utils.AuthRPCOriginsFlag,
+		utils.AuthRPCJWTSecretFlag,
+		utils.RPCRateLimitFlag,
+		utils.RPCAPIKeyHeaderFlag,
+		utils.RPCTrustedProxiesFlag,
+		utils.RPCBatchLimitFlag,
+		utils.RPCResponseLimitFlag,
+		utils.RPCConcurrencyFlag,
 		utils.IPCDisabledFlag,
 		utils.IPCPathFlag,
 		utils.RPCGlobalTxFeeCap,
@@ -187,6 +212,8 @@ func init() {
 		javascriptCommand,
 		// See misccmd.go:
 		versionCommand,
+		// See relayercmd.go:
+		relayerCommand,
 		// See config.go
 		dumpConfigCommand,
 	}
@@ -314,19 +341,40 @@ func startNode(ctx *cli.Context, stack *node.Node, cfg XDCConfig) {
 		utils.Fatalf("Ethereum service not running: %v", err)
 	}
 	if engine, ok := ethereum.Engine().(*XDPoS.XDPoS); ok {
+		// In failover mode the node follows the chain without signing anything
+		// until it holds the failover lease. The engine stops signing as soon as
+		// the lease is lost, staking is started and stopped by the checkpoint
+		// goroutine below, which owns the staking state.
+		failoverCh := make(chan bool)
+		failoverManager := utils.MakeFailoverManager(ctx,
+			func() {
+				engine.SetObserveOnly(false)
+				failoverCh <- true
+			},
+			func() {
+				engine.SetObserveOnly(true)
+				failoverCh <- false
+			},
+		)
+		if failoverManager != nil {
+			engine.SetObserveOnly(true)
+		}
 		go func() {
 			started := false
 			ok := false
 			slaveMode := ctx.GlobalIsSet(utils.XDCSlaveModeFlag.Name)
-			var err error
-			ok, err = ethereum.ValidateMasternode()
-			if err != nil {
-				utils.Fatalf("Can't verify masternode permission: %v", err)
+			standbyMode := func() bool {
+				return failoverManager != nil && !failoverManager.IsActive()
 			}
-			if ok {
+			// startStaking starts staking on a masternode, unless it runs in slave
+			// mode or waits for the failover lease
+			startStaking := func() {
 				if slaveMode {
 					log.Info("Masternode slave mode found.")
 					started = false
+				} else if standbyMode() {
+					log.Info("Masternode standby mode found, waiting for the failover lease.")
+					started = false
 				} else {
 					log.Info("Masternode found. Enabling staking mode...")
 					// Use a reduced number of threads if requested
@@ -347,46 +395,60 @@ func startNode(ctx *cli.Context, stack *node.Node, cfg XDCConfig) {
 					log.Info("Enabled staking node!!!")
 				}
 			}
-			defer close(core.CheckpointCh)
-			for range core.CheckpointCh {
-				log.Info("Checkpoint!!! It's time to reconcile node's state...")
-				log.Info("Update consensus parameters")
-				chain := ethereum.BlockChain()
-				engine.UpdateParams(chain.CurrentHeader())
+			var err error
+			ok, err = ethereum.ValidateMasternode()
+			if err != nil {
+				utils.Fatalf("Can't verify masternode permission: %v", err)
+			}
+			if ok {
+				startStaking()
+			}
+			if failoverManager != nil {
+				failoverManager.Start()
+				go func() {
+					stack.Wait()
+					failoverManager.Stop()
+				}()
+			}
+			for {
+				select {
+				case active := <-failoverCh:
+					if !active {
+						if started {
+							log.Info("Failover lease released. Cancelling staking on this node...")
+							ethereum.StopStaking()
+							started = false
+							log.Info("Cancelled mining mode!!!")
+						}
+						continue
+					}
+					ok, err = ethereum.ValidateMasternode()
+					if err != nil {
+						utils.Fatalf("Can't verify masternode permission: %v", err)
+					}
+					if ok && !started {
+						startStaking()
+					}
 
-				ok, err = ethereum.ValidateMasternode()
-				if err != nil {
-					utils.Fatalf("Can't verify masternode permission: %v", err)
-				}
-				if !ok {
-					if started {
-						log.Info("Only masternode can propose and verify blocks. Cancelling staking on this node...")
-						ethereum.StopStaking()
-						started = false
-						log.Info("Cancelled mining mode!!!")
+				case <-core.CheckpointCh:
+					log.Info("Checkpoint!!! It's time to reconcile node's state...")
+					log.Info("Update consensus parameters")
+					chain := ethereum.BlockChain()
+					engine.UpdateParams(chain.CurrentHeader())
+
+					ok, err = ethereum.ValidateMasternode()
+					if err != nil {
+						utils.Fatalf("Can't verify masternode permission: %v", err)
 					}
-				} else if !started {
-					if slaveMode {
-						log.Info("Masternode slave mode found.")
-						started = false
-					} else {
-						log.Info("Masternode found. Enabling staking mode...")
-						// Use a reduced number of threads if requested
-						if threads := ctx.GlobalInt(utils.StakerThreadsFlag.Name); threads > 0 {
-							type threaded interface {
-								SetThreads(threads int)
-							}
-							if th, ok := ethereum.Engine().(threaded); ok {
-								th.SetThreads(threads)
-							}
-						}
-						// Set the gas price to the limits from the CLI and start mining
-						ethereum.TxPool().SetGasPrice(cfg.Eth.GasPrice)
-						if err := ethereum.StartStaking(true); err != nil {
-							utils.Fatalf("Failed to start staking: %v", err)
+					if !ok {
+						if started {
+							log.Info("Only masternode can propose and verify blocks. Cancelling staking on this node...")
+							ethereum.StopStaking()
+							started = false
+							log.Info("Cancelled mining mode!!!")
 						}
-						started = true
-						log.Info("Enabled staking node!!!")
+					} else if !started {
+						startStaking()
 					}
 				}
 			}
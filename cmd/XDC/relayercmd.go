@@ -0,0 +1,591 @@
+// Copyright (c) 2018 XDPoSChain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/XinFinOrg/XDPoSChain/accounts/abi/bind"
+	"github.com/XinFinOrg/XDPoSChain/accounts/keystore"
+	"github.com/XinFinOrg/XDPoSChain/cmd/utils"
+	"github.com/XinFinOrg/XDPoSChain/common"
+	"github.com/XinFinOrg/XDPoSChain/contracts/XDCx/contract"
+	"github.com/XinFinOrg/XDPoSChain/core/types"
+	"github.com/XinFinOrg/XDPoSChain/ethclient/xdcclient"
+	"github.com/XinFinOrg/XDPoSChain/internal/ethapi"
+	"github.com/XinFinOrg/XDPoSChain/node"
+	"gopkg.in/urfave/cli.v1"
+)
+
+// relayerMaxTradeFee is the bound of the trade fees accepted by the registration
+// contracts, which is 10% of the traded amount.
+const relayerMaxTradeFee = 1000
+
+var (
+	relayerAttachFlag = cli.StringFlag{
+		Name:  "attach",
+		Value: node.DefaultIPCEndpoint(clientIdentifier),
+		Usage: "API endpoint to attach to",
+	}
+	relayerFromFlag = cli.StringFlag{
+		Name:  "from",
+		Usage: "Account owning the relayer, which signs the transactions",
+	}
+	relayerRegistrationFlag = cli.StringFlag{
+		Name:  "registration",
+		Usage: "Address of the relayer registration contract (default: the one of the network)",
+	}
+	relayerLendingRegistrationFlag = cli.StringFlag{
+		Name:  "lending-registration",
+		Usage: "Address of the lending relayer registration contract (default: the one of the network)",
+	}
+	relayerTradeFeeFlag = cli.UintFlag{
+		Name:  "fee",
+		Usage: "Trade fee of the relayer, in 1/10000 of the traded amount (below 1000)",
+	}
+	relayerPairsFlag = cli.StringFlag{
+		Name:  "pairs",
+		Usage: "Comma separated trading pairs of the relayer, as <baseToken>:<quoteToken>",
+	}
+	relayerLendingPairsFlag = cli.StringFlag{
+		Name:  "lending-pairs",
+		Usage: "Comma separated lending pairs of the relayer, as <lendingToken>:<term in seconds>",
+	}
+	relayerCollateralsFlag = cli.StringFlag{
+		Name:  "collaterals",
+		Usage: "Comma separated collateral tokens of the lending pairs, in the same order",
+	}
+	relayerValueFlag = cli.StringFlag{
+		Name:  "value",
+		Usage: "Amount of wei deposited for the relayer fees",
+	}
+	relayerBlockFlag = cli.Int64Flag{
+		Name:  "block",
+		Value: -1,
+		Usage: "Block to read the relayer at (default: latest)",
+	}
+
+	relayerTxFlags = []cli.Flag{
+		relayerAttachFlag,
+		relayerFromFlag,
+		relayerRegistrationFlag,
+		utils.DataDirFlag,
+		utils.KeyStoreDirFlag,
+		utils.PasswordFileFlag,
+		utils.XDCTestnetFlag,
+	}
+
+	relayerCommand = cli.Command{
+		Name:      "relayer",
+		Usage:     "Manage XDCx relayers",
+		ArgsUsage: "",
+		Category:  "RELAYER COMMANDS",
+		Description: `
+Manage the XDCx relayers registered in the RelayerRegistration and
+LendingRelayerRegistration contracts through a running node.
+
+The transactions are signed with the relayer owner account given by --from,
+from the keystore of the data directory. Before sending one, the fee balance
+of the relayer is checked, and a warning is printed when its deposit above the
+locked fund can only pay a few more matches.`,
+		Subcommands: []cli.Command{
+			{
+				Name:      "info",
+				Usage:     "Print the configuration and fee status of a relayer",
+				ArgsUsage: "<coinbase>",
+				Action:    utils.MigrateFlags(relayerInfo),
+				Flags:     []cli.Flag{relayerAttachFlag, relayerBlockFlag},
+				Description: `
+Print the owner, deposit, fee balance, resign status, trading and lending pairs
+of the relayer, along with the number of orders of each pair.`,
+			},
+			{
+				Name:      "register",
+				Usage:     "Register a new relayer",
+				ArgsUsage: "<coinbase>",
+				Action:    utils.MigrateFlags(relayerTxAction(parseRelayerRegister)),
+				Flags:     append(relayerTxFlags, relayerTradeFeeFlag, relayerPairsFlag, relayerValueFlag),
+			},
+			{
+				Name:      "update",
+				Usage:     "Replace the trade fee and the trading pairs of a relayer",
+				ArgsUsage: "<coinbase>",
+				Action:    utils.MigrateFlags(relayerTxAction(parseRelayerUpdate)),
+				Flags:     append(relayerTxFlags, relayerTradeFeeFlag, relayerPairsFlag),
+			},
+			{
+				Name:      "list-pair",
+				Usage:     "Add a trading pair to a relayer",
+				ArgsUsage: "<coinbase> <baseToken> <quoteToken>",
+				Action:    utils.MigrateFlags(relayerTxAction(parseRelayerListPair)),
+				Flags:     relayerTxFlags,
+			},
+			{
+				Name:      "delist-pair",
+				Usage:     "Remove a trading pair from a relayer",
+				ArgsUsage: "<coinbase> <baseToken> <quoteToken>",
+				Action:    utils.MigrateFlags(relayerTxAction(parseRelayerDelistPair)),
+				Flags:     relayerTxFlags,
+			},
+			{
+				Name:      "deposit",
+				Usage:     "Deposit more XDC to pay the relayer fees",
+				ArgsUsage: "<coinbase>",
+				Action:    utils.MigrateFlags(relayerTxAction(parseRelayerDeposit)),
+				Flags:     append(relayerTxFlags, relayerValueFlag),
+			},
+			{
+				Name:      "set-fee",
+				Usage:     "Set the trade fee of a relayer",
+				ArgsUsage: "<coinbase> <fee>",
+				Action:    utils.MigrateFlags(relayerTxAction(parseRelayerSetFee)),
+				Flags:     relayerTxFlags,
+			},
+			{
+				Name:      "set-lending",
+				Usage:     "Set the lending fee, pairs and terms of a relayer",
+				ArgsUsage: "<coinbase>",
+				Action:    utils.MigrateFlags(relayerTxAction(parseRelayerSetLending)),
+				Flags:     append(relayerTxFlags, relayerLendingRegistrationFlag, relayerTradeFeeFlag, relayerLendingPairsFlag, relayerCollateralsFlag),
+			},
+			{
+				Name:      "resign",
+				Usage:     "Resign a relayer, releasing its deposit after 4 weeks",
+				ArgsUsage: "<coinbase>",
+				Action:    utils.MigrateFlags(relayerTxAction(parseRelayerResign)),
+				Flags:     relayerTxFlags,
+			},
+			{
+				Name:      "refund",
+				Usage:     "Refund the deposit of a resigned relayer",
+				ArgsUsage: "<coinbase>",
+				Action:    utils.MigrateFlags(relayerTxAction(parseRelayerRefund)),
+				Flags:     relayerTxFlags,
+			},
+		},
+	}
+)
+
+// relayerClient attaches to the node given by the attach flag.
+func relayerClient(ctx *cli.Context) *xdcclient.Client {
+	client, err := dialRPC(ctx.String(relayerAttachFlag.Name))
+	if err != nil {
+		utils.Fatalf("Unable to attach to XDC node: %v", err)
+	}
+	return xdcclient.NewClient(client)
+}
+
+// relayerArgs parses the coinbase and the additional addresses given as
+// arguments of a relayer command.
+func relayerArgs(ctx *cli.Context, count int) []common.Address {
+	if len(ctx.Args()) != count {
+		utils.Fatalf("This command requires %d arguments.", count)
+	}
+	addrs := make([]common.Address, count)
+	for i, arg := range ctx.Args() {
+		addrs[i] = parseRelayerAddress(arg)
+	}
+	return addrs
+}
+
+func parseRelayerAddress(arg string) common.Address {
+	if !common.IsHexAddress(arg) {
+		utils.Fatalf("Invalid address: %s", arg)
+	}
+	return common.HexToAddress(arg)
+}
+
+// parseRelayerList splits a comma separated flag, returning nil if it's empty.
+func parseRelayerList(value string) []string {
+	if value = strings.TrimSpace(value); value == "" {
+		return nil
+	}
+	items := strings.Split(value, ",")
+	for i := range items {
+		items[i] = strings.TrimSpace(items[i])
+	}
+	return items
+}
+
+// parseRelayerPairs parses the trading pairs flag into the base and quote tokens.
+func parseRelayerPairs(ctx *cli.Context) (bases, quotes []common.Address) {
+	for _, pair := range parseRelayerList(ctx.String(relayerPairsFlag.Name)) {
+		tokens := strings.Split(pair, ":")
+		if len(tokens) != 2 {
+			utils.Fatalf("Invalid trading pair %q, want <baseToken>:<quoteToken>", pair)
+		}
+		bases = append(bases, parseRelayerAddress(tokens[0]))
+		quotes = append(quotes, parseRelayerAddress(tokens[1]))
+	}
+	return bases, quotes
+}
+
+// parseRelayerTradeFee returns the trade fee flag, which must fit the contract.
+func parseRelayerTradeFee(ctx *cli.Context) uint16 {
+	fee := ctx.Uint(relayerTradeFeeFlag.Name)
+	if fee >= relayerMaxTradeFee {
+		utils.Fatalf("Invalid trade fee: %d", fee)
+	}
+	return uint16(fee)
+}
+
+// parseRelayerValue returns the amount of wei given by the value flag.
+func parseRelayerValue(ctx *cli.Context) *big.Int {
+	value, ok := new(big.Int).SetString(ctx.String(relayerValueFlag.Name), 10)
+	if !ok || value.Sign() <= 0 {
+		utils.Fatalf("The deposit must be given in wei with --%s", relayerValueFlag.Name)
+	}
+	return value
+}
+
+// parseRelayerLendingPairs parses the lending pairs flag into the lending tokens
+// and terms, and the collaterals flag into their collateral tokens. Pairs given
+// without collaterals accept any collateral of the lending contract.
+func parseRelayerLendingPairs(ctx *cli.Context) (tokens []common.Address, terms []*big.Int, collaterals []common.Address) {
+	for _, pair := range parseRelayerList(ctx.String(relayerLendingPairsFlag.Name)) {
+		fields := strings.Split(pair, ":")
+		if len(fields) != 2 {
+			utils.Fatalf("Invalid lending pair %q, want <lendingToken>:<term>", pair)
+		}
+		term, ok := new(big.Int).SetString(fields[1], 10)
+		if !ok || term.Sign() <= 0 {
+			utils.Fatalf("Invalid lending term %q", fields[1])
+		}
+		tokens = append(tokens, parseRelayerAddress(fields[0]))
+		terms = append(terms, term)
+	}
+	for _, collateral := range parseRelayerList(ctx.String(relayerCollateralsFlag.Name)) {
+		collaterals = append(collaterals, parseRelayerAddress(collateral))
+	}
+	switch {
+	case len(collaterals) == 0:
+		collaterals = make([]common.Address, len(tokens))
+	case len(collaterals) != len(tokens):
+		utils.Fatalf("Got %d collaterals for %d lending pairs", len(collaterals), len(tokens))
+	}
+	return tokens, terms, collaterals
+}
+
+// parseRelayerContract returns the contract address given by the flag, or the
+// default one of the network.
+func parseRelayerContract(ctx *cli.Context, flag cli.StringFlag, def common.Address) common.Address {
+	if ctx.IsSet(flag.Name) {
+		return parseRelayerAddress(ctx.String(flag.Name))
+	}
+	return def
+}
+
+// relayerTx is a relayer transaction parsed from the command line, which is
+// built once the node is attached and the owner account unlocked.
+type relayerTx struct {
+	coinbase common.Address
+	checkFee bool // Whether to warn about the fee balance before sending
+	build    func(opts *bind.TransactOpts, backend bind.ContractBackend) (*types.Transaction, error)
+}
+
+// relayerTxAction returns the action of a relayer transaction command. All its
+// arguments and flags are parsed before attaching to the node.
+func relayerTxAction(parse func(ctx *cli.Context) *relayerTx) func(ctx *cli.Context) error {
+	return func(ctx *cli.Context) error {
+		tx := parse(ctx)
+		if !ctx.IsSet(relayerFromFlag.Name) {
+			utils.Fatalf("The relayer owner account must be given with --%s", relayerFromFlag.Name)
+		}
+		client := relayerClient(ctx)
+		defer client.Close()
+
+		opts := relayerTransactor(ctx, client)
+		if tx.checkFee {
+			checkRelayerFee(client, tx.coinbase)
+		}
+		signed, err := tx.build(opts, client)
+		return sendRelayerTx(client, tx.coinbase, signed, err)
+	}
+}
+
+// relayerTransactor unlocks the relayer owner account and returns the options
+// to sign its transactions with.
+func relayerTransactor(ctx *cli.Context, client *xdcclient.Client) *bind.TransactOpts {
+	stack, _ := makeConfigNode(ctx)
+	ks := stack.AccountManager().Backends(keystore.KeyStoreType)[0].(*keystore.KeyStore)
+	account, _ := unlockAccount(ctx, ks, ctx.String(relayerFromFlag.Name), 0, utils.MakePasswordList(ctx))
+
+	chainID, err := client.ChainID(context.Background())
+	if err != nil {
+		utils.Fatalf("Failed to get the chain id: %v", err)
+	}
+	opts, err := bind.NewKeyStoreTransactorWithChainID(ks, account, chainID)
+	if err != nil {
+		utils.Fatalf("Failed to create the transactor: %v", err)
+	}
+	return opts
+}
+
+// relayerRegistration parses the relayer registration contract address and
+// returns a constructor binding it to the attached node.
+func relayerRegistration(ctx *cli.Context) func(backend bind.ContractBackend) (*contract.RelayerRegistration, error) {
+	addr := parseRelayerContract(ctx, relayerRegistrationFlag, common.HexToAddress(common.RelayerRegistrationSMC))
+	return func(backend bind.ContractBackend) (*contract.RelayerRegistration, error) {
+		return contract.NewRelayerRegistration(addr, backend)
+	}
+}
+
+// checkRelayerFee warns when the relayer is resigned or its fee balance is low.
+// A relayer which isn't registered yet is ignored.
+func checkRelayerFee(client *xdcclient.Client, coinbase common.Address) {
+	info, err := client.Relayer(context.Background(), coinbase, nil)
+	if err != nil {
+		return
+	}
+	if info.Resigned {
+		fmt.Printf("WARNING: relayer %s resigned, its deposit is refundable from %s\n", coinbase.Hex(), time.Unix(int64(info.DepositReleaseTime), 0).UTC())
+	}
+	if info.LowFeeBalance {
+		fmt.Printf("WARNING: the fee balance of relayer %s only pays %d more matches, deposit more with 'XDC relayer deposit'\n", coinbase.Hex(), info.RemainingMatches)
+	}
+}
+
+// sendRelayerTx waits for a relayer transaction to be mined and reports its outcome.
+func sendRelayerTx(client *xdcclient.Client, coinbase common.Address, tx *types.Transaction, err error) error {
+	if err != nil {
+		utils.Fatalf("Failed to send the transaction: %v", err)
+	}
+	fmt.Printf("Transaction sent: %s\n", tx.Hash().Hex())
+	receipt, err := bind.WaitMined(context.Background(), client, tx)
+	if err != nil {
+		utils.Fatalf("Failed to wait for the transaction: %v", err)
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		utils.Fatalf("Transaction %s failed in block %d", tx.Hash().Hex(), receipt.BlockNumber)
+	}
+	fmt.Printf("Transaction mined in block %d\n", receipt.BlockNumber)
+	checkRelayerFee(client, coinbase)
+	return nil
+}
+
+func relayerInfo(ctx *cli.Context) error {
+	coinbase := relayerArgs(ctx, 1)[0]
+	client := relayerClient(ctx)
+	defer client.Close()
+
+	var number *big.Int
+	if block := ctx.Int64(relayerBlockFlag.Name); block >= 0 {
+		number = big.NewInt(block)
+	}
+	info, err := client.Relayer(context.Background(), coinbase, number)
+	if err != nil {
+		utils.Fatalf("Failed to get relayer %s: %v", coinbase.Hex(), err)
+	}
+	printRelayerInfo(info)
+	return nil
+}
+
+func printRelayerInfo(info *ethapi.RelayerInfo) {
+	fmt.Printf("Coinbase:          %s\n", info.Coinbase.Hex())
+	fmt.Printf("Owner:             %s\n", info.Owner.Hex())
+	fmt.Printf("Deposit:           %v wei\n", info.Deposit.ToInt())
+	fmt.Printf("Locked fund:       %v wei\n", info.LockedFund.ToInt())
+	fmt.Printf("Fee balance:       %v wei (%d matches)\n", info.FeeBalance.ToInt(), info.RemainingMatches)
+	fmt.Printf("Trade fee:         %d\n", info.TradeFee)
+	fmt.Printf("Lending fee:       %d\n", info.LendingFee)
+	if info.Resigned {
+		fmt.Printf("Resigned:          yes, refundable from %s\n", time.Unix(int64(info.DepositReleaseTime), 0).UTC())
+	} else {
+		fmt.Printf("Resigned:          no\n")
+	}
+	fmt.Printf("Trading pairs:     %d\n", len(info.Pairs))
+	for _, pair := range info.Pairs {
+		fmt.Printf("  %s/%s: %d orders\n", pair.BaseToken.Hex(), pair.QuoteToken.Hex(), pair.OrderCount)
+	}
+	fmt.Printf("Lending pairs:     %d\n", len(info.LendingPairs))
+	for _, pair := range info.LendingPairs {
+		fmt.Printf("  %s/%ds: %d orders, collaterals %v\n", pair.LendingToken.Hex(), pair.Term, pair.OrderCount, pair.Collaterals)
+	}
+	if info.LowFeeBalance {
+		fmt.Printf("WARNING: the fee balance only pays %d more matches, deposit more with 'XDC relayer deposit'\n", info.RemainingMatches)
+	}
+}
+
+func parseRelayerRegister(ctx *cli.Context) *relayerTx {
+	coinbase := relayerArgs(ctx, 1)[0]
+	value := parseRelayerValue(ctx)
+	fee := parseRelayerTradeFee(ctx)
+	bases, quotes := parseRelayerPairs(ctx)
+	newRegistration := relayerRegistration(ctx)
+
+	return &relayerTx{
+		coinbase: coinbase,
+		build: func(opts *bind.TransactOpts, backend bind.ContractBackend) (*types.Transaction, error) {
+			registration, err := newRegistration(backend)
+			if err != nil {
+				return nil, err
+			}
+			opts.Value = value
+			return registration.Register(opts, coinbase, fee, bases, quotes)
+		},
+	}
+}
+
+func parseRelayerUpdate(ctx *cli.Context) *relayerTx {
+	coinbase := relayerArgs(ctx, 1)[0]
+	fee := parseRelayerTradeFee(ctx)
+	bases, quotes := parseRelayerPairs(ctx)
+	newRegistration := relayerRegistration(ctx)
+
+	return &relayerTx{
+		coinbase: coinbase,
+		checkFee: true,
+		build: func(opts *bind.TransactOpts, backend bind.ContractBackend) (*types.Transaction, error) {
+			registration, err := newRegistration(backend)
+			if err != nil {
+				return nil, err
+			}
+			return registration.Update(opts, coinbase, fee, bases, quotes)
+		},
+	}
+}
+
+func parseRelayerListPair(ctx *cli.Context) *relayerTx {
+	args := relayerArgs(ctx, 3)
+	newRegistration := relayerRegistration(ctx)
+
+	return &relayerTx{
+		coinbase: args[0],
+		checkFee: true,
+		build: func(opts *bind.TransactOpts, backend bind.ContractBackend) (*types.Transaction, error) {
+			registration, err := newRegistration(backend)
+			if err != nil {
+				return nil, err
+			}
+			return registration.ListToken(opts, args[0], args[1], args[2])
+		},
+	}
+}
+
+func parseRelayerDelistPair(ctx *cli.Context) *relayerTx {
+	args := relayerArgs(ctx, 3)
+	newRegistration := relayerRegistration(ctx)
+
+	return &relayerTx{
+		coinbase: args[0],
+		checkFee: true,
+		build: func(opts *bind.TransactOpts, backend bind.ContractBackend) (*types.Transaction, error) {
+			registration, err := newRegistration(backend)
+			if err != nil {
+				return nil, err
+			}
+			return registration.DeListToken(opts, args[0], args[1], args[2])
+		},
+	}
+}
+
+func parseRelayerDeposit(ctx *cli.Context) *relayerTx {
+	coinbase := relayerArgs(ctx, 1)[0]
+	value := parseRelayerValue(ctx)
+	newRegistration := relayerRegistration(ctx)
+
+	return &relayerTx{
+		coinbase: coinbase,
+		build: func(opts *bind.TransactOpts, backend bind.ContractBackend) (*types.Transaction, error) {
+			registration, err := newRegistration(backend)
+			if err != nil {
+				return nil, err
+			}
+			opts.Value = value
+			return registration.DepositMore(opts, coinbase)
+		},
+	}
+}
+
+func parseRelayerSetFee(ctx *cli.Context) *relayerTx {
+	if len(ctx.Args()) != 2 {
+		utils.Fatalf("This command requires 2 arguments.")
+	}
+	coinbase := parseRelayerAddress(ctx.Args().First())
+	fee, err := strconv.ParseUint(ctx.Args().Get(1), 10, 16)
+	if err != nil || fee >= relayerMaxTradeFee {
+		utils.Fatalf("Invalid trade fee: %s", ctx.Args().Get(1))
+	}
+	newRegistration := relayerRegistration(ctx)
+
+	return &relayerTx{
+		coinbase: coinbase,
+		checkFee: true,
+		build: func(opts *bind.TransactOpts, backend bind.ContractBackend) (*types.Transaction, error) {
+			registration, err := newRegistration(backend)
+			if err != nil {
+				return nil, err
+			}
+			return registration.UpdateFee(opts, coinbase, uint16(fee))
+		},
+	}
+}
+
+func parseRelayerSetLending(ctx *cli.Context) *relayerTx {
+	coinbase := relayerArgs(ctx, 1)[0]
+	fee := parseRelayerTradeFee(ctx)
+	tokens, terms, collaterals := parseRelayerLendingPairs(ctx)
+	addr := parseRelayerContract(ctx, relayerLendingRegistrationFlag, common.HexToAddress(common.LendingRegistrationSMC))
+
+	return &relayerTx{
+		coinbase: coinbase,
+		checkFee: true,
+		build: func(opts *bind.TransactOpts, backend bind.ContractBackend) (*types.Transaction, error) {
+			lending, err := contract.NewLending(addr, backend)
+			if err != nil {
+				return nil, err
+			}
+			return lending.Update(opts, coinbase, fee, tokens, terms, collaterals)
+		},
+	}
+}
+
+func parseRelayerResign(ctx *cli.Context) *relayerTx {
+	coinbase := relayerArgs(ctx, 1)[0]
+	newRegistration := relayerRegistration(ctx)
+
+	return &relayerTx{
+		coinbase: coinbase,
+		build: func(opts *bind.TransactOpts, backend bind.ContractBackend) (*types.Transaction, error) {
+			registration, err := newRegistration(backend)
+			if err != nil {
+				return nil, err
+			}
+			return registration.Resign(opts, coinbase)
+		},
+	}
+}
+
+func parseRelayerRefund(ctx *cli.Context) *relayerTx {
+	coinbase := relayerArgs(ctx, 1)[0]
+	newRegistration := relayerRegistration(ctx)
+
+	return &relayerTx{
+		coinbase: coinbase,
+		build: func(opts *bind.TransactOpts, backend bind.ContractBackend) (*types.Transaction, error) {
+			registration, err := newRegistration(backend)
+			if err != nil {
+				return nil, err
+			}
+			return registration.Refund(opts, coinbase)
+		},
+	}
+}
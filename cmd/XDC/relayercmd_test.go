@@ -0,0 +1,196 @@
+// Copyright (c) 2018 XDPoSChain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"math/big"
+	"testing"
+
+	"github.com/XinFinOrg/XDPoSChain/accounts/abi/bind"
+	"github.com/XinFinOrg/XDPoSChain/accounts/abi/bind/backends"
+	"github.com/XinFinOrg/XDPoSChain/common"
+	"github.com/XinFinOrg/XDPoSChain/contracts/XDCx"
+	"github.com/XinFinOrg/XDPoSChain/contracts/XDCx/contract"
+	"github.com/XinFinOrg/XDPoSChain/core"
+	"github.com/XinFinOrg/XDPoSChain/core/types"
+	"github.com/XinFinOrg/XDPoSChain/crypto"
+	"github.com/XinFinOrg/XDPoSChain/params"
+	"gopkg.in/urfave/cli.v1"
+)
+
+const relayerTestCoinbase = "0x0000000000000000000000000000000000000099"
+
+// The arguments of the relayer commands are checked before attaching to the node.
+func TestRelayerArgs(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"register"}, "This command requires 1 arguments."},
+		{[]string{"register", "0xzz", "--value", "1"}, "Invalid address: 0xzz"},
+		{[]string{"register", relayerTestCoinbase}, "The deposit must be given in wei with --value"},
+		{[]string{"register", relayerTestCoinbase, "--value", "1", "--fee", "1000"}, "Invalid trade fee: 1000"},
+		{[]string{"register", relayerTestCoinbase, "--value", "1", "--pairs", "0x42"}, `Invalid trading pair "0x42", want <baseToken>:<quoteToken>`},
+		{[]string{"deposit", relayerTestCoinbase, "--value", "-5"}, "The deposit must be given in wei with --value"},
+		{[]string{"list-pair", relayerTestCoinbase, "0x0000000000000000000000000000000000000042"}, "This command requires 3 arguments."},
+		{[]string{"set-fee", relayerTestCoinbase, "1000"}, "Invalid trade fee: 1000"},
+		{[]string{"set-lending", relayerTestCoinbase, "--lending-pairs", "0x0000000000000000000000000000000000000001:0"}, `Invalid lending term "0"`},
+		{[]string{"set-lending", relayerTestCoinbase, "--lending-pairs", "0x0000000000000000000000000000000000000001:86400", "--collaterals", "0x0000000000000000000000000000000000000001,0x0000000000000000000000000000000000000042"}, "Got 2 collaterals for 1 lending pairs"},
+		{[]string{"resign", relayerTestCoinbase}, "The relayer owner account must be given with --from"},
+	}
+	for _, tt := range tests {
+		XDC := runXDC(t, append([]string{"relayer"}, tt.args...)...)
+		XDC.Expect("Fatal: " + tt.want + "\n")
+		XDC.ExpectExit()
+	}
+}
+
+// newRelayerTestContext returns the context of a relayer command run with the
+// given arguments.
+func newRelayerTestContext(t *testing.T, command string, args ...string) *cli.Context {
+	for _, cmd := range relayerCommand.Subcommands {
+		if cmd.Name != command {
+			continue
+		}
+		set := flag.NewFlagSet(command, flag.ContinueOnError)
+		for _, f := range cmd.Flags {
+			f.Apply(set)
+		}
+		if err := set.Parse(args); err != nil {
+			t.Fatalf("failed to parse %s arguments: %v", command, err)
+		}
+		return cli.NewContext(app, set, nil)
+	}
+	t.Fatalf("unknown relayer command %s", command)
+	return nil
+}
+
+func TestRelayerTxs(t *testing.T) {
+	var (
+		ownerKey, _   = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		ownerAddr     = crypto.PubkeyToAddress(ownerKey.PublicKey)
+		relayerKey, _ = crypto.HexToECDSA("8a1f9a8f95be41cd7ccb6168179afb4504aefe388d1e14474d32c45c72ce7b7a")
+		relayerAddr   = crypto.PubkeyToAddress(relayerKey.PublicKey)
+		coinbase      = common.HexToAddress(relayerTestCoinbase)
+		token         = common.HexToAddress("0x0000000000000000000000000000000000000042")
+		xdcNative     = common.HexToAddress("0x0000000000000000000000000000000000000001")
+
+		ether      = big.NewInt(params.Ether)
+		funds      = new(big.Int).Mul(big.NewInt(1000000), ether)
+		minDeposit = new(big.Int).Mul(big.NewInt(25000), ether)
+	)
+	backend := backends.NewXDCSimulatedBackend(core.GenesisAlloc{
+		ownerAddr:   {Balance: funds},
+		relayerAddr: {Balance: funds},
+	}, 100000000, params.TestXDPoSMockChainConfig)
+	ownerOpts := bind.NewKeyedTransactor(ownerKey)
+
+	// Deploy the contracts, listing the token and the lending term
+	listingAddr, listing, err := XDCx.DeployXDCXListing(ownerOpts, backend)
+	if err != nil {
+		t.Fatalf("failed to deploy the listing: %v", err)
+	}
+	backend.Commit()
+	registrationAddr, _, err := XDCx.DeployRelayerRegistration(ownerOpts, backend, listingAddr, big.NewInt(200), big.NewInt(200), minDeposit)
+	if err != nil {
+		t.Fatalf("failed to deploy the registration: %v", err)
+	}
+	lendingAddr, lending, err := XDCx.DeployLendingRelayerRegistration(ownerOpts, backend, registrationAddr, listingAddr)
+	if err != nil {
+		t.Fatalf("failed to deploy the lending registration: %v", err)
+	}
+	backend.Commit()
+	listing.TransactOpts.Value = new(big.Int).Mul(big.NewInt(1000), ether)
+	if _, err := listing.Apply(token); err != nil {
+		t.Fatalf("failed to list the token: %v", err)
+	}
+	if _, err := lending.AddBaseToken(xdcNative); err != nil {
+		t.Fatalf("failed to add the lending token: %v", err)
+	}
+	if _, err := lending.AddTerm(big.NewInt(86400)); err != nil {
+		t.Fatalf("failed to add the lending term: %v", err)
+	}
+	backend.Commit()
+
+	registration := "--registration=" + registrationAddr.Hex()
+	commands := []struct {
+		name string
+		args []string
+	}{
+		{"register", []string{registration, "--value=" + minDeposit.String(), "--fee=10", "--pairs=" + token.Hex() + ":" + xdcNative.Hex(), relayerTestCoinbase}},
+		{"set-fee", []string{registration, relayerTestCoinbase, "20"}},
+		{"deposit", []string{registration, "--value=" + ether.String(), relayerTestCoinbase}},
+		{"delist-pair", []string{registration, relayerTestCoinbase, token.Hex(), xdcNative.Hex()}},
+		{"list-pair", []string{registration, relayerTestCoinbase, token.Hex(), xdcNative.Hex()}},
+		{"set-lending", []string{"--lending-registration=" + lendingAddr.Hex(), "--fee=30", "--lending-pairs=" + xdcNative.Hex() + ":86400", relayerTestCoinbase}},
+	}
+	parsers := map[string]func(*cli.Context) *relayerTx{
+		"register":    parseRelayerRegister,
+		"set-fee":     parseRelayerSetFee,
+		"deposit":     parseRelayerDeposit,
+		"delist-pair": parseRelayerDelistPair,
+		"list-pair":   parseRelayerListPair,
+		"set-lending": parseRelayerSetLending,
+		"resign":      parseRelayerResign,
+	}
+	send := func(name string, args []string) {
+		tx := parsers[name](newRelayerTestContext(t, name, args...))
+		if tx.coinbase != coinbase {
+			t.Fatalf("%s: coinbase mismatch: have %x, want %x", name, tx.coinbase, coinbase)
+		}
+		signed, err := tx.build(bind.NewKeyedTransactor(relayerKey), backend)
+		if err != nil {
+			t.Fatalf("%s: failed to send the transaction: %v", name, err)
+		}
+		backend.Commit()
+		receipt, err := backend.TransactionReceipt(context.Background(), signed.Hash())
+		if err != nil || receipt.Status != types.ReceiptStatusSuccessful {
+			t.Fatalf("%s: transaction failed: %v", name, err)
+		}
+	}
+	for _, cmd := range commands {
+		send(cmd.name, cmd.args)
+	}
+
+	caller, err := contract.NewRelayerRegistration(registrationAddr, backend)
+	if err != nil {
+		t.Fatalf("failed to bind the registration: %v", err)
+	}
+	_, owner, deposit, fee, bases, quotes, err := caller.GetRelayerByCoinbase(nil, coinbase)
+	if err != nil {
+		t.Fatalf("failed to get the relayer: %v", err)
+	}
+	if owner != relayerAddr || deposit.Cmp(new(big.Int).Add(minDeposit, ether)) != 0 || fee != 20 {
+		t.Errorf("relayer mismatch: owner %x, deposit %v, fee %d", owner, deposit, fee)
+	}
+	if len(bases) != 1 || bases[0] != token || len(quotes) != 1 || quotes[0] != xdcNative {
+		t.Errorf("pairs mismatch: %x/%x", bases, quotes)
+	}
+	lendingFee, tokens, terms, collaterals, err := lending.GetLendingRelayerByCoinbase(coinbase)
+	if err != nil {
+		t.Fatalf("failed to get the lending relayer: %v", err)
+	}
+	if lendingFee != 30 || len(tokens) != 1 || tokens[0] != xdcNative || len(terms) != 1 || terms[0].Uint64() != 86400 || len(collaterals) != 1 || collaterals[0] != (common.Address{}) {
+		t.Errorf("lending relayer mismatch: fee %d, tokens %x, terms %v, collaterals %x", lendingFee, tokens, terms, collaterals)
+	}
+
+	send("resign", []string{registration, relayerTestCoinbase})
+	if request, err := caller.RESIGNREQUESTS(nil, coinbase); err != nil || request.Sign() == 0 {
+		t.Errorf("resign request mismatch: have %v, %v", request, err)
+	}
+}
@@ -149,11 +149,29 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.RPCPortFlag,
 			utils.RPCHttpWriteTimeoutFlag,
 			utils.RPCApiFlag,
+			utils.GraphQLEnabledFlag,
+			utils.HealthEnabledFlag,
+			utils.HealthMinPeersFlag,
+			utils.HealthMaxHeadAgeFlag,
+			utils.HealthMaxBlocksBehindFlag,
+			utils.HealthMaxCommitLagFlag,
 			utils.WSEnabledFlag,
 			utils.WSListenAddrFlag,
 			utils.WSPortFlag,
 			utils.WSApiFlag,
 			utils.WSAllowedOriginsFlag,
+			utils.AuthRPCEnabledFlag,
+			utils.AuthRPCListenAddrFlag,
+			utils.AuthRPCPortFlag,
+			utils.AuthRPCVirtualHostsFlag,
+			utils.AuthRPCOriginsFlag,
+			utils.AuthRPCJWTSecretFlag,
+			utils.RPCRateLimitFlag,
+			utils.RPCAPIKeyHeaderFlag,
+			utils.RPCTrustedProxiesFlag,
+			utils.RPCBatchLimitFlag,
+			utils.RPCResponseLimitFlag,
+			utils.RPCConcurrencyFlag,
 			utils.IPCDisabledFlag,
 			utils.IPCPathFlag,
 			utils.RPCCORSDomainFlag,
@@ -170,6 +188,7 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.BootnodesFlag,
 			utils.BootnodesV4Flag,
 			utils.BootnodesV5Flag,
+			utils.DNSDiscoveryFlag,
 			utils.ListenPortFlag,
 			utils.MaxPeersFlag,
 			utils.MaxPendingPeersFlag,
@@ -190,6 +209,12 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.TargetGasLimitFlag,
 			utils.GasPriceFlag,
 			utils.ExtraDataFlag,
+			utils.MinerOrderingFlag,
+			utils.MinerPrioritySendersFlag,
+			utils.FailoverFlag,
+			utils.FailoverLockFlag,
+			utils.FailoverHolderFlag,
+			utils.FailoverIntervalFlag,
 		},
 	},
 	//{
@@ -39,6 +39,8 @@ var (
 	pkgFlag  = flag.String("pkg", "", "Package name to generate the binding into")
 	outFlag  = flag.String("out", "", "Output file for the generated binding (default = stdout)")
 	langFlag = flag.String("lang", "go", "Destination language for the bindings (go, java, objc)")
+
+	trc21Flag = flag.Bool("trc21", false, "Generate the helpers estimating the fees paid by the TRC21 issuer (go only)")
 )
 
 func main() {
@@ -122,7 +124,7 @@ func main() {
 		types = append(types, kind)
 	}
 	// Generate the contract binding
-	code, err := bind.Bind(types, abis, bins, *pkgFlag, lang)
+	code, err := bind.BindWithOptions(types, abis, bins, *pkgFlag, lang, bind.BindOptions{TRC21: *trc21Flag})
 	if err != nil {
 		fmt.Printf("Failed to generate ABI binding: %v\n", err)
 		os.Exit(-1)
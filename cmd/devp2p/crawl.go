@@ -0,0 +1,432 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	crand "crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/XinFinOrg/XDPoSChain/common"
+	"github.com/XinFinOrg/XDPoSChain/core/forkid"
+	"github.com/XinFinOrg/XDPoSChain/crypto"
+	"github.com/XinFinOrg/XDPoSChain/eth"
+	"github.com/XinFinOrg/XDPoSChain/log"
+	"github.com/XinFinOrg/XDPoSChain/p2p"
+	"github.com/XinFinOrg/XDPoSChain/p2p/discover"
+	"github.com/XinFinOrg/XDPoSChain/p2p/enr"
+	"github.com/XinFinOrg/XDPoSChain/params"
+	"gopkg.in/urfave/cli.v1"
+)
+
+const (
+	crawlMaxPeers       = 100              // number of simultaneous connections of the crawler
+	crawlMaxENRRequests = 16               // number of simultaneous node record requests
+	crawlStatsInterval  = 10 * time.Second // interval between the progress reports
+	crawlDialTimeout    = time.Minute      // time allowance for a node to complete the handshake
+)
+
+var (
+	crawlCommand = cli.Command{
+		Name:      "crawl",
+		Usage:     "Updates a nodes.json file with the XDPoS nodes found on the network",
+		ArgsUsage: "<nodes.json>",
+		Action:    crawlNodes,
+		Flags: []cli.Flag{
+			bootnodesFlag,
+			recordsFlag,
+			crawlTimeoutFlag,
+			listenAddrFlag,
+			nodekeyFlag,
+		},
+	}
+	bootnodesFlag = cli.StringFlag{
+		Name:  "bootnodes",
+		Usage: "Comma separated enode URLs of the discovery bootstrap nodes (default: mainnet bootnodes)",
+	}
+	recordsFlag = cli.StringFlag{
+		Name:  "records",
+		Usage: "File holding node records to add to the node set, one 'enr:' record per line (see admin.nodeInfo.enr)",
+	}
+	crawlTimeoutFlag = cli.DurationFlag{
+		Name:  "timeout",
+		Usage: "Time limit for the crawl",
+		Value: 30 * time.Minute,
+	}
+	listenAddrFlag = cli.StringFlag{
+		Name:  "addr",
+		Usage: "Listening address of the crawler",
+		Value: ":0",
+	}
+	nodekeyFlag = cli.StringFlag{
+		Name:  "nodekey",
+		Usage: "Node key file of the crawler (default: random key)",
+	}
+)
+
+// statusData is the status message of the eth protocol, exchanged at the start
+// of every eth session.
+type statusData struct {
+	ProtocolVersion uint32
+	NetworkId       uint64
+	TD              *big.Int
+	CurrentBlock    common.Hash
+	GenesisBlock    common.Hash
+	ForkID          forkid.ID `rlp:"optional"` // Only sent from eth/101
+}
+
+// handshakeResult is the outcome of the eth handshake with a node.
+type handshakeResult struct {
+	id      discover.NodeID
+	version uint
+	status  *statusData
+	err     error
+}
+
+// recordResult is the outcome of the node record request to a node found by
+// the discovery.
+type recordResult struct {
+	node   *discover.Node
+	record *enr.Record
+	err    error
+}
+
+// crawler walks the discovery network, requests the node records (EIP-868) of
+// the nodes it finds and records the outcome of the eth handshakes in a node
+// set. Only the nodes supporting an xdpos2 version of the eth protocol get to
+// the handshake, the server drops the others.
+type crawler struct {
+	nodes   nodeSet
+	tab     *discover.Table
+	srv     *p2p.Server
+	found   chan *discover.Node
+	records chan recordResult
+	results chan handshakeResult
+	closed  chan struct{}
+}
+
+func crawlNodes(ctx *cli.Context) error {
+	if ctx.NArg() < 1 {
+		return errors.New("need nodes file as argument")
+	}
+	file := ctx.Args().First()
+	nodes := make(nodeSet)
+	if _, err := os.Stat(file); err == nil {
+		if nodes, err = loadNodesJSON(file); err != nil {
+			return err
+		}
+	}
+	if path := ctx.String(recordsFlag.Name); path != "" {
+		if err := addRecords(nodes, path); err != nil {
+			return err
+		}
+	}
+	c, err := newCrawler(ctx, nodes)
+	if err != nil {
+		return err
+	}
+	c.run(ctx.Duration(crawlTimeoutFlag.Name))
+	c.close()
+	return writeNodesJSON(file, nodes)
+}
+
+// addRecords adds the node records held in the given file to the node set.
+func addRecords(nodes nodeSet, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		record := new(enr.Record)
+		if err := record.UnmarshalText([]byte(text)); err != nil {
+			return fmt.Errorf("%s:%d: invalid record: %v", path, line, err)
+		}
+		node, err := discover.NodeFromRecord(record)
+		if err != nil {
+			return fmt.Errorf("%s:%d: %v", path, line, err)
+		}
+		nodes.addRecord(node.ID, record)
+	}
+	return scanner.Err()
+}
+
+func newCrawler(ctx *cli.Context, nodes nodeSet) (*crawler, error) {
+	var (
+		key *ecdsa.PrivateKey
+		err error
+	)
+	if file := ctx.String(nodekeyFlag.Name); file != "" {
+		key, err = crypto.LoadECDSA(file)
+	} else {
+		key, err = crypto.GenerateKey()
+	}
+	if err != nil {
+		return nil, err
+	}
+	urls := params.MainnetBootnodes
+	if ctx.IsSet(bootnodesFlag.Name) {
+		urls = strings.Split(ctx.String(bootnodesFlag.Name), ",")
+	}
+	bootnodes := make([]*discover.Node, 0, len(urls))
+	for _, url := range urls {
+		node, err := discover.ParseNode(url)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bootnode %q: %v", url, err)
+		}
+		bootnodes = append(bootnodes, node)
+	}
+
+	c := &crawler{
+		nodes:   nodes,
+		found:   make(chan *discover.Node),
+		records: make(chan recordResult),
+		results: make(chan handshakeResult),
+		closed:  make(chan struct{}),
+	}
+	// The discovery runs on its own table, the server only dials the nodes
+	// the crawler hands it.
+	addr, err := net.ResolveUDPAddr("udp", ctx.String(listenAddrFlag.Name))
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenUDP("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	c.tab, err = discover.ListenUDP(conn, discover.Config{
+		PrivateKey: key,
+		Bootnodes:  bootnodes,
+	})
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	var protocols []p2p.Protocol
+	for i, version := range eth.ProtocolVersions {
+		if version < 100 {
+			break // no xdpos2 support
+		}
+		version := version
+		protocols = append(protocols, p2p.Protocol{
+			Name:    eth.ProtocolName,
+			Version: version,
+			Length:  eth.ProtocolLengths[i],
+			Run: func(p *p2p.Peer, rw p2p.MsgReadWriter) error {
+				return c.handshake(version, p, rw)
+			},
+		})
+	}
+	c.srv = &p2p.Server{Config: p2p.Config{
+		PrivateKey:  key,
+		MaxPeers:    crawlMaxPeers,
+		DialRatio:   1,
+		Name:        "XDC-crawler",
+		NoDiscovery: true,
+		Protocols:   protocols,
+	}}
+	if err := c.srv.Start(); err != nil {
+		c.tab.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// handshake reads the eth status message of the peer and disconnects it.
+func (c *crawler) handshake(version uint, p *p2p.Peer, rw p2p.MsgReadWriter) error {
+	status, err := readStatus(rw)
+	select {
+	case c.results <- handshakeResult{p.ID(), version, status, err}:
+	case <-c.closed:
+	}
+	return nil
+}
+
+func readStatus(rw p2p.MsgReadWriter) (*statusData, error) {
+	msg, err := rw.ReadMsg()
+	if err != nil {
+		return nil, err
+	}
+	defer msg.Discard()
+	if msg.Code != eth.StatusMsg {
+		return nil, fmt.Errorf("first message has code %#x, want status", msg.Code)
+	}
+	var status statusData
+	if err := msg.Decode(&status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// discover walks the discovery network with random lookups, handing the nodes
+// it finds to the crawl loop.
+func (c *crawler) discover() {
+	for {
+		var target discover.NodeID
+		crand.Read(target[:])
+		for _, n := range c.tab.Lookup(target) {
+			select {
+			case c.found <- n:
+			case <-c.closed:
+				return
+			}
+		}
+		select {
+		case <-c.closed:
+			return
+		default:
+		}
+	}
+}
+
+// requestENR requests the record of a node found by the discovery. The nodes
+// which don't support ENR requests are still checked with a handshake.
+func (c *crawler) requestENR(n *discover.Node, slots chan struct{}) {
+	defer func() { <-slots }()
+
+	record, err := c.tab.RequestENR(n)
+	select {
+	case c.records <- recordResult{n, record, err}:
+	case <-c.closed:
+	}
+}
+
+// run crawls the network until the timeout expires. The nodes of the set which
+// have a record are checked directly, the others are found through the
+// discovery protocol.
+func (c *crawler) run(timeout time.Duration) {
+	var (
+		seen     = make(map[discover.NodeID]bool)
+		pending  = make(map[discover.NodeID]*discover.Node)
+		started  = make(map[discover.NodeID]time.Time)
+		slots    = make(chan struct{}, crawlMaxENRRequests)
+		deadline = time.NewTimer(timeout)
+		stats    = time.NewTicker(crawlStatsInterval)
+	)
+	defer deadline.Stop()
+	defer stats.Stop()
+
+	check := func(node *discover.Node) {
+		n := c.nodes[node.ID]
+		n.LastCheck = time.Now()
+		c.nodes[node.ID] = n
+		pending[node.ID], started[node.ID] = node, time.Now()
+		c.srv.AddPeer(node)
+	}
+	done := func(id discover.NodeID) {
+		if node, ok := pending[id]; ok {
+			c.srv.RemovePeer(node)
+			delete(pending, id)
+			delete(started, id)
+		}
+	}
+	for id, n := range c.nodes {
+		if n.Record == nil {
+			continue
+		}
+		node, err := discover.NodeFromRecord(n.Record)
+		if err != nil {
+			log.Warn("Skipping invalid node record", "id", id, "err", err)
+			continue
+		}
+		seen[id] = true
+		check(node)
+	}
+	go c.discover()
+
+	for {
+		select {
+		case n := <-c.found:
+			if seen[n.ID] {
+				continue
+			}
+			seen[n.ID] = true
+			select {
+			case slots <- struct{}{}:
+				go c.requestENR(n, slots)
+			default:
+				// Too many record requests in flight, check the node
+				// without its record.
+				check(n)
+			}
+		case res := <-c.records:
+			if res.err != nil {
+				log.Trace("Node record request failed", "id", res.node.ID, "err", res.err)
+			} else {
+				c.nodes.addRecord(res.node.ID, res.record)
+			}
+			check(res.node)
+		case res := <-c.results:
+			c.update(res)
+			done(res.id)
+		case <-stats.C:
+			for id, start := range started {
+				if time.Since(start) > crawlDialTimeout {
+					done(id)
+				}
+			}
+			log.Info("Crawling in progress", "nodes", len(c.nodes), "seen", len(seen), "unchecked", len(pending), "peers", c.srv.PeerCount())
+		case <-deadline.C:
+			log.Info("Crawl finished", "nodes", len(c.nodes), "seen", len(seen), "unchecked", len(pending))
+			return
+		}
+	}
+}
+
+// update stores the outcome of a handshake in the node set.
+func (c *crawler) update(res handshakeResult) {
+	if res.err != nil {
+		log.Debug("Handshake failed", "id", res.id, "err", res.err)
+		return
+	}
+	n, known := c.nodes[res.id]
+	now := time.Now()
+	n.XDPoS = true
+	n.NetworkID = res.status.NetworkId
+	n.ForkHash, n.ForkNext = nil, 0
+	if res.version > 100 {
+		n.ForkHash, n.ForkNext = res.status.ForkID.Hash[:], res.status.ForkID.Next
+	}
+	n.LastCheck = now
+	n.LastResponse = now
+	if n.FirstResponse.IsZero() {
+		n.FirstResponse = now
+	}
+	if !known {
+		log.Debug("Found new node", "id", res.id, "network", res.status.NetworkId)
+	}
+	c.nodes[res.id] = n
+}
+
+func (c *crawler) close() {
+	close(c.closed)
+	c.srv.Stop()
+	c.tab.Close()
+}
@@ -0,0 +1,175 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/XinFinOrg/XDPoSChain/crypto"
+	"github.com/XinFinOrg/XDPoSChain/p2p/dnsdisc"
+	"gopkg.in/urfave/cli.v1"
+)
+
+const (
+	rootTTL     = 10 * 60           // 10 min
+	treeNodeTTL = 4 * 7 * 24 * 3600 // 4 weeks
+	maxTXTChunk = 255               // maximum length of a TXT character string
+)
+
+var (
+	dnsCommand = cli.Command{
+		Name:  "dns",
+		Usage: "DNS discovery (EIP-1459) commands",
+		Subcommands: []cli.Command{
+			dnsSignCommand,
+		},
+	}
+	dnsSignCommand = cli.Command{
+		Name:      "sign",
+		Usage:     "Signs a DNS discovery tree of the XDPoS nodes in a nodes.json file and writes it as a zone file",
+		ArgsUsage: "<nodes.json>",
+		Action:    dnsSign,
+		Flags: []cli.Flag{
+			domainFlag,
+			signingKeyFlag,
+			seqFlag,
+			linkFlag,
+			networkIDFlag,
+			maxAgeFlag,
+			outputFlag,
+		},
+	}
+)
+
+var (
+	domainFlag = cli.StringFlag{
+		Name:  "domain",
+		Usage: "Domain name of the tree",
+	}
+	signingKeyFlag = cli.StringFlag{
+		Name:  "key",
+		Usage: "File holding the hex encoded private key signing the tree",
+	}
+	seqFlag = cli.UintFlag{
+		Name:  "seq",
+		Usage: "Sequence number of the tree (default: current unix time)",
+	}
+	linkFlag = cli.StringFlag{
+		Name:  "links",
+		Usage: "Comma separated enrtree:// URLs of the trees linked from the tree",
+	}
+	maxAgeFlag = cli.DurationFlag{
+		Name:  "maxage",
+		Usage: "Nodes which haven't responded to the crawler within this duration are left out (0 = no limit)",
+		Value: 24 * time.Hour,
+	}
+	outputFlag = cli.StringFlag{
+		Name:  "output",
+		Usage: "Zone file to write (default: stdout)",
+	}
+)
+
+func dnsSign(ctx *cli.Context) error {
+	if ctx.NArg() < 1 {
+		return errors.New("need nodes file as argument")
+	}
+	domain := strings.TrimSuffix(ctx.String(domainFlag.Name), ".")
+	if domain == "" {
+		return fmt.Errorf("missing -%s", domainFlag.Name)
+	}
+	if ctx.String(signingKeyFlag.Name) == "" {
+		return fmt.Errorf("missing -%s", signingKeyFlag.Name)
+	}
+	key, err := crypto.LoadECDSA(ctx.String(signingKeyFlag.Name))
+	if err != nil {
+		return fmt.Errorf("can't load signing key: %v", err)
+	}
+	nodes, err := loadNodesJSON(ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	var links []string
+	if ctx.IsSet(linkFlag.Name) {
+		links = strings.Split(ctx.String(linkFlag.Name), ",")
+	}
+	seq := ctx.Uint(seqFlag.Name)
+	if !ctx.IsSet(seqFlag.Name) {
+		seq = uint(time.Now().Unix())
+	}
+
+	records := nodes.records(ctx.Uint64(networkIDFlag.Name), ctx.Duration(maxAgeFlag.Name))
+	tree, err := dnsdisc.MakeTree(seq, records, links)
+	if err != nil {
+		return err
+	}
+	url, err := tree.Sign(key, domain)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Signed tree with %d nodes: %s\n", len(records), url)
+
+	out := io.Writer(os.Stdout)
+	if file := ctx.String(outputFlag.Name); file != "" {
+		f, err := os.Create(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+	return writeZoneFile(out, domain, url, tree.ToTXT(domain))
+}
+
+// writeZoneFile writes the TXT records of a tree as a DNS zone file.
+func writeZoneFile(w io.Writer, domain, url string, records map[string]string) error {
+	names := make([]string, 0, len(records))
+	for name := range records {
+		if name != domain {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	fmt.Fprintf(w, "; %s\n", url)
+	fmt.Fprintf(w, "$ORIGIN %s.\n", domain)
+	fmt.Fprintf(w, "@ %d IN TXT %s\n", rootTTL, zoneTXT(records[domain]))
+	for _, name := range names {
+		label := strings.TrimSuffix(name, "."+domain)
+		if _, err := fmt.Fprintf(w, "%s %d IN TXT %s\n", label, treeNodeTTL, zoneTXT(records[name])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// zoneTXT quotes a TXT record value, splitting it into character strings of
+// at most 255 bytes.
+func zoneTXT(value string) string {
+	var chunks []string
+	for len(value) > maxTXTChunk {
+		chunks = append(chunks, `"`+value[:maxTXTChunk]+`"`)
+		value = value[maxTXTChunk:]
+	}
+	chunks = append(chunks, `"`+value+`"`)
+	return strings.Join(chunks, " ")
+}
@@ -0,0 +1,133 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/XinFinOrg/XDPoSChain/crypto"
+	"github.com/XinFinOrg/XDPoSChain/p2p/discover"
+	"github.com/XinFinOrg/XDPoSChain/p2p/dnsdisc"
+	"github.com/XinFinOrg/XDPoSChain/p2p/enr"
+)
+
+// This test checks that the zone file written for the XDPoS nodes of a node
+// set can be resolved by the DNS discovery client.
+func TestZoneFile(t *testing.T) {
+	var (
+		now   = time.Now()
+		nodes = make(nodeSet)
+		want  = make(map[discover.NodeID]bool)
+	)
+	for i := 0; i < 30; i++ {
+		key, _ := crypto.GenerateKey()
+		var r enr.Record
+		r.Set(enr.IP4(net.IP{10, 0, 0, byte(i)}))
+		r.Set(enr.TCP(30303))
+		if err := r.Sign(key); err != nil {
+			t.Fatal(err)
+		}
+		n := nodeJSON{Record: &r, XDPoS: true, NetworkID: 50, LastResponse: now}
+		switch i % 4 {
+		case 0:
+			n.NetworkID = 51 // other network
+		case 1:
+			n.XDPoS = false // no xdpos2 support
+		case 2:
+			n.LastResponse = now.Add(-48 * time.Hour) // stale
+		default:
+			want[discover.PubkeyID(&key.PublicKey)] = true
+		}
+		nodes[discover.PubkeyID(&key.PublicKey)] = n
+	}
+
+	tree, err := dnsdisc.MakeTree(1, nodes.records(50, 24*time.Hour), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, _ := crypto.GenerateKey()
+	url, err := tree.Sign(signer, "nodes.example.org")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var zone bytes.Buffer
+	if err := writeZoneFile(&zone, "nodes.example.org", url, tree.ToTXT("nodes.example.org")); err != nil {
+		t.Fatal(err)
+	}
+
+	client := dnsdisc.NewClient(dnsdisc.Config{Resolver: parseZoneFile(t, zone.String())})
+	found, err := client.Nodes(context.Background(), url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != len(want) {
+		t.Fatalf("wrong number of nodes: got %d, want %d", len(found), len(want))
+	}
+	for _, n := range found {
+		if !want[n.ID] {
+			t.Errorf("unexpected node %v in tree", n)
+		}
+	}
+}
+
+// zoneResolver resolves the TXT records of a zone file.
+type zoneResolver map[string]string
+
+func (zr zoneResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	if txt, ok := zr[name]; ok {
+		return []string{txt}, nil
+	}
+	return nil, errors.New("not found")
+}
+
+func parseZoneFile(t *testing.T, zone string) zoneResolver {
+	var (
+		zr     = make(zoneResolver)
+		origin string
+	)
+	for _, line := range strings.Split(zone, "\n") {
+		switch {
+		case line == "" || strings.HasPrefix(line, ";"):
+		case strings.HasPrefix(line, "$ORIGIN "):
+			origin = strings.TrimSuffix(strings.TrimPrefix(line, "$ORIGIN "), ".")
+		default:
+			fields := strings.SplitN(line, " ", 5)
+			if len(fields) != 5 || fields[2] != "IN" || fields[3] != "TXT" {
+				t.Fatalf("invalid zone file line %q", line)
+			}
+			name := fields[0] + "." + origin
+			if fields[0] == "@" {
+				name = origin
+			}
+			var value string
+			for _, chunk := range strings.Split(fields[4], `" "`) {
+				if len(strings.Trim(chunk, `"`)) > maxTXTChunk {
+					t.Fatalf("TXT character string too long in line %q", line)
+				}
+				value += strings.Trim(chunk, `"`)
+			}
+			zr[name] = value
+		}
+	}
+	return zr
+}
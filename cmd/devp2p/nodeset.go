@@ -0,0 +1,101 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/XinFinOrg/XDPoSChain/common"
+	"github.com/XinFinOrg/XDPoSChain/common/hexutil"
+	"github.com/XinFinOrg/XDPoSChain/p2p/discover"
+	"github.com/XinFinOrg/XDPoSChain/p2p/enr"
+)
+
+// nodeJSON is the crawler's knowledge about a node.
+type nodeJSON struct {
+	// Record is the node record, as published by the node itself. Only the
+	// nodes with a record can be put into DNS discovery trees.
+	Record *enr.Record `json:"record,omitempty"`
+
+	// Handshake results of the last successful check.
+	XDPoS     bool          `json:"xdpos2"`
+	NetworkID uint64        `json:"networkId,omitempty"`
+	ForkHash  hexutil.Bytes `json:"forkHash,omitempty"` // Fork ID (EIP-2124), announced from eth/101
+	ForkNext  uint64        `json:"forkNext,omitempty"`
+
+	FirstResponse time.Time `json:"firstResponse,omitempty"`
+	LastResponse  time.Time `json:"lastResponse,omitempty"`
+	LastCheck     time.Time `json:"lastCheck,omitempty"`
+}
+
+// nodeSet is the content of a nodes.json file.
+type nodeSet map[discover.NodeID]nodeJSON
+
+func loadNodesJSON(file string) (nodeSet, error) {
+	var nodes nodeSet
+	if err := common.LoadJSON(file, &nodes); err != nil {
+		return nil, err
+	}
+	return nodes, nil
+}
+
+func writeNodesJSON(file string, nodes nodeSet) error {
+	nodesJSON, err := json.MarshalIndent(nodes, "", "  ")
+	if err != nil {
+		return err
+	}
+	if file == "-" {
+		_, err = os.Stdout.Write(append(nodesJSON, '\n'))
+		return err
+	}
+	return os.WriteFile(file, nodesJSON, 0644)
+}
+
+// addRecord stores the node record of a node, unless a more recent record of
+// the node is known.
+func (ns nodeSet) addRecord(id discover.NodeID, record *enr.Record) {
+	n := ns[id]
+	if n.Record == nil || n.Record.Seq() <= record.Seq() {
+		n.Record = record
+	}
+	ns[id] = n
+}
+
+// records returns the records of the XDPoS nodes of the given network which
+// responded within maxAge, sorted by node address.
+func (ns nodeSet) records(networkID uint64, maxAge time.Duration) []*enr.Record {
+	var (
+		records []*enr.Record
+		cutoff  = time.Now().Add(-maxAge)
+	)
+	for _, n := range ns {
+		if n.Record == nil || !n.XDPoS || n.NetworkID != networkID {
+			continue
+		}
+		if maxAge > 0 && n.LastResponse.Before(cutoff) {
+			continue
+		}
+		records = append(records, n.Record)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return string(records[i].NodeAddr()) < string(records[j].NodeAddr())
+	})
+	return records
+}
@@ -28,12 +28,103 @@ import (
 	"github.com/XinFinOrg/XDPoSChain/log"
 )
 
+// defaultEthstatsImage is the ethstats dashboard deployed by default.
+const defaultEthstatsImage = "puppeth/ethstats:latest"
+
 // ethstatsDockerfile is the Dockerfile required to build an ethstats backend
-// and associated monitoring site.
+// and associated monitoring site. The consensus plugin is added to the backend
+// to display the XDPoS v2 consensus reports of the nodes.
 var ethstatsDockerfile = `
-FROM puppeth/ethstats:latest
+FROM {{.Image}}
 
 RUN echo 'module.exports = {trusted: [{{.Trusted}}], banned: [{{.Banned}}], reserved: ["yournode"]};' > lib/utils/config.js
+
+ADD consensus.js lib/consensus.js
+RUN echo "require('./lib/consensus')(api, server);" >> app.js
+`
+
+// ethstatsConsensusPlugin is the ethstats backend plugin collecting the XDPoS v2
+// "consensus" reports of the logged in nodes. The latest report of each node is
+// rendered on the /consensus page of the site, and served on /consensus.json.
+var ethstatsConsensusPlugin = `
+var secrets = (process.env.WS_SECRET || '').split('|');
+var reports = {};
+
+function escape(value) {
+	return String(value).replace(/[&<>"']/g, function (c) {
+		return {'&': '&amp;', '<': '&lt;', '>': '&gt;', '"': '&quot;', "'": '&#39;'}[c];
+	});
+}
+
+function render() {
+	var rows = Object.keys(reports).sort().map(function (id) {
+		var r = reports[id].consensus;
+		var missed = (r.missedRounds || []).map(function (m) {
+			return escape(m.round) + ' (' + escape(m.miner) + ')';
+		}).join('<br>');
+		return '<tr' + (r.qcSigners < r.qcThreshold ? ' class="warn"' : '') + '>' +
+			'<td>' + escape(id) + '</td>' +
+			'<td>' + escape(r.number) + '</td>' +
+			'<td>' + escape(r.blockRound) + '</td>' +
+			'<td>' + (r.committed ? 'yes' : 'no') + '</td>' +
+			'<td>' + escape(r.round) + '</td>' +
+			'<td>' + escape(r.qcSigners) + ' / ' + escape(r.qcThreshold) + '</td>' +
+			'<td>' + escape(r.epochNumber) + ' (round ' + escape(r.epochRound) + ')</td>' +
+			'<td>' + escape(r.timeouts) + '</td>' +
+			'<td>' + missed + '</td>' +
+			'<td>' + escape(r.pendingVotes) + ' / ' + escape(r.pendingTimeouts) + '</td>' +
+			'<td>' + (r.masternode ? 'yes' : 'no') + '</td>' +
+			'<td>' + escape(new Date(reports[id].updated).toISOString()) + '</td>' +
+			'</tr>';
+	});
+	return '<!DOCTYPE html><html><head><meta charset="utf-8"><meta http-equiv="refresh" content="5">' +
+		'<title>XDPoS v2 consensus</title><style>' +
+		'body{background:#101010;color:#ddd;font-family:monospace}table{border-collapse:collapse}' +
+		'th,td{border:1px solid #333;padding:4px 8px;text-align:left;vertical-align:top}.warn{color:#f74}' +
+		'</style></head><body><h1>XDPoS v2 consensus</h1><table><tr>' +
+		'<th>Node</th><th>Block</th><th>Block round</th><th>Committed</th><th>Round</th><th>QC signers</th>' +
+		'<th>Epoch</th><th>Timeouts</th><th>Missed rounds (miner)</th><th>Pending votes / timeouts</th>' +
+		'<th>Masternode</th><th>Updated</th></tr>' + rows.join('') + '</table></body></html>';
+}
+
+module.exports = function (api, server) {
+	// Keep the latest report of the logged in nodes
+	api.on('connection', function (spark) {
+		var node;
+		spark.on('hello', function (data) {
+			if (data && data.id && secrets.indexOf(data.secret) !== -1) {
+				node = data.id;
+			}
+		});
+		spark.on('consensus', function (data) {
+			if (node && data && data.id === node && data.consensus) {
+				reports[node] = {consensus: data.consensus, updated: Date.now()};
+			}
+		});
+		spark.on('end', function () {
+			if (node) {
+				delete reports[node];
+			}
+		});
+	});
+	// Serve the reports ahead of the other handlers of the site
+	var handlers = server.listeners('request');
+	server.removeAllListeners('request');
+	server.on('request', function (req, res) {
+		var path = req.url.split('?')[0];
+		if (path === '/consensus') {
+			res.writeHead(200, {'Content-Type': 'text/html; charset=utf-8'});
+			return res.end(render());
+		}
+		if (path === '/consensus.json') {
+			res.writeHead(200, {'Content-Type': 'application/json'});
+			return res.end(JSON.stringify(reports));
+		}
+		for (var i = 0; i < handlers.length; i++) {
+			handlers[i].call(server, req, res);
+		}
+	});
+};
 `
 
 // ethstatsComposefile is the docker-compose.yml file required to deploy and
@@ -47,7 +138,8 @@ services:
     ports:
       - "{{.Port}}:3000"{{end}}
     environment:
-      - WS_SECRET={{.Secret}}{{if .VHost}}
+      - WS_SECRET={{.Secret}}
+      - DASHBOARD={{.Image}}{{if .VHost}}
       - VIRTUAL_HOST={{.VHost}}{{end}}{{if .Banned}}
       - BANNED={{.Banned}}{{end}}
     logging:
@@ -61,7 +153,7 @@ services:
 // deployEthstats deploys a new ethstats container to a remote machine via SSH,
 // docker and docker-compose. If an instance with the specified network name
 // already exists there, it will be overwritten!
-func deployEthstats(client *sshClient, network string, port int, secret string, vhost string, trusted []string, banned []string, nocache bool) ([]byte, error) {
+func deployEthstats(client *sshClient, network string, port int, secret string, vhost string, image string, trusted []string, banned []string, nocache bool) ([]byte, error) {
 	// Generate the content to upload to the server
 	workdir := fmt.Sprintf("%d", rand.Int63())
 	files := make(map[string][]byte)
@@ -77,10 +169,12 @@ func deployEthstats(client *sshClient, network string, port int, secret string,
 
 	dockerfile := new(bytes.Buffer)
 	template.Must(template.New("").Parse(ethstatsDockerfile)).Execute(dockerfile, map[string]interface{}{
+		"Image":   image,
 		"Trusted": strings.Join(trustedLabels, ", "),
 		"Banned":  strings.Join(bannedLabels, ", "),
 	})
 	files[filepath.Join(workdir, "Dockerfile")] = dockerfile.Bytes()
+	files[filepath.Join(workdir, "consensus.js")] = []byte(ethstatsConsensusPlugin)
 
 	composefile := new(bytes.Buffer)
 	template.Must(template.New("").Parse(ethstatsComposefile)).Execute(composefile, map[string]interface{}{
@@ -88,6 +182,7 @@ func deployEthstats(client *sshClient, network string, port int, secret string,
 		"Port":    port,
 		"Secret":  secret,
 		"VHost":   vhost,
+		"Image":   image,
 		"Banned":  strings.Join(banned, ","),
 	})
 	files[filepath.Join(workdir, "docker-compose.yaml")] = composefile.Bytes()
@@ -112,6 +207,7 @@ type ethstatsInfos struct {
 	port   int
 	secret string
 	config string
+	image  string
 	banned []string
 }
 
@@ -122,6 +218,8 @@ func (info *ethstatsInfos) Report() map[string]string {
 		"Website address":       info.host,
 		"Website listener port": strconv.Itoa(info.port),
 		"Login secret":          info.secret,
+		"Dashboard image":       info.image,
+		"Consensus reports":     fmt.Sprintf("%s:%d/consensus", info.host, info.port),
 		"Banned addresses":      fmt.Sprintf("%v", info.banned),
 	}
 }
@@ -157,6 +255,11 @@ func checkEthstats(client *sshClient, network string) (*ethstatsInfos, error) {
 	if port != 80 && port != 443 {
 		config += fmt.Sprintf(":%d", port)
 	}
+	// Resolve the deployed dashboard, defaulting for older deployments
+	image := infos.envvars["DASHBOARD"]
+	if image == "" {
+		image = defaultEthstatsImage
+	}
 	// Retrieve the IP blacklist
 	banned := strings.Split(infos.envvars["BANNED"], ",")
 
@@ -170,6 +273,7 @@ func checkEthstats(client *sshClient, network string) (*ethstatsInfos, error) {
 		port:   port,
 		secret: secret,
 		config: config,
+		image:  image,
 		banned: banned,
 	}, nil
 }
@@ -40,6 +40,7 @@ func (w *wizard) deployEthstats() {
 			port:   80,
 			host:   client.server,
 			secret: "",
+			image:  defaultEthstatsImage,
 		}
 	}
 	existed := err == nil
@@ -63,6 +64,12 @@ func (w *wizard) deployEthstats() {
 		fmt.Printf("What should be the secret password for the API? (default = %s)\n", infos.secret)
 		infos.secret = w.readDefaultString(infos.secret)
 	}
+	// Figure out which dashboard to deploy, the XDPoS v2 consensus reports of the
+	// nodes are added to it on /consensus
+	fmt.Println()
+	fmt.Printf("Which ethstats dashboard image should be deployed? (default = %s)\n", infos.image)
+	infos.image = w.readDefaultString(infos.image)
+
 	// Gather any blacklists to ban from reporting
 	if existed {
 		fmt.Println()
@@ -114,7 +121,7 @@ func (w *wizard) deployEthstats() {
 			trusted = append(trusted, client.address)
 		}
 	}
-	if out, err := deployEthstats(client, w.network, infos.port, infos.secret, infos.host, trusted, infos.banned, nocache); err != nil {
+	if out, err := deployEthstats(client, w.network, infos.port, infos.secret, infos.host, infos.image, trusted, infos.banned, nocache); err != nil {
 		log.Error("Failed to deploy ethstats container", "err", err)
 		if len(out) > 0 {
 			fmt.Printf("%s\n", out)
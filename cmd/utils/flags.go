@@ -45,14 +45,17 @@ import (
 	"github.com/XinFinOrg/XDPoSChain/eth/filters"
 	"github.com/XinFinOrg/XDPoSChain/eth/gasprice"
 	"github.com/XinFinOrg/XDPoSChain/ethdb"
+	"github.com/XinFinOrg/XDPoSChain/health"
 	"github.com/XinFinOrg/XDPoSChain/internal/ethapi"
 	"github.com/XinFinOrg/XDPoSChain/log"
 	"github.com/XinFinOrg/XDPoSChain/metrics"
 	"github.com/XinFinOrg/XDPoSChain/metrics/exp"
+	"github.com/XinFinOrg/XDPoSChain/miner/failover"
 	"github.com/XinFinOrg/XDPoSChain/node"
 	"github.com/XinFinOrg/XDPoSChain/p2p"
 	"github.com/XinFinOrg/XDPoSChain/p2p/discover"
 	"github.com/XinFinOrg/XDPoSChain/p2p/discv5"
+	"github.com/XinFinOrg/XDPoSChain/p2p/dnsdisc"
 	"github.com/XinFinOrg/XDPoSChain/p2p/nat"
 	"github.com/XinFinOrg/XDPoSChain/p2p/netutil"
 	"github.com/XinFinOrg/XDPoSChain/params"
@@ -357,6 +360,15 @@ var (
 		Name:  "extradata",
 		Usage: "Block extra data set by the miner (default = client version)",
 	}
+	MinerOrderingFlag = cli.StringFlag{
+		Name:  "miner.ordering",
+		Usage: `Transaction ordering policy of mined blocks ("price", "fifo" or "priority")`,
+		Value: "price",
+	}
+	MinerPrioritySendersFlag = cli.StringFlag{
+		Name:  "miner.prioritysenders",
+		Usage: "Comma separated list of senders whose transactions are mined first by the priority ordering",
+	}
 	// Account settings
 	UnlockedAccountFlag = cli.StringFlag{
 		Name:  "unlock",
@@ -454,6 +466,34 @@ var (
 		Usage: "API's offered over the HTTP-RPC interface",
 		Value: "",
 	}
+	GraphQLEnabledFlag = cli.BoolFlag{
+		Name:  "graphql",
+		Usage: "Enable GraphQL on the HTTP-RPC server at /graphql (requires --rpc)",
+	}
+	HealthEnabledFlag = cli.BoolFlag{
+		Name:  "health",
+		Usage: "Enable the health and readiness checks on the HTTP-RPC server at /health and /ready (requires --rpc)",
+	}
+	HealthMinPeersFlag = cli.IntFlag{
+		Name:  "health.minpeers",
+		Usage: "Minimum number of peers of a ready node",
+		Value: health.DefaultConfig.MinPeers,
+	}
+	HealthMaxHeadAgeFlag = cli.DurationFlag{
+		Name:  "health.maxheadage",
+		Usage: "Maximum age of the head block of a ready node",
+		Value: health.DefaultConfig.MaxHeadAge,
+	}
+	HealthMaxBlocksBehindFlag = cli.Uint64Flag{
+		Name:  "health.maxblocksbehind",
+		Usage: "Maximum number of blocks a ready node is behind the highest known block",
+		Value: health.DefaultConfig.MaxBlocksBehind,
+	}
+	HealthMaxCommitLagFlag = cli.Uint64Flag{
+		Name:  "health.maxcommitlag",
+		Usage: "Maximum number of blocks between the head and the latest committed block of a ready node",
+		Value: health.DefaultConfig.MaxCommitLag,
+	}
 	IPCDisabledFlag = cli.BoolFlag{
 		Name:  "ipcdisable",
 		Usage: "Disable the IPC-RPC server",
@@ -486,6 +526,65 @@ var (
 		Usage: "Origins from which to accept websockets requests",
 		Value: "",
 	}
+	AuthRPCEnabledFlag = cli.BoolFlag{
+		Name:  "authrpc",
+		Usage: "Enable the authenticated HTTP and WS RPC server, serving the APIs allowed by the JWT secret of each client",
+	}
+	AuthRPCListenAddrFlag = cli.StringFlag{
+		Name:  "authrpc.addr",
+		Usage: "Authenticated RPC server listening interface",
+		Value: node.DefaultAuthHost,
+	}
+	AuthRPCPortFlag = cli.IntFlag{
+		Name:  "authrpc.port",
+		Usage: "Authenticated RPC server listening port",
+		Value: node.DefaultAuthPort,
+	}
+	AuthRPCVirtualHostsFlag = cli.StringFlag{
+		Name:  "authrpc.vhosts",
+		Usage: "Comma separated list of virtual hostnames from which to accept authenticated RPC requests (server enforced). Accepts '*' wildcard.",
+		Value: strings.Join(node.DefaultConfig.AuthVirtualHosts, ","),
+	}
+	AuthRPCOriginsFlag = cli.StringFlag{
+		Name:  "authrpc.origins",
+		Usage: "Origins from which to accept authenticated websockets requests",
+		Value: "",
+	}
+	AuthRPCJWTSecretFlag = cli.StringFlag{
+		Name:  "authrpc.jwtsecret",
+		Usage: "Path to the file holding the hex encoded JWT secrets of the authenticated RPC server, one per line followed by the namespaces and methods it may call (default = <datadir>/XDC/jwtsecret)",
+		Value: "",
+	}
+	RPCRateLimitFlag = cli.StringFlag{
+		Name:  "rpc.ratelimit",
+		Usage: "Comma separated request rates allowed per client over HTTP and WS as <method pattern>=<requests per second>[:<burst>] (e.g. eth_getLogs=10:20,debug_trace*=1)",
+		Value: "",
+	}
+	RPCAPIKeyHeaderFlag = cli.StringFlag{
+		Name:  "rpc.apikeyheader",
+		Usage: "HTTP header carrying the API keys that are rate limited per key instead of per IP (keys are set in the config file)",
+		Value: "",
+	}
+	RPCTrustedProxiesFlag = cli.StringFlag{
+		Name:  "rpc.trustedproxies",
+		Usage: "Comma separated IPs and CIDR ranges of the reverse proxies whose X-Forwarded-For header identifies the rate limited clients",
+		Value: "",
+	}
+	RPCBatchLimitFlag = cli.IntFlag{
+		Name:  "rpc.batchlimit",
+		Usage: "Maximum number of requests in a HTTP or WS batch (0 = no limit)",
+		Value: 0,
+	}
+	RPCResponseLimitFlag = cli.IntFlag{
+		Name:  "rpc.responselimit",
+		Usage: "Maximum size in bytes of the results of a HTTP or WS request or batch (0 = no limit)",
+		Value: 0,
+	}
+	RPCConcurrencyFlag = cli.StringFlag{
+		Name:  "rpc.concurrency",
+		Usage: "Comma separated maximum numbers of HTTP and WS requests served at once per namespace as <namespace>=<requests> (e.g. debug=2)",
+		Value: "",
+	}
 	ExecFlag = cli.StringFlag{
 		Name:  "exec",
 		Usage: "Execute JavaScript statement",
@@ -526,6 +625,11 @@ var (
 		Usage: "Comma separated enode URLs for P2P v5 discovery bootstrap (light server, light nodes)",
 		Value: "",
 	}
+	DNSDiscoveryFlag = cli.StringFlag{
+		Name:  "discovery.dns",
+		Usage: "Comma separated enrtree:// URLs of DNS discovery (EIP-1459) trees, whose nodes are dialed alongside the bootnodes and whose masternode records get reserved peer slots",
+		Value: "",
+	}
 	NodeKeyFileFlag = cli.StringFlag{
 		Name:  "nodekey",
 		Usage: "P2P node key file",
@@ -622,6 +726,23 @@ var (
 		Name:  "slave",
 		Usage: "Enable slave mode",
 	}
+	FailoverFlag = cli.BoolFlag{
+		Name:  "failover",
+		Usage: "Enable hot-standby failover mode: only seal blocks and sign votes and timeouts while holding the failover lease",
+	}
+	FailoverLockFlag = cli.StringFlag{
+		Name:  "failover.lock",
+		Usage: "Lock file of the failover lease, on a file system shared by all the nodes using the same key (required with --failover)",
+	}
+	FailoverHolderFlag = cli.StringFlag{
+		Name:  "failover.holder",
+		Usage: "Identity of this node towards the failover lease (default = hostname)",
+	}
+	FailoverIntervalFlag = cli.DurationFlag{
+		Name:  "failover.interval",
+		Usage: "Interval at which the failover lease is acquired or refreshed",
+		Value: failover.DefaultInterval,
+	}
 )
 
 // MakeDataDir retrieves the currently requested data directory, terminating
@@ -735,6 +856,24 @@ func setBootstrapNodesV5(ctx *cli.Context, cfg *p2p.Config) {
 	}
 }
 
+// setDNSDiscovery sets the DNS discovery trees from the command line flags.
+func setDNSDiscovery(ctx *cli.Context, cfg *p2p.Config) {
+	if !ctx.GlobalIsSet(DNSDiscoveryFlag.Name) {
+		return
+	}
+	cfg.DiscoveryDNS = nil
+	for _, url := range strings.Split(ctx.GlobalString(DNSDiscoveryFlag.Name), ",") {
+		url = strings.TrimSpace(url)
+		if url == "" {
+			continue
+		}
+		if _, _, err := dnsdisc.ParseURL(url); err != nil {
+			Fatalf("Option %q: invalid tree URL %q: %v", DNSDiscoveryFlag.Name, url, err)
+		}
+		cfg.DiscoveryDNS = append(cfg.DiscoveryDNS, url)
+	}
+}
+
 // setListenAddress creates a TCP listening address string from set command
 // line flags.
 func setListenAddress(ctx *cli.Context, cfg *p2p.Config) {
@@ -813,6 +952,91 @@ func setWS(ctx *cli.Context, cfg *node.Config) {
 	}
 }
 
+// setAuth creates the authenticated RPC listener interface string from the set
+// command line flags, returning empty if the endpoint is disabled.
+func setAuth(ctx *cli.Context, cfg *node.Config) {
+	if ctx.GlobalBool(AuthRPCEnabledFlag.Name) && cfg.AuthHost == "" {
+		cfg.AuthHost = node.DefaultAuthHost
+		if ctx.GlobalIsSet(AuthRPCListenAddrFlag.Name) {
+			cfg.AuthHost = ctx.GlobalString(AuthRPCListenAddrFlag.Name)
+		}
+	}
+	if ctx.GlobalIsSet(AuthRPCPortFlag.Name) {
+		cfg.AuthPort = ctx.GlobalInt(AuthRPCPortFlag.Name)
+	}
+	if ctx.GlobalIsSet(AuthRPCVirtualHostsFlag.Name) {
+		cfg.AuthVirtualHosts = splitAndTrim(ctx.GlobalString(AuthRPCVirtualHostsFlag.Name))
+	}
+	if ctx.GlobalIsSet(AuthRPCOriginsFlag.Name) {
+		cfg.AuthOrigins = splitAndTrim(ctx.GlobalString(AuthRPCOriginsFlag.Name))
+	}
+	if ctx.GlobalIsSet(AuthRPCJWTSecretFlag.Name) {
+		cfg.JWTSecret = ctx.GlobalString(AuthRPCJWTSecretFlag.Name)
+	}
+}
+
+// setRPCLimits applies the HTTP and WS request limits from the command line
+// flags into the config.
+func setRPCLimits(ctx *cli.Context, cfg *node.Config) {
+	if ctx.GlobalIsSet(RPCRateLimitFlag.Name) {
+		cfg.RPCLimits.Rules = nil
+		for _, limit := range splitAndTrim(ctx.GlobalString(RPCRateLimitFlag.Name)) {
+			rule, err := parseRateLimitRule(limit)
+			if err != nil {
+				Fatalf("Option %q: %v", RPCRateLimitFlag.Name, err)
+			}
+			cfg.RPCLimits.Rules = append(cfg.RPCLimits.Rules, rule)
+		}
+	}
+	if ctx.GlobalIsSet(RPCAPIKeyHeaderFlag.Name) {
+		cfg.RPCLimits.APIKeyHeader = ctx.GlobalString(RPCAPIKeyHeaderFlag.Name)
+	}
+	if ctx.GlobalIsSet(RPCTrustedProxiesFlag.Name) {
+		cfg.RPCLimits.TrustedProxies = splitAndTrim(ctx.GlobalString(RPCTrustedProxiesFlag.Name))
+	}
+	if ctx.GlobalIsSet(RPCBatchLimitFlag.Name) {
+		cfg.RPCLimits.MaxBatchSize = ctx.GlobalInt(RPCBatchLimitFlag.Name)
+	}
+	if ctx.GlobalIsSet(RPCResponseLimitFlag.Name) {
+		cfg.RPCLimits.MaxResponseSize = ctx.GlobalInt(RPCResponseLimitFlag.Name)
+	}
+	if ctx.GlobalIsSet(RPCConcurrencyFlag.Name) {
+		cfg.RPCLimits.Concurrency = make(map[string]int)
+		for _, limit := range splitAndTrim(ctx.GlobalString(RPCConcurrencyFlag.Name)) {
+			parts := strings.Split(limit, "=")
+			if len(parts) != 2 {
+				Fatalf("Option %q: invalid limit %q", RPCConcurrencyFlag.Name, limit)
+			}
+			n, err := strconv.Atoi(parts[1])
+			if err != nil || n <= 0 {
+				Fatalf("Option %q: invalid limit %q", RPCConcurrencyFlag.Name, limit)
+			}
+			cfg.RPCLimits.Concurrency[parts[0]] = n
+		}
+	}
+}
+
+// parseRateLimitRule parses a <method pattern>=<rate>[:<burst>] rate limit,
+// the burst defaults to the rate rounded up.
+func parseRateLimitRule(limit string) (rpc.RateLimitRule, error) {
+	parts := strings.Split(limit, "=")
+	if len(parts) != 2 || parts[0] == "" {
+		return rpc.RateLimitRule{}, fmt.Errorf("invalid rate limit %q", limit)
+	}
+	values := strings.Split(parts[1], ":")
+	rate, err := strconv.ParseFloat(values[0], 64)
+	if err != nil || rate <= 0 || len(values) > 2 {
+		return rpc.RateLimitRule{}, fmt.Errorf("invalid rate limit %q", limit)
+	}
+	burst := int(math.Ceil(rate))
+	if len(values) == 2 {
+		if burst, err = strconv.Atoi(values[1]); err != nil || burst <= 0 {
+			return rpc.RateLimitRule{}, fmt.Errorf("invalid rate limit %q", limit)
+		}
+	}
+	return rpc.RateLimitRule{Method: parts[0], Rate: rate, Burst: burst}, nil
+}
+
 // setIPC creates an IPC path configuration from the set command line flags,
 // returning an empty string if IPC was explicitly disabled, or the set path.
 func setIPC(ctx *cli.Context, cfg *node.Config) {
@@ -917,6 +1141,7 @@ func SetP2PConfig(ctx *cli.Context, cfg *p2p.Config) {
 	setListenAddress(ctx, cfg)
 	setBootstrapNodes(ctx, cfg)
 	// setBootstrapNodesV5(ctx, cfg)
+	setDNSDiscovery(ctx, cfg)
 
 	lightClient := ctx.GlobalBool(LightModeFlag.Name) || ctx.GlobalString(SyncModeFlag.Name) == "light"
 	lightServer := ctx.GlobalInt(LightServFlag.Name) != 0
@@ -984,6 +1209,8 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 	setIPC(ctx, cfg)
 	setHTTP(ctx, cfg)
 	setWS(ctx, cfg)
+	setAuth(ctx, cfg)
+	setRPCLimits(ctx, cfg)
 	setNodeUserIdent(ctx, cfg)
 	setPrefix(ctx, cfg)
 
@@ -1125,6 +1352,23 @@ func checkExclusive(ctx *cli.Context, args ...interface{}) {
 	}
 }
 
+// SetHealthConfig applies the health check related command line flags to the
+// config.
+func SetHealthConfig(ctx *cli.Context, cfg *health.Config) {
+	if ctx.GlobalIsSet(HealthMinPeersFlag.Name) {
+		cfg.MinPeers = ctx.GlobalInt(HealthMinPeersFlag.Name)
+	}
+	if ctx.GlobalIsSet(HealthMaxHeadAgeFlag.Name) {
+		cfg.MaxHeadAge = ctx.GlobalDuration(HealthMaxHeadAgeFlag.Name)
+	}
+	if ctx.GlobalIsSet(HealthMaxBlocksBehindFlag.Name) {
+		cfg.MaxBlocksBehind = ctx.GlobalUint64(HealthMaxBlocksBehindFlag.Name)
+	}
+	if ctx.GlobalIsSet(HealthMaxCommitLagFlag.Name) {
+		cfg.MaxCommitLag = ctx.GlobalUint64(HealthMaxCommitLagFlag.Name)
+	}
+}
+
 // SetShhConfig applies shh-related command line flags to the config.
 func SetShhConfig(ctx *cli.Context, stack *node.Node, cfg *whisper.Config) {
 	if ctx.GlobalIsSet(WhisperMaxMessageSizeFlag.Name) {
@@ -1254,6 +1498,18 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	if ctx.GlobalIsSet(GasPriceFlag.Name) {
 		cfg.GasPrice = GlobalBig(ctx, GasPriceFlag.Name)
 	}
+	if ctx.GlobalIsSet(MinerOrderingFlag.Name) {
+		cfg.MinerOrdering = ctx.GlobalString(MinerOrderingFlag.Name)
+	}
+	if ctx.GlobalIsSet(MinerPrioritySendersFlag.Name) {
+		cfg.MinerPrioritySenders = nil
+		for _, sender := range strings.Split(ctx.GlobalString(MinerPrioritySendersFlag.Name), ",") {
+			if sender = strings.TrimSpace(sender); !common.IsHexAddress(sender) {
+				Fatalf("Option %q: invalid address %q", MinerPrioritySendersFlag.Name, sender)
+			}
+			cfg.MinerPrioritySenders = append(cfg.MinerPrioritySenders, common.HexToAddress(sender))
+		}
+	}
 	if ctx.IsSet(CacheLogSizeFlag.Name) {
 		cfg.FilterLogCacheSize = ctx.Int(CacheLogSizeFlag.Name)
 	}
@@ -1469,6 +1725,32 @@ func RegisterFilterAPI(stack *node.Node, backend ethapi.Backend, ethcfg *ethconf
 	return filterSystem
 }
 
+// MakeFailoverManager creates the hot-standby failover manager if failover mode
+// is enabled, nil otherwise. The callbacks are invoked when the node becomes
+// active or goes back to standby.
+func MakeFailoverManager(ctx *cli.Context, onActive func(), onStandby func()) *failover.Manager {
+	if !ctx.GlobalBool(FailoverFlag.Name) {
+		return nil
+	}
+	path := ctx.GlobalString(FailoverLockFlag.Name)
+	if path == "" {
+		Fatalf("Failover mode requires --%s", FailoverLockFlag.Name)
+	}
+	holder := ctx.GlobalString(FailoverHolderFlag.Name)
+	if holder == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			Fatalf("Failed to resolve the failover holder: %v", err)
+		}
+		holder = hostname
+	}
+	config := failover.Config{
+		Holder:   holder,
+		Interval: ctx.GlobalDuration(FailoverIntervalFlag.Name),
+	}
+	return failover.New(failover.NewFileLockProvider(path), config, onActive, onStandby)
+}
+
 func SetupMetrics(ctx *cli.Context) {
 	if metrics.Enabled {
 		log.Info("Enabling metrics collection")
@@ -6,6 +6,8 @@ import (
 	"path/filepath"
 	"reflect"
 	"testing"
+
+	"github.com/XinFinOrg/XDPoSChain/rpc"
 )
 
 func TestWalkMatch(t *testing.T) {
@@ -70,3 +72,30 @@ func TestWalkMatch(t *testing.T) {
 		})
 	}
 }
+
+func TestParseRateLimitRule(t *testing.T) {
+	tests := []struct {
+		limit   string
+		want    rpc.RateLimitRule
+		wantErr bool
+	}{
+		{"eth_getLogs=10:20", rpc.RateLimitRule{Method: "eth_getLogs", Rate: 10, Burst: 20}, false},
+		{"debug_trace*=0.5", rpc.RateLimitRule{Method: "debug_trace*", Rate: 0.5, Burst: 1}, false},
+		{"*=2.5", rpc.RateLimitRule{Method: "*", Rate: 2.5, Burst: 3}, false},
+		{"eth_getLogs", rpc.RateLimitRule{}, true},
+		{"=1", rpc.RateLimitRule{}, true},
+		{"eth_getLogs=0", rpc.RateLimitRule{}, true},
+		{"eth_getLogs=1:0", rpc.RateLimitRule{}, true},
+		{"eth_getLogs=1:2:3", rpc.RateLimitRule{}, true},
+	}
+	for _, tt := range tests {
+		got, err := parseRateLimitRule(tt.limit)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseRateLimitRule(%q) error = %v, wantErr %v", tt.limit, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseRateLimitRule(%q) got = %+v, want %+v", tt.limit, got, tt.want)
+		}
+	}
+}
@@ -6,7 +6,10 @@ import (
 	"github.com/XinFinOrg/XDPoSChain/eth"
 	"github.com/XinFinOrg/XDPoSChain/eth/downloader"
 	"github.com/XinFinOrg/XDPoSChain/eth/ethconfig"
+	"github.com/XinFinOrg/XDPoSChain/eth/filters"
 	"github.com/XinFinOrg/XDPoSChain/ethstats"
+	"github.com/XinFinOrg/XDPoSChain/graphql"
+	"github.com/XinFinOrg/XDPoSChain/health"
 	"github.com/XinFinOrg/XDPoSChain/les"
 	"github.com/XinFinOrg/XDPoSChain/node"
 	whisper "github.com/XinFinOrg/XDPoSChain/whisper/whisperv6"
@@ -63,6 +66,35 @@ func RegisterEthStatsService(stack *node.Node, url string) {
 	}
 }
 
+// RegisterGraphQLService adds the GraphQL service of the full node to the HTTP
+// RPC endpoint.
+func RegisterGraphQLService(stack *node.Node) {
+	if err := stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
+		var ethServ *eth.Ethereum
+		if err := ctx.Service(&ethServ); err != nil {
+			return nil, err
+		}
+		filterSystem := filters.NewFilterSystem(ethServ.ApiBackend, filters.Config{})
+		return graphql.New(ethServ.ApiBackend, ethServ.BlockChain(), filterSystem)
+	}); err != nil {
+		Fatalf("Failed to register the GraphQL service: %v", err)
+	}
+}
+
+// RegisterHealthService adds the health and readiness checks of the full node
+// to the HTTP RPC endpoint.
+func RegisterHealthService(stack *node.Node, cfg *health.Config) {
+	if err := stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
+		var ethServ *eth.Ethereum
+		if err := ctx.Service(&ethServ); err != nil {
+			return nil, err
+		}
+		return health.New(ethServ, *cfg), nil
+	}); err != nil {
+		Fatalf("Failed to register the health service: %v", err)
+	}
+}
+
 func RegisterXDCXService(stack *node.Node, cfg *XDCx.Config) {
 	XDCX := XDCx.New(cfg)
 	if err := stack.Register(func(n *node.ServiceContext) (node.Service, error) {
@@ -0,0 +1,189 @@
+// Copyright (c) 2018 XDPoSChain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+// xdcxsim replays orders through the XDCx matching engine offline.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/XinFinOrg/XDPoSChain/XDCx"
+	"github.com/XinFinOrg/XDPoSChain/XDCx/simulator"
+	"github.com/XinFinOrg/XDPoSChain/cmd/utils"
+	"github.com/XinFinOrg/XDPoSChain/common"
+	"github.com/XinFinOrg/XDPoSChain/consensus"
+	"github.com/XinFinOrg/XDPoSChain/consensus/XDPoS"
+	"github.com/XinFinOrg/XDPoSChain/core"
+	"github.com/XinFinOrg/XDPoSChain/core/rawdb"
+	"github.com/XinFinOrg/XDPoSChain/core/state"
+	"github.com/XinFinOrg/XDPoSChain/core/types"
+	"github.com/XinFinOrg/XDPoSChain/ethdb"
+	"github.com/XinFinOrg/XDPoSChain/params"
+	"gopkg.in/urfave/cli.v1"
+)
+
+// Git SHA1 commit hash of the release (set via linker flags)
+var gitCommit = ""
+
+var app *cli.App
+
+var (
+	dataDirFlag = utils.DirectoryFlag{
+		Name:  "datadir",
+		Usage: "Data directory of a stopped node to load the state from, an empty state is used if not set",
+	}
+	XDCxDataDirFlag = utils.DirectoryFlag{
+		Name:  "XDCx.datadir",
+		Usage: "Data directory of the XDCx databases (default = <datadir>/XDCx)",
+	}
+	blockFlag = cli.Int64Flag{
+		Name:  "block",
+		Usage: "Number of the block to load the state at, the orders are matched in the next block (default = head block)",
+		Value: -1,
+	}
+	coinbaseFlag = cli.StringFlag{
+		Name:  "coinbase",
+		Usage: "Masternode producing the simulated blocks, which is paid the matching fees (default = author of the loaded block)",
+	}
+	outputFlag = cli.StringFlag{
+		Name:  "output",
+		Usage: "File to write the results to (default = stdout)",
+	}
+)
+
+func init() {
+	app = utils.NewApp(gitCommit, "the XDCx matching engine simulator")
+	app.ArgsUsage = "<scenario.json>"
+	app.Flags = []cli.Flag{
+		dataDirFlag,
+		XDCxDataDirFlag,
+		blockFlag,
+		coinbaseFlag,
+		outputFlag,
+	}
+	app.Action = simulate
+}
+
+func main() {
+	if err := app.Run(os.Args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func simulate(ctx *cli.Context) error {
+	if len(ctx.Args()) != 1 {
+		utils.Fatalf("This command requires a scenario file.")
+	}
+	data, err := os.ReadFile(ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	var scenario Scenario
+	if err := json.Unmarshal(data, &scenario); err != nil {
+		return fmt.Errorf("invalid scenario: %v", err)
+	}
+	var sim *simulator.Simulator
+	if ctx.GlobalIsSet(dataDirFlag.Name) {
+		sim, err = loadSimulator(ctx)
+	} else {
+		sim, err = simulator.NewEmpty(params.XDCMainnetChainConfig, scenario.Block, common.HexToAddress(ctx.GlobalString(coinbaseFlag.Name)))
+	}
+	if err != nil {
+		return err
+	}
+	result, err := scenario.Run(sim)
+	if err != nil {
+		return err
+	}
+	out, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return err
+	}
+	if ctx.GlobalIsSet(outputFlag.Name) {
+		return os.WriteFile(ctx.GlobalString(outputFlag.Name), out, 0644)
+	}
+	fmt.Println(string(out))
+	return nil
+}
+
+// loadSimulator creates a simulator on top of the state of a node. The
+// databases are opened read-only in spirit, nothing is ever written to them,
+// but the node must be stopped as they can't be shared.
+func loadSimulator(ctx *cli.Context) (*simulator.Simulator, error) {
+	dataDir := ctx.GlobalString(dataDirFlag.Name)
+	db, err := rawdb.NewLevelDBDatabase(filepath.Join(dataDir, "XDC", "chaindata"), 128, utils.MakeDatabaseHandles(0), "")
+	if err != nil {
+		return nil, err
+	}
+	head := core.GetHeadBlockHash(db)
+	if head == (common.Hash{}) {
+		return nil, fmt.Errorf("no chain found in %s", dataDir)
+	}
+	number := core.GetBlockNumber(db, head)
+	if ctx.GlobalInt64(blockFlag.Name) >= 0 {
+		number = uint64(ctx.GlobalInt64(blockFlag.Name))
+	}
+	block := core.GetBlock(db, core.GetCanonicalHash(db, number), number)
+	if block == nil {
+		return nil, fmt.Errorf("block %d not found", number)
+	}
+	config, err := core.GetChainConfig(db, core.GetCanonicalHash(db, 0))
+	if err != nil {
+		return nil, err
+	}
+	chain := &chainReader{db: db, config: config, engine: XDPoS.New(config, db), current: core.GetHeader(db, head, core.GetBlockNumber(db, head))}
+	author, err := chain.engine.Author(block.Header())
+	if err != nil {
+		return nil, err
+	}
+	statedb, err := state.New(block.Root(), state.NewDatabase(db))
+	if err != nil {
+		return nil, err
+	}
+	XDCxDataDir := filepath.Join(dataDir, "XDCx")
+	if ctx.GlobalIsSet(XDCxDataDirFlag.Name) {
+		XDCxDataDir = ctx.GlobalString(XDCxDataDirFlag.Name)
+	}
+	tradingService := XDCx.New(&XDCx.Config{DataDir: XDCxDataDir})
+	tradingState, err := tradingService.GetTradingState(block, author)
+	if err != nil {
+		return nil, err
+	}
+	coinbase := author
+	if ctx.GlobalIsSet(coinbaseFlag.Name) {
+		coinbase = common.HexToAddress(ctx.GlobalString(coinbaseFlag.Name))
+	}
+	return simulator.New(chain, block.Header(), coinbase, statedb, tradingState)
+}
+
+// chainReader is the chain of a stopped node, read from its database.
+type chainReader struct {
+	db      ethdb.Database
+	config  *params.ChainConfig
+	engine  consensus.Engine
+	current *types.Header
+}
+
+func (c *chainReader) Engine() consensus.Engine     { return c.engine }
+func (c *chainReader) Config() *params.ChainConfig  { return c.config }
+func (c *chainReader) CurrentHeader() *types.Header { return c.current }
+
+func (c *chainReader) GetHeader(hash common.Hash, number uint64) *types.Header {
+	return core.GetHeader(c.db, hash, number)
+}
@@ -0,0 +1,323 @@
+// Copyright (c) 2018 XDPoSChain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/XinFinOrg/XDPoSChain/XDCx/simulator"
+	"github.com/XinFinOrg/XDPoSChain/XDCx/tradingstate"
+	"github.com/XinFinOrg/XDPoSChain/common"
+	"github.com/XinFinOrg/XDPoSChain/common/hexutil"
+	"github.com/XinFinOrg/XDPoSChain/common/math"
+	"github.com/XinFinOrg/XDPoSChain/core/types"
+	"github.com/XinFinOrg/XDPoSChain/crypto"
+	"github.com/XinFinOrg/XDPoSChain/rlp"
+)
+
+// Scenario is the setup of a simulation followed by the orders of each
+// simulated block. The setup is applied on top of the loaded state, so it can
+// mock balances and relayers of a real chain as well.
+type Scenario struct {
+	Block    uint64            `json:"block"` // first block of a simulation on an empty state
+	Tokens   []ScenarioToken   `json:"tokens"`
+	Relayers []ScenarioRelayer `json:"relayers"`
+	Accounts []ScenarioAccount `json:"accounts"`
+	Prices   []ScenarioPrice   `json:"prices"`
+	Blocks   []ScenarioBlock   `json:"blocks"`
+}
+
+// ScenarioToken sets the decimals of a token instead of reading them from
+// its contract.
+type ScenarioToken struct {
+	Address  common.Address `json:"address"`
+	Decimals uint8          `json:"decimals"`
+}
+
+// ScenarioRelayer registers or updates a relayer.
+type ScenarioRelayer struct {
+	Coinbase common.Address        `json:"coinbase"`
+	Owner    common.Address        `json:"owner"`
+	Deposit  *math.HexOrDecimal256 `json:"deposit"`
+	Fee      *math.HexOrDecimal256 `json:"fee"`
+	Pairs    []ScenarioPair        `json:"pairs"`
+}
+
+// ScenarioPair is a trading pair.
+type ScenarioPair struct {
+	BaseToken  common.Address `json:"baseToken"`
+	QuoteToken common.Address `json:"quoteToken"`
+}
+
+// ScenarioAccount is a trader. Accounts with a private key sign the orders of
+// the scenario, the others only hold balances.
+type ScenarioAccount struct {
+	Key      string                                   `json:"key"`
+	Address  common.Address                           `json:"address"`
+	Balances map[common.Address]*math.HexOrDecimal256 `json:"balances"`
+}
+
+// ScenarioPrice overrides the prices of a pair.
+type ScenarioPrice struct {
+	ScenarioPair
+	LastPrice  *math.HexOrDecimal256 `json:"lastPrice"`
+	EpochPrice *math.HexOrDecimal256 `json:"epochPrice"`
+}
+
+// ScenarioBlock holds the orders matched in a block. Orders are signed with
+// the keys of their accounts while transactions are signed order
+// transactions, RLP encoded, like the ones recorded by a node.
+type ScenarioBlock struct {
+	Orders       []ScenarioOrder `json:"orders"`
+	Transactions []hexutil.Bytes `json:"transactions"`
+}
+
+// ScenarioOrder is a synthetic order. The nonce is the next one of the
+// account unless set.
+type ScenarioOrder struct {
+	Account          common.Address        `json:"account"`
+	Nonce            *math.HexOrDecimal64  `json:"nonce"`
+	ExchangeAddress  common.Address        `json:"exchangeAddress"`
+	BaseToken        common.Address        `json:"baseToken"`
+	QuoteToken       common.Address        `json:"quoteToken"`
+	Status           string                `json:"status"`
+	Side             string                `json:"side"`
+	Type             string                `json:"type"`
+	Quantity         *math.HexOrDecimal256 `json:"quantity"`
+	Price            *math.HexOrDecimal256 `json:"price"`
+	Hash             common.Hash           `json:"hash"`    // order to cancel
+	OrderID          uint64                `json:"orderID"` // order to cancel
+	TimeInForce      string                `json:"timeInForce"`
+	PostOnly         bool                  `json:"postOnly"`
+	ExpireBlock      uint64                `json:"expireBlock"`
+	TriggerPrice     *math.HexOrDecimal256 `json:"triggerPrice"`
+	TriggerCondition string                `json:"triggerCondition"`
+}
+
+// SimulationResult is the outcome of a scenario.
+type SimulationResult struct {
+	Blocks   []BlockResult   `json:"blocks"`
+	Balances []BalanceResult `json:"balances"`
+	Relayers []RelayerResult `json:"relayers"`
+}
+
+// BlockResult holds the outcome of the orders of a block.
+type BlockResult struct {
+	Number uint64        `json:"number"`
+	Orders []OrderResult `json:"orders"`
+}
+
+// OrderResult is the outcome of an order, Error is set if it was dropped
+// before matching.
+type OrderResult struct {
+	Order   *tradingstate.OrderItem   `json:"order"`
+	Trades  []map[string]string       `json:"trades"`
+	Rejects []*tradingstate.OrderItem `json:"rejects"`
+	Error   string                    `json:"error,omitempty"`
+}
+
+// BalanceResult is the final balance of an account in a token.
+type BalanceResult struct {
+	Account common.Address `json:"account"`
+	Token   common.Address `json:"token"`
+	Balance string         `json:"balance"`
+}
+
+// RelayerResult sums up the fees of a relayer.
+type RelayerResult struct {
+	Coinbase    common.Address            `json:"coinbase"`
+	Owner       common.Address            `json:"owner"`
+	Deposit     string                    `json:"deposit"`
+	Trades      uint64                    `json:"trades"`
+	MatchingFee string                    `json:"matchingFee"`
+	TradingFees map[common.Address]string `json:"tradingFees"`
+}
+
+// Run applies the scenario to the simulator.
+func (s *Scenario) Run(sim *simulator.Simulator) (*SimulationResult, error) {
+	keys := make(map[common.Address]*ecdsa.PrivateKey)
+	tokens := map[common.Address]bool{common.XDCNativeAddressBinary: true}
+	accounts := make(map[common.Address]bool)
+
+	for _, token := range s.Tokens {
+		sim.SetTokenDecimals(token.Address, token.Decimals)
+		tokens[token.Address] = true
+	}
+	for _, relayer := range s.Relayers {
+		pairs := make([]simulator.Pair, len(relayer.Pairs))
+		for i, pair := range relayer.Pairs {
+			pairs[i] = simulator.Pair{BaseToken: pair.BaseToken, QuoteToken: pair.QuoteToken}
+			tokens[pair.BaseToken], tokens[pair.QuoteToken] = true, true
+		}
+		sim.RegisterRelayer(simulator.Relayer{
+			Coinbase: relayer.Coinbase,
+			Owner:    relayer.Owner,
+			Deposit:  (*big.Int)(relayer.Deposit),
+			Fee:      (*big.Int)(relayer.Fee),
+			Pairs:    pairs,
+		})
+		accounts[relayer.Owner] = true
+	}
+	for _, account := range s.Accounts {
+		address := account.Address
+		if account.Key != "" {
+			key, err := crypto.HexToECDSA(account.Key)
+			if err != nil {
+				return nil, fmt.Errorf("invalid key of account %s: %v", account.Address.Hex(), err)
+			}
+			address = crypto.PubkeyToAddress(key.PublicKey)
+			keys[address] = key
+		}
+		accounts[address] = true
+		for token, balance := range account.Balances {
+			if err := sim.SetBalance(address, token, (*big.Int)(balance)); err != nil {
+				return nil, err
+			}
+			tokens[token] = true
+		}
+	}
+	for _, price := range s.Prices {
+		if price.LastPrice != nil {
+			sim.SetLastPrice(price.BaseToken, price.QuoteToken, (*big.Int)(price.LastPrice))
+		}
+		if price.EpochPrice != nil {
+			sim.SetEpochPrice(price.BaseToken, price.QuoteToken, (*big.Int)(price.EpochPrice))
+		}
+	}
+
+	result := &SimulationResult{Blocks: []BlockResult{}}
+	for i, block := range s.Blocks {
+		if i > 0 {
+			if err := sim.NextBlock(); err != nil {
+				return nil, err
+			}
+		}
+		blockResult := BlockResult{Number: sim.Header().Number.Uint64(), Orders: []OrderResult{}}
+		for j, scenarioOrder := range block.Orders {
+			key := keys[scenarioOrder.Account]
+			if key == nil {
+				return nil, fmt.Errorf("block %d order %d: no key for account %s", i, j, scenarioOrder.Account.Hex())
+			}
+			order := scenarioOrder.orderItem()
+			if err := sim.SignOrder(order, key); err != nil {
+				return nil, err
+			}
+			applied, err := sim.Apply(order)
+			if err != nil {
+				blockResult.Orders = append(blockResult.Orders, OrderResult{Order: order, Error: err.Error()})
+				continue
+			}
+			blockResult.Orders = append(blockResult.Orders, newOrderResult(applied))
+		}
+		if len(block.Transactions) > 0 {
+			txs := make(types.OrderTransactions, len(block.Transactions))
+			for j, data := range block.Transactions {
+				txs[j] = new(types.OrderTransaction)
+				if err := rlp.DecodeBytes(data, txs[j]); err != nil {
+					return nil, fmt.Errorf("block %d transaction %d: %v", i, j, err)
+				}
+				accounts[txs[j].UserAddress()] = true
+			}
+			applied, err := sim.ApplyTransactions(txs)
+			if err != nil {
+				return nil, err
+			}
+			for _, order := range applied {
+				blockResult.Orders = append(blockResult.Orders, newOrderResult(order))
+			}
+		}
+		result.Blocks = append(result.Blocks, blockResult)
+	}
+
+	for _, account := range sortedAddresses(accounts) {
+		for _, token := range sortedAddresses(tokens) {
+			result.Balances = append(result.Balances, BalanceResult{Account: account, Token: token, Balance: sim.Balance(account, token).String()})
+		}
+	}
+	fees := sim.Fees()
+	relayers := make(map[common.Address]bool)
+	for _, relayer := range s.Relayers {
+		relayers[relayer.Coinbase] = true
+	}
+	for relayer := range fees {
+		relayers[relayer] = true
+	}
+	for _, coinbase := range sortedAddresses(relayers) {
+		relayer := RelayerResult{
+			Coinbase:    coinbase,
+			Owner:       tradingstate.GetRelayerOwner(coinbase, sim.State()),
+			Deposit:     tradingstate.GetRelayerDeposit(coinbase, sim.State()).String(),
+			MatchingFee: "0",
+			TradingFees: map[common.Address]string{},
+		}
+		if fee := fees[coinbase]; fee != nil {
+			relayer.Trades = fee.Trades
+			relayer.MatchingFee = fee.MatchingFee.String()
+			for token, amount := range fee.TradingFees {
+				relayer.TradingFees[token] = amount.String()
+			}
+		}
+		result.Relayers = append(result.Relayers, relayer)
+	}
+	return result, nil
+}
+
+// orderItem converts the scenario order into an unsigned order.
+func (o *ScenarioOrder) orderItem() *tradingstate.OrderItem {
+	order := &tradingstate.OrderItem{
+		Quantity:         (*big.Int)(o.Quantity),
+		Price:            (*big.Int)(o.Price),
+		ExchangeAddress:  o.ExchangeAddress,
+		BaseToken:        o.BaseToken,
+		QuoteToken:       o.QuoteToken,
+		Status:           o.Status,
+		Side:             o.Side,
+		Type:             o.Type,
+		Hash:             o.Hash,
+		OrderID:          o.OrderID,
+		TimeInForce:      o.TimeInForce,
+		PostOnly:         o.PostOnly,
+		ExpireBlock:      o.ExpireBlock,
+		TriggerPrice:     (*big.Int)(o.TriggerPrice),
+		TriggerCondition: o.TriggerCondition,
+	}
+	if order.Type == "" {
+		order.Type = tradingstate.Limit
+	}
+	if o.Nonce != nil {
+		order.Nonce = new(big.Int).SetUint64(uint64(*o.Nonce))
+	}
+	return order
+}
+
+func newOrderResult(result *simulator.Result) OrderResult {
+	return OrderResult{Order: result.Order, Trades: result.Trades, Rejects: result.Rejects}
+}
+
+func sortedAddresses(set map[common.Address]bool) []common.Address {
+	addresses := make([]common.Address, 0, len(set))
+	for address := range set {
+		addresses = append(addresses, address)
+	}
+	sort.Slice(addresses, func(i, j int) bool {
+		return bytes.Compare(addresses[i][:], addresses[j][:]) < 0
+	})
+	return addresses
+}
@@ -47,11 +47,14 @@ var TIPXDCXCancellationFee = big.NewInt(38383838)
 var TIPXDCXCancellationFeeTestnet = big.NewInt(38383838)
 var TIPXDCXMinerDisable = big.NewInt(80370000)    // Target 2nd Oct 2024
 var TIPXDCXReceiverDisable = big.NewInt(80370900) // Target 2nd Oct 2024, safer to release after disable miner
+var TIPXDCXOrderTypes = big.NewInt(9999999999)    // time-in-force, post-only and good-til-block orders
+var TIPXDCXTriggerOrders = big.NewInt(9999999999) // stop-loss and take-profit orders
 var Eip1559Block = big.NewInt(9999999999)
 var BerlinBlock = big.NewInt(76321000)   // Target 19th June 2024
 var LondonBlock = big.NewInt(76321000)   // Target 19th June 2024
 var MergeBlock = big.NewInt(76321000)    // Target 19th June 2024
 var ShanghaiBlock = big.NewInt(76321000) // Target 19th June 2024
+var CancunBlock = big.NewInt(9999999999) // transient storage, MCOPY and EIP-6780 SELFDESTRUCT
 
 var TIPXDCXTestnet = big.NewInt(38383838)
 var IsTestnet bool = false
@@ -25,6 +25,19 @@ func GetGasPrice(number *big.Int) *big.Int {
 	}
 }
 
+// GetTRC21GasPrice returns the gas price of the transactions whose fee is paid
+// by the TRC21 issuer at the given block. An unknown block gets the highest
+// price, so the fee is never underestimated.
+func GetTRC21GasPrice(number *big.Int) *big.Int {
+	if number == nil || number.Cmp(BlockNumberGas50x) >= 0 {
+		return new(big.Int).Set(GasPrice50x)
+	} else if number.Cmp(TIPTRC21Fee) > 0 {
+		return new(big.Int).Set(TRC21GasPrice)
+	} else {
+		return new(big.Int).Set(TRC21GasPriceBefore)
+	}
+}
+
 func GetMinGasPrice(number *big.Int) *big.Int {
 	if number == nil || number.Cmp(BlockNumberGas50x) < 0 {
 		return new(big.Int).Set(MinGasPrice)
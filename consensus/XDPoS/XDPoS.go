@@ -182,6 +182,11 @@ func (x *XDPoS) APIs(chain consensus.ChainReader) []rpc.API {
 		Version:   "1.0",
 		Service:   &API{chain: chain, XDPoS: x},
 		Public:    true,
+	}, {
+		Namespace: "XDPoS",
+		Version:   "1.0",
+		Service:   &PrivateAPI{XDPoS: x},
+		Public:    false,
 	}}
 }
 
@@ -287,6 +292,9 @@ func (x *XDPoS) Seal(chain consensus.ChainReader, block *types.Block, stop <-cha
 	case params.ConsensusEngineVersion2:
 		return x.EngineV2.Seal(chain, block, stop)
 	default: // Default "v1"
+		if x.EngineV2.IsObserveOnly() {
+			return nil, utils.ErrObserveOnly
+		}
 		return x.EngineV1.Seal(chain, block, stop)
 	}
 }
@@ -324,6 +332,12 @@ func (x *XDPoS) Authorize(signer common.Address, signFn clique.SignerFn) {
 	x.EngineV2.Authorize(signer, signFn)
 }
 
+// SetObserveOnly puts the engine in hot-standby mode: blocks and consensus
+// messages are still verified and processed, but nothing is signed.
+func (x *XDPoS) SetObserveOnly(observeOnly bool) {
+	x.EngineV2.SetObserveOnly(observeOnly)
+}
+
 func (x *XDPoS) GetPeriod() uint64 {
 	return x.config.Period
 }
@@ -443,6 +457,17 @@ func (x *XDPoS) CalculateMissingRounds(chain consensus.ChainReader, header *type
 	}
 }
 
+// GetNextEpochMasternodes estimates the masternodes of the epoch following the
+// one of the given header.
+func (x *XDPoS) GetNextEpochMasternodes(chain consensus.ChainReader, header *types.Header) ([]common.Address, error) {
+	switch x.config.BlockConsensusVersion(header.Number, header.Extra, ExtraFieldCheck) {
+	case params.ConsensusEngineVersion2:
+		return x.EngineV2.GetNextEpochMasternodes(chain, header)
+	default: // Default "v1"
+		return nil, errors.New("Not supported in the v1 consensus")
+	}
+}
+
 // Same DB across all consensus engines
 func (x *XDPoS) GetDb() ethdb.Database {
 	return x.db
@@ -22,6 +22,7 @@ import (
 
 	"github.com/XinFinOrg/XDPoSChain/common"
 	"github.com/XinFinOrg/XDPoSChain/consensus"
+	"github.com/XinFinOrg/XDPoSChain/consensus/XDPoS/engines/engine_v2"
 	"github.com/XinFinOrg/XDPoSChain/consensus/XDPoS/utils"
 	"github.com/XinFinOrg/XDPoSChain/core/types"
 	"github.com/XinFinOrg/XDPoSChain/params"
@@ -36,6 +37,12 @@ type API struct {
 	XDPoS *XDPoS
 }
 
+// PrivateAPI exposes the methods of the proof-of-authority scheme which are
+// only meant for the node operator.
+type PrivateAPI struct {
+	XDPoS *XDPoS
+}
+
 type V2BlockInfo struct {
 	Hash       common.Hash
 	Round      types.Round
@@ -344,3 +351,15 @@ func (api *API) GetEpochNumbersBetween(begin, end *rpc.BlockNumber) ([]uint64, e
 	}
 	return epochSwitchNumbers, nil
 }
+
+// ExportSlashingProtection returns the vote and timeout signing history of this
+// node in the slashing protection interchange format.
+func (api *PrivateAPI) ExportSlashingProtection() (*engine_v2.Interchange, error) {
+	return api.XDPoS.EngineV2.ExportSlashingProtection()
+}
+
+// ImportSlashingProtection merges a signing history exported from another node,
+// so that the key can be moved without signing conflicting messages.
+func (api *PrivateAPI) ImportSlashingProtection(interchange engine_v2.Interchange) error {
+	return api.XDPoS.EngineV2.ImportSlashingProtection(&interchange)
+}
@@ -36,12 +36,16 @@ type XDPoS_v2 struct {
 	signatures      *lru.ARCCache // Signatures of recent blocks to speed up mining
 	epochSwitches   *lru.ARCCache // infos of epoch: master nodes, epoch switch block info, parent of that info
 	verifiedHeaders *lru.ARCCache
+	messageSigners  *lru.ARCCache // Signers of vote and timeout signatures, shared by the messages and certificates carrying them
 
 	signer   common.Address  // Ethereum address of the signing key
 	signFn   clique.SignerFn // Signer function to authorize hashes with
 	lock     sync.RWMutex    // Protects the signer fields
 	signLock sync.RWMutex    // Protects the signer fields
 
+	observeOnly bool         // Standby mode, consensus messages are processed but never signed
+	observeLock sync.RWMutex // Held for reading while signing, so that switching to standby waits for in-flight signatures
+
 	BroadcastCh  chan interface{}
 	minePeriodCh chan int
 
@@ -63,6 +67,7 @@ type XDPoS_v2 struct {
 	HookPenalty func(chain consensus.ChainReader, number *big.Int, parentHash common.Hash, candidates []common.Address) ([]common.Address, error)
 
 	ForensicsProcessor *Forensics
+	slashingProtection *SlashingProtection // Refuses to sign votes and timeouts conflicting with the ones signed before
 
 	votePoolCollectionTime time.Time
 }
@@ -77,6 +82,7 @@ func New(chainConfig *params.ChainConfig, db ethdb.Database, minePeriodCh chan i
 	signatures, _ := lru.NewARC(utils.InmemorySnapshots)
 	epochSwitches, _ := lru.NewARC(int(utils.InmemoryEpochs))
 	verifiedHeaders, _ := lru.NewARC(utils.InmemorySnapshots)
+	messageSigners, _ := lru.NewARC(utils.InmemoryMessageSigners)
 
 	timeoutPool := utils.NewPool()
 	votePool := utils.NewPool()
@@ -90,6 +96,7 @@ func New(chainConfig *params.ChainConfig, db ethdb.Database, minePeriodCh chan i
 		signatures: signatures,
 
 		verifiedHeaders: verifiedHeaders,
+		messageSigners:  messageSigners,
 		snapshots:       snapshots,
 		epochSwitches:   epochSwitches,
 		timeoutWorker:   timeoutTimer,
@@ -117,6 +124,7 @@ func New(chainConfig *params.ChainConfig, db ethdb.Database, minePeriodCh chan i
 		highestVotedRound:  types.Round(0),
 		highestCommitBlock: nil,
 		ForensicsProcessor: NewForensics(),
+		slashingProtection: NewSlashingProtection(db, chainConfig.ChainId),
 	}
 	// Add callback to the timer
 	timeoutTimer.OnTimeoutFn = engine.OnCountdownTimeout
@@ -411,6 +419,21 @@ func (x *XDPoS_v2) Authorize(signer common.Address, signFn clique.SignerFn) {
 	x.signFn = signFn
 }
 
+// SetObserveOnly switches the engine between signing and observe-only mode. It
+// only returns once no block, vote or timeout is being signed any more.
+func (x *XDPoS_v2) SetObserveOnly(observeOnly bool) {
+	x.observeLock.Lock()
+	defer x.observeLock.Unlock()
+
+	x.observeOnly = observeOnly
+}
+
+func (x *XDPoS_v2) IsObserveOnly() bool {
+	x.observeLock.RLock()
+	defer x.observeLock.RUnlock()
+	return x.observeOnly
+}
+
 func (x *XDPoS_v2) Author(header *types.Header) (common.Address, error) {
 	return ecrecover(header, x.signatures)
 }
@@ -426,6 +449,12 @@ func (x *XDPoS_v2) Seal(chain consensus.ChainReader, block *types.Block, stop <-
 		return nil, utils.ErrUnknownBlock
 	}
 
+	x.observeLock.RLock()
+	defer x.observeLock.RUnlock()
+	if x.observeOnly {
+		return nil, utils.ErrObserveOnly
+	}
+
 	// Don't hold the signer fields for the entire sealing procedure
 	x.signLock.RLock()
 	signer, signFn := x.signer, x.signFn
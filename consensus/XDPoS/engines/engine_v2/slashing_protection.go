@@ -0,0 +1,468 @@
+package engine_v2
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/XinFinOrg/XDPoSChain/common"
+	"github.com/XinFinOrg/XDPoSChain/consensus/XDPoS/utils"
+	"github.com/XinFinOrg/XDPoSChain/core/types"
+	"github.com/XinFinOrg/XDPoSChain/ethdb"
+	"github.com/XinFinOrg/XDPoSChain/log"
+)
+
+/*
+Slashing protection
+
+Before this node signs a vote or a timeout message, the message is checked
+against the history of messages previously signed by the same key. A vote is
+refused if a different block (or gap number) was already voted for in the same
+round, or if its round is lower than the highest round ever voted. A timeout is
+refused if its round is lower than the highest timeout round ever signed, or if
+a higher gap number was already signed in the same round. The timeout of the
+current round is re-broadcast until the round changes and its gap number
+follows the chain head, so it may be re-signed with the same or a higher gap
+number, the latest of which is recorded. Re-signing the exact same vote is
+always allowed.
+
+The history can be moved between machines with the interchange format below.
+All integers are encoded as decimal strings, hashes and addresses as 0x hex:
+
+	{
+	  "metadata": {
+	    "interchange_format_version": "1",
+	    "chain_id": "50"
+	  },
+	  "data": [
+	    {
+	      "signer": "0x...",
+	      "highest_vote_round": "1024",
+	      "highest_timeout_round": "1020",
+	      "signed_votes": [
+	        { "round": "1024", "block_hash": "0x...", "gap_number": "450" }
+	      ],
+	      "signed_timeouts": [
+	        { "round": "1020", "gap_number": "450" }
+	      ]
+	    }
+	  ]
+	}
+
+Importing merges the records into the local database. The import is refused as
+a whole if any imported record conflicts with a local one.
+*/
+
+const (
+	SlashingProtectionInterchangeVersion = "1"
+	slashingProtectionHistoryRounds      = 1024 // Number of rounds of signing history kept per signer
+)
+
+var (
+	slashingProtectionVotePrefix    = []byte("XDPoS-V2-SP-vote-")    // prefix + signer + round (uint64 big endian) -> SignedVote
+	slashingProtectionTimeoutPrefix = []byte("XDPoS-V2-SP-timeout-") // prefix + signer + round (uint64 big endian) -> SignedTimeout
+	slashingProtectionHighestPrefix = []byte("XDPoS-V2-SP-highest-") // prefix + signer -> highestSigned
+	slashingProtectionSignersKey    = []byte("XDPoS-V2-SP-signers")  // list of signers with a history
+
+	ErrInvalidInterchange = errors.New("invalid slashing protection interchange")
+)
+
+type SignedVote struct {
+	Round     types.Round `json:"round,string"`
+	BlockHash common.Hash `json:"block_hash"`
+	GapNumber uint64      `json:"gap_number,string"`
+}
+
+type SignedTimeout struct {
+	Round     types.Round `json:"round,string"`
+	GapNumber uint64      `json:"gap_number,string"`
+}
+
+type highestSigned struct {
+	VoteRound    types.Round `json:"voteRound"`
+	TimeoutRound types.Round `json:"timeoutRound"`
+}
+
+type InterchangeMetadata struct {
+	InterchangeFormatVersion string `json:"interchange_format_version"`
+	ChainId                  string `json:"chain_id"`
+}
+
+type InterchangeSigner struct {
+	Signer              common.Address  `json:"signer"`
+	HighestVoteRound    types.Round     `json:"highest_vote_round,string"`
+	HighestTimeoutRound types.Round     `json:"highest_timeout_round,string"`
+	SignedVotes         []SignedVote    `json:"signed_votes"`
+	SignedTimeouts      []SignedTimeout `json:"signed_timeouts"`
+}
+
+type Interchange struct {
+	Metadata InterchangeMetadata `json:"metadata"`
+	Data     []InterchangeSigner `json:"data"`
+}
+
+// SlashingProtection records the votes and timeouts signed by this node and
+// refuses to sign any message which conflicts with the recorded history.
+type SlashingProtection struct {
+	db      ethdb.Database
+	chainId *big.Int
+	lock    sync.Mutex
+}
+
+func NewSlashingProtection(db ethdb.Database, chainId *big.Int) *SlashingProtection {
+	return &SlashingProtection{
+		db:      db,
+		chainId: chainId,
+	}
+}
+
+// CheckAndRecordVote returns an error if signing a vote for the given block
+// would conflict with a vote signed before, otherwise it records the vote.
+func (sp *SlashingProtection) CheckAndRecordVote(signer common.Address, blockInfo *types.BlockInfo, gapNumber uint64) error {
+	sp.lock.Lock()
+	defer sp.lock.Unlock()
+
+	vote := SignedVote{Round: blockInfo.Round, BlockHash: blockInfo.Hash, GapNumber: gapNumber}
+	if err := sp.checkVote(signer, vote); err != nil {
+		return err
+	}
+	return sp.writeVotes(signer, []SignedVote{vote}, highestSigned{VoteRound: vote.Round})
+}
+
+// CheckAndRecordTimeout returns an error if signing a timeout for the given
+// round would conflict with a timeout signed before, otherwise it records it.
+func (sp *SlashingProtection) CheckAndRecordTimeout(signer common.Address, round types.Round, gapNumber uint64) error {
+	sp.lock.Lock()
+	defer sp.lock.Unlock()
+
+	timeout := SignedTimeout{Round: round, GapNumber: gapNumber}
+	if err := sp.checkTimeout(signer, timeout); err != nil {
+		return err
+	}
+	return sp.writeTimeouts(signer, []SignedTimeout{timeout}, highestSigned{TimeoutRound: timeout.Round})
+}
+
+func (sp *SlashingProtection) checkVote(signer common.Address, vote SignedVote) error {
+	var existing SignedVote
+	found, err := sp.get(slashingProtectionKey(slashingProtectionVotePrefix, signer, vote.Round), &existing)
+	if err != nil {
+		return err
+	}
+	if found {
+		if existing != vote {
+			return &utils.ErrSlashableSignature{
+				Type:   "vote",
+				Round:  vote.Round,
+				Reason: fmt.Sprintf("already voted for block %s with gap number %d", existing.BlockHash.Hex(), existing.GapNumber),
+			}
+		}
+		return nil
+	}
+	highest, err := sp.highest(signer)
+	if err != nil {
+		return err
+	}
+	if vote.Round <= highest.VoteRound {
+		return &utils.ErrSlashableSignature{
+			Type:   "vote",
+			Round:  vote.Round,
+			Reason: fmt.Sprintf("not higher than the highest voted round %v", highest.VoteRound),
+		}
+	}
+	return nil
+}
+
+func (sp *SlashingProtection) checkTimeout(signer common.Address, timeout SignedTimeout) error {
+	var existing SignedTimeout
+	found, err := sp.get(slashingProtectionKey(slashingProtectionTimeoutPrefix, signer, timeout.Round), &existing)
+	if err != nil {
+		return err
+	}
+	if found {
+		if timeout.GapNumber < existing.GapNumber {
+			return &utils.ErrSlashableSignature{
+				Type:   "timeout",
+				Round:  timeout.Round,
+				Reason: fmt.Sprintf("already signed a timeout with gap number %d", existing.GapNumber),
+			}
+		}
+		return nil
+	}
+	highest, err := sp.highest(signer)
+	if err != nil {
+		return err
+	}
+	if timeout.Round <= highest.TimeoutRound {
+		return &utils.ErrSlashableSignature{
+			Type:   "timeout",
+			Round:  timeout.Round,
+			Reason: fmt.Sprintf("not higher than the highest timeout round %v", highest.TimeoutRound),
+		}
+	}
+	return nil
+}
+
+// Export dumps the whole signing history in the interchange format.
+func (sp *SlashingProtection) Export() (*Interchange, error) {
+	sp.lock.Lock()
+	defer sp.lock.Unlock()
+
+	signers, err := sp.signers()
+	if err != nil {
+		return nil, err
+	}
+	interchange := &Interchange{
+		Metadata: InterchangeMetadata{
+			InterchangeFormatVersion: SlashingProtectionInterchangeVersion,
+			ChainId:                  sp.chainIdString(),
+		},
+		Data: []InterchangeSigner{},
+	}
+	for _, signer := range signers {
+		highest, err := sp.highest(signer)
+		if err != nil {
+			return nil, err
+		}
+		entry := InterchangeSigner{
+			Signer:              signer,
+			HighestVoteRound:    highest.VoteRound,
+			HighestTimeoutRound: highest.TimeoutRound,
+			SignedVotes:         []SignedVote{},
+			SignedTimeouts:      []SignedTimeout{},
+		}
+		err = sp.iterate(slashingProtectionVotePrefix, signer, func(blob []byte) error {
+			var vote SignedVote
+			if err := json.Unmarshal(blob, &vote); err != nil {
+				return err
+			}
+			entry.SignedVotes = append(entry.SignedVotes, vote)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		err = sp.iterate(slashingProtectionTimeoutPrefix, signer, func(blob []byte) error {
+			var timeout SignedTimeout
+			if err := json.Unmarshal(blob, &timeout); err != nil {
+				return err
+			}
+			entry.SignedTimeouts = append(entry.SignedTimeouts, timeout)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		interchange.Data = append(interchange.Data, entry)
+	}
+	return interchange, nil
+}
+
+// Import merges an interchange into the local signing history. Nothing is
+// written if the interchange conflicts with the local history.
+func (sp *SlashingProtection) Import(interchange *Interchange) error {
+	sp.lock.Lock()
+	defer sp.lock.Unlock()
+
+	if interchange.Metadata.InterchangeFormatVersion != SlashingProtectionInterchangeVersion {
+		return fmt.Errorf("%w: unsupported version %q", ErrInvalidInterchange, interchange.Metadata.InterchangeFormatVersion)
+	}
+	if interchange.Metadata.ChainId != sp.chainIdString() {
+		return fmt.Errorf("%w: chain id %q does not match local chain id %q", ErrInvalidInterchange, interchange.Metadata.ChainId, sp.chainIdString())
+	}
+	// Make sure every record can be merged before touching the database
+	for _, entry := range interchange.Data {
+		for _, vote := range entry.SignedVotes {
+			var existing SignedVote
+			found, err := sp.get(slashingProtectionKey(slashingProtectionVotePrefix, entry.Signer, vote.Round), &existing)
+			if err != nil {
+				return err
+			}
+			if found && existing != vote {
+				return fmt.Errorf("%w: signer %s voted for %s at round %v, local history has %s", ErrInvalidInterchange, entry.Signer.Hex(), vote.BlockHash.Hex(), vote.Round, existing.BlockHash.Hex())
+			}
+		}
+		for _, timeout := range entry.SignedTimeouts {
+			var existing SignedTimeout
+			found, err := sp.get(slashingProtectionKey(slashingProtectionTimeoutPrefix, entry.Signer, timeout.Round), &existing)
+			if err != nil {
+				return err
+			}
+			if found && existing != timeout {
+				return fmt.Errorf("%w: signer %s signed timeout with gap number %d at round %v, local history has %d", ErrInvalidInterchange, entry.Signer.Hex(), timeout.GapNumber, timeout.Round, existing.GapNumber)
+			}
+		}
+	}
+	for _, entry := range interchange.Data {
+		highest := highestSigned{VoteRound: entry.HighestVoteRound, TimeoutRound: entry.HighestTimeoutRound}
+		if err := sp.writeVotes(entry.Signer, entry.SignedVotes, highest); err != nil {
+			return err
+		}
+		if err := sp.writeTimeouts(entry.Signer, entry.SignedTimeouts, highest); err != nil {
+			return err
+		}
+	}
+	log.Info("[Import] imported slashing protection history", "signers", len(interchange.Data))
+	return nil
+}
+
+func (sp *SlashingProtection) writeVotes(signer common.Address, votes []SignedVote, highest highestSigned) error {
+	for _, vote := range votes {
+		if vote.Round > highest.VoteRound {
+			highest.VoteRound = vote.Round
+		}
+	}
+	batch := sp.db.NewBatch()
+	for _, vote := range votes {
+		if err := putJSON(batch, slashingProtectionKey(slashingProtectionVotePrefix, signer, vote.Round), vote); err != nil {
+			return err
+		}
+	}
+	if err := sp.updateHighest(batch, signer, highest); err != nil {
+		return err
+	}
+	return batch.Write()
+}
+
+func (sp *SlashingProtection) writeTimeouts(signer common.Address, timeouts []SignedTimeout, highest highestSigned) error {
+	for _, timeout := range timeouts {
+		if timeout.Round > highest.TimeoutRound {
+			highest.TimeoutRound = timeout.Round
+		}
+	}
+	batch := sp.db.NewBatch()
+	for _, timeout := range timeouts {
+		if err := putJSON(batch, slashingProtectionKey(slashingProtectionTimeoutPrefix, signer, timeout.Round), timeout); err != nil {
+			return err
+		}
+	}
+	if err := sp.updateHighest(batch, signer, highest); err != nil {
+		return err
+	}
+	return batch.Write()
+}
+
+// updateHighest merges the given rounds into the stored highest rounds of the
+// signer and prunes the records which fall out of the history window.
+func (sp *SlashingProtection) updateHighest(batch ethdb.Batch, signer common.Address, update highestSigned) error {
+	highest, err := sp.highest(signer)
+	if err != nil {
+		return err
+	}
+	if update.VoteRound > highest.VoteRound {
+		highest.VoteRound = update.VoteRound
+	}
+	if update.TimeoutRound > highest.TimeoutRound {
+		highest.TimeoutRound = update.TimeoutRound
+	}
+	if err := putJSON(batch, append(common.CopyBytes(slashingProtectionHighestPrefix), signer.Bytes()...), highest); err != nil {
+		return err
+	}
+	if err := sp.prune(batch, slashingProtectionVotePrefix, signer, highest.VoteRound); err != nil {
+		return err
+	}
+	if err := sp.prune(batch, slashingProtectionTimeoutPrefix, signer, highest.TimeoutRound); err != nil {
+		return err
+	}
+
+	signers, err := sp.signers()
+	if err != nil {
+		return err
+	}
+	for _, s := range signers {
+		if s == signer {
+			return nil
+		}
+	}
+	return putJSON(batch, slashingProtectionSignersKey, append(signers, signer))
+}
+
+// prune deletes the records older than the history window. Rounds are stored
+// in ascending order, so the iteration stops at the first retained record.
+func (sp *SlashingProtection) prune(batch ethdb.Batch, prefix []byte, signer common.Address, highest types.Round) error {
+	if highest <= slashingProtectionHistoryRounds {
+		return nil
+	}
+	cutoff := highest - slashingProtectionHistoryRounds
+	signerPrefix := append(common.CopyBytes(prefix), signer.Bytes()...)
+	it := sp.db.NewIterator(signerPrefix, nil)
+	defer it.Release()
+	for it.Next() {
+		round := types.Round(binary.BigEndian.Uint64(it.Key()[len(signerPrefix):]))
+		if round >= cutoff {
+			break
+		}
+		if err := batch.Delete(common.CopyBytes(it.Key())); err != nil {
+			return err
+		}
+	}
+	return it.Error()
+}
+
+func (sp *SlashingProtection) iterate(prefix []byte, signer common.Address, fn func(blob []byte) error) error {
+	it := sp.db.NewIterator(append(common.CopyBytes(prefix), signer.Bytes()...), nil)
+	defer it.Release()
+	for it.Next() {
+		if err := fn(it.Value()); err != nil {
+			return err
+		}
+	}
+	return it.Error()
+}
+
+func (sp *SlashingProtection) highest(signer common.Address) (highestSigned, error) {
+	var highest highestSigned
+	_, err := sp.get(append(common.CopyBytes(slashingProtectionHighestPrefix), signer.Bytes()...), &highest)
+	return highest, err
+}
+
+func (sp *SlashingProtection) signers() ([]common.Address, error) {
+	var signers []common.Address
+	_, err := sp.get(slashingProtectionSignersKey, &signers)
+	return signers, err
+}
+
+func (sp *SlashingProtection) get(key []byte, val interface{}) (bool, error) {
+	if ok, err := sp.db.Has(key); err != nil || !ok {
+		return false, err
+	}
+	blob, err := sp.db.Get(key)
+	if err != nil {
+		return false, err
+	}
+	return true, json.Unmarshal(blob, val)
+}
+
+func (sp *SlashingProtection) chainIdString() string {
+	if sp.chainId == nil {
+		return "0"
+	}
+	return sp.chainId.String()
+}
+
+func putJSON(w ethdb.KeyValueWriter, key []byte, val interface{}) error {
+	blob, err := json.Marshal(val)
+	if err != nil {
+		return err
+	}
+	return w.Put(key, blob)
+}
+
+// slashingProtectionKey = prefix + signer + round (uint64 big endian)
+func slashingProtectionKey(prefix []byte, signer common.Address, round types.Round) []byte {
+	enc := make([]byte, 8)
+	binary.BigEndian.PutUint64(enc, uint64(round))
+	return append(append(common.CopyBytes(prefix), signer.Bytes()...), enc...)
+}
+
+// ExportSlashingProtection dumps the signing history of this node.
+func (x *XDPoS_v2) ExportSlashingProtection() (*Interchange, error) {
+	return x.slashingProtection.Export()
+}
+
+// ImportSlashingProtection merges a signing history exported from another node.
+func (x *XDPoS_v2) ImportSlashingProtection(interchange *Interchange) error {
+	return x.slashingProtection.Import(interchange)
+}
@@ -0,0 +1,124 @@
+package engine_v2
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/XinFinOrg/XDPoSChain/common"
+	"github.com/XinFinOrg/XDPoSChain/consensus/XDPoS/utils"
+	"github.com/XinFinOrg/XDPoSChain/core/rawdb"
+	"github.com/XinFinOrg/XDPoSChain/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlashingProtectionVote(t *testing.T) {
+	sp := NewSlashingProtection(rawdb.NewMemoryDatabase(), big.NewInt(50))
+	signer := common.HexToAddress("0x1")
+	blockInfo := &types.BlockInfo{Hash: common.HexToHash("0xa"), Round: 10, Number: big.NewInt(900)}
+
+	assert.Nil(t, sp.CheckAndRecordVote(signer, blockInfo, 450))
+	// Signing the very same vote again is harmless
+	assert.Nil(t, sp.CheckAndRecordVote(signer, blockInfo, 450))
+
+	// Another block in the same round is a double vote
+	conflict := &types.BlockInfo{Hash: common.HexToHash("0xb"), Round: 10, Number: big.NewInt(900)}
+	err := sp.CheckAndRecordVote(signer, conflict, 450)
+	var slashable *utils.ErrSlashableSignature
+	assert.True(t, errors.As(err, &slashable))
+
+	// Same block with a different gap number is refused as well
+	assert.NotNil(t, sp.CheckAndRecordVote(signer, blockInfo, 0))
+
+	// Going back in rounds is refused
+	lower := &types.BlockInfo{Hash: common.HexToHash("0xc"), Round: 9, Number: big.NewInt(899)}
+	assert.NotNil(t, sp.CheckAndRecordVote(signer, lower, 450))
+
+	// Other signers have their own history
+	assert.Nil(t, sp.CheckAndRecordVote(common.HexToAddress("0x2"), conflict, 450))
+
+	higher := &types.BlockInfo{Hash: common.HexToHash("0xd"), Round: 11, Number: big.NewInt(901)}
+	assert.Nil(t, sp.CheckAndRecordVote(signer, higher, 450))
+}
+
+func TestSlashingProtectionTimeout(t *testing.T) {
+	sp := NewSlashingProtection(rawdb.NewMemoryDatabase(), big.NewInt(50))
+	signer := common.HexToAddress("0x1")
+
+	assert.Nil(t, sp.CheckAndRecordTimeout(signer, 5, 450))
+	// Timeouts are re-sent until the round changes, with the gap of the new head
+	assert.Nil(t, sp.CheckAndRecordTimeout(signer, 5, 450))
+	assert.Nil(t, sp.CheckAndRecordTimeout(signer, 5, 1350))
+	// The latest gap number is recorded, a lower one is a conflicting timeout
+	err := sp.CheckAndRecordTimeout(signer, 5, 450)
+	var slashable *utils.ErrSlashableSignature
+	assert.True(t, errors.As(err, &slashable))
+	interchange, err := sp.Export()
+	assert.Nil(t, err)
+	assert.Equal(t, []SignedTimeout{{Round: 5, GapNumber: 1350}}, interchange.Data[0].SignedTimeouts)
+
+	assert.NotNil(t, sp.CheckAndRecordTimeout(signer, 4, 450))
+	assert.Nil(t, sp.CheckAndRecordTimeout(signer, 6, 450))
+}
+
+func TestSlashingProtectionPrune(t *testing.T) {
+	db := rawdb.NewMemoryDatabase()
+	sp := NewSlashingProtection(db, big.NewInt(50))
+	signer := common.HexToAddress("0x1")
+
+	for round := types.Round(1); round <= slashingProtectionHistoryRounds+10; round++ {
+		assert.Nil(t, sp.CheckAndRecordTimeout(signer, round, 450))
+	}
+	interchange, err := sp.Export()
+	assert.Nil(t, err)
+	assert.Equal(t, slashingProtectionHistoryRounds+1, len(interchange.Data[0].SignedTimeouts))
+	assert.Equal(t, types.Round(10), interchange.Data[0].SignedTimeouts[0].Round)
+	// Pruned rounds are still protected by the highest round
+	assert.NotNil(t, sp.CheckAndRecordTimeout(signer, 1, 450))
+}
+
+func TestSlashingProtectionInterchange(t *testing.T) {
+	source := NewSlashingProtection(rawdb.NewMemoryDatabase(), big.NewInt(50))
+	signer := common.HexToAddress("0x1")
+	blockInfo := &types.BlockInfo{Hash: common.HexToHash("0xa"), Round: 10, Number: big.NewInt(900)}
+	assert.Nil(t, source.CheckAndRecordVote(signer, blockInfo, 450))
+	assert.Nil(t, source.CheckAndRecordTimeout(signer, 12, 450))
+
+	exported, err := source.Export()
+	assert.Nil(t, err)
+	blob, err := json.Marshal(exported)
+	assert.Nil(t, err)
+
+	var interchange Interchange
+	assert.Nil(t, json.Unmarshal(blob, &interchange))
+	assert.Equal(t, "50", interchange.Metadata.ChainId)
+	assert.Equal(t, types.Round(10), interchange.Data[0].HighestVoteRound)
+	assert.Equal(t, types.Round(12), interchange.Data[0].HighestTimeoutRound)
+
+	// A node on another chain refuses the history
+	assert.True(t, errors.Is(NewSlashingProtection(rawdb.NewMemoryDatabase(), big.NewInt(51)).Import(&interchange), ErrInvalidInterchange))
+
+	target := NewSlashingProtection(rawdb.NewMemoryDatabase(), big.NewInt(50))
+	assert.Nil(t, target.Import(&interchange))
+	conflict := &types.BlockInfo{Hash: common.HexToHash("0xb"), Round: 10, Number: big.NewInt(900)}
+	assert.NotNil(t, target.CheckAndRecordVote(signer, conflict, 450))
+	assert.NotNil(t, target.CheckAndRecordTimeout(signer, 11, 450))
+	assert.Nil(t, target.CheckAndRecordTimeout(signer, 12, 450))
+
+	// Importing a conflicting history fails without writing anything
+	other := NewSlashingProtection(rawdb.NewMemoryDatabase(), big.NewInt(50))
+	assert.Nil(t, other.CheckAndRecordVote(signer, conflict, 450))
+	otherExported, err := other.Export()
+	assert.Nil(t, err)
+	assert.True(t, errors.Is(target.Import(otherExported), ErrInvalidInterchange))
+	assert.Nil(t, target.CheckAndRecordVote(signer, blockInfo, 450))
+
+	// So does a timeout signed with another gap number in the same round
+	other = NewSlashingProtection(rawdb.NewMemoryDatabase(), big.NewInt(50))
+	assert.Nil(t, other.CheckAndRecordTimeout(signer, 12, 1350))
+	otherExported, err = other.Export()
+	assert.Nil(t, err)
+	assert.True(t, errors.Is(target.Import(otherExported), ErrInvalidInterchange))
+	assert.Nil(t, target.CheckAndRecordTimeout(signer, 12, 450))
+}
@@ -182,6 +182,13 @@ func (x *XDPoS_v2) processTC(blockChainReader consensus.ChainReader, timeoutCert
 	3. send to broadcast channel
 */
 func (x *XDPoS_v2) sendTimeout(chain consensus.ChainReader) error {
+	x.observeLock.RLock()
+	defer x.observeLock.RUnlock()
+	if x.observeOnly {
+		log.Debug("[sendTimeout] Skip sending timeout in observe-only mode", "round", x.currentRound)
+		return nil
+	}
+
 	// Construct the gapNumber
 	var gapNumber uint64
 	currentBlockHeader := chain.CurrentHeader()
@@ -206,6 +213,11 @@ func (x *XDPoS_v2) sendTimeout(chain consensus.ChainReader) error {
 		log.Debug("[sendTimeout] non-epoch-switch block found its epoch block and calculated the gapNumber", "epochSwitchInfo.EpochSwitchBlockInfo.Number", epochSwitchInfo.EpochSwitchBlockInfo.Number.Uint64(), "gapNumber", gapNumber)
 	}
 
+	err = x.slashingProtection.CheckAndRecordTimeout(x.signerAddress(), x.currentRound, gapNumber)
+	if err != nil {
+		log.Error("[sendTimeout] Slashing protection refused to sign timeout", "Error", err, "round", x.currentRound, "gap", gapNumber)
+		return err
+	}
 	signedHash, err := x.signSignature(types.TimeoutSigHash(&types.TimeoutForSign{
 		Round:     x.currentRound,
 		GapNumber: gapNumber,
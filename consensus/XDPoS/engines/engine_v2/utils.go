@@ -103,18 +103,21 @@ func (x *XDPoS_v2) signSignature(signingHash common.Hash) (types.Signature, erro
 	return signedHash, nil
 }
 
+func (x *XDPoS_v2) signerAddress() common.Address {
+	x.signLock.RLock()
+	defer x.signLock.RUnlock()
+	return x.signer
+}
+
 func (x *XDPoS_v2) verifyMsgSignature(signedHashToBeVerified common.Hash, signature types.Signature, masternodes []common.Address) (bool, common.Address, error) {
 	var signerAddress common.Address
 	if len(masternodes) == 0 {
 		return false, signerAddress, errors.New("Empty masternode list detected when verifying message signatures")
 	}
-	// Recover the public key and the Ethereum address
-	pubkey, err := crypto.Ecrecover(signedHashToBeVerified.Bytes(), signature)
+	signerAddress, err := x.recoverMsgSigner(signedHashToBeVerified, signature)
 	if err != nil {
 		return false, signerAddress, fmt.Errorf("Error while verifying message: %v", err)
 	}
-
-	copy(signerAddress[:], crypto.Keccak256(pubkey[1:])[12:])
 	for _, mn := range masternodes {
 		if mn == signerAddress {
 			return true, signerAddress, nil
@@ -125,6 +128,37 @@ func (x *XDPoS_v2) verifyMsgSignature(signedHashToBeVerified common.Hash, signat
 	return false, signerAddress, nil
 }
 
+// recoverMsgSigner recovers the signer of a consensus message signature. The
+// same signatures arrive in votes and timeouts from many peers, and again in
+// the certificates of sync infos and headers, so the signers are cached.
+func (x *XDPoS_v2) recoverMsgSigner(signedHash common.Hash, signature types.Signature) (common.Address, error) {
+	var signer common.Address
+	key := crypto.Keccak256Hash(signedHash.Bytes(), signature)
+	if x.messageSigners != nil {
+		if cached, ok := x.messageSigners.Get(key); ok {
+			return cached.(common.Address), nil
+		}
+	}
+	// Recover the public key and the Ethereum address
+	pubkey, err := crypto.Ecrecover(signedHash.Bytes(), signature)
+	if err != nil {
+		return signer, err
+	}
+	copy(signer[:], crypto.Keccak256(pubkey[1:])[12:])
+	if x.messageSigners != nil {
+		x.messageSigners.Add(key, signer)
+	}
+	return signer, nil
+}
+
+// GetCurrentRound returns the round the engine is currently in.
+func (x *XDPoS_v2) GetCurrentRound() types.Round {
+	x.lock.RLock()
+	defer x.lock.RUnlock()
+
+	return x.currentRound
+}
+
 func (x *XDPoS_v2) getExtraFields(header *types.Header) (*types.QuorumCert, types.Round, []common.Address, error) {
 
 	var masternodes []common.Address
@@ -168,6 +202,26 @@ func (x *XDPoS_v2) GetSignersFromSnapshot(chain consensus.ChainReader, header *t
 	return snap.NextEpochCandidates, err
 }
 
+// GetNextEpochMasternodes estimates the masternodes of the epoch following the
+// given header from the snapshot of the latest gap block, which the next epoch
+// switch block is calculated from. Penalties are only known at the epoch
+// switch, so the candidates are just capped at the maximum masternode count.
+func (x *XDPoS_v2) GetNextEpochMasternodes(chain consensus.ChainReader, header *types.Header) ([]common.Address, error) {
+	number := header.Number.Uint64() + x.config.Gap
+	if number < x.config.Epoch {
+		return nil, fmt.Errorf("no gap block at or before block %d", header.Number)
+	}
+	snap, err := x.getSnapshot(chain, number-number%x.config.Epoch-x.config.Gap, true)
+	if err != nil {
+		return nil, err
+	}
+	masternodes := snap.NextEpochCandidates
+	if max := x.config.V2.CurrentConfig.MaxMasternodes; len(masternodes) > max {
+		masternodes = masternodes[:max]
+	}
+	return append([]common.Address{}, masternodes...), nil
+}
+
 func (x *XDPoS_v2) CalculateMissingRounds(chain consensus.ChainReader, header *types.Header) (*utils.PublicApiMissedRoundsMetadata, error) {
 	var missedRounds []utils.MissedRoundInfo
 	switchInfo, err := x.getEpochSwitchInfo(chain, header, header.Hash())
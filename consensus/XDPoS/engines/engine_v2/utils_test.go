@@ -0,0 +1,37 @@
+package engine_v2
+
+import (
+	"testing"
+
+	"github.com/XinFinOrg/XDPoSChain/common"
+	"github.com/XinFinOrg/XDPoSChain/consensus/XDPoS/utils"
+	"github.com/XinFinOrg/XDPoSChain/crypto"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+func TestVerifyMsgSignatureCache(t *testing.T) {
+	messageSigners, _ := lru.NewARC(utils.InmemoryMessageSigners)
+	x := &XDPoS_v2{messageSigners: messageSigners}
+
+	key, _ := crypto.GenerateKey()
+	signer := crypto.PubkeyToAddress(key.PublicKey)
+	hash := common.Hash{0x1}
+	signature, err := crypto.Sign(hash.Bytes(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		verified, recovered, err := x.verifyMsgSignature(hash, signature, []common.Address{{0x2}, signer})
+		if err != nil || !verified || recovered != signer {
+			t.Fatalf("attempt %d: verified %v, signer %x, err %v", i, verified, recovered, err)
+		}
+		if x.messageSigners.Len() != 1 {
+			t.Fatalf("attempt %d: %d cached signers, want 1", i, x.messageSigners.Len())
+		}
+	}
+	// The cached signer is still checked against the masternodes.
+	verified, _, err := x.verifyMsgSignature(hash, signature, []common.Address{{0x2}})
+	if err != nil || verified {
+		t.Fatalf("signer verified against other masternodes: %v, err %v", verified, err)
+	}
+}
@@ -23,6 +23,13 @@ func (x *XDPoS_v2) sendVote(chainReader consensus.ChainReader, blockInfo *types.
 	// Third step: Construct the vote struct with the above signature & blockinfo struct
 	// Forth step: Send the vote to broadcast channel
 
+	x.observeLock.RLock()
+	defer x.observeLock.RUnlock()
+	if x.observeOnly {
+		log.Debug("Skip sending vote in observe-only mode", "BlockInfoHash", blockInfo.Hash, "round", blockInfo.Round)
+		return nil
+	}
+
 	epochSwitchInfo, err := x.getEpochSwitchInfo(chainReader, nil, blockInfo.Hash)
 	if err != nil {
 		log.Error("getEpochSwitchInfo when sending out Vote", "BlockInfoHash", blockInfo.Hash, "Error", err)
@@ -30,6 +37,11 @@ func (x *XDPoS_v2) sendVote(chainReader consensus.ChainReader, blockInfo *types.
 	}
 	epochSwitchNumber := epochSwitchInfo.EpochSwitchBlockInfo.Number.Uint64()
 	gapNumber := epochSwitchNumber - epochSwitchNumber%x.config.Epoch - x.config.Gap
+	err = x.slashingProtection.CheckAndRecordVote(x.signerAddress(), blockInfo, gapNumber)
+	if err != nil {
+		log.Error("Slashing protection refused to sign Vote", "BlockInfoHash", blockInfo.Hash, "Error", err)
+		return err
+	}
 	signedHash, err := x.signSignature(types.VoteSigHash(&types.VoteForSign{
 		ProposedBlockInfo: blockInfo,
 		GapNumber:         gapNumber,
@@ -20,7 +20,8 @@ var (
 )
 
 const (
-	InmemorySnapshots      = 128 // Number of recent vote snapshots to keep in memory
+	InmemorySnapshots      = 128   // Number of recent vote snapshots to keep in memory
+	InmemoryMessageSigners = 16384 // Number of signers recovered from consensus message signatures to keep in memory
 	BlockSignersCacheLimit = 9000
 	M2ByteLength           = 4
 )
@@ -99,6 +99,10 @@ var (
 	ErrRoundInvalid = errors.New("Invalid Round, it shall be bigger than QC round")
 
 	ErrAlreadyMined = errors.New("Already mined")
+
+	// ErrObserveOnly is returned if a block is attempted to be sealed while the
+	// node is a hot-standby which does not hold the signing lease.
+	ErrObserveOnly = errors.New("node is in observe-only mode")
 )
 
 type ErrIncomingMessageRoundNotEqualCurrentRound struct {
@@ -120,3 +124,13 @@ type ErrIncomingMessageRoundTooFarFromCurrentRound struct {
 func (e *ErrIncomingMessageRoundTooFarFromCurrentRound) Error() string {
 	return fmt.Sprintf("%s message round number: %v is too far away from currentRound: %v", e.Type, e.IncomingRound, e.CurrentRound)
 }
+
+type ErrSlashableSignature struct {
+	Type   string
+	Round  types.Round
+	Reason string
+}
+
+func (e *ErrSlashableSignature) Error() string {
+	return fmt.Sprintf("refuse to sign %s message at round %v: %s", e.Type, e.Round, e.Reason)
+}
@@ -114,3 +114,24 @@ func TestIsYourTurnConsensusV2CrossConfig(t *testing.T) {
 	assert.Nil(t, err)
 	assert.True(t, isYourTurn)
 }
+
+func TestGetNextEpochMasternodesConsensusV2(t *testing.T) {
+	blockchain, _, currentBlock, signer, signFn, _ := PrepareXDCTestBlockChainForV2Engine(t, 901, params.TestXDPoSMockChainConfig, nil)
+	adaptor := blockchain.Engine().(*XDPoS.XDPoS)
+	blockCoinBase := "0x111000000000000000000000000000000123"
+	currentBlock = CreateBlock(blockchain, params.TestXDPoSMockChainConfig, currentBlock, 902, 2, blockCoinBase, signer, signFn, nil, nil, "")
+	err := blockchain.InsertBlock(currentBlock)
+	assert.Nil(t, err)
+
+	// The next epoch is calculated from the snapshot of the latest gap block 450
+	masternodes, err := adaptor.GetNextEpochMasternodes(blockchain, currentBlock.Header())
+	assert.Nil(t, err)
+	candidates, err := adaptor.GetAuthorisedSignersFromSnapshot(blockchain, currentBlock.Header())
+	assert.Nil(t, err)
+	assert.NotEmpty(t, masternodes)
+	assert.Equal(t, candidates, masternodes)
+
+	// Blocks before the v2 switch are not supported
+	_, err = adaptor.GetNextEpochMasternodes(blockchain, blockchain.GetHeaderByNumber(899))
+	assert.NotNil(t, err)
+}
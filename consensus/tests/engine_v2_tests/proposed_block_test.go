@@ -392,3 +392,46 @@ func TestProposedBlockMessageHandlerNotGenerateVoteIfSignerNotInMNlist(t *testin
 		assert.Equal(t, types.Round(6), round)
 	}
 }
+
+func TestProposedBlockMessageHandlerNotGenerateVoteInObserveOnlyMode(t *testing.T) {
+	blockchain, _, currentBlock, _, _, _ := PrepareXDCTestBlockChainForV2Engine(t, 901, params.TestXDPoSMockChainConfig, nil)
+	engine := blockchain.Engine().(*XDPoS.XDPoS)
+	engineV2 := engine.EngineV2
+	engine.SetObserveOnly(true)
+
+	err := engineV2.ProposedBlockHandler(blockchain, currentBlock.Header())
+	if err != nil {
+		t.Fatal("Fail propose proposedBlock handler", err)
+	}
+
+	// Neither vote nor timeout is signed, but the block is still processed
+	select {
+	case <-engineV2.BroadcastCh:
+		t.Fatal("Should not trigger vote or timeout in observe-only mode")
+	case <-time.After(2 * time.Second):
+		round, _, _, _, _, _ := engineV2.GetPropertiesFaker()
+		assert.Equal(t, types.Round(1), round)
+	}
+
+	_, err = engine.Seal(blockchain, currentBlock, nil)
+	assert.Equal(t, utils.ErrObserveOnly, err)
+
+	// Switching back to active mode resumes signing
+	engine.SetObserveOnly(false)
+	err = engineV2.ProposedBlockHandler(blockchain, currentBlock.Header())
+	if err != nil {
+		t.Fatal("Fail propose proposedBlock handler", err)
+	}
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case msg := <-engineV2.BroadcastCh:
+			if vote, ok := msg.(*types.Vote); ok {
+				assert.Equal(t, currentBlock.Hash(), vote.ProposedBlockInfo.Hash)
+				return
+			}
+		case <-timeout:
+			t.Fatal("Fail to trigger vote after leaving observe-only mode")
+		}
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright (c) 2018 XDPoSChain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package XDCx
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/XinFinOrg/XDPoSChain/XDCx/tradingstate"
+	"github.com/XinFinOrg/XDPoSChain/accounts/abi/bind"
+	"github.com/XinFinOrg/XDPoSChain/accounts/abi/bind/backends"
+	"github.com/XinFinOrg/XDPoSChain/common"
+	"github.com/XinFinOrg/XDPoSChain/core"
+	"github.com/XinFinOrg/XDPoSChain/core/rawdb"
+	"github.com/XinFinOrg/XDPoSChain/core/state"
+	"github.com/XinFinOrg/XDPoSChain/crypto"
+	"github.com/XinFinOrg/XDPoSChain/params"
+	"github.com/XinFinOrg/XDPoSChain/rlp"
+)
+
+var (
+	ownerKey, _        = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	ownerAddr          = crypto.PubkeyToAddress(ownerKey.PublicKey)
+	relayerOwnerKey, _ = crypto.HexToECDSA("8a1f9a8f95be41cd7ccb6168179afb4504aefe388d1e14474d32c45c72ce7b7a")
+	relayerOwnerAddr   = crypto.PubkeyToAddress(relayerOwnerKey.PublicKey)
+	relayerCoinbase    = common.HexToAddress("0x0000000000000000000000000000000000000099")
+	xdcNative          = common.HexToAddress("0x0000000000000000000000000000000000000001")
+)
+
+// relayerState copies the storage of the deployed registration contract to the
+// address the relayer state is read from.
+func relayerState(t *testing.T, backend *backends.SimulatedBackend, registration common.Address) *state.StateDB {
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()))
+	var decodeErr error
+	err := backend.ForEachStorageAt(context.Background(), registration, nil, func(key, val common.Hash) bool {
+		// The values are stored RLP encoded in the storage trie
+		_, content, _, err := rlp.Split(bytes.TrimLeft(val[:], "\x00"))
+		if err != nil {
+			decodeErr = err
+			return false
+		}
+		statedb.SetState(common.HexToAddress(common.RelayerRegistrationSMC), key, common.BytesToHash(content))
+		return true
+	})
+	if err == nil {
+		err = decodeErr
+	}
+	if err != nil {
+		t.Fatalf("failed to read the registration storage: %v", err)
+	}
+	return statedb
+}
+
+func TestRelayerState(t *testing.T) {
+	ether := big.NewInt(params.Ether)
+	funds := new(big.Int).Mul(big.NewInt(1000000), ether)
+	backend := backends.NewXDCSimulatedBackend(core.GenesisAlloc{
+		ownerAddr:        {Balance: funds},
+		relayerOwnerAddr: {Balance: funds},
+	}, 100000000, params.TestXDPoSMockChainConfig)
+	ownerOpts := bind.NewKeyedTransactor(ownerKey)
+	relayerOpts := bind.NewKeyedTransactor(relayerOwnerKey)
+
+	listingAddr, listing, err := DeployXDCXListing(ownerOpts, backend)
+	if err != nil {
+		t.Fatalf("failed to deploy the listing: %v", err)
+	}
+	backend.Commit()
+	minDeposit := new(big.Int).Mul(big.NewInt(25000), ether)
+	registrationAddr, registration, err := DeployRelayerRegistration(ownerOpts, backend, listingAddr, big.NewInt(200), big.NewInt(200), minDeposit)
+	if err != nil {
+		t.Fatalf("failed to deploy the registration: %v", err)
+	}
+	backend.Commit()
+
+	token := common.HexToAddress("0x0000000000000000000000000000000000000042")
+	listing.TransactOpts.Value = new(big.Int).Mul(big.NewInt(1000), ether)
+	if _, err := listing.Apply(token); err != nil {
+		t.Fatalf("failed to list the token: %v", err)
+	}
+	backend.Commit()
+
+	registration.TransactOpts = *relayerOpts
+	registration.TransactOpts.Value = minDeposit
+	if _, err := registration.Register(relayerCoinbase, 10, []common.Address{token}, []common.Address{xdcNative}); err != nil {
+		t.Fatalf("failed to register the relayer: %v", err)
+	}
+	backend.Commit()
+	registration.TransactOpts.Value = ether
+	if _, err := registration.DepositMore(relayerCoinbase); err != nil {
+		t.Fatalf("failed to deposit: %v", err)
+	}
+	backend.Commit()
+
+	statedb := relayerState(t, backend, registrationAddr)
+	deposit := new(big.Int).Add(minDeposit, ether)
+	if owner := tradingstate.GetRelayerOwner(relayerCoinbase, statedb); owner != relayerOwnerAddr {
+		t.Errorf("owner mismatch: have %x, want %x", owner, relayerOwnerAddr)
+	}
+	if have := tradingstate.GetRelayerDeposit(relayerCoinbase, statedb); have.Cmp(deposit) != 0 {
+		t.Errorf("deposit mismatch: have %v, want %v", have, deposit)
+	}
+	if fee := tradingstate.GetExRelayerFee(relayerCoinbase, statedb); fee.Uint64() != 10 {
+		t.Errorf("trade fee mismatch: have %v, want 10", fee)
+	}
+	if tradingstate.GetBaseTokenLength(relayerCoinbase, statedb) != 1 || tradingstate.GetBaseTokenAtIndex(relayerCoinbase, statedb, 0) != token {
+		t.Errorf("base tokens mismatch")
+	}
+	if tradingstate.GetQuoteTokenLength(relayerCoinbase, statedb) != 1 || tradingstate.GetQuoteTokenAtIndex(relayerCoinbase, statedb, 0) != xdcNative {
+		t.Errorf("quote tokens mismatch")
+	}
+	if request := tradingstate.GetResignRequest(relayerCoinbase, statedb); request.Sign() != 0 || tradingstate.IsResignedRelayer(relayerCoinbase, statedb) {
+		t.Errorf("active relayer reported as resigned: release time %v", request)
+	}
+
+	registration.TransactOpts.Value = nil
+	if _, err := registration.Resign(relayerCoinbase); err != nil {
+		t.Fatalf("failed to resign: %v", err)
+	}
+	backend.Commit()
+
+	statedb = relayerState(t, backend, registrationAddr)
+	header, err := backend.HeaderByNumber(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("failed to get the head: %v", err)
+	}
+	release := new(big.Int).Add(header.Time, big.NewInt(4*7*24*3600))
+	if request := tradingstate.GetResignRequest(relayerCoinbase, statedb); request.Cmp(release) != 0 {
+		t.Errorf("resign request mismatch: have %v, want %v", request, release)
+	}
+	if !tradingstate.IsResignedRelayer(relayerCoinbase, statedb) {
+		t.Errorf("resigned relayer reported as active")
+	}
+	// The contract keeps the deposit until the refund
+	if have := tradingstate.GetRelayerDeposit(relayerCoinbase, statedb); have.Cmp(deposit) != 0 {
+		t.Errorf("deposit mismatch after resign: have %v, want %v", have, deposit)
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright (c) 2018 XDPoSChain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package trc21issuer
+
+import (
+	"math/big"
+
+	"github.com/XinFinOrg/XDPoSChain/accounts/abi/bind"
+	"github.com/XinFinOrg/XDPoSChain/common"
+	"github.com/XinFinOrg/XDPoSChain/contracts/trc21issuer/contract"
+)
+
+// FeeReader reads the fee capacity of the TRC21 tokens from the issuer contract
+// and their token fee from the tokens themselves. It implements
+// bind.TRC21FeeReader.
+type FeeReader struct {
+	issuer          *contract.TRC21IssuerCaller
+	contractBackend bind.ContractCaller
+}
+
+// NewFeeReader creates a fee reader of the TRC21 issuer contract deployed at
+// issuerAddr.
+func NewFeeReader(issuerAddr common.Address, contractBackend bind.ContractCaller) (*FeeReader, error) {
+	issuer, err := contract.NewTRC21IssuerCaller(issuerAddr, contractBackend)
+	if err != nil {
+		return nil, err
+	}
+	return &FeeReader{issuer, contractBackend}, nil
+}
+
+// TokenCapacity returns the remaining fee capacity of the token.
+func (r *FeeReader) TokenCapacity(opts *bind.CallOpts, token common.Address) (*big.Int, error) {
+	return r.issuer.GetTokenCapacity(opts, token)
+}
+
+// EstimateTokenFee returns the fee the token charges for a transfer of value.
+func (r *FeeReader) EstimateTokenFee(opts *bind.CallOpts, token common.Address, value *big.Int) (*big.Int, error) {
+	trc21, err := contract.NewTRC21Caller(token, r.contractBackend)
+	if err != nil {
+		return nil, err
+	}
+	return trc21.EstimateFee(opts, value)
+}
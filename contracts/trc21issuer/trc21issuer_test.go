@@ -1,12 +1,16 @@
 package trc21issuer
 
 import (
+	"errors"
 	"math/big"
+	"strings"
 	"testing"
 
+	"github.com/XinFinOrg/XDPoSChain/accounts/abi"
 	"github.com/XinFinOrg/XDPoSChain/accounts/abi/bind"
 	"github.com/XinFinOrg/XDPoSChain/accounts/abi/bind/backends"
 	"github.com/XinFinOrg/XDPoSChain/common"
+	"github.com/XinFinOrg/XDPoSChain/contracts/trc21issuer/contract"
 	"github.com/XinFinOrg/XDPoSChain/core"
 	"github.com/XinFinOrg/XDPoSChain/crypto"
 )
@@ -147,3 +151,74 @@ func TestFeeTxWithTRC21Token(t *testing.T) {
 		t.Fatal("can't get balance token fee in  smart contract: ", err, "got", balanceIssuerFee, "wanted", remainFee)
 	}
 }
+
+func TestTRC21TransactOpts(t *testing.T) {
+	contractBackend := backends.NewSimulatedBackend(core.GenesisAlloc{
+		mainAddr: {Balance: big.NewInt(0).Mul(big.NewInt(10000000000000), big.NewInt(10000000000000))},
+	})
+	transactOpts := bind.NewKeyedTransactor(mainKey)
+	trc21IssuerAddr, trc21Issuer, err := DeployTRC21Issuer(transactOpts, contractBackend, big.NewInt(1))
+	if err != nil {
+		t.Fatal("can't deploy smart contract: ", err)
+	}
+	common.TRC21IssuerSMC = trc21IssuerAddr
+	contractBackend.Commit()
+
+	// deploy a token with enough fee capacity and another one with almost none
+	cap := big.NewInt(0).Mul(big.NewInt(10000000), big.NewInt(10000000000000))
+	TRC21fee := big.NewInt(100)
+	richTokenAddr, _, err := DeployTRC21(transactOpts, contractBackend, "RICH", "RCH", 18, cap, TRC21fee)
+	if err != nil {
+		t.Fatal("can't deploy smart contract: ", err)
+	}
+	poorTokenAddr, _, err := DeployTRC21(transactOpts, contractBackend, "POOR", "POR", 18, cap, TRC21fee)
+	if err != nil {
+		t.Fatal("can't deploy smart contract: ", err)
+	}
+	contractBackend.Commit()
+	for token, capacity := range map[common.Address]*big.Int{richTokenAddr: minApply, poorTokenAddr: big.NewInt(1000)} {
+		trc21Issuer.TransactOpts.Value = capacity
+		if _, err := trc21Issuer.Apply(token); err != nil {
+			t.Fatal("can't add a token in smart contract pay swap: ", err)
+		}
+	}
+	contractBackend.Commit()
+
+	fees, err := NewFeeReader(trc21IssuerAddr, contractBackend)
+	if err != nil {
+		t.Fatal("can't create the fee reader: ", err)
+	}
+	parsed, err := abi.JSON(strings.NewReader(contract.MyTRC21ABI))
+	if err != nil {
+		t.Fatal("can't parse the token abi: ", err)
+	}
+	transferAmount := big.NewInt(100000)
+
+	// the issuer pays the fee of the rich token
+	rich := bind.NewBoundContract(richTokenAddr, parsed, contractBackend, contractBackend, contractBackend)
+	opts, estimate, err := rich.TRC21TransactOpts(bind.NewKeyedTransactor(mainKey), fees, "transfer", subAddr, transferAmount)
+	if err != nil {
+		t.Fatal("can't estimate the trc21 fee: ", err)
+	}
+	if estimate.Capacity.Cmp(minApply) != 0 || estimate.TokenFee.Cmp(TRC21fee) != 0 {
+		t.Fatal("fee estimate mismatch: capacity", estimate.Capacity, "token fee", estimate.TokenFee)
+	}
+	if opts.GasLimit != estimate.GasLimit || opts.GasPrice.Cmp(common.GetTRC21GasPrice(big.NewInt(4))) != 0 {
+		t.Fatal("transact opts mismatch: gas", opts.GasLimit, "gas price", opts.GasPrice)
+	}
+	if _, err := rich.Transact(opts, "transfer", subAddr, transferAmount); err != nil {
+		t.Fatal("can't execute transfer in trc21: ", err)
+	}
+	contractBackend.Commit()
+
+	// the capacity of the poor token is exhausted, both in the helper and the backend
+	poor := bind.NewBoundContract(poorTokenAddr, parsed, contractBackend, contractBackend, contractBackend)
+	_, _, err = poor.TRC21TransactOpts(bind.NewKeyedTransactor(mainKey), fees, "transfer", subAddr, transferAmount)
+	if capErr, ok := err.(*bind.TRC21CapacityError); !ok || capErr.Token != poorTokenAddr || capErr.Capacity.Cmp(big.NewInt(1000)) != 0 {
+		t.Fatal("capacity error mismatch: ", err)
+	}
+	opts.GasPrice = common.GetTRC21GasPrice(nil)
+	if _, err := poor.Transact(opts, "transfer", subAddr, transferAmount); !errors.Is(err, bind.ErrTRC21CapacityExhausted) {
+		t.Fatal("simulated backend error mismatch: ", err)
+	}
+}
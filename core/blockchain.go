@@ -174,6 +174,8 @@ type BlockChain struct {
 	resultLendingTrade  *lru.Cache[common.Hash, interface{}]
 	rejectedLendingItem *lru.Cache[common.Hash, interface{}]
 	finalizedTrade      *lru.Cache[common.Hash, interface{}] // include both trades which force update to closed/liquidated by the protocol
+
+	orderResults *lru.Cache[common.Hash, []tradingstate.OrderResult] // orders processed by a block outside of its transactions: key - block hash
 }
 
 // NewBlockChain returns a fully initialised block chain using information
@@ -210,6 +212,7 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, chainConfig *par
 		resultLendingTrade:  lru.NewCache[common.Hash, interface{}](tradingstate.OrderCacheLimit),
 		rejectedLendingItem: lru.NewCache[common.Hash, interface{}](tradingstate.OrderCacheLimit),
 		finalizedTrade:      lru.NewCache[common.Hash, interface{}](tradingstate.OrderCacheLimit),
+		orderResults:        lru.NewCache[common.Hash, []tradingstate.OrderResult](blockCacheLimit),
 	}
 	bc.SetValidator(NewBlockValidator(chainConfig, bc, engine))
 	bc.SetProcessor(NewStateProcessor(chainConfig, bc, engine))
@@ -1618,10 +1621,19 @@ func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals bool) (int, []
 					log.Error("[insertChain] Error while checking if the incoming block is epoch switch block", "Hash", block.Hash(), "Number", block.Number())
 					bc.reportBlock(block, nil, err)
 				}
+				orderResults, err := tradingService.ProcessExpiredOrders(block.Header(), bc, statedb, tradingState)
+				if err != nil {
+					return i, events, coalescedLogs, err
+				}
 				if isEpochSwithBlock {
 					if err := tradingService.UpdateMediumPriceBeforeEpoch(epochNumber, tradingState, statedb); err != nil {
 						return i, events, coalescedLogs, err
 					}
+					triggered, err := tradingService.ProcessTriggerOrders(block.Header(), author, bc, statedb, tradingState)
+					if err != nil {
+						return i, events, coalescedLogs, err
+					}
+					orderResults = append(orderResults, triggered...)
 				} else {
 					for _, txMatchBatch := range txMatchBatchData {
 						log.Debug("Verify matching transaction", "txHash", txMatchBatch.TxHash.Hex())
@@ -1661,6 +1673,9 @@ func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals bool) (int, []
 						}
 					}
 				}
+				if isSDKNode && len(orderResults) > 0 {
+					bc.orderResults.Add(block.Hash(), orderResults)
+				}
 				//check
 				if tradingState != nil && tradingService != nil {
 					gotRoot := tradingState.IntermediateRoot()
@@ -1894,11 +1909,20 @@ func (bc *BlockChain) getResultBlock(block *types.Block, verifiedM2 bool) (*Resu
 				log.Error("[getResultBlock] Error while checking block is epoch switch block", "Hash", block.Hash(), "Number", block.Number())
 				bc.reportBlock(block, nil, err)
 			}
+			orderResults, err := tradingService.ProcessExpiredOrders(block.Header(), bc, statedb, tradingState)
+			if err != nil {
+				return nil, err
+			}
 
 			if isEpochSwithBlock {
 				if err := tradingService.UpdateMediumPriceBeforeEpoch(epochNumber, tradingState, statedb); err != nil {
 					return nil, err
 				}
+				triggered, err := tradingService.ProcessTriggerOrders(block.Header(), author, bc, statedb, tradingState)
+				if err != nil {
+					return nil, err
+				}
+				orderResults = append(orderResults, triggered...)
 			} else {
 				txMatchBatchData, err := ExtractTradingTransactions(block.Transactions())
 				if err != nil {
@@ -1941,6 +1965,9 @@ func (bc *BlockChain) getResultBlock(block *types.Block, verifiedM2 bool) (*Resu
 					}
 				}
 			}
+			if isSDKNode && len(orderResults) > 0 {
+				bc.orderResults.Add(block.Hash(), orderResults)
+			}
 			if tradingState != nil && tradingService != nil {
 				gotRoot := tradingState.IntermediateRoot()
 				expectRoot, _ := tradingService.GetTradingStateRoot(block, author)
@@ -2277,7 +2304,7 @@ func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 		}()
 	}
 	if bc.chainConfig.IsTIPXDCXReceiver(commonBlock.Number()) && bc.chainConfig.XDPoS != nil && commonBlock.NumberU64() > bc.chainConfig.XDPoS.Epoch {
-		bc.reorgTxMatches(deletedTxs, newChain)
+		bc.reorgTxMatches(oldChain, deletedTxs, newChain)
 	}
 	return nil
 }
@@ -2613,7 +2640,8 @@ func (bc *BlockChain) logExchangeData(block *types.Block) {
 		log.Crit("failed to extract matching transaction", "err", err)
 		return
 	}
-	if len(txMatchBatchData) == 0 {
+	orderResults, _ := bc.orderResults.Get(block.Hash())
+	if len(txMatchBatchData) == 0 && len(orderResults) == 0 {
 		return
 	}
 	currentState, err := bc.State()
@@ -2628,6 +2656,17 @@ func (bc *BlockChain) logExchangeData(block *types.Block) {
 		log.Debug("logExchangeData takes", "time", common.PrettyDuration(time.Since(start)), "blockNumber", block.NumberU64())
 	}()
 
+	// The orders processed by the block itself, before its transactions, have
+	// no transaction of their own. They are recorded under the block hash.
+	txMatchTime := time.Unix(block.Header().Time.Int64(), 0).UTC()
+	dirtyOrderCount := uint64(0)
+	for _, result := range orderResults {
+		order := *result.Order
+		if err := XDCXService.SyncDataToSDKNode(&order, block.Hash(), txMatchTime, currentState, result.Trades, result.Rejects, &dirtyOrderCount); err != nil {
+			log.Crit("failed to SyncDataToSDKNode ", "blockNumber", block.Number(), "err", err)
+			return
+		}
+	}
 	for _, txMatchBatch := range txMatchBatchData {
 		dirtyOrderCount := uint64(0)
 		for _, txMatch := range txMatchBatch.Data {
@@ -2654,7 +2693,6 @@ func (bc *BlockChain) logExchangeData(block *types.Block) {
 				rejectedOrders = rejected.([]*tradingstate.OrderItem)
 			}
 
-			txMatchTime := time.Unix(block.Header().Time.Int64(), 0).UTC()
 			if err := XDCXService.SyncDataToSDKNode(takerOrderInTx, txMatchBatch.TxHash, txMatchTime, currentState, trades, rejectedOrders, &dirtyOrderCount); err != nil {
 				log.Crit("failed to SyncDataToSDKNode ", "blockNumber", block.Number(), "err", err)
 				return
@@ -2663,7 +2701,7 @@ func (bc *BlockChain) logExchangeData(block *types.Block) {
 	}
 }
 
-func (bc *BlockChain) reorgTxMatches(deletedTxs types.Transactions, newChain types.Blocks) {
+func (bc *BlockChain) reorgTxMatches(oldChain types.Blocks, deletedTxs types.Transactions, newChain types.Blocks) {
 	engine, ok := bc.Engine().(*XDPoS.XDPoS)
 	if !ok || engine == nil {
 		return
@@ -2679,6 +2717,14 @@ func (bc *BlockChain) reorgTxMatches(deletedTxs types.Transactions, newChain typ
 		// That's why we should put this log statement in an anonymous function
 		log.Debug("reorgTxMatches takes", "time", common.PrettyDuration(time.Since(start)))
 	}()
+	for _, oldBlock := range oldChain {
+		if bc.chainConfig.IsTIPXDCXOrderTypes(oldBlock.Number()) {
+			log.Debug("Rollback reorg block orders", "hash", oldBlock.Hash())
+			if err := XDCXService.RollbackReorgTxMatch(oldBlock.Hash()); err != nil {
+				log.Crit("Reorg trading failed", "err", err, "hash", oldBlock.Hash())
+			}
+		}
+	}
 	for _, deletedTx := range deletedTxs {
 		if deletedTx.IsTradingTransaction() {
 			log.Debug("Rollback reorg txMatch", "txhash", deletedTx.Hash())
@@ -30,6 +30,14 @@ type OrderTxPreEvent struct{ Tx *types.OrderTransaction }
 // LendingTxPreEvent is posted when a order transaction enters the order transaction pool.
 type LendingTxPreEvent struct{ Tx *types.LendingTransaction }
 
+// DroppedTxEvent is posted when a transaction leaves a pool without being
+// included, ReplacedBy is set if it was replaced by another transaction.
+type DroppedTxEvent struct {
+	Hash       common.Hash
+	Reason     TxDropReason
+	ReplacedBy common.Hash
+}
+
 // PendingStateEvent is posted pre mining and notifies of pending state changes.
 type PendingStateEvent struct{}
 
@@ -0,0 +1,325 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package forkid implements EIP-2124 (https://eips.ethereum.org/EIPS/eip-2124),
+// extended with the XDPoS v2 fork points activated at a consensus round.
+package forkid
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"math/big"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/XinFinOrg/XDPoSChain/common"
+	"github.com/XinFinOrg/XDPoSChain/consensus/XDPoS/utils"
+	"github.com/XinFinOrg/XDPoSChain/core/types"
+	"github.com/XinFinOrg/XDPoSChain/params"
+)
+
+var (
+	// ErrRemoteStale is returned by the validator if a remote fork checksum is a
+	// subset of our already applied forks, but the announced next fork block is
+	// not on our already passed chain.
+	ErrRemoteStale = errors.New("remote needs update")
+
+	// ErrLocalIncompatibleOrStale is returned by the validator if a remote fork
+	// checksum does not match any local checksum variation, signalling that the
+	// two chains have diverged in the past at some point (possibly at genesis).
+	ErrLocalIncompatibleOrStale = errors.New("local incompatible or needs update")
+)
+
+// roundFork flags the checksummed fork points which are activated at a XDPoS
+// v2 round instead of a block number.
+const roundFork = uint64(1) << 63
+
+// Blockchain defines all necessary method to build a forkID.
+type Blockchain interface {
+	// Config retrieves the chain's fork configuration.
+	Config() *params.ChainConfig
+
+	// Genesis retrieves the chain's genesis block.
+	Genesis() *types.Block
+
+	// CurrentHeader retrieves the current head header of the canonical chain.
+	CurrentHeader() *types.Header
+
+	// GetHeaderByNumber retrieves a block header from the canonical chain.
+	GetHeaderByNumber(number uint64) *types.Header
+}
+
+// ID is a fork identifier as defined by EIP-2124.
+type ID struct {
+	Hash [4]byte // CRC32 checksum of the genesis block and passed fork points
+	Next uint64  // Block number of the next upcoming fork, or 0 if no forks are known
+}
+
+// Filter is a fork id filter to validate a remotely advertised ID.
+type Filter func(id ID) error
+
+// NewID calculates the fork ID of the chain at its current head.
+func NewID(chain Blockchain) ID {
+	forks := gatherForks(chain.Config())
+	passed, future, _ := forks.split(chain, chain.CurrentHeader())
+
+	return ID{Hash: checksumToBytes(checksum(chain.Genesis().Hash(), passed)), Next: next(future)}
+}
+
+// NewFilter creates a filter that returns if a fork ID should be rejected or
+// not based on the local chain's status.
+func NewFilter(chain Blockchain) Filter {
+	var (
+		forks   = gatherForks(chain.Config())
+		genesis = chain.Genesis().Hash()
+	)
+	return func(id ID) error {
+		return forks.validate(chain, genesis, chain.CurrentHeader(), id)
+	}
+}
+
+// clientForks returns the hard forks hardcoded into the client, which apply on
+// top of the chain config forks.
+var clientForks = func() []*big.Int {
+	return []*big.Int{
+		common.TIP2019Block, common.TIPSigning, common.TIPRandomize, common.TIPIncreaseMasternodes,
+		common.TIPNoHalvingMNReward, new(big.Int).SetUint64(common.BlackListHFNumber), common.TIPTRC21Fee,
+		common.TIPXDCX, common.TIPXDCXLending, common.TIPXDCXCancellationFee, common.TIPXDCXMinerDisable,
+		common.TIPXDCXReceiverDisable, common.TIPXDCXOrderTypes, common.TIPXDCXTriggerOrders,
+		common.BlockNumberGas50x, common.Eip1559Block, common.BerlinBlock, common.LondonBlock,
+		common.MergeBlock, common.ShanghaiBlock, common.CancunBlock,
+	}
+}
+
+// forks is the set of fork points of a chain configuration.
+type forks struct {
+	blocks []uint64 // Block numbers of the block forks, ascending
+	rounds []uint64 // XDPoS v2 rounds of the round forks, ascending
+	v2     uint64   // Number of the first XDPoS v2 block, 0 if v2 is not configured
+}
+
+// gatherForks gathers all the known forks of a chain configuration: the fork
+// blocks of the chain config, the hard forks hardcoded into the client, the
+// XDPoS v2 switch block and the rounds switching the v2 config.
+func gatherForks(config *params.ChainConfig) *forks {
+	// Gather all the fork block numbers via reflection
+	var (
+		kind  = reflect.TypeOf(params.ChainConfig{})
+		conf  = reflect.ValueOf(config).Elem()
+		f     = new(forks)
+		block = func(number *big.Int) {
+			if number != nil && number.Sign() > 0 {
+				f.blocks = append(f.blocks, number.Uint64())
+			}
+		}
+	)
+	for i := 0; i < kind.NumField(); i++ {
+		// Fetch the next field and skip non-fork rules
+		field := kind.Field(i)
+		if !strings.HasSuffix(field.Name, "Block") {
+			continue
+		}
+		if field.Type != reflect.TypeOf(new(big.Int)) {
+			continue
+		}
+		block(conf.Field(i).Interface().(*big.Int))
+	}
+	// Add the hard forks enabled by the client regardless of the chain config
+	for _, number := range clientForks() {
+		block(number)
+	}
+	// Add the XDPoS v2 switch and its config changes
+	if config.XDPoS != nil && config.XDPoS.V2 != nil && config.XDPoS.V2.SwitchBlock != nil {
+		f.v2 = config.XDPoS.V2.SwitchBlock.Uint64() + 1
+		f.blocks = append(f.blocks, f.v2)
+
+		for round := range config.XDPoS.V2.AllConfigs {
+			if round > 0 {
+				f.rounds = append(f.rounds, round)
+			}
+		}
+	}
+	f.blocks = dedup(f.blocks)
+	f.rounds = dedup(f.rounds)
+	return f
+}
+
+// dedup sorts the fork points and removes the duplicates.
+func dedup(points []uint64) []uint64 {
+	sort.Slice(points, func(i, j int) bool { return points[i] < points[j] })
+	for i := 1; i < len(points); i++ {
+		if points[i] == points[i-1] {
+			points = append(points[:i], points[i+1:]...)
+			i--
+		}
+	}
+	return points
+}
+
+// split separates the forks passed by the chain at head, in the order they were
+// activated, from the future block and round forks.
+func (f *forks) split(chain Blockchain, head *types.Header) (passed []uint64, blocks []uint64, rounds []uint64) {
+	var (
+		config = chain.Config()
+		number = head.Number.Uint64()
+		round  = headerRound(config, head)
+	)
+	blocks, rounds = f.blocks, f.rounds
+	for len(blocks) > 0 || len(rounds) > 0 {
+		switch {
+		case len(rounds) > 0 && rounds[0] <= round && f.roundFirst(chain, blocks, number, rounds[0]):
+			passed = append(passed, rounds[0]|roundFork)
+			rounds = rounds[1:]
+		case len(blocks) > 0 && blocks[0] <= number:
+			passed = append(passed, blocks[0])
+			blocks = blocks[1:]
+		default:
+			return passed, blocks, rounds
+		}
+	}
+	return passed, blocks, rounds
+}
+
+// roundFirst reports whether the passed round fork was reached before the next
+// block fork, by checking the round of the block preceding it.
+func (f *forks) roundFirst(chain Blockchain, blocks []uint64, number uint64, round uint64) bool {
+	if len(blocks) == 0 || blocks[0] > number {
+		return true
+	}
+	if blocks[0] <= f.v2 {
+		return false
+	}
+	return headerRound(chain.Config(), chain.GetHeaderByNumber(blocks[0]-1)) >= round
+}
+
+// validate checks a remote fork ID against the local chain at head.
+func (f *forks) validate(chain Blockchain, genesis common.Hash, head *types.Header, id ID) error {
+	var (
+		passed, blocks, rounds = f.split(chain, head)
+
+		sums = make([][4]byte, len(passed)+1)
+		hash = crc32.ChecksumIEEE(genesis[:])
+	)
+	sums[0] = checksumToBytes(hash)
+	for i, fork := range passed {
+		hash = checksumUpdate(hash, fork)
+		sums[i+1] = checksumToBytes(hash)
+	}
+	// If the remote fork checksum matches our current state, check that the
+	// remote has not announced a block fork we have already passed: its chain
+	// would disagree with ours.
+	if id.Hash == sums[len(passed)] {
+		if id.Next > 0 && head.Number.Uint64() >= id.Next {
+			return ErrLocalIncompatibleOrStale
+		}
+		return nil
+	}
+	// If the remote checksum is a subset of our passed forks, the remote is
+	// syncing and must announce the next block fork we passed after it.
+	for i := 0; i < len(passed); i++ {
+		if id.Hash == sums[i] {
+			if id.Next != next(append(blockForks(passed[i:]), blocks...)) {
+				return ErrRemoteStale
+			}
+			return nil
+		}
+	}
+	// If the remote checksum is a superset of our forks, we are syncing and the
+	// remote must have passed our future forks. The activation order of the
+	// round forks against the block forks is unknown, try all of them.
+	if f.future(hash, blocks, rounds, id.Hash) {
+		return nil
+	}
+	return ErrLocalIncompatibleOrStale
+}
+
+// future reports whether the checksum want can be reached by passing the future
+// block and round forks, in any order allowed by the chain.
+func (f *forks) future(hash uint32, blocks []uint64, rounds []uint64, want [4]byte) bool {
+	if len(blocks) > 0 {
+		sum := checksumUpdate(hash, blocks[0])
+		if checksumToBytes(sum) == want || f.future(sum, blocks[1:], rounds, want) {
+			return true
+		}
+	}
+	// Round forks can only be passed after the switch to XDPoS v2
+	if len(rounds) > 0 && (len(blocks) == 0 || blocks[0] > f.v2) {
+		sum := checksumUpdate(hash, rounds[0]|roundFork)
+		if checksumToBytes(sum) == want || f.future(sum, blocks, rounds[1:], want) {
+			return true
+		}
+	}
+	return false
+}
+
+// headerRound returns the XDPoS v2 round of the header, 0 for the headers mined
+// before the v2 switch.
+func headerRound(config *params.ChainConfig, header *types.Header) uint64 {
+	if header == nil || config.XDPoS == nil || config.XDPoS.BlockConsensusVersion(header.Number, header.Extra, false) != params.ConsensusEngineVersion2 {
+		return 0
+	}
+	var fields types.ExtraFields_v2
+	if err := utils.DecodeBytesExtraFields(header.Extra, &fields); err != nil {
+		return 0
+	}
+	return uint64(fields.Round)
+}
+
+// blockForks filters the block forks out of checksummed fork points.
+func blockForks(points []uint64) []uint64 {
+	var blocks []uint64
+	for _, point := range points {
+		if point&roundFork == 0 {
+			blocks = append(blocks, point)
+		}
+	}
+	return blocks
+}
+
+// next returns the first of the block forks, 0 if there are none.
+func next(blocks []uint64) uint64 {
+	if len(blocks) > 0 {
+		return blocks[0]
+	}
+	return 0
+}
+
+// checksum calculates the IEEE CRC32 checksum of the genesis hash followed by
+// the passed fork points.
+func checksum(genesis common.Hash, passed []uint64) uint32 {
+	hash := crc32.ChecksumIEEE(genesis[:])
+	for _, fork := range passed {
+		hash = checksumUpdate(hash, fork)
+	}
+	return hash
+}
+
+// checksumUpdate calculates the next IEEE CRC32 checksum based on the previous
+// one and a fork point.
+func checksumUpdate(hash uint32, fork uint64) uint32 {
+	var blob [8]byte
+	binary.BigEndian.PutUint64(blob[:], fork)
+	return crc32.Update(hash, crc32.IEEETable, blob[:])
+}
+
+// checksumToBytes converts a uint32 checksum into a [4]byte array.
+func checksumToBytes(hash uint32) [4]byte {
+	var blob [4]byte
+	binary.BigEndian.PutUint32(blob[:], hash)
+	return blob
+}
@@ -0,0 +1,258 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package forkid
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/XinFinOrg/XDPoSChain/common"
+	"github.com/XinFinOrg/XDPoSChain/core/types"
+	"github.com/XinFinOrg/XDPoSChain/params"
+	"github.com/XinFinOrg/XDPoSChain/rlp"
+)
+
+// testConfig is a chain config with block forks before and after the XDPoS v2
+// switch at block 101, and v2 config switches at rounds 30 and 60.
+var testConfig = &params.ChainConfig{
+	ChainId:        big.NewInt(1),
+	HomesteadBlock: big.NewInt(0),
+	EIP150Block:    big.NewInt(10),
+	EIP155Block:    big.NewInt(10),
+	ByzantiumBlock: big.NewInt(20),
+	CancunBlock:    big.NewInt(120),
+	LondonBlock:    big.NewInt(300),
+	XDPoS: &params.XDPoSConfig{
+		Epoch: 900,
+		V2: &params.V2{
+			SwitchBlock: big.NewInt(100),
+			AllConfigs: map[uint64]*params.V2Config{
+				0:  {SwitchRound: 0},
+				30: {SwitchRound: 30},
+				60: {SwitchRound: 60},
+			},
+		},
+	},
+}
+
+// testChain is a canonical chain of the test config, whose v2 blocks are mined
+// every other round: round 30 is reached at block 115 and round 60 at 130.
+type testChain struct {
+	genesis *types.Block
+	headers []*types.Header
+	head    uint64
+}
+
+func newTestChain(t *testing.T, length uint64) *testChain {
+	chain := &testChain{genesis: types.NewBlockWithHeader(&types.Header{Number: new(big.Int), Extra: []byte("forkid")})}
+	for number := uint64(0); number <= length; number++ {
+		header := &types.Header{Number: new(big.Int).SetUint64(number)}
+		if number > testConfig.XDPoS.V2.SwitchBlock.Uint64() {
+			fields := &types.ExtraFields_v2{
+				Round:      types.Round(2 * (number - testConfig.XDPoS.V2.SwitchBlock.Uint64())),
+				QuorumCert: &types.QuorumCert{ProposedBlockInfo: &types.BlockInfo{Number: new(big.Int)}},
+			}
+			extra, err := fields.EncodeToBytes()
+			if err != nil {
+				t.Fatalf("failed to encode extra fields: %v", err)
+			}
+			header.Extra = extra
+		}
+		chain.headers = append(chain.headers, header)
+	}
+	return chain
+}
+
+func (c *testChain) Config() *params.ChainConfig  { return testConfig }
+func (c *testChain) Genesis() *types.Block        { return c.genesis }
+func (c *testChain) CurrentHeader() *types.Header { return c.headers[c.head] }
+
+func (c *testChain) GetHeaderByNumber(number uint64) *types.Header {
+	if number > c.head {
+		return nil
+	}
+	return c.headers[number]
+}
+
+// withoutClientForks disables the client hard forks for the duration of a test.
+func withoutClientForks(t *testing.T) {
+	forks := clientForks
+	clientForks = func() []*big.Int { return nil }
+	t.Cleanup(func() { clientForks = forks })
+}
+
+// testID builds the fork ID of the test chain having passed the given forks.
+func testID(chain *testChain, passed []uint64, next uint64) ID {
+	return ID{Hash: checksumToBytes(checksum(chain.genesis.Hash(), passed)), Next: next}
+}
+
+func round(round uint64) uint64 { return round | roundFork }
+
+func TestGatherForks(t *testing.T) {
+	withoutClientForks(t)
+
+	forks := gatherForks(testConfig)
+	if want := []uint64{10, 20, 101, 120, 300}; !equal(forks.blocks, want) {
+		t.Errorf("block forks mismatch: have %v, want %v", forks.blocks, want)
+	}
+	if want := []uint64{30, 60}; !equal(forks.rounds, want) {
+		t.Errorf("round forks mismatch: have %v, want %v", forks.rounds, want)
+	}
+	if forks.v2 != 101 {
+		t.Errorf("v2 switch mismatch: have %d, want %d", forks.v2, 101)
+	}
+}
+
+func TestGatherClientForks(t *testing.T) {
+	forks := gatherForks(testConfig)
+	for _, number := range []uint64{common.TIPSigning.Uint64(), common.TIPXDCX.Uint64(), common.BerlinBlock.Uint64()} {
+		found := false
+		for _, block := range forks.blocks {
+			found = found || block == number
+		}
+		if !found {
+			t.Errorf("client fork %d missing from %v", number, forks.blocks)
+		}
+	}
+}
+
+func TestCreation(t *testing.T) {
+	withoutClientForks(t)
+
+	chain := newTestChain(t, 200)
+	tests := []struct {
+		head   uint64
+		passed []uint64
+		next   uint64
+	}{
+		{0, nil, 10},
+		{9, nil, 10},
+		{10, []uint64{10}, 20},
+		{100, []uint64{10, 20}, 101},
+		{101, []uint64{10, 20, 101}, 120},
+		{114, []uint64{10, 20, 101}, 120},
+		{115, []uint64{10, 20, 101, round(30)}, 120},
+		{120, []uint64{10, 20, 101, round(30), 120}, 300},
+		{130, []uint64{10, 20, 101, round(30), 120, round(60)}, 300},
+		{200, []uint64{10, 20, 101, round(30), 120, round(60)}, 300},
+	}
+	for i, tt := range tests {
+		chain.head = tt.head
+		if have, want := NewID(chain), testID(chain, tt.passed, tt.next); have != want {
+			t.Errorf("test %d: fork ID mismatch: have %x, want %x", i, have, want)
+		}
+	}
+}
+
+func TestValidation(t *testing.T) {
+	withoutClientForks(t)
+
+	chain := newTestChain(t, 200)
+	tests := []struct {
+		head   uint64
+		passed []uint64
+		next   uint64
+		err    error
+	}{
+		// Local and remote are in the same state, without or with a future fork
+		{130, []uint64{10, 20, 101, round(30), 120, round(60)}, 0, nil},
+		{130, []uint64{10, 20, 101, round(30), 120, round(60)}, 300, nil},
+
+		// Local and remote are in the same state, but the remote announces a
+		// fork we already passed without it
+		{130, []uint64{10, 20, 101, round(30), 120, round(60)}, 125, ErrLocalIncompatibleOrStale},
+
+		// Remote is syncing and announces the next block fork we passed
+		{130, []uint64{10, 20, 101}, 120, nil},
+		{130, []uint64{10, 20, 101, round(30)}, 120, nil},
+		{130, []uint64{10, 20, 101, round(30), 120}, 300, nil},
+		{130, nil, 10, nil},
+
+		// Remote is syncing but does not know about the forks we passed
+		{130, []uint64{10, 20, 101}, 0, ErrRemoteStale},
+		{130, []uint64{10, 20, 101}, 121, ErrRemoteStale},
+		{130, []uint64{10, 20, 101, round(30), 120}, 0, ErrRemoteStale},
+
+		// Local is syncing and the remote passed our future forks, in any order
+		// allowed by the chain
+		{101, []uint64{10, 20, 101, round(30), 120, round(60)}, 300, nil},
+		{101, []uint64{10, 20, 101, 120, round(30)}, 300, nil},
+		{101, []uint64{10, 20, 101, 120, round(30), round(60), 300}, 0, nil},
+		{10, []uint64{10, 20, 101, round(30)}, 120, nil},
+
+		// Local is syncing but the remote passed forks we do not know of, or in
+		// an order that is impossible for our chain
+		{101, []uint64{10, 20, 101, round(60)}, 0, ErrLocalIncompatibleOrStale},
+		{101, []uint64{10, 20, 101, 130}, 0, ErrLocalIncompatibleOrStale},
+		{10, []uint64{10, round(30)}, 0, ErrLocalIncompatibleOrStale},
+
+		// Remote passed a round fork in a different order than our chain
+		{130, []uint64{10, 20, 101, 120, round(30)}, 300, ErrLocalIncompatibleOrStale},
+	}
+	for i, tt := range tests {
+		chain.head = tt.head
+		filter := NewFilter(chain)
+		if err := filter(testID(chain, tt.passed, tt.next)); err != tt.err {
+			t.Errorf("test %d: validation error mismatch: have %v, want %v", i, err, tt.err)
+		}
+	}
+	// Remote on another chain
+	chain.head = 130
+	other := &testChain{genesis: types.NewBlockWithHeader(&types.Header{Number: new(big.Int)})}
+	if err := NewFilter(chain)(testID(other, nil, 10)); err != ErrLocalIncompatibleOrStale {
+		t.Errorf("genesis mismatch error mismatch: have %v, want %v", err, ErrLocalIncompatibleOrStale)
+	}
+}
+
+func TestEncoding(t *testing.T) {
+	tests := []struct {
+		id   ID
+		want []byte
+	}{
+		{ID{Hash: checksumToBytes(0), Next: 0}, common.Hex2Bytes("c6840000000080")},
+		{ID{Hash: checksumToBytes(0xdeadbeef), Next: 0xBADDCAFE}, common.Hex2Bytes("ca84deadbeef84baddcafe")},
+		{ID{Hash: checksumToBytes(u32max), Next: u64max}, common.Hex2Bytes("ce84ffffffff88ffffffffffffffff")},
+	}
+	for i, tt := range tests {
+		have, err := rlp.EncodeToBytes(tt.id)
+		if err != nil {
+			t.Errorf("test %d: failed to encode forkid: %v", i, err)
+			continue
+		}
+		if !bytes.Equal(have, tt.want) {
+			t.Errorf("test %d: RLP mismatch: have %x, want %x", i, have, tt.want)
+		}
+	}
+}
+
+const (
+	u32max = ^uint32(0)
+	u64max = ^uint64(0)
+)
+
+func equal(a, b []uint64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
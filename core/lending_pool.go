@@ -140,6 +140,7 @@ type LendingPool struct {
 	queue     map[common.Address]*lendingtxList         // Queued but non-processable transactions
 	beats     map[common.Address]time.Time              // Last heartbeat from each known account
 	all       map[common.Hash]*types.LendingTransaction // All transactions to allow lookups
+	history   *txHistory                                // Lifecycle events of recently seen transactions
 	wg        sync.WaitGroup                            // for shutdown sync
 	homestead bool
 	IsSigner  func(address common.Address) bool
@@ -161,6 +162,7 @@ func NewLendingPool(chainconfig *params.ChainConfig, chain blockChainLending) *L
 		queue:       make(map[common.Address]*lendingtxList),
 		beats:       make(map[common.Address]time.Time),
 		all:         make(map[common.Hash]*types.LendingTransaction),
+		history:     newTxHistory(txHistoryLimit),
 		chainHeadCh: make(chan ChainHeadEvent, chainHeadChanSize),
 	}
 	pool.locals = newLendingAccountSet(pool.signer)
@@ -249,6 +251,7 @@ func (pool *LendingPool) loop() {
 				// Any non-locals old enough should be removed
 				if time.Since(pool.beats[addr]) > pool.config.Lifetime {
 					for _, tx := range pool.queue[addr].Flatten() {
+						pool.history.dropped(tx.Hash(), DropReasonExpired)
 						pool.removeTx(tx.Hash())
 					}
 				}
@@ -297,6 +300,10 @@ func (pool *LendingPool) reset(oldHead, newblock *types.Block) {
 	}
 	pool.currentRootState = state
 
+	if oldHead != nil {
+		pool.recordInclusions(oldHead, newblock)
+	}
+
 	// Inject any transactions discarded due to reorgs
 	log.Debug("Reinjecting stale transactions", "count", len(reinject))
 	pool.addTxsLocked(reinject, false)
@@ -325,6 +332,7 @@ func (pool *LendingPool) Stop() {
 	// Unsubscribe subscriptions registered from blockchain
 	pool.chainHeadSub.Unsubscribe()
 	pool.wg.Wait()
+	pool.history.stop()
 
 	if pool.journal != nil {
 		pool.journal.close()
@@ -338,6 +346,12 @@ func (pool *LendingPool) SubscribeTxPreEvent(ch chan<- LendingTxPreEvent) event.
 	return pool.scope.Track(pool.txFeed.Subscribe(ch))
 }
 
+// SubscribeDroppedTxEvent registers a subscription of DroppedTxEvent and
+// starts sending event to the given channel.
+func (pool *LendingPool) SubscribeDroppedTxEvent(ch chan<- DroppedTxEvent) event.Subscription {
+	return pool.scope.Track(pool.history.feed.Subscribe(ch))
+}
+
 // State returns the virtual managed state of the transaction pool.
 func (pool *LendingPool) State() *lendingstate.LendingManagedState {
 	pool.mu.RLock()
@@ -688,10 +702,13 @@ func (pool *LendingPool) add(tx *types.LendingTransaction, local bool) (bool, er
 		}
 		if old != nil {
 			delete(pool.all, old.Hash())
+			pool.history.replaced(old.Hash(), hash)
 			pendingReplaceMeter.Mark(1)
 		}
 		pool.all[tx.Hash()] = tx
 		pool.journalTx(from, tx)
+		pool.history.added(hash)
+		pool.history.promoted(hash)
 
 		log.Debug("Lending Pooled new executable transaction", "hash", hash, "useraddress", tx.UserAddress(), "nonce", tx.Nonce(), "status", tx.Status(), "lendingid", tx.LendingId())
 		return old != nil, nil
@@ -707,6 +724,7 @@ func (pool *LendingPool) add(tx *types.LendingTransaction, local bool) (bool, er
 		pool.locals.add(from)
 	}
 	pool.journalTx(from, tx)
+	pool.history.added(hash)
 
 	log.Debug("Pooled new future transaction", "hash", hash, "from", from)
 	return replace, nil
@@ -730,6 +748,7 @@ func (pool *LendingPool) enqueueTx(hash common.Hash, tx *types.LendingTransactio
 	// Discard any previous transaction and mark this
 	if old != nil {
 		delete(pool.all, old.Hash())
+		pool.history.replaced(old.Hash(), hash)
 		queuedReplaceMeter.Mark(1)
 	}
 	pool.all[hash] = tx
@@ -762,12 +781,14 @@ func (pool *LendingPool) promoteTx(addr common.Address, hash common.Hash, tx *ty
 	if !inserted {
 		// An older transaction was better, discard this
 		delete(pool.all, hash)
+		pool.history.dropped(hash, DropReasonReplaceUnderpriced)
 		pendingDiscardMeter.Mark(1)
 		return
 	}
 	// Otherwise discard any previous transaction and mark this
 	if old != nil {
 		delete(pool.all, old.Hash())
+		pool.history.replaced(old.Hash(), hash)
 		pendingReplaceMeter.Mark(1)
 	}
 	// Failsafe to work around direct pending inserts (tests)
@@ -777,6 +798,7 @@ func (pool *LendingPool) promoteTx(addr common.Address, hash common.Hash, tx *ty
 	// Set the potentially new pending nonce and notify any subsystems of the new tx
 	pool.beats[addr] = time.Now()
 	pool.pendingState.SetNonce(addr.Hash(), tx.Nonce()+1)
+	pool.history.promoted(hash)
 
 	go pool.txFeed.Send(LendingTxPreEvent{tx})
 }
@@ -897,6 +919,56 @@ func (pool *LendingPool) Get(hash common.Hash) *types.LendingTransaction {
 	return pool.all[hash]
 }
 
+// History returns the lifecycle events recorded for a transaction, or nil if
+// the pool hasn't seen it recently.
+func (pool *LendingPool) History(hash common.Hash) []TxLifecycleEvent {
+	return pool.history.get(hash)
+}
+
+// recordInclusions marks the lending transactions matched in the blocks
+// between the old and the new head as included in the history. Only the blocks
+// above the old head are walked, and at most as many as a reorg the tx pool
+// handles.
+func (pool *LendingPool) recordInclusions(oldHead, newHead *types.Block) {
+	block := newHead
+	for depth := 0; block != nil && depth < txMaxReorgDepth && block.NumberU64() > oldHead.NumberU64(); depth++ {
+		batches, _ := ExtractLendingTransactions(block.Transactions())
+		for _, batch := range batches {
+			for _, item := range batch.Data {
+				if item == nil || item.Nonce == nil {
+					continue
+				}
+				if tx := pool.pooledTx(item.UserAddress, item.Nonce.Uint64()); tx != nil && tx.LendingHash() == item.Hash && tx.Status() == item.Status {
+					pool.history.included(tx.Hash(), block.NumberU64(), block.Hash())
+				}
+			}
+		}
+		block = pool.chain.GetBlock(block.ParentHash(), block.NumberU64()-1)
+	}
+}
+
+// pooledTx returns the pending or queued transaction of the account with the
+// given nonce, if any.
+func (pool *LendingPool) pooledTx(addr common.Address, nonce uint64) *types.LendingTransaction {
+	if list := pool.pending[addr]; list != nil {
+		if tx := list.txs.Get(nonce); tx != nil {
+			return tx
+		}
+	}
+	if list := pool.queue[addr]; list != nil {
+		return list.txs.Get(nonce)
+	}
+	return nil
+}
+
+// droppedStale records the removal of a transaction whose nonce was used up.
+// Unless the transaction itself was matched, another one took its place.
+func (pool *LendingPool) droppedStale(hash common.Hash) {
+	if !pool.history.isIncluded(hash) {
+		pool.history.dropped(hash, DropReasonNonceTooLow)
+	}
+}
+
 // removeTx removes a single transaction from the queue, moving all subsequent
 // transactions back to the future queue.
 func (pool *LendingPool) removeTx(hash common.Hash) {
@@ -964,6 +1036,7 @@ func (pool *LendingPool) promoteExecutables(accounts []common.Address) {
 			hash := tx.Hash()
 			log.Trace("Removed old queued transaction", "hash", hash)
 			delete(pool.all, hash)
+			pool.droppedStale(hash)
 
 		}
 
@@ -978,6 +1051,7 @@ func (pool *LendingPool) promoteExecutables(accounts []common.Address) {
 			for _, tx := range list.Cap(int(pool.config.AccountQueue)) {
 				hash := tx.Hash()
 				delete(pool.all, hash)
+				pool.history.dropped(hash, DropReasonAccountQueueLimit)
 
 				queuedRateLimitMeter.Mark(1)
 				log.Trace("Removed cap-exceeding queued transaction", "hash", hash)
@@ -1023,6 +1097,7 @@ func (pool *LendingPool) promoteExecutables(accounts []common.Address) {
 							// Drop the transaction from the global pools too
 							hash := tx.Hash()
 							delete(pool.all, hash)
+							pool.history.dropped(hash, DropReasonPendingLimit)
 
 							// Update the account nonce to the dropped transaction
 							if nonce := tx.Nonce(); pool.pendingState.GetNonce(offenders[i].Hash()) > nonce {
@@ -1044,6 +1119,7 @@ func (pool *LendingPool) promoteExecutables(accounts []common.Address) {
 						// Drop the transaction from the global pools too
 						hash := tx.Hash()
 						delete(pool.all, hash)
+						pool.history.dropped(hash, DropReasonPendingLimit)
 
 						// Update the account nonce to the dropped transaction
 						if nonce := tx.Nonce(); pool.pendingState.GetNonce(addr.Hash()) > nonce {
@@ -1082,6 +1158,7 @@ func (pool *LendingPool) promoteExecutables(accounts []common.Address) {
 			// Drop all transactions if they are less than the overflow
 			if size := uint64(list.Len()); size <= drop {
 				for _, tx := range list.Flatten() {
+					pool.history.dropped(tx.Hash(), DropReasonQueueLimit)
 					pool.removeTx(tx.Hash())
 				}
 				drop -= size
@@ -1091,6 +1168,7 @@ func (pool *LendingPool) promoteExecutables(accounts []common.Address) {
 			// Otherwise drop only last few transactions
 			txs := list.Flatten()
 			for i := len(txs) - 1; i >= 0 && drop > 0; i-- {
+				pool.history.dropped(txs[i].Hash(), DropReasonQueueLimit)
 				pool.removeTx(txs[i].Hash())
 				drop--
 				queuedRateLimitMeter.Mark(1)
@@ -1112,6 +1190,7 @@ func (pool *LendingPool) demoteUnexecutables() {
 			hash := tx.Hash()
 			log.Debug("Removed old pending transaction", "hash", hash)
 			delete(pool.all, hash)
+			pool.droppedStale(hash)
 		}
 
 		// If there's a gap in front, warn (should never happen) and postpone all transactions
@@ -48,6 +48,9 @@ var (
 	ErrInvalidOrderPrice       = errors.New("invalid order price")
 	ErrInvalidOrderHash        = errors.New("invalid order hash")
 	ErrInvalidCancelledOrder   = errors.New("invalid cancel orderid")
+	ErrOrderTypesNotEnabled    = errors.New("time in force, post only and expiry are not enabled yet")
+	ErrOrderExpired            = errors.New("order expired")
+	ErrTriggerOrdersNotEnabled = errors.New("stop-loss and take-profit orders are not enabled yet")
 )
 
 var (
@@ -149,6 +152,7 @@ type OrderPool struct {
 	queue     map[common.Address]*ordertxList         // Queued but non-processable transactions
 	beats     map[common.Address]time.Time            // Last heartbeat from each known account
 	all       map[common.Hash]*types.OrderTransaction // All transactions to allow lookups
+	history   *txHistory                              // Lifecycle events of recently seen transactions
 	wg        sync.WaitGroup                          // for shutdown sync
 	homestead bool
 	IsSigner  func(address common.Address) bool
@@ -170,6 +174,7 @@ func NewOrderPool(chainconfig *params.ChainConfig, chain blockChainXDCx) *OrderP
 		queue:       make(map[common.Address]*ordertxList),
 		beats:       make(map[common.Address]time.Time),
 		all:         make(map[common.Hash]*types.OrderTransaction),
+		history:     newTxHistory(txHistoryLimit),
 		chainHeadCh: make(chan ChainHeadEvent, chainHeadChanSize),
 	}
 	pool.locals = newOrderAccountSet(pool.signer)
@@ -255,6 +260,7 @@ func (pool *OrderPool) loop() {
 				// Any non-locals old enough should be removed
 				if time.Since(pool.beats[addr]) > pool.config.Lifetime {
 					for _, tx := range pool.queue[addr].Flatten() {
+						pool.history.dropped(tx.Hash(), DropReasonExpired)
 						pool.removeTx(tx.Hash())
 					}
 				}
@@ -303,6 +309,10 @@ func (pool *OrderPool) reset(oldHead, newblock *types.Block) {
 	}
 	pool.currentRootState = state
 
+	if oldHead != nil {
+		pool.recordInclusions(oldHead, newblock)
+	}
+
 	// Inject any transactions discarded due to reorgs
 	log.Debug("Reinjecting stale transactions", "count", len(reinject))
 	pool.addTxsLocked(reinject, false)
@@ -331,6 +341,7 @@ func (pool *OrderPool) Stop() {
 	// Unsubscribe subscriptions registered from blockchain
 	pool.chainHeadSub.Unsubscribe()
 	pool.wg.Wait()
+	pool.history.stop()
 
 	if pool.journal != nil {
 		pool.journal.close()
@@ -344,6 +355,12 @@ func (pool *OrderPool) SubscribeTxPreEvent(ch chan<- OrderTxPreEvent) event.Subs
 	return pool.scope.Track(pool.txFeed.Subscribe(ch))
 }
 
+// SubscribeDroppedTxEvent registers a subscription of DroppedTxEvent and
+// starts sending event to the given channel.
+func (pool *OrderPool) SubscribeDroppedTxEvent(ch chan<- DroppedTxEvent) event.Subscription {
+	return pool.scope.Track(pool.history.feed.Subscribe(ch))
+}
+
 // State returns the virtual managed state of the transaction pool.
 func (pool *OrderPool) State() *tradingstate.XDCXManagedState {
 	pool.mu.RLock()
@@ -457,6 +474,38 @@ func (pool *OrderPool) validateOrder(tx *types.OrderTransaction) error {
 		if orderType != OrderTypeLimit && orderType != OrderTypeMarket {
 			return ErrInvalidOrderType
 		}
+		if tx.HasMatchingOptions() {
+			// The order can't be included before the next block
+			number := new(big.Int).Add(pool.chain.CurrentBlock().Number(), common.Big1)
+			if !pool.chainconfig.IsTIPXDCXOrderTypes(number) {
+				return ErrOrderTypesNotEnabled
+			}
+			order := &tradingstate.OrderItem{
+				Type:        orderType,
+				TimeInForce: tx.TimeInForce(),
+				PostOnly:    tx.PostOnly(),
+				ExpireBlock: tx.ExpireBlock(),
+			}
+			if err := order.VerifyMatchingOptions(); err != nil {
+				return err
+			}
+			if order.IsExpired(number.Uint64()) {
+				return ErrOrderExpired
+			}
+		}
+		if tx.IsTriggerOrder() {
+			number := new(big.Int).Add(pool.chain.CurrentBlock().Number(), common.Big1)
+			if !pool.chainconfig.IsTIPXDCXTriggerOrders(number) {
+				return ErrTriggerOrdersNotEnabled
+			}
+			order := &tradingstate.OrderItem{
+				TriggerPrice:     tx.TriggerPrice(),
+				TriggerCondition: tx.TriggerCondition(),
+			}
+			if err := order.VerifyTriggerOptions(); err != nil {
+				return err
+			}
+		}
 		if err := tradingstate.VerifyPair(cloneStateDb, tx.ExchangeAddress(), tx.BaseToken(), tx.QuoteToken()); err != nil {
 			return err
 		}
@@ -597,10 +646,13 @@ func (pool *OrderPool) add(tx *types.OrderTransaction, local bool) (bool, error)
 		}
 		if old != nil {
 			delete(pool.all, old.Hash())
+			pool.history.replaced(old.Hash(), hash)
 			pendingReplaceMeter.Mark(1)
 		}
 		pool.all[tx.Hash()] = tx
 		pool.journalTx(from, tx)
+		pool.history.added(hash)
+		pool.history.promoted(hash)
 
 		log.Debug("Pooled new executable transaction", "hash", hash, "useraddress", tx.UserAddress().Hex(), "nonce", tx.Nonce(), "status", tx.Status(), "orderid", tx.OrderID())
 		go pool.txFeed.Send(OrderTxPreEvent{tx})
@@ -617,6 +669,7 @@ func (pool *OrderPool) add(tx *types.OrderTransaction, local bool) (bool, error)
 		pool.locals.add(from)
 	}
 	pool.journalTx(from, tx)
+	pool.history.added(hash)
 
 	log.Debug("Pooled new future transaction", "hash", hash, "from", from)
 	return replace, nil
@@ -641,6 +694,7 @@ func (pool *OrderPool) enqueueTx(hash common.Hash, tx *types.OrderTransaction) (
 	// Discard any previous transaction and mark this
 	if old != nil {
 		delete(pool.all, old.Hash())
+		pool.history.replaced(old.Hash(), hash)
 		queuedReplaceMeter.Mark(1)
 	}
 	pool.all[hash] = tx
@@ -674,12 +728,14 @@ func (pool *OrderPool) promoteTx(addr common.Address, hash common.Hash, tx *type
 	if !inserted {
 		// An older transaction was better, discard this
 		delete(pool.all, hash)
+		pool.history.dropped(hash, DropReasonReplaceUnderpriced)
 		pendingDiscardMeter.Mark(1)
 		return
 	}
 	// Otherwise discard any previous transaction and mark this
 	if old != nil {
 		delete(pool.all, old.Hash())
+		pool.history.replaced(old.Hash(), hash)
 		pendingReplaceMeter.Mark(1)
 	}
 	// Failsafe to work around direct pending inserts (tests)
@@ -689,6 +745,7 @@ func (pool *OrderPool) promoteTx(addr common.Address, hash common.Hash, tx *type
 	// Set the potentially new pending nonce and notify any subsystems of the new tx
 	pool.beats[addr] = time.Now()
 	pool.pendingState.SetNonce(addr.Hash(), tx.Nonce()+1)
+	pool.history.promoted(hash)
 	log.Debug("promoteTx txFeed.Send", "addr", tx.UserAddress().Hex(), "nonce", tx.Nonce(), "ohash", tx.OrderHash().Hex(), "status", tx.Status(), "orderid", tx.OrderID())
 	go pool.txFeed.Send(OrderTxPreEvent{tx})
 }
@@ -813,6 +870,56 @@ func (pool *OrderPool) Get(hash common.Hash) *types.OrderTransaction {
 	return pool.all[hash]
 }
 
+// History returns the lifecycle events recorded for a transaction, or nil if
+// the pool hasn't seen it recently.
+func (pool *OrderPool) History(hash common.Hash) []TxLifecycleEvent {
+	return pool.history.get(hash)
+}
+
+// recordInclusions marks the order transactions matched in the blocks between
+// the old and the new head as included in the history. Only the blocks above
+// the old head are walked, and at most as many as a reorg the tx pool handles.
+func (pool *OrderPool) recordInclusions(oldHead, newHead *types.Block) {
+	block := newHead
+	for depth := 0; block != nil && depth < txMaxReorgDepth && block.NumberU64() > oldHead.NumberU64(); depth++ {
+		batches, _ := ExtractTradingTransactions(block.Transactions())
+		for _, batch := range batches {
+			for _, match := range batch.Data {
+				order, err := match.DecodeOrder()
+				if err != nil || order.Nonce == nil {
+					continue
+				}
+				if tx := pool.pooledTx(order.UserAddress, order.Nonce.Uint64()); tx != nil && tx.OrderHash() == order.Hash && tx.Status() == order.Status {
+					pool.history.included(tx.Hash(), block.NumberU64(), block.Hash())
+				}
+			}
+		}
+		block = pool.chain.GetBlock(block.ParentHash(), block.NumberU64()-1)
+	}
+}
+
+// pooledTx returns the pending or queued transaction of the account with the
+// given nonce, if any.
+func (pool *OrderPool) pooledTx(addr common.Address, nonce uint64) *types.OrderTransaction {
+	if list := pool.pending[addr]; list != nil {
+		if tx := list.txs.Get(nonce); tx != nil {
+			return tx
+		}
+	}
+	if list := pool.queue[addr]; list != nil {
+		return list.txs.Get(nonce)
+	}
+	return nil
+}
+
+// droppedStale records the removal of a transaction whose nonce was used up.
+// Unless the transaction itself was matched, another one took its place.
+func (pool *OrderPool) droppedStale(hash common.Hash) {
+	if !pool.history.isIncluded(hash) {
+		pool.history.dropped(hash, DropReasonNonceTooLow)
+	}
+}
+
 // removeTx removes a single transaction from the queue, moving all subsequent
 // transactions back to the future queue.
 func (pool *OrderPool) removeTx(hash common.Hash) {
@@ -880,6 +987,7 @@ func (pool *OrderPool) promoteExecutables(accounts []common.Address) {
 			hash := tx.Hash()
 			log.Debug("Removed old queued transaction", "addr", tx.UserAddress().Hex(), "nonce", tx.Nonce(), "ohash", tx.OrderHash().Hex(), "status", tx.Status(), "orderid", tx.OrderID())
 			delete(pool.all, hash)
+			pool.droppedStale(hash)
 
 		}
 
@@ -894,6 +1002,7 @@ func (pool *OrderPool) promoteExecutables(accounts []common.Address) {
 			for _, tx := range list.Cap(int(pool.config.AccountQueue)) {
 				hash := tx.Hash()
 				delete(pool.all, hash)
+				pool.history.dropped(hash, DropReasonAccountQueueLimit)
 
 				queuedRateLimitMeter.Mark(1)
 				log.Debug("Removed cap-exceeding queued transaction", "addr", tx.UserAddress().Hex(), "nonce", tx.Nonce(), "ohash", tx.OrderHash().Hex(), "status", tx.Status(), "orderid", tx.OrderID())
@@ -940,6 +1049,7 @@ func (pool *OrderPool) promoteExecutables(accounts []common.Address) {
 							// Drop the transaction from the global pools too
 							hash := tx.Hash()
 							delete(pool.all, hash)
+							pool.history.dropped(hash, DropReasonPendingLimit)
 
 							// Update the account nonce to the dropped transaction
 							if nonce := tx.Nonce(); pool.pendingState.GetNonce(offenders[i].Hash()) > nonce {
@@ -961,6 +1071,7 @@ func (pool *OrderPool) promoteExecutables(accounts []common.Address) {
 						// Drop the transaction from the global pools too
 						hash := tx.Hash()
 						delete(pool.all, hash)
+						pool.history.dropped(hash, DropReasonPendingLimit)
 
 						// Update the account nonce to the dropped transaction
 						if nonce := tx.Nonce(); pool.pendingState.GetNonce(addr.Hash()) > nonce {
@@ -999,6 +1110,7 @@ func (pool *OrderPool) promoteExecutables(accounts []common.Address) {
 			// Drop all transactions if they are less than the overflow
 			if size := uint64(list.Len()); size <= drop {
 				for _, tx := range list.Flatten() {
+					pool.history.dropped(tx.Hash(), DropReasonQueueLimit)
 					pool.removeTx(tx.Hash())
 				}
 				drop -= size
@@ -1008,6 +1120,7 @@ func (pool *OrderPool) promoteExecutables(accounts []common.Address) {
 			// Otherwise drop only last few transactions
 			txs := list.Flatten()
 			for i := len(txs) - 1; i >= 0 && drop > 0; i-- {
+				pool.history.dropped(txs[i].Hash(), DropReasonQueueLimit)
 				pool.removeTx(txs[i].Hash())
 				drop--
 				queuedRateLimitMeter.Mark(1)
@@ -1029,6 +1142,7 @@ func (pool *OrderPool) demoteUnexecutables() {
 			hash := tx.Hash()
 			log.Debug("demoteUnexecutables removed old queued transaction", "addr", tx.UserAddress().Hex(), "nonce", tx.Nonce(), "ohash", tx.OrderHash().Hex(), "status", tx.Status(), "orderid", tx.OrderID())
 			delete(pool.all, hash)
+			pool.droppedStale(hash)
 		}
 
 		// If there's a gap in front, warn (should never happen) and postpone all transactions
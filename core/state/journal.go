@@ -41,6 +41,9 @@ type (
 	resetObjectChange struct {
 		prev *stateObject
 	}
+	createContractChange struct {
+		account *common.Address
+	}
 	suicideChange struct {
 		account     *common.Address
 		prev        bool // whether account had already suicided
@@ -64,6 +67,10 @@ type (
 		account            *common.Address
 		prevcode, prevhash []byte
 	}
+	transientStorageChange struct {
+		account       *common.Address
+		key, prevalue common.Hash
+	}
 
 	// Changes to other state values.
 	refundChange struct {
@@ -99,6 +106,12 @@ func (ch resetObjectChange) undo(s *StateDB) {
 	s.setStateObject(ch.prev)
 }
 
+func (ch createContractChange) undo(s *StateDB) {
+	if obj := s.getStateObject(*ch.account); obj != nil {
+		obj.created = false
+	}
+}
+
 func (ch suicideChange) undo(s *StateDB) {
 	obj := s.getStateObject(*ch.account)
 	if obj != nil {
@@ -134,6 +147,10 @@ func (ch storageChange) undo(s *StateDB) {
 	s.getStateObject(*ch.account).setState(ch.key, ch.prevalue)
 }
 
+func (ch transientStorageChange) undo(s *StateDB) {
+	s.setTransientState(*ch.account, ch.key, ch.prevalue)
+}
+
 func (ch refundChange) undo(s *StateDB) {
 	s.refund = ch.prev
 }
@@ -88,6 +88,7 @@ type stateObject struct {
 	suicided  bool
 	touched   bool
 	deleted   bool
+	created   bool                      // true if the contract was deployed in the current transaction (EIP-6780)
 	onDirty   func(addr common.Address) // Callback method to mark a state object newly dirty
 }
 
@@ -355,6 +356,7 @@ func (s *stateObject) deepCopy(db *StateDB, onDirty func(addr common.Address)) *
 	stateObject.suicided = s.suicided
 	stateObject.dirtyCode = s.dirtyCode
 	stateObject.deleted = s.deleted
+	stateObject.created = s.created
 	return stateObject
 }
 
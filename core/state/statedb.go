@@ -77,6 +77,9 @@ type StateDB struct {
 	// Per-transaction access list
 	accessList *accessList
 
+	// Transient storage
+	transientStorage transientStorage
+
 	// Journal of state modifications. This is the backbone of
 	// Snapshot and RevertToSnapshot.
 	journal        journal
@@ -125,6 +128,7 @@ func New(root common.Hash, db Database) (*StateDB, error) {
 		logs:              make(map[common.Hash][]*types.Log),
 		preimages:         make(map[common.Hash][]byte),
 		accessList:        newAccessList(),
+		transientStorage:  newTransientStorage(),
 	}, nil
 }
 
@@ -414,6 +418,51 @@ func (self *StateDB) Suicide(addr common.Address) bool {
 	return true
 }
 
+// Suicide6780 marks the given account as suicided only if it was created in
+// the current transaction, as per EIP-6780.
+func (self *StateDB) Suicide6780(addr common.Address) {
+	if self.IsNewContract(addr) {
+		self.Suicide(addr)
+	}
+}
+
+// IsNewContract reports whether the given account was deployed by a contract
+// creation of the current transaction.
+func (self *StateDB) IsNewContract(addr common.Address) bool {
+	stateObject := self.getStateObject(addr)
+	if stateObject != nil {
+		return stateObject.created
+	}
+	return false
+}
+
+// SetTransientState sets transient storage for a given account. It
+// adds the change to the journal so that it can be rolled back
+// to its previous value if there is a revert.
+func (self *StateDB) SetTransientState(addr common.Address, key, value common.Hash) {
+	prev := self.GetTransientState(addr, key)
+	if prev == value {
+		return
+	}
+	self.journal = append(self.journal, transientStorageChange{
+		account:  &addr,
+		key:      key,
+		prevalue: prev,
+	})
+	self.setTransientState(addr, key, value)
+}
+
+// setTransientState is a lower level setter for transient storage. It
+// is called during a revert to prevent modifications to the journal.
+func (self *StateDB) setTransientState(addr common.Address, key, value common.Hash) {
+	self.transientStorage.Set(addr, key, value)
+}
+
+// GetTransientState gets transient storage for a given account.
+func (self *StateDB) GetTransientState(addr common.Address, key common.Hash) common.Hash {
+	return self.transientStorage.Get(addr, key)
+}
+
 //
 // Setting, updating & deleting state object methods.
 //
@@ -521,6 +570,17 @@ func (self *StateDB) CreateAccount(addr common.Address) {
 	}
 }
 
+// CreateContract marks the given account as deployed by a contract creation of
+// the current transaction. Unlike a plain value transfer creating the account,
+// this allows the contract to be deleted by SELFDESTRUCT under EIP-6780.
+func (self *StateDB) CreateContract(addr common.Address) {
+	stateObject := self.getStateObject(addr)
+	if stateObject != nil && !stateObject.created {
+		self.journal = append(self.journal, createContractChange{account: &addr})
+		stateObject.created = true
+	}
+}
+
 func (db *StateDB) ForEachStorage(addr common.Address, cb func(key, value common.Hash) bool) error {
 	so := db.getStateObject(addr)
 	if so == nil {
@@ -585,6 +645,7 @@ func (self *StateDB) Copy() *StateDB {
 	// However, it doesn't cost us much to copy an empty list, so we do it anyway
 	// to not blow up if we ever decide copy it in the middle of a transaction
 	state.accessList = self.accessList.Copy()
+	state.transientStorage = self.transientStorage.Copy()
 	return state
 }
 
@@ -633,6 +694,7 @@ func (s *StateDB) Finalise(deleteEmptyObjects bool) {
 			stateObject.updateRoot(s.db)
 			s.updateStateObject(stateObject)
 		}
+		stateObject.created = false
 	}
 	// Invalidate journal because reverting across transactions is not allowed.
 	s.clearJournalAndRefund()
@@ -647,11 +709,13 @@ func (s *StateDB) IntermediateRoot(deleteEmptyObjects bool) common.Hash {
 }
 
 // Prepare sets the current transaction hash and index and block hash which is
-// used when the EVM emits new state logs.
+// used when the EVM emits new state logs. It also resets the per-transaction
+// access list and transient storage.
 func (s *StateDB) Prepare(thash common.Hash, ti int) {
 	s.thash = thash
 	s.txIndex = ti
 	s.accessList = newAccessList()
+	s.transientStorage = newTransientStorage()
 }
 
 // DeleteSuicides flags the suicided objects for deletion so that it
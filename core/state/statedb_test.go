@@ -615,3 +615,86 @@ func TestStateDBAccessList(t *testing.T) {
 		t.Fatalf("expected empty, got %d", got)
 	}
 }
+
+func TestTransientStorage(t *testing.T) {
+	state, _ := New(common.Hash{}, NewDatabase(rawdb.NewMemoryDatabase()))
+
+	key := common.Hash{0x01}
+	value := common.Hash{0x02}
+	addr := common.Address{}
+
+	snapshot := state.Snapshot()
+	state.SetTransientState(addr, key, value)
+	if exp, got := 1, state.journal.length(); exp != got {
+		t.Fatalf("journal length mismatch: have %d, want %d", got, exp)
+	}
+	// the retrieved value should equal what was set
+	if got := state.GetTransientState(addr, key); got != value {
+		t.Fatalf("transient storage mismatch: have %x, want %x", got, value)
+	}
+	// revert the transient state being set and then check that the
+	// value is now the empty hash
+	state.RevertToSnapshot(snapshot)
+	if got, exp := state.GetTransientState(addr, key), (common.Hash{}); exp != got {
+		t.Fatalf("transient storage mismatch: have %x, want %x", got, exp)
+	}
+	// set transient state and then copy the statedb and ensure that
+	// the transient state is copied
+	state.SetTransientState(addr, key, value)
+	cpy := state.Copy()
+	if got := cpy.GetTransientState(addr, key); got != value {
+		t.Fatalf("transient storage mismatch: have %x, want %x", got, value)
+	}
+	// the transient storage is cleared for the next transaction
+	state.Prepare(common.Hash{0x03}, 1)
+	if got, exp := state.GetTransientState(addr, key), (common.Hash{}); exp != got {
+		t.Fatalf("transient storage not cleared: have %x, want %x", got, exp)
+	}
+}
+
+func TestSuicide6780(t *testing.T) {
+	state, _ := New(common.Hash{}, NewDatabase(rawdb.NewMemoryDatabase()))
+	existing, funded, created := common.Address{0x01}, common.Address{0x02}, common.Address{0x03}
+
+	state.SetBalance(existing, big.NewInt(1))
+	state.Finalise(true)
+
+	// accounts of previous transactions are kept
+	state.Suicide6780(existing)
+	if state.HasSuicided(existing) {
+		t.Errorf("account of a previous transaction suicided")
+	}
+	// accounts created by a value transfer are not contracts
+	state.CreateAccount(funded)
+	state.Suicide6780(funded)
+	if state.HasSuicided(funded) {
+		t.Errorf("account created by a transfer suicided")
+	}
+	// contracts deployed in the current transaction are deleted
+	state.CreateAccount(created)
+	state.CreateContract(created)
+	state.Suicide6780(created)
+	if !state.HasSuicided(created) {
+		t.Errorf("contract deployed in the transaction not suicided")
+	}
+	state.Finalise(true)
+	if state.Exist(created) {
+		t.Errorf("suicided account still exists")
+	}
+	// the deployment is reverted with the snapshot
+	snapshot := state.Snapshot()
+	state.CreateAccount(created)
+	state.CreateContract(created)
+	state.RevertToSnapshot(snapshot)
+	state.CreateAccount(created)
+	if state.IsNewContract(created) {
+		t.Errorf("reverted deployment still marked")
+	}
+	// the deployment flag does not survive the transaction
+	state.CreateContract(created)
+	state.Finalise(false)
+	state.Suicide6780(created)
+	if state.HasSuicided(created) {
+		t.Errorf("contract of a previous transaction suicided")
+	}
+}
@@ -0,0 +1,213 @@
+// Copyright (c) 2018 XDPoSChain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"sync"
+	"time"
+
+	"github.com/XinFinOrg/XDPoSChain/common"
+	"github.com/XinFinOrg/XDPoSChain/event"
+)
+
+const (
+	txHistoryLimit     = 16384 // Number of transactions whose lifecycle is remembered
+	txHistoryMaxEvents = 32    // Number of events remembered per transaction
+)
+
+// TxEventType is a step in the life of a transaction inside a pool.
+type TxEventType string
+
+const (
+	TxEventAdded    TxEventType = "added"    // Accepted into the pool
+	TxEventPromoted TxEventType = "promoted" // Moved into the executable set
+	TxEventReplaced TxEventType = "replaced" // Replaced by another transaction with the same nonce
+	TxEventDropped  TxEventType = "dropped"  // Removed from the pool without being included
+	TxEventIncluded TxEventType = "included" // Included in the chain
+)
+
+// TxDropReason explains why a transaction left a pool without being included.
+type TxDropReason string
+
+const (
+	DropReasonReplaced           TxDropReason = "replaced"                // Replaced by a transaction with the same nonce
+	DropReasonReplaceUnderpriced TxDropReason = "replacement underpriced" // A transaction with the same nonce paid more
+	DropReasonUnderpriced        TxDropReason = "underpriced"             // Evicted for better paying transactions or below the price limit
+	DropReasonNonceTooLow        TxDropReason = "nonce too low"           // Another transaction with the same nonce was included
+	DropReasonUnpayable          TxDropReason = "unpayable"               // Insufficient funds or gas above the block gas limit
+	DropReasonAccountQueueLimit  TxDropReason = "account queue limit"     // Too many queued transactions from the same sender
+	DropReasonPendingLimit       TxDropReason = "pending limit"           // Global pending limit reached
+	DropReasonQueueLimit         TxDropReason = "queue limit"             // Global queue limit reached
+	DropReasonExpired            TxDropReason = "expired"                 // Queued for longer than the pool lifetime
+)
+
+// TxLifecycleEvent is a single entry of the history of a pooled transaction.
+type TxLifecycleEvent struct {
+	Type        TxEventType
+	Reason      TxDropReason // Set for dropped transactions
+	ReplacedBy  common.Hash  // Set for replaced transactions
+	BlockNumber uint64       // Set for included transactions
+	BlockHash   common.Hash  // Set for included transactions
+	Time        time.Time
+}
+
+// txHistory remembers the lifecycle events of the most recent transactions seen
+// by a pool, so users can find out why a transaction never made it into a block.
+// The tracked hashes form a ring, the oldest one is forgotten when it's full.
+//
+// Drops are announced on the feed by a single goroutine, so a slow subscriber
+// never blocks the pool. The goroutine only runs while there are drops to
+// announce. At most limit announcements are queued, the oldest ones are
+// discarded beyond that.
+type txHistory struct {
+	events map[common.Hash][]TxLifecycleEvent
+	ring   []common.Hash
+	next   int
+	lock   sync.RWMutex
+
+	feed       event.Feed       // DroppedTxEvent feed
+	queue      []DroppedTxEvent // Drops not announced yet
+	announcing bool             // Whether a goroutine is announcing the queue
+	stopped    bool             // Whether the drops are no longer announced
+	queueMu    sync.Mutex
+}
+
+func newTxHistory(limit int) *txHistory {
+	return &txHistory{
+		events: make(map[common.Hash][]TxLifecycleEvent),
+		ring:   make([]common.Hash, limit),
+	}
+}
+
+// stop discards the drops not announced yet and the later ones. It may be
+// called more than once.
+func (h *txHistory) stop() {
+	h.queueMu.Lock()
+	defer h.queueMu.Unlock()
+
+	h.stopped = true
+	h.queue = nil
+}
+
+// notify queues the announcement of a drop, starting the announcing goroutine
+// if it isn't running.
+func (h *txHistory) notify(ev DroppedTxEvent) {
+	h.queueMu.Lock()
+	defer h.queueMu.Unlock()
+
+	if h.stopped {
+		return
+	}
+	if len(h.queue) >= len(h.ring) {
+		h.queue = h.queue[1:]
+	}
+	h.queue = append(h.queue, ev)
+	if !h.announcing {
+		h.announcing = true
+		go h.announce()
+	}
+}
+
+// announce sends the queued drops on the feed until the queue is empty.
+func (h *txHistory) announce() {
+	for {
+		h.queueMu.Lock()
+		queue := h.queue
+		h.queue = nil
+		if len(queue) == 0 {
+			h.announcing = false
+			h.queueMu.Unlock()
+			return
+		}
+		h.queueMu.Unlock()
+
+		for _, ev := range queue {
+			h.feed.Send(ev)
+		}
+	}
+}
+
+func (h *txHistory) record(hash common.Hash, ev TxLifecycleEvent) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	events, ok := h.events[hash]
+	if !ok {
+		if old := h.ring[h.next]; old != (common.Hash{}) {
+			delete(h.events, old)
+		}
+		h.ring[h.next] = hash
+		h.next = (h.next + 1) % len(h.ring)
+	}
+	if len(events) >= txHistoryMaxEvents {
+		events = events[1:]
+	}
+	ev.Time = time.Now()
+	h.events[hash] = append(events, ev)
+}
+
+func (h *txHistory) added(hash common.Hash) {
+	h.record(hash, TxLifecycleEvent{Type: TxEventAdded})
+}
+
+func (h *txHistory) promoted(hash common.Hash) {
+	h.record(hash, TxLifecycleEvent{Type: TxEventPromoted})
+}
+
+func (h *txHistory) replaced(hash common.Hash, by common.Hash) {
+	h.record(hash, TxLifecycleEvent{Type: TxEventReplaced, ReplacedBy: by})
+	h.notify(DroppedTxEvent{Hash: hash, Reason: DropReasonReplaced, ReplacedBy: by})
+}
+
+func (h *txHistory) dropped(hash common.Hash, reason TxDropReason) {
+	h.record(hash, TxLifecycleEvent{Type: TxEventDropped, Reason: reason})
+	h.notify(DroppedTxEvent{Hash: hash, Reason: reason})
+}
+
+// included records the inclusion of a tracked transaction, transactions which
+// never went through the pool are ignored.
+func (h *txHistory) included(hash common.Hash, number uint64, blockHash common.Hash) {
+	h.lock.RLock()
+	_, ok := h.events[hash]
+	h.lock.RUnlock()
+
+	if ok {
+		h.record(hash, TxLifecycleEvent{Type: TxEventIncluded, BlockNumber: number, BlockHash: blockHash})
+	}
+}
+
+// isIncluded reports whether the last known event of the transaction is its
+// inclusion in the chain.
+func (h *txHistory) isIncluded(hash common.Hash) bool {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	events := h.events[hash]
+	return len(events) > 0 && events[len(events)-1].Type == TxEventIncluded
+}
+
+// get returns a copy of the events recorded for the transaction, or nil if it
+// is unknown or was forgotten already.
+func (h *txHistory) get(hash common.Hash) []TxLifecycleEvent {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	events, ok := h.events[hash]
+	if !ok {
+		return nil
+	}
+	return append([]TxLifecycleEvent(nil), events...)
+}
@@ -0,0 +1,171 @@
+// Copyright (c) 2018 XDPoSChain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/XinFinOrg/XDPoSChain/XDCx/tradingstate"
+	"github.com/XinFinOrg/XDPoSChain/common"
+	"github.com/XinFinOrg/XDPoSChain/core/types"
+)
+
+// Tests that the history forgets the oldest transactions once the ring is full.
+func TestTxHistoryRing(t *testing.T) {
+	history := newTxHistory(2)
+	defer history.stop()
+
+	a, b, c := common.HexToHash("0xa"), common.HexToHash("0xb"), common.HexToHash("0xc")
+	history.added(a)
+	history.added(b)
+	history.promoted(a)
+	history.added(c)
+
+	if events := history.get(a); events != nil {
+		t.Errorf("oldest transaction not forgotten: %v", events)
+	}
+	if events := history.get(b); len(events) != 1 {
+		t.Errorf("history length mismatch: have %d, want 1", len(events))
+	}
+	if events := history.get(c); len(events) != 1 {
+		t.Errorf("history length mismatch: have %d, want 1", len(events))
+	}
+}
+
+// Tests that only tracked transactions are marked as included, and that the
+// number of events per transaction is capped.
+func TestTxHistoryInclusion(t *testing.T) {
+	history := newTxHistory(16)
+	defer history.stop()
+
+	tracked, untracked := common.HexToHash("0xa"), common.HexToHash("0xb")
+	history.added(tracked)
+	history.included(tracked, 10, common.HexToHash("0x10"))
+	history.included(untracked, 10, common.HexToHash("0x10"))
+
+	if !history.isIncluded(tracked) {
+		t.Errorf("tracked transaction not included")
+	}
+	if events := history.get(untracked); events != nil {
+		t.Errorf("untracked transaction recorded: %v", events)
+	}
+	events := history.get(tracked)
+	if last := events[len(events)-1]; last.BlockNumber != 10 || last.BlockHash != common.HexToHash("0x10") {
+		t.Errorf("inclusion block mismatch: have %d %x", last.BlockNumber, last.BlockHash)
+	}
+	for i := 0; i < 2*txHistoryMaxEvents; i++ {
+		history.promoted(tracked)
+	}
+	if events := history.get(tracked); len(events) != txHistoryMaxEvents {
+		t.Errorf("history length mismatch: have %d, want %d", len(events), txHistoryMaxEvents)
+	}
+}
+
+// Tests that the drops are announced until the history is stopped, and that it
+// can be stopped more than once.
+func TestTxHistoryStop(t *testing.T) {
+	history := newTxHistory(16)
+
+	ch := make(chan DroppedTxEvent, 1)
+	sub := history.feed.Subscribe(ch)
+	defer sub.Unsubscribe()
+
+	hash := common.HexToHash("0xa")
+	history.dropped(hash, DropReasonUnderpriced)
+	select {
+	case ev := <-ch:
+		if ev.Hash != hash || ev.Reason != DropReasonUnderpriced {
+			t.Errorf("announcement mismatch: %+v", ev)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("drop not announced")
+	}
+	history.stop()
+	history.stop()
+
+	history.dropped(common.HexToHash("0xb"), DropReasonUnderpriced)
+	select {
+	case ev := <-ch:
+		t.Errorf("drop announced after stop: %+v", ev)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// testXDCxChain serves the blocks walked by the order pool, the other methods
+// of the chain aren't used.
+type testXDCxChain struct {
+	blockChainXDCx
+	blocks map[common.Hash]*types.Block
+}
+
+func (c *testXDCxChain) GetBlock(hash common.Hash, number uint64) *types.Block {
+	return c.blocks[hash]
+}
+
+// Tests that the order pool only marks the transactions matched in the new
+// blocks as included, the other ones whose nonce was used up are dropped.
+func TestOrderPoolInclusions(t *testing.T) {
+	user := common.HexToAddress("0x1")
+	newOrder := func(nonce uint64, hash common.Hash) *types.OrderTransaction {
+		return types.NewOrderTransaction(nonce, big.NewInt(1), big.NewInt(1), common.Address{}, user, common.Address{}, common.Address{}, tradingstate.OrderStatusNew, tradingstate.Bid, tradingstate.Limit, hash, 0)
+	}
+	matched, replaced := newOrder(0, common.HexToHash("0x10")), newOrder(1, common.HexToHash("0x11"))
+
+	// The block matches the first order and another one with the nonce of the second
+	var batch tradingstate.TxMatchBatch
+	for _, order := range []*tradingstate.OrderItem{
+		{UserAddress: user, Nonce: big.NewInt(0), Hash: matched.OrderHash(), Status: tradingstate.OrderStatusNew, Signature: &tradingstate.Signature{}},
+		{UserAddress: user, Nonce: big.NewInt(1), Hash: common.HexToHash("0x12"), Status: tradingstate.OrderStatusNew, Signature: &tradingstate.Signature{}},
+	} {
+		data, err := tradingstate.EncodeBytesItem(order)
+		if err != nil {
+			t.Fatal(err)
+		}
+		batch.Data = append(batch.Data, tradingstate.TxDataMatch{Order: data})
+	}
+	data, err := tradingstate.EncodeTxMatchesBatch(batch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	genesis := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(0)})
+	block := types.NewBlock(&types.Header{Number: big.NewInt(1), ParentHash: genesis.Hash()}, []*types.Transaction{
+		types.NewTransaction(0, common.XDCXAddrBinary, common.Big0, 0, common.Big0, data),
+	}, nil, nil)
+
+	pool := &OrderPool{
+		chain:   &testXDCxChain{blocks: map[common.Hash]*types.Block{genesis.Hash(): genesis}},
+		pending: map[common.Address]*ordertxList{user: newOrderTxList(true)},
+		queue:   make(map[common.Address]*ordertxList),
+		history: newTxHistory(16),
+	}
+	defer pool.history.stop()
+	for _, tx := range []*types.OrderTransaction{matched, replaced} {
+		pool.pending[user].Add(tx)
+		pool.history.added(tx.Hash())
+	}
+	pool.recordInclusions(genesis, block)
+	pool.droppedStale(matched.Hash())
+	pool.droppedStale(replaced.Hash())
+
+	if events := pool.History(matched.Hash()); events[len(events)-1].Type != TxEventIncluded || events[len(events)-1].BlockHash != block.Hash() {
+		t.Errorf("matched order not included: %+v", events)
+	}
+	if events := pool.History(replaced.Hash()); events[len(events)-1].Type != TxEventDropped || events[len(events)-1].Reason != DropReasonNonceTooLow {
+		t.Errorf("replaced order not dropped: %+v", events)
+	}
+}
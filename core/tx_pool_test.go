@@ -1781,6 +1781,94 @@ func TestTransactionReplacement(t *testing.T) {
 	}
 }
 
+// Tests that the lifecycle of transactions is recorded and that the dropped
+// ones are announced together with the reason.
+func TestTransactionHistory(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	dropped := make(chan DroppedTxEvent, 16)
+	sub := pool.SubscribeDroppedTxEvent(dropped)
+	defer sub.Unsubscribe()
+
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	pool.currentState.AddBalance(from, new(big.Int).Mul(big.NewInt(params.Ether), big.NewInt(1000)))
+
+	// Stay clear of the minimum gas price, which other tests change
+	var (
+		cheap      = big.NewInt(100 * params.GWei)
+		expensive  = big.NewInt(200 * params.GWei)
+		tx0        = pricedTransaction(0, 100000, cheap, key)
+		tx0Replace = pricedTransaction(0, 100000, expensive, key)
+		tx1        = pricedTransaction(1, 100000, expensive, key)
+		tx5        = pricedTransaction(5, 100000, cheap, key)
+	)
+	for _, tx := range []*types.Transaction{tx0, tx0Replace, tx1, tx5} {
+		if err := pool.addRemoteSync(tx); err != nil {
+			t.Fatalf("failed to add transaction: %v", err)
+		}
+	}
+	// The account nonce moving past the pending ones drops them
+	pool.currentState.SetNonce(from, 2)
+	<-pool.requestReset(nil, nil)
+	// Raising the price limit drops the cheap queued transaction
+	pool.SetGasPrice(expensive)
+
+	want := map[common.Hash][]TxLifecycleEvent{
+		tx0.Hash(): {
+			{Type: TxEventAdded},
+			{Type: TxEventPromoted},
+			{Type: TxEventReplaced, ReplacedBy: tx0Replace.Hash()},
+		},
+		tx0Replace.Hash(): {
+			{Type: TxEventAdded},
+			{Type: TxEventPromoted},
+			{Type: TxEventDropped, Reason: DropReasonNonceTooLow},
+		},
+		tx1.Hash(): {
+			{Type: TxEventAdded},
+			{Type: TxEventPromoted},
+			{Type: TxEventDropped, Reason: DropReasonNonceTooLow},
+		},
+		tx5.Hash(): {
+			{Type: TxEventAdded},
+			{Type: TxEventDropped, Reason: DropReasonUnderpriced},
+		},
+	}
+	for hash, events := range want {
+		have := pool.History(hash)
+		if len(have) != len(events) {
+			t.Fatalf("history length mismatch for %x: have %d, want %d", hash, len(have), len(events))
+		}
+		for i, ev := range events {
+			if have[i].Type != ev.Type || have[i].Reason != ev.Reason || have[i].ReplacedBy != ev.ReplacedBy {
+				t.Errorf("history event %d mismatch for %x: have %+v, want %+v", i, hash, have[i], ev)
+			}
+		}
+	}
+	if history := pool.History(common.Hash{}); history != nil {
+		t.Errorf("unknown transaction has history: %v", history)
+	}
+	// Every transaction left the pool, each of them should have been announced once
+	announced := make(map[common.Hash]TxDropReason)
+	for len(announced) < len(want) {
+		select {
+		case ev := <-dropped:
+			announced[ev.Hash] = ev.Reason
+		case <-time.After(time.Second):
+			t.Fatalf("dropped transaction announcements missing: have %d, want %d", len(announced), len(want))
+		}
+	}
+	if reason := announced[tx0.Hash()]; reason != DropReasonReplaced {
+		t.Errorf("replaced transaction drop reason mismatch: have %q, want %q", reason, DropReasonReplaced)
+	}
+	if reason := announced[tx5.Hash()]; reason != DropReasonUnderpriced {
+		t.Errorf("underpriced transaction drop reason mismatch: have %q, want %q", reason, DropReasonUnderpriced)
+	}
+}
+
 // Tests that local transactions are journaled to disk, but remote transactions
 // get discarded between restarts.
 func TestTransactionJournaling(t *testing.T)         { testTransactionJournaling(t, false) }
@@ -123,6 +123,23 @@ func (ordersign OrderTxSigner) OrderCreateHash(tx *OrderTransaction) common.Hash
 	sha.Write([]byte(tx.Status()))
 	sha.Write([]byte(tx.Type()))
 	sha.Write(common.BigToHash(big.NewInt(int64(tx.Nonce()))).Bytes())
+	// Matching options are only part of the hash when set, so the hash of
+	// plain orders is unchanged
+	if tx.HasMatchingOptions() {
+		sha.Write([]byte(tx.TimeInForce()))
+		if tx.PostOnly() {
+			sha.Write([]byte{1})
+		} else {
+			sha.Write([]byte{0})
+		}
+		sha.Write(common.BigToHash(new(big.Int).SetUint64(tx.ExpireBlock())).Bytes())
+	}
+	if tx.IsTriggerOrder() {
+		if tx.TriggerPrice() != nil {
+			sha.Write(common.BigToHash(tx.TriggerPrice()).Bytes())
+		}
+		sha.Write([]byte(tx.TriggerCondition()))
+	}
 	return common.BytesToHash(sha.Sum(nil))
 }
 
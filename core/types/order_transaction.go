@@ -42,6 +42,16 @@ const (
 	OrderStatusCancelled     = "CANCELLED"
 	OrderTypeMo              = "MO"
 	OrderTypeLo              = "LO"
+
+	// Time-in-force policies, an empty policy means good-til-cancelled
+	TimeInForceGTC = "GTC" // Good til cancelled
+	TimeInForceIOC = "IOC" // Immediate or cancel
+	TimeInForceFOK = "FOK" // Fill or kill
+	TimeInForceGTB = "GTB" // Good til block
+
+	// Trigger conditions of stop-loss and take-profit orders
+	TriggerAbove = "ABOVE" // Triggered when the epoch price rises to the trigger price
+	TriggerBelow = "BELOW" // Triggered when the epoch price falls to the trigger price
 )
 
 // OrderTransaction order transaction
@@ -72,6 +82,15 @@ type ordertxdata struct {
 
 	// This is only used when marshaling to JSON.
 	Hash common.Hash `json:"hash"`
+
+	// Matching options, only allowed after the TIPXDCXOrderTypes fork
+	TimeInForce string `json:"timeInForce,omitempty" rlp:"optional"`
+	PostOnly    bool   `json:"postOnly,omitempty" rlp:"optional"`
+	ExpireBlock uint64 `json:"expireBlock,omitempty" rlp:"optional"`
+
+	// Trigger options, only allowed after the TIPXDCXTriggerOrders fork
+	TriggerPrice     *big.Int `json:"triggerPrice,omitempty" rlp:"optional"`
+	TriggerCondition string   `json:"triggerCondition,omitempty" rlp:"optional"`
 }
 
 // IsCancelledOrder check if tx is cancelled transaction
@@ -119,6 +138,11 @@ func (tx *OrderTransaction) Type() string                    { return tx.data.Ty
 func (tx *OrderTransaction) Signature() (V, R, S *big.Int)   { return tx.data.V, tx.data.R, tx.data.S }
 func (tx *OrderTransaction) OrderHash() common.Hash          { return tx.data.Hash }
 func (tx *OrderTransaction) OrderID() uint64                 { return tx.data.OrderID }
+func (tx *OrderTransaction) TimeInForce() string             { return tx.data.TimeInForce }
+func (tx *OrderTransaction) PostOnly() bool                  { return tx.data.PostOnly }
+func (tx *OrderTransaction) ExpireBlock() uint64             { return tx.data.ExpireBlock }
+func (tx *OrderTransaction) TriggerPrice() *big.Int          { return tx.data.TriggerPrice }
+func (tx *OrderTransaction) TriggerCondition() string        { return tx.data.TriggerCondition }
 func (tx *OrderTransaction) EncodedSide() *big.Int {
 	if tx.Side() == "BUY" {
 		return big.NewInt(0)
@@ -128,6 +152,36 @@ func (tx *OrderTransaction) EncodedSide() *big.Int {
 }
 func (tx *OrderTransaction) SetOrderHash(h common.Hash) { tx.data.Hash = h }
 
+// HasMatchingOptions reports whether the order uses any of the time-in-force,
+// post-only or expiry options.
+func (tx *OrderTransaction) HasMatchingOptions() bool {
+	return tx.data.TimeInForce != "" || tx.data.PostOnly || tx.data.ExpireBlock != 0
+}
+
+// SetMatchingOptions sets the time-in-force policy, the post-only flag and the
+// expiry block of an order. It must be called before signing the order.
+func (tx *OrderTransaction) SetMatchingOptions(timeInForce string, postOnly bool, expireBlock uint64) {
+	tx.data.TimeInForce, tx.data.PostOnly, tx.data.ExpireBlock = timeInForce, postOnly, expireBlock
+	tx.hash = atomic.Value{}
+	tx.size = atomic.Value{}
+	tx.from = atomic.Value{}
+}
+
+// IsTriggerOrder reports whether the order waits for the epoch price to cross
+// its trigger price before being matched.
+func (tx *OrderTransaction) IsTriggerOrder() bool {
+	return tx.data.TriggerPrice != nil || tx.data.TriggerCondition != ""
+}
+
+// SetTrigger sets the trigger price and condition of a stop-loss or take-profit
+// order. It must be called before signing the order.
+func (tx *OrderTransaction) SetTrigger(price *big.Int, condition string) {
+	tx.data.TriggerPrice, tx.data.TriggerCondition = price, condition
+	tx.hash = atomic.Value{}
+	tx.size = atomic.Value{}
+	tx.from = atomic.Value{}
+}
+
 // From get transaction from
 func (tx *OrderTransaction) From() *common.Address {
 	if tx.data.V != nil {
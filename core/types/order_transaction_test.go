@@ -1,12 +1,14 @@
 package types
 
 import (
+	"bytes"
 	"crypto/ecdsa"
 	"math/big"
 	"testing"
 
 	"github.com/XinFinOrg/XDPoSChain/common"
 	"github.com/XinFinOrg/XDPoSChain/crypto"
+	"github.com/XinFinOrg/XDPoSChain/rlp"
 )
 
 func TestNewOrderTransactionByNonce(t *testing.T) {
@@ -29,3 +31,45 @@ func TestNewOrderTransactionByNonce(t *testing.T) {
 	tx := NewOrderTransactionByNonce(OrderTxSigner{}, groups)
 	t.Log(tx)
 }
+
+func TestOrderMatchingOptionsHash(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	signer := OrderTxSigner{}
+	tx := NewOrderTransaction(1, big.NewInt(1), big.NewInt(2), common.Address{1}, crypto.PubkeyToAddress(key.PublicKey), common.Address{2}, common.Address{3}, OrderStatusNew, "BUY", OrderTypeLo, common.Hash{}, 0)
+	plain := signer.Hash(tx)
+	plainEnc, _ := rlp.EncodeToBytes(tx)
+
+	tx.SetMatchingOptions(TimeInForceGTB, true, 100)
+	if signer.Hash(tx) == plain {
+		t.Fatal("matching options not covered by the order hash")
+	}
+	signed, err := OrderSignTx(tx, signer, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc, _ := rlp.EncodeToBytes(signed)
+	var decoded OrderTransaction
+	if err := rlp.DecodeBytes(enc, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if from, err := OrderSender(signer, &decoded); err != nil || from != crypto.PubkeyToAddress(key.PublicKey) {
+		t.Fatalf("sender mismatch: %v %v", from, err)
+	}
+
+	tx.SetMatchingOptions("", false, 0)
+	if signer.Hash(tx) != plain {
+		t.Error("order hash changed without matching options")
+	}
+	if enc, _ := rlp.EncodeToBytes(tx); !bytes.Equal(enc, plainEnc) {
+		t.Error("order encoding changed without matching options")
+	}
+
+	tx.SetTrigger(big.NewInt(3), TriggerBelow)
+	if signer.Hash(tx) == plain {
+		t.Fatal("trigger not covered by the order hash")
+	}
+	tx.SetTrigger(nil, "")
+	if signer.Hash(tx) != plain {
+		t.Error("order hash changed without trigger")
+	}
+}
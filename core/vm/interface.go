@@ -26,6 +26,12 @@ import (
 // StateDB is an EVM database for full state querying.
 type StateDB interface {
 	CreateAccount(common.Address)
+	// CreateContract marks the account as deployed by a contract creation of
+	// the current transaction.
+	CreateContract(common.Address)
+	// IsNewContract reports whether the account was deployed by a contract
+	// creation of the current transaction.
+	IsNewContract(common.Address) bool
 
 	SubBalance(common.Address, *big.Int)
 	AddBalance(common.Address, *big.Int)
@@ -50,6 +56,13 @@ type StateDB interface {
 	Suicide(common.Address) bool
 	HasSuicided(common.Address) bool
 
+	// Suicide6780 is post-EIP6780 suicide, which only deletes the account
+	// if it was created in the same transaction.
+	Suicide6780(common.Address)
+
+	GetTransientState(addr common.Address, key common.Hash) common.Hash
+	SetTransientState(addr common.Address, key, value common.Hash)
+
 	// Exist reports whether the given account exists in state.
 	// Notably this should also return true for suicided accounts.
 	Exist(common.Address) bool
@@ -84,6 +84,8 @@ func NewEVMInterpreter(evm *EVM, cfg Config) *EVMInterpreter {
 	// If jump table was not initialised we set the default one.
 	if cfg.JumpTable == nil {
 		switch {
+		case evm.chainRules.IsCancun:
+			cfg.JumpTable = &cancunInstructionSet
 		case evm.chainRules.IsEIP1559:
 			cfg.JumpTable = &eip1559InstructionSet
 		case evm.chainRules.IsShanghai:
@@ -28,6 +28,14 @@ func memoryReturnDataCopy(stack *Stack) (uint64, bool) {
 	return calcMemSize64(stack.Back(0), stack.Back(2))
 }
 
+func memoryMcopy(stack *Stack) (uint64, bool) {
+	mStart := stack.Back(0) // stack[0]: dest
+	if stack.Back(1).Gt(mStart) {
+		mStart = stack.Back(1) // stack[1]: source
+	}
+	return calcMemSize64(mStart, stack.Back(2)) // stack[2]: length
+}
+
 func memoryCodeCopy(stack *Stack) (uint64, bool) {
 	return calcMemSize64(stack.Back(0), stack.Back(2))
 }
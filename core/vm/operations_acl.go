@@ -200,22 +200,45 @@ var (
 	gasCallCodeEIP2929     = makeCallVariantGasCallEIP2929(gasCallCode)
 )
 
-func gasSelfdestructEIP2929(evm *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize uint64) (uint64, error) {
-	var (
-		gas     uint64
-		address = common.Address(stack.peek().Bytes20())
-	)
-	if !evm.StateDB.AddressInAccessList(address) {
-		// If the caller cannot afford the cost, this change will be rolled back
-		evm.StateDB.AddAddressToAccessList(address)
-		gas = ColdAccountAccessCostEIP2929
-	}
-	// if empty and transfers value
-	if evm.StateDB.Empty(address) && evm.StateDB.GetBalance(contract.Address()).Sign() != 0 {
-		gas += params.CreateBySelfdestructGas
-	}
-	if !evm.StateDB.HasSuicided(contract.Address()) {
-		evm.StateDB.AddRefund(params.SelfdestructRefundGas)
+var (
+	// gasSelfdestructEIP2929 implements dynamic gas for SELFDESTRUCT with the
+	// refund of EIP-2929.
+	gasSelfdestructEIP2929 = makeSelfdestructGasFn(func(evm *EVM, addr common.Address) bool {
+		return !evm.StateDB.HasSuicided(addr)
+	})
+	// gasSelfdestructEIP6780 implements dynamic gas for the EIP-6780
+	// SELFDESTRUCT. XDPoSChain doesn't enable EIP-3529, so this deviates from
+	// Ethereum by keeping the EIP-2929 gas and refund, but the refund is only
+	// granted when the contract is actually deleted: it was deployed in the
+	// same transaction and hasn't selfdestructed yet. An existing contract
+	// only sends its balance and could otherwise collect the refund on every
+	// call.
+	gasSelfdestructEIP6780 = makeSelfdestructGasFn(func(evm *EVM, addr common.Address) bool {
+		return evm.StateDB.IsNewContract(addr) && !evm.StateDB.HasSuicided(addr)
+	})
+)
+
+// makeSelfdestructGasFn can create the selfdestruct dynamic gas function for
+// EIP-2929 and EIP-6780, refunding the gas whenever refundable reports true.
+func makeSelfdestructGasFn(refundable func(evm *EVM, addr common.Address) bool) gasFunc {
+	gasFunc := func(evm *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize uint64) (uint64, error) {
+		var (
+			gas     uint64
+			address = common.Address(stack.peek().Bytes20())
+		)
+		if !evm.StateDB.AddressInAccessList(address) {
+			// If the caller cannot afford the cost, this change will be rolled back
+			evm.StateDB.AddAddressToAccessList(address)
+			gas = ColdAccountAccessCostEIP2929
+		}
+		// if empty and transfers value
+		if evm.StateDB.Empty(address) && evm.StateDB.GetBalance(contract.Address()).Sign() != 0 {
+			gas += params.CreateBySelfdestructGas
+		}
+		if refundable(evm, contract.Address()) {
+			evm.StateDB.AddRefund(params.SelfdestructRefundGas)
+		}
+		return gas, nil
 	}
-	return gas, nil
+	return gasFunc
 }
@@ -340,6 +340,35 @@ func (b *EthApiBackend) SubscribeNewTxsEvent(ch chan<- core.NewTxsEvent) event.S
 	return b.eth.TxPool().SubscribeNewTxsEvent(ch)
 }
 
+// GetPoolTransactionHistory returns the lifecycle events of a transaction from
+// whichever of the transaction, order or lending pools has seen it.
+func (b *EthApiBackend) GetPoolTransactionHistory(hash common.Hash) []core.TxLifecycleEvent {
+	if events := b.eth.txPool.History(hash); events != nil {
+		return events
+	}
+	if events := b.eth.orderPool.History(hash); events != nil {
+		return events
+	}
+	return b.eth.lendingPool.History(hash)
+}
+
+// SubscribeDroppedTxEvent subscribes to the transactions dropped by any of the
+// transaction, order or lending pools.
+func (b *EthApiBackend) SubscribeDroppedTxEvent(ch chan<- core.DroppedTxEvent) event.Subscription {
+	subs := []event.Subscription{
+		b.eth.txPool.SubscribeDroppedTxEvent(ch),
+		b.eth.orderPool.SubscribeDroppedTxEvent(ch),
+		b.eth.lendingPool.SubscribeDroppedTxEvent(ch),
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		<-quit
+		for _, sub := range subs {
+			sub.Unsubscribe()
+		}
+		return nil
+	})
+}
+
 func (b *EthApiBackend) Downloader() *downloader.Downloader {
 	return b.eth.Downloader()
 }
@@ -218,6 +218,11 @@ func New(ctx *node.ServiceContext, config *ethconfig.Config, XDCXServ *XDCx.XDCX
 	}
 	eth.miner = miner.New(eth, eth.chainConfig, eth.EventMux(), eth.engine, ctx.GetConfig().AnnounceTxs)
 	eth.miner.SetExtra(makeExtraData(config.ExtraData))
+	builder, err := miner.NewBlockBuilder(config.MinerOrdering, config.MinerPrioritySenders)
+	if err != nil {
+		return nil, err
+	}
+	eth.miner.SetBlockBuilder(builder)
 
 	if eth.chainConfig.XDPoS != nil {
 		eth.ApiBackend = &EthApiBackend{eth, nil, eth.engine.(*XDPoS.XDPoS)}
@@ -496,6 +501,7 @@ func (s *Ethereum) StartStaking(local bool) error {
 			return fmt.Errorf("signer missing: %v", err)
 		}
 		XDPoS.Authorize(eb, wallet.SignHash)
+		s.protocolManager.signerEntry.authorize(eb, wallet.SignHash)
 	}
 	if local {
 		// If local (CPU) mining is started, we can disable the transaction rejection
@@ -553,6 +559,10 @@ func (s *Ethereum) Start(srvr *p2p.Server) error {
 	}
 	// Start the networking layer and the light server if requested
 	s.protocolManager.Start(maxPeers)
+	s.protocolManager.signerEntry.setNode(srvr.Self().ID)
+	if s.protocolManager.masternodes != nil && len(srvr.DiscoveryDNS) > 0 {
+		s.protocolManager.masternodes.start(srvr, newDNSRegistry(srvr.DiscoveryDNS), srvr.Self().ID, srvr.StaticNodes, srvr.TrustedNodes)
+	}
 	if s.lesServer != nil {
 		s.lesServer.Start(srvr)
 	}
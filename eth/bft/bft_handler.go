@@ -1,6 +1,8 @@
 package bft
 
 import (
+	"github.com/XinFinOrg/XDPoSChain/common"
+	"github.com/XinFinOrg/XDPoSChain/common/mclock"
 	"github.com/XinFinOrg/XDPoSChain/consensus"
 	"github.com/XinFinOrg/XDPoSChain/consensus/XDPoS"
 	"github.com/XinFinOrg/XDPoSChain/consensus/XDPoS/utils"
@@ -11,10 +13,11 @@ import (
 
 const maxBlockDist = 7 // Maximum allowed backward distance from the chain head, 7 is just a magic number indicate very close block
 
-//Define Boradcast Group functions
-type broadcastVoteFn func(*types.Vote)
-type broadcastTimeoutFn func(*types.Timeout)
-type broadcastSyncInfoFn func(*types.SyncInfo)
+//Define Boradcast Group functions, relayed reports whether the message was
+//received from a peer rather than created locally
+type broadcastVoteFn func(vote *types.Vote, relayed bool)
+type broadcastTimeoutFn func(timeout *types.Timeout, relayed bool)
+type broadcastSyncInfoFn func(syncInfo *types.SyncInfo, relayed bool)
 
 // chainHeightFn is a callback type to retrieve the current chain height.
 type chainHeightFn func() uint64
@@ -23,11 +26,13 @@ type Bfter struct {
 	epoch uint64
 
 	blockChainReader consensus.ChainReader
-	broadcastCh      chan interface{}
+	broadcastCh      chan interface{} // Messages created locally by the engine
+	relayCh          chan interface{} // Messages received from peers to relay
 	quit             chan struct{}
 	consensus        ConsensusFns
 	broadcast        BroadcastFns
 	chainHeight      chainHeightFn // Retrieves the current chain's height
+	relay            *Relay        // Deduplicates and rate limits the messages from peers
 }
 
 type ConsensusFns struct {
@@ -39,6 +44,8 @@ type ConsensusFns struct {
 
 	verifySyncInfo  func(consensus.ChainReader, *types.SyncInfo) (bool, error)
 	syncInfoHandler func(consensus.ChainReader, *types.SyncInfo) error
+
+	currentRound func() types.Round
 }
 
 type BroadcastFns struct {
@@ -52,9 +59,11 @@ func New(broadcasts BroadcastFns, blockChainReader *core.BlockChain, chainHeight
 		broadcast:        broadcasts,
 		blockChainReader: blockChainReader,
 		chainHeight:      chainHeight,
+		relay:            NewRelay(mclock.System{}),
 
 		quit:        make(chan struct{}),
 		broadcastCh: make(chan interface{}),
+		relayCh:     make(chan interface{}),
 	}
 }
 
@@ -74,7 +83,34 @@ func (b *Bfter) SetConsensusFuns(engine consensus.Engine) {
 		voteHandler:     e.EngineV2.VoteHandler,
 		timeoutHandler:  e.EngineV2.TimeoutHandler,
 		syncInfoHandler: e.EngineV2.SyncInfoHandler,
+
+		currentRound: e.EngineV2.GetCurrentRound,
+	}
+}
+
+// Admit reports whether a consensus message with the given hash received from
+// the peer should be processed, i.e. it wasn't seen before and the peer is
+// within its message budget.
+func (b *Bfter) Admit(peer string, hash common.Hash) bool {
+	return b.relay.Admit(peer, hash)
+}
+
+// MarkSeen marks a consensus message as seen, so that it isn't processed again
+// when peers echo it.
+func (b *Bfter) MarkSeen(hash common.Hash) {
+	b.relay.MarkSeen(hash)
+}
+
+// relayable reports whether messages of the given round are worth relaying to
+// the peers, i.e. the round is the current or the next one. As the epochs
+// consist of whole rounds, this also limits relaying to the current or next
+// epoch.
+func (b *Bfter) relayable(round types.Round) bool {
+	if b.consensus.currentRound == nil {
+		return true
 	}
+	current := b.consensus.currentRound()
+	return round >= current && round <= current+1
 }
 
 func (b *Bfter) Vote(peer string, vote *types.Vote) error {
@@ -93,7 +129,9 @@ func (b *Bfter) Vote(peer string, vote *types.Vote) error {
 		return err
 	}
 
-	b.broadcastCh <- vote
+	if b.relayable(vote.ProposedBlockInfo.Round) {
+		b.relayCh <- vote
+	}
 
 	if verified {
 		err = b.consensus.voteHandler(b.blockChainReader, vote)
@@ -126,7 +164,9 @@ func (b *Bfter) Timeout(peer string, timeout *types.Timeout) error {
 		return err
 	}
 
-	b.broadcastCh <- timeout
+	if b.relayable(timeout.Round) {
+		b.relayCh <- timeout
+	}
 	if verified {
 		err = b.consensus.timeoutHandler(b.blockChainReader, timeout)
 		if err != nil {
@@ -156,7 +196,14 @@ func (b *Bfter) SyncInfo(peer string, syncInfo *types.SyncInfo) error {
 		return err
 	}
 
-	b.broadcastCh <- syncInfo
+	// A sync info moves the receivers to the round after its certificates
+	round := syncInfo.HighestQuorumCert.ProposedBlockInfo.Round
+	if tc := syncInfo.HighestTimeoutCert; tc != nil && tc.Round > round {
+		round = tc.Round
+	}
+	if b.relayable(round + 1) {
+		b.relayCh <- syncInfo
+	}
 	// Process only if verified and qualified
 	if verified {
 		err = b.consensus.syncInfoHandler(b.blockChainReader, syncInfo)
@@ -183,16 +230,28 @@ func (b *Bfter) loop() {
 			log.Warn("BFT Loop Close")
 			return
 		case obj := <-b.broadcastCh:
-			switch v := obj.(type) {
-			case *types.Vote:
-				go b.broadcast.Vote(v)
-			case *types.Timeout:
-				go b.broadcast.Timeout(v)
-			case *types.SyncInfo:
-				go b.broadcast.SyncInfo(v)
-			default:
-				log.Error("Unknown message type received", "value", v)
-			}
+			// Messages created locally are marked too, so that echoes of
+			// them aren't processed.
+			b.dispatch(obj, false)
+		case obj := <-b.relayCh:
+			b.dispatch(obj, true)
 		}
 	}
 }
+
+// dispatch marks the message as seen and broadcasts it to the peers.
+func (b *Bfter) dispatch(obj interface{}, relayed bool) {
+	switch v := obj.(type) {
+	case *types.Vote:
+		b.relay.MarkSeen(v.Hash())
+		go b.broadcast.Vote(v, relayed)
+	case *types.Timeout:
+		b.relay.MarkSeen(v.Hash())
+		go b.broadcast.Timeout(v, relayed)
+	case *types.SyncInfo:
+		b.relay.MarkSeen(v.Hash())
+		go b.broadcast.SyncInfo(v, relayed)
+	default:
+		log.Error("Unknown message type received", "value", v)
+	}
+}
@@ -75,7 +75,7 @@ func TestSequentialVotes(t *testing.T) {
 		return nil
 	}
 
-	tester.bfter.broadcast.Vote = func(*types.Vote) {
+	tester.bfter.broadcast.Vote = func(*types.Vote, bool) {
 		atomic.AddUint32(&broadcastCounter, 1)
 	}
 
@@ -109,7 +109,7 @@ func TestNotBoardcastInvalidVote(t *testing.T) {
 		atomic.AddUint32(&handlerCounter, 1)
 		return nil
 	}
-	tester.bfter.broadcast.Vote = func(*types.Vote) {
+	tester.bfter.broadcast.Vote = func(*types.Vote, bool) {
 		atomic.AddUint32(&broadcastCounter, 1)
 	}
 
@@ -122,6 +122,38 @@ func TestNotBoardcastInvalidVote(t *testing.T) {
 	}
 }
 
+// Test that votes are only relayed for the current and next rounds
+func TestNotBoardcastVotesOutsideRelayWindow(t *testing.T) {
+	tester := newTester()
+	handlerCounter := uint32(0)
+	broadcastCounter := uint32(0)
+
+	tester.bfter.consensus.currentRound = func() types.Round {
+		return 10
+	}
+	tester.bfter.consensus.verifyVote = func(chain consensus.ChainReader, vote *types.Vote) (bool, error) {
+		return true, nil
+	}
+	tester.bfter.consensus.voteHandler = func(chain consensus.ChainReader, vote *types.Vote) error {
+		atomic.AddUint32(&handlerCounter, 1)
+		return nil
+	}
+	tester.bfter.broadcast.Vote = func(*types.Vote, bool) {
+		atomic.AddUint32(&broadcastCounter, 1)
+	}
+
+	votes := makeVotes(4)
+	for i, round := range []types.Round{9, 10, 11, 12} {
+		votes[i].ProposedBlockInfo.Round = round
+		tester.bfter.Vote(peerID, &votes[i])
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	if int(handlerCounter) != 4 || int(broadcastCounter) != 2 {
+		t.Fatalf("count mismatch: have %v on handler, %v on broadcast, want 4 and 2", handlerCounter, broadcastCounter)
+	}
+}
+
 func TestBoardcastButNotProcessDisqualifiedVotes(t *testing.T) {
 	tester := newTester()
 	handlerCounter := uint32(0)
@@ -136,7 +168,7 @@ func TestBoardcastButNotProcessDisqualifiedVotes(t *testing.T) {
 		atomic.AddUint32(&handlerCounter, 1)
 		return nil
 	}
-	tester.bfter.broadcast.Vote = func(*types.Vote) {
+	tester.bfter.broadcast.Vote = func(*types.Vote, bool) {
 		atomic.AddUint32(&broadcastCounter, 1)
 	}
 
@@ -163,7 +195,7 @@ func TestBoardcastButNotProcessDisqualifiedTimeout(t *testing.T) {
 		atomic.AddUint32(&handlerCounter, 1)
 		return nil
 	}
-	tester.bfter.broadcast.Timeout = func(*types.Timeout) {
+	tester.bfter.broadcast.Timeout = func(*types.Timeout, bool) {
 		atomic.AddUint32(&broadcastCounter, 1)
 	}
 
@@ -190,7 +222,7 @@ func TestBoardcastButNotProcessDisqualifiedSyncInfo(t *testing.T) {
 		atomic.AddUint32(&handlerCounter, 1)
 		return nil
 	}
-	tester.bfter.broadcast.SyncInfo = func(*types.SyncInfo) {
+	tester.bfter.broadcast.SyncInfo = func(*types.SyncInfo, bool) {
 		atomic.AddUint32(&broadcastCounter, 1)
 	}
 
@@ -220,7 +252,7 @@ func TestTimeoutHandler(t *testing.T) {
 		return nil
 	}
 
-	tester.bfter.broadcast.Timeout = func(*types.Timeout) {
+	tester.bfter.broadcast.Timeout = func(*types.Timeout, bool) {
 		atomic.AddUint32(&broadcastCounter, 1)
 	}
 
@@ -253,7 +285,7 @@ func TestTimeoutHandlerRoundNotEqual(t *testing.T) {
 		}
 	}
 
-	tester.bfter.broadcast.Timeout = func(*types.Timeout) {}
+	tester.bfter.broadcast.Timeout = func(*types.Timeout, bool) {}
 
 	timeoutMsg := &types.Timeout{}
 
@@ -277,7 +309,7 @@ func TestSyncInfoHandler(t *testing.T) {
 		atomic.AddUint32(&handlerCounter, 1)
 		return nil
 	}
-	tester.bfter.broadcast.SyncInfo = func(*types.SyncInfo) {
+	tester.bfter.broadcast.SyncInfo = func(*types.SyncInfo, bool) {
 		atomic.AddUint32(&broadcastCounter, 1)
 	}
 
@@ -308,7 +340,7 @@ func TestTooFarVotes(t *testing.T) {
 		return nil
 	}
 
-	tester.bfter.broadcast.Vote = func(*types.Vote) {
+	tester.bfter.broadcast.Vote = func(*types.Vote, bool) {
 		atomic.AddUint32(&broadcastCounter, 1)
 	}
 
@@ -345,7 +377,7 @@ func TestTooFarTimeout(t *testing.T) {
 		return nil
 	}
 
-	tester.bfter.broadcast.Timeout = func(*types.Timeout) {
+	tester.bfter.broadcast.Timeout = func(*types.Timeout, bool) {
 		atomic.AddUint32(&broadcastCounter, 1)
 	}
 
@@ -381,7 +413,7 @@ func TestTooFarSyncInfo(t *testing.T) {
 		atomic.AddUint32(&handlerCounter, 1)
 		return nil
 	}
-	tester.bfter.broadcast.SyncInfo = func(*types.SyncInfo) {
+	tester.bfter.broadcast.SyncInfo = func(*types.SyncInfo, bool) {
 		atomic.AddUint32(&broadcastCounter, 1)
 	}
 
@@ -393,3 +425,31 @@ func TestTooFarSyncInfo(t *testing.T) {
 		t.Fatalf("count mismatch: have %v on verify, have %v on handler, %v on broadcast, want %v", verifyCounter, handlerCounter, broadcastCounter, targetSyncInfo)
 	}
 }
+
+// Tests that the broadcasts report whether the messages were created locally
+// or relayed from peers.
+func TestBroadcastRelayedFlag(t *testing.T) {
+	tester := newTester()
+	tester.bfter.consensus.verifyVote = func(chain consensus.ChainReader, vote *types.Vote) (bool, error) {
+		return true, nil
+	}
+	tester.bfter.consensus.voteHandler = func(chain consensus.ChainReader, vote *types.Vote) error {
+		return nil
+	}
+	relayed := make(chan bool, 2)
+	tester.bfter.broadcast.Vote = func(vote *types.Vote, r bool) {
+		relayed <- r
+	}
+	votes := makeVotes(2)
+
+	tester.bfter.broadcastCh <- &votes[0]
+	if r := <-relayed; r {
+		t.Error("local vote broadcast as relayed")
+	}
+	if err := tester.bfter.Vote(peerID, &votes[1]); err != nil {
+		t.Fatal(err)
+	}
+	if r := <-relayed; !r {
+		t.Error("vote of a peer broadcast as local")
+	}
+}
@@ -0,0 +1,99 @@
+package bft
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/XinFinOrg/XDPoSChain/common"
+	"github.com/XinFinOrg/XDPoSChain/common/mclock"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+const (
+	seenMessages = 16384 // Number of consensus message hashes remembered across all peers
+	limitedPeers = 1024  // Number of peers whose message budgets are tracked
+
+	peerMessageRate  = 200 // Consensus messages per second processed from a single peer
+	peerMessageBurst = 800 // Consensus messages processed at once from a single peer
+)
+
+// Relay decides which consensus messages received from the peers are
+// processed. A message is processed only once, no matter how many peers
+// deliver it, and every peer has a budget of messages it may deliver.
+type Relay struct {
+	clock   mclock.Clock
+	seen    *lru.Cache // Hashes of the messages processed so far
+	lock    sync.Mutex // Protects the creation of budgets
+	budgets *lru.Cache // Peer id -> *messageBudget
+}
+
+// messageBudget is the token bucket of the messages a peer may still deliver.
+type messageBudget struct {
+	lock   sync.Mutex
+	tokens float64
+	last   mclock.AbsTime
+}
+
+// NewRelay creates a relay measuring the peer budgets with the given clock.
+func NewRelay(clock mclock.Clock) *Relay {
+	seen, _ := lru.New(seenMessages)
+	budgets, _ := lru.New(limitedPeers)
+	return &Relay{clock: clock, seen: seen, budgets: budgets}
+}
+
+// Admit reports whether the message with the given hash, delivered by the
+// peer, should be processed. Messages seen before are rejected, just like the
+// ones over the budget of the peer. The latter aren't marked as seen, so they
+// are still accepted from other peers.
+func (r *Relay) Admit(peer string, hash common.Hash) bool {
+	if r.seen.Contains(hash) {
+		return false
+	}
+	if !r.budget(peer).take(r.clock.Now()) {
+		return false
+	}
+	seen, _ := r.seen.ContainsOrAdd(hash, struct{}{})
+	return !seen
+}
+
+// MarkSeen marks a message as seen without charging any peer, e.g. for the
+// messages created locally.
+func (r *Relay) MarkSeen(hash common.Hash) {
+	r.seen.Add(hash, struct{}{})
+}
+
+// budget returns the message budget of a peer, creating a full one for new
+// peers.
+func (r *Relay) budget(peer string) *messageBudget {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if budget, ok := r.budgets.Get(peer); ok {
+		return budget.(*messageBudget)
+	}
+	budget := &messageBudget{tokens: peerMessageBurst, last: r.clock.Now()}
+	r.budgets.Add(peer, budget)
+	return budget
+}
+
+// take refills the budget for the time passed and takes a message from it.
+func (b *messageBudget) take(now mclock.AbsTime) bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	elapsed := float64(now-b.last) / float64(time.Second)
+	b.tokens = math.Min(peerMessageBurst, b.tokens+elapsed*peerMessageRate)
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// Fanout returns the number of peers a relayed consensus message is sent to,
+// out of n peers not having it yet.
+func Fanout(n int) int {
+	return int(math.Sqrt(float64(n)))
+}
@@ -0,0 +1,269 @@
+package bft
+
+import (
+	"fmt"
+	"math/big"
+	"math/rand"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/XinFinOrg/XDPoSChain/common"
+	"github.com/XinFinOrg/XDPoSChain/common/mclock"
+	"github.com/XinFinOrg/XDPoSChain/node"
+	"github.com/XinFinOrg/XDPoSChain/p2p"
+	"github.com/XinFinOrg/XDPoSChain/p2p/discover"
+	"github.com/XinFinOrg/XDPoSChain/p2p/simulations"
+	"github.com/XinFinOrg/XDPoSChain/p2p/simulations/adapters"
+	"github.com/XinFinOrg/XDPoSChain/rpc"
+)
+
+func TestRelayAdmit(t *testing.T) {
+	clock := new(mclock.Simulated)
+	relay := NewRelay(clock)
+
+	// Messages are admitted once, whichever peer delivers them.
+	hash := common.Hash{0x1}
+	if !relay.Admit("a", hash) {
+		t.Fatal("new message not admitted")
+	}
+	if relay.Admit("a", hash) || relay.Admit("b", hash) {
+		t.Fatal("seen message admitted again")
+	}
+	relay.MarkSeen(common.Hash{0x2})
+	if relay.Admit("a", common.Hash{0x2}) {
+		t.Fatal("local message admitted")
+	}
+
+	// Peers over their budget are rejected, without hiding the messages from
+	// the other peers.
+	for i := 1; i < peerMessageBurst; i++ {
+		if !relay.Admit("a", common.BigToHash(big.NewInt(int64(i)))) {
+			t.Fatalf("message %d within budget rejected", i)
+		}
+	}
+	over := common.Hash{0x3}
+	if relay.Admit("a", over) {
+		t.Fatal("message over budget admitted")
+	}
+	if !relay.Admit("b", over) {
+		t.Fatal("message rejected from one peer not admitted from another")
+	}
+	// The budget refills over time.
+	clock.Run(time.Second / peerMessageRate)
+	if !relay.Admit("a", common.Hash{0x4}) {
+		t.Fatal("message rejected after the budget refilled")
+	}
+}
+
+func TestFanout(t *testing.T) {
+	for n, want := range map[int]int{0: 0, 1: 1, 3: 1, 4: 2, 15: 3, 100: 10} {
+		if have := Fanout(n); have != want {
+			t.Errorf("fanout of %d peers: have %d, want %d", n, have, want)
+		}
+	}
+}
+
+// gossipService is a simulation node relaying consensus message hashes. Nodes
+// pass on the messages they admit to a number of their other peers.
+type gossipService struct {
+	server *p2p.Server
+	relay  *Relay
+	fanout func(int) int
+	sent   *int64 // Messages sent by all the nodes of the network
+
+	lock     sync.Mutex
+	peers    map[discover.NodeID]p2p.MsgReadWriter
+	admitted int
+}
+
+func (s *gossipService) Protocols() []p2p.Protocol {
+	return []p2p.Protocol{{Name: "gossip", Version: 1, Length: 1, Run: s.run}}
+}
+
+func (s *gossipService) APIs() []rpc.API { return nil }
+func (s *gossipService) Stop() error     { return nil }
+func (s *gossipService) SaveData()       {}
+
+func (s *gossipService) Start(server *p2p.Server) error {
+	s.server = server
+	return nil
+}
+
+func (s *gossipService) run(p *p2p.Peer, rw p2p.MsgReadWriter) error {
+	s.lock.Lock()
+	s.peers[p.ID()] = rw
+	s.lock.Unlock()
+	defer func() {
+		s.lock.Lock()
+		delete(s.peers, p.ID())
+		s.lock.Unlock()
+	}()
+
+	for {
+		msg, err := rw.ReadMsg()
+		if err != nil {
+			return err
+		}
+		var hash common.Hash
+		if err := msg.Decode(&hash); err != nil {
+			return err
+		}
+		s.receive(p.ID(), hash)
+	}
+}
+
+// receive processes a message delivered by a peer, relaying it if admitted.
+func (s *gossipService) receive(from discover.NodeID, hash common.Hash) {
+	if !s.relay.Admit(from.String(), hash) {
+		return
+	}
+	s.lock.Lock()
+	s.admitted++
+	s.lock.Unlock()
+	s.send(hash, &from)
+}
+
+// send passes a message on to the peers. Messages created locally (from is
+// nil) go to all peers, relayed ones to the fan-out of the other peers.
+func (s *gossipService) send(hash common.Hash, from *discover.NodeID) {
+	s.lock.Lock()
+	var targets []p2p.MsgReadWriter
+	for id, rw := range s.peers {
+		if from == nil || id != *from {
+			targets = append(targets, rw)
+		}
+	}
+	s.lock.Unlock()
+
+	if from != nil {
+		rand.Shuffle(len(targets), func(i, j int) { targets[i], targets[j] = targets[j], targets[i] })
+		targets = targets[:s.fanout(len(targets))]
+	}
+	atomic.AddInt64(s.sent, int64(len(targets)))
+	for _, rw := range targets {
+		// Sending blocks until the peer reads the message, so it mustn't
+		// hold up the read loop of the sender.
+		go p2p.Send(rw, 0, hash)
+	}
+}
+
+func (s *gossipService) counts() (peers, admitted int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return len(s.peers), s.admitted
+}
+
+// TestRelayAmplification measures the messages sent across a simulated network
+// of masternodes, connected to each other through their reserved slots, when
+// relaying to all peers and to the fan-out of them.
+func TestRelayAmplification(t *testing.T) {
+	const (
+		nodes    = 16
+		messages = 10
+	)
+	flood, err := simulateGossip(nodes, messages, func(n int) int { return n })
+	if err != nil {
+		t.Fatal(err)
+	}
+	relay, err := simulateGossip(nodes, messages, Fanout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The messages of every node are sent to all of its peers, which relay
+	// them once each.
+	created := int64(nodes * messages)
+	if want := created * ((nodes - 1) + (nodes-1)*(nodes-2)); flood != want {
+		t.Errorf("flooding sent %d messages, want %d", flood, want)
+	}
+	if want := created * ((nodes - 1) + (nodes-1)*int64(Fanout(nodes-2))); relay != want {
+		t.Errorf("relaying sent %d messages, want %d", relay, want)
+	}
+	deliveries := float64(created * (nodes - 1))
+	t.Logf("messages sent per delivery over %d nodes: flooding %.1f, relaying %.1f", nodes, float64(flood)/deliveries, float64(relay)/deliveries)
+}
+
+// simulateGossip runs a network of fully connected gossip nodes, each of them
+// creating a number of messages, and returns the number of messages sent until
+// all nodes received all messages.
+func simulateGossip(nodes, messages int, fanout func(int) int) (int64, error) {
+	var (
+		sent     int64
+		lock     sync.Mutex
+		services = make(map[discover.NodeID]*gossipService)
+	)
+	adapter := adapters.NewSimAdapter(adapters.Services{
+		"gossip": func(ctx *adapters.ServiceContext) (node.Service, error) {
+			s := &gossipService{
+				relay:  NewRelay(mclock.System{}),
+				fanout: fanout,
+				sent:   &sent,
+				peers:  make(map[discover.NodeID]p2p.MsgReadWriter),
+			}
+			lock.Lock()
+			services[ctx.Config.ID] = s
+			lock.Unlock()
+			return s, nil
+		},
+	})
+	network := simulations.NewNetwork(adapter, &simulations.NetworkConfig{DefaultService: "gossip"})
+	defer network.Shutdown()
+
+	ids := make([]discover.NodeID, nodes)
+	for i := range ids {
+		n, err := network.NewNode()
+		if err != nil {
+			return 0, fmt.Errorf("can't create node: %v", err)
+		}
+		if err := network.Start(n.ID()); err != nil {
+			return 0, fmt.Errorf("can't start node: %v", err)
+		}
+		ids[i] = n.ID()
+	}
+	for i := range ids {
+		for j := i + 1; j < len(ids); j++ {
+			connect(services[ids[i]].server, services[ids[j]].server)
+		}
+	}
+	wait := func(peers, admitted int) error {
+		deadline := time.Now().Add(10 * time.Second)
+		for _, id := range ids {
+			for {
+				p, a := services[id].counts()
+				if p == peers && a == admitted {
+					break
+				}
+				if time.Now().After(deadline) {
+					return fmt.Errorf("node %s has %d peers and %d messages, want %d and %d", id.TerminalString(), p, a, peers, admitted)
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+		}
+		return nil
+	}
+	if err := wait(nodes-1, 0); err != nil {
+		return 0, err
+	}
+	for i, id := range ids {
+		for j := 0; j < messages; j++ {
+			hash := common.BytesToHash([]byte{byte(i), byte(j)})
+			services[id].relay.MarkSeen(hash)
+			services[id].send(hash, nil)
+		}
+	}
+	// Each node receives the messages of all the others.
+	if err := wait(nodes-1, (nodes-1)*messages); err != nil {
+		return 0, err
+	}
+	return atomic.LoadInt64(&sent), nil
+}
+
+// connect links two nodes by an in-memory pipe, the way the simulation adapter
+// dials them, but without making them static peers the servers keep redialing.
+func connect(one, other *p2p.Server) {
+	fd1, fd2 := net.Pipe()
+	go one.SetupConn(fd1, 0, other.Self())
+	go other.SetupConn(fd2, 0, nil)
+}
@@ -0,0 +1,180 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/XinFinOrg/XDPoSChain/accounts"
+	"github.com/XinFinOrg/XDPoSChain/common"
+	"github.com/XinFinOrg/XDPoSChain/core/forkid"
+	"github.com/XinFinOrg/XDPoSChain/crypto"
+	"github.com/XinFinOrg/XDPoSChain/log"
+	"github.com/XinFinOrg/XDPoSChain/p2p/discover"
+	"github.com/XinFinOrg/XDPoSChain/p2p/enr"
+	"github.com/XinFinOrg/XDPoSChain/rlp"
+)
+
+var errMasternodeSignature = errors.New("masternode entry not signed by its address")
+
+// ENREntry is the ENR entry which advertises the eth protocol on the discovery
+// network.
+type ENREntry struct {
+	ForkID forkid.ID // Fork identifier per EIP-2124
+
+	// Ignore additional fields (for forward compatibility).
+	Rest []rlp.RawValue `rlp:"tail"`
+}
+
+// ENRKey implements enr.Entry.
+func (e ENREntry) ENRKey() string {
+	return "eth"
+}
+
+// chainENREntry is the eth entry of the local node record, whose fork ID
+// follows the head of the chain.
+type chainENREntry struct {
+	chain forkid.Blockchain
+
+	lock sync.Mutex
+	head common.Hash // Head the fork ID was computed at
+	id   forkid.ID
+}
+
+// currentENREntry constructs the eth entry of the local node record.
+func currentENREntry(chain forkid.Blockchain) enr.Entry {
+	return &chainENREntry{chain: chain}
+}
+
+// ENRKey implements enr.Entry.
+func (e *chainENREntry) ENRKey() string {
+	return "eth"
+}
+
+// EncodeRLP implements rlp.Encoder, encoding the entry at the current head.
+func (e *chainENREntry) EncodeRLP(w io.Writer) error {
+	return rlp.Encode(w, &ENREntry{ForkID: e.forkID()})
+}
+
+// forkID returns the fork ID at the current head, recomputing it only when
+// the head changed since the last call.
+func (e *chainENREntry) forkID() forkid.ID {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if head := e.chain.CurrentHeader().Hash(); head != e.head {
+		e.head, e.id = head, forkid.NewID(e.chain)
+	}
+	return e.id
+}
+
+// MasternodeENREntry is the ENR entry with which a masternode proves that the
+// node record belongs to the owner of its coinbase address.
+type MasternodeENREntry struct {
+	Address   common.Address // Coinbase address of the masternode
+	Signature []byte         // Signature of the node ID by the coinbase key
+
+	// Ignore additional fields (for forward compatibility).
+	Rest []rlp.RawValue `rlp:"tail"`
+}
+
+// ENRKey implements enr.Entry.
+func (e MasternodeENREntry) ENRKey() string {
+	return "xdpos"
+}
+
+// Verify checks that the entry was signed by the coinbase key for the node
+// with the given ID.
+func (e *MasternodeENREntry) Verify(id discover.NodeID) error {
+	pubkey, err := crypto.SigToPub(masternodeSigHash(id), e.Signature)
+	if err != nil {
+		return err
+	}
+	if crypto.PubkeyToAddress(*pubkey) != e.Address {
+		return errMasternodeSignature
+	}
+	return nil
+}
+
+// masternodeSigHash returns the hash a masternode signs to claim the node
+// record with the given ID.
+func masternodeSigHash(id discover.NodeID) []byte {
+	return crypto.Keccak256(id[:])
+}
+
+// signerENREntry is the xdpos entry of the local node record. It is announced
+// once the node is staking, i.e. the coinbase account is available to sign it.
+type signerENREntry struct {
+	lock   sync.Mutex
+	id     discover.NodeID
+	signer common.Address
+	signFn func(accounts.Account, []byte) ([]byte, error)
+	entry  *MasternodeENREntry // Signed entry, nil until both node and signer are known
+}
+
+// ENRKey implements enr.Entry.
+func (e *signerENREntry) ENRKey() string {
+	return "xdpos"
+}
+
+// setNode sets the ID of the local node, which the entry is signed for.
+func (e *signerENREntry) setNode(id discover.NodeID) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if e.id != id {
+		e.id, e.entry = id, nil
+	}
+}
+
+// authorize sets the coinbase account signing the entry.
+func (e *signerENREntry) authorize(signer common.Address, signFn func(accounts.Account, []byte) ([]byte, error)) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	e.signer, e.signFn, e.entry = signer, signFn, nil
+}
+
+// EncodeRLP implements rlp.Encoder. Before the entry can be signed it encodes
+// to an empty string, which leaves it out of the local node record.
+func (e *signerENREntry) EncodeRLP(w io.Writer) error {
+	entry := e.signed()
+	if entry == nil {
+		_, err := w.Write(rlp.EmptyString)
+		return err
+	}
+	return rlp.Encode(w, entry)
+}
+
+// signed returns the signed entry, signing it on first use.
+func (e *signerENREntry) signed() *MasternodeENREntry {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if e.entry != nil || e.signFn == nil || e.id == (discover.NodeID{}) {
+		return e.entry
+	}
+	sig, err := e.signFn(accounts.Account{Address: e.signer}, masternodeSigHash(e.id))
+	if err != nil {
+		log.Warn("Failed to sign masternode node record entry", "signer", e.signer, "err", err)
+		return nil
+	}
+	e.entry = &MasternodeENREntry{Address: e.signer, Signature: sig}
+	return e.entry
+}
@@ -121,6 +121,11 @@ type Config struct {
 	ExtraData    []byte         `toml:",omitempty"`
 	GasPrice     *big.Int
 
+	// Transaction ordering policy of the blocks created by this node: "price",
+	// "fifo" or "priority". The priority policy favours MinerPrioritySenders.
+	MinerOrdering        string           `toml:",omitempty"`
+	MinerPrioritySenders []common.Address `toml:",omitempty"`
+
 	// Ethash options
 	Ethash ethash.Config
 
@@ -31,6 +31,8 @@ func (c Config) MarshalTOML() (interface{}, error) {
 		MinerThreads            int            `toml:",omitempty"`
 		ExtraData               []byte         `toml:",omitempty"`
 		GasPrice                *big.Int
+		MinerOrdering           string           `toml:",omitempty"`
+		MinerPrioritySenders    []common.Address `toml:",omitempty"`
 		FilterLogCacheSize      int
 		Ethash                  ethash.Config
 		TxPool                  core.TxPoolConfig
@@ -56,6 +58,8 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.MinerThreads = c.MinerThreads
 	enc.ExtraData = c.ExtraData
 	enc.GasPrice = c.GasPrice
+	enc.MinerOrdering = c.MinerOrdering
+	enc.MinerPrioritySenders = c.MinerPrioritySenders
 	enc.FilterLogCacheSize = c.FilterLogCacheSize
 	enc.Ethash = c.Ethash
 	enc.TxPool = c.TxPool
@@ -85,6 +89,8 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 		MinerThreads            *int            `toml:",omitempty"`
 		ExtraData               []byte          `toml:",omitempty"`
 		GasPrice                *big.Int
+		MinerOrdering           *string          `toml:",omitempty"`
+		MinerPrioritySenders    []common.Address `toml:",omitempty"`
 		FilterLogCacheSize      *int
 		Ethash                  *ethash.Config
 		TxPool                  *core.TxPoolConfig
@@ -143,6 +149,12 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.GasPrice != nil {
 		c.GasPrice = dec.GasPrice
 	}
+	if dec.MinerOrdering != nil {
+		c.MinerOrdering = *dec.MinerOrdering
+	}
+	if dec.MinerPrioritySenders != nil {
+		c.MinerPrioritySenders = dec.MinerPrioritySenders
+	}
 	if dec.FilterLogCacheSize != nil {
 		c.FilterLogCacheSize = *dec.FilterLogCacheSize
 	}
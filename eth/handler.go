@@ -32,6 +32,7 @@ import (
 	"github.com/XinFinOrg/XDPoSChain/consensus/XDPoS"
 	"github.com/XinFinOrg/XDPoSChain/consensus/misc"
 	"github.com/XinFinOrg/XDPoSChain/core"
+	"github.com/XinFinOrg/XDPoSChain/core/forkid"
 	"github.com/XinFinOrg/XDPoSChain/core/types"
 	"github.com/XinFinOrg/XDPoSChain/eth/bft"
 	"github.com/XinFinOrg/XDPoSChain/eth/downloader"
@@ -41,6 +42,7 @@ import (
 	"github.com/XinFinOrg/XDPoSChain/log"
 	"github.com/XinFinOrg/XDPoSChain/p2p"
 	"github.com/XinFinOrg/XDPoSChain/p2p/discover"
+	"github.com/XinFinOrg/XDPoSChain/p2p/enr"
 	"github.com/XinFinOrg/XDPoSChain/params"
 	"github.com/XinFinOrg/XDPoSChain/rlp"
 )
@@ -77,6 +79,7 @@ type ProtocolManager struct {
 	lendingpool lendingPool
 	blockchain  *core.BlockChain
 	chainconfig *params.ChainConfig
+	forkFilter  forkid.Filter // Fork ID filter, constant across the lifetime of the node
 	maxPeers    int
 
 	downloader *downloader.Downloader
@@ -84,6 +87,9 @@ type ProtocolManager struct {
 	peers      *peerSet
 	bft        *bft.Bfter
 
+	masternodes *masternodeManager // Reserved masternode connections, nil if not running XDPoS
+	signerEntry *signerENREntry    // Masternode entry of the local node record
+
 	SubProtocols []p2p.Protocol
 
 	eventMux      *event.TypeMux
@@ -107,11 +113,6 @@ type ProtocolManager struct {
 	knownTxs       *lru.Cache
 	knowOrderTxs   *lru.Cache
 	knowLendingTxs *lru.Cache
-
-	// V2 messages
-	knownVotes     *lru.Cache
-	knownSyncInfos *lru.Cache
-	knownTimeouts  *lru.Cache
 }
 
 // NewProtocolManagerEx add order pool to protocol
@@ -132,10 +133,6 @@ func NewProtocolManager(config *params.ChainConfig, mode downloader.SyncMode, ne
 	knowOrderTxs, _ := lru.New(maxKnownOrderTxs)
 	knowLendingTxs, _ := lru.New(maxKnownLendingTxs)
 
-	knownVotes, _ := lru.New(maxKnownVote)
-	knownSyncInfos, _ := lru.New(maxKnownSyncInfo)
-	knownTimeouts, _ := lru.New(maxKnownTimeout)
-
 	// Create the protocol manager with the base fields
 	manager := &ProtocolManager{
 		networkId:      networkID,
@@ -143,6 +140,7 @@ func NewProtocolManager(config *params.ChainConfig, mode downloader.SyncMode, ne
 		txpool:         txpool,
 		blockchain:     blockchain,
 		chainconfig:    config,
+		forkFilter:     forkid.NewFilter(blockchain),
 		peers:          newPeerSet(),
 		newPeerCh:      make(chan *peer),
 		noMorePeers:    make(chan struct{}),
@@ -151,13 +149,14 @@ func NewProtocolManager(config *params.ChainConfig, mode downloader.SyncMode, ne
 		knownTxs:       knownTxs,
 		knowOrderTxs:   knowOrderTxs,
 		knowLendingTxs: knowLendingTxs,
-		knownVotes:     knownVotes,
-		knownSyncInfos: knownSyncInfos,
-		knownTimeouts:  knownTimeouts,
 		orderpool:      nil,
 		lendingpool:    nil,
 		orderTxSub:     nil,
 		lendingTxSub:   nil,
+		signerEntry:    new(signerENREntry),
+	}
+	if engine, ok := engine.(*XDPoS.XDPoS); ok {
+		manager.masternodes = newMasternodeManager(blockchain, engine)
 	}
 	// Figure out whether to allow fast sync or not
 	if mode == downloader.FastSync && blockchain.CurrentBlock().NumberU64() > 0 {
@@ -169,6 +168,7 @@ func NewProtocolManager(config *params.ChainConfig, mode downloader.SyncMode, ne
 	}
 	// Initiate a sub-protocol for every implemented version we can handle
 	manager.SubProtocols = make([]p2p.Protocol, 0, len(ProtocolVersions))
+	ethEntry := currentENREntry(blockchain)
 	for i, version := range ProtocolVersions {
 		// Skip protocol version if incompatible with the mode of operation
 		if mode == downloader.FastSync && version < eth63 {
@@ -200,6 +200,7 @@ func NewProtocolManager(config *params.ChainConfig, mode downloader.SyncMode, ne
 				}
 				return nil
 			},
+			Attributes: []enr.Entry{ethEntry, manager.signerEntry},
 		})
 	}
 	if len(manager.SubProtocols) == 0 {
@@ -333,6 +334,10 @@ func (pm *ProtocolManager) Stop() {
 	// Quit fetcher, txsyncLoop.
 	close(pm.quitSync)
 
+	if pm.masternodes != nil {
+		pm.masternodes.stop()
+	}
+
 	// Disconnect existing sessions.
 	// This also closes the gate for any new registrations on the peer set.
 	// sessions which are already established but not added to pm.peers yet
@@ -366,7 +371,7 @@ func (pm *ProtocolManager) handle(p *peer) error {
 		number  = head.Number.Uint64()
 		td      = pm.blockchain.GetTd(hash, number)
 	)
-	if err := p.Handshake(pm.networkId, td, hash, genesis.Hash()); err != nil {
+	if err := p.Handshake(pm.networkId, td, hash, genesis.Hash(), forkid.NewID(pm.blockchain), pm.forkFilter); err != nil {
 		p.Log().Debug("Ethereum handshake failed", "err", err)
 		return err
 	}
@@ -859,11 +864,10 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 		}
 		p.MarkVote(vote.Hash())
 
-		exist, _ := pm.knownVotes.ContainsOrAdd(vote.Hash(), true)
-		if !exist {
+		if pm.bft.Admit(p.id, vote.Hash()) {
 			go pm.bft.Vote(p.id, &vote)
 		} else {
-			log.Debug("Discarded vote, known vote", "vote hash", vote.Hash(), "voted block hash", vote.ProposedBlockInfo.Hash.Hex(), "number", vote.ProposedBlockInfo.Number, "round", vote.ProposedBlockInfo.Round)
+			log.Debug("Discarded vote, known vote or peer over budget", "vote hash", vote.Hash(), "voted block hash", vote.ProposedBlockInfo.Hash.Hex(), "number", vote.ProposedBlockInfo.Number, "round", vote.ProposedBlockInfo.Round)
 		}
 
 	case msg.Code == TimeoutMsg:
@@ -877,12 +881,10 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 		}
 		p.MarkTimeout(timeout.Hash())
 
-		exist, _ := pm.knownTimeouts.ContainsOrAdd(timeout.Hash(), true)
-
-		if !exist {
+		if pm.bft.Admit(p.id, timeout.Hash()) {
 			go pm.bft.Timeout(p.id, &timeout)
 		} else {
-			log.Trace("Discarded Timeout, known Timeout", "Signature", timeout.Signature, "hash", timeout.Hash(), "round", timeout.Round)
+			log.Trace("Discarded Timeout, known Timeout or peer over budget", "Signature", timeout.Signature, "hash", timeout.Hash(), "round", timeout.Round)
 		}
 
 	case msg.Code == SyncInfoMsg:
@@ -896,11 +898,10 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 		}
 		p.MarkSyncInfo(syncInfo.Hash())
 
-		exist, _ := pm.knownSyncInfos.ContainsOrAdd(syncInfo.Hash(), true)
-		if !exist {
+		if pm.bft.Admit(p.id, syncInfo.Hash()) {
 			go pm.bft.SyncInfo(p.id, &syncInfo)
 		} else {
-			log.Trace("Discarded SyncInfo, known SyncInfo", "hash", syncInfo.Hash())
+			log.Trace("Discarded SyncInfo, known SyncInfo or peer over budget", "hash", syncInfo.Hash())
 		}
 
 	default:
@@ -960,11 +961,11 @@ func (pm *ProtocolManager) BroadcastTxs(txs types.Transactions) {
 	}
 }
 
-// BroadcastVote will propagate a Vote to all peers which are not known to
-// already have the given vote.
-func (pm *ProtocolManager) BroadcastVote(vote *types.Vote) {
+// BroadcastVote will propagate a Vote to the consensus peers among the ones
+// which are not known to already have the given vote.
+func (pm *ProtocolManager) BroadcastVote(vote *types.Vote, relayed bool) {
 	hash := vote.Hash()
-	peers := pm.peers.PeersWithoutVote(hash)
+	peers := pm.consensusPeers(pm.peers.PeersWithoutVote(hash), relayed)
 	if len(peers) > 0 {
 		for _, peer := range peers {
 			err := peer.SendVote(vote)
@@ -977,11 +978,11 @@ func (pm *ProtocolManager) BroadcastVote(vote *types.Vote) {
 	}
 }
 
-// BroadcastTimeout will propagate a Timeout to all peers which are not known to
-// already have the given timeout.
-func (pm *ProtocolManager) BroadcastTimeout(timeout *types.Timeout) {
+// BroadcastTimeout will propagate a Timeout to the consensus peers among the ones
+// which are not known to already have the given timeout.
+func (pm *ProtocolManager) BroadcastTimeout(timeout *types.Timeout, relayed bool) {
 	hash := timeout.Hash()
-	peers := pm.peers.PeersWithoutTimeout(hash)
+	peers := pm.consensusPeers(pm.peers.PeersWithoutTimeout(hash), relayed)
 	if len(peers) > 0 {
 		for _, peer := range peers {
 			err := peer.SendTimeout(timeout)
@@ -994,11 +995,11 @@ func (pm *ProtocolManager) BroadcastTimeout(timeout *types.Timeout) {
 	}
 }
 
-// BroadcastSyncInfo will propagate a SyncInfo to all peers which are not known to
-// already have the given SyncInfo.
-func (pm *ProtocolManager) BroadcastSyncInfo(syncInfo *types.SyncInfo) {
+// BroadcastSyncInfo will propagate a SyncInfo to the consensus peers among the ones
+// which are not known to already have the given SyncInfo.
+func (pm *ProtocolManager) BroadcastSyncInfo(syncInfo *types.SyncInfo, relayed bool) {
 	hash := syncInfo.Hash()
-	peers := pm.peers.PeersWithoutSyncInfo(hash)
+	peers := pm.consensusPeers(pm.peers.PeersWithoutSyncInfo(hash), relayed)
 	if len(peers) > 0 {
 		for _, peer := range peers {
 			err := peer.SendSyncInfo(syncInfo)
@@ -1012,6 +1013,21 @@ func (pm *ProtocolManager) BroadcastSyncInfo(syncInfo *types.SyncInfo) {
 
 }
 
+// consensusPeers selects the peers a consensus message is sent to, out of the
+// peers not having it yet. Messages created locally are sent to all of them.
+// Relayed messages are sent to the peers holding reserved masternode slots,
+// and to the square root of the others.
+func (pm *ProtocolManager) consensusPeers(peers []*peer, relayed bool) []*peer {
+	var direct int
+	if pm.masternodes != nil {
+		peers, direct = pm.masternodes.masternodesFirst(peers)
+	}
+	if !relayed {
+		return peers
+	}
+	return peers[:direct+bft.Fanout(len(peers)-direct)]
+}
+
 // OrderBroadcastTx will propagate a transaction to all peers which are not known to
 // already have the given transaction.
 func (pm *ProtocolManager) OrderBroadcastTx(hash common.Hash, tx *types.OrderTransaction) {
@@ -0,0 +1,342 @@
+// Copyright (c) 2018 XDPoSChain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/XinFinOrg/XDPoSChain/common"
+	"github.com/XinFinOrg/XDPoSChain/consensus"
+	"github.com/XinFinOrg/XDPoSChain/core"
+	"github.com/XinFinOrg/XDPoSChain/core/types"
+	"github.com/XinFinOrg/XDPoSChain/event"
+	"github.com/XinFinOrg/XDPoSChain/log"
+	"github.com/XinFinOrg/XDPoSChain/p2p/discover"
+	"github.com/XinFinOrg/XDPoSChain/p2p/dnsdisc"
+	"github.com/XinFinOrg/XDPoSChain/p2p/enr"
+)
+
+const (
+	// masternodeResolveInterval is the interval of resolving the published
+	// masternode records again.
+	masternodeResolveInterval = 10 * time.Minute
+
+	// masternodeHeadChanSize is the size of channel listening to ChainHeadEvent.
+	masternodeHeadChanSize = 10
+)
+
+// masternodeRegistry is a source of published masternode node records.
+type masternodeRegistry interface {
+	Records(ctx context.Context) ([]*enr.Record, error)
+}
+
+// dnsRegistry resolves the masternode records from DNS discovery trees.
+type dnsRegistry struct {
+	client *dnsdisc.Client
+	urls   []string
+}
+
+// newDNSRegistry creates a registry resolving the trees at the given URLs.
+func newDNSRegistry(urls []string) *dnsRegistry {
+	return &dnsRegistry{client: dnsdisc.NewClient(dnsdisc.Config{}), urls: urls}
+}
+
+// Records implements masternodeRegistry.
+func (r *dnsRegistry) Records(ctx context.Context) ([]*enr.Record, error) {
+	return r.client.Records(ctx, r.urls...)
+}
+
+// masternodeEngine is the part of the XDPoS engine the masternode sets are
+// learnt from.
+type masternodeEngine interface {
+	GetMasternodes(chain consensus.ChainReader, header *types.Header) []common.Address
+	GetNextEpochMasternodes(chain consensus.ChainReader, header *types.Header) ([]common.Address, error)
+}
+
+// masternodeChain is the part of the blockchain followed by the masternode
+// manager.
+type masternodeChain interface {
+	consensus.ChainReader
+	SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription
+}
+
+// masternodeServer is the part of the p2p server keeping the connections to
+// the masternodes.
+type masternodeServer interface {
+	AddPeer(node *discover.Node)
+	RemovePeer(node *discover.Node)
+	AddTrustedPeer(node *discover.Node)
+	RemoveTrustedPeer(node *discover.Node)
+}
+
+// masternodeManager keeps connections to the masternodes of the current and
+// the next epoch. Once their node records are published, the masternodes get
+// reserved peer slots and are dialed proactively, so that consensus messages
+// can be delivered to them directly.
+type masternodeManager struct {
+	chain  masternodeChain
+	engine masternodeEngine
+
+	server   masternodeServer
+	registry masternodeRegistry
+	self     discover.NodeID
+	static   map[discover.NodeID]bool // Static nodes of the operator, left alone
+	trusted  map[discover.NodeID]bool // Trusted nodes of the operator, left alone
+
+	lock      sync.RWMutex
+	wanted    map[common.Address]bool           // Masternodes of the current and next epoch
+	published map[common.Address]*discover.Node // Verified masternode records by coinbase address
+	reserved  map[common.Address]*discover.Node // Masternodes holding a reserved slot
+	peers     map[discover.NodeID]bool          // IDs of the reserved nodes
+
+	records  chan []*enr.Record
+	quit     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// newMasternodeManager creates a masternode manager following the masternode
+// sets of the given chain.
+func newMasternodeManager(chain masternodeChain, engine masternodeEngine) *masternodeManager {
+	return &masternodeManager{
+		chain:     chain,
+		engine:    engine,
+		wanted:    make(map[common.Address]bool),
+		published: make(map[common.Address]*discover.Node),
+		reserved:  make(map[common.Address]*discover.Node),
+		peers:     make(map[discover.NodeID]bool),
+		static:    make(map[discover.NodeID]bool),
+		trusted:   make(map[discover.NodeID]bool),
+		records:   make(chan []*enr.Record),
+		quit:      make(chan struct{}),
+	}
+}
+
+// start begins reserving slots on the given server for the masternodes whose
+// records are published in the registry. The static and trusted nodes
+// configured by the operator keep their slots regardless of the masternodes.
+func (m *masternodeManager) start(server masternodeServer, registry masternodeRegistry, self discover.NodeID, static, trusted []*discover.Node) {
+	m.server, m.registry, m.self = server, registry, self
+	for _, n := range static {
+		m.static[n.ID] = true
+	}
+	for _, n := range trusted {
+		m.trusted[n.ID] = true
+	}
+
+	m.wg.Add(2)
+	go m.loop()
+	go m.resolveLoop()
+}
+
+// stop terminates the manager. It's safe to call even if it was never started,
+// or more than once.
+func (m *masternodeManager) stop() {
+	m.stopOnce.Do(func() { close(m.quit) })
+	m.wg.Wait()
+}
+
+// loop follows the chain head and the resolved records, updating the reserved
+// masternode connections.
+func (m *masternodeManager) loop() {
+	defer m.wg.Done()
+
+	headCh := make(chan core.ChainHeadEvent, masternodeHeadChanSize)
+	headSub := m.chain.SubscribeChainHeadEvent(headCh)
+	defer headSub.Unsubscribe()
+
+	m.setHead(m.chain.CurrentHeader())
+	for {
+		select {
+		case ev := <-headCh:
+			m.setHead(ev.Block.Header())
+		case records := <-m.records:
+			m.setRecords(records)
+		case <-headSub.Err():
+			return
+		case <-m.quit:
+			return
+		}
+	}
+}
+
+// resolveLoop periodically resolves the published masternode records.
+func (m *masternodeManager) resolveLoop() {
+	defer m.wg.Done()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-m.quit
+		cancel()
+	}()
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-timer.C:
+			records, err := m.registry.Records(ctx)
+			if err != nil {
+				log.Warn("Failed to resolve masternode records", "err", err)
+			} else {
+				select {
+				case m.records <- records:
+				case <-m.quit:
+					return
+				}
+			}
+			timer.Reset(masternodeResolveInterval)
+		case <-m.quit:
+			return
+		}
+	}
+}
+
+// setHead updates the wanted masternodes to the current and next epoch
+// masternodes at the given head.
+func (m *masternodeManager) setHead(header *types.Header) {
+	masternodes := m.engine.GetMasternodes(m.chain, header)
+	next, err := m.engine.GetNextEpochMasternodes(m.chain, header)
+	if err != nil {
+		log.Trace("Next epoch masternodes unavailable", "number", header.Number, "err", err)
+	}
+	wanted := make(map[common.Address]bool, len(masternodes)+len(next))
+	for _, addr := range masternodes {
+		wanted[addr] = true
+	}
+	for _, addr := range next {
+		wanted[addr] = true
+	}
+	m.lock.Lock()
+	m.wanted = wanted
+	m.lock.Unlock()
+
+	m.reserve()
+}
+
+// setRecords replaces the published masternode records with the verified
+// masternode entries of the given records.
+func (m *masternodeManager) setRecords(records []*enr.Record) {
+	published := make(map[common.Address]*discover.Node)
+	for _, r := range records {
+		var entry MasternodeENREntry
+		if err := r.Load(&entry); err != nil {
+			continue
+		}
+		n, err := discover.NodeFromRecord(r)
+		if err != nil || n.ID == m.self {
+			continue
+		}
+		if err := entry.Verify(n.ID); err != nil {
+			log.Debug("Skipping invalid masternode record", "id", n.ID, "address", entry.Address, "err", err)
+			continue
+		}
+		published[entry.Address] = n
+	}
+	log.Debug("Resolved masternode records", "records", len(records), "masternodes", len(published))
+
+	m.lock.Lock()
+	m.published = published
+	m.lock.Unlock()
+
+	m.reserve()
+}
+
+// reserve updates the reserved slots to the published records of the wanted
+// masternodes.
+func (m *masternodeManager) reserve() {
+	var add, drop []*discover.Node
+
+	m.lock.Lock()
+	for addr, old := range m.reserved {
+		if n := m.published[addr]; !m.wanted[addr] || n == nil || !sameNode(n, old) {
+			drop = append(drop, old)
+			delete(m.reserved, addr)
+			delete(m.peers, old.ID)
+		}
+	}
+	for addr := range m.wanted {
+		if n := m.published[addr]; n != nil && m.reserved[addr] == nil {
+			add = append(add, n)
+			m.reserved[addr] = n
+			m.peers[n.ID] = true
+		}
+	}
+	m.lock.Unlock()
+
+	// The server is called without holding the lock, it blocks until the
+	// request is picked up by the server loop. The operator's own static and
+	// trusted nodes are never added or removed by the manager.
+	for _, n := range drop {
+		log.Debug("Releasing masternode peer slot", "id", n.ID)
+		if !m.trusted[n.ID] {
+			m.server.RemoveTrustedPeer(n)
+		}
+		if !m.static[n.ID] {
+			m.server.RemovePeer(n)
+		}
+	}
+	for _, n := range add {
+		log.Debug("Reserving masternode peer slot", "id", n.ID)
+		if !m.trusted[n.ID] {
+			m.server.AddTrustedPeer(n)
+		}
+		if !m.static[n.ID] {
+			m.server.AddPeer(n)
+		}
+	}
+}
+
+// isMasternode reports whether the node with the given ID holds a reserved
+// masternode slot.
+func (m *masternodeManager) isMasternode(id discover.NodeID) bool {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	return m.peers[id]
+}
+
+// masternodesFirst reorders the given peers so that the masternodes come
+// first, and returns the number of masternodes among them.
+func (m *masternodeManager) masternodesFirst(peers []*peer) ([]*peer, int) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	if len(m.peers) == 0 {
+		return peers, 0
+	}
+	sorted := make([]*peer, 0, len(peers))
+	for _, p := range peers {
+		if m.peers[p.ID()] {
+			sorted = append(sorted, p)
+		}
+	}
+	masternodes := len(sorted)
+	for _, p := range peers {
+		if !m.peers[p.ID()] {
+			sorted = append(sorted, p)
+		}
+	}
+	return sorted, masternodes
+}
+
+// sameNode reports whether a and b are the same node at the same endpoint.
+func sameNode(a, b *discover.Node) bool {
+	return a.ID == b.ID && a.IP.Equal(b.IP) && a.TCP == b.TCP
+}
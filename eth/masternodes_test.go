@@ -0,0 +1,223 @@
+// Copyright (c) 2018 XDPoSChain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"net"
+	"testing"
+
+	"github.com/XinFinOrg/XDPoSChain/accounts"
+	"github.com/XinFinOrg/XDPoSChain/common"
+	"github.com/XinFinOrg/XDPoSChain/consensus"
+	"github.com/XinFinOrg/XDPoSChain/core/types"
+	"github.com/XinFinOrg/XDPoSChain/crypto"
+	"github.com/XinFinOrg/XDPoSChain/p2p"
+	"github.com/XinFinOrg/XDPoSChain/p2p/discover"
+	"github.com/XinFinOrg/XDPoSChain/p2p/enr"
+	"github.com/XinFinOrg/XDPoSChain/rlp"
+)
+
+// testMasternode is a masternode with its coinbase and node keys.
+type testMasternode struct {
+	coinbase *ecdsa.PrivateKey
+	nodekey  *ecdsa.PrivateKey
+}
+
+func newTestMasternode() *testMasternode {
+	coinbase, _ := crypto.GenerateKey()
+	nodekey, _ := crypto.GenerateKey()
+	return &testMasternode{coinbase: coinbase, nodekey: nodekey}
+}
+
+func (mn *testMasternode) address() common.Address {
+	return crypto.PubkeyToAddress(mn.coinbase.PublicKey)
+}
+
+func (mn *testMasternode) id() discover.NodeID {
+	return discover.PubkeyID(&mn.nodekey.PublicKey)
+}
+
+func (mn *testMasternode) signFn(account accounts.Account, hash []byte) ([]byte, error) {
+	return crypto.Sign(hash, mn.coinbase)
+}
+
+// record creates the signed node record of the masternode, announcing the
+// masternode entry signed for the node with the given ID.
+func (mn *testMasternode) record(t *testing.T, id discover.NodeID) *enr.Record {
+	entry := new(signerENREntry)
+	entry.setNode(id)
+	entry.authorize(mn.address(), mn.signFn)
+
+	var r enr.Record
+	r.Set(enr.IP4(net.IP{10, 0, 0, 1}))
+	r.Set(enr.TCP(30303))
+	r.Set(entry)
+	if err := r.Sign(mn.nodekey); err != nil {
+		t.Fatal(err)
+	}
+	return &r
+}
+
+func TestMasternodeENREntry(t *testing.T) {
+	mn := newTestMasternode()
+	entry := new(signerENREntry)
+
+	// The entry is left out until both the node and the signer are known.
+	if enc, _ := rlp.EncodeToBytes(entry); string(enc) != string(rlp.EmptyString) {
+		t.Fatalf("unsigned entry encoded to %x", enc)
+	}
+	entry.setNode(mn.id())
+	if enc, _ := rlp.EncodeToBytes(entry); string(enc) != string(rlp.EmptyString) {
+		t.Fatalf("entry without signer encoded to %x", enc)
+	}
+	entry.authorize(mn.address(), mn.signFn)
+	enc, err := rlp.EncodeToBytes(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded MasternodeENREntry
+	if err := rlp.DecodeBytes(enc, &decoded); err != nil {
+		t.Fatalf("can't decode entry: %v", err)
+	}
+	if decoded.Address != mn.address() {
+		t.Errorf("wrong address: got %x, want %x", decoded.Address, mn.address())
+	}
+	if err := decoded.Verify(mn.id()); err != nil {
+		t.Errorf("valid entry not verified: %v", err)
+	}
+	if err := decoded.Verify(newTestMasternode().id()); err == nil {
+		t.Error("entry verified for another node")
+	}
+}
+
+type testMasternodeEngine struct {
+	current, next []common.Address
+}
+
+func (e *testMasternodeEngine) GetMasternodes(chain consensus.ChainReader, header *types.Header) []common.Address {
+	return e.current
+}
+
+func (e *testMasternodeEngine) GetNextEpochMasternodes(chain consensus.ChainReader, header *types.Header) ([]common.Address, error) {
+	return e.next, nil
+}
+
+type testMasternodeServer struct {
+	static, trusted map[discover.NodeID]bool
+}
+
+func (s *testMasternodeServer) AddPeer(n *discover.Node)           { s.static[n.ID] = true }
+func (s *testMasternodeServer) RemovePeer(n *discover.Node)        { delete(s.static, n.ID) }
+func (s *testMasternodeServer) AddTrustedPeer(n *discover.Node)    { s.trusted[n.ID] = true }
+func (s *testMasternodeServer) RemoveTrustedPeer(n *discover.Node) { delete(s.trusted, n.ID) }
+
+func TestMasternodeManager(t *testing.T) {
+	var (
+		current = newTestMasternode()
+		next    = newTestMasternode()
+		other   = newTestMasternode()
+		forged  = newTestMasternode()
+		self    = newTestMasternode()
+	)
+	engine := &testMasternodeEngine{
+		current: []common.Address{current.address(), forged.address(), self.address()},
+		next:    []common.Address{next.address()},
+	}
+	server := &testMasternodeServer{static: make(map[discover.NodeID]bool), trusted: make(map[discover.NodeID]bool)}
+	m := newMasternodeManager(nil, engine)
+	m.server, m.self = server, self.id()
+
+	header := &types.Header{Number: big.NewInt(1)}
+	m.setHead(header)
+	m.setRecords([]*enr.Record{
+		current.record(t, current.id()),
+		next.record(t, next.id()),
+		other.record(t, other.id()),
+		forged.record(t, current.id()), // entry signed for another node
+		self.record(t, self.id()),
+	})
+	check := func(want ...*testMasternode) {
+		t.Helper()
+		if len(server.static) != len(want) || len(server.trusted) != len(want) {
+			t.Fatalf("wrong number of reserved peers: static %d, trusted %d, want %d", len(server.static), len(server.trusted), len(want))
+		}
+		for _, mn := range want {
+			if !server.static[mn.id()] || !server.trusted[mn.id()] || !m.isMasternode(mn.id()) {
+				t.Errorf("masternode %x has no reserved slot", mn.address())
+			}
+		}
+	}
+	check(current, next)
+
+	// Masternodes leaving the next epoch release their slot.
+	engine.next = nil
+	m.setHead(header)
+	check(current)
+	if m.isMasternode(next.id()) {
+		t.Error("released masternode still reported")
+	}
+
+	// Peers of the masternodes are ordered first.
+	peers := []*peer{
+		newPeer(eth63, p2p.NewPeer(other.id(), "other", nil), nil),
+		newPeer(eth63, p2p.NewPeer(current.id(), "current", nil), nil),
+		newPeer(eth63, p2p.NewPeer(next.id(), "next", nil), nil),
+	}
+	sorted, masternodes := m.masternodesFirst(peers)
+	if len(sorted) != len(peers) || masternodes != 1 || sorted[0].ID() != current.id() {
+		t.Errorf("masternode peer not ordered first: %v (%d masternodes)", sorted, masternodes)
+	}
+}
+
+func TestMasternodeManagerOperatorNodes(t *testing.T) {
+	var (
+		static  = newTestMasternode()
+		trusted = newTestMasternode()
+	)
+	engine := &testMasternodeEngine{current: []common.Address{static.address(), trusted.address()}}
+	server := &testMasternodeServer{
+		static:  map[discover.NodeID]bool{static.id(): true},
+		trusted: map[discover.NodeID]bool{trusted.id(): true},
+	}
+	m := newMasternodeManager(nil, engine)
+	m.server = server
+	m.static[static.id()] = true
+	m.trusted[trusted.id()] = true
+
+	header := &types.Header{Number: big.NewInt(1)}
+	m.setHead(header)
+	m.setRecords([]*enr.Record{static.record(t, static.id()), trusted.record(t, trusted.id())})
+	if !server.static[trusted.id()] || !server.trusted[static.id()] {
+		t.Fatal("missing slots of the masternodes not reserved")
+	}
+	// Leaving masternodes only release the slots reserved by the manager.
+	engine.current = nil
+	m.setHead(header)
+	if !server.static[static.id()] || server.trusted[static.id()] {
+		t.Errorf("static node slots mismatch: static %t, trusted %t", server.static[static.id()], server.trusted[static.id()])
+	}
+	if server.static[trusted.id()] || !server.trusted[trusted.id()] {
+		t.Errorf("trusted node slots mismatch: static %t, trusted %t", server.static[trusted.id()], server.trusted[trusted.id()])
+	}
+}
+
+func TestMasternodeManagerStopTwice(t *testing.T) {
+	m := newMasternodeManager(nil, &testMasternodeEngine{})
+	m.stop()
+	m.stop()
+}
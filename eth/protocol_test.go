@@ -17,12 +17,14 @@
 package eth
 
 import (
+	"bytes"
 	"fmt"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/XinFinOrg/XDPoSChain/common"
+	"github.com/XinFinOrg/XDPoSChain/core/forkid"
 	"github.com/XinFinOrg/XDPoSChain/core/types"
 	"github.com/XinFinOrg/XDPoSChain/crypto"
 	"github.com/XinFinOrg/XDPoSChain/eth/downloader"
@@ -38,8 +40,10 @@ func init() {
 var testAccount, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
 
 // Tests that handshake failures are detected and reported correctly.
-func TestStatusMsgErrors62(t *testing.T) { testStatusMsgErrors(t, 62) }
-func TestStatusMsgErrors63(t *testing.T) { testStatusMsgErrors(t, 63) }
+func TestStatusMsgErrors62(t *testing.T)  { testStatusMsgErrors(t, 62) }
+func TestStatusMsgErrors63(t *testing.T)  { testStatusMsgErrors(t, 63) }
+func TestStatusMsgErrors100(t *testing.T) { testStatusMsgErrors(t, xdpos2) }
+func TestStatusMsgErrors101(t *testing.T) { testStatusMsgErrors(t, xdpos2ForkID) }
 
 func testStatusMsgErrors(t *testing.T, protocol int) {
 	pm, _ := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, nil)
@@ -60,18 +64,28 @@ func testStatusMsgErrors(t *testing.T, protocol int) {
 			wantError: errResp(ErrNoStatusMsg, "first msg has code 2 (!= 0)"),
 		},
 		{
-			code: StatusMsg, data: statusData{10, ethconfig.Defaults.NetworkId, td, head.Hash(), genesis.Hash()},
+			code: StatusMsg, data: statusData{10, ethconfig.Defaults.NetworkId, td, head.Hash(), genesis.Hash(), forkid.ID{}},
 			wantError: errResp(ErrProtocolVersionMismatch, "10 (!= %d)", protocol),
 		},
 		{
-			code: StatusMsg, data: statusData{uint32(protocol), 999, td, head.Hash(), genesis.Hash()},
+			code: StatusMsg, data: statusData{uint32(protocol), 999, td, head.Hash(), genesis.Hash(), forkid.ID{}},
 			wantError: errResp(ErrNetworkIdMismatch, "999 (!= 88)"),
 		},
 		{
-			code: StatusMsg, data: statusData{uint32(protocol), ethconfig.Defaults.NetworkId, td, head.Hash(), common.Hash{3}},
+			code: StatusMsg, data: statusData{uint32(protocol), ethconfig.Defaults.NetworkId, td, head.Hash(), common.Hash{3}, forkid.ID{}},
 			wantError: errResp(ErrGenesisBlockMismatch, "0300000000000000 (!= %x)", genesis.Hash().Bytes()[:8]),
 		},
 	}
+	if protocol >= xdpos2ForkID {
+		tests = append(tests, struct {
+			code      uint64
+			data      interface{}
+			wantError error
+		}{
+			code: StatusMsg, data: statusData{uint32(protocol), ethconfig.Defaults.NetworkId, td, head.Hash(), genesis.Hash(), forkid.ID{Hash: [4]byte{0x00, 0x01, 0x02, 0x03}}},
+			wantError: errResp(ErrForkIDRejected, forkid.ErrLocalIncompatibleOrStale.Error()),
+		})
+	}
 
 	for i, test := range tests {
 		p, errc := newTestPeer("peer", protocol, pm, false)
@@ -94,8 +108,9 @@ func testStatusMsgErrors(t *testing.T, protocol int) {
 }
 
 // This test checks that received transactions are added to the local pool.
-func TestRecvTransactions62(t *testing.T) { testRecvTransactions(t, 62) }
-func TestRecvTransactions63(t *testing.T) { testRecvTransactions(t, 63) }
+func TestRecvTransactions62(t *testing.T)  { testRecvTransactions(t, 62) }
+func TestRecvTransactions63(t *testing.T)  { testRecvTransactions(t, 63) }
+func TestRecvTransactions101(t *testing.T) { testRecvTransactions(t, xdpos2ForkID) }
 
 func testRecvTransactions(t *testing.T, protocol int) {
 	txAdded := make(chan []*types.Transaction)
@@ -121,6 +136,33 @@ func testRecvTransactions(t *testing.T, protocol int) {
 	}
 }
 
+// Tests that the eth entry of the node record announces the fork ID of the chain.
+func TestENREntry(t *testing.T) {
+	pm, _ := newTestProtocolManagerMust(t, downloader.FullSync, 4, nil, nil)
+	defer pm.Stop()
+
+	var entry ENREntry
+	for _, proto := range pm.SubProtocols {
+		if len(proto.Attributes) != 2 {
+			t.Fatalf("eth/%d: wrong number of attributes: got %d, want 2", proto.Version, len(proto.Attributes))
+		}
+		enc, err := rlp.EncodeToBytes(proto.Attributes[0])
+		if err != nil {
+			t.Fatalf("eth/%d: can't encode attribute: %v", proto.Version, err)
+		}
+		if err := rlp.DecodeBytes(enc, &entry); err != nil {
+			t.Fatalf("eth/%d: can't decode attribute: %v", proto.Version, err)
+		}
+		if want := forkid.NewID(pm.blockchain); entry.ForkID != want {
+			t.Errorf("eth/%d: wrong fork ID: got %x, want %x", proto.Version, entry.ForkID, want)
+		}
+		// The masternode entry is left out unless the node is staking.
+		if enc, _ := rlp.EncodeToBytes(proto.Attributes[1]); !bytes.Equal(enc, rlp.EmptyString) {
+			t.Errorf("eth/%d: masternode entry announced without signer: %x", proto.Version, enc)
+		}
+	}
+}
+
 // This test checks that pending transactions are sent.
 func TestSendTransactions62(t *testing.T) { testSendTransactions(t, 62) }
 func TestSendTransactions63(t *testing.T) { testSendTransactions(t, 63) }
@@ -0,0 +1,518 @@
+// Copyright (c) 2018 XDPoSChain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package xdcclient
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/XinFinOrg/XDPoSChain/XDCx/tradingstate"
+	"github.com/XinFinOrg/XDPoSChain/XDCxlending/lendingstate"
+	"github.com/XinFinOrg/XDPoSChain/accounts"
+	"github.com/XinFinOrg/XDPoSChain/common"
+	"github.com/XinFinOrg/XDPoSChain/core/state"
+	"github.com/XinFinOrg/XDPoSChain/core/types"
+	"github.com/XinFinOrg/XDPoSChain/crypto"
+)
+
+var (
+	// ErrInvalidLendingQuantity is returned when a lending order or top up has
+	// no positive quantity.
+	ErrInvalidLendingQuantity = errors.New("invalid lending quantity")
+
+	// ErrInvalidLendingCollateral is returned when a borrowing order has no
+	// collateral, or uses the lending token as collateral.
+	ErrInvalidLendingCollateral = errors.New("invalid lending collateral")
+
+	// ErrInvalidLendingTradeID is returned when a top up or repayment doesn't
+	// refer to a lending trade.
+	ErrInvalidLendingTradeID = errors.New("invalid lending trade id")
+
+	// ErrSignerMismatch is returned when the signature of a transaction doesn't
+	// recover to the address of the signer.
+	ErrSignerMismatch = errors.New("signature doesn't match the signer address")
+)
+
+// TxSigner signs order and lending transactions on behalf of an account.
+type TxSigner interface {
+	// Address returns the account the transactions are signed for.
+	Address() common.Address
+
+	// SignHash signs the given digest, returning the signature in the
+	// [R || S || V] format where V is 0 or 1.
+	SignHash(hash []byte) ([]byte, error)
+}
+
+type keySigner struct {
+	key *ecdsa.PrivateKey
+}
+
+// NewKeySigner creates a signer from a raw private key.
+func NewKeySigner(key *ecdsa.PrivateKey) TxSigner {
+	return &keySigner{key: key}
+}
+
+func (s *keySigner) Address() common.Address { return crypto.PubkeyToAddress(s.key.PublicKey) }
+
+func (s *keySigner) SignHash(hash []byte) ([]byte, error) { return crypto.Sign(hash, s.key) }
+
+type walletSigner struct {
+	wallet  accounts.Wallet
+	account accounts.Account
+}
+
+// NewWalletSigner creates a signer from an account of a wallet. The account has
+// to be unlocked, or the wallet must not require a passphrase.
+func NewWalletSigner(wallet accounts.Wallet, account accounts.Account) TxSigner {
+	return &walletSigner{wallet: wallet, account: account}
+}
+
+func (s *walletSigner) Address() common.Address { return s.account.Address }
+
+func (s *walletSigner) SignHash(hash []byte) ([]byte, error) {
+	return s.wallet.SignHash(s.account, hash)
+}
+
+// signedMessage returns the digest actually signed for the given hash of an
+// order or lending transaction.
+func signedMessage(hash common.Hash) []byte {
+	return crypto.Keccak256([]byte("\x19Ethereum Signed Message:\n32"), hash.Bytes())
+}
+
+// PairValidator checks that a relayer lists the pair or the lending book an
+// order is sent to.
+type PairValidator interface {
+	// ValidateOrderPair checks that the relayer lists the trading pair.
+	ValidateOrderPair(relayer, baseToken, quoteToken common.Address) error
+
+	// ValidateLendingPair checks that the relayer lists the lending token with
+	// the term and, if collateralToken isn't zero, accepts the collateral.
+	ValidateLendingPair(relayer, lendingToken, collateralToken common.Address, term uint64) error
+}
+
+// StateValidator validates orders against the relayer registrations of a state.
+type StateValidator struct {
+	statedb *state.StateDB
+}
+
+// NewStateValidator creates a validator reading the relayer registrations from
+// the given state.
+func NewStateValidator(statedb *state.StateDB) *StateValidator {
+	return &StateValidator{statedb: statedb}
+}
+
+// ValidateOrderPair implements PairValidator.
+func (v *StateValidator) ValidateOrderPair(relayer, baseToken, quoteToken common.Address) error {
+	if !tradingstate.IsValidRelayer(v.statedb, relayer) {
+		return tradingstate.ErrInvalidRelayer
+	}
+	return tradingstate.VerifyPair(v.statedb, relayer, baseToken, quoteToken)
+}
+
+// ValidateLendingPair implements PairValidator.
+func (v *StateValidator) ValidateLendingPair(relayer, lendingToken, collateralToken common.Address, term uint64) error {
+	if !lendingstate.IsValidRelayer(v.statedb, relayer) {
+		return fmt.Errorf("invalid lending relayer %s", relayer.Hex())
+	}
+	if valid, _ := lendingstate.IsValidPair(v.statedb, relayer, lendingToken, term); !valid {
+		return fmt.Errorf("invalid lending pair. LendingToken: %s. Term: %d. Relayer terms: %v", lendingToken.Hex(), term, lendingstate.GetTerms(v.statedb, relayer))
+	}
+	if collateralToken.IsZero() {
+		return nil
+	}
+	for _, collateral := range lendingstate.GetCollaterals(v.statedb, relayer, lendingToken, term) {
+		if collateral == collateralToken {
+			return nil
+		}
+	}
+	return ErrInvalidLendingCollateral
+}
+
+// OrderBuilder builds and signs XDCx order transactions.
+type OrderBuilder struct {
+	exchange   common.Address
+	baseToken  common.Address
+	quoteToken common.Address
+	status     string
+	side       string
+	orderType  string
+	quantity   *big.Int
+	price      *big.Int
+	orderHash  common.Hash
+	orderID    uint64
+
+	nonce    uint64
+	hasNonce bool
+
+	timeInForce      string
+	postOnly         bool
+	expireBlock      uint64
+	triggerPrice     *big.Int
+	triggerCondition string
+}
+
+// NewLimitOrder starts a limit order of the given side (BUY or SELL) on the
+// pair listed by the exchange.
+func NewLimitOrder(exchange, baseToken, quoteToken common.Address, side string, quantity, price *big.Int) *OrderBuilder {
+	return &OrderBuilder{
+		exchange:   exchange,
+		baseToken:  baseToken,
+		quoteToken: quoteToken,
+		status:     tradingstate.OrderNew,
+		side:       side,
+		orderType:  tradingstate.Limit,
+		quantity:   quantity,
+		price:      price,
+	}
+}
+
+// NewMarketOrder starts a market order of the given side (BUY or SELL) on the
+// pair listed by the exchange.
+func NewMarketOrder(exchange, baseToken, quoteToken common.Address, side string, quantity *big.Int) *OrderBuilder {
+	return &OrderBuilder{
+		exchange:   exchange,
+		baseToken:  baseToken,
+		quoteToken: quoteToken,
+		status:     tradingstate.OrderNew,
+		side:       side,
+		orderType:  tradingstate.Market,
+		quantity:   quantity,
+		price:      new(big.Int),
+	}
+}
+
+// NewCancelOrder starts the cancellation of the order with the given hash and
+// id.
+func NewCancelOrder(exchange, baseToken, quoteToken common.Address, orderHash common.Hash, orderID uint64) *OrderBuilder {
+	return &OrderBuilder{
+		exchange:   exchange,
+		baseToken:  baseToken,
+		quoteToken: quoteToken,
+		status:     tradingstate.Cancel,
+		orderType:  tradingstate.Limit,
+		quantity:   new(big.Int),
+		price:      new(big.Int),
+		orderHash:  orderHash,
+		orderID:    orderID,
+	}
+}
+
+// Nonce sets the order nonce. If unset, the client looks up the next order
+// nonce of the signer.
+func (b *OrderBuilder) Nonce(nonce uint64) *OrderBuilder {
+	b.nonce, b.hasNonce = nonce, true
+	return b
+}
+
+// TimeInForce sets the time-in-force policy of the order. The expiry block is
+// only used by good-til-block orders.
+func (b *OrderBuilder) TimeInForce(timeInForce string, expireBlock uint64) *OrderBuilder {
+	b.timeInForce, b.expireBlock = timeInForce, expireBlock
+	return b
+}
+
+// PostOnly makes the order rejected instead of matched if it would take
+// liquidity.
+func (b *OrderBuilder) PostOnly() *OrderBuilder {
+	b.postOnly = true
+	return b
+}
+
+// Trigger turns the order into a stop-loss or take-profit order, waiting for
+// the epoch price to cross the trigger price (ABOVE or BELOW).
+func (b *OrderBuilder) Trigger(price *big.Int, condition string) *OrderBuilder {
+	b.triggerPrice, b.triggerCondition = price, condition
+	return b
+}
+
+// Sign builds the order with the given nonce, signs it and verifies it the
+// same way the order pool does.
+func (b *OrderBuilder) Sign(signer TxSigner, nonce uint64) (*types.OrderTransaction, error) {
+	if b.hasNonce {
+		nonce = b.nonce
+	}
+	tx := types.NewOrderTransaction(nonce, b.quantity, b.price, b.exchange, signer.Address(), b.baseToken, b.quoteToken, b.status, b.side, b.orderType, b.orderHash, b.orderID)
+	tx.SetMatchingOptions(b.timeInForce, b.postOnly, b.expireBlock)
+	tx.SetTrigger(b.triggerPrice, b.triggerCondition)
+
+	var txSigner types.OrderTxSigner
+	if !tx.IsCancelledOrder() {
+		tx.SetOrderHash(txSigner.Hash(tx))
+	}
+	sig, err := signer.SignHash(signedMessage(txSigner.Hash(tx)))
+	if err != nil {
+		return nil, err
+	}
+	if tx, err = tx.WithSignature(txSigner, sig); err != nil {
+		return nil, err
+	}
+	V, R, S := tx.Signature()
+	order := &tradingstate.OrderItem{
+		Nonce:            new(big.Int).SetUint64(tx.Nonce()),
+		Quantity:         tx.Quantity(),
+		Price:            tx.Price(),
+		ExchangeAddress:  tx.ExchangeAddress(),
+		UserAddress:      tx.UserAddress(),
+		BaseToken:        tx.BaseToken(),
+		QuoteToken:       tx.QuoteToken(),
+		Status:           tx.Status(),
+		Side:             tx.Side(),
+		Type:             tx.Type(),
+		Hash:             tx.OrderHash(),
+		OrderID:          tx.OrderID(),
+		TimeInForce:      tx.TimeInForce(),
+		PostOnly:         tx.PostOnly(),
+		ExpireBlock:      tx.ExpireBlock(),
+		TriggerPrice:     tx.TriggerPrice(),
+		TriggerCondition: tx.TriggerCondition(),
+		Signature: &tradingstate.Signature{
+			V: byte(V.Uint64()),
+			R: common.BigToHash(R),
+			S: common.BigToHash(S),
+		},
+	}
+	if err := order.VerifyBasicOrderInfo(); err != nil {
+		if err == tradingstate.ErrInvalidSignature {
+			return nil, ErrSignerMismatch
+		}
+		return nil, err
+	}
+	return tx, nil
+}
+
+// LendingBuilder builds and signs XDCx lending transactions.
+type LendingBuilder struct {
+	relayer         common.Address
+	lendingToken    common.Address
+	collateralToken common.Address
+	term            uint64
+	status          string
+	side            string
+	lendingType     string
+	quantity        *big.Int
+	interest        uint64
+	autoTopUp       bool
+	lendingHash     common.Hash
+	lendingID       uint64
+	tradeID         uint64
+
+	nonce    uint64
+	hasNonce bool
+}
+
+// NewBorrow starts a market borrowing order of the lending book listed by the
+// relayer, backed by the given collateral.
+func NewBorrow(relayer, lendingToken, collateralToken common.Address, term uint64, quantity *big.Int) *LendingBuilder {
+	return &LendingBuilder{
+		relayer:         relayer,
+		lendingToken:    lendingToken,
+		collateralToken: collateralToken,
+		term:            term,
+		status:          lendingstate.LendingStatusNew,
+		side:            lendingstate.Borrowing,
+		lendingType:     lendingstate.Market,
+		quantity:        quantity,
+	}
+}
+
+// NewInvest starts a market investing order of the lending book listed by the
+// relayer.
+func NewInvest(relayer, lendingToken common.Address, term uint64, quantity *big.Int) *LendingBuilder {
+	return &LendingBuilder{
+		relayer:      relayer,
+		lendingToken: lendingToken,
+		term:         term,
+		status:       lendingstate.LendingStatusNew,
+		side:         lendingstate.Investing,
+		lendingType:  lendingstate.Market,
+		quantity:     quantity,
+	}
+}
+
+// NewCancelLending starts the cancellation of the lending order with the given
+// hash and id.
+func NewCancelLending(relayer, lendingToken common.Address, term uint64, lendingHash common.Hash, lendingID uint64) *LendingBuilder {
+	return &LendingBuilder{
+		relayer:      relayer,
+		lendingToken: lendingToken,
+		term:         term,
+		status:       lendingstate.LendingStatusCancelled,
+		lendingType:  lendingstate.Limit,
+		quantity:     new(big.Int),
+		lendingHash:  lendingHash,
+		lendingID:    lendingID,
+	}
+}
+
+// NewTopUp starts a deposit of more collateral into the given lending trade.
+func NewTopUp(relayer, lendingToken common.Address, term uint64, tradeID uint64, quantity *big.Int) *LendingBuilder {
+	return &LendingBuilder{
+		relayer:      relayer,
+		lendingToken: lendingToken,
+		term:         term,
+		status:       lendingstate.LendingStatusNew,
+		side:         lendingstate.Borrowing,
+		lendingType:  lendingstate.TopUp,
+		quantity:     quantity,
+		tradeID:      tradeID,
+	}
+}
+
+// NewRepay starts the repayment of the given lending trade.
+func NewRepay(relayer, lendingToken common.Address, term uint64, tradeID uint64) *LendingBuilder {
+	return &LendingBuilder{
+		relayer:      relayer,
+		lendingToken: lendingToken,
+		term:         term,
+		status:       lendingstate.LendingStatusNew,
+		side:         lendingstate.Borrowing,
+		lendingType:  lendingstate.Repay,
+		quantity:     new(big.Int),
+		tradeID:      tradeID,
+	}
+}
+
+// Interest turns a borrowing or investing order into a limit order at the
+// given interest rate.
+func (b *LendingBuilder) Interest(interest uint64) *LendingBuilder {
+	b.lendingType, b.interest = lendingstate.Limit, interest
+	return b
+}
+
+// AutoTopUp lets the lending engine top up the collateral of the borrowing
+// instead of liquidating it.
+func (b *LendingBuilder) AutoTopUp() *LendingBuilder {
+	b.autoTopUp = true
+	return b
+}
+
+// Nonce sets the lending nonce. If unset, the client looks up the next lending
+// nonce of the signer.
+func (b *LendingBuilder) Nonce(nonce uint64) *LendingBuilder {
+	b.nonce, b.hasNonce = nonce, true
+	return b
+}
+
+// verify runs the checks of the lending pool which don't need the chain state.
+func (b *LendingBuilder) verify() error {
+	switch b.lendingType {
+	case lendingstate.Limit, lendingstate.Market:
+		if b.status == lendingstate.LendingStatusCancelled {
+			if b.lendingID == 0 {
+				return errors.New("invalid cancelled lending id")
+			}
+			return nil
+		}
+		if b.quantity == nil || b.quantity.Sign() <= 0 {
+			return ErrInvalidLendingQuantity
+		}
+		if b.lendingType == lendingstate.Limit && b.interest == 0 {
+			return errors.New("invalid lending interest")
+		}
+		if b.side == lendingstate.Borrowing && (b.collateralToken.IsZero() || b.collateralToken == b.lendingToken) {
+			return ErrInvalidLendingCollateral
+		}
+	case lendingstate.TopUp:
+		if b.quantity == nil || b.quantity.Sign() <= 0 {
+			return ErrInvalidLendingQuantity
+		}
+		fallthrough
+	case lendingstate.Repay:
+		if b.tradeID == 0 {
+			return ErrInvalidLendingTradeID
+		}
+	}
+	return nil
+}
+
+// Sign builds the lending transaction with the given nonce and signs it.
+func (b *LendingBuilder) Sign(signer TxSigner, nonce uint64) (*types.LendingTransaction, error) {
+	if err := b.verify(); err != nil {
+		return nil, err
+	}
+	if b.hasNonce {
+		nonce = b.nonce
+	}
+	tx := types.NewLendingTransaction(nonce, b.quantity, b.interest, b.term, b.relayer, signer.Address(), b.lendingToken, b.collateralToken, b.autoTopUp, b.status, b.side, b.lendingType, b.lendingHash, b.lendingID, b.tradeID, "")
+
+	var txSigner types.LendingTxSigner
+	if !tx.IsCancelledLending() {
+		tx.SetLendingHash(txSigner.Hash(tx))
+	}
+	sig, err := signer.SignHash(signedMessage(txSigner.Hash(tx)))
+	if err != nil {
+		return nil, err
+	}
+	if tx, err = tx.WithSignature(txSigner, sig); err != nil {
+		return nil, err
+	}
+	if from, err := types.LendingSender(txSigner, tx); err != nil || from != signer.Address() {
+		return nil, ErrSignerMismatch
+	}
+	return tx, nil
+}
+
+// SendOrder signs the order for the signer and sends it to the order pool of
+// the node. The pair is checked first if a validator is given.
+func (xc *Client) SendOrder(ctx context.Context, b *OrderBuilder, signer TxSigner, validator PairValidator) (*types.OrderTransaction, error) {
+	if validator != nil && b.status == tradingstate.OrderNew {
+		if err := validator.ValidateOrderPair(b.exchange, b.baseToken, b.quoteToken); err != nil {
+			return nil, err
+		}
+	}
+	var nonce uint64
+	if !b.hasNonce {
+		var err error
+		if nonce, err = xc.OrderCount(ctx, signer.Address(), nil); err != nil {
+			return nil, err
+		}
+	}
+	tx, err := b.Sign(signer, nonce)
+	if err != nil {
+		return nil, err
+	}
+	return tx, xc.SendOrderTransaction(ctx, tx)
+}
+
+// SendLending signs the lending transaction for the signer and sends it to the
+// lending pool of the node. The lending book is checked first if a validator
+// is given.
+func (xc *Client) SendLending(ctx context.Context, b *LendingBuilder, signer TxSigner, validator PairValidator) (*types.LendingTransaction, error) {
+	if validator != nil {
+		collateral := b.collateralToken
+		if b.side != lendingstate.Borrowing || b.status != lendingstate.LendingStatusNew || b.lendingType == lendingstate.TopUp || b.lendingType == lendingstate.Repay {
+			collateral = common.Address{}
+		}
+		if err := validator.ValidateLendingPair(b.relayer, b.lendingToken, collateral, b.term); err != nil {
+			return nil, err
+		}
+	}
+	var nonce uint64
+	if !b.hasNonce {
+		var err error
+		if nonce, err = xc.LendingOrderCount(ctx, signer.Address(), nil); err != nil {
+			return nil, err
+		}
+	}
+	tx, err := b.Sign(signer, nonce)
+	if err != nil {
+		return nil, err
+	}
+	return tx, xc.SendLendingTransaction(ctx, tx)
+}
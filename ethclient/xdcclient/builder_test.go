@@ -0,0 +1,190 @@
+// Copyright (c) 2018 XDPoSChain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package xdcclient
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/XinFinOrg/XDPoSChain/XDCx/tradingstate"
+	"github.com/XinFinOrg/XDPoSChain/common"
+	"github.com/XinFinOrg/XDPoSChain/common/hexutil"
+	"github.com/XinFinOrg/XDPoSChain/core/types"
+	"github.com/XinFinOrg/XDPoSChain/crypto"
+	"github.com/XinFinOrg/XDPoSChain/rlp"
+	"github.com/XinFinOrg/XDPoSChain/rpc"
+)
+
+var (
+	testRelayer  = common.HexToAddress("0x0000000000000000000000000000000000000004")
+	testKey, _   = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	testAddress  = crypto.PubkeyToAddress(testKey.PublicKey)
+	errNotListed = errors.New("pair not listed")
+)
+
+// testValidator accepts the pairs of a single relayer.
+type testValidator struct{}
+
+func (v testValidator) ValidateOrderPair(relayer, baseToken, quoteToken common.Address) error {
+	if relayer != testRelayer {
+		return errNotListed
+	}
+	return nil
+}
+
+func (v testValidator) ValidateLendingPair(relayer, lendingToken, collateralToken common.Address, term uint64) error {
+	if relayer != testRelayer || term != 86400 {
+		return errNotListed
+	}
+	return nil
+}
+
+// testXDCxAPI records the order and lending transactions sent to the XDCx
+// namespace. The pools of a node ignore them until the XDCx receiver fork, so
+// the sending is checked against it instead.
+type testXDCxAPI struct {
+	orders   []*types.OrderTransaction
+	lendings []*types.LendingTransaction
+}
+
+func (api *testXDCxAPI) GetOrderCount(addr common.Address, number *rpc.BlockNumberOrHash) *hexutil.Uint64 {
+	count := hexutil.Uint64(7)
+	return &count
+}
+
+func (api *testXDCxAPI) GetLendingOrderCount(addr common.Address, number *rpc.BlockNumberOrHash) *hexutil.Uint64 {
+	count := hexutil.Uint64(2)
+	return &count
+}
+
+func (api *testXDCxAPI) SendOrderRawTransaction(encodedTx hexutil.Bytes) (common.Hash, error) {
+	tx := new(types.OrderTransaction)
+	if err := rlp.DecodeBytes(encodedTx, tx); err != nil {
+		return common.Hash{}, err
+	}
+	api.orders = append(api.orders, tx)
+	return tx.Hash(), nil
+}
+
+func (api *testXDCxAPI) SendLendingRawTransaction(encodedTx hexutil.Bytes) (common.Hash, error) {
+	tx := new(types.LendingTransaction)
+	if err := rlp.DecodeBytes(encodedTx, tx); err != nil {
+		return common.Hash{}, err
+	}
+	api.lendings = append(api.lendings, tx)
+	return tx.Hash(), nil
+}
+
+func TestSignOrder(t *testing.T) {
+	signer := NewKeySigner(testKey)
+
+	tx, err := NewLimitOrder(testRelayer, testBaseToken, testQuoteToken, tradingstate.Bid, big.NewInt(10), big.NewInt(100)).
+		TimeInForce(types.TimeInForceGTB, 500).
+		PostOnly().
+		Sign(signer, 3)
+	if err != nil {
+		t.Fatalf("failed to sign limit order: %v", err)
+	}
+	if from, err := types.OrderSender(types.OrderTxSigner{}, tx); err != nil || from != testAddress {
+		t.Errorf("sender mismatch: have %x, %v, want %x", from, err, testAddress)
+	}
+	if tx.Nonce() != 3 || tx.UserAddress() != testAddress || tx.OrderHash() != (types.OrderTxSigner{}).Hash(tx) {
+		t.Errorf("order fields mismatch: nonce %d, user %x, hash %x", tx.Nonce(), tx.UserAddress(), tx.OrderHash())
+	}
+
+	cancel, err := NewCancelOrder(testRelayer, testBaseToken, testQuoteToken, tx.OrderHash(), 12).Sign(signer, 4)
+	if err != nil {
+		t.Fatalf("failed to sign cancel order: %v", err)
+	}
+	if !cancel.IsCancelledOrder() || cancel.OrderHash() != tx.OrderHash() || cancel.OrderID() != 12 {
+		t.Errorf("cancel order mismatch: hash %x, id %d", cancel.OrderHash(), cancel.OrderID())
+	}
+
+	// The checks of the order pool are run before returning the order
+	if _, err := NewMarketOrder(testRelayer, testBaseToken, testQuoteToken, tradingstate.Ask, big.NewInt(10)).PostOnly().Sign(signer, 5); err != tradingstate.ErrInvalidPostOnly {
+		t.Errorf("post-only market order error mismatch: have %v, want %v", err, tradingstate.ErrInvalidPostOnly)
+	}
+	if _, err := NewLimitOrder(testRelayer, testBaseToken, testQuoteToken, "HOLD", big.NewInt(10), big.NewInt(100)).Sign(signer, 5); err != tradingstate.ErrInvalidOrderSide {
+		t.Errorf("invalid side error mismatch: have %v, want %v", err, tradingstate.ErrInvalidOrderSide)
+	}
+}
+
+func TestSignLending(t *testing.T) {
+	signer := NewKeySigner(testKey)
+	lendingToken, collateralToken := testQuoteToken, testBaseToken
+
+	tx, err := NewBorrow(testRelayer, lendingToken, collateralToken, 86400, big.NewInt(1000)).Interest(5).AutoTopUp().Sign(signer, 1)
+	if err != nil {
+		t.Fatalf("failed to sign borrowing: %v", err)
+	}
+	if !tx.IsLoTypeLending() || tx.Interest() != 5 || !tx.AutoTopUp() || tx.LendingHash() != (types.LendingTxSigner{}).Hash(tx) {
+		t.Errorf("borrowing fields mismatch: type %s, interest %d, hash %x", tx.Type(), tx.Interest(), tx.LendingHash())
+	}
+	repay, err := NewRepay(testRelayer, lendingToken, 86400, 9).Sign(signer, 2)
+	if err != nil {
+		t.Fatalf("failed to sign repayment: %v", err)
+	}
+	if from, err := types.LendingSender(types.LendingTxSigner{}, repay); err != nil || from != testAddress || !repay.IsRepayLending() {
+		t.Errorf("repayment mismatch: sender %x, %v, type %s", from, err, repay.Type())
+	}
+
+	if _, err := NewBorrow(testRelayer, lendingToken, lendingToken, 86400, big.NewInt(1000)).Sign(signer, 3); err != ErrInvalidLendingCollateral {
+		t.Errorf("collateral error mismatch: have %v, want %v", err, ErrInvalidLendingCollateral)
+	}
+	if _, err := NewTopUp(testRelayer, lendingToken, 86400, 0, big.NewInt(1)).Sign(signer, 3); err != ErrInvalidLendingTradeID {
+		t.Errorf("trade id error mismatch: have %v, want %v", err, ErrInvalidLendingTradeID)
+	}
+}
+
+func TestSendOrderAndLending(t *testing.T) {
+	api := new(testXDCxAPI)
+	client := newStubClient(t, map[string]interface{}{"XDCx": api})
+	signer := NewKeySigner(testKey)
+	ctx := context.Background()
+
+	order, err := client.SendOrder(ctx, NewMarketOrder(testRelayer, testBaseToken, testQuoteToken, tradingstate.Bid, big.NewInt(10)), signer, testValidator{})
+	if err != nil {
+		t.Fatalf("failed to send order: %v", err)
+	}
+	if order.Nonce() != 7 {
+		t.Errorf("order nonce mismatch: have %d, want 7", order.Nonce())
+	}
+	if len(api.orders) != 1 || api.orders[0].OrderHash() != order.OrderHash() {
+		t.Fatalf("order not received by the node")
+	}
+	if _, err := client.SendOrder(ctx, NewMarketOrder(testMasternode, testBaseToken, testQuoteToken, tradingstate.Bid, big.NewInt(10)), signer, testValidator{}); err != errNotListed {
+		t.Errorf("unlisted pair error mismatch: have %v, want %v", err, errNotListed)
+	}
+
+	lending, err := client.SendLending(ctx, NewInvest(testRelayer, testQuoteToken, 86400, big.NewInt(1000)).Nonce(11), signer, testValidator{})
+	if err != nil {
+		t.Fatalf("failed to send lending: %v", err)
+	}
+	if lending.Nonce() != 11 {
+		t.Errorf("lending nonce mismatch: have %d, want 11", lending.Nonce())
+	}
+	if len(api.lendings) != 1 || api.lendings[0].LendingHash() != lending.LendingHash() {
+		t.Fatalf("lending not received by the node")
+	}
+	if _, err := client.SendLending(ctx, NewInvest(testRelayer, testQuoteToken, 60, big.NewInt(1000)), signer, testValidator{}); err != errNotListed {
+		t.Errorf("unlisted term error mismatch: have %v, want %v", err, errNotListed)
+	}
+	if len(api.orders) != 1 || len(api.lendings) != 1 {
+		t.Errorf("rejected transactions were sent: %d orders, %d lendings", len(api.orders), len(api.lendings))
+	}
+}
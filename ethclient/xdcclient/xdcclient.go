@@ -0,0 +1,679 @@
+// Copyright (c) 2018 XDPoSChain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+// Package xdcclient provides a client for the XDPoS, XDCx, lending and TRC21 fee
+// RPC APIs. It embeds an ethclient.Client, so the standard eth_ calls are
+// available too.
+package xdcclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"time"
+
+	ethereum "github.com/XinFinOrg/XDPoSChain"
+	"github.com/XinFinOrg/XDPoSChain/XDCx/tradingstate"
+	"github.com/XinFinOrg/XDPoSChain/XDCxlending/lendingstate"
+	"github.com/XinFinOrg/XDPoSChain/common"
+	"github.com/XinFinOrg/XDPoSChain/common/hexutil"
+	"github.com/XinFinOrg/XDPoSChain/consensus/XDPoS"
+	"github.com/XinFinOrg/XDPoSChain/consensus/XDPoS/utils"
+	"github.com/XinFinOrg/XDPoSChain/core/types"
+	"github.com/XinFinOrg/XDPoSChain/ethclient"
+	"github.com/XinFinOrg/XDPoSChain/event"
+	"github.com/XinFinOrg/XDPoSChain/internal/ethapi"
+	"github.com/XinFinOrg/XDPoSChain/log"
+	"github.com/XinFinOrg/XDPoSChain/rpc"
+)
+
+// headFetchTimeout is how long the subscriptions wait for the XDPoS v2
+// information of a new chain head.
+const headFetchTimeout = 10 * time.Second
+
+// CommittedBlockNumber selects the latest block committed by the XDPoS v2
+// consensus in the block number arguments of the client.
+var CommittedBlockNumber = big.NewInt(int64(rpc.CommittedBlockNumber))
+
+// Client defines typed wrappers for the XDPoS, XDCx and lending RPC APIs.
+type Client struct {
+	*ethclient.Client
+	c *rpc.Client
+}
+
+// Dial connects a client to the given URL.
+func Dial(rawurl string) (*Client, error) {
+	c, err := rpc.Dial(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(c), nil
+}
+
+// NewClient creates a client that uses the given RPC client.
+func NewClient(c *rpc.Client) *Client {
+	return &Client{ethclient.NewClient(c), c}
+}
+
+// Close closes the underlying RPC connection.
+func (xc *Client) Close() {
+	xc.c.Close()
+}
+
+// CandidateStatus is the status of a masternode candidate in an epoch.
+type CandidateStatus struct {
+	Status   string   `json:"status"`   // MASTERNODE, SLASHED or PROPOSED
+	Capacity *big.Int `json:"capacity"` // total stake, -1 if the masternode isn't a candidate
+}
+
+// CandidateStatusInfo is the status of a single candidate in an epoch.
+type CandidateStatusInfo struct {
+	CandidateStatus
+	Epoch   int64 `json:"epoch"`
+	Success bool  `json:"success"`
+}
+
+// CandidatesInfo is the status of all candidates in an epoch.
+type CandidatesInfo struct {
+	Epoch      int64                              `json:"epoch"`
+	Success    bool                               `json:"success"`
+	Candidates map[common.Address]CandidateStatus `json:"candidates"`
+}
+
+// XDPoS consensus
+
+// Snapshot returns the state snapshot at the given block. If number is nil, the
+// latest known block is used.
+func (xc *Client) Snapshot(ctx context.Context, number *big.Int) (*utils.PublicApiSnapshot, error) {
+	var snap *utils.PublicApiSnapshot
+	if err := xc.c.CallContext(ctx, &snap, "XDPoS_getSnapshot", toBlockNumArg(number)); err != nil {
+		return nil, err
+	}
+	if snap == nil {
+		return nil, ethereum.NotFound
+	}
+	return snap, nil
+}
+
+// SnapshotAtHash returns the state snapshot at the given block.
+func (xc *Client) SnapshotAtHash(ctx context.Context, hash common.Hash) (*utils.PublicApiSnapshot, error) {
+	var snap *utils.PublicApiSnapshot
+	if err := xc.c.CallContext(ctx, &snap, "XDPoS_getSnapshotAtHash", hash); err != nil {
+		return nil, err
+	}
+	if snap == nil {
+		return nil, ethereum.NotFound
+	}
+	return snap, nil
+}
+
+// Signers returns the authorized signers at the given block. If number is nil,
+// the latest known block is used.
+func (xc *Client) Signers(ctx context.Context, number *big.Int) ([]common.Address, error) {
+	var signers []common.Address
+	err := xc.c.CallContext(ctx, &signers, "XDPoS_getSigners", toBlockNumArg(number))
+	return signers, err
+}
+
+// SignersAtHash returns the authorized signers at the given block.
+func (xc *Client) SignersAtHash(ctx context.Context, hash common.Hash) ([]common.Address, error) {
+	var signers []common.Address
+	err := xc.c.CallContext(ctx, &signers, "XDPoS_getSignersAtHash", hash)
+	return signers, err
+}
+
+// MasternodesByNumber returns the masternodes, penalized nodes and standby
+// nodes at the given block. If number is nil, the latest known block is used.
+func (xc *Client) MasternodesByNumber(ctx context.Context, number *big.Int) (*XDPoS.MasternodesStatus, error) {
+	// The error of the status is an interface, which doesn't survive the JSON
+	// encoding, it only tells whether the call failed.
+	var status struct {
+		XDPoS.MasternodesStatus
+		Error json.RawMessage
+	}
+	if err := xc.c.CallContext(ctx, &status, "XDPoS_getMasternodesByNumber", toBlockNumArg(number)); err != nil {
+		return nil, err
+	}
+	if len(status.Error) > 0 && string(status.Error) != "null" {
+		return nil, errors.New("failed to get masternodes status")
+	}
+	return &status.MasternodesStatus, nil
+}
+
+// LatestPoolStatus returns the content of the vote and timeout pools, and the
+// masternodes which haven't sent their messages yet.
+func (xc *Client) LatestPoolStatus(ctx context.Context) (XDPoS.MessageStatus, error) {
+	var status XDPoS.MessageStatus
+	err := xc.c.CallContext(ctx, &status, "XDPoS_getLatestPoolStatus")
+	return status, err
+}
+
+// V2BlockByNumber returns the XDPoS v2 information of the given block. If number
+// is nil, the latest known block is used.
+func (xc *Client) V2BlockByNumber(ctx context.Context, number *big.Int) (*XDPoS.V2BlockInfo, error) {
+	return xc.getV2Block(ctx, "XDPoS_getV2BlockByNumber", toBlockNumArg(number))
+}
+
+// V2BlockByHash returns the XDPoS v2 information of the given block.
+func (xc *Client) V2BlockByHash(ctx context.Context, hash common.Hash) (*XDPoS.V2BlockInfo, error) {
+	return xc.getV2Block(ctx, "XDPoS_getV2BlockByHash", hash)
+}
+
+func (xc *Client) getV2Block(ctx context.Context, method string, args ...interface{}) (*XDPoS.V2BlockInfo, error) {
+	var info *XDPoS.V2BlockInfo
+	if err := xc.c.CallContext(ctx, &info, method, args...); err != nil {
+		return nil, err
+	}
+	if info == nil {
+		return nil, ethereum.NotFound
+	}
+	if info.Error != "" {
+		return info, errors.New(info.Error)
+	}
+	return info, nil
+}
+
+// NetworkInformation returns the network id, the addresses of the system
+// contracts and the consensus configuration of the node.
+func (xc *Client) NetworkInformation(ctx context.Context) (*XDPoS.NetworkInformation, error) {
+	var info XDPoS.NetworkInformation
+	if err := xc.c.CallContext(ctx, &info, "XDPoS_networkInformation"); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// MissedRoundsInEpochByBlockNum returns the rounds of the epoch of the given
+// block in which the masternode in turn didn't mine. If number is nil, the
+// latest known block is used.
+func (xc *Client) MissedRoundsInEpochByBlockNum(ctx context.Context, number *big.Int) (*utils.PublicApiMissedRoundsMetadata, error) {
+	var missed *utils.PublicApiMissedRoundsMetadata
+	if err := xc.c.CallContext(ctx, &missed, "XDPoS_getMissedRoundsInEpochByBlockNum", toBlockNumArg(number)); err != nil {
+		return nil, err
+	}
+	if missed == nil {
+		return nil, ethereum.NotFound
+	}
+	return missed, nil
+}
+
+// EpochNumbersBetween returns the numbers of the epoch switch blocks between
+// the two given blocks.
+func (xc *Client) EpochNumbersBetween(ctx context.Context, begin, end *big.Int) ([]uint64, error) {
+	var numbers []uint64
+	err := xc.c.CallContext(ctx, &numbers, "XDPoS_getEpochNumbersBetween", toBlockNumArg(begin), toBlockNumArg(end))
+	return numbers, err
+}
+
+// Block signers and masternode candidates
+
+// BlockSignersByNumber returns the masternodes which signed the given block.
+func (xc *Client) BlockSignersByNumber(ctx context.Context, number *big.Int) ([]common.Address, error) {
+	var signers []common.Address
+	err := xc.c.CallContext(ctx, &signers, "eth_getBlockSignersByNumber", toBlockNumArg(number))
+	return signers, err
+}
+
+// BlockSignersByHash returns the masternodes which signed the given block.
+func (xc *Client) BlockSignersByHash(ctx context.Context, hash common.Hash) ([]common.Address, error) {
+	var signers []common.Address
+	err := xc.c.CallContext(ctx, &signers, "eth_getBlockSignersByHash", hash)
+	return signers, err
+}
+
+// BlockFinalityByNumber returns the percentage of masternodes which signed the
+// given block.
+func (xc *Client) BlockFinalityByNumber(ctx context.Context, number *big.Int) (uint, error) {
+	var finality uint
+	err := xc.c.CallContext(ctx, &finality, "eth_getBlockFinalityByNumber", toBlockNumArg(number))
+	return finality, err
+}
+
+// BlockFinalityByHash returns the percentage of masternodes which signed the
+// given block.
+func (xc *Client) BlockFinalityByHash(ctx context.Context, hash common.Hash) (uint, error) {
+	var finality uint
+	err := xc.c.CallContext(ctx, &finality, "eth_getBlockFinalityByHash", hash)
+	return finality, err
+}
+
+// Candidates returns the status of all masternode candidates in the given
+// epoch. If epoch is nil, the latest epoch is used.
+func (xc *Client) Candidates(ctx context.Context, epoch *big.Int) (*CandidatesInfo, error) {
+	var info CandidatesInfo
+	if err := xc.c.CallContext(ctx, &info, "eth_getCandidates", toEpochNumArg(epoch)); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// CandidateStatus returns the status of a masternode candidate in the given
+// epoch. If epoch is nil, the latest epoch is used.
+func (xc *Client) CandidateStatus(ctx context.Context, candidate common.Address, epoch *big.Int) (*CandidateStatusInfo, error) {
+	var info CandidateStatusInfo
+	if err := xc.c.CallContext(ctx, &info, "eth_getCandidateStatus", candidate, toEpochNumArg(epoch)); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// RewardByHash returns the rewards paid at the given checkpoint block, by
+// masternode and by receiver.
+func (xc *Client) RewardByHash(ctx context.Context, hash common.Hash) (map[string]map[string]map[string]*big.Int, error) {
+	var rewards map[string]map[string]map[string]*big.Int
+	err := xc.c.CallContext(ctx, &rewards, "eth_getRewardByHash", hash)
+	return rewards, err
+}
+
+// XDCx trading
+//
+// The order book methods read the books at the block with the given number, the
+// latest known block if nil. The books of old blocks may have been pruned.
+
+// OrderCount returns the nonce of the next order of the given account. If
+// number is nil, the pending orders of the pool are counted too.
+func (xc *Client) OrderCount(ctx context.Context, account common.Address, number *big.Int) (uint64, error) {
+	var count hexutil.Uint64
+	var err error
+	if number == nil {
+		err = xc.c.CallContext(ctx, &count, "XDCx_getOrderCount", account)
+	} else {
+		err = xc.c.CallContext(ctx, &count, "XDCx_getOrderCount", account, toBlockNumArg(number))
+	}
+	return uint64(count), err
+}
+
+// Relayer returns the configuration and the fee status of a relayer. If number
+// is nil, the latest known block is used.
+func (xc *Client) Relayer(ctx context.Context, coinbase common.Address, number *big.Int) (*ethapi.RelayerInfo, error) {
+	var info ethapi.RelayerInfo
+	if err := xc.c.CallContext(ctx, &info, "XDCx_getRelayer", coinbase, toBlockNumArg(number)); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// BestBid returns the highest bid price of the pair and its volume.
+func (xc *Client) BestBid(ctx context.Context, baseToken, quoteToken common.Address, number *big.Int) (*ethapi.PriceVolume, error) {
+	var result ethapi.PriceVolume
+	if err := xc.c.CallContext(ctx, &result, "XDCx_getBestBid", baseToken, quoteToken, toBlockNumArg(number)); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// BestAsk returns the lowest ask price of the pair and its volume.
+func (xc *Client) BestAsk(ctx context.Context, baseToken, quoteToken common.Address, number *big.Int) (*ethapi.PriceVolume, error) {
+	var result ethapi.PriceVolume
+	if err := xc.c.CallContext(ctx, &result, "XDCx_getBestAsk", baseToken, quoteToken, toBlockNumArg(number)); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Bids returns the volume of the pair at every bid price.
+func (xc *Client) Bids(ctx context.Context, baseToken, quoteToken common.Address, number *big.Int) (map[*big.Int]*big.Int, error) {
+	var result map[*big.Int]*big.Int
+	err := xc.c.CallContext(ctx, &result, "XDCx_getBids", baseToken, quoteToken, toBlockNumArg(number))
+	return result, err
+}
+
+// Asks returns the volume of the pair at every ask price.
+func (xc *Client) Asks(ctx context.Context, baseToken, quoteToken common.Address, number *big.Int) (map[*big.Int]*big.Int, error) {
+	var result map[*big.Int]*big.Int
+	err := xc.c.CallContext(ctx, &result, "XDCx_getAsks", baseToken, quoteToken, toBlockNumArg(number))
+	return result, err
+}
+
+// BidTree returns the orders of the pair at every bid price.
+func (xc *Client) BidTree(ctx context.Context, baseToken, quoteToken common.Address, number *big.Int) (map[*big.Int]tradingstate.DumpOrderList, error) {
+	var result map[*big.Int]tradingstate.DumpOrderList
+	err := xc.c.CallContext(ctx, &result, "XDCx_getBidTree", baseToken, quoteToken, toBlockNumArg(number))
+	return result, err
+}
+
+// AskTree returns the orders of the pair at every ask price.
+func (xc *Client) AskTree(ctx context.Context, baseToken, quoteToken common.Address, number *big.Int) (map[*big.Int]tradingstate.DumpOrderList, error) {
+	var result map[*big.Int]tradingstate.DumpOrderList
+	err := xc.c.CallContext(ctx, &result, "XDCx_getAskTree", baseToken, quoteToken, toBlockNumArg(number))
+	return result, err
+}
+
+// Price returns the last traded price of the pair.
+func (xc *Client) Price(ctx context.Context, baseToken, quoteToken common.Address, number *big.Int) (*big.Int, error) {
+	return xc.getPrice(ctx, "XDCx_getPrice", baseToken, quoteToken, number)
+}
+
+// LastEpochPrice returns the average price of the pair in the last epoch.
+func (xc *Client) LastEpochPrice(ctx context.Context, baseToken, quoteToken common.Address, number *big.Int) (*big.Int, error) {
+	return xc.getPrice(ctx, "XDCx_getLastEpochPrice", baseToken, quoteToken, number)
+}
+
+// CurrentEpochPrice returns the average price of the pair in the current epoch.
+func (xc *Client) CurrentEpochPrice(ctx context.Context, baseToken, quoteToken common.Address, number *big.Int) (*big.Int, error) {
+	return xc.getPrice(ctx, "XDCx_getCurrentEpochPrice", baseToken, quoteToken, number)
+}
+
+func (xc *Client) getPrice(ctx context.Context, method string, baseToken, quoteToken common.Address, number *big.Int) (*big.Int, error) {
+	var price *big.Int
+	if err := xc.c.CallContext(ctx, &price, method, baseToken, quoteToken, toBlockNumArg(number)); err != nil {
+		return nil, err
+	}
+	if price == nil {
+		return new(big.Int), nil
+	}
+	return price, nil
+}
+
+// OrderById returns an order of the pair which is still in the order book.
+func (xc *Client) OrderById(ctx context.Context, baseToken, quoteToken common.Address, orderId uint64, number *big.Int) (*tradingstate.OrderItem, error) {
+	var order *tradingstate.OrderItem
+	if err := xc.c.CallContext(ctx, &order, "XDCx_getOrderById", baseToken, quoteToken, orderId, toBlockNumArg(number)); err != nil {
+		return nil, err
+	}
+	if order == nil {
+		return nil, ethereum.NotFound
+	}
+	return order, nil
+}
+
+// TradingOrderBookInfo returns the prices, volumes and nonce of the order book
+// of the pair.
+func (xc *Client) TradingOrderBookInfo(ctx context.Context, baseToken, quoteToken common.Address, number *big.Int) (*tradingstate.DumpOrderBookInfo, error) {
+	var info *tradingstate.DumpOrderBookInfo
+	if err := xc.c.CallContext(ctx, &info, "XDCx_getTradingOrderBookInfo", baseToken, quoteToken, toBlockNumArg(number)); err != nil {
+		return nil, err
+	}
+	if info == nil {
+		return nil, ethereum.NotFound
+	}
+	return info, nil
+}
+
+// LiquidationPriceTree returns the lending trades of the pair at every
+// liquidation price.
+func (xc *Client) LiquidationPriceTree(ctx context.Context, baseToken, quoteToken common.Address, number *big.Int) (map[*big.Int]tradingstate.DumpLendingBook, error) {
+	var result map[*big.Int]tradingstate.DumpLendingBook
+	err := xc.c.CallContext(ctx, &result, "XDCx_getLiquidationPriceTree", baseToken, quoteToken, toBlockNumArg(number))
+	return result, err
+}
+
+// OrderTxMatchByHash returns the orders matched by the given transaction.
+func (xc *Client) OrderTxMatchByHash(ctx context.Context, txHash common.Hash) ([]*tradingstate.OrderItem, error) {
+	var orders []*tradingstate.OrderItem
+	err := xc.c.CallContext(ctx, &orders, "XDCx_getOrderTxMatchByHash", txHash)
+	return orders, err
+}
+
+// XDCx lending
+
+// LendingOrderCount returns the nonce of the next lending order of the given
+// account.
+func (xc *Client) LendingOrderCount(ctx context.Context, account common.Address, number *big.Int) (uint64, error) {
+	var count hexutil.Uint64
+	err := xc.c.CallContext(ctx, &count, "XDCx_getLendingOrderCount", account, toBlockNumArg(number))
+	return uint64(count), err
+}
+
+// BestInvesting returns the lowest interest offered by investors for the
+// lending book and its volume.
+func (xc *Client) BestInvesting(ctx context.Context, lendingToken common.Address, term uint64, number *big.Int) (*ethapi.InterestVolume, error) {
+	var result ethapi.InterestVolume
+	if err := xc.c.CallContext(ctx, &result, "XDCx_getBestInvesting", lendingToken, term, toBlockNumArg(number)); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// BestBorrowing returns the highest interest requested by borrowers for the
+// lending book and its volume.
+func (xc *Client) BestBorrowing(ctx context.Context, lendingToken common.Address, term uint64, number *big.Int) (*ethapi.InterestVolume, error) {
+	var result ethapi.InterestVolume
+	if err := xc.c.CallContext(ctx, &result, "XDCx_getBestBorrowing", lendingToken, term, toBlockNumArg(number)); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Invests returns the volume of the lending book at every investing interest.
+func (xc *Client) Invests(ctx context.Context, lendingToken common.Address, term uint64, number *big.Int) (map[*big.Int]*big.Int, error) {
+	var result map[*big.Int]*big.Int
+	err := xc.c.CallContext(ctx, &result, "XDCx_getInvests", lendingToken, term, toBlockNumArg(number))
+	return result, err
+}
+
+// Borrows returns the volume of the lending book at every borrowing interest.
+func (xc *Client) Borrows(ctx context.Context, lendingToken common.Address, term uint64, number *big.Int) (map[*big.Int]*big.Int, error) {
+	var result map[*big.Int]*big.Int
+	err := xc.c.CallContext(ctx, &result, "XDCx_getBorrows", lendingToken, term, toBlockNumArg(number))
+	return result, err
+}
+
+// InvestingTree returns the orders of the lending book at every investing
+// interest.
+func (xc *Client) InvestingTree(ctx context.Context, lendingToken common.Address, term uint64, number *big.Int) (map[*big.Int]lendingstate.DumpOrderList, error) {
+	var result map[*big.Int]lendingstate.DumpOrderList
+	err := xc.c.CallContext(ctx, &result, "XDCx_getInvestingTree", lendingToken, term, toBlockNumArg(number))
+	return result, err
+}
+
+// BorrowingTree returns the orders of the lending book at every borrowing
+// interest.
+func (xc *Client) BorrowingTree(ctx context.Context, lendingToken common.Address, term uint64, number *big.Int) (map[*big.Int]lendingstate.DumpOrderList, error) {
+	var result map[*big.Int]lendingstate.DumpOrderList
+	err := xc.c.CallContext(ctx, &result, "XDCx_getBorrowingTree", lendingToken, term, toBlockNumArg(number))
+	return result, err
+}
+
+// LendingOrderBookInfo returns the interests, volumes and nonce of the lending
+// book.
+func (xc *Client) LendingOrderBookInfo(ctx context.Context, lendingToken common.Address, term uint64, number *big.Int) (*lendingstate.DumpOrderBookInfo, error) {
+	var info *lendingstate.DumpOrderBookInfo
+	if err := xc.c.CallContext(ctx, &info, "XDCx_getLendingOrderBookInfo", lendingToken, term, toBlockNumArg(number)); err != nil {
+		return nil, err
+	}
+	if info == nil {
+		return nil, ethereum.NotFound
+	}
+	return info, nil
+}
+
+// LendingTradeTree returns the open lending trades of the lending book by id.
+func (xc *Client) LendingTradeTree(ctx context.Context, lendingToken common.Address, term uint64, number *big.Int) (map[*big.Int]lendingstate.LendingTrade, error) {
+	var result map[*big.Int]lendingstate.LendingTrade
+	err := xc.c.CallContext(ctx, &result, "XDCx_getLendingTradeTree", lendingToken, term, toBlockNumArg(number))
+	return result, err
+}
+
+// LiquidationTimeTree returns the lending trades of the lending book at every
+// liquidation time.
+func (xc *Client) LiquidationTimeTree(ctx context.Context, lendingToken common.Address, term uint64, number *big.Int) (map[*big.Int]lendingstate.DumpOrderList, error) {
+	var result map[*big.Int]lendingstate.DumpOrderList
+	err := xc.c.CallContext(ctx, &result, "XDCx_getLiquidationTimeTree", lendingToken, term, toBlockNumArg(number))
+	return result, err
+}
+
+// LendingOrderById returns a lending order which is still in the lending book.
+func (xc *Client) LendingOrderById(ctx context.Context, lendingToken common.Address, term uint64, orderId uint64, number *big.Int) (*lendingstate.LendingItem, error) {
+	var order lendingstate.LendingItem
+	if err := xc.c.CallContext(ctx, &order, "XDCx_getLendingOrderById", lendingToken, term, orderId, toBlockNumArg(number)); err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// LendingTradeById returns an open lending trade.
+func (xc *Client) LendingTradeById(ctx context.Context, lendingToken common.Address, term uint64, tradeId uint64, number *big.Int) (*lendingstate.LendingTrade, error) {
+	var trade lendingstate.LendingTrade
+	if err := xc.c.CallContext(ctx, &trade, "XDCx_getLendingTradeById", lendingToken, term, tradeId, toBlockNumArg(number)); err != nil {
+		return nil, err
+	}
+	return &trade, nil
+}
+
+// LendingTxMatchByHash returns the lending orders matched by the given
+// transaction.
+func (xc *Client) LendingTxMatchByHash(ctx context.Context, txHash common.Hash) ([]*lendingstate.LendingItem, error) {
+	var items []*lendingstate.LendingItem
+	err := xc.c.CallContext(ctx, &items, "XDCx_getLendingTxMatchByHash", txHash)
+	return items, err
+}
+
+// LiquidatedTradesByTxHash returns the lending trades liquidated, repaid,
+// topped up or recalled by the given transaction.
+func (xc *Client) LiquidatedTradesByTxHash(ctx context.Context, txHash common.Hash) (*lendingstate.FinalizedResult, error) {
+	var result lendingstate.FinalizedResult
+	if err := xc.c.CallContext(ctx, &result, "XDCx_getLiquidatedTradesByTxHash", txHash); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// LendingPositions returns the open lending trades of the borrower with their
+// liquidation risk. The collateral prices of the pairs in prices replace the
+// ones used by the liquidation to simulate price moves. If number is nil, the
+// latest known block is used.
+func (xc *Client) LendingPositions(ctx context.Context, borrower common.Address, number *big.Int, prices []ethapi.LendingPriceOverride) (*ethapi.LendingPositions, error) {
+	var result ethapi.LendingPositions
+	if err := xc.c.CallContext(ctx, &result, "XDCx_getLendingPositions", borrower, toBlockNumArg(number), prices); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// TRC21 fee capacity
+
+// TRC21FeeCapacity returns the amount of wei the TRC21 issuer can still spend on
+// the fees of the transactions to the token. If number is nil, the latest known
+// block is used.
+func (xc *Client) TRC21FeeCapacity(ctx context.Context, token common.Address, number *big.Int) (*big.Int, error) {
+	var capacity hexutil.Big
+	if err := xc.c.CallContext(ctx, &capacity, "XDC_getTRC21FeeCapacity", token, toBlockNumArg(number)); err != nil {
+		return nil, err
+	}
+	return (*big.Int)(&capacity), nil
+}
+
+// TRC21Tokens returns the tokens registered in the TRC21 issuer and their fee
+// capacity.
+func (xc *Client) TRC21Tokens(ctx context.Context) (map[common.Address]*big.Int, error) {
+	var tokens map[common.Address]*hexutil.Big
+	if err := xc.c.CallContext(ctx, &tokens, "XDC_listTRC21Tokens"); err != nil {
+		return nil, err
+	}
+	result := make(map[common.Address]*big.Int, len(tokens))
+	for token, capacity := range tokens {
+		result[token] = (*big.Int)(capacity)
+	}
+	return result, nil
+}
+
+// Subscriptions
+
+// SubscribeV2Blocks subscribes to the XDPoS v2 information of every new chain
+// head on the given channel.
+func (xc *Client) SubscribeV2Blocks(ctx context.Context, ch chan<- *XDPoS.V2BlockInfo) (ethereum.Subscription, error) {
+	return xc.subscribeHeads(ctx, func(ctx context.Context, head *types.Header) (*XDPoS.V2BlockInfo, error) {
+		return xc.V2BlockByHash(ctx, head.Hash())
+	}, ch)
+}
+
+// SubscribeCommittedBlocks subscribes to the blocks committed by the XDPoS v2
+// consensus on the given channel. The committed block is checked at every new
+// chain head, so several blocks committed at once are reported as the last one.
+func (xc *Client) SubscribeCommittedBlocks(ctx context.Context, ch chan<- *XDPoS.V2BlockInfo) (ethereum.Subscription, error) {
+	var last *big.Int
+	return xc.subscribeHeads(ctx, func(ctx context.Context, head *types.Header) (*XDPoS.V2BlockInfo, error) {
+		info, err := xc.V2BlockByNumber(ctx, CommittedBlockNumber)
+		if err != nil {
+			return nil, err
+		}
+		if info.Number == nil || (last != nil && info.Number.Cmp(last) <= 0) {
+			return nil, nil
+		}
+		last = info.Number
+		return info, nil
+	}, ch)
+}
+
+// SubscribeTRC21FeeCapacityLow subscribes to the alerts sent when the fee
+// capacity of one of the tokens drops below the threshold. If no token is
+// given, all the registered tokens are watched.
+func (xc *Client) SubscribeTRC21FeeCapacityLow(ctx context.Context, threshold *big.Int, tokens []common.Address, ch chan<- *ethapi.RPCTRC21CapacityAlert) (ethereum.Subscription, error) {
+	return xc.c.Subscribe(ctx, "XDC", ch, "trc21FeeCapacityLow", (*hexutil.Big)(threshold), tokens)
+}
+
+// subscribeHeads subscribes to the new chain heads and delivers what fetch
+// returns for them on the given channel. Each fetch gets headFetchTimeout to
+// complete, nil results and the heads failing to fetch are skipped.
+func (xc *Client) subscribeHeads(ctx context.Context, fetch func(context.Context, *types.Header) (*XDPoS.V2BlockInfo, error), ch chan<- *XDPoS.V2BlockInfo) (ethereum.Subscription, error) {
+	// The newHeads subscription of the node takes no parameters, unlike the
+	// one made by SubscribeNewHead
+	heads := make(chan *types.Header, 16)
+	sub, err := xc.c.EthSubscribe(ctx, heads, "newHeads")
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case head := <-heads:
+				ctx, cancel := context.WithTimeout(context.Background(), headFetchTimeout)
+				info, err := fetch(ctx, head)
+				cancel()
+				if err != nil {
+					log.Warn("Failed to fetch the XDPoS v2 info of a chain head", "number", head.Number, "hash", head.Hash(), "err", err)
+					continue
+				}
+				if info == nil {
+					continue
+				}
+				select {
+				case ch <- info:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+func toBlockNumArg(number *big.Int) string {
+	if number == nil {
+		return "latest"
+	}
+	switch number.Int64() {
+	case int64(rpc.PendingBlockNumber):
+		return "pending"
+	case int64(rpc.CommittedBlockNumber):
+		return "committed"
+	}
+	return hexutil.EncodeBig(number)
+}
+
+func toEpochNumArg(epoch *big.Int) string {
+	if epoch == nil {
+		return "latest"
+	}
+	return hexutil.EncodeBig(epoch)
+}
@@ -0,0 +1,390 @@
+// Copyright (c) 2018 XDPoSChain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package xdcclient
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/XinFinOrg/XDPoSChain/XDCx"
+	"github.com/XinFinOrg/XDPoSChain/XDCx/tradingstate"
+	"github.com/XinFinOrg/XDPoSChain/XDCxlending"
+	"github.com/XinFinOrg/XDPoSChain/accounts/abi/bind"
+	"github.com/XinFinOrg/XDPoSChain/common"
+	"github.com/XinFinOrg/XDPoSChain/consensus/XDPoS"
+	"github.com/XinFinOrg/XDPoSChain/consensus/ethash"
+	"github.com/XinFinOrg/XDPoSChain/core"
+	"github.com/XinFinOrg/XDPoSChain/core/rawdb"
+	"github.com/XinFinOrg/XDPoSChain/core/state"
+	"github.com/XinFinOrg/XDPoSChain/core/types"
+	"github.com/XinFinOrg/XDPoSChain/crypto"
+	"github.com/XinFinOrg/XDPoSChain/eth"
+	"github.com/XinFinOrg/XDPoSChain/eth/ethconfig"
+	"github.com/XinFinOrg/XDPoSChain/node"
+	"github.com/XinFinOrg/XDPoSChain/rpc"
+)
+
+var (
+	testMasternode = common.HexToAddress("0x0000000000000000000000000000000000000001")
+	testBaseToken  = common.HexToAddress("0x0000000000000000000000000000000000000002")
+	testQuoteToken = common.HexToAddress("0x0000000000000000000000000000000000000003")
+
+	testDeposit     = new(big.Int).Mul(common.BasePrice, big.NewInt(30000))
+	testFeeCapacity = big.NewInt(500)
+)
+
+// testGenesis returns a developer genesis sealed by testAddress, with testRelayer
+// registered for the testBaseToken/testQuoteToken pair and testBaseToken
+// registered in the TRC21 issuer.
+func testGenesis() *core.Genesis {
+	genesis := core.DeveloperGenesisBlock(2, testAddress)
+
+	relayer := make(map[common.Hash]common.Hash)
+	loc := tradingstate.GetLocMappingAtKey(testRelayer.Hash(), tradingstate.RelayerMappingSlot["RELAYER_LIST"])
+	field := func(name string) common.Hash {
+		return common.BigToHash(new(big.Int).Add(loc, tradingstate.RelayerStructMappingSlot[name]))
+	}
+	relayer[field("_deposit")] = common.BigToHash(testDeposit)
+	relayer[field("_owner")] = testAddress.Hash()
+	relayer[field("_fromTokens")] = common.BigToHash(common.Big1)
+	relayer[state.GetLocDynamicArrAtElement(field("_fromTokens"), 0, 1)] = testBaseToken.Hash()
+	relayer[field("_toTokens")] = common.BigToHash(common.Big1)
+	relayer[state.GetLocDynamicArrAtElement(field("_toTokens"), 0, 1)] = testQuoteToken.Hash()
+	genesis.Alloc[common.HexToAddress(common.RelayerRegistrationSMC)] = core.GenesisAccount{Balance: common.Big1, Storage: relayer}
+
+	tokens := common.BigToHash(new(big.Int).SetUint64(state.SlotTRC21Issuer["tokens"]))
+	capacity := state.GetLocMappingAtKey(testBaseToken.Hash(), state.SlotTRC21Issuer["tokensState"])
+	genesis.Alloc[common.TRC21IssuerSMC] = core.GenesisAccount{Balance: common.Big1, Storage: map[common.Hash]common.Hash{
+		tokens: common.BigToHash(common.Big1),
+		state.GetLocDynamicArrAtElement(tokens, 0, 1): testBaseToken.Hash(),
+		common.BigToHash(capacity):                    common.BigToHash(testFeeCapacity),
+	}}
+
+	// Seal the genesis, the XDCx state of a block is looked up by its signer
+	engine := XDPoS.New(genesis.Config, rawdb.NewMemoryDatabase())
+	seal, err := crypto.Sign(engine.SigHash(genesis.ToBlock(nil).Header()).Bytes(), testKey)
+	if err != nil {
+		panic(err)
+	}
+	copy(genesis.ExtraData[len(genesis.ExtraData)-len(seal):], seal)
+	return genesis
+}
+
+// newTestClient starts an in-process node running the XDPoS, XDCx and lending
+// services on testGenesis and returns a client attached to it.
+func newTestClient(t *testing.T) *Client {
+	stack, err := node.New(&node.Config{DataDir: t.TempDir(), UseLightweightKDF: true, Name: "xdcclient-tester", IPCPath: "XDC.ipc"})
+	if err != nil {
+		t.Fatalf("failed to create node: %v", err)
+	}
+	XDCX := XDCx.New(&XDCx.Config{})
+	lending := XDCxlending.New(XDCX)
+	if err := stack.Register(func(ctx *node.ServiceContext) (node.Service, error) { return XDCX, nil }); err != nil {
+		t.Fatalf("failed to register XDCx: %v", err)
+	}
+	if err := stack.Register(func(ctx *node.ServiceContext) (node.Service, error) { return lending, nil }); err != nil {
+		t.Fatalf("failed to register lending: %v", err)
+	}
+	if err := stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
+		config := &ethconfig.Config{
+			Genesis:   testGenesis(),
+			Etherbase: testAddress,
+			Ethash:    ethash.Config{PowMode: ethash.ModeTest},
+		}
+		return eth.New(ctx, config, XDCX, lending)
+	}); err != nil {
+		t.Fatalf("failed to register Ethereum: %v", err)
+	}
+	if err := stack.Start(); err != nil {
+		t.Fatalf("failed to start node: %v", err)
+	}
+	t.Cleanup(func() { stack.Stop() })
+
+	rpcClient, err := stack.Attach()
+	if err != nil {
+		t.Fatalf("failed to attach to node: %v", err)
+	}
+	client := NewClient(rpcClient)
+	t.Cleanup(client.Close)
+	return client
+}
+
+// newStubClient returns a client attached to an in-process server running the
+// given stub namespaces.
+func newStubClient(t *testing.T, apis map[string]interface{}) *Client {
+	server := rpc.NewServer()
+	for name, api := range apis {
+		if err := server.RegisterName(name, api); err != nil {
+			t.Fatalf("failed to register %s: %v", name, err)
+		}
+	}
+	t.Cleanup(server.Stop)
+
+	client := NewClient(rpc.DialInProc(server))
+	t.Cleanup(client.Close)
+	return client
+}
+
+func TestXDPoSCalls(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+
+	signers, err := client.Signers(ctx, nil)
+	if err != nil {
+		t.Fatalf("failed to get signers: %v", err)
+	}
+	if len(signers) != 1 || signers[0] != testAddress {
+		t.Errorf("signers mismatch: have %x, want [%x]", signers, testAddress)
+	}
+	snap, err := client.Snapshot(ctx, big.NewInt(0))
+	if err != nil {
+		t.Fatalf("failed to get snapshot: %v", err)
+	}
+	if _, ok := snap.Signers[testAddress]; snap.Number != 0 || !ok {
+		t.Errorf("snapshot mismatch: %+v", snap)
+	}
+	status, err := client.MasternodesByNumber(ctx, big.NewInt(0))
+	if err != nil {
+		t.Fatalf("failed to get masternodes: %v", err)
+	}
+	if status.Number != 0 {
+		t.Errorf("masternodes number mismatch: have %d, want 0", status.Number)
+	}
+	// The chain never switches to v2, so there is no committed block
+	if _, err := client.V2BlockByNumber(ctx, CommittedBlockNumber); err == nil {
+		t.Errorf("expected an error for the committed block of a v1 chain")
+	}
+
+	network, err := client.NetworkInformation(ctx)
+	if err != nil {
+		t.Fatalf("failed to get network information: %v", err)
+	}
+	genesis := testGenesis()
+	if network.NetworkId.Cmp(genesis.Config.ChainId) != 0 || network.RelayerRegistrationAddress != common.HexToAddress(common.RelayerRegistrationSMC) || network.ConsensusConfigs.Period != 2 {
+		t.Errorf("network information mismatch: %+v", network)
+	}
+}
+
+func TestCandidates(t *testing.T) {
+	client := newTestClient(t)
+
+	// The developer genesis doesn't deploy the validator contract
+	if _, err := client.Candidates(context.Background(), nil); err == nil || err.Error() != bind.ErrNoCode.Error() {
+		t.Errorf("candidates error mismatch: have %v, want %v", err, bind.ErrNoCode)
+	}
+}
+
+func TestXDCxCalls(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+
+	for _, number := range []*big.Int{nil, big.NewInt(0)} {
+		if count, err := client.OrderCount(ctx, testAddress, number); err != nil || count != 0 {
+			t.Errorf("order count mismatch at %v: have %d, %v, want 0", number, count, err)
+		}
+	}
+	relayer, err := client.Relayer(ctx, testRelayer, nil)
+	if err != nil {
+		t.Fatalf("failed to get relayer: %v", err)
+	}
+	balance := new(big.Int).Sub(testDeposit, new(big.Int).Mul(common.BasePrice, common.RelayerLockedFund))
+	if relayer.Owner != testAddress || relayer.Deposit.ToInt().Cmp(testDeposit) != 0 || relayer.FeeBalance.ToInt().Cmp(balance) != 0 || relayer.LowFeeBalance || relayer.Resigned {
+		t.Errorf("relayer mismatch: %+v", relayer)
+	}
+	if len(relayer.Pairs) != 1 || relayer.Pairs[0].BaseToken != testBaseToken || relayer.Pairs[0].QuoteToken != testQuoteToken || relayer.Pairs[0].OrderCount != 0 {
+		t.Errorf("relayer pairs mismatch: %+v", relayer.Pairs)
+	}
+	if _, err := client.Relayer(ctx, testMasternode, big.NewInt(0)); err == nil {
+		t.Errorf("expected an error for an unregistered relayer")
+	}
+	positions, err := client.LendingPositions(ctx, testMasternode, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to get lending positions: %v", err)
+	}
+	if positions.Borrower != testMasternode || positions.BlockNumber != 0 || len(positions.Positions) != 0 {
+		t.Errorf("lending positions mismatch: %+v", positions)
+	}
+	// Nothing was traded yet, so the order book doesn't exist
+	if _, err := client.BestBid(ctx, testBaseToken, testQuoteToken, nil); err == nil {
+		t.Errorf("expected an error for the best bid of an empty book")
+	}
+	if _, err := client.Bids(ctx, testBaseToken, testQuoteToken, big.NewInt(0)); err == nil {
+		t.Errorf("expected an error for the bids of an empty book")
+	}
+}
+
+func TestTRC21Calls(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+
+	for _, number := range []*big.Int{nil, big.NewInt(0)} {
+		if capacity, err := client.TRC21FeeCapacity(ctx, testBaseToken, number); err != nil || capacity.Cmp(testFeeCapacity) != 0 {
+			t.Errorf("capacity mismatch at %v: have %v, %v, want %v", number, capacity, err, testFeeCapacity)
+		}
+	}
+	if _, err := client.TRC21FeeCapacity(ctx, testQuoteToken, nil); err == nil {
+		t.Errorf("expected an error for an unregistered token")
+	}
+	tokens, err := client.TRC21Tokens(ctx)
+	if err != nil {
+		t.Fatalf("failed to list tokens: %v", err)
+	}
+	if len(tokens) != 1 || tokens[testBaseToken].Cmp(testFeeCapacity) != 0 {
+		t.Errorf("tokens mismatch: %v", tokens)
+	}
+}
+
+// testHeadsAPI feeds the newHeads subscriptions of the eth namespace.
+type testHeadsAPI struct {
+	heads chan *types.Header
+}
+
+func (api *testHeadsAPI) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return nil, rpc.ErrNotificationsUnsupported
+	}
+	sub := notifier.CreateSubscription()
+	go func() {
+		for {
+			select {
+			case head := <-api.heads:
+				notifier.Notify(sub.ID, head)
+			case <-sub.Err():
+				return
+			}
+		}
+	}()
+	return sub, nil
+}
+
+// testV2API serves the XDPoS v2 information of the known blocks and a sequence
+// of committed block numbers, a negative number failing the call.
+type testV2API struct {
+	lock      sync.Mutex
+	blocks    map[common.Hash]*big.Int
+	committed []int64
+}
+
+func (api *testV2API) GetV2BlockByHash(hash common.Hash) *XDPoS.V2BlockInfo {
+	api.lock.Lock()
+	defer api.lock.Unlock()
+
+	number, ok := api.blocks[hash]
+	if !ok {
+		return &XDPoS.V2BlockInfo{Hash: hash, Error: "not a v2 block"}
+	}
+	return &XDPoS.V2BlockInfo{Hash: hash, Number: number}
+}
+
+func (api *testV2API) GetV2BlockByNumber(number *rpc.BlockNumber) (*XDPoS.V2BlockInfo, error) {
+	api.lock.Lock()
+	defer api.lock.Unlock()
+
+	if *number != rpc.CommittedBlockNumber || len(api.committed) == 0 {
+		return nil, errors.New("unexpected block number")
+	}
+	committed := api.committed[0]
+	api.committed = api.committed[1:]
+	if committed < 0 {
+		return &XDPoS.V2BlockInfo{Error: "no committed block"}, nil
+	}
+	return &XDPoS.V2BlockInfo{Number: big.NewInt(committed), Committed: true}, nil
+}
+
+// newTestHeads returns headers 1 to n.
+func newTestHeads(n int) []*types.Header {
+	heads := make([]*types.Header, n)
+	for i := range heads {
+		heads[i] = &types.Header{Number: big.NewInt(int64(i + 1)), Difficulty: common.Big1, Time: big.NewInt(int64(i + 1))}
+	}
+	return heads
+}
+
+// checkV2Blocks checks the block numbers delivered by a subscription, and that
+// it's still running after them.
+func checkV2Blocks(t *testing.T, sub interface{ Err() <-chan error }, ch <-chan *XDPoS.V2BlockInfo, want ...int64) {
+	t.Helper()
+	for _, number := range want {
+		select {
+		case info := <-ch:
+			if info.Number.Int64() != number {
+				t.Fatalf("block number mismatch: have %d, want %d", info.Number, number)
+			}
+		case err := <-sub.Err():
+			t.Fatalf("subscription failed: %v", err)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("block %d not delivered", number)
+		}
+	}
+	select {
+	case info := <-ch:
+		t.Fatalf("unexpected block %d", info.Number)
+	case err := <-sub.Err():
+		t.Fatalf("subscription failed: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// Tests that the v2 blocks of the new heads are delivered, skipping the heads
+// failing to fetch, and outlive the context of the subscription call.
+func TestSubscribeV2Blocks(t *testing.T) {
+	heads := newTestHeads(3)
+	eth := &testHeadsAPI{heads: make(chan *types.Header, len(heads))}
+	v2 := &testV2API{blocks: map[common.Hash]*big.Int{heads[0].Hash(): heads[0].Number, heads[2].Hash(): heads[2].Number}}
+	client := newStubClient(t, map[string]interface{}{"eth": eth, "XDPoS": v2})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := make(chan *XDPoS.V2BlockInfo)
+	sub, err := client.SubscribeV2Blocks(ctx, ch)
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	defer sub.Unsubscribe()
+	cancel()
+
+	for _, head := range heads {
+		eth.heads <- head
+	}
+	checkV2Blocks(t, sub, ch, 1, 3)
+}
+
+// Tests that the committed blocks are delivered once, skipping the heads
+// failing to fetch.
+func TestSubscribeCommittedBlocks(t *testing.T) {
+	heads := newTestHeads(5)
+	eth := &testHeadsAPI{heads: make(chan *types.Header, len(heads))}
+	v2 := &testV2API{committed: []int64{-1, 5, 5, -1, 7}}
+	client := newStubClient(t, map[string]interface{}{"eth": eth, "XDPoS": v2})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := make(chan *XDPoS.V2BlockInfo)
+	sub, err := client.SubscribeCommittedBlocks(ctx, ch)
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	defer sub.Unsubscribe()
+	cancel()
+
+	for _, head := range heads {
+		eth.heads <- head
+	}
+	checkV2Blocks(t, sub, ch, 5, 7)
+}
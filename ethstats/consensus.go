@@ -0,0 +1,230 @@
+// Copyright (c) 2018 XDPoSChain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package ethstats
+
+import (
+	"errors"
+	"math"
+	"math/big"
+
+	"github.com/XinFinOrg/XDPoSChain/common"
+	"github.com/XinFinOrg/XDPoSChain/consensus/XDPoS"
+	"github.com/XinFinOrg/XDPoSChain/consensus/XDPoS/utils"
+	"github.com/XinFinOrg/XDPoSChain/core"
+	"github.com/XinFinOrg/XDPoSChain/core/types"
+	"github.com/XinFinOrg/XDPoSChain/eth"
+	"github.com/XinFinOrg/XDPoSChain/log"
+	"github.com/XinFinOrg/XDPoSChain/params"
+)
+
+// consensusStats is the information to report about the XDPoS v2 consensus
+// state of the local node.
+type consensusStats struct {
+	Number          *big.Int           `json:"number"`
+	Hash            common.Hash        `json:"hash"`
+	BlockRound      uint64             `json:"blockRound"`      // Round the block was proposed in
+	Committed       bool               `json:"committed"`       // Whether the block is committed
+	Round           uint64             `json:"round"`           // Current round of the node
+	QCSigners       int                `json:"qcSigners"`       // Signatures of the quorum certificate in the block
+	QCThreshold     int                `json:"qcThreshold"`     // Signatures needed to form a quorum certificate
+	EpochRound      uint64             `json:"epochRound"`      // Round of the epoch switch block
+	EpochNumber     *big.Int           `json:"epochNumber"`     // Number of the epoch switch block
+	Timeouts        int                `json:"timeouts"`        // Rounds of the epoch ended by a timeout
+	MissedRounds    []missedRoundStats `json:"missedRounds"`    // Rounds of the epoch without a block
+	PendingVotes    int                `json:"pendingVotes"`    // Votes in the pool of the node
+	PendingTimeouts int                `json:"pendingTimeouts"` // Timeouts in the pool of the node
+	Masternode      bool               `json:"masternode"`      // Whether the node is a masternode of the epoch
+}
+
+// missedRoundStats is the information to report about a round without a block.
+type missedRoundStats struct {
+	Round uint64         `json:"round"`
+	Miner common.Address `json:"miner"` // Masternode whose turn it was to propose
+}
+
+// consensusBackend retrieves the XDPoS v2 consensus state of the local node.
+type consensusBackend interface {
+	IsV2(header *types.Header) bool
+	BlockInfo(header *types.Header) *XDPoS.V2BlockInfo
+	CurrentRound() types.Round
+	EpochNumber(header *types.Header) (uint64, error)
+	CertThreshold(qc *types.QuorumCert) int
+	MissingRounds(header *types.Header) (*utils.PublicApiMissedRoundsMetadata, error)
+	PoolStatus() XDPoS.MessageStatus
+	IsMasternode(header *types.Header) bool
+}
+
+// xdposBackend is the consensus backend of a full node running XDPoS.
+type xdposBackend struct {
+	eth    *eth.Ethereum
+	chain  *core.BlockChain
+	engine *XDPoS.XDPoS
+	api    *XDPoS.API
+}
+
+// newXDPoSBackend creates the consensus backend of a full node, returning nil
+// if the node doesn't run XDPoS.
+func newXDPoSBackend(ethServ *eth.Ethereum) *xdposBackend {
+	engine, ok := ethServ.Engine().(*XDPoS.XDPoS)
+	if !ok {
+		return nil
+	}
+	chain := ethServ.BlockChain()
+	for _, api := range engine.APIs(chain) {
+		if service, ok := api.Service.(*XDPoS.API); ok && api.Namespace == "XDPoS" {
+			return &xdposBackend{eth: ethServ, chain: chain, engine: engine, api: service}
+		}
+	}
+	log.Warn("XDPoS API not found, consensus stats disabled")
+	return nil
+}
+
+func (b *xdposBackend) IsV2(header *types.Header) bool {
+	return b.chain.Config().XDPoS.BlockConsensusVersion(header.Number, header.Extra, XDPoS.ExtraFieldCheck) == params.ConsensusEngineVersion2
+}
+
+func (b *xdposBackend) BlockInfo(header *types.Header) *XDPoS.V2BlockInfo {
+	return b.api.GetV2BlockByHeader(header, false)
+}
+
+func (b *xdposBackend) CurrentRound() types.Round {
+	return b.engine.EngineV2.GetCurrentRound()
+}
+
+func (b *xdposBackend) EpochNumber(header *types.Header) (uint64, error) {
+	_, epoch, err := b.engine.EngineV2.IsEpochSwitch(header)
+	return epoch, err
+}
+
+// CertThreshold returns the signatures needed to certify the block of the given
+// quorum certificate, from the masternodes of its epoch.
+func (b *xdposBackend) CertThreshold(qc *types.QuorumCert) int {
+	masternodes := b.engine.EngineV2.GetMasternodesByHash(b.chain, qc.ProposedBlockInfo.Hash)
+	certThreshold := b.chain.Config().XDPoS.V2.Config(uint64(qc.ProposedBlockInfo.Round)).CertThreshold
+	return int(math.Ceil(float64(len(masternodes)) * certThreshold))
+}
+
+func (b *xdposBackend) MissingRounds(header *types.Header) (*utils.PublicApiMissedRoundsMetadata, error) {
+	return b.engine.CalculateMissingRounds(b.chain, header)
+}
+
+func (b *xdposBackend) PoolStatus() XDPoS.MessageStatus {
+	return b.api.GetLatestPoolStatus()
+}
+
+func (b *xdposBackend) IsMasternode(header *types.Header) bool {
+	etherbase, err := b.eth.Etherbase()
+	if err != nil {
+		return false
+	}
+	for _, masternode := range b.engine.GetMasternodes(b.chain, header) {
+		if masternode == etherbase {
+			return true
+		}
+	}
+	return false
+}
+
+// consensusReporter assembles the consensus reports of the local node. Finding
+// the missed rounds walks the epoch up to the reported block, so they are kept
+// for the epoch and only looked up again once rounds were skipped since.
+type consensusReporter struct {
+	backend consensusBackend
+
+	missed       *utils.PublicApiMissedRoundsMetadata // Missed rounds of the last walked epoch
+	missedEpoch  uint64                               // Epoch of the cached missed rounds
+	missedNumber uint64                               // Number of the block the missed rounds are current for
+	missedRound  types.Round                          // Round of the block the missed rounds are current for
+}
+
+func newConsensusReporter(backend consensusBackend) *consensusReporter {
+	return &consensusReporter{backend: backend}
+}
+
+// missingRounds returns the rounds of the epoch of the given block which timed
+// out. Blocks following the cached one without skipping a round reuse the cache.
+func (r *consensusReporter) missingRounds(header *types.Header, round types.Round) (*utils.PublicApiMissedRoundsMetadata, error) {
+	epoch, err := r.backend.EpochNumber(header)
+	if err != nil {
+		return nil, err
+	}
+	number := header.Number.Uint64()
+	if r.missed != nil && epoch == r.missedEpoch && number >= r.missedNumber && round >= r.missedRound &&
+		uint64(round-r.missedRound) == number-r.missedNumber {
+		r.missedNumber, r.missedRound = number, round
+		return r.missed, nil
+	}
+	missed, err := r.backend.MissingRounds(header)
+	if err != nil {
+		return nil, err
+	}
+	r.missed, r.missedEpoch, r.missedNumber, r.missedRound = missed, epoch, number, round
+	return missed, nil
+}
+
+// assemble retrieves the XDPoS v2 consensus state of the given block, returning
+// nil if the block predates the switch to v2.
+func (r *consensusReporter) assemble(header *types.Header) (*consensusStats, error) {
+	if !r.backend.IsV2(header) {
+		return nil, nil
+	}
+	info := r.backend.BlockInfo(header)
+	if info.Error != "" {
+		return nil, errors.New(info.Error)
+	}
+	details := &consensusStats{
+		Number:       info.Number,
+		Hash:         info.Hash,
+		BlockRound:   uint64(info.Round),
+		Committed:    info.Committed,
+		Round:        uint64(r.backend.CurrentRound()),
+		MissedRounds: []missedRoundStats{},
+	}
+	// Count the signatures of the quorum certificate against the threshold of
+	// the epoch of the certified block
+	var extra types.ExtraFields_v2
+	if err := utils.DecodeBytesExtraFields(header.Extra, &extra); err != nil {
+		return nil, err
+	}
+	if qc := extra.QuorumCert; qc != nil {
+		details.QCSigners = len(qc.Signatures)
+		details.QCThreshold = r.backend.CertThreshold(qc)
+	}
+	// Gather the rounds of the epoch which timed out without a block
+	missed, err := r.missingRounds(header, extra.Round)
+	if err != nil {
+		return nil, err
+	}
+	details.EpochRound = uint64(missed.EpochRound)
+	details.EpochNumber = missed.EpochBlockNumber
+	details.Timeouts = len(missed.MissedRounds)
+	for _, round := range missed.MissedRounds {
+		details.MissedRounds = append(details.MissedRounds, missedRoundStats{
+			Round: uint64(round.Round),
+			Miner: round.Miner,
+		})
+	}
+	// Count the messages waiting in the pools for a certificate
+	status := r.backend.PoolStatus()
+	for _, signers := range status["vote"] {
+		details.PendingVotes += signers.CurrentNumber
+	}
+	for _, signers := range status["timeout"] {
+		details.PendingTimeouts += signers.CurrentNumber
+	}
+	details.Masternode = r.backend.IsMasternode(header)
+	return details, nil
+}
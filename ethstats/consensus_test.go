@@ -0,0 +1,195 @@
+// Copyright (c) 2018 XDPoSChain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package ethstats
+
+import (
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/XinFinOrg/XDPoSChain/common"
+	"github.com/XinFinOrg/XDPoSChain/consensus/XDPoS"
+	"github.com/XinFinOrg/XDPoSChain/consensus/XDPoS/utils"
+	"github.com/XinFinOrg/XDPoSChain/core/types"
+)
+
+// testConsensusBackend is a consensus backend serving a fixed consensus state.
+type testConsensusBackend struct {
+	v1          bool
+	info        *XDPoS.V2BlockInfo
+	round       types.Round
+	epoch       uint64
+	threshold   int
+	missed      *utils.PublicApiMissedRoundsMetadata
+	missedErr   error
+	pool        XDPoS.MessageStatus
+	masternode  bool
+	missedCalls int
+}
+
+func (b *testConsensusBackend) IsV2(*types.Header) bool                    { return !b.v1 }
+func (b *testConsensusBackend) BlockInfo(*types.Header) *XDPoS.V2BlockInfo { return b.info }
+func (b *testConsensusBackend) CurrentRound() types.Round                  { return b.round }
+func (b *testConsensusBackend) EpochNumber(*types.Header) (uint64, error) {
+	return b.epoch, nil
+}
+func (b *testConsensusBackend) CertThreshold(*types.QuorumCert) int { return b.threshold }
+func (b *testConsensusBackend) MissingRounds(*types.Header) (*utils.PublicApiMissedRoundsMetadata, error) {
+	b.missedCalls++
+	return b.missed, b.missedErr
+}
+func (b *testConsensusBackend) PoolStatus() XDPoS.MessageStatus { return b.pool }
+func (b *testConsensusBackend) IsMasternode(*types.Header) bool { return b.masternode }
+
+// newConsensusTestHeader creates a v2 header proposed in the given round,
+// carrying a quorum certificate with the given signatures, or none if negative.
+func newConsensusTestHeader(t *testing.T, number uint64, round types.Round, signatures int) *types.Header {
+	extra := types.ExtraFields_v2{Round: round}
+	if signatures >= 0 {
+		extra.QuorumCert = &types.QuorumCert{
+			ProposedBlockInfo: &types.BlockInfo{Hash: common.HexToHash("0x01"), Round: round - 1, Number: new(big.Int).SetUint64(number - 1)},
+			Signatures:        make([]types.Signature, signatures),
+		}
+	}
+	encoded, err := extra.EncodeToBytes()
+	if err != nil {
+		t.Fatalf("failed to encode extra fields: %v", err)
+	}
+	return &types.Header{Number: new(big.Int).SetUint64(number), Extra: encoded}
+}
+
+func TestAssembleConsensusStats(t *testing.T) {
+	var (
+		hash   = common.HexToHash("0xabcd")
+		miner  = common.HexToAddress("0x1234")
+		info   = &XDPoS.V2BlockInfo{Hash: hash, Round: 12, Number: big.NewInt(910), Committed: true}
+		epoch  = &utils.PublicApiMissedRoundsMetadata{EpochRound: 0, EpochBlockNumber: big.NewInt(900)}
+		missed = &utils.PublicApiMissedRoundsMetadata{
+			EpochRound:       0,
+			EpochBlockNumber: big.NewInt(900),
+			MissedRounds:     []utils.MissedRoundInfo{{Round: 5, Miner: miner}, {Round: 6, Miner: miner}},
+		}
+		pool = XDPoS.MessageStatus{
+			"vote":    {"a": {CurrentNumber: 2}, "b": {CurrentNumber: 1}},
+			"timeout": {"c": {CurrentNumber: 3}},
+		}
+	)
+	tests := []struct {
+		name    string
+		backend *testConsensusBackend
+		header  *types.Header
+		want    *consensusStats
+		wantErr bool
+	}{
+		{
+			name:    "v1 block",
+			backend: &testConsensusBackend{v1: true},
+			header:  &types.Header{Number: big.NewInt(10), Extra: make([]byte, 97)},
+		},
+		{
+			name:    "v2 block",
+			backend: &testConsensusBackend{info: info, round: 13, threshold: 3, missed: epoch, pool: XDPoS.MessageStatus{}, masternode: true},
+			header:  newConsensusTestHeader(t, 910, 12, 4),
+			want: &consensusStats{
+				Number: big.NewInt(910), Hash: hash, BlockRound: 12, Committed: true, Round: 13,
+				QCSigners: 4, QCThreshold: 3, EpochNumber: big.NewInt(900),
+				MissedRounds: []missedRoundStats{}, Masternode: true,
+			},
+		},
+		{
+			name:    "v2 block with unsigned quorum certificate",
+			backend: &testConsensusBackend{info: info, round: 12, threshold: 3, missed: epoch, pool: XDPoS.MessageStatus{}},
+			header:  newConsensusTestHeader(t, 910, 12, 0),
+			want: &consensusStats{
+				Number: big.NewInt(910), Hash: hash, BlockRound: 12, Committed: true, Round: 12,
+				QCThreshold: 3, EpochNumber: big.NewInt(900), MissedRounds: []missedRoundStats{},
+			},
+		},
+		{
+			name:    "v2 block without quorum certificate",
+			backend: &testConsensusBackend{info: info, round: 12, threshold: 3, missed: epoch, pool: XDPoS.MessageStatus{}},
+			header:  newConsensusTestHeader(t, 910, 12, -1),
+			wantErr: true,
+		},
+		{
+			name:    "v2 block after timeouts",
+			backend: &testConsensusBackend{info: info, round: 14, threshold: 3, missed: missed, pool: pool},
+			header:  newConsensusTestHeader(t, 910, 12, 3),
+			want: &consensusStats{
+				Number: big.NewInt(910), Hash: hash, BlockRound: 12, Committed: true, Round: 14,
+				QCSigners: 3, QCThreshold: 3, EpochNumber: big.NewInt(900), Timeouts: 2,
+				MissedRounds:    []missedRoundStats{{Round: 5, Miner: miner}, {Round: 6, Miner: miner}},
+				PendingVotes:    3,
+				PendingTimeouts: 3,
+			},
+		},
+		{
+			name:    "unknown committed block",
+			backend: &testConsensusBackend{info: &XDPoS.V2BlockInfo{Hash: hash, Error: "can not find latest committed block from consensus"}},
+			header:  newConsensusTestHeader(t, 910, 12, 3),
+			wantErr: true,
+		},
+		{
+			name:    "missed rounds failure",
+			backend: &testConsensusBackend{info: info, missedErr: errors.New("unknown epoch")},
+			header:  newConsensusTestHeader(t, 910, 12, 3),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newConsensusReporter(tt.backend).assemble(tt.header)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error mismatch: have %v, want error %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("stats mismatch:\nhave %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConsensusMissedRoundsCache(t *testing.T) {
+	backend := &testConsensusBackend{
+		info:   &XDPoS.V2BlockInfo{Number: big.NewInt(910)},
+		missed: &utils.PublicApiMissedRoundsMetadata{EpochBlockNumber: big.NewInt(900)},
+	}
+	reporter := newConsensusReporter(backend)
+	steps := []struct {
+		number uint64
+		round  types.Round
+		epoch  uint64
+		walks  int
+	}{
+		{910, 12, 1, 1}, // first report walks the epoch
+		{911, 13, 1, 1}, // no round skipped
+		{912, 14, 1, 1}, // no round skipped
+		{913, 16, 1, 2}, // round 15 timed out
+		{914, 17, 1, 2}, // no round skipped
+		{914, 18, 1, 3}, // sibling of a reported block
+		{915, 19, 2, 4}, // new epoch
+	}
+	for i, step := range steps {
+		backend.epoch = step.epoch
+		if _, err := reporter.assemble(newConsensusTestHeader(t, step.number, step.round, 1)); err != nil {
+			t.Fatalf("step %d: failed to assemble stats: %v", i, err)
+		}
+		if backend.missedCalls != step.walks {
+			t.Errorf("step %d: epoch walks mismatch: have %d, want %d", i, backend.missedCalls, step.walks)
+		}
+	}
+}
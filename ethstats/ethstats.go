@@ -77,10 +77,11 @@ type blockChain interface {
 // Service implements an Ethereum netstats reporting daemon that pushes local
 // chain statistics up to a monitoring server.
 type Service struct {
-	server *p2p.Server        // Peer-to-peer server to retrieve networking infos
-	eth    *eth.Ethereum      // Full Ethereum service if monitoring a full node
-	les    *les.LightEthereum // Light Ethereum service if monitoring a light node
-	engine consensus.Engine   // Consensus engine to retrieve variadic block fields
+	server    *p2p.Server        // Peer-to-peer server to retrieve networking infos
+	eth       *eth.Ethereum      // Full Ethereum service if monitoring a full node
+	les       *les.LightEthereum // Light Ethereum service if monitoring a light node
+	engine    consensus.Engine   // Consensus engine to retrieve variadic block fields
+	consensus *consensusReporter // XDPoS v2 consensus state reporter if monitoring a full node
 
 	node string // Name of the node to display on the monitoring page
 	pass string // Password to authorize access to the monitoring page
@@ -145,21 +146,28 @@ func New(url string, ethServ *eth.Ethereum, lesServ *les.LightEthereum) (*Servic
 		return nil, fmt.Errorf("invalid netstats url: \"%s\", should be nodename:secret@host:port", url)
 	}
 	// Assemble and return the stats service
-	var engine consensus.Engine
+	var (
+		engine   consensus.Engine
+		reporter *consensusReporter
+	)
 	if ethServ != nil {
 		engine = ethServ.Engine()
+		if backend := newXDPoSBackend(ethServ); backend != nil {
+			reporter = newConsensusReporter(backend)
+		}
 	} else {
 		engine = lesServ.Engine()
 	}
 	return &Service{
-		eth:    ethServ,
-		les:    lesServ,
-		engine: engine,
-		node:   parts[1],
-		pass:   parts[3],
-		host:   parts[4],
-		pongCh: make(chan struct{}),
-		histCh: make(chan []uint64, 1),
+		eth:       ethServ,
+		les:       lesServ,
+		engine:    engine,
+		consensus: reporter,
+		node:      parts[1],
+		pass:      parts[3],
+		host:      parts[4],
+		pongCh:    make(chan struct{}),
+		histCh:    make(chan []uint64, 1),
 	}, nil
 }
 
@@ -331,6 +339,9 @@ func (s *Service) loop() {
 				if err = s.reportBlock(conn, head); err != nil {
 					log.Warn("Block stats report failed", "err", err)
 				}
+				if err = s.reportConsensus(conn, head); err != nil {
+					log.Warn("Consensus stats report failed", "err", err)
+				}
 				if err = s.reportPending(conn); err != nil {
 					log.Warn("Post-block transaction stats report failed", "err", err)
 				}
@@ -511,6 +522,9 @@ func (s *Service) report(conn *connWrapper) error {
 	if err := s.reportBlock(conn, nil); err != nil {
 		return err
 	}
+	if err := s.reportConsensus(conn, nil); err != nil {
+		return err
+	}
 	if err := s.reportPending(conn); err != nil {
 		return err
 	}
@@ -627,6 +641,37 @@ func (s *Service) reportBlock(conn *connWrapper, block *types.Block) error {
 	return conn.WriteJSON(report)
 }
 
+// reportConsensus retrieves the XDPoS v2 consensus state of the given block, or
+// of the current head if nil, and reports it to the stats server. Nothing is
+// reported by light nodes or before the switch to v2.
+func (s *Service) reportConsensus(conn *connWrapper, block *types.Block) error {
+	if s.consensus == nil {
+		return nil
+	}
+	if block == nil {
+		block = s.eth.BlockChain().CurrentBlock()
+	}
+	details, err := s.consensus.assemble(block.Header())
+	if err != nil {
+		log.Debug("Failed to assemble consensus stats", "number", block.Number(), "hash", block.Hash(), "err", err)
+		return nil
+	}
+	if details == nil {
+		return nil
+	}
+	// Assemble the consensus report and send it to the server
+	log.Trace("Sending consensus state to ethstats", "number", details.Number, "round", details.Round)
+
+	stats := map[string]interface{}{
+		"id":        s.node,
+		"consensus": details,
+	}
+	report := map[string][]interface{}{
+		"emit": {"consensus", stats},
+	}
+	return conn.WriteJSON(report)
+}
+
 // reportForensics forward the forensics repors it to the stats server.
 func (s *Service) reportForensics(conn *connWrapper, forensicsProof *types.ForensicProof) error {
 	log.Info("Sending Forensics report to ethstats", "ForensicsType", forensicsProof.ForensicsType)
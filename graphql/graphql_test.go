@@ -0,0 +1,324 @@
+// Copyright (c) 2018 XDPoSChain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/XinFinOrg/XDPoSChain/common"
+	"github.com/XinFinOrg/XDPoSChain/consensus"
+	"github.com/XinFinOrg/XDPoSChain/consensus/ethash"
+	"github.com/XinFinOrg/XDPoSChain/core"
+	"github.com/XinFinOrg/XDPoSChain/core/rawdb"
+	"github.com/XinFinOrg/XDPoSChain/core/state"
+	"github.com/XinFinOrg/XDPoSChain/core/types"
+	"github.com/XinFinOrg/XDPoSChain/core/vm"
+	"github.com/XinFinOrg/XDPoSChain/crypto"
+	"github.com/XinFinOrg/XDPoSChain/eth/filters"
+	"github.com/XinFinOrg/XDPoSChain/ethdb"
+	"github.com/XinFinOrg/XDPoSChain/internal/ethapi"
+	"github.com/XinFinOrg/XDPoSChain/params"
+	"github.com/XinFinOrg/XDPoSChain/rpc"
+)
+
+var (
+	testKey, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	testAddress = crypto.PubkeyToAddress(testKey.PublicKey)
+	testRecvr   = common.HexToAddress("0x00000000000000000000000000000000000000b0")
+
+	// logCode is the init code of a contract emitting an empty log on creation.
+	logCode = []byte{byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.LOG0), byte(vm.STOP)}
+)
+
+// testBackend is an ethapi.Backend serving a local chain, the methods the
+// resolvers don't use panic.
+type testBackend struct {
+	ethapi.Backend
+	db     ethdb.Database
+	chain  *core.BlockChain
+	config *params.ChainConfig
+}
+
+func (b *testBackend) ChainDb() ethdb.Database          { return b.db }
+func (b *testBackend) ChainConfig() *params.ChainConfig { return b.config }
+func (b *testBackend) CurrentBlock() *types.Block       { return b.chain.CurrentBlock() }
+func (b *testBackend) GetEngine() consensus.Engine      { return b.chain.Engine() }
+func (b *testBackend) GetTd(hash common.Hash) *big.Int  { return b.chain.GetTdByHash(hash) }
+
+func (b *testBackend) GetPoolTransaction(hash common.Hash) *types.Transaction { return nil }
+
+func (b *testBackend) GetPoolTransactions() (types.Transactions, error) { return nil, nil }
+
+func (b *testBackend) HeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Header, error) {
+	if number == rpc.LatestBlockNumber {
+		return b.chain.CurrentHeader(), nil
+	}
+	return b.chain.GetHeaderByNumber(uint64(number)), nil
+}
+
+func (b *testBackend) HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error) {
+	return b.chain.GetHeaderByHash(hash), nil
+}
+
+func (b *testBackend) HeaderByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*types.Header, error) {
+	if hash, ok := blockNrOrHash.Hash(); ok {
+		return b.HeaderByHash(ctx, hash)
+	}
+	number, _ := blockNrOrHash.Number()
+	return b.HeaderByNumber(ctx, number)
+}
+
+func (b *testBackend) BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	return b.chain.GetBlockByHash(hash), nil
+}
+
+func (b *testBackend) BlockByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*types.Block, error) {
+	header, err := b.HeaderByNumberOrHash(ctx, blockNrOrHash)
+	if header == nil || err != nil {
+		return nil, err
+	}
+	return b.chain.GetBlock(header.Hash(), header.Number.Uint64()), nil
+}
+
+func (b *testBackend) StateAndHeaderByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*state.StateDB, *types.Header, error) {
+	header, err := b.HeaderByNumberOrHash(ctx, blockNrOrHash)
+	if header == nil || err != nil {
+		return nil, nil, err
+	}
+	statedb, err := b.chain.StateAt(header.Root)
+	return statedb, header, err
+}
+
+func (b *testBackend) GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
+	return b.chain.GetReceiptsByHash(hash), nil
+}
+
+func (b *testBackend) GetLogs(ctx context.Context, hash common.Hash, number uint64) ([][]*types.Log, error) {
+	return rawdb.ReadLogs(b.db, hash, number), nil
+}
+
+func (b *testBackend) GetBody(ctx context.Context, hash common.Hash, number rpc.BlockNumber) (*types.Body, error) {
+	return b.chain.GetBody(hash), nil
+}
+
+// newTestBackend creates a chain with a contract creation emitting a log in
+// the first block and a transfer in the second one.
+func newTestBackend(t *testing.T) *testBackend {
+	var (
+		db     = rawdb.NewMemoryDatabase()
+		config = params.TestChainConfig
+		gspec  = &core.Genesis{
+			Config: config,
+			Alloc:  core.GenesisAlloc{testAddress: {Balance: big.NewInt(params.Ether)}},
+		}
+		genesis = gspec.MustCommit(db)
+		signer  = types.LatestSigner(config)
+	)
+	blocks, _ := core.GenerateChain(config, genesis, ethash.NewFaker(), db, 3, func(i int, gen *core.BlockGen) {
+		var tx *types.Transaction
+		switch i {
+		case 0:
+			tx = types.NewContractCreation(gen.TxNonce(testAddress), new(big.Int), 100000, big.NewInt(1), logCode)
+		case 1:
+			tx = types.NewTransaction(gen.TxNonce(testAddress), testRecvr, big.NewInt(1000), params.TxGas, big.NewInt(1), nil)
+		default:
+			return
+		}
+		signed, err := types.SignTx(tx, signer, testKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gen.AddTx(signed)
+	})
+	chain, err := core.NewBlockChain(db, nil, config, ethash.NewFaker(), vm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatal(err)
+	}
+	return &testBackend{db: db, chain: chain, config: config}
+}
+
+// query runs a GraphQL query through the HTTP handler and decodes its data.
+func query(t *testing.T, handler http.Handler, q string, result interface{}) {
+	t.Helper()
+	body, _ := json.Marshal(map[string]string{"query": q})
+	req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(body)))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	var resp struct {
+		Data   json.RawMessage
+		Errors []struct{ Message string }
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid response %q: %v", rec.Body.String(), err)
+	}
+	if len(resp.Errors) > 0 {
+		t.Fatalf("query failed: %v", resp.Errors)
+	}
+	if err := json.Unmarshal(resp.Data, result); err != nil {
+		t.Fatalf("invalid data %s: %v", resp.Data, err)
+	}
+}
+
+func TestBlockQueries(t *testing.T) {
+	backend := newTestBackend(t)
+	handler, err := newHandler(backend, backend.chain, filters.NewFilterSystem(backend, filters.Config{}))
+	if err != nil {
+		t.Fatalf("failed to create the handler: %v", err)
+	}
+	var result struct {
+		Block struct {
+			Number           int64
+			Hash             common.Hash
+			TransactionCount int
+			Parent           struct{ Number int64 }
+			Transactions     []struct {
+				Hash   common.Hash
+				Status int64
+				From   struct{ Address common.Address }
+				To     struct {
+					Address common.Address
+					Balance string
+				}
+			}
+		}
+		Blocks []struct{ Number int64 }
+	}
+	query(t, handler, `{
+		block(number: "0x2") {
+			number hash transactionCount parent { number }
+			transactions { hash status from { address } to { address balance } }
+		}
+		blocks(from: 1) { number }
+	}`, &result)
+
+	block := backend.chain.GetBlockByNumber(2)
+	if result.Block.Number != 2 || result.Block.Hash != block.Hash() || result.Block.Parent.Number != 1 {
+		t.Errorf("block mismatch: %+v", result.Block)
+	}
+	if result.Block.TransactionCount != 1 || len(result.Block.Transactions) != 1 {
+		t.Fatalf("transactions mismatch: %+v", result.Block)
+	}
+	tx := result.Block.Transactions[0]
+	if tx.Hash != block.Transactions()[0].Hash() || tx.Status != 1 || tx.From.Address != testAddress {
+		t.Errorf("transaction mismatch: %+v", tx)
+	}
+	if tx.To.Address != testRecvr || tx.To.Balance != "0x3e8" {
+		t.Errorf("recipient mismatch: %+v", tx.To)
+	}
+	if len(result.Blocks) != 3 || result.Blocks[0].Number != 1 || result.Blocks[2].Number != 3 {
+		t.Errorf("block range mismatch: %+v", result.Blocks)
+	}
+}
+
+func TestTransactionAndLogQueries(t *testing.T) {
+	backend := newTestBackend(t)
+	handler, err := newHandler(backend, backend.chain, filters.NewFilterSystem(backend, filters.Config{}))
+	if err != nil {
+		t.Fatalf("failed to create the handler: %v", err)
+	}
+	creation := backend.chain.GetBlockByNumber(1).Transactions()[0]
+	var result struct {
+		Transaction struct {
+			Index           int
+			GasUsed         int64
+			Block           struct{ Number int64 }
+			CreatedContract struct{ Address common.Address }
+			Logs            []struct {
+				Index       int
+				Account     struct{ Address common.Address }
+				Transaction struct{ Hash common.Hash }
+			}
+		}
+		Block struct {
+			Logs []struct {
+				Account struct{ Address common.Address }
+			}
+		}
+		Missing *struct{ Hash common.Hash }
+	}
+	query(t, handler, `{
+		transaction(hash: "`+creation.Hash().Hex()+`") {
+			index gasUsed block { number } createdContract { address }
+			logs { index account { address } transaction { hash } }
+		}
+		block(number: 1) { logs(filter: {}) { account { address } } }
+		missing: transaction(hash: "0x0000000000000000000000000000000000000000000000000000000000000001") { hash }
+	}`, &result)
+
+	contract := crypto.CreateAddress(testAddress, 0)
+	tx := result.Transaction
+	if tx.Index != 0 || tx.Block.Number != 1 || tx.GasUsed == 0 || tx.CreatedContract.Address != contract {
+		t.Errorf("transaction mismatch: %+v", tx)
+	}
+	if len(tx.Logs) != 1 || tx.Logs[0].Account.Address != contract || tx.Logs[0].Transaction.Hash != creation.Hash() {
+		t.Errorf("transaction logs mismatch: %+v", tx.Logs)
+	}
+	if logs := result.Block.Logs; len(logs) != 1 || logs[0].Account.Address != contract {
+		t.Errorf("block logs mismatch: %+v", logs)
+	}
+	if result.Missing != nil {
+		t.Errorf("unknown transaction resolved: %+v", result.Missing)
+	}
+}
+
+func TestXDPoSV2Fields(t *testing.T) {
+	config := *params.TestChainConfig
+	config.XDPoS = &params.XDPoSConfig{V2: &params.V2{SwitchBlock: big.NewInt(10)}}
+	r := &Resolver{backend: &testBackend{config: &config}}
+
+	qc := &types.QuorumCert{
+		ProposedBlockInfo: &types.BlockInfo{Hash: common.HexToHash("0x01"), Round: 6, Number: big.NewInt(11)},
+		Signatures:        []types.Signature{{0x01}, {0x02}},
+		GapNumber:         450,
+	}
+	extra, err := (&types.ExtraFields_v2{Round: 7, QuorumCert: qc}).EncodeToBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := &types.Header{Number: big.NewInt(12), Extra: extra}
+	block := &Block{r: r, header: header, hash: header.Hash()}
+
+	round, err := block.Round(context.Background())
+	if err != nil || round == nil || *round != 7 {
+		t.Fatalf("round mismatch: %v, %v", round, err)
+	}
+	cert, err := block.QuorumCert(context.Background())
+	if err != nil || cert == nil {
+		t.Fatalf("quorum certificate missing: %v", err)
+	}
+	if cert.BlockHash() != qc.ProposedBlockInfo.Hash || cert.Round() != 6 || cert.Number() != 11 || cert.GapNumber() != 450 || len(cert.Signatures()) != 2 {
+		t.Errorf("quorum certificate mismatch: %+v", cert.qc)
+	}
+	// The blocks before the switch have no v2 fields
+	header = &types.Header{Number: big.NewInt(10), Extra: make([]byte, 32)}
+	block = &Block{r: r, header: header, hash: header.Hash()}
+	if round, err := block.Round(context.Background()); err != nil || round != nil {
+		t.Errorf("v1 block has a round: %v, %v", round, err)
+	}
+	if committed, err := block.Committed(context.Background()); err != nil || committed != nil {
+		t.Errorf("v1 block has a commit status: %v, %v", committed, err)
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package graphql
+
+import (
+	"net/http"
+
+	"github.com/XinFinOrg/XDPoSChain/consensus"
+	"github.com/XinFinOrg/XDPoSChain/eth/filters"
+	"github.com/XinFinOrg/XDPoSChain/internal/ethapi"
+	"github.com/XinFinOrg/XDPoSChain/p2p"
+	"github.com/XinFinOrg/XDPoSChain/rpc"
+	"github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/relay"
+)
+
+// Service encapsulates a GraphQL service, served by the node on the HTTP RPC
+// endpoint at /graphql.
+type Service struct {
+	handler http.Handler // The handler of the GraphQL requests
+}
+
+// New constructs a new GraphQL service resolving the queries with the backend.
+func New(backend ethapi.Backend, chain consensus.ChainReader, filterSystem *filters.FilterSystem) (*Service, error) {
+	handler, err := newHandler(backend, chain, filterSystem)
+	if err != nil {
+		return nil, err
+	}
+	return &Service{handler: handler}, nil
+}
+
+// newHandler returns a new `http.Handler` that will answer GraphQL queries.
+func newHandler(backend ethapi.Backend, chain consensus.ChainReader, filterSystem *filters.FilterSystem) (http.Handler, error) {
+	q := Resolver{
+		backend:      backend,
+		chain:        chain,
+		filterSystem: filterSystem,
+		blockChain:   ethapi.NewPublicBlockChainAPI(backend, chain),
+	}
+	s, err := graphql.ParseSchema(schema, &q)
+	if err != nil {
+		return nil, err
+	}
+	return &relay.Handler{Schema: s}, nil
+}
+
+// Protocols returns the list of protocols exported by this service.
+func (s *Service) Protocols() []p2p.Protocol { return nil }
+
+// APIs returns the list of APIs exported by this service.
+func (s *Service) APIs() []rpc.API { return nil }
+
+// HTTPHandlers returns the GraphQL handler, served at /graphql.
+func (s *Service) HTTPHandlers() map[string]http.Handler {
+	return map[string]http.Handler{"/graphql": s.handler}
+}
+
+// Start is called after all services have been constructed and the networking
+// layer was also initialized to spawn any goroutines required by the service.
+func (s *Service) Start(server *p2p.Server) error { return nil }
+
+// SaveData is a noop, the service has no state.
+func (s *Service) SaveData() {}
+
+// Stop terminates all goroutines belonging to this service, blocking until they
+// are all terminated.
+func (s *Service) Stop() error { return nil }
@@ -0,0 +1,211 @@
+// Copyright (c) 2018 XDPoSChain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package graphql
+
+import (
+	"context"
+
+	"github.com/XinFinOrg/XDPoSChain/common"
+	"github.com/XinFinOrg/XDPoSChain/common/hexutil"
+	"github.com/XinFinOrg/XDPoSChain/consensus/XDPoS"
+	"github.com/XinFinOrg/XDPoSChain/consensus/XDPoS/utils"
+	"github.com/XinFinOrg/XDPoSChain/core/types"
+	"github.com/XinFinOrg/XDPoSChain/params"
+	"github.com/XinFinOrg/XDPoSChain/rpc"
+)
+
+// QuorumCert represents the XDPoS v2 quorum certificate carried by a block.
+type QuorumCert struct {
+	qc *types.QuorumCert
+}
+
+func (q *QuorumCert) BlockHash() common.Hash {
+	return q.qc.ProposedBlockInfo.Hash
+}
+
+func (q *QuorumCert) Round() Long {
+	return Long(q.qc.ProposedBlockInfo.Round)
+}
+
+func (q *QuorumCert) Number() Long {
+	return Long(q.qc.ProposedBlockInfo.Number.Uint64())
+}
+
+func (q *QuorumCert) GapNumber() Long {
+	return Long(q.qc.GapNumber)
+}
+
+func (q *QuorumCert) Signatures() []hexutil.Bytes {
+	signatures := make([]hexutil.Bytes, len(q.qc.Signatures))
+	for i, signature := range q.qc.Signatures {
+		signatures[i] = hexutil.Bytes(signature)
+	}
+	return signatures
+}
+
+// Epoch represents the XDPoS epoch of a block.
+type Epoch struct {
+	r           *Resolver
+	engine      *XDPoS.XDPoS
+	header      *types.Header // header of the block the epoch was queried for
+	number      uint64
+	switchBlock uint64
+}
+
+func (e *Epoch) Number() Long {
+	return Long(e.number)
+}
+
+func (e *Epoch) SwitchBlock() *Block {
+	numberOrHash := rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(e.switchBlock))
+	return &Block{
+		r:            e.r,
+		numberOrHash: &numberOrHash,
+	}
+}
+
+func (e *Epoch) Masternodes() []common.Address {
+	return e.engine.GetMasternodes(e.r.chain, e.header)
+}
+
+func (e *Epoch) Penalties() []common.Address {
+	header := e.r.chain.GetHeaderByNumber(e.switchBlock)
+	if header == nil {
+		return []common.Address{}
+	}
+	return common.ExtractAddressFromBytes(header.Penalties)
+}
+
+// engine returns the XDPoS consensus engine, nil if the chain runs another one.
+func (r *Resolver) engine() *XDPoS.XDPoS {
+	engine, _ := r.backend.GetEngine().(*XDPoS.XDPoS)
+	return engine
+}
+
+// extraFields returns the XDPoS v2 extra fields of the block, nil for the
+// blocks mined before the v2 switch.
+func (b *Block) extraFields(ctx context.Context) (*types.ExtraFields_v2, error) {
+	header, err := b.resolveHeader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	config := b.r.backend.ChainConfig().XDPoS
+	if config == nil || config.BlockConsensusVersion(header.Number, header.Extra, XDPoS.ExtraFieldCheck) != params.ConsensusEngineVersion2 {
+		return nil, nil
+	}
+	var fields types.ExtraFields_v2
+	if err := utils.DecodeBytesExtraFields(header.Extra, &fields); err != nil {
+		return nil, err
+	}
+	return &fields, nil
+}
+
+func (b *Block) Validator(ctx context.Context) (hexutil.Bytes, error) {
+	header, err := b.resolveHeader(ctx)
+	if err != nil {
+		return hexutil.Bytes{}, err
+	}
+	return header.Validator, nil
+}
+
+func (b *Block) Validators(ctx context.Context) (hexutil.Bytes, error) {
+	header, err := b.resolveHeader(ctx)
+	if err != nil {
+		return hexutil.Bytes{}, err
+	}
+	return header.Validators, nil
+}
+
+func (b *Block) Penalties(ctx context.Context) ([]common.Address, error) {
+	header, err := b.resolveHeader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return common.ExtractAddressFromBytes(header.Penalties), nil
+}
+
+func (b *Block) Signers(ctx context.Context) ([]common.Address, error) {
+	hash, err := b.Hash(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return b.r.blockChain.GetBlockSignersByHash(ctx, hash)
+}
+
+func (b *Block) Finality(ctx context.Context) (int32, error) {
+	hash, err := b.Hash(ctx)
+	if err != nil {
+		return 0, err
+	}
+	finality, err := b.r.blockChain.GetBlockFinalityByHash(ctx, hash)
+	return int32(finality), err
+}
+
+func (b *Block) Round(ctx context.Context) (*Long, error) {
+	fields, err := b.extraFields(ctx)
+	if err != nil || fields == nil {
+		return nil, err
+	}
+	round := Long(fields.Round)
+	return &round, nil
+}
+
+func (b *Block) QuorumCert(ctx context.Context) (*QuorumCert, error) {
+	fields, err := b.extraFields(ctx)
+	if err != nil || fields == nil || fields.QuorumCert == nil || fields.QuorumCert.ProposedBlockInfo == nil {
+		return nil, err
+	}
+	return &QuorumCert{fields.QuorumCert}, nil
+}
+
+func (b *Block) Committed(ctx context.Context) (*bool, error) {
+	fields, err := b.extraFields(ctx)
+	if err != nil || fields == nil {
+		return nil, err
+	}
+	engine := b.r.engine()
+	if engine == nil {
+		return nil, nil
+	}
+	latest := engine.EngineV2.GetLatestCommittedBlockInfo()
+	if latest == nil {
+		return nil, nil
+	}
+	// Only the blocks of the canonical chain are committed
+	number := b.header.Number.Uint64()
+	canonical := b.r.chain.GetHeaderByNumber(number)
+	committed := canonical != nil && canonical.Hash() == b.hash && number <= latest.Number.Uint64()
+	return &committed, nil
+}
+
+func (b *Block) Epoch(ctx context.Context) (*Epoch, error) {
+	header, err := b.resolveHeader(ctx)
+	engine := b.r.engine()
+	if err != nil || engine == nil {
+		return nil, err
+	}
+	switchBlock, number, err := engine.GetCurrentEpochSwitchBlock(b.r.chain, header.Number)
+	if err != nil {
+		return nil, err
+	}
+	return &Epoch{
+		r:           b.r,
+		engine:      engine,
+		header:      header,
+		number:      number,
+		switchBlock: switchBlock,
+	}, nil
+}
@@ -0,0 +1,192 @@
+// Copyright (c) 2018 XDPoSChain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+// Package health serves the health and readiness checks of a node on its HTTP
+// RPC endpoint, for load balancers and orchestrators.
+//
+// GET /health reports whether the services of the node work: XDCx and lending
+// have the state of the head block, and SDK nodes reach MongoDB. GET /ready
+// additionally reports whether the node follows the chain closely enough to
+// serve requests, against the thresholds of the Config. Both answer 200 when
+// all their checks pass and 503 otherwise, with the details of all the checks
+// in the body:
+//
+//	{
+//	  "status": "fail",          // "ok" or "fail", the outcome of the endpoint
+//	  "head": 81234567,          // Number of the head block
+//	  "checks": {
+//	    "peers":     {"status": "ok", "value": 23, "threshold": 3},
+//	    "headAge":   {"status": "ok", "value": 2, "threshold": 60},
+//	    "syncLag":   {"status": "ok", "value": 0, "threshold": 30},
+//	    "commitLag": {"status": "fail", "value": 45, "threshold": 20},
+//	    "xdcx":      {"status": "ok"},
+//	    "lending":   {"status": "ok"},
+//	    "mongodb":   {"status": "fail", "error": "no reachable servers"}
+//	  }
+//	}
+//
+// The checks are:
+//
+//	peers      connected peers, at least the threshold
+//	headAge    seconds since the head block was sealed, at most the threshold
+//	syncLag    blocks between the head and the highest block known to the
+//	           downloader, at most the threshold
+//	commitLag  blocks between the head and the latest block committed by XDPoS
+//	           v2, at most the threshold; missing before the switch to v2
+//	xdcx       the XDCx trading state of the head is available; missing before
+//	           XDCx is enabled
+//	lending    the XDCx lending state of the head is available; missing before
+//	           XDCx is enabled
+//	mongodb    the MongoDB database of the XDCx SDK node answers; missing on
+//	           other nodes
+//
+// The last three are the checks of /health, all of them the ones of /ready.
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/XinFinOrg/XDPoSChain"
+	"github.com/XinFinOrg/XDPoSChain/core/types"
+	"github.com/XinFinOrg/XDPoSChain/log"
+)
+
+const (
+	statusOK   = "ok"
+	statusFail = "fail"
+)
+
+// Config contains the thresholds of the readiness checks.
+type Config struct {
+	MinPeers        int           // Minimum number of connected peers
+	MaxHeadAge      time.Duration // Maximum age of the head block
+	MaxBlocksBehind uint64        // Maximum distance of the head to the highest known block
+	MaxCommitLag    uint64        // Maximum distance of the head to the latest XDPoS v2 committed block
+}
+
+// DefaultConfig contains the default thresholds of the readiness checks.
+var DefaultConfig = Config{
+	MinPeers:        1,
+	MaxHeadAge:      time.Minute,
+	MaxBlocksBehind: 30,
+	MaxCommitLag:    20,
+}
+
+// Backend provides the state of the node the checks are performed on.
+type Backend interface {
+	// PeerCount returns the number of connected peers.
+	PeerCount() int
+
+	// CurrentHeader returns the head of the canonical chain.
+	CurrentHeader() *types.Header
+
+	// SyncProgress returns the progress of the chain synchronisation.
+	SyncProgress() XDPoSChain.SyncProgress
+
+	// CommittedBlock returns the latest block committed by XDPoS v2, nil before
+	// the switch to v2.
+	CommittedBlock() *types.BlockInfo
+
+	// Services checks the services of the node, returning the outcome of the
+	// checks performed by service name.
+	Services() map[string]error
+}
+
+// Check is the outcome of a single check. Threshold checks carry the measured
+// value and the threshold, service checks the reason of their failure.
+type Check struct {
+	Status    string      `json:"status"`
+	Value     interface{} `json:"value,omitempty"`
+	Threshold interface{} `json:"threshold,omitempty"`
+	Error     string      `json:"error,omitempty"`
+}
+
+// Report is the body of the answers of the endpoints.
+type Report struct {
+	Status string            `json:"status"`
+	Head   uint64            `json:"head"`
+	Checks map[string]*Check `json:"checks"`
+}
+
+// handler serves the health or the readiness checks.
+type handler struct {
+	config  Config
+	backend Backend
+	ready   bool // Whether the readiness checks are served
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	report := h.report()
+
+	w.Header().Set("Content-Type", "application/json")
+	if report.Status != statusOK {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		log.Debug("Failed to write health report", "err", err)
+	}
+}
+
+// report performs the checks, failing the report if any of the service checks
+// or, for the readiness checks, of the threshold checks fails.
+func (h *handler) report() *Report {
+	head := h.backend.CurrentHeader()
+	report := &Report{
+		Status: statusOK,
+		Head:   head.Number.Uint64(),
+		Checks: make(map[string]*Check),
+	}
+	threshold := func(name string, ok bool, value, threshold interface{}) {
+		check := &Check{Status: statusOK, Value: value, Threshold: threshold}
+		if !ok {
+			check.Status = statusFail
+			if h.ready {
+				report.Status = statusFail
+			}
+		}
+		report.Checks[name] = check
+	}
+	peers := h.backend.PeerCount()
+	threshold("peers", peers >= h.config.MinPeers, peers, h.config.MinPeers)
+
+	age := time.Since(time.Unix(head.Time.Int64(), 0))
+	threshold("headAge", age <= h.config.MaxHeadAge, int64(age/time.Second), int64(h.config.MaxHeadAge/time.Second))
+
+	var behind uint64
+	if progress := h.backend.SyncProgress(); progress.HighestBlock > report.Head {
+		behind = progress.HighestBlock - report.Head
+	}
+	threshold("syncLag", behind <= h.config.MaxBlocksBehind, behind, h.config.MaxBlocksBehind)
+
+	if committed := h.backend.CommittedBlock(); committed != nil {
+		var lag uint64
+		if number := committed.Number.Uint64(); number < report.Head {
+			lag = report.Head - number
+		}
+		threshold("commitLag", lag <= h.config.MaxCommitLag, lag, h.config.MaxCommitLag)
+	}
+
+	for name, err := range h.backend.Services() {
+		check := &Check{Status: statusOK}
+		if err != nil {
+			check.Status, check.Error = statusFail, err.Error()
+			report.Status = statusFail
+		}
+		report.Checks[name] = check
+	}
+	return report
+}
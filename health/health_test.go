@@ -0,0 +1,138 @@
+// Copyright (c) 2018 XDPoSChain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package health
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/XinFinOrg/XDPoSChain"
+	"github.com/XinFinOrg/XDPoSChain/core/types"
+)
+
+type testBackend struct {
+	peers     int
+	head      *types.Header
+	highest   uint64
+	committed *types.BlockInfo
+	services  map[string]error
+}
+
+func (b *testBackend) PeerCount() int                   { return b.peers }
+func (b *testBackend) CurrentHeader() *types.Header     { return b.head }
+func (b *testBackend) CommittedBlock() *types.BlockInfo { return b.committed }
+func (b *testBackend) Services() map[string]error       { return b.services }
+
+func (b *testBackend) SyncProgress() XDPoSChain.SyncProgress {
+	return XDPoSChain.SyncProgress{CurrentBlock: b.head.Number.Uint64(), HighestBlock: b.highest}
+}
+
+func newTestBackend() *testBackend {
+	return &testBackend{
+		peers:     5,
+		head:      &types.Header{Number: big.NewInt(1000), Time: big.NewInt(time.Now().Unix())},
+		highest:   1000,
+		committed: &types.BlockInfo{Number: big.NewInt(998)},
+		services:  map[string]error{"xdcx": nil, "lending": nil},
+	}
+}
+
+// serve queries the health or the readiness checks of the backend.
+func serve(t *testing.T, backend Backend, ready bool) (int, *Report) {
+	h := &handler{config: DefaultConfig, backend: backend, ready: ready}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("content type mismatch: have %q, want %q", ct, "application/json")
+	}
+	report := new(Report)
+	if err := json.Unmarshal(rec.Body.Bytes(), report); err != nil {
+		t.Fatalf("invalid report %q: %v", rec.Body.String(), err)
+	}
+	return rec.Code, report
+}
+
+func TestHealthy(t *testing.T) {
+	backend := newTestBackend()
+	for _, ready := range []bool{false, true} {
+		code, report := serve(t, backend, ready)
+		if code != http.StatusOK || report.Status != statusOK {
+			t.Fatalf("ready %v: have %d %q, want 200 %q", ready, code, report.Status, statusOK)
+		}
+		if report.Head != 1000 {
+			t.Errorf("head mismatch: have %d, want %d", report.Head, 1000)
+		}
+		for _, name := range []string{"peers", "headAge", "syncLag", "commitLag", "xdcx", "lending"} {
+			if check := report.Checks[name]; check == nil || check.Status != statusOK {
+				t.Errorf("ready %v: check %s not passed: %+v", ready, name, check)
+			}
+		}
+		if lag := report.Checks["commitLag"].Value; lag != float64(2) {
+			t.Errorf("commit lag mismatch: have %v, want 2", lag)
+		}
+	}
+}
+
+func TestNotReady(t *testing.T) {
+	tests := map[string]func(*testBackend){
+		"peers":     func(b *testBackend) { b.peers = 0 },
+		"headAge":   func(b *testBackend) { b.head.Time = big.NewInt(time.Now().Add(-time.Hour).Unix()) },
+		"syncLag":   func(b *testBackend) { b.highest = 1100 },
+		"commitLag": func(b *testBackend) { b.committed.Number = big.NewInt(900) },
+	}
+	for name, setup := range tests {
+		backend := newTestBackend()
+		setup(backend)
+
+		// The node is healthy, but not ready to serve
+		if code, report := serve(t, backend, false); code != http.StatusOK {
+			t.Errorf("%s: health failed: %d %+v", name, code, report.Checks[name])
+		}
+		code, report := serve(t, backend, true)
+		if code != http.StatusServiceUnavailable || report.Status != statusFail {
+			t.Errorf("%s: have %d %q, want 503 %q", name, code, report.Status, statusFail)
+		}
+		if check := report.Checks[name]; check == nil || check.Status != statusFail {
+			t.Errorf("%s: check not failed: %+v", name, check)
+		}
+	}
+}
+
+func TestUnhealthy(t *testing.T) {
+	backend := newTestBackend()
+	backend.committed = nil
+	backend.services["mongodb"] = errors.New("no reachable servers")
+
+	for _, ready := range []bool{false, true} {
+		code, report := serve(t, backend, ready)
+		if code != http.StatusServiceUnavailable || report.Status != statusFail {
+			t.Fatalf("ready %v: have %d %q, want 503 %q", ready, code, report.Status, statusFail)
+		}
+		check := report.Checks["mongodb"]
+		if check == nil || check.Status != statusFail || check.Error != "no reachable servers" {
+			t.Errorf("ready %v: mongodb check mismatch: %+v", ready, check)
+		}
+		if _, ok := report.Checks["commitLag"]; ok {
+			t.Errorf("ready %v: commit lag checked before XDPoS v2", ready)
+		}
+	}
+}
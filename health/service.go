@@ -0,0 +1,141 @@
+// Copyright (c) 2018 XDPoSChain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package health
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/XinFinOrg/XDPoSChain"
+	"github.com/XinFinOrg/XDPoSChain/XDCxDAO"
+	"github.com/XinFinOrg/XDPoSChain/consensus/XDPoS"
+	"github.com/XinFinOrg/XDPoSChain/core/types"
+	"github.com/XinFinOrg/XDPoSChain/eth"
+	"github.com/XinFinOrg/XDPoSChain/p2p"
+	"github.com/XinFinOrg/XDPoSChain/rpc"
+)
+
+var (
+	errMissingTradingState = errors.New("missing trading state of the head block")
+	errMissingLendingState = errors.New("missing lending state of the head block")
+)
+
+// Service serves the health and readiness checks of a full node, at /health
+// and /ready of the HTTP RPC endpoint.
+type Service struct {
+	backend *ethBackend
+	config  Config
+}
+
+// New creates the health service of the given full node.
+func New(ethServ *eth.Ethereum, config Config) *Service {
+	return &Service{backend: &ethBackend{eth: ethServ}, config: config}
+}
+
+// Protocols returns the list of protocols exported by this service.
+func (s *Service) Protocols() []p2p.Protocol { return nil }
+
+// APIs returns the list of APIs exported by this service.
+func (s *Service) APIs() []rpc.API { return nil }
+
+// HTTPHandlers returns the handlers of the health checks, served at /health,
+// and of the readiness checks, served at /ready.
+func (s *Service) HTTPHandlers() map[string]http.Handler {
+	return map[string]http.Handler{
+		"/health": &handler{config: s.config, backend: s.backend},
+		"/ready":  &handler{config: s.config, backend: s.backend, ready: true},
+	}
+}
+
+// Start is called after all services have been constructed and the networking
+// layer was also initialized to spawn any goroutines required by the service.
+func (s *Service) Start(server *p2p.Server) error {
+	s.backend.server = server
+	return nil
+}
+
+// SaveData is a noop, the service has no state.
+func (s *Service) SaveData() {}
+
+// Stop terminates all goroutines belonging to this service, blocking until they
+// are all terminated.
+func (s *Service) Stop() error { return nil }
+
+// ethBackend checks the state of a full node.
+type ethBackend struct {
+	eth    *eth.Ethereum
+	server *p2p.Server
+}
+
+func (b *ethBackend) PeerCount() int {
+	return b.server.PeerCount()
+}
+
+func (b *ethBackend) CurrentHeader() *types.Header {
+	return b.eth.BlockChain().CurrentHeader()
+}
+
+func (b *ethBackend) SyncProgress() XDPoSChain.SyncProgress {
+	return b.eth.Downloader().Progress()
+}
+
+func (b *ethBackend) CommittedBlock() *types.BlockInfo {
+	if engine, ok := b.eth.Engine().(*XDPoS.XDPoS); ok && engine.EngineV2 != nil {
+		return engine.EngineV2.GetLatestCommittedBlockInfo()
+	}
+	return nil
+}
+
+// Services checks that XDCx and lending have the state of the head block, once
+// XDCx is enabled, and that SDK nodes reach their MongoDB database.
+func (b *ethBackend) Services() map[string]error {
+	var (
+		services = make(map[string]error)
+		chain    = b.eth.BlockChain()
+		block    = chain.CurrentBlock()
+		trading  = b.eth.GetXDCX()
+		lending  = b.eth.GetXDCXLending()
+	)
+	if chain.Config().IsTIPXDCX(block.Number()) && chain.Config().XDPoS != nil && block.NumberU64() > chain.Config().XDPoS.Epoch {
+		author, err := b.eth.Engine().Author(block.Header())
+		if trading != nil {
+			switch {
+			case err != nil:
+				services["xdcx"] = err
+			case !trading.HasTradingState(block, author):
+				services["xdcx"] = errMissingTradingState
+			default:
+				services["xdcx"] = nil
+			}
+		}
+		if lending != nil {
+			switch {
+			case err != nil:
+				services["lending"] = err
+			case !lending.HasLendingState(block, author):
+				services["lending"] = errMissingLendingState
+			default:
+				services["lending"] = nil
+			}
+		}
+	}
+	if trading != nil && trading.IsSDKNode() {
+		if db, ok := trading.GetMongoDB().(*XDCxDAO.MongoDatabase); ok {
+			services["mongodb"] = db.Ping()
+		}
+	}
+	return services
+}
@@ -196,6 +196,86 @@ func (s *PublicTxPoolAPI) Inspect() map[string]map[string]map[string]string {
 	return content
 }
 
+// RPCTxLifecycleEvent is a step in the life of a pooled transaction, as
+// returned by txpool_getTransactionHistory.
+type RPCTxLifecycleEvent struct {
+	Type        core.TxEventType  `json:"type"`
+	Reason      core.TxDropReason `json:"reason,omitempty"`
+	ReplacedBy  *common.Hash      `json:"replacedBy,omitempty"`
+	BlockNumber *hexutil.Uint64   `json:"blockNumber,omitempty"`
+	BlockHash   *common.Hash      `json:"blockHash,omitempty"`
+	Time        hexutil.Uint64    `json:"time"`
+}
+
+// GetTransactionHistory returns what happened to a transaction while it was in
+// one of the transaction, order or lending pools, or nil if the node hasn't seen
+// it recently.
+func (s *PublicTxPoolAPI) GetTransactionHistory(hash common.Hash) []*RPCTxLifecycleEvent {
+	events := s.b.GetPoolTransactionHistory(hash)
+	if len(events) == 0 {
+		return nil
+	}
+	result := make([]*RPCTxLifecycleEvent, len(events))
+	for i, ev := range events {
+		result[i] = &RPCTxLifecycleEvent{
+			Type:   ev.Type,
+			Reason: ev.Reason,
+			Time:   hexutil.Uint64(ev.Time.Unix()),
+		}
+		if ev.Type == core.TxEventReplaced {
+			replacedBy := ev.ReplacedBy
+			result[i].ReplacedBy = &replacedBy
+		}
+		if ev.Type == core.TxEventIncluded {
+			number, hash := hexutil.Uint64(ev.BlockNumber), ev.BlockHash
+			result[i].BlockNumber, result[i].BlockHash = &number, &hash
+		}
+	}
+	return result
+}
+
+// RPCDroppedTransaction is the notification sent to droppedTransactions subscribers.
+type RPCDroppedTransaction struct {
+	Hash       common.Hash       `json:"hash"`
+	Reason     core.TxDropReason `json:"reason"`
+	ReplacedBy *common.Hash      `json:"replacedBy,omitempty"`
+}
+
+// DroppedTransactions creates a subscription that is triggered each time a
+// transaction leaves one of the pools without being included.
+func (s *PublicTxPoolAPI) DroppedTransactions(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		dropped := make(chan core.DroppedTxEvent, 128)
+		droppedSub := s.b.SubscribeDroppedTxEvent(dropped)
+
+		for {
+			select {
+			case ev := <-dropped:
+				notification := &RPCDroppedTransaction{Hash: ev.Hash, Reason: ev.Reason}
+				if ev.ReplacedBy != (common.Hash{}) {
+					notification.ReplacedBy = &ev.ReplacedBy
+				}
+				notifier.Notify(rpcSub.ID, notification)
+			case <-rpcSub.Err():
+				droppedSub.Unsubscribe()
+				return
+			case <-notifier.Closed():
+				droppedSub.Unsubscribe()
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
 // PublicAccountAPI provides an API to access accounts managed by this node.
 // It offers only methods that can retrieve accounts.
 type PublicAccountAPI struct {
@@ -723,9 +803,14 @@ func (s *PublicBlockChainAPI) GetBlockReceipts(ctx context.Context, blockNrOrHas
 	// Derive the sender.
 	signer := types.MakeSigner(s.b.ChainConfig(), block.Number())
 
+	tokens, err := trc21SponsoredTokens(ctx, s.b, block.ParentHash())
+	if err != nil {
+		log.Debug("Failed to read the TRC21 sponsored tokens", "number", block.NumberU64(), "err", err)
+	}
 	result := make([]map[string]interface{}, len(receipts))
 	for i, receipt := range receipts {
 		result[i] = marshalReceipt(receipt, block.Hash(), block.NumberU64(), signer, txs[i], i)
+		setTRC21FeePaid(result[i], tokens, txs[i], block.NumberU64(), receipt.GasUsed)
 	}
 
 	return result, nil
@@ -2146,6 +2231,13 @@ func (s *PublicTransactionPoolAPI) GetTransactionReceipt(ctx context.Context, ha
 	if receipt.ContractAddress != (common.Address{}) {
 		fields["contractAddress"] = receipt.ContractAddress
 	}
+	var tokens map[common.Address]*big.Int
+	if header, err := s.b.HeaderByHash(ctx, blockHash); header != nil && err == nil {
+		if tokens, err = trc21SponsoredTokens(ctx, s.b, header.ParentHash); err != nil {
+			log.Debug("Failed to read the TRC21 sponsored tokens", "number", blockNumber, "err", err)
+		}
+	}
+	setTRC21FeePaid(fields, tokens, tx, blockNumber, receipt.GasUsed)
 	return fields, nil
 }
 
@@ -2436,17 +2528,22 @@ func (s *PublicXDCXTransactionPoolAPI) GetOrderStats(ctx context.Context) interf
 
 // OrderMsg struct
 type OrderMsg struct {
-	AccountNonce    hexutil.Uint64 `json:"nonce"    gencodec:"required"`
-	Quantity        hexutil.Big    `json:"quantity,omitempty"`
-	Price           hexutil.Big    `json:"price,omitempty"`
-	ExchangeAddress common.Address `json:"exchangeAddress,omitempty"`
-	UserAddress     common.Address `json:"userAddress,omitempty"`
-	BaseToken       common.Address `json:"baseToken,omitempty"`
-	QuoteToken      common.Address `json:"quoteToken,omitempty"`
-	Status          string         `json:"status,omitempty"`
-	Side            string         `json:"side,omitempty"`
-	Type            string         `json:"type,omitempty"`
-	OrderID         hexutil.Uint64 `json:"orderid,omitempty"`
+	AccountNonce     hexutil.Uint64 `json:"nonce"    gencodec:"required"`
+	Quantity         hexutil.Big    `json:"quantity,omitempty"`
+	Price            hexutil.Big    `json:"price,omitempty"`
+	ExchangeAddress  common.Address `json:"exchangeAddress,omitempty"`
+	UserAddress      common.Address `json:"userAddress,omitempty"`
+	BaseToken        common.Address `json:"baseToken,omitempty"`
+	QuoteToken       common.Address `json:"quoteToken,omitempty"`
+	Status           string         `json:"status,omitempty"`
+	Side             string         `json:"side,omitempty"`
+	Type             string         `json:"type,omitempty"`
+	OrderID          hexutil.Uint64 `json:"orderid,omitempty"`
+	TimeInForce      string         `json:"timeInForce,omitempty"`
+	PostOnly         bool           `json:"postOnly,omitempty"`
+	ExpireBlock      hexutil.Uint64 `json:"expireBlock,omitempty"`
+	TriggerPrice     *hexutil.Big   `json:"triggerPrice,omitempty"`
+	TriggerCondition string         `json:"triggerCondition,omitempty"`
 	// Signature values
 	V hexutil.Big `json:"v" gencodec:"required"`
 	R hexutil.Big `json:"r" gencodec:"required"`
@@ -2497,6 +2594,10 @@ type InterestVolume struct {
 // The sender is responsible for signing the transaction and using the correct nonce.
 func (s *PublicXDCXTransactionPoolAPI) SendOrder(ctx context.Context, msg OrderMsg) (common.Hash, error) {
 	tx := types.NewOrderTransaction(uint64(msg.AccountNonce), msg.Quantity.ToInt(), msg.Price.ToInt(), msg.ExchangeAddress, msg.UserAddress, msg.BaseToken, msg.QuoteToken, msg.Status, msg.Side, msg.Type, msg.Hash, uint64(msg.OrderID))
+	tx.SetMatchingOptions(msg.TimeInForce, msg.PostOnly, uint64(msg.ExpireBlock))
+	if msg.TriggerPrice != nil || msg.TriggerCondition != "" {
+		tx.SetTrigger((*big.Int)(msg.TriggerPrice), msg.TriggerCondition)
+	}
 	tx = tx.ImportSignature(msg.V.ToInt(), msg.R.ToInt(), msg.S.ToInt())
 	return submitOrderTransaction(ctx, s.b, tx)
 }
@@ -2510,8 +2611,15 @@ func (s *PublicXDCXTransactionPoolAPI) SendLending(ctx context.Context, msg Lend
 }
 
 // GetOrderCount returns the number of transactions the given address has sent for the given block number
-func (s *PublicXDCXTransactionPoolAPI) GetOrderCount(ctx context.Context, addr common.Address) (*hexutil.Uint64, error) {
-
+func (s *PublicXDCXTransactionPoolAPI) GetOrderCount(ctx context.Context, addr common.Address, blockNrOrHash *rpc.BlockNumberOrHash) (*hexutil.Uint64, error) {
+	if blockNrOrHash != nil {
+		XDCxState, err := s.tradingStateAt(ctx, blockNrOrHash)
+		if err != nil {
+			return nil, err
+		}
+		nonce := XDCxState.GetNonce(addr.Hash())
+		return (*hexutil.Uint64)(&nonce), nil
+	}
 	nonce, err := s.b.GetOrderNonce(addr.Hash())
 	if err != nil {
 		return (*hexutil.Uint64)(&nonce), err
@@ -2519,22 +2627,10 @@ func (s *PublicXDCXTransactionPoolAPI) GetOrderCount(ctx context.Context, addr c
 	return (*hexutil.Uint64)(&nonce), err
 }
 
-func (s *PublicXDCXTransactionPoolAPI) GetBestBid(ctx context.Context, baseToken, quoteToken common.Address) (PriceVolume, error) {
+func (s *PublicXDCXTransactionPoolAPI) GetBestBid(ctx context.Context, baseToken, quoteToken common.Address, blockNrOrHash *rpc.BlockNumberOrHash) (PriceVolume, error) {
 
 	result := PriceVolume{}
-	block := s.b.CurrentBlock()
-	if block == nil {
-		return result, errors.New("Current block not found")
-	}
-	XDCxService := s.b.XDCxService()
-	if XDCxService == nil {
-		return result, errors.New("XDCX service not found")
-	}
-	author, err := s.b.GetEngine().Author(block.Header())
-	if err != nil {
-		return result, err
-	}
-	XDCxState, err := XDCxService.GetTradingState(block, author)
+	XDCxState, err := s.tradingStateAt(ctx, blockNrOrHash)
 	if err != nil {
 		return result, err
 	}
@@ -2545,21 +2641,9 @@ func (s *PublicXDCXTransactionPoolAPI) GetBestBid(ctx context.Context, baseToken
 	return result, nil
 }
 
-func (s *PublicXDCXTransactionPoolAPI) GetBestAsk(ctx context.Context, baseToken, quoteToken common.Address) (PriceVolume, error) {
+func (s *PublicXDCXTransactionPoolAPI) GetBestAsk(ctx context.Context, baseToken, quoteToken common.Address, blockNrOrHash *rpc.BlockNumberOrHash) (PriceVolume, error) {
 	result := PriceVolume{}
-	block := s.b.CurrentBlock()
-	if block == nil {
-		return result, errors.New("Current block not found")
-	}
-	XDCxService := s.b.XDCxService()
-	if XDCxService == nil {
-		return result, errors.New("XDCX service not found")
-	}
-	author, err := s.b.GetEngine().Author(block.Header())
-	if err != nil {
-		return result, err
-	}
-	XDCxState, err := XDCxService.GetTradingState(block, author)
+	XDCxState, err := s.tradingStateAt(ctx, blockNrOrHash)
 	if err != nil {
 		return result, err
 	}
@@ -2570,20 +2654,8 @@ func (s *PublicXDCXTransactionPoolAPI) GetBestAsk(ctx context.Context, baseToken
 	return result, nil
 }
 
-func (s *PublicXDCXTransactionPoolAPI) GetBidTree(ctx context.Context, baseToken, quoteToken common.Address) (map[*big.Int]tradingstate.DumpOrderList, error) {
-	block := s.b.CurrentBlock()
-	if block == nil {
-		return nil, errors.New("Current block not found")
-	}
-	XDCxService := s.b.XDCxService()
-	if XDCxService == nil {
-		return nil, errors.New("XDCX service not found")
-	}
-	author, err := s.b.GetEngine().Author(block.Header())
-	if err != nil {
-		return nil, err
-	}
-	XDCxState, err := XDCxService.GetTradingState(block, author)
+func (s *PublicXDCXTransactionPoolAPI) GetBidTree(ctx context.Context, baseToken, quoteToken common.Address, blockNrOrHash *rpc.BlockNumberOrHash) (map[*big.Int]tradingstate.DumpOrderList, error) {
+	XDCxState, err := s.tradingStateAt(ctx, blockNrOrHash)
 	if err != nil {
 		return nil, err
 	}
@@ -2594,20 +2666,8 @@ func (s *PublicXDCXTransactionPoolAPI) GetBidTree(ctx context.Context, baseToken
 	return result, nil
 }
 
-func (s *PublicXDCXTransactionPoolAPI) GetPrice(ctx context.Context, baseToken, quoteToken common.Address) (*big.Int, error) {
-	block := s.b.CurrentBlock()
-	if block == nil {
-		return nil, errors.New("Current block not found")
-	}
-	XDCxService := s.b.XDCxService()
-	if XDCxService == nil {
-		return nil, errors.New("XDCX service not found")
-	}
-	author, err := s.b.GetEngine().Author(block.Header())
-	if err != nil {
-		return nil, err
-	}
-	XDCxState, err := XDCxService.GetTradingState(block, author)
+func (s *PublicXDCXTransactionPoolAPI) GetPrice(ctx context.Context, baseToken, quoteToken common.Address, blockNrOrHash *rpc.BlockNumberOrHash) (*big.Int, error) {
+	XDCxState, err := s.tradingStateAt(ctx, blockNrOrHash)
 	if err != nil {
 		return nil, err
 	}
@@ -2618,20 +2678,8 @@ func (s *PublicXDCXTransactionPoolAPI) GetPrice(ctx context.Context, baseToken,
 	return price, nil
 }
 
-func (s *PublicXDCXTransactionPoolAPI) GetLastEpochPrice(ctx context.Context, baseToken, quoteToken common.Address) (*big.Int, error) {
-	block := s.b.CurrentBlock()
-	if block == nil {
-		return nil, errors.New("Current block not found")
-	}
-	XDCxService := s.b.XDCxService()
-	if XDCxService == nil {
-		return nil, errors.New("XDCX service not found")
-	}
-	author, err := s.b.GetEngine().Author(block.Header())
-	if err != nil {
-		return nil, err
-	}
-	XDCxState, err := XDCxService.GetTradingState(block, author)
+func (s *PublicXDCXTransactionPoolAPI) GetLastEpochPrice(ctx context.Context, baseToken, quoteToken common.Address, blockNrOrHash *rpc.BlockNumberOrHash) (*big.Int, error) {
+	XDCxState, err := s.tradingStateAt(ctx, blockNrOrHash)
 	if err != nil {
 		return nil, err
 	}
@@ -2642,20 +2690,8 @@ func (s *PublicXDCXTransactionPoolAPI) GetLastEpochPrice(ctx context.Context, ba
 	return price, nil
 }
 
-func (s *PublicXDCXTransactionPoolAPI) GetCurrentEpochPrice(ctx context.Context, baseToken, quoteToken common.Address) (*big.Int, error) {
-	block := s.b.CurrentBlock()
-	if block == nil {
-		return nil, errors.New("Current block not found")
-	}
-	XDCxService := s.b.XDCxService()
-	if XDCxService == nil {
-		return nil, errors.New("XDCX service not found")
-	}
-	author, err := s.b.GetEngine().Author(block.Header())
-	if err != nil {
-		return nil, err
-	}
-	XDCxState, err := XDCxService.GetTradingState(block, author)
+func (s *PublicXDCXTransactionPoolAPI) GetCurrentEpochPrice(ctx context.Context, baseToken, quoteToken common.Address, blockNrOrHash *rpc.BlockNumberOrHash) (*big.Int, error) {
+	XDCxState, err := s.tradingStateAt(ctx, blockNrOrHash)
 	if err != nil {
 		return nil, err
 	}
@@ -2666,20 +2702,8 @@ func (s *PublicXDCXTransactionPoolAPI) GetCurrentEpochPrice(ctx context.Context,
 	return price, nil
 }
 
-func (s *PublicXDCXTransactionPoolAPI) GetAskTree(ctx context.Context, baseToken, quoteToken common.Address) (map[*big.Int]tradingstate.DumpOrderList, error) {
-	block := s.b.CurrentBlock()
-	if block == nil {
-		return nil, errors.New("Current block not found")
-	}
-	XDCxService := s.b.XDCxService()
-	if XDCxService == nil {
-		return nil, errors.New("XDCX service not found")
-	}
-	author, err := s.b.GetEngine().Author(block.Header())
-	if err != nil {
-		return nil, err
-	}
-	XDCxState, err := XDCxService.GetTradingState(block, author)
+func (s *PublicXDCXTransactionPoolAPI) GetAskTree(ctx context.Context, baseToken, quoteToken common.Address, blockNrOrHash *rpc.BlockNumberOrHash) (map[*big.Int]tradingstate.DumpOrderList, error) {
+	XDCxState, err := s.tradingStateAt(ctx, blockNrOrHash)
 	if err != nil {
 		return nil, err
 	}
@@ -2690,20 +2714,8 @@ func (s *PublicXDCXTransactionPoolAPI) GetAskTree(ctx context.Context, baseToken
 	return result, nil
 }
 
-func (s *PublicXDCXTransactionPoolAPI) GetOrderById(ctx context.Context, baseToken, quoteToken common.Address, orderId uint64) (interface{}, error) {
-	block := s.b.CurrentBlock()
-	if block == nil {
-		return nil, errors.New("Current block not found")
-	}
-	XDCxService := s.b.XDCxService()
-	if XDCxService == nil {
-		return nil, errors.New("XDCX service not found")
-	}
-	author, err := s.b.GetEngine().Author(block.Header())
-	if err != nil {
-		return nil, err
-	}
-	XDCxState, err := XDCxService.GetTradingState(block, author)
+func (s *PublicXDCXTransactionPoolAPI) GetOrderById(ctx context.Context, baseToken, quoteToken common.Address, orderId uint64, blockNrOrHash *rpc.BlockNumberOrHash) (interface{}, error) {
+	XDCxState, err := s.tradingStateAt(ctx, blockNrOrHash)
 	if err != nil {
 		return nil, err
 	}
@@ -2715,20 +2727,8 @@ func (s *PublicXDCXTransactionPoolAPI) GetOrderById(ctx context.Context, baseTok
 	return orderitem, nil
 }
 
-func (s *PublicXDCXTransactionPoolAPI) GetTradingOrderBookInfo(ctx context.Context, baseToken, quoteToken common.Address) (*tradingstate.DumpOrderBookInfo, error) {
-	block := s.b.CurrentBlock()
-	if block == nil {
-		return nil, errors.New("Current block not found")
-	}
-	XDCxService := s.b.XDCxService()
-	if XDCxService == nil {
-		return nil, errors.New("XDCX service not found")
-	}
-	author, err := s.b.GetEngine().Author(block.Header())
-	if err != nil {
-		return nil, err
-	}
-	XDCxState, err := XDCxService.GetTradingState(block, author)
+func (s *PublicXDCXTransactionPoolAPI) GetTradingOrderBookInfo(ctx context.Context, baseToken, quoteToken common.Address, blockNrOrHash *rpc.BlockNumberOrHash) (*tradingstate.DumpOrderBookInfo, error) {
+	XDCxState, err := s.tradingStateAt(ctx, blockNrOrHash)
 	if err != nil {
 		return nil, err
 	}
@@ -2739,20 +2739,8 @@ func (s *PublicXDCXTransactionPoolAPI) GetTradingOrderBookInfo(ctx context.Conte
 	return result, nil
 }
 
-func (s *PublicXDCXTransactionPoolAPI) GetLiquidationPriceTree(ctx context.Context, baseToken, quoteToken common.Address) (map[*big.Int]tradingstate.DumpLendingBook, error) {
-	block := s.b.CurrentBlock()
-	if block == nil {
-		return nil, errors.New("Current block not found")
-	}
-	XDCxService := s.b.XDCxService()
-	if XDCxService == nil {
-		return nil, errors.New("XDCX service not found")
-	}
-	author, err := s.b.GetEngine().Author(block.Header())
-	if err != nil {
-		return nil, err
-	}
-	XDCxState, err := XDCxService.GetTradingState(block, author)
+func (s *PublicXDCXTransactionPoolAPI) GetLiquidationPriceTree(ctx context.Context, baseToken, quoteToken common.Address, blockNrOrHash *rpc.BlockNumberOrHash) (map[*big.Int]tradingstate.DumpLendingBook, error) {
+	XDCxState, err := s.tradingStateAt(ctx, blockNrOrHash)
 	if err != nil {
 		return nil, err
 	}
@@ -2763,20 +2751,8 @@ func (s *PublicXDCXTransactionPoolAPI) GetLiquidationPriceTree(ctx context.Conte
 	return result, nil
 }
 
-func (s *PublicXDCXTransactionPoolAPI) GetInvestingTree(ctx context.Context, lendingToken common.Address, term uint64) (map[*big.Int]lendingstate.DumpOrderList, error) {
-	block := s.b.CurrentBlock()
-	if block == nil {
-		return nil, errors.New("Current block not found")
-	}
-	lendingService := s.b.LendingService()
-	if lendingService == nil {
-		return nil, errors.New("XDCX Lending service not found")
-	}
-	author, err := s.b.GetEngine().Author(block.Header())
-	if err != nil {
-		return nil, err
-	}
-	lendingState, err := lendingService.GetLendingState(block, author)
+func (s *PublicXDCXTransactionPoolAPI) GetInvestingTree(ctx context.Context, lendingToken common.Address, term uint64, blockNrOrHash *rpc.BlockNumberOrHash) (map[*big.Int]lendingstate.DumpOrderList, error) {
+	lendingState, err := s.lendingStateAt(ctx, blockNrOrHash)
 	if err != nil {
 		return nil, err
 	}
@@ -2787,20 +2763,8 @@ func (s *PublicXDCXTransactionPoolAPI) GetInvestingTree(ctx context.Context, len
 	return result, nil
 }
 
-func (s *PublicXDCXTransactionPoolAPI) GetBorrowingTree(ctx context.Context, lendingToken common.Address, term uint64) (map[*big.Int]lendingstate.DumpOrderList, error) {
-	block := s.b.CurrentBlock()
-	if block == nil {
-		return nil, errors.New("Current block not found")
-	}
-	lendingService := s.b.LendingService()
-	if lendingService == nil {
-		return nil, errors.New("XDCX Lending service not found")
-	}
-	author, err := s.b.GetEngine().Author(block.Header())
-	if err != nil {
-		return nil, err
-	}
-	lendingState, err := lendingService.GetLendingState(block, author)
+func (s *PublicXDCXTransactionPoolAPI) GetBorrowingTree(ctx context.Context, lendingToken common.Address, term uint64, blockNrOrHash *rpc.BlockNumberOrHash) (map[*big.Int]lendingstate.DumpOrderList, error) {
+	lendingState, err := s.lendingStateAt(ctx, blockNrOrHash)
 	if err != nil {
 		return nil, err
 	}
@@ -2811,20 +2775,8 @@ func (s *PublicXDCXTransactionPoolAPI) GetBorrowingTree(ctx context.Context, len
 	return result, nil
 }
 
-func (s *PublicXDCXTransactionPoolAPI) GetLendingOrderBookInfo(tx context.Context, lendingToken common.Address, term uint64) (*lendingstate.DumpOrderBookInfo, error) {
-	block := s.b.CurrentBlock()
-	if block == nil {
-		return nil, errors.New("Current block not found")
-	}
-	lendingService := s.b.LendingService()
-	if lendingService == nil {
-		return nil, errors.New("XDCX Lending service not found")
-	}
-	author, err := s.b.GetEngine().Author(block.Header())
-	if err != nil {
-		return nil, err
-	}
-	lendingState, err := lendingService.GetLendingState(block, author)
+func (s *PublicXDCXTransactionPoolAPI) GetLendingOrderBookInfo(ctx context.Context, lendingToken common.Address, term uint64, blockNrOrHash *rpc.BlockNumberOrHash) (*lendingstate.DumpOrderBookInfo, error) {
+	lendingState, err := s.lendingStateAt(ctx, blockNrOrHash)
 	if err != nil {
 		return nil, err
 	}
@@ -2835,20 +2787,8 @@ func (s *PublicXDCXTransactionPoolAPI) GetLendingOrderBookInfo(tx context.Contex
 	return result, nil
 }
 
-func (s *PublicXDCXTransactionPoolAPI) getLendingOrderTree(ctx context.Context, lendingToken common.Address, term uint64) (map[*big.Int]lendingstate.LendingItem, error) {
-	block := s.b.CurrentBlock()
-	if block == nil {
-		return nil, errors.New("Current block not found")
-	}
-	lendingService := s.b.LendingService()
-	if lendingService == nil {
-		return nil, errors.New("XDCX Lending service not found")
-	}
-	author, err := s.b.GetEngine().Author(block.Header())
-	if err != nil {
-		return nil, err
-	}
-	lendingState, err := lendingService.GetLendingState(block, author)
+func (s *PublicXDCXTransactionPoolAPI) getLendingOrderTree(ctx context.Context, lendingToken common.Address, term uint64, blockNrOrHash *rpc.BlockNumberOrHash) (map[*big.Int]lendingstate.LendingItem, error) {
+	lendingState, err := s.lendingStateAt(ctx, blockNrOrHash)
 	if err != nil {
 		return nil, err
 	}
@@ -2859,20 +2799,8 @@ func (s *PublicXDCXTransactionPoolAPI) getLendingOrderTree(ctx context.Context,
 	return result, nil
 }
 
-func (s *PublicXDCXTransactionPoolAPI) GetLendingTradeTree(ctx context.Context, lendingToken common.Address, term uint64) (map[*big.Int]lendingstate.LendingTrade, error) {
-	block := s.b.CurrentBlock()
-	if block == nil {
-		return nil, errors.New("Current block not found")
-	}
-	lendingService := s.b.LendingService()
-	if lendingService == nil {
-		return nil, errors.New("XDCX Lending service not found")
-	}
-	author, err := s.b.GetEngine().Author(block.Header())
-	if err != nil {
-		return nil, err
-	}
-	lendingState, err := lendingService.GetLendingState(block, author)
+func (s *PublicXDCXTransactionPoolAPI) GetLendingTradeTree(ctx context.Context, lendingToken common.Address, term uint64, blockNrOrHash *rpc.BlockNumberOrHash) (map[*big.Int]lendingstate.LendingTrade, error) {
+	lendingState, err := s.lendingStateAt(ctx, blockNrOrHash)
 	if err != nil {
 		return nil, err
 	}
@@ -2883,20 +2811,8 @@ func (s *PublicXDCXTransactionPoolAPI) GetLendingTradeTree(ctx context.Context,
 	return result, nil
 }
 
-func (s *PublicXDCXTransactionPoolAPI) GetLiquidationTimeTree(ctx context.Context, lendingToken common.Address, term uint64) (map[*big.Int]lendingstate.DumpOrderList, error) {
-	block := s.b.CurrentBlock()
-	if block == nil {
-		return nil, errors.New("Current block not found")
-	}
-	lendingService := s.b.LendingService()
-	if lendingService == nil {
-		return nil, errors.New("XDCX Lending service not found")
-	}
-	author, err := s.b.GetEngine().Author(block.Header())
-	if err != nil {
-		return nil, err
-	}
-	lendingState, err := lendingService.GetLendingState(block, author)
+func (s *PublicXDCXTransactionPoolAPI) GetLiquidationTimeTree(ctx context.Context, lendingToken common.Address, term uint64, blockNrOrHash *rpc.BlockNumberOrHash) (map[*big.Int]lendingstate.DumpOrderList, error) {
+	lendingState, err := s.lendingStateAt(ctx, blockNrOrHash)
 	if err != nil {
 		return nil, err
 	}
@@ -2907,20 +2823,8 @@ func (s *PublicXDCXTransactionPoolAPI) GetLiquidationTimeTree(ctx context.Contex
 	return result, nil
 }
 
-func (s *PublicXDCXTransactionPoolAPI) GetLendingOrderCount(ctx context.Context, addr common.Address) (*hexutil.Uint64, error) {
-	block := s.b.CurrentBlock()
-	if block == nil {
-		return nil, errors.New("Current block not found")
-	}
-	lendingService := s.b.LendingService()
-	if lendingService == nil {
-		return nil, errors.New("XDCX Lending service not found")
-	}
-	author, err := s.b.GetEngine().Author(block.Header())
-	if err != nil {
-		return nil, err
-	}
-	lendingState, err := lendingService.GetLendingState(block, author)
+func (s *PublicXDCXTransactionPoolAPI) GetLendingOrderCount(ctx context.Context, addr common.Address, blockNrOrHash *rpc.BlockNumberOrHash) (*hexutil.Uint64, error) {
+	lendingState, err := s.lendingStateAt(ctx, blockNrOrHash)
 	if err != nil {
 		return nil, err
 	}
@@ -2928,21 +2832,9 @@ func (s *PublicXDCXTransactionPoolAPI) GetLendingOrderCount(ctx context.Context,
 	return (*hexutil.Uint64)(&nonce), err
 }
 
-func (s *PublicXDCXTransactionPoolAPI) GetBestInvesting(ctx context.Context, lendingToken common.Address, term uint64) (InterestVolume, error) {
+func (s *PublicXDCXTransactionPoolAPI) GetBestInvesting(ctx context.Context, lendingToken common.Address, term uint64, blockNrOrHash *rpc.BlockNumberOrHash) (InterestVolume, error) {
 	result := InterestVolume{}
-	block := s.b.CurrentBlock()
-	if block == nil {
-		return result, errors.New("Current block not found")
-	}
-	lendingService := s.b.LendingService()
-	if lendingService == nil {
-		return result, errors.New("XDCX Lending service not found")
-	}
-	author, err := s.b.GetEngine().Author(block.Header())
-	if err != nil {
-		return result, err
-	}
-	lendingState, err := lendingService.GetLendingState(block, author)
+	lendingState, err := s.lendingStateAt(ctx, blockNrOrHash)
 	if err != nil {
 		return result, err
 	}
@@ -2950,21 +2842,9 @@ func (s *PublicXDCXTransactionPoolAPI) GetBestInvesting(ctx context.Context, len
 	return result, nil
 }
 
-func (s *PublicXDCXTransactionPoolAPI) GetBestBorrowing(ctx context.Context, lendingToken common.Address, term uint64) (InterestVolume, error) {
+func (s *PublicXDCXTransactionPoolAPI) GetBestBorrowing(ctx context.Context, lendingToken common.Address, term uint64, blockNrOrHash *rpc.BlockNumberOrHash) (InterestVolume, error) {
 	result := InterestVolume{}
-	block := s.b.CurrentBlock()
-	if block == nil {
-		return result, errors.New("Current block not found")
-	}
-	lendingService := s.b.LendingService()
-	if lendingService == nil {
-		return result, errors.New("XDCX Lending service not found")
-	}
-	author, err := s.b.GetEngine().Author(block.Header())
-	if err != nil {
-		return result, err
-	}
-	lendingState, err := lendingService.GetLendingState(block, author)
+	lendingState, err := s.lendingStateAt(ctx, blockNrOrHash)
 	if err != nil {
 		return result, err
 	}
@@ -2972,20 +2852,8 @@ func (s *PublicXDCXTransactionPoolAPI) GetBestBorrowing(ctx context.Context, len
 	return result, nil
 }
 
-func (s *PublicXDCXTransactionPoolAPI) GetBids(ctx context.Context, baseToken, quoteToken common.Address) (map[*big.Int]*big.Int, error) {
-	block := s.b.CurrentBlock()
-	if block == nil {
-		return nil, errors.New("Current block not found")
-	}
-	XDCxService := s.b.XDCxService()
-	if XDCxService == nil {
-		return nil, errors.New("XDCX service not found")
-	}
-	author, err := s.b.GetEngine().Author(block.Header())
-	if err != nil {
-		return nil, err
-	}
-	XDCxState, err := XDCxService.GetTradingState(block, author)
+func (s *PublicXDCXTransactionPoolAPI) GetBids(ctx context.Context, baseToken, quoteToken common.Address, blockNrOrHash *rpc.BlockNumberOrHash) (map[*big.Int]*big.Int, error) {
+	XDCxState, err := s.tradingStateAt(ctx, blockNrOrHash)
 	if err != nil {
 		return nil, err
 	}
@@ -2996,20 +2864,8 @@ func (s *PublicXDCXTransactionPoolAPI) GetBids(ctx context.Context, baseToken, q
 	return result, nil
 }
 
-func (s *PublicXDCXTransactionPoolAPI) GetAsks(ctx context.Context, baseToken, quoteToken common.Address) (map[*big.Int]*big.Int, error) {
-	block := s.b.CurrentBlock()
-	if block == nil {
-		return nil, errors.New("Current block not found")
-	}
-	XDCxService := s.b.XDCxService()
-	if XDCxService == nil {
-		return nil, errors.New("XDCX service not found")
-	}
-	author, err := s.b.GetEngine().Author(block.Header())
-	if err != nil {
-		return nil, err
-	}
-	XDCxState, err := XDCxService.GetTradingState(block, author)
+func (s *PublicXDCXTransactionPoolAPI) GetAsks(ctx context.Context, baseToken, quoteToken common.Address, blockNrOrHash *rpc.BlockNumberOrHash) (map[*big.Int]*big.Int, error) {
+	XDCxState, err := s.tradingStateAt(ctx, blockNrOrHash)
 	if err != nil {
 		return nil, err
 	}
@@ -3020,20 +2876,8 @@ func (s *PublicXDCXTransactionPoolAPI) GetAsks(ctx context.Context, baseToken, q
 	return result, nil
 }
 
-func (s *PublicXDCXTransactionPoolAPI) GetInvests(ctx context.Context, lendingToken common.Address, term uint64) (map[*big.Int]*big.Int, error) {
-	block := s.b.CurrentBlock()
-	if block == nil {
-		return nil, errors.New("Current block not found")
-	}
-	lendingService := s.b.LendingService()
-	if lendingService == nil {
-		return nil, errors.New("XDCX Lending service not found")
-	}
-	author, err := s.b.GetEngine().Author(block.Header())
-	if err != nil {
-		return nil, err
-	}
-	lendingState, err := lendingService.GetLendingState(block, author)
+func (s *PublicXDCXTransactionPoolAPI) GetInvests(ctx context.Context, lendingToken common.Address, term uint64, blockNrOrHash *rpc.BlockNumberOrHash) (map[*big.Int]*big.Int, error) {
+	lendingState, err := s.lendingStateAt(ctx, blockNrOrHash)
 	if err != nil {
 		return nil, err
 	}
@@ -3044,20 +2888,8 @@ func (s *PublicXDCXTransactionPoolAPI) GetInvests(ctx context.Context, lendingTo
 	return result, nil
 }
 
-func (s *PublicXDCXTransactionPoolAPI) GetBorrows(ctx context.Context, lendingToken common.Address, term uint64) (map[*big.Int]*big.Int, error) {
-	block := s.b.CurrentBlock()
-	if block == nil {
-		return nil, errors.New("Current block not found")
-	}
-	lendingService := s.b.LendingService()
-	if lendingService == nil {
-		return nil, errors.New("XDCX Lending service not found")
-	}
-	author, err := s.b.GetEngine().Author(block.Header())
-	if err != nil {
-		return nil, err
-	}
-	lendingState, err := lendingService.GetLendingState(block, author)
+func (s *PublicXDCXTransactionPoolAPI) GetBorrows(ctx context.Context, lendingToken common.Address, term uint64, blockNrOrHash *rpc.BlockNumberOrHash) (map[*big.Int]*big.Int, error) {
+	lendingState, err := s.lendingStateAt(ctx, blockNrOrHash)
 	if err != nil {
 		return nil, err
 	}
@@ -3101,21 +2933,9 @@ func (s *PublicXDCXTransactionPoolAPI) GetLiquidatedTradesByTxHash(ctx context.C
 	return finalizedResult, nil
 }
 
-func (s *PublicXDCXTransactionPoolAPI) GetLendingOrderById(ctx context.Context, lendingToken common.Address, term uint64, orderId uint64) (lendingstate.LendingItem, error) {
+func (s *PublicXDCXTransactionPoolAPI) GetLendingOrderById(ctx context.Context, lendingToken common.Address, term uint64, orderId uint64, blockNrOrHash *rpc.BlockNumberOrHash) (lendingstate.LendingItem, error) {
 	lendingItem := lendingstate.LendingItem{}
-	block := s.b.CurrentBlock()
-	if block == nil {
-		return lendingItem, errors.New("Current block not found")
-	}
-	lendingService := s.b.LendingService()
-	if lendingService == nil {
-		return lendingItem, errors.New("XDCX Lending service not found")
-	}
-	author, err := s.b.GetEngine().Author(block.Header())
-	if err != nil {
-		return lendingItem, err
-	}
-	lendingState, err := lendingService.GetLendingState(block, author)
+	lendingState, err := s.lendingStateAt(ctx, blockNrOrHash)
 	if err != nil {
 		return lendingItem, err
 	}
@@ -3128,21 +2948,9 @@ func (s *PublicXDCXTransactionPoolAPI) GetLendingOrderById(ctx context.Context,
 	return lendingItem, nil
 }
 
-func (s *PublicXDCXTransactionPoolAPI) GetLendingTradeById(ctx context.Context, lendingToken common.Address, term uint64, tradeId uint64) (lendingstate.LendingTrade, error) {
+func (s *PublicXDCXTransactionPoolAPI) GetLendingTradeById(ctx context.Context, lendingToken common.Address, term uint64, tradeId uint64, blockNrOrHash *rpc.BlockNumberOrHash) (lendingstate.LendingTrade, error) {
 	lendingItem := lendingstate.LendingTrade{}
-	block := s.b.CurrentBlock()
-	if block == nil {
-		return lendingItem, errors.New("Current block not found")
-	}
-	lendingService := s.b.LendingService()
-	if lendingService == nil {
-		return lendingItem, errors.New("XDCX Lending service not found")
-	}
-	author, err := s.b.GetEngine().Author(block.Header())
-	if err != nil {
-		return lendingItem, err
-	}
-	lendingState, err := lendingService.GetLendingState(block, author)
+	lendingState, err := s.lendingStateAt(ctx, blockNrOrHash)
 	if err != nil {
 		return lendingItem, err
 	}
@@ -83,6 +83,8 @@ type Backend interface {
 	Stats() (pending int, queued int)
 	TxPoolContent() (map[common.Address]types.Transactions, map[common.Address]types.Transactions)
 	SubscribeNewTxsEvent(chan<- core.NewTxsEvent) event.Subscription
+	GetPoolTransactionHistory(txHash common.Hash) []core.TxLifecycleEvent
+	SubscribeDroppedTxEvent(chan<- core.DroppedTxEvent) event.Subscription
 
 	// Order Pool Transaction
 	SendOrderTx(ctx context.Context, signedTx *types.OrderTransaction) error
@@ -134,6 +136,11 @@ func GetAPIs(apiBackend Backend, chainReader consensus.ChainReader) []rpc.API {
 			Version:   "1.0",
 			Service:   NewPublicXDCXTransactionPoolAPI(apiBackend, nonceLock),
 			Public:    true,
+		}, {
+			Namespace: "XDC",
+			Version:   "1.0",
+			Service:   NewPublicTRC21API(apiBackend),
+			Public:    true,
 		}, {
 			Namespace: "txpool",
 			Version:   "1.0",
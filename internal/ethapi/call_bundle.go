@@ -0,0 +1,263 @@
+// Copyright (c) 2018 XDPoSChain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/XinFinOrg/XDPoSChain/common"
+	"github.com/XinFinOrg/XDPoSChain/common/hexutil"
+	"github.com/XinFinOrg/XDPoSChain/common/math"
+	"github.com/XinFinOrg/XDPoSChain/consensus"
+	"github.com/XinFinOrg/XDPoSChain/core"
+	"github.com/XinFinOrg/XDPoSChain/core/state"
+	"github.com/XinFinOrg/XDPoSChain/core/types"
+	"github.com/XinFinOrg/XDPoSChain/core/vm"
+	"github.com/XinFinOrg/XDPoSChain/log"
+	"github.com/XinFinOrg/XDPoSChain/params"
+	"github.com/XinFinOrg/XDPoSChain/rpc"
+)
+
+// callBundleTimeout bounds the execution time of a whole bundle.
+const callBundleTimeout = 5 * time.Second
+
+var errEmptyBundle = errors.New("no transactions to simulate")
+
+// BlockOverrides is the set of header fields overridden when simulating a
+// bundle, so it can be executed as if it was included in a future block.
+type BlockOverrides struct {
+	Number   *hexutil.Big    `json:"number"`
+	Time     *hexutil.Uint64 `json:"time"`
+	Coinbase *common.Address `json:"coinbase"`
+}
+
+// Apply overrides the given block context.
+func (diff *BlockOverrides) Apply(blockCtx *vm.BlockContext) {
+	if diff == nil {
+		return
+	}
+	if diff.Number != nil {
+		blockCtx.BlockNumber = diff.Number.ToInt()
+	}
+	if diff.Time != nil {
+		blockCtx.Time = new(big.Int).SetUint64(uint64(*diff.Time))
+	}
+	if diff.Coinbase != nil {
+		blockCtx.Coinbase = *diff.Coinbase
+	}
+}
+
+// CallBundleResult is the outcome of a single transaction of a simulated bundle.
+type CallBundleResult struct {
+	TxHash     *common.Hash   `json:"txHash,omitempty"`
+	ReturnData hexutil.Bytes  `json:"returnData"`
+	GasUsed    hexutil.Uint64 `json:"gasUsed"`
+	Logs       []*types.Log   `json:"logs"`
+	Error      string         `json:"error,omitempty"`
+}
+
+// chainContext makes the backend usable to construct an EVM block context.
+type chainContext struct {
+	b   Backend
+	ctx context.Context
+}
+
+func (c *chainContext) Engine() consensus.Engine {
+	return c.b.GetEngine()
+}
+
+func (c *chainContext) GetHeader(hash common.Hash, number uint64) *types.Header {
+	// This method is called to get the hash for a block number when executing
+	// the BLOCKHASH opcode, errors are reported as an empty hash.
+	header, err := c.b.HeaderByHash(c.ctx, hash)
+	if err != nil || header == nil || header.Number.Uint64() != number {
+		return nil
+	}
+	return header
+}
+
+func (c *chainContext) CurrentHeader() *types.Header {
+	return c.b.CurrentBlock().Header()
+}
+
+func (c *chainContext) Config() *params.ChainConfig {
+	return c.b.ChainConfig()
+}
+
+// CallMany executes the given calls one after the other on top of the state of
+// the given block, every call seeing the changes made by the previous ones. As
+// with eth_call, callers are not charged for gas, except for calls to TRC21
+// tokens whose fees are paid from the token fee capacity as in a block.
+func (s *PublicBlockChainAPI) CallMany(ctx context.Context, calls []TransactionArgs, blockNrOrHash *rpc.BlockNumberOrHash, overrides *StateOverride, blockOverrides *BlockOverrides) ([]*CallBundleResult, error) {
+	if len(calls) == 0 {
+		return nil, errEmptyBundle
+	}
+	return doCallBundle(ctx, s.b, blockNrOrHash, overrides, blockOverrides, len(calls), func(i int, number *big.Int, feeCapacity map[common.Address]*big.Int) (types.Message, *common.Hash, error) {
+		args := calls[i]
+		msg := args.ToMessage(s.b, number, s.b.RPCGasCap())
+		if args.To != nil {
+			if balanceTokenFee, ok := feeCapacity[*args.To]; ok {
+				return types.NewMessage(msg.From(), msg.To(), 0, msg.Value(), msg.Gas(), msg.GasPrice(), msg.Data(), msg.AccessList(), false, balanceTokenFee, number), nil, nil
+			}
+		}
+		msg.SetBalanceTokenFeeForCall()
+		return msg, nil, nil
+	})
+}
+
+// CallBundle executes the given signed transactions one after the other on top
+// of the state of the given block, exactly as they would be executed if they
+// were included in a block in that order: nonces are checked and fees charged.
+func (s *PublicBlockChainAPI) CallBundle(ctx context.Context, encodedTxs []hexutil.Bytes, blockNrOrHash *rpc.BlockNumberOrHash, overrides *StateOverride, blockOverrides *BlockOverrides) ([]*CallBundleResult, error) {
+	if len(encodedTxs) == 0 {
+		return nil, errEmptyBundle
+	}
+	txs := make([]*types.Transaction, len(encodedTxs))
+	for i, encoded := range encodedTxs {
+		tx := new(types.Transaction)
+		if err := tx.UnmarshalBinary(encoded); err != nil {
+			return nil, fmt.Errorf("transaction %d: %w", i, err)
+		}
+		txs[i] = tx
+	}
+	return doCallBundle(ctx, s.b, blockNrOrHash, overrides, blockOverrides, len(txs), func(i int, number *big.Int, feeCapacity map[common.Address]*big.Int) (types.Message, *common.Hash, error) {
+		tx := txs[i]
+		var balanceFee *big.Int
+		if to := tx.To(); to != nil {
+			balanceFee = feeCapacity[*to]
+		}
+		msg, err := tx.AsMessage(types.MakeSigner(s.b.ChainConfig(), number), balanceFee, number)
+		hash := tx.Hash()
+		return msg, &hash, err
+	})
+}
+
+// doCallBundle executes count messages sequentially on a copy of the requested
+// state. The messages are created on the fly by toMessage, given the number of
+// the simulated block and the remaining TRC21 fee capacity of every token, along
+// with the hash of the transaction they come from, if any.
+func doCallBundle(ctx context.Context, b Backend, blockNrOrHash *rpc.BlockNumberOrHash, overrides *StateOverride, blockOverrides *BlockOverrides, count int, toMessage func(i int, number *big.Int, feeCapacity map[common.Address]*big.Int) (types.Message, *common.Hash, error)) ([]*CallBundleResult, error) {
+	defer func(start time.Time) { log.Debug("Executing EVM call bundle finished", "runtime", time.Since(start)) }(time.Now())
+
+	if blockNrOrHash == nil {
+		latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+		blockNrOrHash = &latest
+	}
+	statedb, header, err := b.StateAndHeaderByNumberOrHash(ctx, *blockNrOrHash)
+	if statedb == nil || err != nil {
+		return nil, err
+	}
+	if header == nil {
+		return nil, errors.New("nil header in doCallBundle")
+	}
+	if err := overrides.Apply(statedb); err != nil {
+		return nil, err
+	}
+	block, err := b.BlockByNumberOrHash(ctx, *blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, fmt.Errorf("nil block in doCallBundle: number=%d, hash=%s", header.Number.Uint64(), header.Hash().Hex())
+	}
+	author, err := b.GetEngine().Author(block.Header())
+	if err != nil {
+		return nil, err
+	}
+	XDCxState, err := b.XDCxService().GetTradingState(block, author)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, callBundleTimeout)
+	defer cancel()
+
+	blockCtx := core.NewEVMBlockContext(header, &chainContext{b: b, ctx: ctx}, nil)
+	blockOverrides.Apply(&blockCtx)
+	evm := vm.NewEVM(blockCtx, vm.TxContext{}, statedb, XDCxState, b.ChainConfig(), vm.Config{})
+	go func() {
+		<-ctx.Done()
+		evm.Cancel()
+	}()
+
+	var (
+		number      = blockCtx.BlockNumber
+		owner       = statedb.GetOwner(blockCtx.Coinbase)
+		feeCapacity = state.GetTRC21FeeCapacityFromState(statedb)
+		gp          = new(core.GasPool).AddGas(math.MaxUint64)
+		results     = make([]*CallBundleResult, 0, count)
+	)
+	for i := 0; i < count; i++ {
+		msg, txHash, err := toMessage(i, number, feeCapacity)
+		if err != nil {
+			return nil, fmt.Errorf("transaction %d: %w", i, err)
+		}
+		result := &CallBundleResult{TxHash: txHash, Logs: []*types.Log{}}
+
+		// Logs are collected by transaction hash, calls get a placeholder one
+		hash := common.BigToHash(big.NewInt(int64(i)))
+		if txHash != nil {
+			hash = *txHash
+		}
+		statedb.Prepare(hash, i)
+		evm.Reset(core.NewEVMTxContext(msg), statedb)
+
+		res, gas, failed, err, vmErr := core.ApplyMessage(evm, msg, gp, owner)
+		if evm.Cancelled() {
+			return nil, fmt.Errorf("execution aborted (timeout = %v)", callBundleTimeout)
+		}
+		if err != nil {
+			// The transaction could not be included, the state is left untouched
+			result.Error = err.Error()
+			results = append(results, result)
+			continue
+		}
+		statedb.Finalise(true)
+
+		result.ReturnData = res
+		result.GasUsed = hexutil.Uint64(gas)
+		if logs := statedb.GetLogs(hash, common.Hash{}); logs != nil {
+			if txHash == nil {
+				for _, l := range logs {
+					l.TxHash = common.Hash{}
+				}
+			}
+			result.Logs = logs
+		}
+		if failed {
+			if len(res) > 0 {
+				result.Error = newRevertError(res).Error()
+			} else if vmErr != nil {
+				result.Error = vmErr.Error()
+			}
+		}
+		if to := msg.To(); to != nil && msg.BalanceTokenFee() != nil {
+			if _, ok := feeCapacity[*to]; ok {
+				if failed {
+					state.PayFeeWithTRC21TxFail(statedb, msg.From(), *to)
+				}
+				fee := common.GetGasFee(number.Uint64(), gas)
+				feeCapacity[*to] = new(big.Int).Sub(feeCapacity[*to], fee)
+			}
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
@@ -0,0 +1,273 @@
+package ethapi
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/XinFinOrg/XDPoSChain/XDCx"
+	"github.com/XinFinOrg/XDPoSChain/XDCx/tradingstate"
+	"github.com/XinFinOrg/XDPoSChain/common"
+	"github.com/XinFinOrg/XDPoSChain/common/hexutil"
+	"github.com/XinFinOrg/XDPoSChain/consensus"
+	"github.com/XinFinOrg/XDPoSChain/consensus/ethash"
+	"github.com/XinFinOrg/XDPoSChain/core/rawdb"
+	"github.com/XinFinOrg/XDPoSChain/core/state"
+	"github.com/XinFinOrg/XDPoSChain/core/types"
+	"github.com/XinFinOrg/XDPoSChain/crypto"
+	"github.com/XinFinOrg/XDPoSChain/params"
+	"github.com/XinFinOrg/XDPoSChain/rpc"
+)
+
+var (
+	// counterCode increments the first storage slot and returns its new value.
+	counterCode = common.FromHex("6000546001018060005560005260206000f3")
+	// numberCode returns the number and the coinbase of the block.
+	numberCode = common.FromHex("436000524160205260406000f3")
+	// revertCode reverts with 0xdeadbeef.
+	revertCode = common.FromHex("63deadbeef6000526004601cfd")
+
+	counterAddr = common.HexToAddress("0xc0")
+	numberAddr  = common.HexToAddress("0xc1")
+	revertAddr  = common.HexToAddress("0xc2")
+	tokenAddr   = common.HexToAddress("0xc3")
+)
+
+// callBundleTestBackend is a backend serving a single block and its state, as
+// needed to simulate bundles.
+type callBundleTestBackend struct {
+	Backend
+
+	db     state.Database
+	root   common.Hash
+	block  *types.Block
+	engine consensus.Engine
+	XDCx   *XDCx.XDCX
+}
+
+// newCallBundleTestBackend creates a backend whose state holds the test
+// contracts, a funded account and a TRC21 token with the given fee capacity.
+func newCallBundleTestBackend(t *testing.T, funded common.Address, tokenCapacity *big.Int) *callBundleTestBackend {
+	db := rawdb.NewMemoryDatabase()
+	stateCache := state.NewDatabase(db)
+	statedb, _ := state.New(common.Hash{}, stateCache)
+	statedb.SetBalance(funded, big.NewInt(params.Ether))
+	statedb.SetCode(counterAddr, counterCode)
+	statedb.SetCode(numberAddr, numberCode)
+	statedb.SetCode(revertAddr, revertCode)
+	statedb.SetCode(tokenAddr, counterCode)
+
+	// Register the token in the TRC21 issuer with its fee capacity
+	slotTokens := common.BigToHash(new(big.Int).SetUint64(state.SlotTRC21Issuer["tokens"]))
+	statedb.SetState(common.TRC21IssuerSMC, slotTokens, common.BigToHash(common.Big1))
+	statedb.SetState(common.TRC21IssuerSMC, state.GetLocDynamicArrAtElement(slotTokens, 0, 1), tokenAddr.Hash())
+	capacityKey := state.GetLocMappingAtKey(tokenAddr.Hash(), state.SlotTRC21Issuer["tokensState"])
+	statedb.SetState(common.TRC21IssuerSMC, common.BigToHash(capacityKey), common.BigToHash(tokenCapacity))
+
+	root, err := statedb.Commit(false)
+	if err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	header := &types.Header{
+		Number:     big.NewInt(1),
+		Time:       big.NewInt(1000),
+		GasLimit:   params.GenesisGasLimit,
+		Difficulty: common.Big1,
+		Coinbase:   common.HexToAddress("0xc01b"),
+		Root:       root,
+	}
+	return &callBundleTestBackend{
+		db:     stateCache,
+		root:   root,
+		block:  types.NewBlockWithHeader(header),
+		engine: ethash.NewFaker(),
+		XDCx:   &XDCx.XDCX{StateCache: tradingstate.NewDatabase(rawdb.NewMemoryDatabase())},
+	}
+}
+
+func (b *callBundleTestBackend) StateAndHeaderByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*state.StateDB, *types.Header, error) {
+	statedb, err := state.New(b.root, b.db)
+	return statedb, b.block.Header(), err
+}
+
+func (b *callBundleTestBackend) BlockByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*types.Block, error) {
+	return b.block, nil
+}
+
+func (b *callBundleTestBackend) HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error) {
+	if hash == b.block.Hash() {
+		return b.block.Header(), nil
+	}
+	return nil, nil
+}
+
+func (b *callBundleTestBackend) CurrentBlock() *types.Block       { return b.block }
+func (b *callBundleTestBackend) GetEngine() consensus.Engine      { return b.engine }
+func (b *callBundleTestBackend) XDCxService() *XDCx.XDCX          { return b.XDCx }
+func (b *callBundleTestBackend) ChainConfig() *params.ChainConfig { return params.TestChainConfig }
+func (b *callBundleTestBackend) RPCGasCap() uint64                { return 25000000 }
+
+// decodeWord returns the i-th 32 bytes word of the given return data.
+func decodeWord(data []byte, i int) *big.Int {
+	return new(big.Int).SetBytes(data[32*i : 32*(i+1)])
+}
+
+func TestCallManySequentialState(t *testing.T) {
+	from := common.HexToAddress("0x1000")
+	api := NewPublicBlockChainAPI(newCallBundleTestBackend(t, from, common.Big0), nil)
+
+	calls := []TransactionArgs{{From: &from, To: &counterAddr}, {From: &from, To: &counterAddr}, {From: &from, To: &counterAddr}}
+	results, err := api.CallMany(context.Background(), calls, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to simulate calls: %v", err)
+	}
+	for i, result := range results {
+		if result.Error != "" {
+			t.Fatalf("call %d failed: %s", i, result.Error)
+		}
+		if have := decodeWord(result.ReturnData, 0); have.Int64() != int64(i+1) {
+			t.Errorf("call %d: counter mismatch: have %v, want %d", i, have, i+1)
+		}
+	}
+	if _, err := api.CallMany(context.Background(), nil, nil, nil, nil); err != errEmptyBundle {
+		t.Errorf("empty bundle error mismatch: have %v, want %v", err, errEmptyBundle)
+	}
+}
+
+func TestCallManyTRC21FeeCapacity(t *testing.T) {
+	var (
+		from = common.HexToAddress("0x1000")
+		gas  = hexutil.Uint64(50000)
+		call = TransactionArgs{From: &from, To: &tokenAddr, Gas: &gas}
+		// Gas bought by a call, paid from the capacity at the TRC21 gas price
+		cost = new(big.Int).Mul(big.NewInt(int64(gas)), common.GetGasPrice(common.Big1))
+	)
+	// Learn the fee of a single call to the token
+	api := NewPublicBlockChainAPI(newCallBundleTestBackend(t, from, new(big.Int).Mul(cost, common.Big2)), nil)
+	results, err := api.CallMany(context.Background(), []TransactionArgs{call}, nil, nil, nil)
+	if err != nil || results[0].Error != "" {
+		t.Fatalf("failed to simulate call: %v %+v", err, results[0])
+	}
+	fee := common.GetGasFee(1, uint64(results[0].GasUsed))
+
+	// Leave enough capacity for the first call only
+	capacity := new(big.Int).Add(cost, fee)
+	capacity.Sub(capacity, common.Big1)
+	api = NewPublicBlockChainAPI(newCallBundleTestBackend(t, from, capacity), nil)
+
+	results, err = api.CallMany(context.Background(), []TransactionArgs{call, call}, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to simulate calls: %v", err)
+	}
+	if results[0].Error != "" {
+		t.Fatalf("first call failed: %s", results[0].Error)
+	}
+	if !strings.Contains(results[1].Error, "insufficient balance") {
+		t.Errorf("second call error mismatch: have %q, want insufficient balance", results[1].Error)
+	}
+	if len(results[1].ReturnData) != 0 || results[1].GasUsed != 0 {
+		t.Errorf("second call should not have been executed: %+v", results[1])
+	}
+}
+
+func TestCallManyRevert(t *testing.T) {
+	from := common.HexToAddress("0x1000")
+	api := NewPublicBlockChainAPI(newCallBundleTestBackend(t, from, common.Big0), nil)
+
+	calls := []TransactionArgs{{From: &from, To: &revertAddr}, {From: &from, To: &counterAddr}}
+	results, err := api.CallMany(context.Background(), calls, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to simulate calls: %v", err)
+	}
+	if results[0].Error != "execution reverted" {
+		t.Errorf("revert error mismatch: have %q, want %q", results[0].Error, "execution reverted")
+	}
+	if have := hexutil.Encode(results[0].ReturnData); have != "0xdeadbeef" {
+		t.Errorf("revert data mismatch: have %s, want 0xdeadbeef", have)
+	}
+	if results[0].GasUsed == 0 {
+		t.Errorf("reverted call should use gas")
+	}
+	if results[1].Error != "" || decodeWord(results[1].ReturnData, 0).Int64() != 1 {
+		t.Errorf("call following a revert mismatch: %+v", results[1])
+	}
+}
+
+func TestCallManyOverrides(t *testing.T) {
+	var (
+		from     = common.HexToAddress("0x1000")
+		coinbase = common.HexToAddress("0xbeef")
+		number   = (*hexutil.Big)(big.NewInt(1000))
+		value    = common.BigToHash(big.NewInt(41))
+		code     = hexutil.Bytes(counterCode)
+	)
+	api := NewPublicBlockChainAPI(newCallBundleTestBackend(t, from, common.Big0), nil)
+
+	// The block fields seen by the calls are overridden
+	calls := []TransactionArgs{{From: &from, To: &numberAddr}}
+	results, err := api.CallMany(context.Background(), calls, nil, nil, &BlockOverrides{Number: number, Coinbase: &coinbase})
+	if err != nil || results[0].Error != "" {
+		t.Fatalf("failed to simulate calls: %v %v", err, results)
+	}
+	if have := decodeWord(results[0].ReturnData, 0); have.Cmp(number.ToInt()) != 0 {
+		t.Errorf("number mismatch: have %v, want %v", have, number)
+	}
+	if have := common.BigToAddress(decodeWord(results[0].ReturnData, 1)); have != coinbase {
+		t.Errorf("coinbase mismatch: have %x, want %x", have, coinbase)
+	}
+	// The state seen by the calls is overridden
+	target := common.HexToAddress("0xc4")
+	overrides := &StateOverride{
+		counterAddr: {StateDiff: &map[common.Hash]common.Hash{{}: value}},
+		target:      {Code: &code},
+	}
+	calls = []TransactionArgs{{From: &from, To: &counterAddr}, {From: &from, To: &target}}
+	results, err = api.CallMany(context.Background(), calls, nil, overrides, nil)
+	if err != nil {
+		t.Fatalf("failed to simulate calls: %v", err)
+	}
+	if have := decodeWord(results[0].ReturnData, 0); have.Int64() != 42 {
+		t.Errorf("overridden storage mismatch: have %v, want 42", have)
+	}
+	if have := decodeWord(results[1].ReturnData, 0); have.Int64() != 1 {
+		t.Errorf("overridden code mismatch: have %v, want 1", have)
+	}
+}
+
+func TestCallBundle(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	api := NewPublicBlockChainAPI(newCallBundleTestBackend(t, from, common.Big0), nil)
+
+	signer := types.MakeSigner(params.TestChainConfig, common.Big1)
+	sign := func(nonce uint64) hexutil.Bytes {
+		tx, err := types.SignTx(types.NewTransaction(nonce, counterAddr, common.Big0, 100000, common.Big1, nil), signer, key)
+		if err != nil {
+			t.Fatalf("failed to sign transaction: %v", err)
+		}
+		encoded, _ := tx.MarshalBinary()
+		return encoded
+	}
+	results, err := api.CallBundle(context.Background(), []hexutil.Bytes{sign(0), sign(5), sign(1)}, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to simulate bundle: %v", err)
+	}
+	for i, result := range results {
+		if result.TxHash == nil {
+			t.Errorf("transaction %d: missing hash", i)
+		}
+	}
+	if results[0].Error != "" || decodeWord(results[0].ReturnData, 0).Int64() != 1 {
+		t.Errorf("first transaction mismatch: %+v", results[0])
+	}
+	if !strings.Contains(results[1].Error, "nonce too high") {
+		t.Errorf("nonce error mismatch: have %q, want nonce too high", results[1].Error)
+	}
+	if results[2].Error != "" || decodeWord(results[2].ReturnData, 0).Int64() != 2 {
+		t.Errorf("last transaction mismatch: %+v", results[2])
+	}
+	if _, err := api.CallBundle(context.Background(), []hexutil.Bytes{{0x01}}, nil, nil, nil); err == nil {
+		t.Errorf("undecodable transaction should fail the bundle")
+	}
+}
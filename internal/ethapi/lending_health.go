@@ -0,0 +1,198 @@
+// Copyright (c) 2018 XDPoSChain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"sort"
+
+	"github.com/XinFinOrg/XDPoSChain/XDCxlending"
+	"github.com/XinFinOrg/XDPoSChain/XDCxlending/lendingstate"
+	"github.com/XinFinOrg/XDPoSChain/common"
+	"github.com/XinFinOrg/XDPoSChain/common/hexutil"
+	"github.com/XinFinOrg/XDPoSChain/rpc"
+)
+
+// LendingPriceOverride replaces the price of the collateral token in lending
+// token when evaluating the lending positions.
+type LendingPriceOverride struct {
+	CollateralToken common.Address `json:"collateralToken"`
+	LendingToken    common.Address `json:"lendingToken"`
+	Price           *hexutil.Big   `json:"price"`
+}
+
+// LendingPosition is an open lending trade of a borrower along with how close
+// it is to being liquidated. The price dependent fields are empty when no
+// collateral price is known for the pair, in which case the trade can only
+// be closed by time.
+type LendingPosition struct {
+	LendingBook            common.Hash    `json:"lendingBook"`
+	TradeId                hexutil.Uint64 `json:"tradeId"`
+	Hash                   common.Hash    `json:"hash"`
+	LendingToken           common.Address `json:"lendingToken"`
+	CollateralToken        common.Address `json:"collateralToken"`
+	Term                   hexutil.Uint64 `json:"term"`
+	Interest               hexutil.Uint64 `json:"interest"`
+	Amount                 *hexutil.Big   `json:"amount"`
+	CollateralLockedAmount *hexutil.Big   `json:"collateralLockedAmount"`
+	AutoTopUp              bool           `json:"autoTopUp"`
+	DepositRate            *hexutil.Big   `json:"depositRate"`
+	LiquidationRate        *hexutil.Big   `json:"liquidationRate"`
+	CollateralPrice        *hexutil.Big   `json:"collateralPrice"`
+	SimulatedPrice         bool           `json:"simulatedPrice"`
+	LiquidationPrice       *hexutil.Big   `json:"liquidationPrice"`
+	CollateralRatio        *hexutil.Big   `json:"collateralRatio"`
+	LiquidationTime        hexutil.Uint64 `json:"liquidationTime"`
+	ExpiresIn              hexutil.Uint64 `json:"expiresIn"`
+	Expired                bool           `json:"expired"`
+	Liquidatable           bool           `json:"liquidatable"`
+	TopUpAmount            *hexutil.Big   `json:"topUpAmount"`
+	TopUpFunded            bool           `json:"topUpFunded"`
+	RecallAmount           *hexutil.Big   `json:"recallAmount"`
+}
+
+// LendingPositions is the result of XDCx_getLendingPositions. The trades are
+// liquidated, topped up or recalled at NextLiquidationBlock only.
+type LendingPositions struct {
+	Borrower             common.Address    `json:"borrower"`
+	BlockNumber          hexutil.Uint64    `json:"blockNumber"`
+	BlockHash            common.Hash       `json:"blockHash"`
+	NextLiquidationBlock hexutil.Uint64    `json:"nextLiquidationBlock"`
+	Positions            []LendingPosition `json:"positions"`
+}
+
+// GetLendingPositions returns the open lending trades of the borrower at the
+// given block, the latest one by default, with their liquidation risk. The
+// collateral prices are the ones the liquidation uses unless they are
+// overridden by prices, which allows to simulate price moves.
+func (s *PublicXDCXTransactionPoolAPI) GetLendingPositions(ctx context.Context, borrower common.Address, blockNrOrHash *rpc.BlockNumberOrHash, prices *[]LendingPriceOverride) (*LendingPositions, error) {
+	lendingService := s.b.LendingService()
+	if lendingService == nil {
+		return nil, errors.New("XDCX Lending service not found")
+	}
+	if blockNrOrHash == nil {
+		latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+		blockNrOrHash = &latest
+	}
+	statedb, header, err := s.b.StateAndHeaderByNumberOrHash(ctx, *blockNrOrHash)
+	if statedb == nil || err != nil {
+		return nil, err
+	}
+	tradingState, err := s.tradingStateAt(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	lendingState, err := s.lendingStateAt(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	overrides := make(map[lendingstate.LendingPair]*big.Int)
+	if prices != nil {
+		for _, override := range *prices {
+			if override.Price == nil || override.Price.ToInt().Sign() <= 0 {
+				return nil, errors.New("price override must be positive")
+			}
+			overrides[lendingstate.LendingPair{LendingToken: override.LendingToken, CollateralToken: override.CollateralToken}] = override.Price.ToInt()
+		}
+	}
+	result := &LendingPositions{
+		Borrower:             borrower,
+		BlockNumber:          hexutil.Uint64(header.Number.Uint64()),
+		BlockHash:            header.Hash(),
+		NextLiquidationBlock: hexutil.Uint64(nextLiquidationBlock(header.Number.Uint64(), s.b.ChainConfig().XDPoS.Epoch)),
+		Positions:            []LendingPosition{},
+	}
+	lendingBooks, err := lendingstate.GetAllLendingBooks(statedb)
+	if err != nil {
+		// no lending token or term registered yet, so no trade either
+		return result, nil
+	}
+	chain := &chainContext{b: s.b, ctx: ctx}
+	for lendingBook := range lendingBooks {
+		trades, err := lendingState.DumpLendingTradeTrie(lendingBook)
+		if err != nil {
+			// the order book has not been used
+			continue
+		}
+		for _, trade := range trades {
+			if trade.Borrower != borrower || trade.Amount == nil || trade.Amount.Sign() == 0 {
+				continue
+			}
+			pair := lendingstate.LendingPair{LendingToken: trade.LendingToken, CollateralToken: trade.CollateralToken}
+			price, simulated := overrides[pair]
+			if !simulated {
+				// the liquidation skips the pairs whose price cannot be read
+				if _, price, err = lendingService.GetCollateralPrices(header, chain, statedb, tradingState, trade.CollateralToken, trade.LendingToken); err != nil {
+					price = nil
+				}
+			}
+			health, err := lendingService.GetTradeHealth(header, chain, statedb, &trade, price)
+			if err != nil {
+				return nil, err
+			}
+			result.Positions = append(result.Positions, newLendingPosition(lendingBook, &trade, health, simulated))
+		}
+	}
+	sort.Slice(result.Positions, func(i, j int) bool {
+		if cmp := bytes.Compare(result.Positions[i].LendingBook[:], result.Positions[j].LendingBook[:]); cmp != 0 {
+			return cmp < 0
+		}
+		return result.Positions[i].TradeId < result.Positions[j].TradeId
+	})
+	return result, nil
+}
+
+// newLendingPosition merges a lending trade with its health.
+func newLendingPosition(lendingBook common.Hash, trade *lendingstate.LendingTrade, health *XDCxlending.TradeHealth, simulated bool) LendingPosition {
+	return LendingPosition{
+		LendingBook:            lendingBook,
+		TradeId:                hexutil.Uint64(trade.TradeId),
+		Hash:                   trade.Hash,
+		LendingToken:           trade.LendingToken,
+		CollateralToken:        trade.CollateralToken,
+		Term:                   hexutil.Uint64(trade.Term),
+		Interest:               hexutil.Uint64(trade.Interest),
+		Amount:                 (*hexutil.Big)(trade.Amount),
+		CollateralLockedAmount: (*hexutil.Big)(trade.CollateralLockedAmount),
+		AutoTopUp:              trade.AutoTopUp,
+		DepositRate:            (*hexutil.Big)(trade.DepositRate),
+		LiquidationRate:        (*hexutil.Big)(trade.LiquidationRate),
+		CollateralPrice:        (*hexutil.Big)(health.CollateralPrice),
+		SimulatedPrice:         simulated,
+		LiquidationPrice:       (*hexutil.Big)(trade.LiquidationPrice),
+		CollateralRatio:        (*hexutil.Big)(health.CollateralRatio),
+		LiquidationTime:        hexutil.Uint64(trade.LiquidationTime),
+		ExpiresIn:              hexutil.Uint64(health.ExpiresIn),
+		Expired:                health.Expired,
+		Liquidatable:           health.Liquidatable,
+		TopUpAmount:            (*hexutil.Big)(health.TopUpAmount),
+		TopUpFunded:            health.TopUpFunded,
+		RecallAmount:           (*hexutil.Big)(health.RecallAmount),
+	}
+}
+
+// nextLiquidationBlock returns the first block after number at which the
+// lending trades are liquidated.
+func nextLiquidationBlock(number, epoch uint64) uint64 {
+	next := number - number%epoch + common.LiquidateLendingTradeBlock
+	if next <= number {
+		next += epoch
+	}
+	return next
+}
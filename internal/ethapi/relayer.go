@@ -0,0 +1,160 @@
+// Copyright (c) 2018 XDPoSChain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"context"
+	"errors"
+	"math/big"
+
+	"github.com/XinFinOrg/XDPoSChain/XDCx/tradingstate"
+	"github.com/XinFinOrg/XDPoSChain/XDCxlending/lendingstate"
+	"github.com/XinFinOrg/XDPoSChain/common"
+	"github.com/XinFinOrg/XDPoSChain/common/hexutil"
+	"github.com/XinFinOrg/XDPoSChain/core/state"
+	"github.com/XinFinOrg/XDPoSChain/rpc"
+)
+
+// RelayerLowFeeMatches is the number of matches the deposit of a relayer must
+// still be able to pay for before it is reported as low.
+const RelayerLowFeeMatches = 1000
+
+var errRelayerNotFound = errors.New("relayer is not registered")
+
+// RelayerPair is a trading pair listed by a relayer.
+type RelayerPair struct {
+	BaseToken  common.Address `json:"baseToken"`
+	QuoteToken common.Address `json:"quoteToken"`
+	OrderCount hexutil.Uint64 `json:"orderCount"`
+}
+
+// RelayerLendingPair is a lending token and term listed by a relayer.
+type RelayerLendingPair struct {
+	LendingToken common.Address   `json:"lendingToken"`
+	Term         hexutil.Uint64   `json:"term"`
+	Collaterals  []common.Address `json:"collaterals"`
+	OrderCount   hexutil.Uint64   `json:"orderCount"`
+}
+
+// RelayerInfo is the configuration and fee status of a relayer. The order
+// counts are the number of orders ever placed in the order books of the pairs,
+// which are shared by all the relayers listing them.
+type RelayerInfo struct {
+	Coinbase           common.Address       `json:"coinbase"`
+	Owner              common.Address       `json:"owner"`
+	Deposit            *hexutil.Big         `json:"deposit"`
+	LockedFund         *hexutil.Big         `json:"lockedFund"`
+	FeeBalance         *hexutil.Big         `json:"feeBalance"`
+	RemainingMatches   hexutil.Uint64       `json:"remainingMatches"`
+	LowFeeBalance      bool                 `json:"lowFeeBalance"`
+	TradeFee           hexutil.Uint64       `json:"tradeFee"`
+	Resigned           bool                 `json:"resigned"`
+	DepositReleaseTime hexutil.Uint64       `json:"depositReleaseTime"`
+	Pairs              []RelayerPair        `json:"pairs"`
+	LendingFee         hexutil.Uint64       `json:"lendingFee"`
+	LendingPairs       []RelayerLendingPair `json:"lendingPairs"`
+}
+
+// GetRelayer returns the configuration and the fee status of the relayer at
+// the given block, the latest one by default.
+func (s *PublicXDCXTransactionPoolAPI) GetRelayer(ctx context.Context, coinbase common.Address, blockNrOrHash *rpc.BlockNumberOrHash) (*RelayerInfo, error) {
+	if blockNrOrHash == nil {
+		latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+		blockNrOrHash = &latest
+	}
+	statedb, _, err := s.b.StateAndHeaderByNumberOrHash(ctx, *blockNrOrHash)
+	if statedb == nil || err != nil {
+		return nil, err
+	}
+	owner := tradingstate.GetRelayerOwner(coinbase, statedb)
+	if owner == (common.Address{}) {
+		return nil, errRelayerNotFound
+	}
+	info := newRelayerInfo(coinbase, owner, statedb)
+
+	// The order counts are only available with the XDCx and lending services
+	if err := s.countRelayerOrders(ctx, info, blockNrOrHash); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// newRelayerInfo reads the registration of the relayer from the relayer and
+// lending registration contracts.
+func newRelayerInfo(coinbase, owner common.Address, statedb *state.StateDB) *RelayerInfo {
+	deposit := tradingstate.GetRelayerDeposit(coinbase, statedb)
+	lockedFund := new(big.Int).Mul(common.BasePrice, common.RelayerLockedFund)
+	balance := new(big.Int).Sub(deposit, lockedFund)
+	if balance.Sign() < 0 {
+		balance = new(big.Int)
+	}
+	matches := new(big.Int).Div(balance, common.RelayerFee).Uint64()
+
+	info := &RelayerInfo{
+		Coinbase:           coinbase,
+		Owner:              owner,
+		Deposit:            (*hexutil.Big)(deposit),
+		LockedFund:         (*hexutil.Big)(lockedFund),
+		FeeBalance:         (*hexutil.Big)(balance),
+		RemainingMatches:   hexutil.Uint64(matches),
+		LowFeeBalance:      matches < RelayerLowFeeMatches,
+		TradeFee:           hexutil.Uint64(tradingstate.GetExRelayerFee(coinbase, statedb).Uint64()),
+		Resigned:           tradingstate.IsResignedRelayer(coinbase, statedb),
+		DepositReleaseTime: hexutil.Uint64(tradingstate.GetResignRequest(coinbase, statedb).Uint64()),
+		Pairs:              []RelayerPair{},
+		LendingFee:         hexutil.Uint64(lendingstate.GetFee(statedb, coinbase).Uint64()),
+		LendingPairs:       []RelayerLendingPair{},
+	}
+	baseLength, quoteLength := tradingstate.GetBaseTokenLength(coinbase, statedb), tradingstate.GetQuoteTokenLength(coinbase, statedb)
+	for i := uint64(0); i < baseLength && i < quoteLength; i++ {
+		info.Pairs = append(info.Pairs, RelayerPair{
+			BaseToken:  tradingstate.GetBaseTokenAtIndex(coinbase, statedb, i),
+			QuoteToken: tradingstate.GetQuoteTokenAtIndex(coinbase, statedb, i),
+		})
+	}
+	bases, terms := lendingstate.GetBaseList(statedb, coinbase), lendingstate.GetTerms(statedb, coinbase)
+	for i := 0; i < len(bases) && i < len(terms); i++ {
+		info.LendingPairs = append(info.LendingPairs, RelayerLendingPair{
+			LendingToken: bases[i],
+			Term:         hexutil.Uint64(terms[i]),
+			Collaterals:  lendingstate.GetCollaterals(statedb, coinbase, bases[i], terms[i]),
+		})
+	}
+	return info
+}
+
+// countRelayerOrders fills the order counts of the pairs of the relayer.
+func (s *PublicXDCXTransactionPoolAPI) countRelayerOrders(ctx context.Context, info *RelayerInfo, blockNrOrHash *rpc.BlockNumberOrHash) error {
+	if s.b.XDCxService() != nil && len(info.Pairs) > 0 {
+		XDCxState, err := s.tradingStateAt(ctx, blockNrOrHash)
+		if err != nil {
+			return err
+		}
+		for i, pair := range info.Pairs {
+			info.Pairs[i].OrderCount = hexutil.Uint64(XDCxState.GetNonce(tradingstate.GetTradingOrderBookHash(pair.BaseToken, pair.QuoteToken)))
+		}
+	}
+	if s.b.LendingService() != nil && len(info.LendingPairs) > 0 {
+		lendingState, err := s.lendingStateAt(ctx, blockNrOrHash)
+		if err != nil {
+			return err
+		}
+		for i, pair := range info.LendingPairs {
+			info.LendingPairs[i].OrderCount = hexutil.Uint64(lendingState.GetNonce(lendingstate.GetLendingOrderBookHash(pair.LendingToken, uint64(pair.Term))))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package ethapi
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/XinFinOrg/XDPoSChain/XDCx/tradingstate"
+	"github.com/XinFinOrg/XDPoSChain/common"
+	"github.com/XinFinOrg/XDPoSChain/core/rawdb"
+	"github.com/XinFinOrg/XDPoSChain/core/state"
+)
+
+// newRelayerTestState registers the relayer in the relayer registration
+// contract with the given deposit and resign request.
+func newRelayerTestState(coinbase, owner, base, quote common.Address, deposit, resignRequest *big.Int) *state.StateDB {
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()))
+	registration := common.HexToAddress(common.RelayerRegistrationSMC)
+	loc := tradingstate.GetLocMappingAtKey(coinbase.Hash(), tradingstate.RelayerMappingSlot["RELAYER_LIST"])
+	field := func(name string) common.Hash {
+		return common.BigToHash(new(big.Int).Add(loc, tradingstate.RelayerStructMappingSlot[name]))
+	}
+	statedb.SetState(registration, field("_deposit"), common.BigToHash(deposit))
+	statedb.SetState(registration, field("_fee"), common.BigToHash(big.NewInt(25)))
+	statedb.SetState(registration, field("_owner"), owner.Hash())
+	statedb.SetState(registration, field("_fromTokens"), common.BigToHash(common.Big1))
+	statedb.SetState(registration, state.GetLocDynamicArrAtElement(field("_fromTokens"), 0, 1), base.Hash())
+	statedb.SetState(registration, field("_toTokens"), common.BigToHash(common.Big1))
+	statedb.SetState(registration, state.GetLocDynamicArrAtElement(field("_toTokens"), 0, 1), quote.Hash())
+
+	resign := tradingstate.GetLocMappingAtKey(coinbase.Hash(), tradingstate.RelayerMappingSlot["RESIGN_REQUESTS"])
+	statedb.SetState(registration, common.BigToHash(resign), common.BigToHash(resignRequest))
+	return statedb
+}
+
+func TestNewRelayerInfo(t *testing.T) {
+	var (
+		coinbase   = common.HexToAddress("0x0000000000000000000000000000000000000099")
+		owner      = common.HexToAddress("0x0000000000000000000000000000000000000098")
+		base       = common.HexToAddress("0x0000000000000000000000000000000000000042")
+		quote      = common.HexToAddress("0x0000000000000000000000000000000000000001")
+		lockedFund = new(big.Int).Mul(common.BasePrice, common.RelayerLockedFund)
+	)
+	// Deposit above the locked fund paying for the given number of matches
+	deposit := func(matches int64) *big.Int {
+		return new(big.Int).Add(lockedFund, new(big.Int).Mul(common.RelayerFee, big.NewInt(matches)))
+	}
+	tests := []struct {
+		name     string
+		deposit  *big.Int
+		resign   *big.Int
+		balance  *big.Int
+		matches  uint64
+		low      bool
+		resigned bool
+	}{
+		{
+			name:    "funded",
+			deposit: deposit(5000),
+			resign:  common.Big0,
+			balance: new(big.Int).Mul(common.RelayerFee, big.NewInt(5000)),
+			matches: 5000,
+		},
+		{
+			name:    "low fee balance",
+			deposit: deposit(RelayerLowFeeMatches - 1),
+			resign:  common.Big0,
+			balance: new(big.Int).Mul(common.RelayerFee, big.NewInt(RelayerLowFeeMatches-1)),
+			matches: RelayerLowFeeMatches - 1,
+			low:     true,
+		},
+		{
+			name:    "deposit below the locked fund",
+			deposit: new(big.Int).Sub(lockedFund, common.Big1),
+			resign:  common.Big0,
+			balance: common.Big0,
+			low:     true,
+		},
+		{
+			name:     "resigned",
+			deposit:  deposit(5000),
+			resign:   big.NewInt(1700000000),
+			balance:  new(big.Int).Mul(common.RelayerFee, big.NewInt(5000)),
+			matches:  5000,
+			resigned: true,
+		},
+	}
+	for _, tt := range tests {
+		statedb := newRelayerTestState(coinbase, owner, base, quote, tt.deposit, tt.resign)
+		info := newRelayerInfo(coinbase, owner, statedb)
+
+		if info.Coinbase != coinbase || info.Owner != owner || uint64(info.TradeFee) != 25 {
+			t.Errorf("%s: relayer mismatch: %+v", tt.name, info)
+		}
+		if info.Deposit.ToInt().Cmp(tt.deposit) != 0 || info.LockedFund.ToInt().Cmp(lockedFund) != 0 {
+			t.Errorf("%s: deposit mismatch: have %v locked %v, want %v locked %v", tt.name, info.Deposit, info.LockedFund, tt.deposit, lockedFund)
+		}
+		if info.FeeBalance.ToInt().Cmp(tt.balance) != 0 || uint64(info.RemainingMatches) != tt.matches || info.LowFeeBalance != tt.low {
+			t.Errorf("%s: fee balance mismatch: have %v (%d matches, low %v), want %v (%d matches, low %v)", tt.name, info.FeeBalance, info.RemainingMatches, info.LowFeeBalance, tt.balance, tt.matches, tt.low)
+		}
+		if info.Resigned != tt.resigned || uint64(info.DepositReleaseTime) != tt.resign.Uint64() {
+			t.Errorf("%s: resign mismatch: have %v at %d, want %v at %v", tt.name, info.Resigned, info.DepositReleaseTime, tt.resigned, tt.resign)
+		}
+		if len(info.Pairs) != 1 || info.Pairs[0].BaseToken != base || info.Pairs[0].QuoteToken != quote {
+			t.Errorf("%s: pairs mismatch: %+v", tt.name, info.Pairs)
+		}
+		if len(info.LendingPairs) != 0 {
+			t.Errorf("%s: unexpected lending pairs: %+v", tt.name, info.LendingPairs)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+// Copyright (c) 2018 XDPoSChain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"context"
+	"errors"
+	"math/big"
+
+	"github.com/XinFinOrg/XDPoSChain/common"
+	"github.com/XinFinOrg/XDPoSChain/common/hexutil"
+	"github.com/XinFinOrg/XDPoSChain/core"
+	"github.com/XinFinOrg/XDPoSChain/core/state"
+	"github.com/XinFinOrg/XDPoSChain/core/types"
+	"github.com/XinFinOrg/XDPoSChain/log"
+	"github.com/XinFinOrg/XDPoSChain/rpc"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+// trc21TokensCacheLimit is the number of blocks whose sponsored tokens are kept,
+// so the receipts of a block read the state of its parent once.
+const trc21TokensCacheLimit = 128
+
+var (
+	errTRC21TokenNotFound = errors.New("token is not registered in the TRC21 issuer")
+	errTRC21StateNotFound = errors.New("parent state not available")
+	trc21TokensCache, _   = lru.New(trc21TokensCacheLimit) // parent hash -> map[common.Address]*big.Int
+)
+
+// PublicTRC21API exposes the fee capacity the TRC21 issuer keeps for the tokens
+// whose transaction fees it sponsors.
+type PublicTRC21API struct {
+	b Backend
+}
+
+// NewPublicTRC21API creates a new TRC21 fee capacity API.
+func NewPublicTRC21API(b Backend) *PublicTRC21API {
+	return &PublicTRC21API{b}
+}
+
+// RPCTRC21CapacityAlert is the notification sent to trc21FeeCapacityLow
+// subscribers.
+type RPCTRC21CapacityAlert struct {
+	Token       common.Address `json:"token"`
+	Capacity    *hexutil.Big   `json:"capacity"`
+	Threshold   *hexutil.Big   `json:"threshold"`
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	BlockHash   common.Hash    `json:"blockHash"`
+}
+
+// GetTRC21FeeCapacity returns the amount of wei the TRC21 issuer can still spend
+// on the fees of the transactions to the token at the given block.
+func (s *PublicTRC21API) GetTRC21FeeCapacity(ctx context.Context, token common.Address, blockNrOrHash rpc.BlockNumberOrHash) (*hexutil.Big, error) {
+	tokens, err := s.feeCapacities(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	capacity, ok := tokens[token]
+	if !ok {
+		return nil, errTRC21TokenNotFound
+	}
+	return (*hexutil.Big)(capacity), nil
+}
+
+// ListTRC21Tokens returns the tokens registered in the TRC21 issuer at the
+// latest block, along with their fee capacity.
+func (s *PublicTRC21API) ListTRC21Tokens(ctx context.Context) (map[common.Address]*hexutil.Big, error) {
+	tokens, err := s.feeCapacities(ctx, rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber))
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[common.Address]*hexutil.Big, len(tokens))
+	for token, capacity := range tokens {
+		result[token] = (*hexutil.Big)(capacity)
+	}
+	return result, nil
+}
+
+// TRC21FeeCapacityLow creates a subscription that is triggered each time the fee
+// capacity of a token drops below the threshold. It fires again for a token
+// only after its capacity was topped up above the threshold. If tokens is
+// empty, all the registered tokens are watched.
+func (s *PublicTRC21API) TRC21FeeCapacityLow(ctx context.Context, threshold hexutil.Big, tokens *[]common.Address) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	var watched map[common.Address]bool
+	if tokens != nil && len(*tokens) > 0 {
+		watched = make(map[common.Address]bool, len(*tokens))
+		for _, token := range *tokens {
+			watched[token] = true
+		}
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		heads := make(chan core.ChainHeadEvent, 16)
+		headSub := s.b.SubscribeChainHeadEvent(heads)
+		defer headSub.Unsubscribe()
+
+		below := make(map[common.Address]bool)
+		for {
+			select {
+			case ev := <-heads:
+				capacities, err := s.feeCapacities(context.Background(), rpc.BlockNumberOrHashWithHash(ev.Block.Hash(), false))
+				if err != nil {
+					log.Debug("Failed to read the TRC21 fee capacities", "number", ev.Block.NumberU64(), "err", err)
+					continue
+				}
+				for token, capacity := range capacities {
+					if watched != nil && !watched[token] {
+						continue
+					}
+					if capacity.Cmp(threshold.ToInt()) >= 0 {
+						delete(below, token)
+						continue
+					}
+					if below[token] {
+						continue
+					}
+					below[token] = true
+					notifier.Notify(rpcSub.ID, &RPCTRC21CapacityAlert{
+						Token:       token,
+						Capacity:    (*hexutil.Big)(capacity),
+						Threshold:   &threshold,
+						BlockNumber: hexutil.Uint64(ev.Block.NumberU64()),
+						BlockHash:   ev.Block.Hash(),
+					})
+				}
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
+// feeCapacities reads the fee capacity of the registered tokens at the given block.
+func (s *PublicTRC21API) feeCapacities(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (map[common.Address]*big.Int, error) {
+	statedb, header, err := s.b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	if statedb == nil || err != nil {
+		return nil, err
+	}
+	return state.GetTRC21FeeCapacityFromStateWithCache(header.Root, statedb), nil
+}
+
+// trc21SponsoredTokens returns the tokens whose fees were paid by the TRC21
+// issuer in the block with the given parent, which are the tokens registered
+// at the parent. It fails if the parent state is not available anymore.
+func trc21SponsoredTokens(ctx context.Context, b Backend, parent common.Hash) (map[common.Address]*big.Int, error) {
+	if tokens, ok := trc21TokensCache.Get(parent); ok {
+		return tokens.(map[common.Address]*big.Int), nil
+	}
+	statedb, header, err := b.StateAndHeaderByNumberOrHash(ctx, rpc.BlockNumberOrHashWithHash(parent, false))
+	if err != nil {
+		return nil, err
+	}
+	if statedb == nil {
+		return nil, errTRC21StateNotFound
+	}
+	tokens := state.GetTRC21FeeCapacityFromStateWithCache(header.Root, statedb)
+	trc21TokensCache.Add(parent, tokens)
+	return tokens, nil
+}
+
+// setTRC21FeePaid reports in the receipt fields how much of the fee of the
+// transaction was paid from the fee capacity of a TRC21 token. If the sponsored
+// tokens are not known, as the parent state was pruned, the field is null.
+func setTRC21FeePaid(fields map[string]interface{}, tokens map[common.Address]*big.Int, tx *types.Transaction, blockNumber uint64, gasUsed uint64) {
+	if tx.To() == nil {
+		return
+	}
+	if tokens == nil {
+		fields["trc21FeePaid"] = nil
+		return
+	}
+	if _, ok := tokens[*tx.To()]; ok {
+		fields["trc21FeePaid"] = (*hexutil.Big)(common.GetGasFee(blockNumber, gasUsed))
+	}
+}
@@ -0,0 +1,77 @@
+package ethapi
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/XinFinOrg/XDPoSChain/common"
+	"github.com/XinFinOrg/XDPoSChain/common/hexutil"
+	"github.com/XinFinOrg/XDPoSChain/core/state"
+	"github.com/XinFinOrg/XDPoSChain/core/types"
+	"github.com/XinFinOrg/XDPoSChain/rpc"
+)
+
+// trc21TestBackend counts the state loads of the TRC21 sponsored tokens, and
+// serves no state once pruned.
+type trc21TestBackend struct {
+	*callBundleTestBackend
+	loads  int
+	pruned bool
+}
+
+func (b *trc21TestBackend) StateAndHeaderByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*state.StateDB, *types.Header, error) {
+	b.loads++
+	if b.pruned {
+		return nil, nil, errors.New("missing trie node")
+	}
+	return b.callBundleTestBackend.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+}
+
+func TestTRC21SponsoredTokens(t *testing.T) {
+	b := &trc21TestBackend{callBundleTestBackend: newCallBundleTestBackend(t, common.Address{}, big.NewInt(1000))}
+	parent := b.block.Hash()
+	ctx := context.Background()
+
+	// The receipts of a block read the state of its parent once
+	for i := 0; i < 3; i++ {
+		tokens, err := trc21SponsoredTokens(ctx, b, parent)
+		if err != nil {
+			t.Fatalf("failed to read the sponsored tokens: %v", err)
+		}
+		if tokens[tokenAddr] == nil || tokens[tokenAddr].Cmp(big.NewInt(1000)) != 0 {
+			t.Fatalf("sponsored tokens mismatch: %v", tokens)
+		}
+		fields := make(map[string]interface{})
+		setTRC21FeePaid(fields, tokens, types.NewTransaction(0, tokenAddr, nil, 50000, nil, nil), 2, 21000)
+		if fee, ok := fields["trc21FeePaid"].(*hexutil.Big); !ok || fee.ToInt().Cmp(common.GetGasFee(2, 21000)) != 0 {
+			t.Errorf("sponsored fee mismatch: have %v, want %v", fields["trc21FeePaid"], common.GetGasFee(2, 21000))
+		}
+		fields = make(map[string]interface{})
+		setTRC21FeePaid(fields, tokens, types.NewTransaction(0, counterAddr, nil, 50000, nil, nil), 2, 21000)
+		if _, ok := fields["trc21FeePaid"]; ok {
+			t.Errorf("fee of an unsponsored transaction reported: %v", fields["trc21FeePaid"])
+		}
+	}
+	if b.loads != 1 {
+		t.Errorf("parent state loaded %d times, want 1", b.loads)
+	}
+
+	// Without the parent state the field is null instead of missing
+	b.pruned = true
+	tokens, err := trc21SponsoredTokens(ctx, b, common.HexToHash("0x01"))
+	if err == nil {
+		t.Fatal("pruned parent state read")
+	}
+	fields := make(map[string]interface{})
+	setTRC21FeePaid(fields, tokens, types.NewTransaction(0, counterAddr, nil, 50000, nil, nil), 2, 21000)
+	if fee, ok := fields["trc21FeePaid"]; !ok || fee != nil {
+		t.Errorf("unknown sponsored fee mismatch: have %v (set %v), want null", fee, ok)
+	}
+	fields = make(map[string]interface{})
+	setTRC21FeePaid(fields, tokens, types.NewContractCreation(0, nil, 50000, nil, nil), 2, 21000)
+	if _, ok := fields["trc21FeePaid"]; ok {
+		t.Errorf("fee of a contract creation reported: %v", fields["trc21FeePaid"])
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright (c) 2018 XDPoSChain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/XinFinOrg/XDPoSChain/XDCx/tradingstate"
+	"github.com/XinFinOrg/XDPoSChain/XDCxlending/lendingstate"
+	"github.com/XinFinOrg/XDPoSChain/core/types"
+	"github.com/XinFinOrg/XDPoSChain/rpc"
+)
+
+// errStateNotAvailable is returned when the XDCx tries of the requested block
+// are missing from the database, usually because they have been pruned.
+var errStateNotAvailable = errors.New("state not available")
+
+// xdcxBlock returns the block the XDCx state is read at, the current block if
+// blockNrOrHash is nil.
+func (s *PublicXDCXTransactionPoolAPI) xdcxBlock(ctx context.Context, blockNrOrHash *rpc.BlockNumberOrHash) (*types.Block, error) {
+	if blockNrOrHash == nil {
+		block := s.b.CurrentBlock()
+		if block == nil {
+			return nil, errors.New("Current block not found")
+		}
+		return block, nil
+	}
+	block, err := s.b.BlockByNumberOrHash(ctx, *blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, errors.New("block not found")
+	}
+	return block, nil
+}
+
+// tradingStateAt returns the XDCx trading state at the given block, the current
+// block if blockNrOrHash is nil.
+func (s *PublicXDCXTransactionPoolAPI) tradingStateAt(ctx context.Context, blockNrOrHash *rpc.BlockNumberOrHash) (*tradingstate.TradingStateDB, error) {
+	XDCxService := s.b.XDCxService()
+	if XDCxService == nil {
+		return nil, errors.New("XDCX service not found")
+	}
+	block, err := s.xdcxBlock(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	author, err := s.b.GetEngine().Author(block.Header())
+	if err != nil {
+		return nil, err
+	}
+	root, err := XDCxService.GetTradingStateRoot(block, author)
+	if err != nil {
+		return nil, err
+	}
+	XDCxState, err := tradingstate.New(root, XDCxService.GetStateCache())
+	if err != nil {
+		return nil, fmt.Errorf("trading %w at block %d (root %x): %v", errStateNotAvailable, block.Number(), root, err)
+	}
+	return XDCxState, nil
+}
+
+// lendingStateAt returns the XDCx lending state at the given block, the
+// current block if blockNrOrHash is nil.
+func (s *PublicXDCXTransactionPoolAPI) lendingStateAt(ctx context.Context, blockNrOrHash *rpc.BlockNumberOrHash) (*lendingstate.LendingStateDB, error) {
+	lendingService := s.b.LendingService()
+	if lendingService == nil {
+		return nil, errors.New("XDCX Lending service not found")
+	}
+	block, err := s.xdcxBlock(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	author, err := s.b.GetEngine().Author(block.Header())
+	if err != nil {
+		return nil, err
+	}
+	root, err := lendingService.GetLendingStateRoot(block, author)
+	if err != nil {
+		return nil, err
+	}
+	lendingState, err := lendingstate.New(root, lendingService.GetStateCache())
+	if err != nil {
+		return nil, fmt.Errorf("lending %w at block %d (root %x): %v", errStateNotAvailable, block.Number(), root, err)
+	}
+	return lendingState, nil
+}
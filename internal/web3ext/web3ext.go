@@ -22,6 +22,7 @@ var Modules = map[string]string{
 	"chequebook":  Chequebook_JS,
 	"clique":      Clique_JS,
 	"XDPoS":       XDPoS_JS,
+	"XDC":         XDC_JS,
 	"debug":       Debug_JS,
 	"eth":         Eth_JS,
 	"miner":       Miner_JS,
@@ -168,6 +169,15 @@ web3._extend({
 			params: 2,
 			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter, web3._extend.formatters.inputBlockNumberFormatter]
 		}),
+		new web3._extend.Method({
+			name: 'exportSlashingProtection',
+			call: 'XDPoS_exportSlashingProtection'
+		}),
+		new web3._extend.Method({
+			name: 'importSlashingProtection',
+			call: 'XDPoS_importSlashingProtection',
+			params: 1
+		}),
 	],
 	properties: [
 		new web3._extend.Property({
@@ -538,6 +548,18 @@ web3._extend({
 			call: 'eth_getBlockReceipts',
 			params: 1,
 		}),
+		new web3._extend.Method({
+			name: 'callMany',
+			call: 'eth_callMany',
+			params: 4,
+			inputFormatter: [null, web3._extend.formatters.inputBlockNumberFormatter, null, null],
+		}),
+		new web3._extend.Method({
+			name: 'callBundle',
+			call: 'eth_callBundle',
+			params: 4,
+			inputFormatter: [null, web3._extend.formatters.inputBlockNumberFormatter, null, null],
+		}),
 	],
 	properties: [
 		new web3._extend.Property({
@@ -688,6 +710,26 @@ web3._extend({
 });
 `
 
+const XDC_JS = `
+web3._extend({
+	property: 'XDC',
+	methods: [
+		new web3._extend.Method({
+			name: 'getTRC21FeeCapacity',
+			call: 'XDC_getTRC21FeeCapacity',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, web3._extend.formatters.inputDefaultBlockNumberFormatter],
+			outputFormatter: web3._extend.utils.toBigNumber
+		}),
+		new web3._extend.Method({
+			name: 'listTRC21Tokens',
+			call: 'XDC_listTRC21Tokens',
+			params: 0
+		}),
+	]
+});
+`
+
 const XDCX_JS = `
 web3._extend({
 	property: 'XDCx',
@@ -748,122 +790,158 @@ web3._extend({
 		new web3._extend.Method({
             name: 'getOrderCount',
             call: 'XDCx_getOrderCount',
-            params: 1
+            params: 2,
+            inputFormatter: [web3._extend.formatters.inputAddressFormatter, null]
         }),
+		new web3._extend.Method({
+			name: 'getRelayer',
+			call: 'XDCx_getRelayer',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, web3._extend.formatters.inputDefaultBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getLendingPositions',
+			call: 'XDCx_getLendingPositions',
+			params: 3,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, web3._extend.formatters.inputDefaultBlockNumberFormatter, null]
+		}),
 		new web3._extend.Method({
             name: 'getBestBid',
             call: 'XDCx_getBestBid',
-            params: 2
+            params: 3,
+            inputFormatter: [null, null, web3._extend.formatters.inputDefaultBlockNumberFormatter]
 		}),
 		new web3._extend.Method({
             name: 'getBestAsk',
             call: 'XDCx_getBestAsk',
-            params: 2
+            params: 3,
+            inputFormatter: [null, null, web3._extend.formatters.inputDefaultBlockNumberFormatter]
 		}),
 		new web3._extend.Method({
             name: 'getBidTree',
             call: 'XDCx_getBidTree',
-            params: 2
+            params: 3,
+            inputFormatter: [null, null, web3._extend.formatters.inputDefaultBlockNumberFormatter]
 		}),
 		new web3._extend.Method({
             name: 'getAskTree',
             call: 'XDCx_getAskTree',
-            params: 2
+            params: 3,
+            inputFormatter: [null, null, web3._extend.formatters.inputDefaultBlockNumberFormatter]
 		}),
 		new web3._extend.Method({
             name: 'getOrderById',
             call: 'XDCx_getOrderById',
-            params: 3
+            params: 4,
+            inputFormatter: [null, null, null, web3._extend.formatters.inputDefaultBlockNumberFormatter]
 		}),
 		new web3._extend.Method({
             name: 'getPrice',
             call: 'XDCx_getPrice',
-            params: 2
+            params: 3,
+            inputFormatter: [null, null, web3._extend.formatters.inputDefaultBlockNumberFormatter]
 		}),
 		new web3._extend.Method({
             name: 'getLastEpochPrice',
             call: 'XDCx_getLastEpochPrice',
-            params: 2
+            params: 3,
+            inputFormatter: [null, null, web3._extend.formatters.inputDefaultBlockNumberFormatter]
 		}),
 		new web3._extend.Method({
             name: 'getCurrentEpochPrice',
             call: 'XDCx_getCurrentEpochPrice',
-            params: 2
+            params: 3,
+            inputFormatter: [null, null, web3._extend.formatters.inputDefaultBlockNumberFormatter]
 		}),
 		new web3._extend.Method({
             name: 'getTradingOrderBookInfo',
             call: 'XDCx_getTradingOrderBookInfo',
-            params: 2
+            params: 3,
+            inputFormatter: [null, null, web3._extend.formatters.inputDefaultBlockNumberFormatter]
 		}),
 		new web3._extend.Method({
             name: 'getLiquidationPriceTree',
             call: 'XDCx_getLiquidationPriceTree',
-            params: 2
+            params: 3,
+            inputFormatter: [null, null, web3._extend.formatters.inputDefaultBlockNumberFormatter]
 		}),
 		new web3._extend.Method({
             name: 'getInvestingTree',
             call: 'XDCx_getInvestingTree',
-            params: 2
+            params: 3,
+            inputFormatter: [null, null, web3._extend.formatters.inputDefaultBlockNumberFormatter]
 		}),
 		new web3._extend.Method({
             name: 'getBorrowingTree',
             call: 'XDCx_getBorrowingTree',
-            params: 2
+            params: 3,
+            inputFormatter: [null, null, web3._extend.formatters.inputDefaultBlockNumberFormatter]
 		}),
 		new web3._extend.Method({
             name: 'getLendingOrderBookInfo',
             call: 'XDCx_getLendingOrderBookInfo',
-            params: 2
+            params: 3,
+            inputFormatter: [null, null, web3._extend.formatters.inputDefaultBlockNumberFormatter]
 		}),
 		new web3._extend.Method({
             name: 'getLendingOrderTree',
             call: 'XDCx_getLendingOrderTree',
-            params: 2
+            params: 3,
+            inputFormatter: [null, null, web3._extend.formatters.inputDefaultBlockNumberFormatter]
 		}),
 		new web3._extend.Method({
             name: 'getLendingTradeTree',
             call: 'XDCx_getLendingTradeTree',
-            params: 2
+            params: 3,
+            inputFormatter: [null, null, web3._extend.formatters.inputDefaultBlockNumberFormatter]
 		}),
 		new web3._extend.Method({
             name: 'getLiquidationTimeTree',
             call: 'XDCx_getLiquidationTimeTree',
-            params: 2
+            params: 3,
+            inputFormatter: [null, null, web3._extend.formatters.inputDefaultBlockNumberFormatter]
 		}),
 		new web3._extend.Method({
             name: 'getLendingOrderCount',
             call: 'XDCx_getLendingOrderCount',
-            params: 1
+            params: 2,
+            inputFormatter: [null, web3._extend.formatters.inputDefaultBlockNumberFormatter]
         }),
 		new web3._extend.Method({
             name: 'getBestInvesting',
             call: 'XDCx_getBestInvesting',
-            params: 2
+            params: 3,
+            inputFormatter: [null, null, web3._extend.formatters.inputDefaultBlockNumberFormatter]
 		}),
 		new web3._extend.Method({
             name: 'getBestBorrowing',
             call: 'XDCx_getBestBorrowing',
-            params: 2
+            params: 3,
+            inputFormatter: [null, null, web3._extend.formatters.inputDefaultBlockNumberFormatter]
 		}),
 		new web3._extend.Method({
             name: 'getBids',
             call: 'XDCx_getBids',
-            params: 2
+            params: 3,
+            inputFormatter: [null, null, web3._extend.formatters.inputDefaultBlockNumberFormatter]
 		}),
 		new web3._extend.Method({
             name: 'getAsks',
             call: 'XDCx_getAsks',
-            params: 2
+            params: 3,
+            inputFormatter: [null, null, web3._extend.formatters.inputDefaultBlockNumberFormatter]
 		}),
 		new web3._extend.Method({
             name: 'getInvests',
             call: 'XDCx_getInvests',
-            params: 2
+            params: 3,
+            inputFormatter: [null, null, web3._extend.formatters.inputDefaultBlockNumberFormatter]
 		}),
 		new web3._extend.Method({
             name: 'getBorrows',
             call: 'XDCx_getBorrows',
-            params: 2
+            params: 3,
+            inputFormatter: [null, null, web3._extend.formatters.inputDefaultBlockNumberFormatter]
 		}),
 		new web3._extend.Method({
             name: 'getLendingTxMatchByHash',
@@ -878,12 +956,14 @@ web3._extend({
 		new web3._extend.Method({
             name: 'getLendingOrderById',
             call: 'XDCx_getLendingOrderById',
-            params: 3
+            params: 4,
+            inputFormatter: [null, null, null, web3._extend.formatters.inputDefaultBlockNumberFormatter]
 		}),
 		new web3._extend.Method({
             name: 'getLendingTradeById',
             call: 'XDCx_getLendingTradeById',
-            params: 3
+            params: 4,
+            inputFormatter: [null, null, null, web3._extend.formatters.inputDefaultBlockNumberFormatter]
 		}),
 	]
 });
@@ -1035,7 +1115,14 @@ web3._extend({
 const TxPool_JS = `
 web3._extend({
 	property: 'txpool',
-	methods: [],
+	methods:
+	[
+		new web3._extend.Method({
+			name: 'getTransactionHistory',
+			call: 'txpool_getTransactionHistory',
+			params: 1
+		}),
+	],
 	properties:
 	[
 		new web3._extend.Property({
@@ -234,6 +234,17 @@ func (b *LesApiBackend) SubscribeNewTxsEvent(ch chan<- core.NewTxsEvent) event.S
 	return b.eth.txPool.SubscribeNewTxsEvent(ch)
 }
 
+func (b *LesApiBackend) GetPoolTransactionHistory(hash common.Hash) []core.TxLifecycleEvent {
+	return nil
+}
+
+func (b *LesApiBackend) SubscribeDroppedTxEvent(ch chan<- core.DroppedTxEvent) event.Subscription {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		<-quit
+		return nil
+	})
+}
+
 func (b *LesApiBackend) SubscribeChainEvent(ch chan<- core.ChainEvent) event.Subscription {
 	return b.eth.blockchain.SubscribeChainEvent(ch)
 }
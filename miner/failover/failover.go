@@ -0,0 +1,195 @@
+// Copyright (c) 2018 XDPoSChain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+// Package failover implements an active/passive setup for masternodes: several
+// nodes share the same key, but only the one holding the lease of a LockProvider
+// seals blocks and signs votes and timeouts. The others follow the chain in
+// observe-only mode and take over once the lease becomes available.
+package failover
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/XinFinOrg/XDPoSChain/log"
+	"github.com/XinFinOrg/XDPoSChain/metrics"
+)
+
+const DefaultInterval = 2 * time.Second
+
+var (
+	roleGauge      = metrics.NewRegisteredGauge("failover/role", nil) // 1 while active, 0 while standby
+	promotionMeter = metrics.NewRegisteredMeter("failover/promotions", nil)
+	demotionMeter  = metrics.NewRegisteredMeter("failover/demotions", nil)
+)
+
+// Role is the part a node currently plays in the failover setup.
+type Role int32
+
+const (
+	RoleStandby Role = iota
+	RoleActive
+)
+
+func (r Role) String() string {
+	switch r {
+	case RoleActive:
+		return "active"
+	default:
+		return "standby"
+	}
+}
+
+// Config contains the settings of the failover manager.
+type Config struct {
+	Holder   string        // Identity of this node towards the lock provider
+	Interval time.Duration // How often the lease is acquired or refreshed
+}
+
+// Manager acquires and keeps the lease of a LockProvider and switches the node
+// between the active and the standby role accordingly.
+type Manager struct {
+	provider LockProvider
+	config   Config
+
+	// onActive is called after the lease is acquired, it should enable signing.
+	// onStandby is called before the lease is released, it should disable signing
+	// and only return once nothing is being signed any more.
+	onActive  func()
+	onStandby func()
+
+	role int32 // Role, accessed atomically
+	lock sync.Mutex
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+func New(provider LockProvider, config Config, onActive func(), onStandby func()) *Manager {
+	if config.Interval == 0 {
+		config.Interval = DefaultInterval
+	}
+	return &Manager{
+		provider:  provider,
+		config:    config,
+		onActive:  onActive,
+		onStandby: onStandby,
+		role:      int32(RoleStandby),
+	}
+}
+
+// Start begins competing for the lease in the background.
+func (m *Manager) Start() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.quit != nil {
+		return
+	}
+	m.quit = make(chan struct{})
+	roleGauge.Update(int64(RoleStandby))
+	log.Info("Failover manager started in standby mode", "holder", m.config.Holder)
+
+	m.wg.Add(1)
+	go m.loop(m.quit)
+}
+
+// Stop steps down if the node is active, relinquishes the lease and stops
+// competing for it.
+func (m *Manager) Stop() {
+	m.lock.Lock()
+	if m.quit == nil {
+		m.lock.Unlock()
+		return
+	}
+	close(m.quit)
+	m.quit = nil
+	m.lock.Unlock()
+
+	m.wg.Wait()
+}
+
+func (m *Manager) Role() Role {
+	return Role(atomic.LoadInt32(&m.role))
+}
+
+func (m *Manager) IsActive() bool {
+	return m.Role() == RoleActive
+}
+
+func (m *Manager) loop(quit chan struct{}) {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(m.config.Interval)
+	defer ticker.Stop()
+
+	m.step()
+	for {
+		select {
+		case <-ticker.C:
+			m.step()
+		case <-quit:
+			if m.IsActive() {
+				m.demote("shutting down")
+			}
+			return
+		}
+	}
+}
+
+// step refreshes the lease while active, or tries to take it while standby.
+func (m *Manager) step() {
+	if m.IsActive() {
+		if err := m.provider.Refresh(m.config.Holder); err != nil {
+			log.Error("Failed to refresh the failover lease", "holder", m.config.Holder, "err", err)
+			m.demote("lease lost")
+		}
+		return
+	}
+	ok, err := m.provider.TryLock(m.config.Holder)
+	if err != nil {
+		log.Warn("Failed to acquire the failover lease", "holder", m.config.Holder, "err", err)
+		return
+	}
+	if ok {
+		m.promote()
+	}
+}
+
+func (m *Manager) promote() {
+	atomic.StoreInt32(&m.role, int32(RoleActive))
+	roleGauge.Update(int64(RoleActive))
+	promotionMeter.Mark(1)
+	log.Info("Failover lease acquired, switching to active mode", "holder", m.config.Holder)
+
+	if m.onActive != nil {
+		m.onActive()
+	}
+}
+
+func (m *Manager) demote(reason string) {
+	log.Warn("Switching to standby mode", "holder", m.config.Holder, "reason", reason)
+	// Stop signing before the lease can be taken over by another node
+	if m.onStandby != nil {
+		m.onStandby()
+	}
+	atomic.StoreInt32(&m.role, int32(RoleStandby))
+	roleGauge.Update(int64(RoleStandby))
+	demotionMeter.Mark(1)
+
+	if err := m.provider.Unlock(m.config.Holder); err != nil {
+		log.Error("Failed to release the failover lease", "holder", m.config.Holder, "err", err)
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright (c) 2018 XDPoSChain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package failover
+
+import (
+	"errors"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type testSigner struct {
+	signing int32
+}
+
+func (s *testSigner) manager(provider LockProvider, holder string) *Manager {
+	return New(provider, Config{Holder: holder, Interval: 10 * time.Millisecond},
+		func() { atomic.StoreInt32(&s.signing, 1) },
+		func() { atomic.StoreInt32(&s.signing, 0) },
+	)
+}
+
+func (s *testSigner) isSigning() bool {
+	return atomic.LoadInt32(&s.signing) == 1
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	for i := 0; i < 100; i++ {
+		if cond() {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %s", what)
+}
+
+func TestFailoverHandOver(t *testing.T) {
+	provider := NewMemoryLockProvider(time.Second)
+	primary, standby := new(testSigner), new(testSigner)
+
+	first := primary.manager(provider, "primary")
+	first.Start()
+	waitFor(t, "primary to become active", first.IsActive)
+
+	second := standby.manager(provider, "standby")
+	second.Start()
+	defer second.Stop()
+
+	time.Sleep(50 * time.Millisecond)
+	if second.IsActive() || standby.isSigning() {
+		t.Fatalf("standby became active while the lease is held")
+	}
+	if !primary.isSigning() {
+		t.Fatalf("active node is not signing")
+	}
+
+	first.Stop()
+	if first.IsActive() || primary.isSigning() {
+		t.Fatalf("stopped node is still active")
+	}
+	waitFor(t, "standby to take over", second.IsActive)
+	if !standby.isSigning() {
+		t.Fatalf("promoted node is not signing")
+	}
+}
+
+// lostLeaseProvider simulates a lease taken over by another node, for example
+// after the active node was paused for longer than the lease time to live.
+type lostLeaseProvider struct {
+	LockProvider
+	lost int32
+}
+
+func (p *lostLeaseProvider) TryLock(holder string) (bool, error) {
+	if atomic.LoadInt32(&p.lost) == 1 {
+		return false, nil
+	}
+	return p.LockProvider.TryLock(holder)
+}
+
+func (p *lostLeaseProvider) Refresh(holder string) error {
+	if atomic.LoadInt32(&p.lost) == 1 {
+		return ErrLeaseLost
+	}
+	return p.LockProvider.Refresh(holder)
+}
+
+func TestFailoverLeaseLost(t *testing.T) {
+	provider := &lostLeaseProvider{LockProvider: NewMemoryLockProvider(time.Second)}
+	signer := new(testSigner)
+
+	m := signer.manager(provider, "primary")
+	m.Start()
+	defer m.Stop()
+	waitFor(t, "node to become active", m.IsActive)
+
+	atomic.StoreInt32(&provider.lost, 1)
+	waitFor(t, "node to step down", func() bool { return !m.IsActive() })
+	if signer.isSigning() {
+		t.Fatalf("node is still signing after losing the lease")
+	}
+	time.Sleep(50 * time.Millisecond)
+	if m.IsActive() {
+		t.Fatalf("node became active while the lease is held by another node")
+	}
+}
+
+func TestMemoryLockProviderExpiry(t *testing.T) {
+	provider := NewMemoryLockProvider(20 * time.Millisecond)
+	if ok, _ := provider.TryLock("primary"); !ok {
+		t.Fatalf("failed to take free lease")
+	}
+	if ok, _ := provider.TryLock("standby"); ok {
+		t.Fatalf("took lease held by another holder")
+	}
+	time.Sleep(30 * time.Millisecond)
+	if ok, _ := provider.TryLock("standby"); !ok {
+		t.Fatalf("expired lease could not be taken over")
+	}
+	if err := provider.Refresh("primary"); !errors.Is(err, ErrLeaseLost) {
+		t.Fatalf("refresh of a lost lease: have %v, want %v", err, ErrLeaseLost)
+	}
+}
+
+func TestFileLockProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "LOCK")
+	first, second := NewFileLockProvider(path), NewFileLockProvider(path)
+
+	if ok, err := first.TryLock("first"); !ok || err != nil {
+		t.Fatalf("failed to take free lock: %v %v", ok, err)
+	}
+	if ok, err := second.TryLock("second"); ok || err != nil {
+		t.Fatalf("took lock held by another holder: %v %v", ok, err)
+	}
+	if err := first.Refresh("first"); err != nil {
+		t.Fatalf("failed to refresh held lock: %v", err)
+	}
+	if err := second.Refresh("second"); !errors.Is(err, ErrLeaseLost) {
+		t.Fatalf("refresh of a lock not held: have %v, want %v", err, ErrLeaseLost)
+	}
+	if err := first.Unlock("first"); err != nil {
+		t.Fatalf("failed to release lock: %v", err)
+	}
+	if ok, err := second.TryLock("second"); !ok || err != nil {
+		t.Fatalf("failed to take released lock: %v %v", ok, err)
+	}
+	second.Unlock("second")
+}
@@ -0,0 +1,154 @@
+// Copyright (c) 2018 XDPoSChain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package failover
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/prometheus/prometheus/util/flock"
+)
+
+var (
+	// ErrLeaseLost is returned when refreshing a lease which expired or was
+	// taken over by another holder.
+	ErrLeaseLost = errors.New("failover lease lost")
+)
+
+// LockProvider grants the exclusive lease which allows one of several nodes
+// sharing the same key to seal blocks and sign votes and timeouts.
+type LockProvider interface {
+	// TryLock attempts to take the lease on behalf of holder without blocking.
+	// It returns false if the lease is currently held by somebody else.
+	TryLock(holder string) (bool, error)
+
+	// Refresh keeps the lease of holder alive, returning ErrLeaseLost if the
+	// lease is not held by holder any more.
+	Refresh(holder string) error
+
+	// Unlock hands the lease of holder back.
+	Unlock(holder string) error
+}
+
+// FileLockProvider implements LockProvider on top of an exclusive flock. The
+// lock file has to live on a file system shared by all the nodes and honouring
+// flock, the lease is held as long as the process keeps the file open.
+type FileLockProvider struct {
+	path string
+
+	holder   string
+	releaser flock.Releaser
+	lock     sync.Mutex
+}
+
+func NewFileLockProvider(path string) *FileLockProvider {
+	return &FileLockProvider{path: path}
+}
+
+func (p *FileLockProvider) TryLock(holder string) (bool, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.releaser != nil {
+		return p.holder == holder, nil
+	}
+	releaser, _, err := flock.New(p.path)
+	if err != nil {
+		// A non-blocking flock of a file locked by another process fails
+		// with EWOULDBLOCK, which is EAGAIN on most systems
+		if errors.Is(err, syscall.EWOULDBLOCK) || errors.Is(err, syscall.EAGAIN) {
+			return false, nil
+		}
+		return false, err
+	}
+	p.holder, p.releaser = holder, releaser
+	return true, nil
+}
+
+func (p *FileLockProvider) Refresh(holder string) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.releaser == nil || p.holder != holder {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+func (p *FileLockProvider) Unlock(holder string) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.releaser == nil || p.holder != holder {
+		return nil
+	}
+	err := p.releaser.Release()
+	p.holder, p.releaser = "", nil
+	return err
+}
+
+// MemoryLockProvider is an in-process lease with a time to live, which behaves
+// like an etcd lease: the holder has to refresh it before it expires, otherwise
+// any other holder can take it over. It is meant to be shared by several
+// failover managers in tests and single host setups.
+type MemoryLockProvider struct {
+	ttl time.Duration
+
+	holder string
+	expiry time.Time
+	lock   sync.Mutex
+}
+
+func NewMemoryLockProvider(ttl time.Duration) *MemoryLockProvider {
+	return &MemoryLockProvider{ttl: ttl}
+}
+
+func (p *MemoryLockProvider) TryLock(holder string) (bool, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	now := time.Now()
+	if p.holder != "" && p.holder != holder && now.Before(p.expiry) {
+		return false, nil
+	}
+	p.holder, p.expiry = holder, now.Add(p.ttl)
+	return true, nil
+}
+
+func (p *MemoryLockProvider) Refresh(holder string) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	now := time.Now()
+	if p.holder != holder || !now.Before(p.expiry) {
+		return fmt.Errorf("%w: held by %q", ErrLeaseLost, p.holder)
+	}
+	p.expiry = now.Add(p.ttl)
+	return nil
+}
+
+func (p *MemoryLockProvider) Unlock(holder string) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.holder == holder {
+		p.holder, p.expiry = "", time.Time{}
+	}
+	return nil
+}
@@ -164,6 +164,12 @@ func (self *Miner) SetExtra(extra []byte) error {
 	return nil
 }
 
+// SetBlockBuilder sets the policy ordering the pending transactions in the
+// blocks created from now on.
+func (self *Miner) SetBlockBuilder(builder BlockBuilder) {
+	self.worker.setBlockBuilder(builder)
+}
+
 // Pending returns the currently pending block and associated state.
 func (self *Miner) Pending() (*types.Block, *state.StateDB) {
 	return self.worker.pending()
@@ -0,0 +1,218 @@
+// Copyright (c) 2018 XDPoSChain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"bytes"
+	"container/heap"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/XinFinOrg/XDPoSChain/common"
+	"github.com/XinFinOrg/XDPoSChain/core/types"
+)
+
+// Names of the built-in ordering policies.
+const (
+	OrderingPrice    = "price"
+	OrderingFIFO     = "fifo"
+	OrderingPriority = "priority"
+)
+
+var errNoPrioritySenders = errors.New("priority ordering requires at least one priority sender")
+
+// TransactionSet hands out pending transactions one at a time, honouring the
+// nonce order of every account. Shift moves on to the next transaction of the
+// same account, Pop drops the remaining transactions of the account.
+type TransactionSet interface {
+	Peek() *types.Transaction
+	Shift()
+	Pop()
+}
+
+// BlockBuilder is the policy deciding in which order the pending transactions
+// are committed to a new block. Special transactions, including the XDCx trading
+// and lending ones, are always committed first whatever the policy.
+type BlockBuilder interface {
+	// Order arranges the pending transactions, grouped by sender and sorted by
+	// nonce. The pending map is reowned and must not be used by the caller any
+	// more. feeCapacity lists the TRC21 tokens paying the fees of their holders.
+	Order(signer types.Signer, pending map[common.Address]types.Transactions, feeCapacity map[common.Address]*big.Int) TransactionSet
+}
+
+// NewBlockBuilder returns the built-in ordering policy with the given name.
+func NewBlockBuilder(name string, prioritySenders []common.Address) (BlockBuilder, error) {
+	switch name {
+	case "", OrderingPrice:
+		return PriceOrdering{}, nil
+	case OrderingFIFO:
+		return FIFOOrdering{}, nil
+	case OrderingPriority:
+		if len(prioritySenders) == 0 {
+			return nil, errNoPrioritySenders
+		}
+		return NewPriorityOrdering(prioritySenders), nil
+	default:
+		return nil, fmt.Errorf("unknown transaction ordering %q", name)
+	}
+}
+
+// PriceOrdering commits the best paying transactions first, breaking ties by
+// arrival time. It's the default policy.
+type PriceOrdering struct{}
+
+func (PriceOrdering) Order(signer types.Signer, pending map[common.Address]types.Transactions, feeCapacity map[common.Address]*big.Int) TransactionSet {
+	txs, _ := types.NewTransactionsByPriceAndNonce(signer, pending, nil, feeCapacity)
+	return txs
+}
+
+// FIFOOrdering commits transactions in the order they arrived at the node,
+// regardless of the price they pay.
+type FIFOOrdering struct{}
+
+func (FIFOOrdering) Order(signer types.Signer, pending map[common.Address]types.Transactions, feeCapacity map[common.Address]*big.Int) TransactionSet {
+	return newOrderedTransactions(signer, pending, byArrival)
+}
+
+// PriorityOrdering commits the transactions of an allowlist of senders first,
+// then all the others. Both groups are ordered by price.
+type PriorityOrdering struct {
+	senders map[common.Address]struct{}
+}
+
+func NewPriorityOrdering(senders []common.Address) *PriorityOrdering {
+	ordering := &PriorityOrdering{senders: make(map[common.Address]struct{}, len(senders))}
+	for _, sender := range senders {
+		ordering.senders[sender] = struct{}{}
+	}
+	return ordering
+}
+
+func (p *PriorityOrdering) Order(signer types.Signer, pending map[common.Address]types.Transactions, feeCapacity map[common.Address]*big.Int) TransactionSet {
+	return newOrderedTransactions(signer, pending, func(a, b *types.Transaction) bool {
+		aPriority, bPriority := p.isPriority(signer, a), p.isPriority(signer, b)
+		if aPriority != bPriority {
+			return aPriority
+		}
+		if cmp := effectiveGasPrice(a, feeCapacity).Cmp(effectiveGasPrice(b, feeCapacity)); cmp != 0 {
+			return cmp > 0
+		}
+		return byArrival(a, b)
+	})
+}
+
+func (p *PriorityOrdering) isPriority(signer types.Signer, tx *types.Transaction) bool {
+	from, _ := types.Sender(signer, tx)
+	_, ok := p.senders[from]
+	return ok
+}
+
+// effectiveGasPrice returns the price a transaction pays the block producer,
+// fees paid by TRC21 tokens are charged at a fixed price.
+func effectiveGasPrice(tx *types.Transaction, feeCapacity map[common.Address]*big.Int) *big.Int {
+	if to := tx.To(); to != nil {
+		if _, ok := feeCapacity[*to]; ok {
+			return common.TRC21GasPrice
+		}
+	}
+	return tx.GasPrice()
+}
+
+// txTime returns the time a transaction was first seen locally. Tests override
+// it to simulate the arrival of the transactions.
+var txTime = (*types.Transaction).Time
+
+// byArrival orders transactions by the time they were first seen, and by hash
+// if they arrived at the same time so the order is always deterministic.
+func byArrival(a, b *types.Transaction) bool {
+	if at, bt := txTime(a), txTime(b); !at.Equal(bt) {
+		return at.Before(bt)
+	}
+	ah, bh := a.Hash(), b.Hash()
+	return bytes.Compare(ah[:], bh[:]) < 0
+}
+
+// orderedTransactions is a TransactionSet whose account heads are sorted by an
+// arbitrary policy, the same way types.TransactionsByPriceAndNonce sorts them
+// by price.
+type orderedTransactions struct {
+	txs    map[common.Address]types.Transactions // Per account nonce-sorted list of transactions
+	heads  txHeads                               // Next transaction for each unique account
+	signer types.Signer
+}
+
+func newOrderedTransactions(signer types.Signer, pending map[common.Address]types.Transactions, less func(a, b *types.Transaction) bool) *orderedTransactions {
+	heads := txHeads{less: less}
+	for from, accTxs := range pending {
+		if len(accTxs) == 0 {
+			delete(pending, from)
+			continue
+		}
+		heads.txs = append(heads.txs, accTxs[0])
+		pending[from] = accTxs[1:]
+	}
+	heap.Init(&heads)
+
+	return &orderedTransactions{
+		txs:    pending,
+		heads:  heads,
+		signer: signer,
+	}
+}
+
+func (t *orderedTransactions) Peek() *types.Transaction {
+	if len(t.heads.txs) == 0 {
+		return nil
+	}
+	return t.heads.txs[0]
+}
+
+func (t *orderedTransactions) Shift() {
+	acc, _ := types.Sender(t.signer, t.heads.txs[0])
+	if txs, ok := t.txs[acc]; ok && len(txs) > 0 {
+		t.heads.txs[0], t.txs[acc] = txs[0], txs[1:]
+		heap.Fix(&t.heads, 0)
+	} else {
+		heap.Pop(&t.heads)
+	}
+}
+
+func (t *orderedTransactions) Pop() {
+	heap.Pop(&t.heads)
+}
+
+// txHeads implements the heap interface over the next transaction of every account.
+type txHeads struct {
+	txs  types.Transactions
+	less func(a, b *types.Transaction) bool
+}
+
+func (h txHeads) Len() int           { return len(h.txs) }
+func (h txHeads) Less(i, j int) bool { return h.less(h.txs[i], h.txs[j]) }
+func (h txHeads) Swap(i, j int)      { h.txs[i], h.txs[j] = h.txs[j], h.txs[i] }
+
+func (h *txHeads) Push(x interface{}) {
+	h.txs = append(h.txs, x.(*types.Transaction))
+}
+
+func (h *txHeads) Pop() interface{} {
+	old := h.txs
+	n := len(old)
+	x := old[n-1]
+	h.txs = old[0 : n-1]
+	return x
+}
@@ -0,0 +1,163 @@
+// Copyright (c) 2018 XDPoSChain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/XinFinOrg/XDPoSChain/common"
+	"github.com/XinFinOrg/XDPoSChain/core/types"
+	"github.com/XinFinOrg/XDPoSChain/crypto"
+)
+
+var (
+	orderingSigner = types.HomesteadSigner{}
+	orderingKeys   = []*ecdsa.PrivateKey{
+		mustKey("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291"),
+		mustKey("8a1f9a8f95be41cd7ccb6168179afb4504aefe388d1e14474d32c45c72ce7b7a"),
+		mustKey("49a7b37aa6f6645917e7b807e9d1c00d4fa71f18343b0d4122a4d2df64dd6fee"),
+	}
+	orderingEpoch = time.Unix(1600000000, 0)
+
+	// orderingArrivals holds the simulated arrival times of the transactions.
+	orderingArrivals = make(map[common.Hash]time.Time)
+)
+
+func init() {
+	txTime = func(tx *types.Transaction) time.Time {
+		if arrival, ok := orderingArrivals[tx.Hash()]; ok {
+			return arrival
+		}
+		return tx.Time()
+	}
+}
+
+func mustKey(hex string) *ecdsa.PrivateKey {
+	key, err := crypto.HexToECDSA(hex)
+	if err != nil {
+		panic(err)
+	}
+	return key
+}
+
+// orderingTx creates a signed transaction which arrived at the given second.
+func orderingTx(key *ecdsa.PrivateKey, nonce uint64, price int64, arrival int) *types.Transaction {
+	tx, _ := types.SignTx(types.NewTransaction(nonce, common.Address{}, big.NewInt(0), 21000, big.NewInt(price), nil), orderingSigner, key)
+	orderingArrivals[tx.Hash()] = orderingEpoch.Add(time.Duration(arrival) * time.Second)
+	return tx
+}
+
+// orderingPending groups the transactions by sender like the pool does.
+func orderingPending(txs ...*types.Transaction) map[common.Address]types.Transactions {
+	pending := make(map[common.Address]types.Transactions)
+	for _, tx := range txs {
+		from, _ := types.Sender(orderingSigner, tx)
+		pending[from] = append(pending[from], tx)
+	}
+	return pending
+}
+
+func drain(set TransactionSet) []*types.Transaction {
+	var txs []*types.Transaction
+	for tx := set.Peek(); tx != nil; tx = set.Peek() {
+		txs = append(txs, tx)
+		set.Shift()
+	}
+	return txs
+}
+
+func checkOrder(t *testing.T, have, want []*types.Transaction) {
+	t.Helper()
+	if len(have) != len(want) {
+		t.Fatalf("transaction count mismatch: have %d, want %d", len(have), len(want))
+	}
+	for i := range want {
+		if have[i].Hash() != want[i].Hash() {
+			t.Errorf("transaction %d: have nonce %d price %v, want nonce %d price %v", i, have[i].Nonce(), have[i].GasPrice(), want[i].Nonce(), want[i].GasPrice())
+		}
+	}
+}
+
+func TestFIFOOrdering(t *testing.T) {
+	a0 := orderingTx(orderingKeys[0], 0, 1, 3)
+	a1 := orderingTx(orderingKeys[0], 1, 9, 4)
+	b0 := orderingTx(orderingKeys[1], 0, 5, 1)
+	b1 := orderingTx(orderingKeys[1], 1, 1, 5)
+	c0 := orderingTx(orderingKeys[2], 0, 7, 2)
+
+	set := FIFOOrdering{}.Order(orderingSigner, orderingPending(a0, a1, b0, b1, c0), nil)
+	checkOrder(t, drain(set), []*types.Transaction{b0, c0, a0, a1, b1})
+}
+
+func TestFIFOOrderingNonceGap(t *testing.T) {
+	// A later nonce which arrived earlier must still wait for its predecessor
+	a0 := orderingTx(orderingKeys[0], 0, 1, 5)
+	a1 := orderingTx(orderingKeys[0], 1, 1, 1)
+	b0 := orderingTx(orderingKeys[1], 0, 1, 3)
+
+	set := FIFOOrdering{}.Order(orderingSigner, orderingPending(a0, a1, b0), nil)
+	checkOrder(t, drain(set), []*types.Transaction{b0, a0, a1})
+}
+
+func TestPriceOrdering(t *testing.T) {
+	a0 := orderingTx(orderingKeys[0], 0, 1, 1)
+	b0 := orderingTx(orderingKeys[1], 0, 5, 2)
+	c0 := orderingTx(orderingKeys[2], 0, 3, 3)
+
+	set := PriceOrdering{}.Order(orderingSigner, orderingPending(a0, b0, c0), nil)
+	checkOrder(t, drain(set), []*types.Transaction{b0, c0, a0})
+}
+
+func TestPriorityOrdering(t *testing.T) {
+	a0 := orderingTx(orderingKeys[0], 0, 1, 1)
+	a1 := orderingTx(orderingKeys[0], 1, 1, 2)
+	b0 := orderingTx(orderingKeys[1], 0, 9, 3)
+	c0 := orderingTx(orderingKeys[2], 0, 5, 4)
+
+	priority := crypto.PubkeyToAddress(orderingKeys[0].PublicKey)
+	set := NewPriorityOrdering([]common.Address{priority}).Order(orderingSigner, orderingPending(a0, a1, b0, c0), nil)
+	checkOrder(t, drain(set), []*types.Transaction{a0, a1, b0, c0})
+}
+
+func TestOrderingPop(t *testing.T) {
+	a0 := orderingTx(orderingKeys[0], 0, 1, 1)
+	a1 := orderingTx(orderingKeys[0], 1, 1, 2)
+	b0 := orderingTx(orderingKeys[1], 0, 1, 3)
+
+	set := FIFOOrdering{}.Order(orderingSigner, orderingPending(a0, a1, b0), nil)
+	set.Pop() // Skip the whole account of a0
+	checkOrder(t, drain(set), []*types.Transaction{b0})
+}
+
+func TestNewBlockBuilder(t *testing.T) {
+	for _, name := range []string{"", OrderingPrice, OrderingFIFO} {
+		if _, err := NewBlockBuilder(name, nil); err != nil {
+			t.Errorf("ordering %q: unexpected error: %v", name, err)
+		}
+	}
+	if _, err := NewBlockBuilder(OrderingPriority, nil); err != errNoPrioritySenders {
+		t.Errorf("priority ordering without senders: have %v, want %v", err, errNoPrioritySenders)
+	}
+	if _, err := NewBlockBuilder(OrderingPriority, []common.Address{{1}}); err != nil {
+		t.Errorf("priority ordering: unexpected error: %v", err)
+	}
+	if _, err := NewBlockBuilder("random", nil); err == nil {
+		t.Errorf("unknown ordering accepted")
+	}
+}
@@ -127,6 +127,7 @@ type worker struct {
 
 	coinbase common.Address
 	extra    []byte
+	builder  BlockBuilder // Policy ordering the pending transactions in new blocks
 
 	snapshotMu       sync.RWMutex // The lock used to protect the block snapshot and state snapshot
 	snapshotBlock    *types.Block
@@ -166,6 +167,7 @@ func newWorker(config *params.ChainConfig, engine consensus.Engine, coinbase com
 		agents:         make(map[Agent]struct{}),
 		unconfirmed:    newUnconfirmedBlocks(eth.BlockChain(), miningLogAtDepth),
 		announceTxs:    announceTxs,
+		builder:        PriceOrdering{},
 	}
 	if worker.announceTxs {
 		// Subscribe NewTxsEvent for tx pool
@@ -194,6 +196,12 @@ func (self *worker) setExtra(extra []byte) {
 	self.extra = extra
 }
 
+func (self *worker) setBlockBuilder(builder BlockBuilder) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	self.builder = builder
+}
+
 // pending returns the pending state and corresponding block. The returned
 // values can be nil in case the pending block is not initialized.
 func (w *worker) pending() (*types.Block, *state.StateDB) {
@@ -326,10 +334,13 @@ func (self *worker) update() {
 					txs[acc] = append(txs[acc], tx)
 				}
 				feeCapacity := state.GetTRC21FeeCapacityFromState(self.current.state)
-				txset, specialTxs := types.NewTransactionsByPriceAndNonce(self.current.signer, txs, nil, feeCapacity)
+				self.mu.Lock()
+				builder := self.builder
+				self.mu.Unlock()
+				txset := builder.Order(self.current.signer, txs, feeCapacity)
 
 				tcount := self.current.tcount
-				self.current.commitTransactions(self.mux, feeCapacity, txset, specialTxs, self.chain, self.coinbase, &self.pendingLogsFeed)
+				self.current.commitTransactions(self.mux, feeCapacity, txset, nil, self.chain, self.coinbase, &self.pendingLogsFeed)
 
 				// Only update the snapshot if any new transactions were added
 				// to the pending block
@@ -573,7 +584,6 @@ func (self *worker) commitNewWork() {
 		parent = self.chain.CurrentBlock()
 	}
 
-	var signers map[common.Address]struct{}
 	if parent.Hash().Hex() == self.lastParentBlockCommit {
 		return
 	}
@@ -657,7 +667,7 @@ func (self *worker) commitNewWork() {
 	}
 	// won't grasp txs at checkpoint
 	var (
-		txs                                                                  *types.TransactionsByPriceAndNonce
+		txs                                                                  TransactionSet
 		specialTxs                                                           types.Transactions
 		tradingTransaction                                                   *types.Transaction
 		lendingTransaction                                                   *types.Transaction
@@ -681,7 +691,7 @@ func (self *worker) commitNewWork() {
 				log.Error("Failed to fetch pending transactions", "err", err)
 				return
 			}
-			txs, specialTxs = types.NewTransactionsByPriceAndNonce(self.current.signer, pending, signers, feeCapacity)
+			txs = self.builder.Order(self.current.signer, pending, feeCapacity)
 		}
 	}
 	if atomic.LoadInt32(&self.mining) == 1 {
@@ -698,6 +708,10 @@ func (self *worker) commitNewWork() {
 				if err != nil {
 					log.Error("[commitNewWork] fail to check if block is epoch switch block when performing XDCX and XDCXLending operations", "BlockNum", header.Number, "Hash", header.Hash())
 				}
+				if _, err := XDCX.ProcessExpiredOrders(header, self.chain, work.state, work.tradingState); err != nil {
+					log.Error("Fail when process expired orders", "error", err)
+					return
+				}
 
 				if isEpochSwitchBlock {
 					err := XDCX.UpdateMediumPriceBeforeEpoch(epochNumber, work.tradingState, work.state)
@@ -705,6 +719,10 @@ func (self *worker) commitNewWork() {
 						log.Error("Fail when update medium price last epoch", "error", err)
 						return
 					}
+					if _, err := XDCX.ProcessTriggerOrders(header, self.coinbase, self.chain, work.state, work.tradingState); err != nil {
+						log.Error("Fail when process trigger orders", "error", err)
+						return
+					}
 				} else {
 					// won't grasp tx at checkpoint
 					//https://github.com/XinFinOrg/XDPoSChain-v1/pull/416
@@ -841,7 +859,7 @@ func (self *worker) commitNewWork() {
 	self.updateSnapshot()
 }
 
-func (env *Work) commitTransactions(mux *event.TypeMux, balanceFee map[common.Address]*big.Int, txs *types.TransactionsByPriceAndNonce, specialTxs types.Transactions, bc *core.BlockChain, coinbase common.Address, pendingLogsFeed *event.Feed) {
+func (env *Work) commitTransactions(mux *event.TypeMux, balanceFee map[common.Address]*big.Int, txs TransactionSet, specialTxs types.Transactions, bc *core.BlockChain, coinbase common.Address, pendingLogsFeed *event.Feed) {
 	gp := new(core.GasPool).AddGas(env.header.GasLimit)
 	balanceUpdated := map[common.Address]*big.Int{}
 	totalFeeUsed := big.NewInt(0)
@@ -18,6 +18,8 @@ package node
 
 import (
 	"crypto/ecdsa"
+	"crypto/rand"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -29,10 +31,12 @@ import (
 	"github.com/XinFinOrg/XDPoSChain/accounts/keystore"
 	"github.com/XinFinOrg/XDPoSChain/accounts/usbwallet"
 	"github.com/XinFinOrg/XDPoSChain/common"
+	"github.com/XinFinOrg/XDPoSChain/common/hexutil"
 	"github.com/XinFinOrg/XDPoSChain/crypto"
 	"github.com/XinFinOrg/XDPoSChain/log"
 	"github.com/XinFinOrg/XDPoSChain/p2p"
 	"github.com/XinFinOrg/XDPoSChain/p2p/discover"
+	"github.com/XinFinOrg/XDPoSChain/rpc"
 )
 
 const (
@@ -41,6 +45,7 @@ const (
 	datadirStaticNodes     = "static-nodes.json"  // Path within the datadir to the static node list
 	datadirTrustedNodes    = "trusted-nodes.json" // Path within the datadir to the trusted node list
 	datadirNodeDatabase    = "nodes"              // Path within the datadir to store the node infos
+	datadirJWTSecret       = "jwtsecret"          // Path within the datadir to the secrets of the authenticated RPC tokens
 )
 
 // Config represents a small collection of configuration values to fine tune the
@@ -148,6 +153,35 @@ type Config struct {
 	// private APIs to untrusted users is a major security risk.
 	WSExposeAll bool `toml:",omitempty"`
 
+	// AuthHost is the host interface on which to start the authenticated HTTP and
+	// websocket RPC server. If this field is empty, no authenticated endpoint will
+	// be started. All the APIs are served on this endpoint, each token may only
+	// call the namespaces and methods of its claims.
+	AuthHost string `toml:",omitempty"`
+
+	// AuthPort is the TCP port number on which to start the authenticated RPC
+	// server.
+	AuthPort int `toml:",omitempty"`
+
+	// AuthVirtualHosts is the list of virtual hostnames which are allowed on
+	// incoming requests to the authenticated RPC server.
+	AuthVirtualHosts []string `toml:",omitempty"`
+
+	// AuthOrigins is the list of domain to accept websocket requests from on the
+	// authenticated RPC server. Browsers always send their origin, so a page
+	// holding a token can only reach the endpoint from one of these domains.
+	AuthOrigins []string `toml:",omitempty"`
+
+	// JWTSecret is the file holding the hex encoded secrets of the tokens accepted
+	// by the authenticated RPC server, one per line followed by the namespaces and
+	// method patterns its holders may call, all of them if none. A random secret
+	// is generated if the file doesn't exist. The file is reloaded when it changes.
+	JWTSecret string `toml:",omitempty"`
+
+	// RPCLimits are the rate, batch, response size and concurrency limits of the
+	// requests served over HTTP and websocket.
+	RPCLimits rpc.RateLimitConfig
+
 	// Logger is a custom logger to use with the p2p.Server.
 	Logger log.Logger `toml:",omitempty"`
 
@@ -223,6 +257,15 @@ func (c *Config) WSEndpoint() string {
 	return fmt.Sprintf("%s:%d", c.WSHost, c.WSPort)
 }
 
+// AuthEndpoint resolves the authenticated RPC endpoint based on the configured
+// host interface and port parameters.
+func (c *Config) AuthEndpoint() string {
+	if c.AuthHost == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s:%d", c.AuthHost, c.AuthPort)
+}
+
 // DefaultWSEndpoint returns the websocket endpoint used by default.
 func DefaultWSEndpoint() string {
 	config := &Config{WSHost: DefaultWSHost, WSPort: DefaultWSPort}
@@ -335,6 +378,32 @@ func (c *Config) NodeKey() *ecdsa.PrivateKey {
 	return key
 }
 
+// JWTSecretFile loads the secrets of the tokens accepted by the authenticated RPC
+// server, generating a random secret if the file doesn't exist yet.
+func (c *Config) JWTSecretFile() (*rpc.JWTSecretFile, error) {
+	path := c.JWTSecret
+	if path == "" {
+		path = datadirJWTSecret
+	}
+	if path = c.resolvePath(path); path == "" {
+		return nil, errors.New("no JWT secret file configured for an ephemeral node")
+	}
+	if !common.FileExist(path) {
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, err
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(path, []byte(hexutil.Encode(secret)+"\n"), 0600); err != nil {
+			return nil, err
+		}
+		log.Info("Generated JWT secret", "path", path)
+	}
+	return rpc.NewJWTSecretFile(path)
+}
+
 // StaticNodes returns a list of node enode URLs configured as static nodes.
 func (c *Config) StaticNodes() []*discover.Node {
 	return c.parsePersistentNodes(c.resolvePath(datadirStaticNodes))
@@ -33,6 +33,8 @@ const (
 	DefaultHTTPWriteTimeOut = 10 * time.Second // Default write timeout for the HTTP RPC server
 	DefaultWSHost           = "localhost"      // Default host interface for the websocket RPC server
 	DefaultWSPort           = 8546             // Default TCP port for the websocket RPC server
+	DefaultAuthHost         = "localhost"      // Default host interface for the authenticated RPC server
+	DefaultAuthPort         = 8551             // Default TCP port for the authenticated RPC server
 )
 
 // DefaultConfig contains reasonable default settings.
@@ -44,6 +46,8 @@ var DefaultConfig = Config{
 	HTTPVirtualHosts: []string{"localhost"},
 	WSPort:           DefaultWSPort,
 	WSModules:        []string{"net", "web3"},
+	AuthPort:         DefaultAuthPort,
+	AuthVirtualHosts: []string{"localhost"},
 	P2P: p2p.Config{
 		ListenAddr: ":30303",
 		MaxPeers:   25,
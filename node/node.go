@@ -20,6 +20,7 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"net/http"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -60,15 +61,20 @@ type Node struct {
 	ipcListener net.Listener // IPC RPC listener socket to serve API requests
 	ipcHandler  *rpc.Server  // IPC RPC request handler to process the API requests
 
-	httpEndpoint  string       // HTTP endpoint (interface + port) to listen at (empty = HTTP disabled)
-	httpWhitelist []string     // HTTP RPC modules to allow through this endpoint
-	httpListener  net.Listener // HTTP RPC listener socket to server API requests
-	httpHandler   *rpc.Server  // HTTP RPC request handler to process the API requests
+	httpEndpoint  string                  // HTTP endpoint (interface + port) to listen at (empty = HTTP disabled)
+	httpWhitelist []string                // HTTP RPC modules to allow through this endpoint
+	httpListener  net.Listener            // HTTP RPC listener socket to server API requests
+	httpHandler   *rpc.Server             // HTTP RPC request handler to process the API requests
+	httpHandlers  map[string]http.Handler // Extra HTTP handlers of the services, by path
 
 	wsEndpoint string       // Websocket endpoint (interface + port) to listen at (empty = websocket disabled)
 	wsListener net.Listener // Websocket RPC listener socket to server API requests
 	wsHandler  *rpc.Server  // Websocket RPC request handler to process the API requests
 
+	authEndpoint string       // Authenticated RPC endpoint (interface + port) to listen at (empty = disabled)
+	authListener net.Listener // Authenticated RPC listener socket to serve API requests
+	authHandler  *rpc.Server  // Authenticated RPC request handler to process the API requests
+
 	stop chan struct{} // Channel to wait for termination notifications
 	lock sync.RWMutex
 
@@ -122,6 +128,7 @@ func New(conf *Config) (*Node, error) {
 		ipcEndpoint:       conf.IPCEndpoint(),
 		httpEndpoint:      conf.HTTPEndpoint(),
 		wsEndpoint:        conf.WSEndpoint(),
+		authEndpoint:      conf.AuthEndpoint(),
 		eventmux:          new(event.TypeMux),
 		log:               conf.Logger,
 	}, nil
@@ -258,9 +265,16 @@ func (n *Node) openDataDir() error {
 func (n *Node) startRPC(services map[reflect.Type]Service) error {
 	// Gather all the possible APIs to surface
 	apis := n.apis()
+	handlers := make(map[string]http.Handler)
 	for _, service := range services {
 		apis = append(apis, service.APIs()...)
+		if service, ok := service.(HTTPHandlerService); ok {
+			for path, handler := range service.HTTPHandlers() {
+				handlers[path] = handler
+			}
+		}
 	}
+	n.httpHandlers = handlers
 	// Start the various API endpoints, terminating all in case of errors
 	if err := n.startInProc(apis); err != nil {
 		return err
@@ -280,6 +294,13 @@ func (n *Node) startRPC(services map[reflect.Type]Service) error {
 		n.stopInProc()
 		return err
 	}
+	if err := n.startAuth(n.authEndpoint, apis, n.config.AuthVirtualHosts, n.config.AuthOrigins); err != nil {
+		n.stopWS()
+		n.stopHTTP()
+		n.stopIPC()
+		n.stopInProc()
+		return err
+	}
 	// All API endpoints started successfully
 	n.rpcAPIs = apis
 	return nil
@@ -395,6 +416,9 @@ func (n *Node) startHTTP(endpoint string, apis []rpc.API, modules []string, cors
 	}
 	// Register all the APIs exposed by the services
 	handler := rpc.NewServer()
+	if err := handler.SetRateLimits(n.config.RPCLimits); err != nil {
+		return err
+	}
 	for _, api := range apis {
 		if whitelist[api.Namespace] || (len(whitelist) == 0 && api.Public) {
 			if err := handler.RegisterName(api.Namespace, api.Service); err != nil {
@@ -411,7 +435,7 @@ func (n *Node) startHTTP(endpoint string, apis []rpc.API, modules []string, cors
 	if listener, err = net.Listen("tcp", endpoint); err != nil {
 		return err
 	}
-	go rpc.NewHTTPServer(cors, vhosts, handler, n.config.HTTPWriteTimeout).Serve(listener)
+	go rpc.NewHTTPServer(cors, vhosts, n.httpMux(handler), n.config.HTTPWriteTimeout).Serve(listener)
 	n.log.Info("HTTP endpoint opened", "url", fmt.Sprintf("http://%s", endpoint), "cors", strings.Join(cors, ","), "vhosts", strings.Join(vhosts, ","))
 	// All listeners booted successfully
 	n.httpEndpoint = endpoint
@@ -421,6 +445,22 @@ func (n *Node) startHTTP(endpoint string, apis []rpc.API, modules []string, cors
 	return nil
 }
 
+// httpMux serves the extra HTTP handlers of the services next to the JSON-RPC
+// handler at the root of the HTTP endpoint. Their requests are limited as the
+// calls of a method named after their path, e.g. "graphql" for /graphql.
+func (n *Node) httpMux(handler *rpc.Server) http.Handler {
+	if len(n.httpHandlers) == 0 {
+		return handler
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/", handler)
+	for path, h := range n.httpHandlers {
+		mux.Handle(path, handler.LimitedHandler(strings.Trim(path, "/"), h))
+		n.log.Info("HTTP handler registered", "path", path)
+	}
+	return mux
+}
+
 // stopHTTP terminates the HTTP RPC endpoint.
 func (n *Node) stopHTTP() {
 	if n.httpListener != nil {
@@ -448,6 +488,9 @@ func (n *Node) startWS(endpoint string, apis []rpc.API, modules []string, wsOrig
 	}
 	// Register all the APIs exposed by the services
 	handler := rpc.NewServer()
+	if err := handler.SetRateLimits(n.config.RPCLimits); err != nil {
+		return err
+	}
 	for _, api := range apis {
 		if exposeAll || whitelist[api.Namespace] || (len(whitelist) == 0 && api.Public) {
 			if err := handler.RegisterName(api.Namespace, api.Service); err != nil {
@@ -489,6 +532,52 @@ func (n *Node) stopWS() {
 	}
 }
 
+// startAuth initializes and starts the authenticated HTTP and websocket RPC
+// endpoint. All the APIs are registered, the tokens of the clients restrict
+// the methods they may call.
+func (n *Node) startAuth(endpoint string, apis []rpc.API, vhosts, origins []string) error {
+	// Short circuit if the authenticated endpoint isn't being exposed
+	if endpoint == "" {
+		return nil
+	}
+	secrets, err := n.config.JWTSecretFile()
+	if err != nil {
+		return err
+	}
+	handler := rpc.NewServer()
+	for _, api := range apis {
+		if err := handler.RegisterName(api.Namespace, api.Service); err != nil {
+			return err
+		}
+		n.log.Debug("Authenticated RPC registered", "service", api.Service, "namespace", api.Namespace)
+	}
+	listener, err := net.Listen("tcp", endpoint)
+	if err != nil {
+		return err
+	}
+	go rpc.NewAuthHTTPServer(vhosts, origins, secrets, handler, n.config.HTTPWriteTimeout).Serve(listener)
+	n.log.Info("Authenticated RPC endpoint opened", "url", fmt.Sprintf("http://%s", listener.Addr()), "vhosts", strings.Join(vhosts, ","))
+
+	n.authEndpoint = endpoint
+	n.authListener = listener
+	n.authHandler = handler
+	return nil
+}
+
+// stopAuth terminates the authenticated RPC endpoint.
+func (n *Node) stopAuth() {
+	if n.authListener != nil {
+		n.authListener.Close()
+		n.authListener = nil
+
+		n.log.Info("Authenticated RPC endpoint closed", "url", fmt.Sprintf("http://%s", n.authEndpoint))
+	}
+	if n.authHandler != nil {
+		n.authHandler.Stop()
+		n.authHandler = nil
+	}
+}
+
 // Stop terminates a running node along with all it's services. In the node was
 // not started, an error is returned.
 func (n *Node) Stop() error {
@@ -504,6 +593,7 @@ func (n *Node) Stop() error {
 		service.SaveData()
 	}
 	// Terminate the API, services and the p2p server.
+	n.stopAuth()
 	n.stopWS()
 	n.stopHTTP()
 	n.stopIPC()
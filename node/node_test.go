@@ -18,6 +18,8 @@ package node
 
 import (
 	"errors"
+	"io"
+	"net/http"
 	"os"
 	"reflect"
 	"testing"
@@ -572,3 +574,109 @@ func TestAPIGather(t *testing.T) {
 		}
 	}
 }
+
+// Tests that the authenticated endpoint only serves the methods allowed by the
+// tokens of the clients.
+func TestAuthEndpoint(t *testing.T) {
+	dir := t.TempDir()
+	stack, err := New(&Config{
+		DataDir:          dir,
+		P2P:              p2p.Config{PrivateKey: testNodeKey},
+		AuthHost:         "127.0.0.1",
+		AuthVirtualHosts: []string{"*"},
+		HTTPWriteTimeout: DefaultHTTPWriteTimeOut,
+	})
+	if err != nil {
+		t.Fatalf("failed to create protocol stack: %v", err)
+	}
+	if err := stack.Start(); err != nil {
+		t.Fatalf("failed to start protocol stack: %v", err)
+	}
+	defer stack.Stop()
+
+	// A secret is generated on the first start
+	data, err := os.ReadFile(stack.config.resolvePath(datadirJWTSecret))
+	if err != nil {
+		t.Fatalf("failed to read the generated secret: %v", err)
+	}
+	secrets, err := rpc.ParseJWTSecrets(data)
+	if err != nil {
+		t.Fatalf("invalid generated secret: %v", err)
+	}
+	url := "http://" + stack.authListener.Addr().String()
+	client, err := rpc.DialHTTPWithAuth(url, rpc.NewJWTAuth(secrets[0].Key, nil, []string{"admin_nodeInfo"}))
+	if err != nil {
+		t.Fatalf("failed to dial the authenticated endpoint: %v", err)
+	}
+	defer client.Close()
+
+	var info map[string]interface{}
+	if err := client.Call(&info, "admin_nodeInfo"); err != nil {
+		t.Fatalf("allowed method failed: %v", err)
+	}
+	if err := client.Call(&info, "admin_peers"); err == nil {
+		t.Fatal("method not allowed by the token served")
+	}
+}
+
+// handlerService is a service serving an HTTP handler next to the RPC APIs.
+type handlerService struct{ NoopService }
+
+func (s *handlerService) HTTPHandlers() map[string]http.Handler {
+	return map[string]http.Handler{"/test": http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "handled")
+	})}
+}
+
+// Tests that the HTTP handlers of the services are served on the HTTP endpoint
+// next to the RPC APIs, within the limits of the clients.
+func TestHTTPHandlers(t *testing.T) {
+	stack, err := New(&Config{
+		P2P:              p2p.Config{PrivateKey: testNodeKey},
+		HTTPHost:         "127.0.0.1",
+		HTTPVirtualHosts: []string{"*"},
+		HTTPWriteTimeout: DefaultHTTPWriteTimeOut,
+		RPCLimits: rpc.RateLimitConfig{
+			Rules: []rpc.RateLimitRule{{Method: "test", Rate: 0.001, Burst: 1}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create protocol stack: %v", err)
+	}
+	if err := stack.Register(func(*ServiceContext) (Service, error) { return new(handlerService), nil }); err != nil {
+		t.Fatalf("failed to register service: %v", err)
+	}
+	if err := stack.Start(); err != nil {
+		t.Fatalf("failed to start protocol stack: %v", err)
+	}
+	defer stack.Stop()
+
+	url := "http://" + stack.httpListener.Addr().String()
+	resp, err := http.Get(url + "/test")
+	if err != nil {
+		t.Fatalf("failed to query the handler: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "handled" {
+		t.Fatalf("handler response mismatch: have %q, want %q", body, "handled")
+	}
+	resp, err = http.Get(url + "/test")
+	if err != nil {
+		t.Fatalf("failed to query the handler: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("request above the rate limit not rejected: status %d", resp.StatusCode)
+	}
+	client, err := rpc.DialHTTP(url)
+	if err != nil {
+		t.Fatalf("failed to dial the HTTP endpoint: %v", err)
+	}
+	defer client.Close()
+
+	var info map[string]interface{}
+	if err := client.Call(&info, "rpc_modules"); err != nil {
+		t.Fatalf("RPC not served next to the handler: %v", err)
+	}
+}
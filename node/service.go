@@ -17,10 +17,11 @@
 package node
 
 import (
-	"github.com/XinFinOrg/XDPoSChain/core/rawdb"
+	"net/http"
 	"reflect"
 
 	"github.com/XinFinOrg/XDPoSChain/accounts"
+	"github.com/XinFinOrg/XDPoSChain/core/rawdb"
 	"github.com/XinFinOrg/XDPoSChain/ethdb"
 	"github.com/XinFinOrg/XDPoSChain/event"
 	"github.com/XinFinOrg/XDPoSChain/p2p"
@@ -103,3 +104,10 @@ type Service interface {
 	// are all terminated.
 	Stop() error
 }
+
+// HTTPHandlerService is implemented by the services serving HTTP handlers on
+// the HTTP RPC endpoint next to JSON-RPC, e.g. GraphQL at /graphql.
+type HTTPHandlerService interface {
+	// HTTPHandlers retrieves the handlers of the service, by path.
+	HTTPHandlers() map[string]http.Handler
+}
@@ -76,6 +76,7 @@ type dialstate struct {
 	lookupRunning bool
 	dialing       map[discover.NodeID]connFlag
 	lookupBuf     []*discover.Node // current discovery lookup results
+	dnsNodes      []*discover.Node // nodes of the DNS discovery trees not tried yet
 	randomNodes   []*discover.Node // filled from Table
 	static        map[discover.NodeID]*dialTask
 	hist          *dialHistory
@@ -151,6 +152,11 @@ func (s *dialstate) addStatic(n *discover.Node) {
 	s.static[n.ID] = &dialTask{flags: staticDialedConn, dest: n}
 }
 
+// addDNSNodes replaces the dial candidates found in the DNS discovery trees.
+func (s *dialstate) addDNSNodes(nodes []*discover.Node) {
+	s.dnsNodes = append(s.dnsNodes[:0], nodes...)
+}
+
 func (s *dialstate) removeStatic(n *discover.Node) {
 	// This removes a task so future attempts to connect will not be made.
 	delete(s.static, n.ID)
@@ -226,9 +232,18 @@ func (s *dialstate) newTasks(nRunning int, peers map[discover.NodeID]*Peer, now
 			}
 		}
 	}
+	// Create dynamic dials from the nodes of the DNS discovery trees,
+	// removing tried items.
+	i := 0
+	for ; i < len(s.dnsNodes) && needDynDials > 0; i++ {
+		if addDial(dynDialedConn, s.dnsNodes[i]) {
+			needDynDials--
+		}
+	}
+	s.dnsNodes = s.dnsNodes[:copy(s.dnsNodes, s.dnsNodes[i:])]
 	// Create dynamic dials from random lookup results, removing tried
 	// items from the result buffer.
-	i := 0
+	i = 0
 	for ; i < len(s.lookupBuf) && needDynDials > 0; i++ {
 		if addDial(dynDialedConn, s.lookupBuf[i]) {
 			needDynDials--
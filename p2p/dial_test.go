@@ -407,6 +407,42 @@ func TestDialStateDynDialFromTable(t *testing.T) {
 	})
 }
 
+// This test checks that the nodes found in the DNS discovery trees are dialed.
+func TestDialStateDynDialFromDNS(t *testing.T) {
+	dnsNodes := []*discover.Node{
+		{ID: uintID(1)},
+		{ID: uintID(2)},
+		{ID: uintID(3)},
+	}
+	state := newDialState(nil, nil, fakeTable{}, 4, nil)
+	state.addDNSNodes(dnsNodes)
+
+	runDialTest(t, dialtest{
+		init: state,
+		rounds: []round{
+			// The DNS nodes are dialed, a lookup is launched for the
+			// remaining dynamic dial.
+			{
+				new: []task{
+					&dialTask{flags: dynDialedConn, dest: &discover.Node{ID: uintID(1)}},
+					&dialTask{flags: dynDialedConn, dest: &discover.Node{ID: uintID(2)}},
+					&dialTask{flags: dynDialedConn, dest: &discover.Node{ID: uintID(3)}},
+					&discoverTask{},
+				},
+			},
+			// The DNS nodes are not dialed again once they have been tried.
+			{
+				done: []task{
+					&dialTask{flags: dynDialedConn, dest: &discover.Node{ID: uintID(1)}},
+					&dialTask{flags: dynDialedConn, dest: &discover.Node{ID: uintID(2)}},
+					&dialTask{flags: dynDialedConn, dest: &discover.Node{ID: uintID(3)}},
+				},
+				new: nil,
+			},
+		},
+	})
+}
+
 // This test checks that candidates that do not match the netrestrict list are not dialed.
 func TestDialStateNetRestrict(t *testing.T) {
 	// This table always returns the same random nodes
@@ -34,6 +34,7 @@ import (
 	"github.com/XinFinOrg/XDPoSChain/common"
 	"github.com/XinFinOrg/XDPoSChain/crypto"
 	"github.com/XinFinOrg/XDPoSChain/crypto/secp256k1"
+	"github.com/XinFinOrg/XDPoSChain/p2p/enr"
 )
 
 const NodeIDBits = 512
@@ -71,6 +72,41 @@ func NewNode(id NodeID, ip net.IP, udpPort, tcpPort uint16) *Node {
 	}
 }
 
+// NodeFromRecord creates a node from a signed node record. The record must hold
+// the public key, IP address and TCP port of the node. The UDP port defaults to
+// the TCP port if the record doesn't hold one.
+func NodeFromRecord(r *enr.Record) (*Node, error) {
+	var (
+		pubkey enr.Secp256k1
+		ip4    enr.IP4
+		ip6    enr.IP6
+		tcp    enr.TCP
+		udp    enr.UDP
+		ip     net.IP
+	)
+	if err := r.Load(&pubkey); err != nil {
+		return nil, err
+	}
+	if err := r.Load(&ip4); err == nil {
+		ip = net.IP(ip4)
+	} else if err := r.Load(&ip6); err == nil {
+		ip = net.IP(ip6)
+	} else {
+		return nil, errors.New("record has no IP address")
+	}
+	if err := r.Load(&tcp); err != nil {
+		return nil, err
+	}
+	if err := r.Load(&udp); err != nil {
+		if !enr.IsNotFound(err) {
+			return nil, err
+		}
+		udp = enr.UDP(tcp)
+	}
+	pub := ecdsa.PublicKey(pubkey)
+	return NewNode(PubkeyID(&pub), ip, uint16(udp), uint16(tcp)), nil
+}
+
 func (n *Node) addr() *net.UDPAddr {
 	return &net.UDPAddr{IP: n.IP, Port: int(n.UDP)}
 }
@@ -36,6 +36,7 @@ import (
 	"github.com/XinFinOrg/XDPoSChain/common"
 	"github.com/XinFinOrg/XDPoSChain/crypto"
 	"github.com/XinFinOrg/XDPoSChain/log"
+	"github.com/XinFinOrg/XDPoSChain/p2p/enr"
 	"github.com/XinFinOrg/XDPoSChain/p2p/netutil"
 )
 
@@ -101,6 +102,7 @@ type transport interface {
 	ping(NodeID, *net.UDPAddr) error
 	waitping(NodeID) error
 	findnode(toid NodeID, addr *net.UDPAddr, target NodeID) ([]*Node, error)
+	requestENR(toid NodeID, addr *net.UDPAddr) (*enr.Record, error)
 	close()
 }
 
@@ -270,6 +272,15 @@ func (tab *Table) Resolve(targetID NodeID) *Node {
 	return nil
 }
 
+// RequestENR retrieves the signed record of the given node (EIP-868), bonding
+// with it first so that it answers the request.
+func (tab *Table) RequestENR(n *Node) (*enr.Record, error) {
+	if _, err := tab.bond(false, n.ID, n.addr(), n.TCP); err != nil {
+		return nil, err
+	}
+	return tab.net.requestENR(n.ID, n.addr())
+}
+
 // Lookup performs a network search for nodes close
 // to the given target. It approaches the target by querying
 // nodes that are closer to it on each iteration.
@@ -27,6 +27,7 @@ import (
 
 	"github.com/XinFinOrg/XDPoSChain/crypto"
 	"github.com/XinFinOrg/XDPoSChain/log"
+	"github.com/XinFinOrg/XDPoSChain/p2p/enr"
 	"github.com/XinFinOrg/XDPoSChain/p2p/nat"
 	"github.com/XinFinOrg/XDPoSChain/p2p/netutil"
 	"github.com/XinFinOrg/XDPoSChain/rlp"
@@ -44,6 +45,8 @@ var (
 	errTimeout          = errors.New("RPC timeout")
 	errClockWarp        = errors.New("reply deadline too far in the future")
 	errClosed           = errors.New("socket closed")
+	errNoRecord         = errors.New("no local record")
+	errInvalidRecord    = errors.New("record of another node")
 )
 
 // Timeouts
@@ -64,6 +67,8 @@ const (
 	findnodePacket
 	neighborsPacket
 	pingXDC
+	enrRequestPacket
+	enrResponsePacket
 )
 
 // RPC request structures
@@ -72,6 +77,7 @@ type (
 		Version    uint
 		From, To   rpcEndpoint
 		Expiration uint64
+		ENRSeq     uint64 `rlp:"optional"` // Sequence number of the sender's record (EIP-868)
 		// Ignore additional fields (for forward compatibility).
 		Rest []rlp.RawValue `rlp:"tail"`
 	}
@@ -85,6 +91,7 @@ type (
 
 		ReplyTok   []byte // This contains the hash of the ping packet.
 		Expiration uint64 // Absolute timestamp at which the packet becomes invalid.
+		ENRSeq     uint64 `rlp:"optional"` // Sequence number of the sender's record (EIP-868)
 		// Ignore additional fields (for forward compatibility).
 		Rest []rlp.RawValue `rlp:"tail"`
 	}
@@ -105,6 +112,21 @@ type (
 		Rest []rlp.RawValue `rlp:"tail"`
 	}
 
+	// enrRequest queries for the remote node's record (EIP-868).
+	enrRequest struct {
+		Expiration uint64
+		// Ignore additional fields (for forward compatibility).
+		Rest []rlp.RawValue `rlp:"tail"`
+	}
+
+	// enrResponse is the reply to enrRequest.
+	enrResponse struct {
+		ReplyTok []byte // Hash of the enrRequest packet.
+		Record   enr.Record
+		// Ignore additional fields (for forward compatibility).
+		Rest []rlp.RawValue `rlp:"tail"`
+	}
+
 	rpcNode struct {
 		IP  net.IP // len 4 for IPv4 or 16 for IPv6
 		UDP uint16 // for discovery protocol
@@ -164,6 +186,7 @@ type udp struct {
 	netrestrict *netutil.Netlist
 	priv        *ecdsa.PrivateKey
 	ourEndpoint rpcEndpoint
+	record      func() *enr.Record
 
 	addpending chan *pending
 	gotreply   chan reply
@@ -223,11 +246,12 @@ type Config struct {
 	PrivateKey *ecdsa.PrivateKey
 
 	// These settings are optional:
-	AnnounceAddr *net.UDPAddr      // local address announced in the DHT
-	NodeDBPath   string            // if set, the node database is stored at this filesystem location
-	NetRestrict  *netutil.Netlist  // network whitelist
-	Bootnodes    []*Node           // list of bootstrap nodes
-	Unhandled    chan<- ReadPacket // unhandled packets are sent on this channel
+	AnnounceAddr *net.UDPAddr       // local address announced in the DHT
+	NodeDBPath   string             // if set, the node database is stored at this filesystem location
+	NetRestrict  *netutil.Netlist   // network whitelist
+	Bootnodes    []*Node            // list of bootstrap nodes
+	Unhandled    chan<- ReadPacket  // unhandled packets are sent on this channel
+	NodeRecord   func() *enr.Record // if set, returns the local record served to ENR requests
 }
 
 // ListenUDP returns a new table that listens for UDP packets on laddr.
@@ -245,6 +269,7 @@ func newUDP(c conn, cfg Config) (*Table, *udp, error) {
 		conn:        c,
 		priv:        cfg.PrivateKey,
 		netrestrict: cfg.NetRestrict,
+		record:      cfg.NodeRecord,
 		closing:     make(chan struct{}),
 		gotreply:    make(chan reply),
 		addpending:  make(chan *pending),
@@ -279,6 +304,7 @@ func (t *udp) ping(toid NodeID, toaddr *net.UDPAddr) error {
 		From:       t.ourEndpoint,
 		To:         makeEndpoint(toaddr, 0), // TODO: maybe use known TCP port from DB
 		Expiration: uint64(time.Now().Add(expiration).Unix()),
+		ENRSeq:     t.localSeq(),
 	}
 	packet, hash, err := encodePacket(t.priv, pingXDC, req)
 	if err != nil {
@@ -321,6 +347,51 @@ func (t *udp) findnode(toid NodeID, toaddr *net.UDPAddr, target NodeID) ([]*Node
 	return nodes, err
 }
 
+// requestENR sends an ENR request to the given node and waits for its record.
+func (t *udp) requestENR(toid NodeID, toaddr *net.UDPAddr) (*enr.Record, error) {
+	req := &enrRequest{
+		Expiration: uint64(time.Now().Add(expiration).Unix()),
+	}
+	packet, hash, err := encodePacket(t.priv, enrRequestPacket, req)
+	if err != nil {
+		return nil, err
+	}
+	var record *enr.Record
+	errc := t.pending(toid, enrResponsePacket, func(r interface{}) bool {
+		reply := r.(*enrResponse)
+		if !bytes.Equal(reply.ReplyTok, hash) {
+			return false
+		}
+		record = &reply.Record
+		return true
+	})
+	t.write(toaddr, req.name(), packet)
+	if err := <-errc; err != nil {
+		return nil, err
+	}
+	// The record signature was verified while decoding, check it is the
+	// record of the queried node.
+	var pubkey enr.Secp256k1
+	if err := record.Load(&pubkey); err != nil {
+		return nil, err
+	}
+	if PubkeyID((*ecdsa.PublicKey)(&pubkey)) != toid {
+		return nil, errInvalidRecord
+	}
+	return record, nil
+}
+
+// localSeq returns the sequence number of the local record, 0 if there is none.
+func (t *udp) localSeq() uint64 {
+	if t.record == nil {
+		return 0
+	}
+	if record := t.record(); record != nil {
+		return record.Seq()
+	}
+	return 0
+}
+
 // pending adds a reply callback to the pending reply queue.
 // see the documentation of type pending for a detailed explanation.
 func (t *udp) pending(id NodeID, ptype byte, callback func(interface{}) bool) <-chan error {
@@ -572,6 +643,10 @@ func decodePacket(buf []byte) (packet, NodeID, []byte, error) {
 		req = new(findnode)
 	case neighborsPacket:
 		req = new(neighbors)
+	case enrRequestPacket:
+		req = new(enrRequest)
+	case enrResponsePacket:
+		req = new(enrResponse)
 	default:
 		return nil, fromID, hash, fmt.Errorf("unknown type: %d", ptype)
 	}
@@ -588,6 +663,7 @@ func (req *ping) handle(t *udp, from *net.UDPAddr, fromID NodeID, mac []byte) er
 		To:         makeEndpoint(from, req.From.TCP),
 		ReplyTok:   mac,
 		Expiration: uint64(time.Now().Add(expiration).Unix()),
+		ENRSeq:     t.localSeq(),
 	})
 	if !t.handleReply(fromID, pingXDC, req) {
 		// Note: we're ignoring the provided IP address right now
@@ -663,6 +739,40 @@ func (req *neighbors) handle(t *udp, from *net.UDPAddr, fromID NodeID, mac []byt
 
 func (req *neighbors) name() string { return "NEIGHBORS/v4" }
 
+func (req *enrRequest) handle(t *udp, from *net.UDPAddr, fromID NodeID, mac []byte) error {
+	if expired(req.Expiration) {
+		return errExpired
+	}
+	if !t.db.hasBond(fromID) {
+		// No bond exists, we don't process the packet for the same reason
+		// as findnode: the record is much bigger than the request.
+		return errUnknownNode
+	}
+	var record *enr.Record
+	if t.record != nil {
+		record = t.record()
+	}
+	if record == nil {
+		return errNoRecord
+	}
+	t.send(from, enrResponsePacket, &enrResponse{
+		ReplyTok: mac,
+		Record:   *record,
+	})
+	return nil
+}
+
+func (req *enrRequest) name() string { return "ENRREQUEST/v4" }
+
+func (req *enrResponse) handle(t *udp, from *net.UDPAddr, fromID NodeID, mac []byte) error {
+	if !t.handleReply(fromID, enrResponsePacket, req) {
+		return errUnsolicitedReply
+	}
+	return nil
+}
+
+func (req *enrResponse) name() string { return "ENRRESPONSE/v4" }
+
 func expired(ts uint64) bool {
 	return time.Unix(int64(ts), 0).Before(time.Now())
 }
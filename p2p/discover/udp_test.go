@@ -35,6 +35,7 @@ import (
 
 	"github.com/XinFinOrg/XDPoSChain/common"
 	"github.com/XinFinOrg/XDPoSChain/crypto"
+	"github.com/XinFinOrg/XDPoSChain/p2p/enr"
 	"github.com/XinFinOrg/XDPoSChain/rlp"
 	"github.com/davecgh/go-spew/spew"
 )
@@ -128,6 +129,8 @@ func TestUDP_packetErrors(t *testing.T) {
 	test.packetIn(errUnsolicitedReply, pongPacket, &pong{ReplyTok: []byte{}, Expiration: futureExp})
 	test.packetIn(errUnknownNode, findnodePacket, &findnode{Expiration: futureExp})
 	test.packetIn(errUnsolicitedReply, neighborsPacket, &neighbors{Expiration: futureExp})
+	test.packetIn(errUnknownNode, enrRequestPacket, &enrRequest{Expiration: futureExp})
+	test.packetIn(errUnsolicitedReply, enrResponsePacket, &enrResponse{ReplyTok: []byte{}, Record: *testRecord(t, test.remotekey, 1)})
 }
 
 func TestUDP_pingTimeout(t *testing.T) {
@@ -322,6 +325,83 @@ func TestUDP_findnodeMultiReply(t *testing.T) {
 	}
 }
 
+func TestUDP_ENRRequest(t *testing.T) {
+	test := newUDPTest(t)
+	defer test.table.Close()
+
+	record := testRecord(t, test.localkey, 5)
+	test.udp.record = func() *enr.Record { return record }
+
+	// the record is only served to bonded nodes.
+	test.packetIn(errUnknownNode, enrRequestPacket, &enrRequest{Expiration: futureExp})
+	test.table.db.updateBondTime(PubkeyID(&test.remotekey.PublicKey), time.Now())
+
+	test.packetIn(errExpired, enrRequestPacket, &enrRequest{Expiration: 1})
+	test.packetIn(nil, enrRequestPacket, &enrRequest{Expiration: futureExp})
+	test.waitPacketOut(func(p *enrResponse) {
+		if !bytes.Equal(p.ReplyTok, test.sent[len(test.sent)-1][:macSize]) {
+			t.Errorf("wrong reply token: %x", p.ReplyTok)
+		}
+		if p.Record.Seq() != record.Seq() {
+			t.Errorf("wrong record seq: got %d, want %d", p.Record.Seq(), record.Seq())
+		}
+	})
+
+	// pings and pongs announce the record sequence number.
+	test.packetIn(nil, pingXDC, &ping{From: testRemote, To: testLocalAnnounced, Version: Version, Expiration: futureExp})
+	test.waitPacketOut(func(p *pong) {
+		if p.ENRSeq != record.Seq() {
+			t.Errorf("wrong pong ENRSeq: got %d, want %d", p.ENRSeq, record.Seq())
+		}
+	})
+}
+
+func TestUDP_requestENR(t *testing.T) {
+	test := newUDPTest(t)
+	defer test.table.Close()
+
+	for _, key := range []*ecdsa.PrivateKey{test.remotekey, newkey()} {
+		resultc, errc := make(chan *enr.Record), make(chan error)
+		go func() {
+			record, err := test.udp.requestENR(PubkeyID(&test.remotekey.PublicKey), test.remoteaddr)
+			if err != nil {
+				errc <- err
+			} else {
+				resultc <- record
+			}
+		}()
+		hash, _ := test.waitPacketOut(func(p *enrRequest) {})
+		test.packetIn(nil, enrResponsePacket, &enrResponse{ReplyTok: hash, Record: *testRecord(t, key, 3)})
+
+		select {
+		case record := <-resultc:
+			if key != test.remotekey {
+				t.Errorf("record of another node accepted")
+			} else if record.Seq() != 3 {
+				t.Errorf("wrong record seq: got %d, want %d", record.Seq(), 3)
+			}
+		case err := <-errc:
+			if key == test.remotekey || err != errInvalidRecord {
+				t.Errorf("requestENR error: %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("requestENR did not return within 5 seconds")
+		}
+	}
+}
+
+// testRecord returns a signed record of the given key with the sequence number.
+func testRecord(t *testing.T, key *ecdsa.PrivateKey, seq uint64) *enr.Record {
+	var r enr.Record
+	r.Set(enr.IP4(net.IP{10, 0, 1, 99}))
+	r.Set(enr.UDP(30303))
+	r.SetSeq(seq - 1)
+	if err := r.Sign(key); err != nil {
+		t.Fatalf("can't sign record: %v", err)
+	}
+	return &r
+}
+
 func TestUDP_successfulPing(t *testing.T) {
 	test := newUDPTest(t)
 	added := make(chan *Node, 1)
@@ -398,7 +478,6 @@ var testPackets = []struct {
 			From:       rpcEndpoint{net.ParseIP("127.0.0.1").To4(), 3322, 5544},
 			To:         rpcEndpoint{net.ParseIP("::1"), 2222, 3333},
 			Expiration: 1136239445,
-			Rest:       []rlp.RawValue{},
 		},
 	},
 	{
@@ -408,25 +487,28 @@ var testPackets = []struct {
 			From:       rpcEndpoint{net.ParseIP("127.0.0.1").To4(), 3322, 5544},
 			To:         rpcEndpoint{net.ParseIP("::1"), 2222, 3333},
 			Expiration: 1136239445,
-			Rest:       []rlp.RawValue{{0x01}, {0x02}},
+			ENRSeq:     1,
+			Rest:       []rlp.RawValue{{0x02}},
 		},
 	},
 	{
-		input: "e3e987421accd2c75967d4a7229c436c18760def054738d8d9669697ee4726cdc9949c51df3e90d795d33d3f57d508c4687913338f6eb9caa89873aaae9dd49a5473ade5ea452c4df9d1f842eadf03439dbc373c0de8b20b412b6760d7b479140105f83e82022bd79020010db83c4d001500000000abcdef12820cfa8215a8d79020010db885a308d313198a2e037073488208ae82823a8443b9a355c50102030405",
+		input: "4925d0d9261bca4d8f214aa0801aca1568cb68317199af9dc7eada1a66d35a5f7cd18e323dcd576fd7606a079a895e4cffc842b0d7d6695139ac7d44d2f26c01397099796d890e5345ff81087f033d3a0db0165cfc48da0c7e8d211467cdd25a0105f83f82022bd79020010db83c4d001500000000abcdef12820cfa8215a8d79020010db885a308d313198a2e037073488208ae82823a8443b9a35503c50102030405",
 		wantPacket: &ping{
 			Version:    555,
 			From:       rpcEndpoint{net.ParseIP("2001:db8:3c4d:15::abcd:ef12"), 3322, 5544},
 			To:         rpcEndpoint{net.ParseIP("2001:db8:85a3:8d3:1319:8a2e:370:7348"), 2222, 33338},
 			Expiration: 1136239445,
+			ENRSeq:     3,
 			Rest:       []rlp.RawValue{{0xC5, 0x01, 0x02, 0x03, 0x04, 0x05}},
 		},
 	},
 	{
-		input: "09b2428d83348d27cdf7064ad9024f526cebc19e4958f0fdad87c15eb598dd61d08423e0bf66b2069869e1724125f820d851c136684082774f870e614d95a2855d000f05d1648b2d5945470bc187c2d2216fbe870f43ed0909009882e176a46b0102f846d79020010db885a308d313198a2e037073488208ae82823aa0fbc914b16819237dcd8801d7e53f69e9719adecb3cc0e790c57e91ca4461c9548443b9a355c6010203c2040506a0c969a58f6f9095004c0177a6b47f451530cab38966a25cca5cb58f055542124e",
+		input: "8c52e9627f9b24a9aa73b850410e5841d2557b1dc18302c22ab3fbfa5229df339f557fa3075c4926507398a12d250d13c58b9a13520dfda8e06592628407c04d6187f5b7066fc8cf138729ff50031cd15e62b13e983d5b39a680d16aa439f0cd0002f847d79020010db885a308d313198a2e037073488208ae82823aa0fbc914b16819237dcd8801d7e53f69e9719adecb3cc0e790c57e91ca4461c9548443b9a35503c6010203c2040506",
 		wantPacket: &pong{
 			To:         rpcEndpoint{net.ParseIP("2001:db8:85a3:8d3:1319:8a2e:370:7348"), 2222, 33338},
 			ReplyTok:   common.Hex2Bytes("fbc914b16819237dcd8801d7e53f69e9719adecb3cc0e790c57e91ca4461c954"),
 			Expiration: 1136239445,
+			ENRSeq:     3,
 			Rest:       []rlp.RawValue{{0xC6, 0x01, 0x02, 0x03, 0xC2, 0x04, 0x05}, {0x06}},
 		},
 	},
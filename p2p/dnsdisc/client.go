@@ -0,0 +1,281 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package dnsdisc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/XinFinOrg/XDPoSChain/crypto"
+	"github.com/XinFinOrg/XDPoSChain/log"
+	"github.com/XinFinOrg/XDPoSChain/p2p/discover"
+	"github.com/XinFinOrg/XDPoSChain/p2p/enr"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+// Client discovers nodes by querying DNS servers.
+type Client struct {
+	cfg     Config
+	entries *lru.Cache
+}
+
+// Config holds configuration options for the client.
+type Config struct {
+	Timeout    time.Duration // timeout used for DNS lookups (default 5s)
+	CacheLimit int           // maximum number of cached records (default 1000)
+	Logger     log.Logger    // debug log target (default the root logger)
+	Resolver   Resolver      // the DNS resolver (default net.DefaultResolver)
+}
+
+// Resolver is a DNS resolver that can query TXT records.
+type Resolver interface {
+	LookupTXT(ctx context.Context, domain string) ([]string, error)
+}
+
+func (cfg Config) withDefaults() Config {
+	const (
+		defaultTimeout = 5 * time.Second
+		defaultCache   = 1000
+	)
+	if cfg.Timeout == 0 {
+		cfg.Timeout = defaultTimeout
+	}
+	if cfg.CacheLimit == 0 {
+		cfg.CacheLimit = defaultCache
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = log.Root()
+	}
+	if cfg.Resolver == nil {
+		cfg.Resolver = new(net.Resolver)
+	}
+	return cfg
+}
+
+// NewClient creates a client.
+func NewClient(cfg Config) *Client {
+	cfg = cfg.withDefaults()
+	cache, err := lru.New(cfg.CacheLimit)
+	if err != nil {
+		panic(err)
+	}
+	return &Client{cfg: cfg, entries: cache}
+}
+
+// SyncTree downloads the entire node tree at the given URL.
+func (c *Client) SyncTree(url string) (*Tree, error) {
+	loc, err := parseLink(url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid enrtree URL: %v", err)
+	}
+	return c.syncTree(context.Background(), loc)
+}
+
+// Nodes downloads the node trees at the given URLs, following the links to
+// other trees, and returns the nodes found in them. Trees failing to sync are
+// skipped, an error is returned only if no node could be found.
+func (c *Client) Nodes(ctx context.Context, urls ...string) ([]*discover.Node, error) {
+	records, err := c.Records(ctx, urls...)
+	if err != nil {
+		return nil, err
+	}
+	nodes := make([]*discover.Node, len(records))
+	for i, r := range records {
+		// Records returns valid node records only.
+		nodes[i], _ = discover.NodeFromRecord(r)
+	}
+	return nodes, nil
+}
+
+// Records is like Nodes, but returns the node records found in the trees,
+// including any entries beyond the node endpoint.
+func (c *Client) Records(ctx context.Context, urls ...string) ([]*enr.Record, error) {
+	var queue []*linkEntry
+	for _, url := range urls {
+		loc, err := parseLink(url)
+		if err != nil {
+			return nil, fmt.Errorf("invalid enrtree URL %q: %v", url, err)
+		}
+		queue = append(queue, loc)
+	}
+	var (
+		synced   = make(map[string]bool)
+		known    = make(map[discover.NodeID]bool)
+		records  []*enr.Record
+		firstErr error
+	)
+	for len(queue) > 0 {
+		loc := queue[0]
+		queue = queue[1:]
+		if synced[loc.str] {
+			continue
+		}
+		synced[loc.str] = true
+
+		t, err := c.syncTree(ctx, loc)
+		if err != nil {
+			c.cfg.Logger.Debug("DNS discovery tree sync failed", "tree", loc.domain, "err", err)
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		for _, e := range t.entries {
+			if le, ok := e.(*linkEntry); ok {
+				queue = append(queue, le)
+			}
+		}
+		for _, r := range t.Nodes() {
+			n, err := discover.NodeFromRecord(r)
+			if err != nil {
+				c.cfg.Logger.Trace("Skipping invalid DNS discovery record", "tree", loc.domain, "err", err)
+				continue
+			}
+			if !known[n.ID] {
+				known[n.ID] = true
+				records = append(records, r)
+			}
+		}
+	}
+	if len(records) == 0 && firstErr != nil {
+		return nil, firstErr
+	}
+	return records, nil
+}
+
+// syncTree downloads the root and all entries of the tree at loc.
+func (c *Client) syncTree(ctx context.Context, loc *linkEntry) (*Tree, error) {
+	root, err := c.resolveRoot(ctx, loc)
+	if err != nil {
+		return nil, err
+	}
+	t := &Tree{root: &root, entries: make(map[string]entry)}
+	if err := c.syncEntries(ctx, loc.domain, root.eroot, t.entries, false); err != nil {
+		return nil, err
+	}
+	if err := c.syncEntries(ctx, loc.domain, root.lroot, t.entries, true); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// syncEntries downloads the subtree below the given hash into dest.
+func (c *Client) syncEntries(ctx context.Context, domain, hash string, dest map[string]entry, link bool) error {
+	if _, ok := dest[hash]; ok {
+		return nil
+	}
+	e, err := c.resolveEntry(ctx, domain, hash)
+	if err != nil {
+		return err
+	}
+	dest[hash] = e
+	switch e := e.(type) {
+	case *branchEntry:
+		for _, child := range e.children {
+			if err := c.syncEntries(ctx, domain, child, dest, link); err != nil {
+				return err
+			}
+		}
+	case *enrEntry:
+		if link {
+			return nameError{domain, errENRInLinkTree}
+		}
+	case *linkEntry:
+		if !link {
+			return nameError{domain, errLinkInENRTree}
+		}
+	}
+	return nil
+}
+
+// resolveRoot retrieves a root entry via DNS.
+func (c *Client) resolveRoot(ctx context.Context, loc *linkEntry) (rootEntry, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	defer cancel()
+
+	txts, err := c.cfg.Resolver.LookupTXT(ctx, loc.domain)
+	c.cfg.Logger.Trace("Updating DNS discovery root", "tree", loc.domain, "err", err)
+	if err != nil {
+		return rootEntry{}, err
+	}
+	for _, txt := range txts {
+		if strings.HasPrefix(txt, rootPrefix) {
+			return parseAndVerifyRoot(txt, loc)
+		}
+	}
+	return rootEntry{}, nameError{loc.domain, errNoRoot}
+}
+
+func parseAndVerifyRoot(txt string, loc *linkEntry) (rootEntry, error) {
+	e, err := parseRoot(txt)
+	if err != nil {
+		return e, err
+	}
+	if !e.verifySignature(loc.pubkey) {
+		return e, entryError{typ: "root", err: errInvalidSig}
+	}
+	return e, nil
+}
+
+// resolveEntry retrieves an entry from the cache or fetches it from the network
+// if it isn't cached.
+func (c *Client) resolveEntry(ctx context.Context, domain, hash string) (entry, error) {
+	if e, ok := c.entries.Get(hash); ok {
+		return e.(entry), nil
+	}
+	e, err := c.doResolveEntry(ctx, domain, hash)
+	if err != nil {
+		return nil, err
+	}
+	c.entries.Add(hash, e)
+	return e, nil
+}
+
+// doResolveEntry fetches an entry via DNS.
+func (c *Client) doResolveEntry(ctx context.Context, domain, hash string) (entry, error) {
+	wantHash, err := b32format.DecodeString(hash)
+	if err != nil {
+		return nil, errors.New("invalid base32 hash")
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	defer cancel()
+
+	name := hash + "." + domain
+	txts, err := c.cfg.Resolver.LookupTXT(ctx, name)
+	c.cfg.Logger.Trace("DNS discovery lookup", "name", name, "err", err)
+	if err != nil {
+		return nil, err
+	}
+	for _, txt := range txts {
+		e, err := parseEntry(txt)
+		if err == errUnknownEntry {
+			continue
+		}
+		if !bytes.HasPrefix(crypto.Keccak256([]byte(txt)), wantHash) {
+			err = nameError{name, errHashMismatch}
+		} else if err != nil {
+			err = nameError{name, err}
+		}
+		return e, err
+	}
+	return nil, nameError{name, errNoEntry}
+}
@@ -0,0 +1,166 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package dnsdisc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/XinFinOrg/XDPoSChain/p2p/discover"
+	"github.com/XinFinOrg/XDPoSChain/p2p/enr"
+)
+
+func TestClientSyncTree(t *testing.T) {
+	key := testKey(signingKeySeed)
+	records := testRecords(nodesSeed1, 20)
+	tree, url := makeTestTree(t, "n", key, records, nil)
+
+	c := NewClient(Config{Resolver: newMapResolver(tree.ToTXT("n"))})
+	stree, err := c.SyncTree(url)
+	if err != nil {
+		t.Fatal("sync error:", err)
+	}
+	if !reflect.DeepEqual(stree.Nodes(), tree.Nodes()) {
+		t.Errorf("wrong nodes in synced tree")
+	}
+	if stree.Seq() != tree.Seq() {
+		t.Errorf("synced tree has wrong seq: %d", stree.Seq())
+	}
+	if !reflect.DeepEqual(stree.ToTXT("n"), tree.ToTXT("n")) {
+		t.Errorf("synced tree differs from source tree")
+	}
+}
+
+// This test checks that the client rejects trees with a root signed by
+// another key than the one in the URL.
+func TestClientSyncTreeBadSignature(t *testing.T) {
+	tree, _ := makeTestTree(t, "n", testKey(signingKeySeed), testRecords(nodesSeed1, 2), nil)
+	url := newLinkEntry("n", &testKey(nodesSeed2).PublicKey).String()
+
+	c := NewClient(Config{Resolver: newMapResolver(tree.ToTXT("n"))})
+	_, err := c.SyncTree(url)
+	if want := (entryError{"root", errInvalidSig}); err != want {
+		t.Fatalf("expected error %q, got %q", want, err)
+	}
+}
+
+// This test checks that entries whose content doesn't match the hash they are
+// published under are rejected.
+func TestClientSyncTreeHashMismatch(t *testing.T) {
+	records := testRecords(nodesSeed1, 2)
+	tree, url := makeTestTree(t, "n", testKey(signingKeySeed), records, nil)
+	txt := tree.ToTXT("n")
+	other := testRecords(nodesSeed2, 1)[0]
+	for name, value := range txt {
+		if strings.HasPrefix(value, enrPrefix) {
+			text, _ := other.MarshalText()
+			txt[name] = string(text)
+			break
+		}
+	}
+
+	c := NewClient(Config{Resolver: newMapResolver(txt)})
+	_, err := c.SyncTree(url)
+	var nerr nameError
+	if !errors.As(err, &nerr) || nerr.err != errHashMismatch {
+		t.Fatalf("expected hash mismatch error, got %v", err)
+	}
+}
+
+// This test checks that Nodes follows the links between trees and returns the
+// nodes of all linked trees.
+func TestClientNodes(t *testing.T) {
+	var (
+		key    = testKey(signingKeySeed)
+		nodes1 = testRecords(nodesSeed1, 10)
+		nodes2 = testRecords(nodesSeed2, 10)
+	)
+	tree2, url2 := makeTestTree(t, "t2", key, nodes2, nil)
+	tree1, url1 := makeTestTree(t, "t1", key, nodes1, []string{url2})
+
+	r := mapResolver{}
+	r.add(tree1.ToTXT("t1"))
+	r.add(tree2.ToTXT("t2"))
+
+	c := NewClient(Config{Resolver: r})
+	nodes, err := c.Nodes(context.Background(), url1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := make(map[discover.NodeID]bool)
+	for _, rec := range append(nodes1, nodes2...) {
+		n, _ := discover.NodeFromRecord(rec)
+		want[n.ID] = true
+	}
+	if len(nodes) != len(want) {
+		t.Fatalf("wrong number of nodes: got %d, want %d", len(nodes), len(want))
+	}
+	for _, n := range nodes {
+		if !want[n.ID] {
+			t.Errorf("unexpected node %v", n)
+		}
+	}
+
+	// Unknown trees are skipped while there are nodes in the others.
+	missing := newLinkEntry("missing", &key.PublicKey).String()
+	if nodes, err := c.Nodes(context.Background(), missing, url2); err != nil || len(nodes) != len(nodes2) {
+		t.Fatalf("wrong result with missing tree: %d nodes, err %v", len(nodes), err)
+	}
+	if _, err := c.Nodes(context.Background(), missing); err == nil {
+		t.Fatal("expected error for missing tree")
+	}
+}
+
+func makeTestTree(t *testing.T, domain string, key *ecdsa.PrivateKey, records []*enr.Record, links []string) (*Tree, string) {
+	tree, err := MakeTree(1, records, links)
+	if err != nil {
+		t.Fatal(err)
+	}
+	url, err := tree.Sign(key, domain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tree, url
+}
+
+// mapResolver implements Resolver.
+type mapResolver map[string]string
+
+func newMapResolver(maps ...map[string]string) mapResolver {
+	mr := make(mapResolver)
+	for _, m := range maps {
+		mr.add(m)
+	}
+	return mr
+}
+
+func (mr mapResolver) add(m map[string]string) {
+	for k, v := range m {
+		mr[k] = v
+	}
+}
+
+func (mr mapResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	if record, ok := mr[name]; ok {
+		return []string{record}, nil
+	}
+	return nil, errors.New("not found")
+}
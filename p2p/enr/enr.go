@@ -30,10 +30,12 @@ package enr
 import (
 	"bytes"
 	"crypto/ecdsa"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"io"
 	"sort"
+	"strings"
 
 	"github.com/XinFinOrg/XDPoSChain/crypto"
 	"github.com/XinFinOrg/XDPoSChain/crypto/sha3"
@@ -54,6 +56,7 @@ var (
 	errTooBig         = fmt.Errorf("record bigger than %d bytes", SizeLimit)
 	errEncodeUnsigned = errors.New("can't encode unsigned record")
 	errNotFound       = errors.New("no such key in record")
+	errMissingPrefix  = errors.New("missing 'enr:' prefix for base64-encoded record")
 )
 
 // Record represents a node record. The zero value is an empty record.
@@ -205,6 +208,28 @@ func (r *Record) DecodeRLP(s *rlp.Stream) error {
 	return nil
 }
 
+// MarshalText implements encoding.TextMarshaler. The text form of a record is
+// its RLP encoding in URL-safe base64 without padding, prefixed with "enr:".
+func (r Record) MarshalText() ([]byte, error) {
+	if !r.Signed() {
+		return nil, errEncodeUnsigned
+	}
+	return []byte("enr:" + base64.RawURLEncoding.EncodeToString(r.raw)), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler. Decoding verifies the
+// signature.
+func (r *Record) UnmarshalText(input []byte) error {
+	if !bytes.HasPrefix(input, []byte("enr:")) {
+		return errMissingPrefix
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimSpace(string(input[4:])))
+	if err != nil {
+		return err
+	}
+	return rlp.DecodeBytes(raw, r)
+}
+
 type s256raw []byte
 
 func (s256raw) ENRKey() string { return "secp256k1" }
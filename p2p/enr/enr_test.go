@@ -21,6 +21,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"math/rand"
+	"strings"
 	"testing"
 	"time"
 
@@ -224,6 +225,29 @@ func TestSignEncodeAndDecode(t *testing.T) {
 	assert.Equal(t, blob, blob2)
 }
 
+// TestTextEncodeAndDecode tests the "enr:" text form of a record.
+func TestTextEncodeAndDecode(t *testing.T) {
+	var r Record
+	r.Set(IP4{127, 0, 0, 1})
+	r.Set(TCP(30303))
+	r.Set(UDP(30301))
+	require.NoError(t, r.Sign(privkey))
+
+	text, err := r.MarshalText()
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(text), "enr:"))
+
+	var r2 Record
+	require.NoError(t, r2.UnmarshalText(text))
+	assert.Equal(t, r, r2)
+
+	var tcp TCP
+	require.NoError(t, r2.Load(&tcp))
+	assert.Equal(t, TCP(30303), tcp)
+
+	assert.Error(t, r2.UnmarshalText(text[4:]))
+}
+
 func TestNodeAddr(t *testing.T) {
 	var r Record
 	if addr := r.NodeAddr(); addr != nil {
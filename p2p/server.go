@@ -18,6 +18,8 @@
 package p2p
 
 import (
+	"bytes"
+	"context"
 	"crypto/ecdsa"
 	"errors"
 	"net"
@@ -30,8 +32,11 @@ import (
 	"github.com/XinFinOrg/XDPoSChain/log"
 	"github.com/XinFinOrg/XDPoSChain/p2p/discover"
 	"github.com/XinFinOrg/XDPoSChain/p2p/discv5"
+	"github.com/XinFinOrg/XDPoSChain/p2p/dnsdisc"
+	"github.com/XinFinOrg/XDPoSChain/p2p/enr"
 	"github.com/XinFinOrg/XDPoSChain/p2p/nat"
 	"github.com/XinFinOrg/XDPoSChain/p2p/netutil"
+	"github.com/XinFinOrg/XDPoSChain/rlp"
 )
 
 const (
@@ -48,6 +53,9 @@ const (
 
 	// Maximum amount of time allowed for writing a complete message.
 	frameWriteTimeout = 20 * time.Second
+
+	// Interval between the updates of the DNS discovery trees.
+	dnsRecheckInterval = 30 * time.Minute
 )
 
 var errServerStopped = errors.New("server stopped")
@@ -92,6 +100,11 @@ type Config struct {
 	// protocol.
 	BootstrapNodesV5 []*discv5.Node `toml:",omitempty"`
 
+	// DiscoveryDNS holds the enrtree:// URLs of the DNS discovery (EIP-1459)
+	// trees. Their nodes are dialed alongside the ones found by the discovery
+	// protocol, which must be enabled.
+	DiscoveryDNS []string `toml:",omitempty"`
+
 	// Static nodes are used as pre-configured connections which are always
 	// maintained and re-connected on disconnects.
 	StaticNodes []*discover.Node
@@ -161,6 +174,10 @@ type Server struct {
 	lastLookup   time.Time
 	DiscV5       *discv5.Network
 
+	recordMu      sync.Mutex  // protects record and recordContent
+	record        *enr.Record // signed local record, re-signed when its content changes
+	recordContent []byte      // encoded entries of the signed local record
+
 	// These are for Peers, PeerCount (and nothing else).
 	peerOp     chan peerOpFunc
 	peerOpDone chan struct{}
@@ -168,6 +185,9 @@ type Server struct {
 	quit          chan struct{}
 	addstatic     chan *discover.Node
 	removestatic  chan *discover.Node
+	addtrusted    chan *discover.Node
+	removetrusted chan *discover.Node
+	adddns        chan []*discover.Node
 	posthandshake chan *conn
 	addpeer       chan *conn
 	delpeer       chan peerDrop
@@ -300,6 +320,23 @@ func (srv *Server) RemovePeer(node *discover.Node) {
 	}
 }
 
+// AddTrustedPeer adds the given node to the set of trusted nodes, which are
+// always allowed to connect, even above the peer limit.
+func (srv *Server) AddTrustedPeer(node *discover.Node) {
+	select {
+	case srv.addtrusted <- node:
+	case <-srv.quit:
+	}
+}
+
+// RemoveTrustedPeer removes the given node from the set of trusted nodes.
+func (srv *Server) RemoveTrustedPeer(node *discover.Node) {
+	select {
+	case srv.removetrusted <- node:
+	case <-srv.quit:
+	}
+}
+
 // SubscribePeers subscribes the given channel to peer events
 func (srv *Server) SubscribeEvents(ch chan *PeerEvent) event.Subscription {
 	return srv.peerFeed.Subscribe(ch)
@@ -410,6 +447,9 @@ func (srv *Server) Start() (err error) {
 	srv.posthandshake = make(chan *conn)
 	srv.addstatic = make(chan *discover.Node)
 	srv.removestatic = make(chan *discover.Node)
+	srv.addtrusted = make(chan *discover.Node)
+	srv.removetrusted = make(chan *discover.Node)
+	srv.adddns = make(chan []*discover.Node)
 	srv.peerOp = make(chan peerOpFunc)
 	srv.peerOpDone = make(chan struct{})
 
@@ -455,6 +495,7 @@ func (srv *Server) Start() (err error) {
 			NetRestrict:  srv.NetRestrict,
 			Bootnodes:    srv.BootstrapNodes,
 			Unhandled:    unhandled,
+			NodeRecord:   func() *enr.Record { return srv.nodeRecord(srv.Self()) },
 		}
 		ntab, err := discover.ListenUDP(conn, cfg)
 		if err != nil {
@@ -500,12 +541,57 @@ func (srv *Server) Start() (err error) {
 		srv.log.Warn("P2P server will be useless, neither dialing nor listening")
 	}
 
+	if len(srv.DiscoveryDNS) > 0 {
+		if dynPeers == 0 {
+			srv.log.Warn("DNS discovery needs peer discovery and dialing enabled, ignoring trees", "trees", len(srv.DiscoveryDNS))
+		} else {
+			srv.loopWG.Add(1)
+			go srv.dnsLoop()
+		}
+	}
+
 	srv.loopWG.Add(1)
 	go srv.run(dialer)
 	srv.running = true
 	return nil
 }
 
+// dnsLoop periodically resolves the DNS discovery trees and hands their nodes
+// to the dialer.
+func (srv *Server) dnsLoop() {
+	defer srv.loopWG.Done()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-srv.quit
+		cancel()
+	}()
+
+	client := dnsdisc.NewClient(dnsdisc.Config{Logger: srv.log})
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-timer.C:
+			nodes, err := client.Nodes(ctx, srv.DiscoveryDNS...)
+			if err != nil {
+				srv.log.Warn("Failed to resolve DNS discovery trees", "err", err)
+			} else {
+				srv.log.Debug("Resolved DNS discovery trees", "nodes", len(nodes))
+				select {
+				case srv.adddns <- nodes:
+				case <-srv.quit:
+					return
+				}
+			}
+			timer.Reset(dnsRecheckInterval)
+		case <-srv.quit:
+			return
+		}
+	}
+}
+
 func (srv *Server) startListening() error {
 	// Launch the TCP listener.
 	listener, err := net.Listen("tcp", srv.ListenAddr)
@@ -533,6 +619,7 @@ type dialer interface {
 	taskDone(task, time.Time)
 	addStatic(*discover.Node)
 	removeStatic(*discover.Node)
+	addDNSNodes([]*discover.Node)
 }
 
 func (srv *Server) run(dialstate dialer) {
@@ -546,8 +633,8 @@ func (srv *Server) run(dialstate dialer) {
 		queuedTasks  []task // tasks that can't run yet
 	)
 	// Put trusted nodes into a map to speed up checks.
-	// Trusted peers are loaded on startup and cannot be
-	// modified while the server is running.
+	// Trusted peers are loaded on startup and can be
+	// modified through AddTrustedPeer and RemoveTrustedPeer.
 	for _, n := range srv.TrustedNodes {
 		trusted[n.ID] = true
 	}
@@ -605,6 +692,20 @@ running:
 			if p, ok := peers[n.ID]; ok {
 				p.Disconnect(DiscRequested)
 			}
+		case n := <-srv.addtrusted:
+			// This channel is used by AddTrustedPeer to add a node
+			// to the trusted node set.
+			srv.log.Debug("Adding trusted node", "node", n)
+			trusted[n.ID] = true
+		case n := <-srv.removetrusted:
+			// This channel is used by RemoveTrustedPeer to remove a
+			// node from the trusted node set.
+			srv.log.Debug("Removing trusted node", "node", n)
+			delete(trusted, n.ID)
+		case nodes := <-srv.adddns:
+			// This channel is used by dnsLoop to hand over the
+			// nodes of the DNS discovery trees to the dialer.
+			dialstate.addDNSNodes(nodes)
 		case op := <-srv.peerOp:
 			// This channel is used by Peers and PeerCount.
 			op(peers)
@@ -920,6 +1021,7 @@ type NodeInfo struct {
 	ID    string `json:"id"`    // Unique node identifier (also the encryption key)
 	Name  string `json:"name"`  // Name of the node, including client type, version, OS, custom data
 	Enode string `json:"enode"` // Enode URL for adding this peer from remote peers
+	ENR   string `json:"enr"`   // Node record of the node, published in DNS discovery trees
 	IP    string `json:"ip"`    // IP address of the node
 	Ports struct {
 		Discovery int `json:"discovery"` // UDP listening port for discovery protocol
@@ -944,6 +1046,10 @@ func (srv *Server) NodeInfo() *NodeInfo {
 	}
 	info.Ports.Discovery = int(node.UDP)
 	info.Ports.Listener = int(node.TCP)
+	if record := srv.nodeRecord(node); record != nil {
+		text, _ := record.MarshalText()
+		info.ENR = string(text)
+	}
 
 	// Gather all the running protocol infos (only once per protocol type)
 	for _, proto := range srv.Protocols {
@@ -958,6 +1064,76 @@ func (srv *Server) NodeInfo() *NodeInfo {
 	return info
 }
 
+// nodeRecord returns the signed node record announcing the given endpoint and
+// the protocol attributes of the local node, or nil if the endpoint isn't
+// reachable. The record is only re-signed, with a higher sequence number, when
+// its content changes.
+func (srv *Server) nodeRecord(self *discover.Node) *enr.Record {
+	if self.IP == nil || self.IP.IsUnspecified() || self.TCP == 0 || srv.PrivateKey == nil {
+		return nil
+	}
+	var entries []enr.Entry
+	if ip4 := self.IP.To4(); ip4 != nil {
+		entries = append(entries, enr.IP4(ip4))
+	} else {
+		entries = append(entries, enr.IP6(self.IP))
+	}
+	entries = append(entries, enr.TCP(self.TCP))
+	if self.UDP != 0 {
+		entries = append(entries, enr.UDP(self.UDP))
+	}
+	// Protocols sharing an attribute key (e.g. versions of the same protocol)
+	// announce the attribute of the first of them. Attributes encoding to an
+	// empty string are left out, so that protocols can announce them only
+	// while they apply.
+	seen := make(map[string]bool)
+	for _, proto := range srv.Protocols {
+		for _, attr := range proto.Attributes {
+			if seen[attr.ENRKey()] {
+				continue
+			}
+			seen[attr.ENRKey()] = true
+			if enc, err := rlp.EncodeToBytes(attr); err == nil && bytes.Equal(enc, rlp.EmptyString) {
+				continue
+			}
+			entries = append(entries, attr)
+		}
+	}
+	var pairs []interface{}
+	for _, entry := range entries {
+		pairs = append(pairs, entry.ENRKey(), entry)
+	}
+	content, err := rlp.EncodeToBytes(pairs)
+	if err != nil {
+		srv.log.Error("Failed to encode node record", "err", err)
+		return nil
+	}
+
+	srv.recordMu.Lock()
+	defer srv.recordMu.Unlock()
+
+	if srv.record != nil && bytes.Equal(content, srv.recordContent) {
+		return srv.record
+	}
+	// The sequence number follows the clock so that it keeps growing across
+	// restarts of the node.
+	seq := uint64(time.Now().Unix())
+	if srv.record != nil && seq <= srv.record.Seq() {
+		seq = srv.record.Seq() + 1
+	}
+	var r enr.Record
+	for _, entry := range entries {
+		r.Set(entry)
+	}
+	r.SetSeq(seq - 1)
+	if err := r.Sign(srv.PrivateKey); err != nil {
+		srv.log.Error("Failed to sign node record", "err", err)
+		return nil
+	}
+	srv.record, srv.recordContent = &r, content
+	return srv.record
+}
+
 // PeersInfo returns an array of metadata objects describing connected peers.
 func (srv *Server) PeersInfo() []*PeerInfo {
 	// Gather all the generic and sub-protocol specific infos
@@ -19,6 +19,7 @@ package p2p
 import (
 	"crypto/ecdsa"
 	"errors"
+	"io"
 	"math/rand"
 	"net"
 	"reflect"
@@ -29,6 +30,8 @@ import (
 	"github.com/XinFinOrg/XDPoSChain/crypto/sha3"
 	"github.com/XinFinOrg/XDPoSChain/log"
 	"github.com/XinFinOrg/XDPoSChain/p2p/discover"
+	"github.com/XinFinOrg/XDPoSChain/p2p/enr"
+	"github.com/XinFinOrg/XDPoSChain/rlp"
 )
 
 func init() {
@@ -323,6 +326,8 @@ func (tg taskgen) addStatic(*discover.Node) {
 }
 func (tg taskgen) removeStatic(*discover.Node) {
 }
+func (tg taskgen) addDNSNodes([]*discover.Node) {
+}
 
 type testTask struct {
 	index  int
@@ -378,6 +383,23 @@ func TestServerAtCap(t *testing.T) {
 		t.Error("Server did not set trusted flag")
 	}
 
+	// Add a node to the trusted set and check that it's accepted.
+	id := randomID()
+	srv.AddTrustedPeer(&discover.Node{ID: id})
+	c = newconn(id)
+	if err := srv.checkpoint(c, srv.posthandshake); err != nil {
+		t.Error("unexpected error for trusted conn @posthandshake:", err)
+	}
+	if !c.is(trustedConn) {
+		t.Error("Server did not set trusted flag")
+	}
+
+	// Remove it from the trusted set and check that it's rejected again.
+	srv.RemoveTrustedPeer(&discover.Node{ID: id})
+	c = newconn(id)
+	if err := srv.checkpoint(c, srv.posthandshake); err != DiscTooManyPeers {
+		t.Error("wrong error for insert:", err)
+	}
 }
 
 func TestServerSetupConn(t *testing.T) {
@@ -502,6 +524,62 @@ func (c *setupTransport) ReadMsg() (Msg, error) {
 	panic("ReadMsg called on setupTransport")
 }
 
+// testAttr is a node record attribute whose value can change over time.
+type testAttr struct{ value *uint }
+
+func (a testAttr) ENRKey() string { return "test" }
+
+func (a testAttr) EncodeRLP(w io.Writer) error { return rlp.Encode(w, *a.value) }
+
+func TestServerNodeRecord(t *testing.T) {
+	value := uint(1)
+	srv := &Server{
+		Config: Config{
+			PrivateKey: newkey(),
+			Protocols: []Protocol{
+				{Name: "test", Version: 2, Attributes: []enr.Entry{testAttr{&value}}},
+				{Name: "test", Version: 1, Attributes: []enr.Entry{testAttr{new(uint)}}},
+			},
+		},
+		log: log.New(),
+	}
+	if srv.nodeRecord(&discover.Node{IP: net.IPv4zero, TCP: 30303}) != nil {
+		t.Fatal("record created for unspecified address")
+	}
+	self := &discover.Node{IP: net.IP{10, 0, 0, 1}, TCP: 30303, UDP: 30301}
+	record := srv.nodeRecord(self)
+	if record == nil {
+		t.Fatal("no record created")
+	}
+	if again := srv.nodeRecord(self); again.Seq() != record.Seq() {
+		t.Errorf("unchanged record re-signed: seq %d, want %d", again.Seq(), record.Seq())
+	}
+	var (
+		tcp  enr.TCP
+		attr uint
+	)
+	if err := record.Load(&tcp); err != nil || tcp != 30303 {
+		t.Errorf("wrong TCP entry %d: %v", tcp, err)
+	}
+	if err := record.Load(enr.WithEntry("test", &attr)); err != nil || attr != 1 {
+		t.Errorf("wrong protocol attribute %d: %v", attr, err)
+	}
+	// Changing the attribute value updates the record with a higher seq
+	value = 2
+	updated := srv.nodeRecord(self)
+	if updated.Seq() <= record.Seq() {
+		t.Errorf("changed record not re-signed: seq %d, previous %d", updated.Seq(), record.Seq())
+	}
+	if err := updated.Load(enr.WithEntry("test", &attr)); err != nil || attr != 2 {
+		t.Errorf("wrong updated protocol attribute %d: %v", attr, err)
+	}
+	// Attributes encoding to an empty string are left out
+	value = 0
+	if err := srv.nodeRecord(self).Load(enr.WithEntry("test", &attr)); !enr.IsNotFound(err) {
+		t.Errorf("empty protocol attribute announced: %v", err)
+	}
+}
+
 func newkey() *ecdsa.PrivateKey {
 	key, err := crypto.GenerateKey()
 	if err != nil {
@@ -378,6 +378,7 @@ type ChainConfig struct {
 	MergeBlock      *big.Int `json:"mergeBlock,omitempty"`
 	ShanghaiBlock   *big.Int `json:"shanghaiBlock,omitempty"`
 	Eip1559Block    *big.Int `json:"eip1559Block,omitempty"`
+	CancunBlock     *big.Int `json:"cancunBlock,omitempty"` // Cancun switch block (nil = no fork, 0 = already activated), requires Eip1559Block
 
 	// Various consensus engines
 	Ethash *EthashConfig `json:"ethash,omitempty"`
@@ -514,7 +515,7 @@ func (c *ChainConfig) String() string {
 	default:
 		engine = "unknown"
 	}
-	return fmt.Sprintf("{ChainID: %v Homestead: %v DAO: %v DAOSupport: %v EIP150: %v EIP155: %v EIP158: %v Byzantium: %v Constantinople: %v Istanbul: %v  BerlinBlock: %v LondonBlock: %v MergeBlock: %v ShanghaiBlock: %v Eip1559Block: %v Engine: %v}",
+	return fmt.Sprintf("{ChainID: %v Homestead: %v DAO: %v DAOSupport: %v EIP150: %v EIP155: %v EIP158: %v Byzantium: %v Constantinople: %v Istanbul: %v  BerlinBlock: %v LondonBlock: %v MergeBlock: %v ShanghaiBlock: %v Eip1559Block: %v CancunBlock: %v Engine: %v}",
 		c.ChainId,
 		c.HomesteadBlock,
 		c.DAOForkBlock,
@@ -530,6 +531,7 @@ func (c *ChainConfig) String() string {
 		common.MergeBlock,
 		common.ShanghaiBlock,
 		common.Eip1559Block,
+		common.CancunBlock,
 		engine,
 	)
 }
@@ -601,6 +603,11 @@ func (c *ChainConfig) IsEIP1559(num *big.Int) bool {
 	return isForked(common.Eip1559Block, num) || isForked(c.Eip1559Block, num)
 }
 
+// IsCancun returns whether num is either equal to the Cancun fork block or greater.
+func (c *ChainConfig) IsCancun(num *big.Int) bool {
+	return isForked(common.CancunBlock, num) || isForked(c.CancunBlock, num)
+}
+
 func (c *ChainConfig) IsTIP2019(num *big.Int) bool {
 	return isForked(common.TIP2019Block, num)
 }
@@ -646,6 +653,14 @@ func (c *ChainConfig) IsTIPXDCXCancellationFee(num *big.Int) bool {
 	return isForked(common.TIPXDCXCancellationFee, num)
 }
 
+func (c *ChainConfig) IsTIPXDCXOrderTypes(num *big.Int) bool {
+	return isForked(common.TIPXDCXOrderTypes, num)
+}
+
+func (c *ChainConfig) IsTIPXDCXTriggerOrders(num *big.Int) bool {
+	return isForked(common.TIPXDCXTriggerOrders, num)
+}
+
 // GasTable returns the gas table corresponding to the current phase (homestead or homestead reprice).
 //
 // The returned GasTable's fields shouldn't, under any circumstances, be changed.
@@ -779,7 +794,7 @@ type Rules struct {
 	IsBerlin, IsLondon                                      bool
 	IsMerge, IsShanghai                                     bool
 	IsXDCxDisable                                           bool
-	IsEIP1559                                               bool
+	IsEIP1559, IsCancun                                     bool
 }
 
 func (c *ChainConfig) Rules(num *big.Int) Rules {
@@ -803,5 +818,6 @@ func (c *ChainConfig) Rules(num *big.Int) Rules {
 		IsShanghai:       c.IsShanghai(num),
 		IsXDCxDisable:    c.IsXDCxDisable(num),
 		IsEIP1559:        c.IsEIP1559(num),
+		IsCancun:         c.IsCancun(num),
 	}
 }
@@ -0,0 +1,440 @@
+// Copyright (c) 2018 XDPoSChain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/XinFinOrg/XDPoSChain/log"
+)
+
+const (
+	// jwtMaxClockSkew is how far the issuance time of a token may be from the
+	// local time. Clients sign a fresh token for each request, so a token
+	// can't be replayed after this window.
+	jwtMaxClockSkew = 60 * time.Second
+
+	// jwtSecretLength is the length in bytes of the token secrets.
+	jwtSecretLength = 32
+
+	// jwtReloadInterval is how often a secret file is checked for changes.
+	jwtReloadInterval = time.Second
+)
+
+var (
+	errMissingToken  = errors.New("missing bearer token")
+	errInvalidToken  = errors.New("invalid token")
+	errTokenExpired  = errors.New("token expired")
+	errTokenSkewed   = errors.New("token issued too far from the current time")
+	errNoJWTSecret   = errors.New("no JWT secret")
+	jwtHeaderEncoded = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+)
+
+// JWTSecret is a secret of the tokens accepted by an authenticated endpoint,
+// with the namespaces and methods the clients holding it may call. The
+// permissions are set by the operator in the secret file, a secret without any
+// may call every method.
+type JWTSecret struct {
+	Key        []byte
+	Namespaces []string
+	Methods    []string
+}
+
+// allows returns whether the holders of the secret may call the method.
+func (s *JWTSecret) allows(method string) bool {
+	if len(s.Namespaces) == 0 && len(s.Methods) == 0 {
+		return true
+	}
+	return allowsMethod(s.Namespaces, s.Methods, method)
+}
+
+// JWTClaims are the claims of the tokens accepted by an authenticated endpoint.
+// A token may narrow the permissions of its secret down to the methods of its
+// namespaces and the ones matching its method patterns, it never extends them.
+type JWTClaims struct {
+	IssuedAt   int64    `json:"iat"`
+	ExpiresAt  int64    `json:"exp,omitempty"`
+	Namespaces []string `json:"namespaces,omitempty"`
+	Methods    []string `json:"methods,omitempty"`
+}
+
+// expired returns whether the token expired at the given time.
+func (c *JWTClaims) expired(now time.Time) bool {
+	return c.ExpiresAt != 0 && now.Unix() >= c.ExpiresAt
+}
+
+// allows returns whether the token doesn't exclude the method.
+func (c *JWTClaims) allows(method string) bool {
+	if len(c.Namespaces) == 0 && len(c.Methods) == 0 {
+		return true
+	}
+	return allowsMethod(c.Namespaces, c.Methods, method)
+}
+
+// allowsMethod returns whether the method is in one of the namespaces, "*" for
+// all of them, or matches one of the method patterns, e.g. "admin_addPeer" or
+// "debug_trace*".
+func allowsMethod(namespaces, methods []string, method string) bool {
+	namespace := strings.SplitN(method, serviceMethodSeparator, 2)[0]
+	for _, allowed := range namespaces {
+		if allowed == "*" || allowed == namespace {
+			return true
+		}
+	}
+	for _, pattern := range methods {
+		if ok, _ := path.Match(pattern, method); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// jwtAccess is what a client authenticated with a token may call: the methods
+// allowed by the secret which signed the token, narrowed by its claims.
+type jwtAccess struct {
+	secret atomic.Pointer[JWTSecret]
+	claims *JWTClaims
+}
+
+func newJWTAccess(secret *JWTSecret, claims *JWTClaims) *jwtAccess {
+	access := &jwtAccess{claims: claims}
+	access.secret.Store(secret)
+	return access
+}
+
+// allows returns whether the client may call the method.
+func (a *jwtAccess) allows(method string) bool {
+	return a.secret.Load().allows(method) && a.claims.allows(method)
+}
+
+// SignJWT creates an HS256 token with the given claims.
+func SignJWT(secret []byte, claims JWTClaims) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	unsigned := jwtHeaderEncoded + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(jwtSignature(secret, unsigned)), nil
+}
+
+// verifyJWT checks the token was signed with one of the secrets and is
+// currently valid, and returns its claims and the secret which signed it.
+func verifyJWT(token string, secrets []*JWTSecret, now time.Time) (*JWTClaims, *JWTSecret, error) {
+	claims, secret, err := parseJWT(token, secrets)
+	if err != nil {
+		return nil, nil, err
+	}
+	if skew := now.Sub(time.Unix(claims.IssuedAt, 0)); skew > jwtMaxClockSkew || skew < -jwtMaxClockSkew {
+		return nil, nil, errTokenSkewed
+	}
+	if claims.expired(now) {
+		return nil, nil, errTokenExpired
+	}
+	return claims, secret, nil
+}
+
+// parseJWT checks the token was signed with one of the secrets and returns its
+// claims and the secret which signed it, without checking the times.
+func parseJWT(token string, secrets []*JWTSecret) (*JWTClaims, *JWTSecret, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, nil, errInvalidToken
+	}
+	header, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, nil, errInvalidToken
+	}
+	var alg struct {
+		Alg string `json:"alg"`
+	}
+	if err := json.Unmarshal(header, &alg); err != nil || alg.Alg != "HS256" {
+		return nil, nil, errInvalidToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, nil, errInvalidToken
+	}
+	unsigned := parts[0] + "." + parts[1]
+	var signer *JWTSecret
+	for _, secret := range secrets {
+		if hmac.Equal(signature, jwtSignature(secret.Key, unsigned)) {
+			signer = secret
+			break
+		}
+	}
+	if signer == nil {
+		return nil, nil, errInvalidToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, nil, errInvalidToken
+	}
+	claims := new(JWTClaims)
+	if err := json.Unmarshal(payload, claims); err != nil {
+		return nil, nil, errInvalidToken
+	}
+	return claims, signer, nil
+}
+
+func jwtSignature(secret []byte, unsigned string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(unsigned))
+	return mac.Sum(nil)
+}
+
+// JWTSecretFile holds the secrets of the tokens accepted by an authenticated
+// endpoint, read from a file with a hex encoded 32 byte secret per line. The
+// secret may be followed by the namespaces and the method patterns its holders
+// may call, e.g. "0x... eth net admin_nodeInfo debug_trace*". The file is
+// reloaded when it changes, all of its secrets are accepted so they can be
+// rotated without a restart: add the new secret, move the clients to it, then
+// remove the old one.
+type JWTSecretFile struct {
+	path string
+
+	lock    sync.Mutex
+	secrets []*JWTSecret
+	modTime time.Time
+	checked time.Time
+}
+
+// NewJWTSecretFile loads the secrets from the file.
+func NewJWTSecretFile(path string) (*JWTSecretFile, error) {
+	f := &JWTSecretFile{path: path}
+	if err := f.reload(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Secrets returns the current secrets. A file that became invalid is logged
+// and ignored until it is fixed.
+func (f *JWTSecretFile) Secrets() []*JWTSecret {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if time.Since(f.checked) >= jwtReloadInterval {
+		if err := f.reload(); err != nil {
+			log.Warn("Failed to reload JWT secrets", "path", f.path, "err", err)
+		}
+	}
+	return f.secrets
+}
+
+// reload reads the file again if it was modified since it was last read.
+func (f *JWTSecretFile) reload() error {
+	f.checked = time.Now()
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return err
+	}
+	if info.ModTime().Equal(f.modTime) && f.secrets != nil {
+		return nil
+	}
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return err
+	}
+	secrets, err := ParseJWTSecrets(data)
+	if err != nil {
+		return err
+	}
+	if f.secrets != nil {
+		log.Info("Reloaded JWT secrets", "path", f.path, "count", len(secrets))
+	}
+	f.secrets, f.modTime = secrets, info.ModTime()
+	return nil
+}
+
+// ParseJWTSecrets parses the secrets of a secret file, one per line: the hex
+// encoded secret followed by the namespaces and method patterns it may call,
+// separated by spaces. Method patterns are told from namespaces by their
+// separator, e.g. "admin_nodeInfo". Empty lines and lines starting with '#' are
+// skipped.
+func ParseJWTSecrets(data []byte) ([]*JWTSecret, error) {
+	var secrets []*JWTSecret
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		key, err := hex.DecodeString(strings.TrimPrefix(fields[0], "0x"))
+		if err != nil || len(key) != jwtSecretLength {
+			return nil, fmt.Errorf("line %d: invalid secret, want %d hex encoded bytes", line, jwtSecretLength)
+		}
+		secret := &JWTSecret{Key: key}
+		for _, allowed := range fields[1:] {
+			if !strings.Contains(allowed, serviceMethodSeparator) {
+				secret.Namespaces = append(secret.Namespaces, allowed)
+				continue
+			}
+			if _, err := path.Match(allowed, ""); err != nil {
+				return nil, fmt.Errorf("line %d: invalid method pattern %q", line, allowed)
+			}
+			secret.Methods = append(secret.Methods, allowed)
+		}
+		secrets = append(secrets, secret)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(secrets) == 0 {
+		return nil, errNoJWTSecret
+	}
+	return secrets, nil
+}
+
+// jwtAccessKey is the context key of the access of an authenticated request.
+type jwtAccessKey struct{}
+
+// jwtSession is the token a websocket connection was authenticated with. The
+// connection outlives the handshake, so the token is checked again until the
+// connection is closed.
+type jwtSession struct {
+	token   string
+	access  *jwtAccess
+	secrets *JWTSecretFile
+}
+
+// watch closes the connection once the token expires or its secret is rotated
+// out of the secret file. Changes to the permissions of the secret apply to the
+// open connection.
+func (s *jwtSession) watch(codec ServerCodec) {
+	ticker := time.NewTicker(jwtReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-codec.closed():
+			return
+		case now := <-ticker.C:
+			var (
+				secret *JWTSecret
+				err    = errTokenExpired
+			)
+			if !s.access.claims.expired(now) {
+				_, secret, err = parseJWT(s.token, s.secrets.Secrets())
+			}
+			if err == nil {
+				s.access.secret.Store(secret)
+			} else {
+				log.Debug("Closing authenticated websocket", "remote", codec.remoteAddr(), "err", err)
+				codec.close()
+				return
+			}
+		}
+	}
+}
+
+// AuthHandler returns a handler serving JSON-RPC over HTTP and websocket to the
+// clients presenting a bearer token signed with one of the secrets. Each request
+// is restricted to the namespaces and methods of the secret, narrowed by the
+// claims of the token, and websocket connections are closed once their token is
+// no longer valid.
+func (s *Server) AuthHandler(secrets *JWTSecretFile, allowedOrigins []string) http.Handler {
+	upgrader := newWebsocketUpgrader(allowedOrigins)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		access, err := authenticate(r, secrets.Secrets())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		if isWebsocket(r) {
+			s.serveWebsocket(upgrader, w, r, &jwtSession{token: bearerToken(r), access: access, secrets: secrets})
+			return
+		}
+		s.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), jwtAccessKey{}, access)))
+	})
+}
+
+// NewAuthHTTPServer creates a new authenticated HTTP and websocket RPC server
+// around an API provider.
+func NewAuthHTTPServer(vhosts, origins []string, secrets *JWTSecretFile, srv *Server, writeTimeout time.Duration) *http.Server {
+	handler := newVHostHandler(vhosts, srv.AuthHandler(secrets, origins))
+	return &http.Server{
+		Handler:      handler,
+		ReadTimeout:  5 * time.Second,
+		WriteTimeout: writeTimeout + time.Second,
+		IdleTimeout:  120 * time.Second,
+	}
+}
+
+// authenticate verifies the bearer token of the request and returns what the
+// client may call.
+func authenticate(r *http.Request, secrets []*JWTSecret) (*jwtAccess, error) {
+	token := bearerToken(r)
+	if token == "" {
+		return nil, errMissingToken
+	}
+	claims, secret, err := verifyJWT(token, secrets, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return newJWTAccess(secret, claims), nil
+}
+
+// bearerToken returns the bearer token of the request, empty if there is none.
+func bearerToken(r *http.Request) string {
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "Bearer ") {
+		return ""
+	}
+	return strings.TrimPrefix(auth, "Bearer ")
+}
+
+// isWebsocket returns whether the request is a websocket upgrade.
+func isWebsocket(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get("Upgrade"), "websocket") &&
+		strings.Contains(strings.ToLower(r.Header.Get("Connection")), "upgrade")
+}
+
+// HTTPAuth adds the credentials of a client to the headers of its requests.
+type HTTPAuth func(header http.Header) error
+
+// NewJWTAuth creates an HTTPAuth signing a fresh token for each request. The
+// token narrows the permissions of the secret down to the given namespaces and
+// methods, if any.
+func NewJWTAuth(secret []byte, namespaces, methods []string) HTTPAuth {
+	return func(header http.Header) error {
+		token, err := SignJWT(secret, JWTClaims{
+			IssuedAt:   time.Now().Unix(),
+			Namespaces: namespaces,
+			Methods:    methods,
+		})
+		if err != nil {
+			return err
+		}
+		header.Set("Authorization", "Bearer "+token)
+		return nil
+	}
+}
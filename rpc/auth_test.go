@@ -0,0 +1,321 @@
+// Copyright (c) 2018 XDPoSChain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+var (
+	testJWTSecret       = bytes.Repeat([]byte{0x01}, 32)
+	otherJWTSecret      = bytes.Repeat([]byte{0x02}, 32)
+	restrictedJWTSecret = bytes.Repeat([]byte{0x03}, 32)
+)
+
+func TestVerifyJWT(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	sign := func(secret []byte, claims JWTClaims) string {
+		token, err := SignJWT(secret, claims)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	valid := sign(testJWTSecret, JWTClaims{IssuedAt: now.Unix(), Namespaces: []string{"admin"}})
+	signer := &JWTSecret{Key: testJWTSecret}
+	claims, secret, err := verifyJWT(valid, []*JWTSecret{{Key: otherJWTSecret}, signer}, now)
+	if err != nil {
+		t.Fatalf("valid token rejected: %v", err)
+	}
+	if len(claims.Namespaces) != 1 || claims.Namespaces[0] != "admin" {
+		t.Errorf("claims mismatch: %+v", claims)
+	}
+	if secret != signer {
+		t.Errorf("secret mismatch: have %x, want %x", secret.Key, signer.Key)
+	}
+	// An unsigned token with the same claims must be rejected
+	parts := strings.Split(valid, ".")
+	unsigned := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","typ":"JWT"}`)) + "." + parts[1] + "."
+
+	tests := []struct {
+		name  string
+		token string
+		want  error
+	}{
+		{"wrong secret", valid, errInvalidToken},
+		{"unsigned", unsigned, errInvalidToken},
+		{"malformed", "abc", errInvalidToken},
+		{"old", sign(otherJWTSecret, JWTClaims{IssuedAt: now.Unix() - 61}), errTokenSkewed},
+		{"future", sign(otherJWTSecret, JWTClaims{IssuedAt: now.Unix() + 61}), errTokenSkewed},
+		{"expired", sign(otherJWTSecret, JWTClaims{IssuedAt: now.Unix(), ExpiresAt: now.Unix()}), errTokenExpired},
+	}
+	for _, tt := range tests {
+		if _, _, err := verifyJWT(tt.token, []*JWTSecret{{Key: otherJWTSecret}}, now); err != tt.want {
+			t.Errorf("%s: error mismatch: have %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestJWTClaimsAllows(t *testing.T) {
+	claims := &JWTClaims{Namespaces: []string{"eth"}, Methods: []string{"admin_addPeer", "debug_trace*"}}
+	for method, want := range map[string]bool{
+		"eth_blockNumber":          true,
+		"eth_subscribe":            true,
+		"admin_addPeer":            true,
+		"admin_removePeer":         false,
+		"debug_traceTransaction":   true,
+		"debug_setHead":            false,
+		"personal_unlockAccount":   false,
+		"ethx_blockNumber":         false,
+		"admin_addPeerAndTrusted":  false,
+		"debug_traceBlockByNumber": true,
+	} {
+		if have := claims.allows(method); have != want {
+			t.Errorf("%s: allowed %v, want %v", method, have, want)
+		}
+	}
+	if !(&JWTClaims{Namespaces: []string{"*"}}).allows("personal_unlockAccount") {
+		t.Error("wildcard namespace doesn't allow all methods")
+	}
+	if !(&JWTClaims{}).allows("personal_unlockAccount") {
+		t.Error("claims without permissions narrow the secret")
+	}
+}
+
+// Tests that a token can only narrow the permissions of its secret.
+func TestJWTAccessAllows(t *testing.T) {
+	secrets, err := ParseJWTSecrets([]byte(hex.EncodeToString(restrictedJWTSecret) + " eth admin_nodeInfo debug_trace*\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := secrets[0]
+	if len(secret.Namespaces) != 1 || len(secret.Methods) != 2 {
+		t.Fatalf("permissions mismatch: %+v", secret)
+	}
+	for _, tt := range []struct {
+		claims JWTClaims
+		method string
+		want   bool
+	}{
+		{JWTClaims{}, "eth_blockNumber", true},
+		{JWTClaims{}, "admin_nodeInfo", true},
+		{JWTClaims{}, "admin_addPeer", false},
+		{JWTClaims{Namespaces: []string{"*"}}, "admin_addPeer", false},
+		{JWTClaims{Namespaces: []string{"*"}}, "debug_traceTransaction", true},
+		{JWTClaims{Methods: []string{"*"}}, "personal_unlockAccount", false},
+		{JWTClaims{Namespaces: []string{"debug"}}, "eth_blockNumber", false},
+		{JWTClaims{Namespaces: []string{"debug"}}, "debug_setHead", false},
+	} {
+		if have := newJWTAccess(secret, &tt.claims).allows(tt.method); have != tt.want {
+			t.Errorf("%+v %s: allowed %v, want %v", tt.claims, tt.method, have, tt.want)
+		}
+	}
+	if _, err := ParseJWTSecrets([]byte(hex.EncodeToString(restrictedJWTSecret) + " debug_[trace")); err == nil {
+		t.Error("invalid method pattern accepted")
+	}
+}
+
+func TestJWTSecretFileReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jwtsecret")
+	if err := os.WriteFile(path, []byte(hex.EncodeToString(testJWTSecret)+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	file, err := NewJWTSecretFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secrets := file.Secrets(); len(secrets) != 1 || !bytes.Equal(secrets[0].Key, testJWTSecret) {
+		t.Fatalf("secrets mismatch: have %d", len(secrets))
+	}
+	// Rotate in a new secret, both are accepted
+	data := "# rotated\n0x" + hex.EncodeToString(otherJWTSecret) + "\n\n" + hex.EncodeToString(testJWTSecret) + "\n"
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	future := time.Now().Add(time.Minute)
+	os.Chtimes(path, future, future)
+	file.checked = time.Time{}
+	if secrets := file.Secrets(); len(secrets) != 2 || !bytes.Equal(secrets[0].Key, otherJWTSecret) {
+		t.Fatalf("secrets not reloaded: have %d", len(secrets))
+	}
+	// An invalid file keeps the previous secrets
+	if err := os.WriteFile(path, []byte("0x1234\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	future = future.Add(time.Minute)
+	os.Chtimes(path, future, future)
+	file.checked = time.Time{}
+	if secrets := file.Secrets(); len(secrets) != 2 {
+		t.Fatalf("secrets dropped for an invalid file: have %d", len(secrets))
+	}
+	if _, err := NewJWTSecretFile(path); err == nil {
+		t.Fatal("invalid secret file loaded")
+	}
+}
+
+func TestAuthHandler(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jwtsecret")
+	data := hex.EncodeToString(testJWTSecret) + "\n" + hex.EncodeToString(restrictedJWTSecret) + " test\n"
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	secrets, err := NewJWTSecretFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := newTestServer()
+	defer server.Stop()
+	ts := httptest.NewServer(server.AuthHandler(secrets, nil))
+	defer ts.Close()
+
+	checkAccess := func(name string, client *Client) {
+		t.Helper()
+		defer client.Close()
+
+		var result echoResult
+		if err := client.Call(&result, "test_echo", "x", 1, nil); err != nil {
+			t.Fatalf("%s: allowed method rejected: %v", name, err)
+		}
+		err := client.Call(&result, "nftest_echo", 1)
+		if e, ok := err.(Error); !ok || e.ErrorCode() != -32004 {
+			t.Fatalf("%s: wrong error for a method not allowed: %v", name, err)
+		}
+	}
+	auth := NewJWTAuth(testJWTSecret, []string{"test"}, nil)
+	client, err := DialHTTPWithAuth(ts.URL, auth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkAccess("http", client)
+
+	wsURL := "ws:" + strings.TrimPrefix(ts.URL, "http:")
+	client, err = DialWebsocketWithAuth(context.Background(), wsURL, "", auth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkAccess("websocket", client)
+
+	// Clients can't grant themselves more than their secret allows
+	auth = NewJWTAuth(restrictedJWTSecret, []string{"*"}, nil)
+	client, err = DialHTTPWithAuth(ts.URL, auth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkAccess("restricted http", client)
+
+	client, err = DialWebsocketWithAuth(context.Background(), wsURL, "", auth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkAccess("restricted websocket", client)
+
+	// Clients without a valid token are turned away
+	client, err = DialHTTPWithAuth(ts.URL, NewJWTAuth(otherJWTSecret, []string{"*"}, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	var result echoResult
+	if err := client.Call(&result, "test_echo", "x", 1, nil); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Fatalf("request with an invalid token not rejected: %v", err)
+	}
+	if _, err := DialWebsocket(context.Background(), wsURL, ""); err == nil {
+		t.Fatal("websocket without a token connected")
+	}
+}
+
+// Tests that authenticated websockets are only kept open while their token is
+// valid, and only accepted from the configured origins.
+func TestAuthWebsocketSession(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jwtsecret")
+	if err := os.WriteFile(path, []byte(hex.EncodeToString(testJWTSecret)), 0600); err != nil {
+		t.Fatal(err)
+	}
+	secrets, err := NewJWTSecretFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := newTestServer()
+	defer server.Stop()
+	ts := httptest.NewServer(server.AuthHandler(secrets, []string{"http://allowed.example"}))
+	defer ts.Close()
+	wsURL := "ws:" + strings.TrimPrefix(ts.URL, "http:")
+
+	dial := func(origin string, claims JWTClaims) (*websocket.Conn, error) {
+		token, err := SignJWT(testJWTSecret, claims)
+		if err != nil {
+			t.Fatal(err)
+		}
+		header := http.Header{"Authorization": {"Bearer " + token}}
+		if origin != "" {
+			header.Set("Origin", origin)
+		}
+		conn, _, err := websocket.DefaultDialer.Dial(wsURL, header)
+		return conn, err
+	}
+	// waitClosed serves a request on the connection, then waits for the server
+	// to close it.
+	waitClosed := func(name string, conn *websocket.Conn) {
+		t.Helper()
+		defer conn.Close()
+
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","id":1,"method":"test_echo","params":["x",1]}`)); err != nil {
+			t.Fatalf("%s: failed to send the request: %v", name, err)
+		}
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		if _, msg, err := conn.ReadMessage(); err != nil || !strings.Contains(string(msg), `"result"`) {
+			t.Fatalf("%s: request not served: %s, %v", name, msg, err)
+		}
+		_, _, err := conn.ReadMessage()
+		if ne, ok := err.(net.Error); ok && ne.Timeout() {
+			t.Fatalf("%s: connection not closed", name)
+		}
+	}
+
+	if _, err := dial("http://other.example", JWTClaims{IssuedAt: time.Now().Unix(), Namespaces: []string{"*"}}); err == nil {
+		t.Fatal("websocket from a forbidden origin connected")
+	}
+	conn, err := dial("http://allowed.example", JWTClaims{IssuedAt: time.Now().Unix(), ExpiresAt: time.Now().Unix() + 1, Namespaces: []string{"*"}})
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	waitClosed("expired", conn)
+
+	conn, err = dial("", JWTClaims{IssuedAt: time.Now().Unix(), Namespaces: []string{"*"}})
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	// Rotate the secret of the token out
+	if err := os.WriteFile(path, []byte(hex.EncodeToString(otherJWTSecret)), 0600); err != nil {
+		t.Fatal(err)
+	}
+	future := time.Now().Add(time.Minute)
+	os.Chtimes(path, future, future)
+	waitClosed("rotated", conn)
+}
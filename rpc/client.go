@@ -78,6 +78,8 @@ type Client struct {
 	idgen    func() ID // for subscriptions
 	isHTTP   bool
 	services *serviceRegistry
+	limiter  *clientLimiter // limits of the requests served to the peer
+	access   *jwtAccess     // methods the peer may call, nil if all
 
 	idCounter uint32
 
@@ -113,6 +115,8 @@ type clientConn struct {
 func (c *Client) newClientConn(conn ServerCodec) *clientConn {
 	ctx := context.WithValue(context.Background(), clientContextKey{}, c)
 	handler := newHandler(ctx, conn, c.idgen, c.services)
+	handler.limiter = c.limiter
+	handler.access = c.access
 	return &clientConn{conn, handler}
 }
 
@@ -198,17 +202,19 @@ func newClient(initctx context.Context, connect reconnectFunc) (*Client, error)
 	if err != nil {
 		return nil, err
 	}
-	c := initClient(conn, randomIDGenerator(), new(serviceRegistry))
+	c := initClient(conn, randomIDGenerator(), new(serviceRegistry), nil, nil)
 	c.reconnectFunc = connect
 	return c, nil
 }
 
-func initClient(conn ServerCodec, idgen func() ID, services *serviceRegistry) *Client {
+func initClient(conn ServerCodec, idgen func() ID, services *serviceRegistry, limiter *clientLimiter, access *jwtAccess) *Client {
 	_, isHTTP := conn.(*httpConn)
 	c := &Client{
 		idgen:       idgen,
 		isHTTP:      isHTTP,
 		services:    services,
+		limiter:     limiter,
+		access:      access,
 		writeConn:   conn,
 		close:       make(chan struct{}),
 		closing:     make(chan struct{}),
@@ -60,6 +60,8 @@ type handler struct {
 	conn           jsonWriter                     // where responses will be sent
 	log            log.Logger
 	allowSubscribe bool
+	limiter        *clientLimiter // limits of the served requests, nil if unlimited
+	access         *jwtAccess     // methods the peer may call, nil if all
 
 	subLock    sync.Mutex
 	serverSubs map[ID]*Subscription
@@ -68,6 +70,9 @@ type handler struct {
 type callProc struct {
 	ctx       context.Context
 	notifiers []*Notifier
+
+	responseSize    int   // size of the results encoded so far
+	responseLimited error // set once the results exceeded the size limit
 }
 
 func newHandler(connCtx context.Context, conn jsonWriter, idgen func() ID, reg *serviceRegistry) *handler {
@@ -100,6 +105,23 @@ func (h *handler) handleBatch(msgs []*jsonrpcMessage) {
 		})
 		return
 	}
+	// Reject all the calls of batches that are too large:
+	if err := h.limiter.checkBatch(len(msgs)); err != nil {
+		h.startCallProc(func(cp *callProc) {
+			answers := make([]*jsonrpcMessage, 0, len(msgs))
+			for _, msg := range msgs {
+				if msg.isCall() {
+					answers = append(answers, msg.errorResponse(err))
+				}
+			}
+			if len(answers) == 0 {
+				h.conn.writeJSON(cp.ctx, errorMessage(err))
+				return
+			}
+			h.conn.writeJSON(cp.ctx, answers)
+		})
+		return
+	}
 
 	// Handle non-call messages first:
 	calls := make([]*jsonrpcMessage, 0, len(msgs))
@@ -115,6 +137,13 @@ func (h *handler) handleBatch(msgs []*jsonrpcMessage) {
 	h.startCallProc(func(cp *callProc) {
 		answers := make([]*jsonrpcMessage, 0, len(msgs))
 		for _, msg := range calls {
+			// Reject the remaining calls once the responses are too large.
+			if cp.responseLimited != nil {
+				if msg.isCall() {
+					answers = append(answers, msg.errorResponse(cp.responseLimited))
+				}
+				continue
+			}
 			if answer := h.handleCallMsg(cp, msg); answer != nil {
 				answers = append(answers, answer)
 			}
@@ -316,6 +345,18 @@ func (h *handler) handleCallMsg(ctx *callProc, msg *jsonrpcMessage) *jsonrpcMess
 
 // handleCall processes method calls.
 func (h *handler) handleCall(cp *callProc, msg *jsonrpcMessage) *jsonrpcMessage {
+	if h.access != nil && !h.access.allows(msg.Method) {
+		return msg.errorResponse(&methodNotAllowedError{method: msg.Method})
+	}
+	if err := h.limiter.allow(msg.Method); err != nil {
+		return msg.errorResponse(err)
+	}
+	release, err := h.limiter.acquire(msg.namespace())
+	if err != nil {
+		return msg.errorResponse(err)
+	}
+	defer release()
+
 	if msg.isSubscribe() {
 		return h.handleSubscribe(cp, msg)
 	}
@@ -333,7 +374,7 @@ func (h *handler) handleCall(cp *callProc, msg *jsonrpcMessage) *jsonrpcMessage
 		return msg.errorResponse(&invalidParamsError{err.Error()})
 	}
 	start := time.Now()
-	answer := h.runMethod(cp.ctx, msg, callb, args)
+	answer := h.runMethod(cp.ctx, cp, msg, callb, args)
 
 	// Collect the statistics for RPC calls if metrics is enabled.
 	// We only care about pure rpc call. Filter out subscription.
@@ -380,16 +421,28 @@ func (h *handler) handleSubscribe(cp *callProc, msg *jsonrpcMessage) *jsonrpcMes
 	cp.notifiers = append(cp.notifiers, n)
 	ctx := context.WithValue(cp.ctx, notifierKey{}, n)
 
-	return h.runMethod(ctx, msg, callb, args)
+	return h.runMethod(ctx, cp, msg, callb, args)
 }
 
-// runMethod runs the Go callback for an RPC method.
-func (h *handler) runMethod(ctx context.Context, msg *jsonrpcMessage, callb *callback, args []reflect.Value) *jsonrpcMessage {
+// runMethod runs the Go callback for an RPC method. The result of a call is
+// encoded within the response size limit left for the call proc.
+func (h *handler) runMethod(ctx context.Context, cp *callProc, msg *jsonrpcMessage, callb *callback, args []reflect.Value) *jsonrpcMessage {
 	result, err := callb.call(ctx, msg.Method, args)
 	if err != nil {
 		return msg.errorResponse(err)
 	}
-	return msg.response(result)
+	if !msg.isCall() {
+		return msg.response(result)
+	}
+	enc, err := h.limiter.encodeResult(result, cp.responseSize)
+	if err != nil {
+		if _, ok := err.(*limitExceededError); ok {
+			cp.responseLimited = err
+		}
+		return msg.errorResponse(err)
+	}
+	cp.responseSize += len(enc)
+	return &jsonrpcMessage{Version: vsn, ID: msg.ID, Result: enc}
 }
 
 // unsubscribe is the callback function for all *_unsubscribe calls.
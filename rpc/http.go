@@ -50,6 +50,7 @@ type httpConn struct {
 	closeCh   chan interface{}
 	mu        sync.Mutex // protects headers
 	headers   http.Header
+	auth      HTTPAuth // adds the credentials to each request, if set
 }
 
 // httpConn is treated specially by Client.
@@ -109,6 +110,16 @@ var DefaultHTTPTimeouts = HTTPTimeouts{
 // DialHTTPWithClient creates a new RPC client that connects to an RPC server over HTTP
 // using the provided HTTP Client.
 func DialHTTPWithClient(endpoint string, client *http.Client) (*Client, error) {
+	return dialHTTP(endpoint, client, nil)
+}
+
+// DialHTTPWithAuth creates a new RPC client that connects to an authenticated RPC
+// server over HTTP. The credentials are added to each request.
+func DialHTTPWithAuth(endpoint string, auth HTTPAuth) (*Client, error) {
+	return dialHTTP(endpoint, new(http.Client), auth)
+}
+
+func dialHTTP(endpoint string, client *http.Client, auth HTTPAuth) (*Client, error) {
 	// Sanity check URL so we don't end up with a client that will fail every request.
 	_, err := url.Parse(endpoint)
 	if err != nil {
@@ -123,6 +134,7 @@ func DialHTTPWithClient(endpoint string, client *http.Client) (*Client, error) {
 		hc := &httpConn{
 			client:  client,
 			headers: headers,
+			auth:    auth,
 			url:     endpoint,
 			closeCh: make(chan interface{}),
 		}
@@ -194,6 +206,11 @@ func (hc *httpConn) doRequest(ctx context.Context, msg interface{}) (io.ReadClos
 	hc.mu.Lock()
 	req.Header = hc.headers.Clone()
 	hc.mu.Unlock()
+	if hc.auth != nil {
+		if err := hc.auth(req.Header); err != nil {
+			return nil, err
+		}
+	}
 
 	// do request
 	resp, err := hc.client.Do(req)
@@ -233,7 +250,7 @@ func (t *httpServerConn) SetWriteDeadline(time.Time) error { return nil }
 // NewHTTPServer creates a new HTTP RPC server around an API provider.
 //
 // Deprecated: Server implements http.Handler
-func NewHTTPServer(cors []string, vhosts []string, srv *Server, writeTimeout time.Duration) *http.Server {
+func NewHTTPServer(cors []string, vhosts []string, srv http.Handler, writeTimeout time.Duration) *http.Server {
 	// Wrap the CORS-handler within a host-handler
 	handler := newCorsHandler(srv, cors)
 	handler = newVHostHandler(vhosts, handler)
@@ -275,7 +292,29 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("content-type", contentType)
 	codec := newHTTPServerConn(r, w)
 	defer codec.close()
-	s.serveSingleRequest(ctx, codec)
+	s.serveSingleRequest(ctx, codec, s.limits.client(r.RemoteAddr, r.Header))
+}
+
+// LimitedHandler applies the limits of the clients of the server to the requests
+// of another HTTP handler served next to it, e.g. GraphQL. Each request counts as
+// a call of the method for the rate limits and for the concurrency limit of its
+// namespace.
+func (s *Server) LimitedHandler(method string, h http.Handler) http.Handler {
+	namespace := strings.SplitN(method, serviceMethodSeparator, 2)[0]
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limiter := s.limits.client(r.RemoteAddr, r.Header)
+		if err := limiter.allow(method); err != nil {
+			http.Error(w, err.Error(), http.StatusTooManyRequests)
+			return
+		}
+		release, err := limiter.acquire(namespace)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusTooManyRequests)
+			return
+		}
+		defer release()
+		h.ServeHTTP(w, r)
+	})
 }
 
 // validateRequest returns a non-zero response code and error message if the
@@ -305,7 +344,7 @@ func validateRequest(r *http.Request) (int, error) {
 	return http.StatusUnsupportedMediaType, err
 }
 
-func newCorsHandler(srv *Server, allowedOrigins []string) http.Handler {
+func newCorsHandler(srv http.Handler, allowedOrigins []string) http.Handler {
 	// disable CORS support if user has not specified a custom CORS configuration
 	if len(allowedOrigins) == 0 {
 		return srv
@@ -27,8 +27,20 @@ var (
 	successfulRequestGauge = metrics.NewRegisteredGauge("rpc/success", nil)
 	failedReqeustGauge     = metrics.NewRegisteredGauge("rpc/failure", nil)
 	rpcServingTimer        = metrics.NewRegisteredTimer("rpc/duration/all", nil)
+
+	limitedRequestMeter     = metrics.NewRegisteredMeter("rpc/limited", nil)
+	rateLimitedMeter        = metrics.NewRegisteredMeter("rpc/limited/rate", nil)
+	batchLimitedMeter       = metrics.NewRegisteredMeter("rpc/limited/batch", nil)
+	responseLimitedMeter    = metrics.NewRegisteredMeter("rpc/limited/response", nil)
+	concurrencyLimitedMeter = metrics.NewRegisteredMeter("rpc/limited/concurrency", nil)
 )
 
+// markLimited counts a request rejected by the limits of the server.
+func markLimited(reason metrics.Meter) {
+	limitedRequestMeter.Mark(1)
+	reason.Mark(1)
+}
+
 func newRPCServingTimer(method string, valid bool) metrics.Timer {
 	flag := "success"
 	if !valid {
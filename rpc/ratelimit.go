@@ -0,0 +1,410 @@
+// Copyright (c) 2018 XDPoSChain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/XinFinOrg/XDPoSChain/common/mclock"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+// limitedClients is the number of clients whose token buckets are tracked, the
+// least recently seen clients start over with full buckets.
+const limitedClients = 16384
+
+// RateLimitConfig are the limits a server applies to the requests of its
+// clients. The zero value doesn't limit anything.
+type RateLimitConfig struct {
+	// Rules are the request rates allowed per client. A request must satisfy all
+	// the rules matching its method.
+	Rules []RateLimitRule `toml:",omitempty"`
+
+	// APIKeyHeader is the HTTP header carrying the API key of a client. The
+	// requests with one of the APIKeys are limited per key, the others per
+	// remote IP.
+	APIKeyHeader string   `toml:",omitempty"`
+	APIKeys      []string `toml:",omitempty"`
+
+	// TrustedProxies are the IPs and CIDR ranges of the reverse proxies in front
+	// of the server. The requests they forward are limited per the client IP
+	// they add to the X-Forwarded-For header.
+	TrustedProxies []string `toml:",omitempty"`
+
+	// MaxBatchSize is the maximum number of requests in a batch.
+	MaxBatchSize int `toml:",omitempty"`
+
+	// MaxResponseSize is the maximum size in bytes of the results of a request,
+	// or of all the requests of a batch.
+	MaxResponseSize int `toml:",omitempty"`
+
+	// Concurrency is the maximum number of requests served at once per
+	// namespace, across all the clients.
+	Concurrency map[string]int `toml:",omitempty"`
+}
+
+// RateLimitRule is a token bucket limiting the requests of a client to the
+// methods matching a pattern, e.g. "eth_getLogs" or "debug_trace*".
+type RateLimitRule struct {
+	Method string  // method pattern, in the syntax of path.Match
+	Rate   float64 // requests per second
+	Burst  int     // requests allowed at once, at least 1
+}
+
+// rateLimiter enforces a RateLimitConfig.
+type rateLimiter struct {
+	config  RateLimitConfig
+	keys    map[string]bool
+	proxies []*net.IPNet
+	clock   mclock.Clock
+	lock    sync.Mutex               // protects the creation of buckets
+	buckets *lru.Cache               // bucketKey -> *tokenBucket
+	running map[string]chan struct{} // namespace -> concurrency semaphore
+}
+
+type bucketKey struct {
+	rule   int
+	client string
+}
+
+// tokenBucket holds the requests a client may still send for a rule.
+type tokenBucket struct {
+	mu     sync.Mutex
+	tokens float64
+	last   mclock.AbsTime
+}
+
+func newRateLimiter(config RateLimitConfig, clock mclock.Clock) (*rateLimiter, error) {
+	for _, rule := range config.Rules {
+		if _, err := path.Match(rule.Method, ""); err != nil {
+			return nil, fmt.Errorf("invalid method pattern %q: %v", rule.Method, err)
+		}
+		if rule.Rate <= 0 || rule.Burst < 1 {
+			return nil, fmt.Errorf("invalid rate limit for %q: rate %v, burst %d", rule.Method, rule.Rate, rule.Burst)
+		}
+	}
+	buckets, _ := lru.New(limitedClients)
+	limiter := &rateLimiter{
+		config:  config,
+		keys:    make(map[string]bool),
+		clock:   clock,
+		buckets: buckets,
+		running: make(map[string]chan struct{}),
+	}
+	for _, key := range config.APIKeys {
+		limiter.keys[key] = true
+	}
+	for _, proxy := range config.TrustedProxies {
+		cidr := proxy
+		if !strings.Contains(cidr, "/") {
+			if ip := net.ParseIP(cidr); ip != nil && ip.To4() != nil {
+				cidr += "/32"
+			} else {
+				cidr += "/128"
+			}
+		}
+		_, ipnet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %v", proxy, err)
+		}
+		limiter.proxies = append(limiter.proxies, ipnet)
+	}
+	for namespace, limit := range config.Concurrency {
+		if limit > 0 {
+			limiter.running[namespace] = make(chan struct{}, limit)
+		}
+	}
+	return limiter, nil
+}
+
+// client returns the limits of the client with the given remote address and
+// request headers.
+func (l *rateLimiter) client(remote string, header http.Header) *clientLimiter {
+	if l == nil {
+		return nil
+	}
+	if l.config.APIKeyHeader != "" && header != nil {
+		if key := header.Get(l.config.APIKeyHeader); l.keys[key] {
+			return &clientLimiter{l, "key:" + key}
+		}
+	}
+	if host, _, err := net.SplitHostPort(remote); err == nil {
+		remote = host
+	}
+	if header != nil && l.trusted(remote) {
+		remote = l.forwardedFor(remote, header.Values("X-Forwarded-For"))
+	}
+	return &clientLimiter{l, "ip:" + remote}
+}
+
+// trusted returns whether the IP is one of the trusted proxies.
+func (l *rateLimiter) trusted(ip string) bool {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false
+	}
+	for _, proxy := range l.proxies {
+		if proxy.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// forwardedFor returns the IP of the client a trusted proxy forwarded the
+// request for. Each proxy appends the address it received the request from,
+// so the client is the last address which isn't a trusted proxy. The entries
+// before it are set by the client and can't be trusted.
+func (l *rateLimiter) forwardedFor(proxy string, forwarded []string) string {
+	var addrs []string
+	for _, value := range forwarded {
+		for _, addr := range strings.Split(value, ",") {
+			addrs = append(addrs, strings.TrimSpace(addr))
+		}
+	}
+	for i := len(addrs) - 1; i >= 0; i-- {
+		if net.ParseIP(addrs[i]) == nil {
+			break
+		}
+		proxy = addrs[i]
+		if !l.trusted(proxy) {
+			break
+		}
+	}
+	return proxy
+}
+
+// clientLimiter applies the limits of a server to the requests of one client.
+// A nil clientLimiter doesn't limit anything.
+type clientLimiter struct {
+	*rateLimiter
+	id string
+}
+
+// allow takes a token from all the buckets of the client matching method.
+func (c *clientLimiter) allow(method string) error {
+	if c == nil {
+		return nil
+	}
+	now := c.clock.Now()
+	for i, rule := range c.config.Rules {
+		if ok, _ := path.Match(rule.Method, method); !ok {
+			continue
+		}
+		if !c.bucket(i, now).take(rule, now) {
+			markLimited(rateLimitedMeter)
+			return &limitExceededError{fmt.Sprintf("rate limit of %s exceeded", rule.Method)}
+		}
+	}
+	return nil
+}
+
+// bucket returns the token bucket of the client for a rule, creating a full
+// one for new clients.
+func (c *clientLimiter) bucket(rule int, now mclock.AbsTime) *tokenBucket {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	key := bucketKey{rule, c.id}
+	if bucket, ok := c.buckets.Get(key); ok {
+		return bucket.(*tokenBucket)
+	}
+	bucket := &tokenBucket{tokens: float64(c.config.Rules[rule].Burst), last: now}
+	c.buckets.Add(key, bucket)
+	return bucket
+}
+
+// acquire reserves a request slot in the namespace. The returned function
+// releases it.
+func (c *clientLimiter) acquire(namespace string) (func(), error) {
+	if c == nil || c.running[namespace] == nil {
+		return func() {}, nil
+	}
+	sem := c.running[namespace]
+	select {
+	case sem <- struct{}{}:
+		return func() { <-sem }, nil
+	default:
+		markLimited(concurrencyLimitedMeter)
+		return nil, &limitExceededError{fmt.Sprintf("too many concurrent %s requests", namespace)}
+	}
+}
+
+// checkBatch returns an error if the batch has too many requests.
+func (c *clientLimiter) checkBatch(size int) error {
+	if c == nil || c.config.MaxBatchSize <= 0 || size <= c.config.MaxBatchSize {
+		return nil
+	}
+	markLimited(batchLimitedMeter)
+	return &limitExceededError{fmt.Sprintf("batch of %d requests exceeds the limit of %d", size, c.config.MaxBatchSize)}
+}
+
+// encodeResult encodes the result of a request, given the size of the results
+// already encoded for the same request or batch. Encoding stops with an error
+// as soon as the results exceed the response size limit.
+func (c *clientLimiter) encodeResult(result interface{}, used int) (json.RawMessage, error) {
+	if c == nil || c.config.MaxResponseSize <= 0 {
+		return json.Marshal(result)
+	}
+	enc := &limitedEncoder{limit: c.config.MaxResponseSize - used}
+	if err := enc.encode(reflect.ValueOf(result)); err != nil {
+		if err != errResponseTooLarge {
+			return nil, err
+		}
+		markLimited(responseLimitedMeter)
+		return nil, &limitExceededError{fmt.Sprintf("response exceeds the limit of %d bytes", c.config.MaxResponseSize)}
+	}
+	return enc.buf.Bytes(), nil
+}
+
+// take refills the bucket with the tokens earned since the last request and
+// takes one if there is any.
+func (b *tokenBucket) take(rule RateLimitRule, now mclock.AbsTime) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	elapsed := time.Duration(now - b.last).Seconds()
+	b.tokens = math.Min(float64(rule.Burst), b.tokens+elapsed*rule.Rate)
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+var (
+	errResponseTooLarge = errors.New("response too large")
+
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// limitedEncoder encodes a value as json.Marshal does, failing as soon as the
+// output exceeds the limit. Slices, arrays, maps, pointers and interfaces are
+// encoded element by element, the other values with json.Marshal, so no more
+// than the limit and one element of a large result is ever encoded.
+type limitedEncoder struct {
+	buf   bytes.Buffer
+	limit int
+}
+
+func (e *limitedEncoder) write(data []byte) error {
+	if e.buf.Len()+len(data) > e.limit {
+		return errResponseTooLarge
+	}
+	e.buf.Write(data)
+	return nil
+}
+
+// marshal encodes the value with json.Marshal, through its address if it has
+// one as json.Marshal does for the elements of a slice.
+func (e *limitedEncoder) marshal(v reflect.Value) error {
+	if v.CanAddr() {
+		v = v.Addr()
+	}
+	data, err := json.Marshal(v.Interface())
+	if err != nil {
+		return err
+	}
+	return e.write(data)
+}
+
+func (e *limitedEncoder) encode(v reflect.Value) error {
+	if !v.IsValid() {
+		return e.write([]byte("null"))
+	}
+	if t := v.Type(); t.Implements(jsonMarshalerType) || t.Implements(textMarshalerType) ||
+		reflect.PointerTo(t).Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType) {
+		return e.marshal(v)
+	}
+	switch v.Kind() {
+	case reflect.Interface, reflect.Pointer:
+		if v.IsNil() {
+			return e.write([]byte("null"))
+		}
+		return e.encode(v.Elem())
+
+	case reflect.Slice, reflect.Array:
+		// Byte slices are encoded as base64 strings
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return e.marshal(v)
+		}
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return e.write([]byte("null"))
+		}
+		if err := e.write([]byte("[")); err != nil {
+			return err
+		}
+		for i := 0; i < v.Len(); i++ {
+			if i > 0 {
+				if err := e.write([]byte(",")); err != nil {
+					return err
+				}
+			}
+			if err := e.encode(v.Index(i)); err != nil {
+				return err
+			}
+		}
+		return e.write([]byte("]"))
+
+	case reflect.Map:
+		// Only maps with plain string keys are split, json.Marshal sorts them
+		if v.Type().Key().Kind() != reflect.String {
+			return e.marshal(v)
+		}
+		if v.IsNil() {
+			return e.write([]byte("null"))
+		}
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		if err := e.write([]byte("{")); err != nil {
+			return err
+		}
+		for i, key := range keys {
+			name, err := json.Marshal(key.String())
+			if err != nil {
+				return err
+			}
+			if i > 0 {
+				name = append([]byte(","), name...)
+			}
+			if err := e.write(append(name, ':')); err != nil {
+				return err
+			}
+			if err := e.encode(v.MapIndex(key)); err != nil {
+				return err
+			}
+		}
+		return e.write([]byte("}"))
+
+	default:
+		return e.marshal(v)
+	}
+}
@@ -0,0 +1,280 @@
+// Copyright (c) 2018 XDPoSChain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/XinFinOrg/XDPoSChain/common/mclock"
+)
+
+func TestRateLimitRules(t *testing.T) {
+	clock := new(mclock.Simulated)
+	limiter, err := newRateLimiter(RateLimitConfig{
+		Rules: []RateLimitRule{
+			{Method: "*", Rate: 10, Burst: 10},
+			{Method: "debug_trace*", Rate: 1, Burst: 2},
+		},
+		APIKeyHeader: "X-API-Key",
+		APIKeys:      []string{"secret"},
+	}, clock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := limiter.client("10.0.0.1:30303", nil)
+
+	// The burst of the tracing rule is spent, the other methods aren't limited
+	for i := 0; i < 2; i++ {
+		if err := client.allow("debug_traceBlockByNumber"); err != nil {
+			t.Fatalf("request %d rejected: %v", i, err)
+		}
+	}
+	if err := client.allow("debug_traceTransaction"); err == nil {
+		t.Fatal("request above the burst allowed")
+	}
+	if err := client.allow("eth_blockNumber"); err != nil {
+		t.Fatalf("unrelated method rejected: %v", err)
+	}
+	// Other ports of the same IP share the buckets, other clients don't
+	if err := limiter.client("10.0.0.1:30304", nil).allow("debug_traceTransaction"); err == nil {
+		t.Fatal("request from the same IP allowed")
+	}
+	if err := limiter.client("10.0.0.2:30303", nil).allow("debug_traceTransaction"); err != nil {
+		t.Fatalf("request from another IP rejected: %v", err)
+	}
+	header := make(http.Header)
+	header.Set("X-API-Key", "secret")
+	if err := limiter.client("10.0.0.1:30303", header).allow("debug_traceTransaction"); err != nil {
+		t.Fatalf("request with an API key rejected: %v", err)
+	}
+	header.Set("X-API-Key", "unknown")
+	if err := limiter.client("10.0.0.1:30303", header).allow("debug_traceTransaction"); err == nil {
+		t.Fatal("request with an unknown API key not limited by IP")
+	}
+	// The bucket refills over time
+	clock.Run(time.Second)
+	if err := client.allow("debug_traceTransaction"); err != nil {
+		t.Fatalf("request rejected after refill: %v", err)
+	}
+	if err := client.allow("debug_traceTransaction"); err == nil {
+		t.Fatal("request above the refill allowed")
+	}
+}
+
+func TestRateLimitTrustedProxies(t *testing.T) {
+	limiter, err := newRateLimiter(RateLimitConfig{
+		Rules:          []RateLimitRule{{Method: "*", Rate: 1, Burst: 1}},
+		TrustedProxies: []string{"10.0.0.1", "192.168.0.0/16"},
+	}, new(mclock.Simulated))
+	if err != nil {
+		t.Fatal(err)
+	}
+	forwarded := func(values ...string) http.Header {
+		header := make(http.Header)
+		for _, value := range values {
+			header.Add("X-Forwarded-For", value)
+		}
+		return header
+	}
+	tests := []struct {
+		remote string
+		header http.Header
+		want   string
+	}{
+		{"10.0.0.1:443", forwarded("1.2.3.4"), "ip:1.2.3.4"},
+		{"10.0.0.1:443", forwarded("1.2.3.4, 192.168.1.1"), "ip:1.2.3.4"},
+		{"10.0.0.1:443", forwarded("1.2.3.4", "192.168.1.1"), "ip:1.2.3.4"},
+		// The entries left of the client are set by the client itself
+		{"10.0.0.1:443", forwarded("6.6.6.6, 1.2.3.4"), "ip:1.2.3.4"},
+		{"10.0.0.1:443", forwarded("6.6.6.6, garbage, 192.168.1.1"), "ip:192.168.1.1"},
+		{"10.0.0.1:443", nil, "ip:10.0.0.1"},
+		// Untrusted peers can't pick the IP they are limited by
+		{"10.0.0.2:443", forwarded("1.2.3.4"), "ip:10.0.0.2"},
+	}
+	for _, tt := range tests {
+		if have := limiter.client(tt.remote, tt.header).id; have != tt.want {
+			t.Errorf("client %s with %v: have %s, want %s", tt.remote, tt.header, have, tt.want)
+		}
+	}
+	// The clients behind a proxy are limited separately
+	if err := limiter.client("10.0.0.1:443", forwarded("1.2.3.4")).allow("eth_call"); err != nil {
+		t.Fatalf("first client rejected: %v", err)
+	}
+	if err := limiter.client("10.0.0.1:443", forwarded("1.2.3.5")).allow("eth_call"); err != nil {
+		t.Fatalf("second client rejected: %v", err)
+	}
+	if err := limiter.client("10.0.0.1:443", forwarded("1.2.3.4")).allow("eth_call"); err == nil {
+		t.Fatal("first client not limited")
+	}
+}
+
+// countingMarshaler counts the elements of a result that were encoded.
+type countingMarshaler struct{ count *int }
+
+func (m countingMarshaler) MarshalJSON() ([]byte, error) {
+	*m.count++
+	return []byte(`"0123456789"`), nil
+}
+
+type stringKey string
+
+func TestLimitedEncoder(t *testing.T) {
+	var (
+		nilPointer *big.Int
+		values     = []interface{}{
+			nil,
+			nilPointer,
+			[]int(nil),
+			map[string]int(nil),
+			[]byte{1, 2, 3},
+			[2]byte{1, 2},
+			[]big.Int{*big.NewInt(5)},
+			&[]*big.Int{big.NewInt(6), nil},
+			map[stringKey]interface{}{"b": 1, "a": []string{"<x>"}, "c": nil, "\u2028": true},
+			map[int]string{2: "a", 1: "b"},
+			[]interface{}{json.RawMessage(`{"raw":1}`), time.Unix(0, 0).UTC(), struct{ A, b int }{1, 2}},
+			map[string][]map[string]uint64{"blocks": {{"number": 1}, {"number": 2}}},
+		}
+	)
+	for i, value := range values {
+		want, err := json.Marshal(value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		enc := &limitedEncoder{limit: 1 << 20}
+		if err := enc.encode(reflect.ValueOf(value)); err != nil {
+			t.Fatalf("value %d: encoding failed: %v", i, err)
+		}
+		if !bytes.Equal(enc.buf.Bytes(), want) {
+			t.Errorf("value %d: encoding mismatch: have %s, want %s", i, enc.buf.Bytes(), want)
+		}
+	}
+	// A large result is only encoded up to the limit
+	var count int
+	result := make([]countingMarshaler, 1000)
+	for i := range result {
+		result[i] = countingMarshaler{&count}
+	}
+	enc := &limitedEncoder{limit: 100}
+	if err := enc.encode(reflect.ValueOf(result)); err != errResponseTooLarge {
+		t.Fatalf("error mismatch: have %v, want %v", err, errResponseTooLarge)
+	}
+	if count > 9 {
+		t.Errorf("encoded %d elements past the limit", count)
+	}
+}
+
+func TestRateLimitConcurrency(t *testing.T) {
+	limiter, err := newRateLimiter(RateLimitConfig{Concurrency: map[string]int{"debug": 1}}, mclock.System{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := limiter.client("10.0.0.1:30303", nil)
+
+	release, err := client.acquire("debug")
+	if err != nil {
+		t.Fatalf("first request rejected: %v", err)
+	}
+	if _, err := limiter.client("10.0.0.2:30303", nil).acquire("debug"); err == nil {
+		t.Fatal("concurrent request allowed")
+	}
+	if _, err := client.acquire("eth"); err != nil {
+		t.Fatalf("request to an unlimited namespace rejected: %v", err)
+	}
+	release()
+	if _, err := client.acquire("debug"); err != nil {
+		t.Fatalf("request rejected after release: %v", err)
+	}
+}
+
+func TestRateLimitInvalidConfig(t *testing.T) {
+	configs := []RateLimitConfig{
+		{Rules: []RateLimitRule{{Method: "[", Rate: 1, Burst: 1}}},
+		{Rules: []RateLimitRule{{Method: "*", Rate: 0, Burst: 1}}},
+		{Rules: []RateLimitRule{{Method: "*", Rate: 1, Burst: 0}}},
+		{TrustedProxies: []string{"10.0.0.0/33"}},
+		{TrustedProxies: []string{"proxy.local"}},
+	}
+	for i, config := range configs {
+		if err := NewServer().SetRateLimits(config); err == nil {
+			t.Errorf("config %d accepted", i)
+		}
+	}
+}
+
+func TestServerRateLimits(t *testing.T) {
+	server := newTestServer()
+	defer server.Stop()
+	server.RegisterName("large", largeRespService{100})
+	if err := server.SetRateLimits(RateLimitConfig{
+		Rules:           []RateLimitRule{{Method: "test_echo", Rate: 0.001, Burst: 1}},
+		MaxBatchSize:    3,
+		MaxResponseSize: 150,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	client, err := DialHTTP(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	checkLimited := func(name string, err error) {
+		t.Helper()
+		if err == nil {
+			t.Fatalf("%s: request not limited", name)
+		}
+		if e, ok := err.(Error); !ok || e.ErrorCode() != -32005 {
+			t.Fatalf("%s: wrong error %v", name, err)
+		}
+	}
+	var result echoResult
+	if err := client.Call(&result, "test_echo", "x", 1, nil); err != nil {
+		t.Fatalf("first request rejected: %v", err)
+	}
+	checkLimited("rate", client.Call(&result, "test_echo", "x", 1, nil))
+
+	// A batch above the limit is rejected as a whole
+	batch := make([]BatchElem, 4)
+	for i := range batch {
+		batch[i] = BatchElem{Method: "large_largeResp", Result: new(string)}
+	}
+	if err := client.BatchCall(batch); err != nil {
+		t.Fatal(err)
+	}
+	for i := range batch {
+		checkLimited("batch", batch[i].Error)
+	}
+	// The responses of a batch are limited in total
+	batch = batch[:2]
+	if err := client.BatchCall(batch); err != nil {
+		t.Fatal(err)
+	}
+	if batch[0].Error != nil {
+		t.Fatalf("first response rejected: %v", batch[0].Error)
+	}
+	checkLimited("response", batch[1].Error)
+}
@@ -21,6 +21,7 @@ import (
 	"io"
 	"sync/atomic"
 
+	"github.com/XinFinOrg/XDPoSChain/common/mclock"
 	"github.com/XinFinOrg/XDPoSChain/log"
 	mapset "github.com/deckarep/golang-set"
 )
@@ -46,6 +47,7 @@ type Server struct {
 	idgen    func() ID
 	run      int32
 	codecs   mapset.Set
+	limits   *rateLimiter
 }
 
 // NewServer creates a new server instance with no registered handlers.
@@ -66,12 +68,29 @@ func (s *Server) RegisterName(name string, receiver interface{}) error {
 	return s.services.registerName(name, receiver)
 }
 
+// SetRateLimits sets the limits applied to the requests of the clients. It must
+// be called before the server starts serving requests.
+func (s *Server) SetRateLimits(config RateLimitConfig) error {
+	limits, err := newRateLimiter(config, mclock.System{})
+	if err != nil {
+		return err
+	}
+	s.limits = limits
+	return nil
+}
+
 // ServeCodec reads incoming requests from codec, calls the appropriate callback and writes
 // the response back using the given codec. It will block until the codec is closed or the
 // server is stopped. In either case the codec is closed.
 //
 // Note that codec options are no longer supported.
 func (s *Server) ServeCodec(codec ServerCodec, options CodecOption) {
+	s.serveCodec(codec, s.limits.client(codec.remoteAddr(), nil), nil)
+}
+
+// serveCodec serves the requests read from codec within the limits of the client,
+// restricted to the methods allowed by access if not nil.
+func (s *Server) serveCodec(codec ServerCodec, limiter *clientLimiter, access *jwtAccess) {
 	defer codec.close()
 
 	// Don't serve if server is stopped.
@@ -83,7 +102,7 @@ func (s *Server) ServeCodec(codec ServerCodec, options CodecOption) {
 	s.codecs.Add(codec)
 	defer s.codecs.Remove(codec)
 
-	c := initClient(codec, s.idgen, &s.services)
+	c := initClient(codec, s.idgen, &s.services, limiter, access)
 	<-codec.closed()
 	c.Close()
 }
@@ -91,7 +110,7 @@ func (s *Server) ServeCodec(codec ServerCodec, options CodecOption) {
 // serveSingleRequest reads and processes a single RPC request from the given codec. This
 // is used to serve HTTP connections. Subscriptions and reverse calls are not allowed in
 // this mode.
-func (s *Server) serveSingleRequest(ctx context.Context, codec ServerCodec) {
+func (s *Server) serveSingleRequest(ctx context.Context, codec ServerCodec, limiter *clientLimiter) {
 	// Don't serve if server is stopped.
 	if atomic.LoadInt32(&s.run) == 0 {
 		return
@@ -99,6 +118,8 @@ func (s *Server) serveSingleRequest(ctx context.Context, codec ServerCodec) {
 
 	h := newHandler(ctx, codec, s.idgen, &s.services)
 	h.allowSubscribe = false
+	h.limiter = limiter
+	h.access, _ = ctx.Value(jwtAccessKey{}).(*jwtAccess)
 	defer h.close(io.EOF, nil)
 
 	reqs, batch, err := codec.readBatch()
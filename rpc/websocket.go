@@ -47,21 +47,36 @@ var wsBufferPool = new(sync.Pool)
 // allowedOrigins should be a comma-separated list of allowed origin URLs.
 // To allow connections with any origin, pass "*".
 func (s *Server) WebsocketHandler(allowedOrigins []string) http.Handler {
-	var upgrader = websocket.Upgrader{
+	upgrader := newWebsocketUpgrader(allowedOrigins)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.serveWebsocket(upgrader, w, r, nil)
+	})
+}
+
+func newWebsocketUpgrader(allowedOrigins []string) *websocket.Upgrader {
+	return &websocket.Upgrader{
 		ReadBufferSize:  wsReadBuffer,
 		WriteBufferSize: wsWriteBuffer,
 		WriteBufferPool: wsBufferPool,
 		CheckOrigin:     wsHandshakeValidator(allowedOrigins),
 	}
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		conn, err := upgrader.Upgrade(w, r, nil)
-		if err != nil {
-			log.Debug("WebSocket upgrade failed", "err", err)
-			return
-		}
-		codec := newWebsocketCodec(conn)
-		s.ServeCodec(codec, 0)
-	})
+}
+
+// serveWebsocket upgrades the request and serves JSON-RPC on the connection,
+// restricted to the methods allowed by the session token if not nil.
+func (s *Server) serveWebsocket(upgrader *websocket.Upgrader, w http.ResponseWriter, r *http.Request, session *jwtSession) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Debug("WebSocket upgrade failed", "err", err)
+		return
+	}
+	codec := newWebsocketCodec(conn)
+	var access *jwtAccess
+	if session != nil {
+		access = session.access
+		go session.watch(codec)
+	}
+	s.serveCodec(codec, s.limits.client(r.RemoteAddr, r.Header), access)
 }
 
 // NewWSServer creates a new websocket RPC server around an API provider.
@@ -190,11 +205,33 @@ func parseOriginURL(origin string) (string, string, string, error) {
 // DialWebsocketWithDialer creates a new RPC client that communicates with a JSON-RPC server
 // that is listening on the given endpoint using the provided dialer.
 func DialWebsocketWithDialer(ctx context.Context, endpoint, origin string, dialer websocket.Dialer) (*Client, error) {
+	return dialWebsocket(ctx, endpoint, origin, dialer, nil)
+}
+
+// DialWebsocketWithAuth creates a new RPC client that communicates with an
+// authenticated JSON-RPC server that is listening on the given endpoint. The
+// credentials are added to the handshake of each connection.
+func DialWebsocketWithAuth(ctx context.Context, endpoint, origin string, auth HTTPAuth) (*Client, error) {
+	dialer := websocket.Dialer{
+		ReadBufferSize:  wsReadBuffer,
+		WriteBufferSize: wsWriteBuffer,
+		WriteBufferPool: wsBufferPool,
+	}
+	return dialWebsocket(ctx, endpoint, origin, dialer, auth)
+}
+
+func dialWebsocket(ctx context.Context, endpoint, origin string, dialer websocket.Dialer, auth HTTPAuth) (*Client, error) {
 	endpoint, header, err := wsClientHeaders(endpoint, origin)
 	if err != nil {
 		return nil, err
 	}
 	return newClient(ctx, func(ctx context.Context) (ServerCodec, error) {
+		header := header.Clone()
+		if auth != nil {
+			if err := auth(header); err != nil {
+				return nil, err
+			}
+		}
 		conn, resp, err := dialer.DialContext(ctx, endpoint, header)
 		if err != nil {
 			hErr := wsHandshakeError{err: err}
@@ -0,0 +1,296 @@
+// Copyright (c) 2018 XDPoSChain
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package tests
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/XinFinOrg/XDPoSChain/common"
+	"github.com/XinFinOrg/XDPoSChain/core"
+	"github.com/XinFinOrg/XDPoSChain/core/rawdb"
+	"github.com/XinFinOrg/XDPoSChain/core/state"
+	"github.com/XinFinOrg/XDPoSChain/core/types"
+	"github.com/XinFinOrg/XDPoSChain/core/vm"
+	"github.com/XinFinOrg/XDPoSChain/crypto"
+	"github.com/XinFinOrg/XDPoSChain/params"
+)
+
+var (
+	cancunSender      = common.HexToAddress("0x00000000000000000000000000000000000000a0")
+	cancunBeneficiary = common.HexToAddress("0x00000000000000000000000000000000000000b0")
+
+	// transientContract reads the slot 1 of its transient storage plus one
+	// into the storage slot 1, then writes 0x2a to the transient slot 1 and
+	// reads it back into the storage slot 0.
+	transientContract = common.HexToAddress("0x00000000000000000000000000000000000000c1")
+	transientCode     = []byte{
+		byte(vm.PUSH1), 1, byte(vm.TLOAD), byte(vm.PUSH1), 1, byte(vm.ADD), byte(vm.PUSH1), 1, byte(vm.SSTORE),
+		byte(vm.PUSH1), 0x2a, byte(vm.PUSH1), 1, byte(vm.TSTORE),
+		byte(vm.PUSH1), 1, byte(vm.TLOAD), byte(vm.PUSH1), 0, byte(vm.SSTORE),
+		byte(vm.STOP),
+	}
+	// revertContract calls itself to write 0x2a to the transient slot 1 and
+	// revert, then stores the transient slot 1 plus one into the storage slot 0.
+	revertContract = common.HexToAddress("0x00000000000000000000000000000000000000c2")
+	revertCode     = []byte{
+		byte(vm.CALLER), byte(vm.ADDRESS), byte(vm.EQ), byte(vm.PUSH1), 0x1e, byte(vm.JUMPI),
+		byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0,
+		byte(vm.ADDRESS), byte(vm.GAS), byte(vm.CALL), byte(vm.POP),
+		byte(vm.PUSH1), 1, byte(vm.TLOAD), byte(vm.PUSH1), 1, byte(vm.ADD), byte(vm.PUSH1), 0, byte(vm.SSTORE),
+		byte(vm.STOP),
+		byte(vm.JUMPDEST), // 0x1e
+		byte(vm.PUSH1), 0x2a, byte(vm.PUSH1), 1, byte(vm.TSTORE),
+		byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.REVERT),
+	}
+	// staticContract writes to its transient storage through a STATICCALL
+	// to itself and stores the success of the call into the storage slot 0.
+	staticContract = common.HexToAddress("0x00000000000000000000000000000000000000c3")
+	staticCode     = []byte{
+		byte(vm.CALLER), byte(vm.ADDRESS), byte(vm.EQ), byte(vm.PUSH1), 0x15, byte(vm.JUMPI),
+		byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0,
+		byte(vm.ADDRESS), byte(vm.GAS), byte(vm.STATICCALL), byte(vm.PUSH1), 0, byte(vm.SSTORE),
+		byte(vm.STOP),
+		byte(vm.JUMPDEST), // 0x15
+		byte(vm.PUSH1), 1, byte(vm.PUSH1), 1, byte(vm.TSTORE),
+		byte(vm.STOP),
+	}
+	// mcopyContract copies the memory word at 0x20 to 0 and stores it into
+	// the storage slot 0.
+	mcopyContract = common.HexToAddress("0x00000000000000000000000000000000000000c4")
+	mcopyCode     = []byte{
+		byte(vm.PUSH1), 0x2a, byte(vm.PUSH1), 0x20, byte(vm.MSTORE),
+		byte(vm.PUSH1), 0x20, byte(vm.PUSH1), 0x20, byte(vm.PUSH1), 0, byte(vm.MCOPY),
+		byte(vm.PUSH1), 0, byte(vm.MLOAD), byte(vm.PUSH1), 0, byte(vm.SSTORE),
+		byte(vm.STOP),
+	}
+	// selfdestructContract sends its balance to the beneficiary and destructs.
+	selfdestructContract = common.HexToAddress("0x00000000000000000000000000000000000000c5")
+	selfdestructCode     = append(append([]byte{byte(vm.PUSH20)}, cancunBeneficiary.Bytes()...), byte(vm.SELFDESTRUCT))
+	// selfdestructDeployCode stores 1 into the storage slot 0 and deploys
+	// selfdestructCode.
+	selfdestructDeployCode = append([]byte{
+		byte(vm.PUSH1), 1, byte(vm.PUSH1), 0, byte(vm.SSTORE),
+		byte(vm.PUSH1), byte(len(selfdestructCode)), byte(vm.PUSH1), 17, byte(vm.PUSH1), 0, byte(vm.CODECOPY),
+		byte(vm.PUSH1), byte(len(selfdestructCode)), byte(vm.PUSH1), 0, byte(vm.RETURN),
+	}, selfdestructCode...)
+	// create2Contract runs selfdestructCode as init code with CREATE2 and the
+	// salt 0, forwarding the call value.
+	create2Contract = common.HexToAddress("0x00000000000000000000000000000000000000c6")
+	create2Code     = append([]byte{
+		byte(vm.PUSH1), byte(len(selfdestructCode)), byte(vm.PUSH1), 19, byte(vm.PUSH1), 0, byte(vm.CODECOPY),
+		byte(vm.PUSH1), 0, byte(vm.PUSH1), byte(len(selfdestructCode)), byte(vm.PUSH1), 0, byte(vm.CALLVALUE), byte(vm.CREATE2),
+		byte(vm.PUSH1), 0, byte(vm.SSTORE),
+		byte(vm.STOP),
+	}, selfdestructCode...)
+)
+
+// cancunState applies messages on top of the Cancun test accounts, one
+// transaction per message.
+type cancunState struct {
+	t       *testing.T
+	config  *params.ChainConfig
+	statedb *state.StateDB
+	txs     int
+	refund  uint64 // gas refund of the last transaction
+}
+
+func newCancunState(t *testing.T, config *params.ChainConfig) *cancunState {
+	balance := new(big.Int).Mul(big.NewInt(1000), big.NewInt(params.Ether))
+	statedb := MakePreState(rawdb.NewMemoryDatabase(), core.GenesisAlloc{
+		cancunSender:         {Balance: balance},
+		transientContract:    {Code: transientCode, Balance: new(big.Int)},
+		revertContract:       {Code: revertCode, Balance: new(big.Int)},
+		staticContract:       {Code: staticCode, Balance: new(big.Int), Storage: map[common.Hash]common.Hash{{}: common.BigToHash(big.NewInt(0xff))}},
+		mcopyContract:        {Code: mcopyCode, Balance: new(big.Int)},
+		selfdestructContract: {Code: selfdestructCode, Balance: big.NewInt(100)},
+		create2Contract:      {Code: create2Code, Balance: new(big.Int)},
+	})
+	return &cancunState{t: t, config: config, statedb: statedb}
+}
+
+// apply executes a message from the sender as a new transaction and returns
+// the error of the EVM.
+func (s *cancunState) apply(to *common.Address, value int64, data []byte) error {
+	s.statedb.Prepare(common.BigToHash(big.NewInt(int64(s.txs))), s.txs)
+	s.txs++
+
+	// static calls are only read-only since TIPXDCXCancellationFee
+	header := &types.Header{Number: new(big.Int).Set(common.TIPXDCXCancellationFee), Time: big.NewInt(0), Difficulty: new(big.Int), GasLimit: 10000000}
+	msg := types.NewMessage(cancunSender, to, s.statedb.GetNonce(cancunSender), big.NewInt(value), 1000000, new(big.Int), data, nil, false, nil, header.Number)
+	context := core.NewEVMBlockContext(header, nil, &common.Address{})
+	evm := vm.NewEVM(context, core.NewEVMTxContext(msg), s.statedb, nil, s.config, vm.Config{})
+
+	_, _, _, err, vmErr := core.ApplyMessage(evm, msg, new(core.GasPool).AddGas(header.GasLimit), common.Address{})
+	if err != nil {
+		s.t.Fatalf("transaction %d failed: %v", s.txs, err)
+	}
+	s.refund = s.statedb.GetRefund()
+	s.statedb.Finalise(true)
+	return vmErr
+}
+
+func (s *cancunState) storage(addr common.Address, slot int64) int64 {
+	return s.statedb.GetState(addr, common.BigToHash(big.NewInt(slot))).Big().Int64()
+}
+
+func TestCancunTransientStorage(t *testing.T) {
+	s := newCancunState(t, Forks["Cancun"])
+
+	for i := 0; i < 2; i++ {
+		if err := s.apply(&transientContract, 0, nil); err != nil {
+			t.Fatalf("transaction %d failed: %v", i, err)
+		}
+		// the transient storage is cleared at the end of the transaction
+		if have := s.storage(transientContract, 1); have != 1 {
+			t.Errorf("transaction %d: transient storage not cleared: have %#x, want 0x1", i, have)
+		}
+		if have := s.storage(transientContract, 0); have != 0x2a {
+			t.Errorf("transaction %d: transient storage mismatch: have %#x, want 0x2a", i, have)
+		}
+	}
+	// the transient storage is reverted with the call
+	if err := s.apply(&revertContract, 0, nil); err != nil {
+		t.Fatalf("revert transaction failed: %v", err)
+	}
+	if have := s.storage(revertContract, 0); have != 1 {
+		t.Errorf("transient storage not reverted: have %#x, want 0x1", have)
+	}
+	// the transient storage can't be written in a static call
+	if err := s.apply(&staticContract, 0, nil); err != nil {
+		t.Fatalf("static transaction failed: %v", err)
+	}
+	if have := s.storage(staticContract, 0); have != 0 {
+		t.Errorf("transient storage written in a static call: %#x", have)
+	}
+}
+
+func TestCancunMCopy(t *testing.T) {
+	s := newCancunState(t, Forks["Cancun"])
+
+	if err := s.apply(&mcopyContract, 0, nil); err != nil {
+		t.Fatalf("transaction failed: %v", err)
+	}
+	if have := s.storage(mcopyContract, 0); have != 0x2a {
+		t.Errorf("copied memory mismatch: have %#x, want 0x2a", have)
+	}
+}
+
+func TestCancunSelfdestruct(t *testing.T) {
+	s := newCancunState(t, Forks["Cancun"])
+
+	// an existing contract only sends its balance
+	if err := s.apply(&selfdestructContract, 0, nil); err != nil {
+		t.Fatalf("transaction failed: %v", err)
+	}
+	if !s.statedb.Exist(selfdestructContract) || len(s.statedb.GetCode(selfdestructContract)) == 0 {
+		t.Errorf("existing contract destructed")
+	}
+	if have := s.statedb.GetBalance(selfdestructContract); have.Sign() != 0 {
+		t.Errorf("contract balance mismatch: have %v, want 0", have)
+	}
+	if have := s.statedb.GetBalance(cancunBeneficiary); have.Cmp(big.NewInt(100)) != 0 {
+		t.Errorf("beneficiary balance mismatch: have %v, want 100", have)
+	}
+	// a contract created in the same transaction is destructed
+	created := crypto.CreateAddress(cancunSender, s.statedb.GetNonce(cancunSender))
+	if err := s.apply(nil, 5, selfdestructCode); err != nil {
+		t.Fatalf("creation failed: %v", err)
+	}
+	if s.statedb.Exist(created) {
+		t.Errorf("contract created in the transaction not destructed")
+	}
+	if have := s.statedb.GetBalance(cancunBeneficiary); have.Cmp(big.NewInt(105)) != 0 {
+		t.Errorf("beneficiary balance mismatch: have %v, want 105", have)
+	}
+}
+
+func TestCancunSelfdestructSameBlock(t *testing.T) {
+	s := newCancunState(t, Forks["Cancun"])
+
+	// a contract deployed by a previous transaction of the block only sends
+	// its balance
+	contract := crypto.CreateAddress(cancunSender, s.statedb.GetNonce(cancunSender))
+	if err := s.apply(nil, 0, selfdestructDeployCode); err != nil {
+		t.Fatalf("deployment failed: %v", err)
+	}
+	if err := s.apply(&contract, 7, nil); err != nil {
+		t.Fatalf("transaction failed: %v", err)
+	}
+	if !s.statedb.Exist(contract) || len(s.statedb.GetCode(contract)) == 0 {
+		t.Errorf("contract of a previous transaction destructed")
+	}
+	if have := s.storage(contract, 0); have != 1 {
+		t.Errorf("contract storage mismatch: have %#x, want 0x1", have)
+	}
+	if have := s.statedb.GetBalance(contract); have.Sign() != 0 {
+		t.Errorf("contract balance mismatch: have %v, want 0", have)
+	}
+	if have := s.statedb.GetBalance(cancunBeneficiary); have.Cmp(big.NewInt(7)) != 0 {
+		t.Errorf("beneficiary balance mismatch: have %v, want 7", have)
+	}
+	if s.refund != 0 {
+		t.Errorf("refund granted for a kept contract: %d", s.refund)
+	}
+}
+
+func TestCancunSelfdestructCreate2(t *testing.T) {
+	s := newCancunState(t, Forks["Cancun"])
+
+	// the address is funded by a plain transfer first
+	created := crypto.CreateAddress2(create2Contract, [32]byte{}, crypto.Keccak256(selfdestructCode))
+	if err := s.apply(&created, 3, nil); err != nil {
+		t.Fatalf("transfer failed: %v", err)
+	}
+	if s.statedb.IsNewContract(created) {
+		t.Errorf("account funded by a transfer marked as a new contract")
+	}
+	// the contract deployed there with CREATE2 is destructed in the same
+	// transaction
+	if err := s.apply(&create2Contract, 5, nil); err != nil {
+		t.Fatalf("transaction failed: %v", err)
+	}
+	if have := common.BigToAddress(s.statedb.GetState(create2Contract, common.Hash{}).Big()); have != created {
+		t.Fatalf("created address mismatch: have %x, want %x", have, created)
+	}
+	if s.statedb.Exist(created) {
+		t.Errorf("contract created in the transaction not destructed")
+	}
+	if have := s.statedb.GetBalance(cancunBeneficiary); have.Cmp(big.NewInt(8)) != 0 {
+		t.Errorf("beneficiary balance mismatch: have %v, want 8", have)
+	}
+	if s.refund != params.SelfdestructRefundGas {
+		t.Errorf("refund mismatch: have %d, want %d", s.refund, params.SelfdestructRefundGas)
+	}
+}
+
+func TestPreCancunOpcodes(t *testing.T) {
+	config := *Forks["Cancun"]
+	config.CancunBlock = nil
+	s := newCancunState(t, &config)
+
+	for _, contract := range []common.Address{transientContract, mcopyContract} {
+		if err := s.apply(&contract, 0, nil); err == nil {
+			t.Errorf("contract %x executed before Cancun", contract)
+		}
+	}
+	if err := s.apply(&selfdestructContract, 0, nil); err != nil {
+		t.Fatalf("transaction failed: %v", err)
+	}
+	if s.statedb.Exist(selfdestructContract) {
+		t.Errorf("contract not destructed before Cancun")
+	}
+}
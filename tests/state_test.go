@@ -19,6 +19,7 @@ package tests
 import (
 	"bytes"
 	"fmt"
+	"path/filepath"
 	"reflect"
 	"testing"
 
@@ -34,6 +35,7 @@ func TestState(t *testing.T) {
 	// Broken tests:
 	st.skipLoad(`^stTransactionTest/OverflowGasRequire\.json`) // gasLimit > 256 bits
 	st.skipLoad(`^stTransactionTest/zeroSigTransa[^/]*\.json`) // EIP-86 is not supported yet
+	st.skipLoad(`^Cancun/`)                                    // run by TestStateCancun
 	// Expected failures:
 	st.fails(`^stRevertTest/RevertPrecompiledTouch\.json/EIP158`, "bug in test")
 	st.fails(`^stRevertTest/RevertPrefoundEmptyOOG\.json/EIP158`, "bug in test")
@@ -63,6 +65,42 @@ func TestState(t *testing.T) {
 	})
 }
 
+// TestStateCancun runs the state tests of the Cancun opcodes: transient storage
+// (EIP-1153), MCOPY (EIP-5656) and the SELFDESTRUCT restriction (EIP-6780).
+// Only the Cancun post states are checked, the tests of the older forks target
+// rules XDPoSChain doesn't follow. The test is skipped without the tests
+// submodule, the opcodes are also covered by the tests in cancun_test.go.
+func TestStateCancun(t *testing.T) {
+	t.Parallel()
+
+	st := new(testMatcher)
+	st.skipLoad(`^stEIP4844`) // blob transactions are not supported
+
+	var ran int
+	defer func() {
+		if !t.Skipped() {
+			t.Logf("ran %d Cancun state tests", ran)
+		}
+	}()
+	st.walk(t, filepath.Join(stateTestDir, "Cancun"), func(t *testing.T, name string, test *StateTest) {
+		for _, subtest := range test.Subtests() {
+			if subtest.Fork != "Cancun" {
+				continue
+			}
+			subtest := subtest
+			key := fmt.Sprintf("%s/%d", subtest.Fork, subtest.Index)
+			name := name + "/" + key
+			t.Run(key, func(t *testing.T) {
+				ran++
+				withTrace(t, test.gasLimit(subtest), func(vmconfig vm.Config) error {
+					_, err := test.Run(subtest, vmconfig)
+					return st.checkFailure(t, name, err)
+				})
+			})
+		}
+	})
+}
+
 // Transactions with gasLimit above this value will not get a VM trace on failure.
 const traceErrorLimit = 400000
 
@@ -19,6 +19,7 @@ package tests
 import (
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math/big"
 	"strings"
@@ -63,9 +64,10 @@ type stJSON struct {
 }
 
 type stPostState struct {
-	Root    common.UnprefixedHash `json:"hash"`
-	Logs    common.UnprefixedHash `json:"logs"`
-	Indexes struct {
+	Root            common.UnprefixedHash `json:"hash"`
+	Logs            common.UnprefixedHash `json:"logs"`
+	ExpectException string                `json:"expectException"`
+	Indexes         struct {
 		Data  int `json:"data"`
 		Gas   int `json:"gas"`
 		Value int `json:"value"`
@@ -77,6 +79,8 @@ type stPostState struct {
 type stEnv struct {
 	Coinbase   common.Address `json:"currentCoinbase"   gencodec:"required"`
 	Difficulty *big.Int       `json:"currentDifficulty" gencodec:"required"`
+	Random     *common.Hash   `json:"currentRandom"     gencodec:"optional"`
+	BaseFee    *big.Int       `json:"currentBaseFee"    gencodec:"optional"`
 	GasLimit   uint64         `json:"currentGasLimit"   gencodec:"required"`
 	Number     uint64         `json:"currentNumber"     gencodec:"required"`
 	Timestamp  uint64         `json:"currentTimestamp"  gencodec:"required"`
@@ -85,6 +89,7 @@ type stEnv struct {
 type stEnvMarshaling struct {
 	Coinbase   common.UnprefixedAddress
 	Difficulty *math.HexOrDecimal256
+	BaseFee    *math.HexOrDecimal256
 	GasLimit   math.HexOrDecimal64
 	Number     math.HexOrDecimal64
 	Timestamp  math.HexOrDecimal64
@@ -93,20 +98,25 @@ type stEnvMarshaling struct {
 //go:generate gencodec -type stTransaction -field-override stTransactionMarshaling -out gen_sttransaction.go
 
 type stTransaction struct {
-	GasPrice   *big.Int `json:"gasPrice"`
-	Nonce      uint64   `json:"nonce"`
-	To         string   `json:"to"`
-	Data       []string `json:"data"`
-	GasLimit   []uint64 `json:"gasLimit"`
-	Value      []string `json:"value"`
-	PrivateKey []byte   `json:"secretKey"`
+	GasPrice             *big.Int            `json:"gasPrice"`
+	MaxFeePerGas         *big.Int            `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas *big.Int            `json:"maxPriorityFeePerGas"`
+	Nonce                uint64              `json:"nonce"`
+	To                   string              `json:"to"`
+	Data                 []string            `json:"data"`
+	AccessLists          []*types.AccessList `json:"accessLists,omitempty"`
+	GasLimit             []uint64            `json:"gasLimit"`
+	Value                []string            `json:"value"`
+	PrivateKey           []byte              `json:"secretKey"`
 }
 
 type stTransactionMarshaling struct {
-	GasPrice   *math.HexOrDecimal256
-	Nonce      math.HexOrDecimal64
-	GasLimit   []math.HexOrDecimal64
-	PrivateKey hexutil.Bytes
+	GasPrice             *math.HexOrDecimal256
+	MaxFeePerGas         *math.HexOrDecimal256
+	MaxPriorityFeePerGas *math.HexOrDecimal256
+	Nonce                math.HexOrDecimal64
+	GasLimit             []math.HexOrDecimal64
+	PrivateKey           hexutil.Bytes
 }
 
 // Subtests returns all valid subtests of the test.
@@ -131,25 +141,36 @@ func (t *StateTest) Run(subtest StateSubtest, vmconfig vm.Config) (*state.StateD
 	statedb := MakePreState(db, t.json.Pre)
 
 	post := t.json.Post[subtest.Fork][subtest.Index]
-	msg, err := t.json.Tx.toMessage(post, block.Number())
+	msg, err := t.json.Tx.toMessage(post, block.Number(), t.json.Env.BaseFee)
 	if err != nil {
-		return nil, err
+		if post.ExpectException == "" {
+			return nil, err
+		}
 	}
+	// An invalid transaction leaves the pre-state untouched
+	if msg != nil {
+		// Prepare the EVM.
+		txContext := core.NewEVMTxContext(msg)
+		context := core.NewEVMBlockContext(block.Header(), nil, &t.json.Env.Coinbase)
+		context.GetHash = vmTestBlockHash
+		if t.json.Env.Random != nil {
+			context.Random = t.json.Env.Random
+		}
+		evm := vm.NewEVM(context, txContext, statedb, nil, config, vmconfig)
 
-	// Prepare the EVM.
-	txContext := core.NewEVMTxContext(msg)
-	context := core.NewEVMBlockContext(block.Header(), nil, &t.json.Env.Coinbase)
-	context.GetHash = vmTestBlockHash
-	evm := vm.NewEVM(context, txContext, statedb, nil, config, vmconfig)
-
-	// Execute the message.
-	snapshot := statedb.Snapshot()
-	gaspool := new(core.GasPool)
-	gaspool.AddGas(block.GasLimit())
+		// Execute the message.
+		snapshot := statedb.Snapshot()
+		gaspool := new(core.GasPool)
+		gaspool.AddGas(block.GasLimit())
 
-	coinbase := &t.json.Env.Coinbase
-	if _, _, _, err, _ := core.ApplyMessage(evm, msg, gaspool, *coinbase); err != nil {
-		statedb.RevertToSnapshot(snapshot)
+		coinbase := &t.json.Env.Coinbase
+		if _, gas, _, err, _ := core.ApplyMessage(evm, msg, gaspool, *coinbase); err != nil {
+			statedb.RevertToSnapshot(snapshot)
+		} else if baseFee := t.json.Env.BaseFee; baseFee != nil && config.IsEIP1559(block.Number()) {
+			// The whole gas price is paid to the coinbase, while the tests
+			// of the forks charging a base fee expect it to be burnt
+			statedb.SubBalance(*coinbase, new(big.Int).Mul(new(big.Int).SetUint64(gas), baseFee))
+		}
 	}
 	if logs := rlpHash(statedb.Logs()); logs != common.Hash(post.Logs) {
 		return statedb, fmt.Errorf("post state logs hash mismatch: got %x, want %x", logs, post.Logs)
@@ -196,7 +217,7 @@ func (t *StateTest) genesis(config *params.ChainConfig) *core.Genesis {
 	}
 }
 
-func (tx *stTransaction) toMessage(ps stPostState, number *big.Int) (core.Message, error) {
+func (tx *stTransaction) toMessage(ps stPostState, number *big.Int, baseFee *big.Int) (core.Message, error) {
 	// Derive sender from private key if present.
 	var from common.Address
 	if len(tx.PrivateKey) > 0 {
@@ -241,7 +262,26 @@ func (tx *stTransaction) toMessage(ps stPostState, number *big.Int) (core.Messag
 	if err != nil {
 		return nil, fmt.Errorf("invalid tx data %q", dataHex)
 	}
-	msg := types.NewMessage(from, to, tx.Nonce, value, gasLimit, tx.GasPrice, data, nil, true, nil, number)
+	var accessList types.AccessList
+	if tx.AccessLists != nil && ps.Indexes.Data < len(tx.AccessLists) && tx.AccessLists[ps.Indexes.Data] != nil {
+		accessList = *tx.AccessLists[ps.Indexes.Data]
+	}
+	// Dynamic fee transactions pay the base fee and their tip, up to their cap.
+	gasPrice := tx.GasPrice
+	if tx.MaxFeePerGas != nil {
+		if baseFee == nil {
+			return nil, errors.New("dynamic fee transaction without base fee")
+		}
+		if tx.MaxFeePerGas.Cmp(baseFee) < 0 {
+			return nil, fmt.Errorf("max fee per gas %v below base fee %v", tx.MaxFeePerGas, baseFee)
+		}
+		tip := new(big.Int)
+		if tx.MaxPriorityFeePerGas != nil {
+			tip.Set(tx.MaxPriorityFeePerGas)
+		}
+		gasPrice = math.BigMin(tip.Add(tip, baseFee), tx.MaxFeePerGas)
+	}
+	msg := types.NewMessage(from, to, tx.Nonce, value, gasLimit, gasPrice, data, accessList, true, nil, number)
 	return msg, nil
 }
 